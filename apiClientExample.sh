@@ -73,6 +73,10 @@ apiCmd "address" "UserPubKeyAddr=$pubKeyAddr"
 
 apiCmd "getpurchaseinfo"
 
+apiCmd "tickets"
+
 apiCmd "stats"
 
+apiCmd "estimate"
+
 #cleanUp
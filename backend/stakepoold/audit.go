@@ -0,0 +1,126 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jrick/logrotate/rotator"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// auditParamsMax is the maximum length of the request parameters recorded for
+// each audited request.  Longer parameters are truncated.
+const auditParamsMax = 512
+
+// grpcAudit records every gRPC request when the auditlog option is set.  It is
+// nil otherwise.
+var grpcAudit *auditLog
+
+// auditRecord is a single line of the audit log.
+type auditRecord struct {
+	Time     string `json:"time"`
+	Method   string `json:"method"`
+	Peer     string `json:"peer"`
+	Cert     string `json:"cert"`
+	Params   string `json:"params"`
+	Code     string `json:"code"`
+	Duration string `json:"duration"`
+}
+
+// auditLog writes one JSON encoded auditRecord per line for each gRPC request
+// so that it can be reconstructed which frontend triggered which wallet
+// mutation.
+type auditLog struct {
+	mtx sync.Mutex
+	w   io.WriteCloser
+}
+
+// newAuditLog returns an auditLog writing to logFile, which is rotated in the
+// same way as the main log file.
+func newAuditLog(logFile string) (*auditLog, error) {
+	logDir, _ := filepath.Split(logFile)
+	if err := os.MkdirAll(logDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %v", err)
+	}
+	r, err := rotator.New(logFile, 10*1024, false, 3)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log rotator: %v", err)
+	}
+	return &auditLog{w: r}, nil
+}
+
+// Close closes the audit log.
+func (a *auditLog) Close() error {
+	return a.w.Close()
+}
+
+// record writes the audit record of a request to method with parameters req
+// which was received at start and finished with err.
+func (a *auditLog) record(ctx context.Context, method string, req interface{},
+	err error, start time.Time) {
+	addr, cert := peerIdentity(ctx)
+	b, jsonErr := json.Marshal(&auditRecord{
+		Time:     start.UTC().Format(time.RFC3339Nano),
+		Method:   method,
+		Peer:     addr,
+		Cert:     cert,
+		Params:   summarizeParams(req),
+		Code:     status.Code(err).String(),
+		Duration: time.Since(start).String(),
+	})
+	if jsonErr != nil {
+		grpcLog.Errorf("unable to encode audit record for %s: %v", method,
+			jsonErr)
+		return
+	}
+	b = append(b, '\n')
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if _, err := a.w.Write(b); err != nil {
+		grpcLog.Errorf("unable to write audit record for %s: %v", method, err)
+	}
+}
+
+// peerIdentity returns the network address of the caller and the identity of
+// the TLS certificate it presented, if any.
+func peerIdentity(ctx context.Context) (string, string) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown", ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return p.Addr.String(), ""
+	}
+	c := tlsInfo.State.PeerCertificates[0]
+	return p.Addr.String(), fmt.Sprintf("%s sha256:%x", c.Subject.CommonName,
+		sha256.Sum256(c.Raw))
+}
+
+// summarizeParams returns the JSON encoding of the request parameters,
+// truncated to auditParamsMax bytes.  The encoding is deterministic, so
+// identical requests always produce identical summaries.
+func summarizeParams(req interface{}) string {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Sprintf("unencodable %T: %v", req, err)
+	}
+	if len(b) > auditParamsMax {
+		return fmt.Sprintf("%s... (%d bytes)", b[:auditParamsMax], len(b))
+	}
+	return string(b)
+}
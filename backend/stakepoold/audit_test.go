@@ -0,0 +1,75 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type nopWriteCloser struct {
+	bytes.Buffer
+}
+
+func (*nopWriteCloser) Close() error { return nil }
+
+func TestAuditLogRecord(t *testing.T) {
+	w := new(nopWriteCloser)
+	a := &auditLog{w: w}
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 45678},
+	})
+	req := &pb.ImportNewScriptRequest{Script: []byte{0x51}}
+	a.record(ctx, "/stakepoolrpc.StakepooldService/ImportNewScript", req,
+		nil, time.Now())
+	a.record(ctx, "/stakepoolrpc.StakepooldService/ImportNewScript", req,
+		status.Error(codes.Unavailable, "wallet down"), time.Now())
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d audit records, want 2", len(lines))
+	}
+	for i, wantCode := range []string{"OK", "Unavailable"} {
+		var rec auditRecord
+		if err := json.Unmarshal([]byte(lines[i]), &rec); err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if rec.Method != "/stakepoolrpc.StakepooldService/ImportNewScript" {
+			t.Errorf("record %d: method %q", i, rec.Method)
+		}
+		if rec.Peer != "127.0.0.1:45678" {
+			t.Errorf("record %d: peer %q", i, rec.Peer)
+		}
+		if rec.Params != summarizeParams(req) {
+			t.Errorf("record %d: params %q", i, rec.Params)
+		}
+		if rec.Code != wantCode {
+			t.Errorf("record %d: code %q, want %q", i, rec.Code, wantCode)
+		}
+	}
+}
+
+func TestSummarizeParams(t *testing.T) {
+	req := &pb.ImportNewScriptRequest{Script: bytes.Repeat([]byte{0x51}, 1000)}
+	summary := summarizeParams(req)
+	if summary != summarizeParams(req) {
+		t.Fatal("summary is not deterministic")
+	}
+	if !strings.HasSuffix(summary, " bytes)") ||
+		len(summary) > auditParamsMax+len("... (0000 bytes)") {
+		t.Fatalf("summary not truncated: %q", summary)
+	}
+}
@@ -14,19 +14,41 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrstakepool/backend/stakepoold/stakepool"
+	"github.com/decred/dcrstakepool/internal/netparams"
+	"github.com/decred/dcrstakepool/internal/notify"
+	"github.com/decred/dcrstakepool/internal/secrets"
 	"github.com/decred/dcrstakepool/internal/version"
 	flags "github.com/jessevdk/go-flags"
 )
 
 const (
-	defaultConfigFilename = "stakepoold.conf"
-	defaultDataDirname    = "data"
-	defaultLogLevel       = "info"
-	defaultLogDirname     = "logs"
-	defaultLogFilename    = "stakepoold.log"
-	defaultPoolFees       = 5
+	defaultConfigFilename   = "stakepoold.conf"
+	defaultDataDirname      = "data"
+	defaultLogLevel         = "info"
+	defaultLogDirname       = "logs"
+	defaultLogFilename      = "stakepoold.log"
+	defaultAuditLogFilename = "audit.log"
+	defaultPoolFees         = 5
+	defaultFeeMode          = stakepool.FeeModeCommitment
+	defaultReconnectAlert   = time.Minute * 5
+	defaultVoteErrorAlert   = 1
+	defaultWinningNtfnAlert = 6
+	defaultDBPingInterval   = time.Minute
+	defaultUserDataStale    = time.Minute * 30
+
+	defaultGRPCKeepalive        = time.Minute
+	defaultGRPCKeepaliveTimeout = time.Second * 20
+	defaultGRPCMaxMessageSize   = 64 << 20
+	defaultVoteSignerTimeout    = time.Second * 5
+
+	// minGRPCMaxMessageSize and maxGRPCMaxMessageSize bound the maximum size
+	// of gRPC messages.  The minimum is the default of gRPC.
+	minGRPCMaxMessageSize = 4 << 20
+	maxGRPCMaxMessageSize = 1 << 30
 )
 
 var (
@@ -42,6 +64,10 @@ var (
 	defaultDBUser = "stakepool"
 )
 
+// activeNetParams is a pointer to the parameters specific to the
+// currently active decred network.
+var activeNetParams = &netparams.MainNet
+
 // runServiceCommand is only set to a real function on Windows.  It is used
 // to parse and execute service commands specified via the -s flag.
 var runServiceCommand func(string) error
@@ -50,33 +76,85 @@ var runServiceCommand func(string) error
 //
 // See loadConfig for details on the configuration load process.
 type config struct {
-	HomeDir          string   `short:"A" long:"appdata" description:"Path to application home directory"`
-	ShowVersion      bool     `short:"V" long:"version" description:"Display version information and exit"`
-	ConfigFile       string   `short:"C" long:"configfile" description:"Path to configuration file"`
-	DataDir          string   `short:"b" long:"datadir" description:"Directory to store data"`
-	LogDir           string   `long:"logdir" description:"Directory to log output."`
-	TestNet          bool     `long:"testnet" description:"Use the test network"`
-	SimNet           bool     `long:"simnet" description:"Use the simulation test network"`
-	DebugLevel       string   `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
-	ColdWalletExtPub string   `long:"coldwalletextpub" description:"The extended public key for addresses to which voting service user fees are sent."`
-	PoolFees         float64  `long:"poolfees" description:"The per-ticket fees the user must send to the voting service with their tickets"`
-	DBHost           string   `long:"dbhost" description:"Hostname for database connection"`
-	DBUser           string   `long:"dbuser" description:"Username for database connection"`
-	DBPassword       string   `long:"dbpassword" description:"Password for database connection"`
-	DBPort           string   `long:"dbport" description:"Port for database connection"`
-	DBName           string   `long:"dbname" description:"Name of database"`
-	DcrdHost         string   `long:"dcrdhost" description:"Hostname/IP for dcrd server"`
-	DcrdUser         string   `long:"dcrduser" description:"Username for dcrd server"`
-	DcrdPassword     string   `long:"dcrdpassword" description:"Password for dcrd server"`
-	DcrdCert         string   `long:"dcrdcert" description:"Certificate path for dcrd server"`
-	WalletHost       string   `long:"wallethost" description:"Hostname for wallet server"`
-	WalletUser       string   `long:"walletuser" description:"Username for wallet server"`
-	WalletPassword   string   `long:"walletpassword" description:"Password for wallet server"`
-	WalletCert       string   `long:"walletcert" description:"Certificate path for wallet server"`
-	NoRPCListen      bool     `long:"norpclisten" description:"Do not start a gRPC server. User voting preferences update on a ticker"`
-	RPCListeners     []string `long:"rpclisten" description:"Add an interface/port to listen for RPC connections (default port: 9113, testnet: 19113)"`
-	RPCCert          string   `long:"rpccert" description:"File containing the certificate file"`
-	RPCKey           string   `long:"rpckey" description:"File containing the certificate key"`
+	HomeDir          string        `short:"A" long:"appdata" description:"Path to application home directory"`
+	ShowVersion      bool          `short:"V" long:"version" description:"Display version information and exit"`
+	ConfigFile       string        `short:"C" long:"configfile" description:"Path to configuration file"`
+	ValidateConfig   bool          `long:"validateconfig" description:"Check the configuration, print a JSON report to stdout and exit with status 1 when a check fails"`
+	ValidateProbe    bool          `long:"validateprobe" description:"With validateconfig, also check that the database, dcrd and dcrwallet accept connections"`
+	DataDir          string        `short:"b" long:"datadir" description:"Directory to store data"`
+	LogDir           string        `long:"logdir" description:"Directory to log output."`
+	Network          string        `long:"network" description:"Network to use {mainnet, testnet, simnet} (default: mainnet)"`
+	TestNet          bool          `long:"testnet" description:"DEPRECATED: Use the test network, use network=testnet instead"`
+	SimNet           bool          `long:"simnet" description:"DEPRECATED: Use the simulation test network, use network=simnet instead"`
+	DebugLevel       string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
+	ColdWalletExtPub string        `long:"coldwalletextpub" description:"The extended public key for addresses to which voting service user fees are sent."`
+	PoolFees         float64       `long:"poolfees" description:"The per-ticket fees the user must send to the voting service with their tickets"`
+	FeeMode          string        `long:"feemode" description:"How users pay the pool fees {commitment, deferred} -- With deferred, tickets do not commit to the fee and only tickets whose separate fee transaction was paid are voted"`
+	DBHost           string        `long:"dbhost" description:"Hostname for database connection"`
+	DBUser           string        `long:"dbuser" description:"Username for database connection"`
+	DBPassword       string        `long:"dbpassword" description:"Password for database connection"`
+	DBPort           string        `long:"dbport" description:"Port for database connection"`
+	DBName           string        `long:"dbname" description:"Name of database"`
+	DcrdHost         string        `long:"dcrdhost" description:"Hostname/IP for dcrd server"`
+	DcrdUser         string        `long:"dcrduser" description:"Username for dcrd server"`
+	DcrdPassword     string        `long:"dcrdpassword" description:"Password for dcrd server"`
+	DcrdCert         string        `long:"dcrdcert" description:"Certificate path for dcrd server"`
+	WalletHost       string        `long:"wallethost" description:"Hostname for wallet server"`
+	WalletUser       string        `long:"walletuser" description:"Username for wallet server"`
+	WalletPassword   string        `long:"walletpassword" description:"Password for wallet server"`
+	WalletCert       string        `long:"walletcert" description:"Certificate path for wallet server"`
+	NoRPCListen      bool          `long:"norpclisten" description:"Do not start a gRPC server. User voting preferences update on a ticker"`
+	RPCListeners     []string      `long:"rpclisten" description:"Add an interface/port to listen for RPC connections (default port: 9113, testnet: 19113)"`
+	RPCCert          string        `long:"rpccert" description:"File containing the certificate file"`
+	RPCKey           string        `long:"rpckey" description:"File containing the certificate key"`
+	ReconnectAlert   time.Duration `long:"reconnectalert" description:"Log a critical alert when dcrd or dcrwallet has been disconnected for longer than this"`
+	AuditLog         bool          `long:"auditlog" description:"Record every gRPC request (method, caller, parameters, result code and duration) to a separate rotating audit.log in the log directory"`
+	MetricsListen    string        `long:"metricslisten" description:"Interface/port to serve Prometheus metrics on at /metrics, e.g. 127.0.0.1:9114. Disabled when empty."`
+	TicketPolicies   []string      `long:"ticketpolicy" description:"Reject tickets which fail a custom ticket acceptance policy, given as name or name:arguments -- May be specified multiple times -- Available: denyaddrs:<file of addresses>"`
+	ForcedChoice     []string      `long:"forcedchoice" description:"Choice every ticket votes with on an agenda regardless of the voting preferences of users, given as agendaid:choiceid. Set the same forced choices on dcrstakepool, which shows the agendas as locked. May be repeated."`
+
+	// Secret references
+	SecretsDir string `long:"secretsdir" description:"Directory holding one file per secret, for file:<name> references in place of sensitive option values"`
+	VaultAddr  string `long:"vaultaddr" description:"Address of the HashiCorp Vault server, for vault:<path>#<key> references in place of sensitive option values"`
+	VaultToken string `long:"vaulttoken" description:"Token used to authenticate to Vault, which may itself be an env: or file: reference (default: VAULT_TOKEN environment variable)"`
+
+	// Database schema
+	CreateMissingIndexes bool `long:"createmissingindexes" description:"Create the database indexes found missing by the schema check on startup instead of only warning about the queries they slow down"`
+
+	// Database connection health
+	DBPingInterval     time.Duration `long:"dbpinginterval" description:"Ping the database this often so that connections broken while idle are replaced before they are needed. 0 disables the pings."`
+	UserDataStaleAlert time.Duration `long:"userdatastalealert" description:"Send an alert when the user voting preferences used for voting could not be refreshed from the database for longer than this. 0 disables the alert."`
+
+	// Per-user limits
+	MaxUserLiveTickets int `long:"maxuserlivetickets" description:"Ignore new tickets of a user who already has this many live tickets, like tickets which fail the fee or ticket policy checks, so that a single user cannot take up the capacity of the voting service. Admins may still add them. 0 disables the limit."`
+
+	// Low fee ticket surge protection
+	MaxLowFeePerBlock  int  `long:"maxlowfeeperblock" description:"Log a critical alert when more than this many new tickets in a block fail the fee or ticket policy checks, which usually means coldwalletextpub or poolfees is misconfigured. 0 disables the check."`
+	PauseOnLowFeeSurge bool `long:"pauseonlowfeesurge" description:"When maxlowfeeperblock is exceeded, hold tickets which fail the checks for review instead of ignoring them until an admin resumes automatic classification"`
+
+	// gRPC connection health
+	GRPCKeepalive                    time.Duration `long:"grpckeepalive" description:"Ping gRPC clients after this much inactivity on a connection to detect connections which broke silently. 0 disables the pings."`
+	GRPCKeepaliveTimeout             time.Duration `long:"grpckeepalivetimeout" description:"Close gRPC connections when a keepalive ping is not answered within this time"`
+	GRPCKeepalivePermitWithoutStream bool          `long:"grpckeepalivepermitwithoutstream" description:"Accept keepalive pings from clients while no RPCs are in progress, as sent by dcrstakepool with stakepooldkeepalivepermitwithoutstream"`
+	GRPCMaxMessageSize               int           `long:"grpcmaxmessagesize" description:"Maximum size in bytes of the gRPC messages received and sent, e.g. the scripts and voting preferences of all users (4 MiB to 1 GiB). Advertised to dcrstakepool, which sends no larger requests."`
+
+	// Operator alerts
+	TelegramToken    string        `long:"telegramtoken" description:"Token of a Telegram bot which sends critical alerts, such as a locked wallet or failing votes, to operators"`
+	TelegramChatID   string        `long:"telegramchatid" description:"Telegram chat, group or channel the bot of telegramtoken sends alerts to"`
+	MatrixHomeServer string        `long:"matrixhomeserver" description:"Address of the homeserver of a Matrix bot account which sends critical alerts to operators, e.g. https://matrix.example.com"`
+	MatrixToken      string        `long:"matrixtoken" description:"Access token of the Matrix bot account"`
+	MatrixRoomID     string        `long:"matrixroomid" description:"Matrix room the bot of matrixtoken sends alerts to, e.g. !abcdefg:example.com"`
+	AlertCooldown    time.Duration `long:"alertcooldown" description:"Minimum time between two alerts of the same kind"`
+	VoteErrorAlert   int           `long:"voteerroralert" description:"Send an alert when this many votes of a block fail. 0 disables the alert."`
+	WinningNtfnAlert int           `long:"winningntfnalert" description:"Send an alert when dcrd connects more than this many blocks without notifying their winning tickets, or no managed ticket is selected for far longer than expected from the live tickets. 0 disables the alerts."`
+
+	// Warm standby
+	Standby               bool `long:"standby" description:"Start as a warm standby which keeps scripts, tickets and user data up to date but does not broadcast votes or revocations until it is promoted to active"`
+	StandbyFailoverMisses int  `long:"standbyfailovermisses" description:"While in standby, promote to active once the votes of this many consecutive winning tickets were not mined. 0 disables automatic failover."`
+
+	// External vote signer
+	VoteSigner        string        `long:"votesigner" description:"Path of the unix socket of an external signer process which signs the votes instead of dcrwallet, so that dcrwallet only has to watch the tickets and the voting keys can be kept isolated. Expired and missed tickets are not revoked."`
+	VoteSignerTimeout time.Duration `long:"votesignertimeout" description:"Give up on a vote when the external signer does not respond within this time"`
 }
 
 // serviceOptions defines the configuration options for the daemon as a service
@@ -227,6 +305,37 @@ func fileExists(name string) bool {
 	return true
 }
 
+// resolveSecrets replaces the values of the sensitive options which reference
+// a secret, given as env:<variable>, file:<name> or vault:<path>#<key>, with
+// the referenced secret.
+func (c *config) resolveSecrets() error {
+	var dir string
+	if c.SecretsDir != "" {
+		dir = cleanAndExpandPath(c.SecretsDir)
+	}
+	r, err := secrets.NewResolver(dir, c.VaultAddr, c.VaultToken)
+	if err != nil {
+		return err
+	}
+
+	names := []string{"dbpassword", "dcrdpassword", "walletpassword",
+		"telegramtoken", "matrixtoken"}
+	values := []*string{&c.DBPassword, &c.DcrdPassword, &c.WalletPassword,
+		&c.TelegramToken, &c.MatrixToken}
+	return r.ResolveAll(names, values)
+}
+
+// alertConfig returns the options of the bots which send alerts to operators.
+func (c *config) alertConfig() *notify.Config {
+	return &notify.Config{
+		TelegramToken:    c.TelegramToken,
+		TelegramChatID:   c.TelegramChatID,
+		MatrixHomeServer: c.MatrixHomeServer,
+		MatrixToken:      c.MatrixToken,
+		MatrixRoomID:     c.MatrixRoomID,
+	}
+}
+
 // newConfigParser returns a new command line flags parser.
 func newConfigParser(cfg *config, so *serviceOptions, options flags.Options) *flags.Parser {
 	parser := flags.NewParser(cfg, options)
@@ -240,10 +349,10 @@ func newConfigParser(cfg *config, so *serviceOptions, options flags.Options) *fl
 // line options.
 //
 // The configuration proceeds as follows:
-// 	1) Start with a default config with sane settings
-// 	2) Pre-parse the command line to check for an alternative config file
-// 	3) Load configuration file overwriting defaults with any specified options
-// 	4) Parse CLI options and overwrite/add any specified options
+//  1. Start with a default config with sane settings
+//  2. Pre-parse the command line to check for an alternative config file
+//  3. Load configuration file overwriting defaults with any specified options
+//  4. Parse CLI options and overwrite/add any specified options
 //
 // The above results in daemon functioning properly without any config settings
 // while still allowing the user to override settings with config files and
@@ -251,17 +360,32 @@ func newConfigParser(cfg *config, so *serviceOptions, options flags.Options) *fl
 func loadConfig() (*config, []string, error) {
 	// Default config.
 	cfg := config{
-		HomeDir:    defaultHomeDir,
-		ConfigFile: defaultConfigFile,
-		DebugLevel: defaultLogLevel,
-		DataDir:    defaultDataDir,
-		DBName:     defaultDBName,
-		DBPort:     defaultDBPort,
-		DBUser:     defaultDBUser,
-		LogDir:     defaultLogDir,
-		PoolFees:   defaultPoolFees,
-		RPCKey:     defaultRPCKeyFile,
-		RPCCert:    defaultRPCCertFile,
+		HomeDir:        defaultHomeDir,
+		ConfigFile:     defaultConfigFile,
+		DebugLevel:     defaultLogLevel,
+		DataDir:        defaultDataDir,
+		DBName:         defaultDBName,
+		DBPort:         defaultDBPort,
+		DBUser:         defaultDBUser,
+		LogDir:         defaultLogDir,
+		PoolFees:       defaultPoolFees,
+		FeeMode:        defaultFeeMode,
+		RPCKey:         defaultRPCKeyFile,
+		RPCCert:        defaultRPCCertFile,
+		ReconnectAlert: defaultReconnectAlert,
+
+		GRPCKeepalive:        defaultGRPCKeepalive,
+		GRPCKeepaliveTimeout: defaultGRPCKeepaliveTimeout,
+		GRPCMaxMessageSize:   defaultGRPCMaxMessageSize,
+
+		AlertCooldown:    notify.DefaultCooldown,
+		VoteErrorAlert:   defaultVoteErrorAlert,
+		WinningNtfnAlert: defaultWinningNtfnAlert,
+
+		DBPingInterval:     defaultDBPingInterval,
+		UserDataStaleAlert: defaultUserDataStale,
+
+		VoteSignerTimeout: defaultVoteSignerTimeout,
 	}
 
 	// Service options which are only added on Windows.
@@ -339,7 +463,8 @@ func loadConfig() (*config, []string, error) {
 	// Load additional config from file.
 	var configFileError error
 	parser := newConfigParser(&cfg, &serviceOpts, flags.Default)
-	if !(preCfg.SimNet) || cfg.ConfigFile != defaultConfigFile {
+	simNet := preCfg.SimNet || preCfg.Network == netparams.SimNetName
+	if !simNet || cfg.ConfigFile != defaultConfigFile {
 		err := flags.NewIniParser(parser).ParseFile(cfg.ConfigFile)
 		if err != nil {
 			var e *os.PathError
@@ -384,25 +509,10 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// Multiple networks can't be selected simultaneously.
-	var numNets int
-
-	// Count number of network flags passed; assign active network params
-	// while we're at it
-	activeNetParams = &mainNetParams
-	if cfg.TestNet {
-		numNets++
-		activeNetParams = &testNet3Params
-	}
-	if cfg.SimNet {
-		numNets++
-		// Also disable dns seeding on the simulation test network.
-		activeNetParams = &simNetParams
-	}
-	if numNets > 1 {
-		str := "%s: The testnet and simnet params can't be " +
-			"used together -- choose one of the three"
-		err := fmt.Errorf(str, funcName)
+	// Assign active network params.
+	activeNetParams, err = netparams.Select(cfg.Network, cfg.TestNet, cfg.SimNet)
+	if err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
 		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr, usageMessage)
 		return nil, nil, err
@@ -415,12 +525,12 @@ func loadConfig() (*config, []string, error) {
 	// means each individual piece of serialized data does not have to
 	// worry about changing names per network and such.
 	cfg.DataDir = cleanAndExpandPath(cfg.DataDir)
-	cfg.DataDir = filepath.Join(cfg.DataDir, netName(activeNetParams))
+	cfg.DataDir = filepath.Join(cfg.DataDir, activeNetParams.DirName())
 
 	// Append the network type to the log directory so it is "namespaced"
 	// per network in the same fashion as the data directory.
 	cfg.LogDir = cleanAndExpandPath(cfg.LogDir)
-	cfg.LogDir = filepath.Join(cfg.LogDir, netName(activeNetParams))
+	cfg.LogDir = filepath.Join(cfg.LogDir, activeNetParams.DirName())
 
 	// Special show command to list supported subsystems and exit.
 	if cfg.DebugLevel == "show" {
@@ -440,6 +550,132 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Replace references to secrets in sensitive options with the secrets
+	// so that they are validated below.
+	if err := cfg.resolveSecrets(); err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.FeeMode != stakepool.FeeModeCommitment &&
+		cfg.FeeMode != stakepool.FeeModeDeferred {
+		str := "%s: feemode must be %s or %s, not %q"
+		err := fmt.Errorf(str, funcName, stakepool.FeeModeCommitment,
+			stakepool.FeeModeDeferred, cfg.FeeMode)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.MaxUserLiveTickets < 0 {
+		str := "%s: maxuserlivetickets may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.MaxLowFeePerBlock < 0 {
+		str := "%s: maxlowfeeperblock may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.PauseOnLowFeeSurge && cfg.MaxLowFeePerBlock == 0 {
+		str := "%s: pauseonlowfeesurge requires maxlowfeeperblock"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if _, err := cfg.alertConfig().Notifiers(); err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.AlertCooldown < 0 {
+		str := "%s: alertcooldown may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.VoteErrorAlert < 0 {
+		str := "%s: voteerroralert may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.WinningNtfnAlert < 0 {
+		str := "%s: winningntfnalert may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.DBPingInterval < 0 {
+		str := "%s: dbpinginterval may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.UserDataStaleAlert < 0 {
+		str := "%s: userdatastalealert may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.GRPCKeepalive < 0 {
+		str := "%s: grpckeepalive may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.GRPCKeepalive > 0 && cfg.GRPCKeepaliveTimeout <= 0 {
+		str := "%s: grpckeepalivetimeout must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.GRPCMaxMessageSize < minGRPCMaxMessageSize ||
+		cfg.GRPCMaxMessageSize > maxGRPCMaxMessageSize {
+		str := "%s: grpcmaxmessagesize must be between %d and %d"
+		err := fmt.Errorf(str, funcName, minGRPCMaxMessageSize,
+			maxGRPCMaxMessageSize)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.StandbyFailoverMisses < 0 {
+		str := "%s: standbyfailovermisses may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.StandbyFailoverMisses > 0 && !cfg.Standby {
+		str := "%s: standbyfailovermisses requires standby"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.VoteSigner != "" {
+		cfg.VoteSigner = cleanAndExpandPath(cfg.VoteSigner)
+		if cfg.VoteSignerTimeout <= 0 {
+			str := "%s: votesignertimeout must be positive"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
 	if cfg.DBHost == "" {
 		str := "%s: dbhost is not set in config"
 		err := fmt.Errorf(str, funcName)
@@ -572,11 +808,20 @@ func loadConfig() (*config, []string, error) {
 		}
 		cfg.RPCListeners = make([]string, 0, len(addrs))
 		for _, addr := range addrs {
-			addr = net.JoinHostPort(addr, activeNetParams.RPCServerPort)
+			addr = net.JoinHostPort(addr, activeNetParams.StakepooldRPCServerPort)
 			cfg.RPCListeners = append(cfg.RPCListeners, addr)
 		}
 	} else {
-		cfg.RPCListeners = normalizeAddresses(cfg.RPCListeners, activeNetParams.RPCServerPort)
+		cfg.RPCListeners = normalizeAddresses(cfg.RPCListeners, activeNetParams.StakepooldRPCServerPort)
+	}
+
+	if cfg.MetricsListen != "" {
+		if _, _, err := net.SplitHostPort(cfg.MetricsListen); err != nil {
+			str := "%s: invalid metricslisten %q: %v"
+			err := fmt.Errorf(str, funcName, cfg.MetricsListen, err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
 	}
 
 	// Warn about missing config file only after all other configuration is
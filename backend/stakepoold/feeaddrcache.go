@@ -0,0 +1,143 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+)
+
+// feeAddressCacheKind is the kind of data of the fee address cache in the gob
+// metrics.
+const feeAddressCacheKind = "FeeAddresses"
+
+// feeAddressCache is the on-disk cache of the fee addresses derived for an
+// extended public key on a network.  Addresses holds the addresses of the
+// external branch from index 0 in order, and Checksum covers all other fields
+// so that a truncated or modified cache is detected.
+type feeAddressCache struct {
+	ExtPub    string
+	Network   string
+	Addresses []string
+	Checksum  [sha256.Size]byte
+}
+
+// checksum returns the checksum of the extended public key, network and
+// addresses of the cache.
+func (fc *feeAddressCache) checksum() [sha256.Size]byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%d\n", fc.ExtPub, fc.Network, len(fc.Addresses))
+	for _, addr := range fc.Addresses {
+		fmt.Fprintf(h, "%s\n", addr)
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// feeAddressCachePath returns the path of the fee address cache for the
+// extended public key on the network in dataDir.  The key is hashed so that
+// changing coldwalletextpub uses a different cache file.
+func feeAddressCachePath(dataDir, xpubStr string, params *chaincfg.Params) string {
+	h := sha256.Sum256([]byte(xpubStr))
+	name := fmt.Sprintf("feeaddrs-%s-%s.gob", params.Name,
+		hex.EncodeToString(h[:8]))
+	return filepath.Join(dataDir, name)
+}
+
+// readFeeAddressCache returns the cached fee addresses of the extended public
+// key on the network.  The error satisfies os.IsNotExist when there is no
+// cache yet.
+func readFeeAddressCache(path, xpubStr string, params *chaincfg.Params) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	start := time.Now()
+	var fc feeAddressCache
+	err = gob.NewDecoder(f).Decode(&fc)
+	observeGob("load", feeAddressCacheKind, start, err)
+	if err != nil {
+		return nil, err
+	}
+	if fc.ExtPub != xpubStr || fc.Network != params.Name {
+		return nil, errors.New("cache is for a different extended public key")
+	}
+	if fc.checksum() != fc.Checksum {
+		return nil, errors.New("checksum mismatch")
+	}
+	return fc.Addresses, nil
+}
+
+// writeFeeAddressCache replaces the fee address cache of the extended public
+// key on the network with addrs.  The cache is written to a temporary file
+// first so that an interrupted write leaves the previous cache intact.
+func writeFeeAddressCache(path, xpubStr string, params *chaincfg.Params, addrs []string) error {
+	fc := feeAddressCache{
+		ExtPub:    xpubStr,
+		Network:   params.Name,
+		Addresses: addrs,
+	}
+	fc.Checksum = fc.checksum()
+
+	start := time.Now()
+	err := func() error {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(&fc); err != nil {
+			return err
+		}
+		tmpPath := path + ".tmp"
+		if err := ioutil.WriteFile(tmpPath, buf.Bytes(), 0600); err != nil {
+			return err
+		}
+		return os.Rename(tmpPath, path)
+	}()
+	observeGob("save", feeAddressCacheKind, start, err)
+	return err
+}
+
+// loadFeeAddresses returns the voting service fee addresses of the extended
+// public key like calculateFeeAddresses, but reads the addresses derived by
+// previous runs from the cache in dataDir and only derives the missing ones.
+// An unreadable cache is logged and derived again from scratch.
+func loadFeeAddresses(dataDir, xpubStr string, params *chaincfg.Params) (map[string]struct{}, error) {
+	path := feeAddressCachePath(dataDir, xpubStr, params)
+	cached, err := readFeeAddressCache(path, xpubStr, params)
+	if err != nil && !os.IsNotExist(err) {
+		log.Warnf("Ignoring fee address cache %s: %v", path, err)
+		cached = nil
+	}
+
+	if uint32(len(cached)) < numServicePaymentFeeAddresses {
+		log.Infof("Please wait, deriving %v voting service fees addresses "+
+			"for extended public key %s (%d cached)",
+			numServicePaymentFeeAddresses-uint32(len(cached)), xpubStr,
+			len(cached))
+	}
+	addrs, err := extendFeeAddresses(xpubStr, params, cached)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addrs) > len(cached) {
+		if err := writeFeeAddressCache(path, xpubStr, params, addrs); err != nil {
+			log.Warnf("Unable to write fee address cache %s: %v", path, err)
+		}
+	}
+
+	return feeAddressMap(addrs), nil
+}
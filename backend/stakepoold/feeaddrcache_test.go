@@ -0,0 +1,77 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+)
+
+func TestFeeAddressCache(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "stakepoold-feeaddrs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	params := chaincfg.TestNet3Params()
+	path := feeAddressCachePath(dataDir, xpubTestNet, params)
+
+	// Cache only the first addresses, as if numServicePaymentFeeAddresses was
+	// increased since the cache was written.
+	partial := childrenTestNet[:5]
+	if err := writeFeeAddressCache(path, xpubTestNet, params, partial); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := readFeeAddressCache(path, xpubTestNet, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cached, partial) {
+		t.Fatalf("read %v from cache, expected %v", cached, partial)
+	}
+
+	// The missing addresses are derived and added to the cache.
+	want, err := calculateFeeAddresses(xpubTestNet, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := loadFeeAddresses(dataDir, xpubTestNet, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(addrs, want) {
+		t.Fatal("addresses loaded from a partial cache differ from derived ones")
+	}
+	cached, err = readFeeAddressCache(path, xpubTestNet, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint32(len(cached)) != numServicePaymentFeeAddresses {
+		t.Fatalf("cached %d addresses, expected %d", len(cached),
+			numServicePaymentFeeAddresses)
+	}
+
+	// A cache for another key or a corrupted cache is rejected.
+	if _, err := readFeeAddressCache(path, xpubMainNet, params); err == nil {
+		t.Fatal("read the cache of another extended public key")
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := len(b) / 2
+	b[i] ^= 0xff
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readFeeAddressCache(path, xpubTestNet, params); err == nil {
+		t.Fatal("read a corrupted cache")
+	}
+}
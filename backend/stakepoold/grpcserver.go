@@ -24,9 +24,16 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
 )
 
+// grpcKeepaliveMinTime is the shortest interval at which clients may send
+// keepalive pings.  It matches the minimum interval of gRPC clients, and
+// connections of clients pinging more often are closed.
+const grpcKeepaliveMinTime = 10 * time.Second
+
 // generateRPCKeyPair generates a new RPC TLS keypair and writes the cert and
 // possibly also the key in PEM format to the paths specified by the config.  If
 // successful, the new keypair is returned.
@@ -93,6 +100,9 @@ func interceptUnary(ctx context.Context, req interface{}, info *grpc.UnaryServer
 	defer cancel()
 
 	resp, err = handler(ctx, req)
+	if grpcAudit != nil {
+		grpcAudit.record(ctx, info.FullMethod, req, err, startTime)
+	}
 	if err != nil && peerOk {
 		grpcLog.Errorf("%s invoked by %s failed: %v",
 			method, peer.Addr.String(), err)
@@ -205,9 +215,42 @@ func startGRPCServers(stakepoold *stakepool.Stakepoold) (*grpc.Server, error) {
 		return nil, err
 	}
 	creds := credentials.NewServerTLSFromCert(&keyPair)
-	svr = grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(interceptUnary))
-	server.StartVersionService(svr)
+	if cfg.AuditLog {
+		grpcAudit, err = newAuditLog(filepath.Join(cfg.LogDir,
+			defaultAuditLogFilename))
+		if err != nil {
+			return nil, err
+		}
+
+		// Ask callers for a certificate so that their identity can be
+		// recorded in the audit log.  Callers without one are still
+		// accepted.
+		creds = credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{keyPair},
+			ClientAuth:   tls.RequestClientCert,
+		})
+	}
+	opts := []grpc.ServerOption{
+		grpc.Creds(creds),
+		grpc.UnaryInterceptor(interceptUnary),
+		grpc.StatsHandler(connLogger{}),
+		grpc.MaxRecvMsgSize(cfg.GRPCMaxMessageSize),
+		grpc.MaxSendMsgSize(cfg.GRPCMaxMessageSize),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             grpcKeepaliveMinTime,
+			PermitWithoutStream: cfg.GRPCKeepalivePermitWithoutStream,
+		}),
+	}
+	if cfg.GRPCKeepalive > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    cfg.GRPCKeepalive,
+			Timeout: cfg.GRPCKeepaliveTimeout,
+		}))
+	}
+	svr = grpc.NewServer(opts...)
+	server.StartVersionService(svr, cfg.GRPCMaxMessageSize)
 	server.StartStakepooldService(stakepoold, svr)
+	server.StartDebugService(stakepoold, svr)
 	for _, lis := range listeners {
 		lis := lis
 		go func() {
@@ -226,3 +269,32 @@ func startGRPCServers(stakepoold *stakepool.Stakepoold) (*grpc.Server, error) {
 
 	return svr, nil
 }
+
+// connAddrKey is the context key of the remote address of a gRPC connection.
+type connAddrKey struct{}
+
+// connLogger is a gRPC stats handler which logs when clients connect and
+// disconnect.  Connections which broke silently, e.g. half-open connections
+// through a firewall which dropped them, are logged as disconnected once the
+// keepalive pings detect them.
+type connLogger struct{}
+
+func (connLogger) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return context.WithValue(ctx, connAddrKey{}, info.RemoteAddr)
+}
+
+func (connLogger) HandleConn(ctx context.Context, s stats.ConnStats) {
+	addr := ctx.Value(connAddrKey{})
+	switch s.(type) {
+	case *stats.ConnBegin:
+		log.Debugf("gRPC client %v connected", addr)
+	case *stats.ConnEnd:
+		log.Infof("gRPC client %v disconnected", addr)
+	}
+}
+
+func (connLogger) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (connLogger) HandleRPC(context.Context, stats.RPCStats) {}
@@ -12,18 +12,23 @@ import (
 	"github.com/decred/dcrstakepool/backend/stakepoold/rpc/server"
 	"github.com/decred/dcrstakepool/backend/stakepoold/stakepool"
 	"github.com/decred/dcrstakepool/backend/stakepoold/userdata"
+	"github.com/decred/dcrstakepool/internal/notify"
 	"github.com/decred/dcrstakepool/signal"
 	"github.com/decred/slog"
 	"github.com/jrick/logrotate/rotator"
 )
 
 // logWriter implements an io.Writer that outputs to both standard output and
-// the write-end pipe of an initialized log rotator.
+// the write-end pipe of an initialized log rotator.  Until the log rotator is
+// initialized, e.g. in tests, it only outputs to standard output.
 type logWriter struct{}
 
 func (logWriter) Write(p []byte) (n int, err error) {
 	os.Stdout.Write(p)
-	return logRotator.Write(p)
+	if logRotator != nil {
+		return logRotator.Write(p)
+	}
+	return len(p), nil
 }
 
 // Loggers per subsystem.  A single backend logger is created and all subsytem
@@ -48,6 +53,7 @@ var (
 	dbLog        = backendLog.Logger("DB")
 	grpcLog      = backendLog.Logger("GRPC")
 	log          = backendLog.Logger("STPK")
+	notifyLog    = backendLog.Logger("NTFY")
 	stakepoolLog = backendLog.Logger("CORE")
 )
 
@@ -57,6 +63,7 @@ var subsystemLoggers = map[string]slog.Logger{
 	"DB":   dbLog,
 	"GRPC": grpcLog,
 	"STPK": log,
+	"NTFY": notifyLog,
 	"CORE": stakepoolLog,
 }
 
@@ -65,6 +72,7 @@ func init() {
 	userdata.UseLogger(dbLog)
 	server.UseLogger(grpcLog)
 	stakepool.UseLogger(stakepoolLog)
+	notify.UseLogger(notifyLog)
 	signal.UseLogger(log)
 }
 
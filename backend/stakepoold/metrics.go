@@ -0,0 +1,87 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrstakepool/backend/stakepoold/metrics"
+	"github.com/decred/dcrstakepool/backend/stakepoold/stakepool"
+)
+
+// metricsShutdownTimeout is how long in-flight scrapes are given to finish
+// when stakepoold shuts down.
+const metricsShutdownTimeout = 5 * time.Second
+
+var (
+	// gobDuration and gobErrors track saving and loading the gob encoded
+	// data files and the fee address cache, by operation (save or load) and
+	// kind of data.
+	gobDuration = metrics.NewHistogramVec("stakepoold_gob_duration_seconds",
+		"Duration of saving or loading gob encoded data.",
+		metrics.DefaultBuckets, "op", "kind")
+	gobErrors = metrics.NewCounterVec("stakepoold_gob_errors_total",
+		"Number of failures to save or load gob encoded data.", "op", "kind")
+)
+
+// observeGob records a save or load of the kind of gob encoded data which
+// started at start and failed when err is not nil.
+func observeGob(op, kind string, start time.Time, err error) {
+	gobDuration.ObserveDuration(time.Since(start), op, kind)
+	if err != nil {
+		gobErrors.Inc(op, kind)
+	} else {
+		gobErrors.Add(0, op, kind)
+	}
+}
+
+// startMetricsServer serves the metrics of spd at /metrics on listen until
+// ctx is cancelled.  An error is returned when listen cannot be bound.
+func startMetricsServer(ctx context.Context, wg *sync.WaitGroup, spd *stakepool.Stakepoold, listen string) error {
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+
+	registry := new(metrics.Registry)
+	registry.Register(gobDuration, gobErrors)
+	spd.RegisterMetrics(registry)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	server := &http.Server{
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		log.Infof("Serving metrics on http://%s/metrics", listen)
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Errorf("Metrics server failed: %v", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(),
+			metricsShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Warnf("Metrics server shutdown: %v", err)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,297 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package metrics implements the counters, gauges and histograms exported by
+// stakepoold in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the histogram buckets
+// used for durations.  They range from 5ms for a fast wallet RPC to 10s for a
+// slow block.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector is a metric family which writes its samples in the text
+// exposition format.
+type Collector interface {
+	writeTo(w *bufio.Writer)
+}
+
+// labelsKey joins label values into a map key.
+func labelsKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// formatLabels returns the label pairs of a sample, including the braces, or
+// an empty string when there are no labels.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		var value string
+		if i < len(values) {
+			value = values[i]
+		}
+		b.WriteString(escapeLabelValue(value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// escapeLabelValue escapes backslashes, double quotes and line feeds in a
+// label value.
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat formats a sample value.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric family.
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sortedKeys returns the keys of the series of a metric family in order, so
+// that the samples are written in a stable order.
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec is a family of counters partitioned by label values.
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mtx    sync.Mutex
+	keys   map[string][]string
+	values map[string]float64
+}
+
+// NewCounterVec returns a family of counters with the passed label names.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		keys:   make(map[string][]string),
+		values: make(map[string]float64),
+	}
+}
+
+// Add adds n to the counter with the passed label values.
+func (v *CounterVec) Add(n float64, labelValues ...string) {
+	key := labelsKey(labelValues)
+	v.mtx.Lock()
+	if _, ok := v.keys[key]; !ok {
+		v.keys[key] = labelValues
+	}
+	v.values[key] += n
+	v.mtx.Unlock()
+}
+
+// Inc increments the counter with the passed label values.
+func (v *CounterVec) Inc(labelValues ...string) {
+	v.Add(1, labelValues...)
+}
+
+func (v *CounterVec) writeTo(w *bufio.Writer) {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	writeHeader(w, v.name, v.help, "counter")
+	for _, key := range sortedKeys(v.keys) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name,
+			formatLabels(v.labels, v.keys[key]), formatFloat(v.values[key]))
+	}
+}
+
+// GaugeFunc is a family of gauges whose values are read when they are
+// collected.
+type GaugeFunc struct {
+	name   string
+	help   string
+	labels []string
+	fn     func() []Sample
+}
+
+// Sample is the value of a gauge with the label values in LabelValues.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// NewGaugeFunc returns a family of gauges with the passed label names, which
+// are read from fn when they are collected.
+func NewGaugeFunc(name, help string, fn func() []Sample, labels ...string) *GaugeFunc {
+	return &GaugeFunc{
+		name:   name,
+		help:   help,
+		labels: labels,
+		fn:     fn,
+	}
+}
+
+func (g *GaugeFunc) writeTo(w *bufio.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	for _, s := range g.fn() {
+		fmt.Fprintf(w, "%s%s %s\n", g.name,
+			formatLabels(g.labels, s.LabelValues), formatFloat(s.Value))
+	}
+}
+
+// histogram holds the observations of one series of a HistogramVec.
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec is a family of histograms partitioned by label values.
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mtx    sync.Mutex
+	keys   map[string][]string
+	series map[string]*histogram
+}
+
+// NewHistogramVec returns a family of histograms with the passed bucket upper
+// bounds, in increasing order, and label names.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		keys:    make(map[string][]string),
+		series:  make(map[string]*histogram),
+	}
+}
+
+// Observe adds an observation of value to the histogram with the passed label
+// values.
+func (v *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := labelsKey(labelValues)
+	i := sort.SearchFloat64s(v.buckets, value)
+
+	v.mtx.Lock()
+	h, ok := v.series[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(v.buckets))}
+		v.series[key] = h
+		v.keys[key] = labelValues
+	}
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += value
+	v.mtx.Unlock()
+}
+
+// ObserveDuration adds an observation of the duration d in seconds to the
+// histogram with the passed label values.
+func (v *HistogramVec) ObserveDuration(d time.Duration, labelValues ...string) {
+	v.Observe(d.Seconds(), labelValues...)
+}
+
+func (v *HistogramVec) writeTo(w *bufio.Writer) {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	writeHeader(w, v.name, v.help, "histogram")
+	bucketLabels := append(append([]string(nil), v.labels...), "le")
+	for _, key := range sortedKeys(v.keys) {
+		h := v.series[key]
+		values := v.keys[key]
+		var cumulative uint64
+		for i, bound := range v.buckets {
+			cumulative += h.counts[i]
+			le := append(append([]string(nil), values...), formatFloat(bound))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.name,
+				formatLabels(bucketLabels, le), cumulative)
+		}
+		le := append(append([]string(nil), values...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.name,
+			formatLabels(bucketLabels, le), h.count)
+		labels := formatLabels(v.labels, values)
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.name, labels, formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.name, labels, h.count)
+	}
+}
+
+// Registry holds the collectors which are exported together.
+type Registry struct {
+	mtx        sync.Mutex
+	collectors []Collector
+}
+
+// Register adds collectors to the registry.
+func (r *Registry) Register(cs ...Collector) {
+	r.mtx.Lock()
+	r.collectors = append(r.collectors, cs...)
+	r.mtx.Unlock()
+}
+
+// Write writes the samples of all registered collectors to w in the text
+// exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mtx.Lock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mtx.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.writeTo(bw)
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serves the samples of all registered collectors.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = r.Write(w)
+}
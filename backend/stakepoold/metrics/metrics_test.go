@@ -0,0 +1,53 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	votes := NewCounterVec("votes_total", "Votes.", "result")
+	votes.Inc("voted")
+	votes.Add(2, "voted")
+	votes.Inc(`a"b`)
+
+	duration := NewHistogramVec("duration_seconds", "Durations.",
+		[]float64{0.1, 1}, "method")
+	duration.Observe(0.05, "getinfo")
+	duration.Observe(0.5, "getinfo")
+	duration.Observe(5, "getinfo")
+
+	depth := NewGaugeFunc("queue_depth", "Depth.", func() []Sample {
+		return []Sample{{LabelValues: []string{"winning"}, Value: 3}}
+	}, "queue")
+
+	var r Registry
+	r.Register(votes, duration, depth)
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP votes_total Votes.
+# TYPE votes_total counter
+votes_total{result="a\"b"} 1
+votes_total{result="voted"} 3
+# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{method="getinfo",le="0.1"} 1
+duration_seconds_bucket{method="getinfo",le="1"} 2
+duration_seconds_bucket{method="getinfo",le="+Inf"} 3
+duration_seconds_sum{method="getinfo"} 5.55
+duration_seconds_count{method="getinfo"} 3
+# HELP queue_depth Depth.
+# TYPE queue_depth gauge
+queue_depth{queue="winning"} 3
+`
+	if got := b.String(); got != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
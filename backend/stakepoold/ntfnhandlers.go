@@ -5,12 +5,40 @@ import (
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/rpcclient/v6"
+	"github.com/decred/dcrd/wire"
 	"github.com/decred/dcrstakepool/backend/stakepoold/stakepool"
 )
 
 // Define notification handlers
-func getNodeNtfnHandlers(spd *stakepool.Stakepoold) *rpcclient.NotificationHandlers {
+func getNodeNtfnHandlers(spd *stakepool.Stakepoold, connMon *connMonitor) *rpcclient.NotificationHandlers {
 	return &rpcclient.NotificationHandlers{
+		OnClientConnected: connMon.onNodeConnected,
+		OnBlockConnected: func(blockHeader []byte, _ [][]byte) {
+			var header wire.BlockHeader
+			if err := header.FromBytes(blockHeader); err != nil {
+				log.Errorf("failed to deserialize connected block "+
+					"header: %v", err)
+				return
+			}
+			blockHash := header.BlockHash()
+			spd.ConnectBlock(&blockHash, int64(header.Height))
+			spd.WinningWatch.BlockConnected(int64(header.Height),
+				header.PoolSize)
+		},
+		OnBlockDisconnected: func(blockHeader []byte) {
+			var header wire.BlockHeader
+			if err := header.FromBytes(blockHeader); err != nil {
+				log.Errorf("failed to deserialize disconnected block "+
+					"header: %v", err)
+				return
+			}
+			blockHash := header.BlockHash()
+			height := int64(header.Height)
+			log.Warnf("block %v (height %d) was disconnected from the "+
+				"main chain", blockHash, height)
+			spd.DisconnectBlock(&blockHash, height)
+			connMon.onBlockDisconnected(height)
+		},
 		OnNewTickets: func(blockHash *chainhash.Hash, blockHeight int64, _ int64, tickets []*chainhash.Hash) {
 			nt := stakepool.NewTicketsForBlock{
 				BlockHash:   blockHash,
@@ -40,6 +68,8 @@ func getNodeNtfnHandlers(spd *stakepool.Stakepoold) *rpcclient.NotificationHandl
 				BlockHeight:    blockHeight,
 				WinningTickets: winningTickets,
 			}
+			connMon.setBestHeight(blockHeight)
+			spd.WinningWatch.WinningTicketsNotified(blockHeight)
 			spd.WinningTicketsChan <- wt
 		},
 	}
@@ -0,0 +1,243 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/decred/dcrd/rpcclient/v6"
+	"github.com/decred/dcrstakepool/backend/stakepoold/stakepool"
+	"github.com/decred/dcrstakepool/internal/notify"
+)
+
+// connCheckInterval is the amount of time between checks of the dcrd and
+// dcrwallet connection state.
+const connCheckInterval = time.Second * 10
+
+// walletLockCheckInterval is the amount of time between checks of whether
+// dcrwallet is locked.
+const walletLockCheckInterval = time.Minute
+
+// registerNodeNotifications subscribes the dcrd client to all notifications
+// required for voting and ticket tracking.
+func registerNodeNotifications(ctx context.Context, nodeConn *rpcclient.Client) error {
+	if err := nodeConn.NotifyBlocks(ctx); err != nil {
+		return fmt.Errorf("failed to register daemon RPC client for "+
+			"block notifications: %v", err)
+	}
+	if err := nodeConn.NotifyWinningTickets(ctx); err != nil {
+		return fmt.Errorf("failed to register daemon RPC client for "+
+			"winning tickets notifications: %v", err)
+	}
+	if err := nodeConn.NotifyNewTickets(ctx); err != nil {
+		return fmt.Errorf("failed to register daemon RPC client for "+
+			"new tickets notifications: %v", err)
+	}
+	if err := nodeConn.NotifySpentAndMissedTickets(ctx); err != nil {
+		return fmt.Errorf("failed to register daemon RPC client for "+
+			"spent/missed tickets notifications: %v", err)
+	}
+	return nil
+}
+
+// connMonitor watches the dcrd and dcrwallet connections.  When either
+// reconnects it resynchronizes the ticket data that may have changed during
+// the outage, re-registering for dcrd notifications as needed, and it raises a
+// critical alert when a connection has been down for longer than the
+// configured threshold or when dcrwallet is locked.  The ticket data is also
+// resynchronized after chain reorganizations.
+type connMonitor struct {
+	// The following fields are accessed atomically.
+	bestHeight int64
+	nodeConns  int32
+	reorged    int32
+
+	spd            *stakepool.Stakepoold
+	alertThreshold time.Duration
+	alerter        *notify.Alerter
+	nodeConnected  chan struct{}
+}
+
+// newConnMonitor returns a connMonitor which alerts after alertThreshold,
+// also sending the alerts with alerter.  The monitor must be passed to
+// getNodeNtfnHandlers so that it is notified of dcrd connection events.
+func newConnMonitor(alertThreshold time.Duration, alerter *notify.Alerter) *connMonitor {
+	return &connMonitor{
+		alertThreshold: alertThreshold,
+		alerter:        alerter,
+		nodeConnected:  make(chan struct{}, 1),
+	}
+}
+
+// onNodeConnected is called by the dcrd client every time it connects.  The
+// initial connection is ignored.
+func (m *connMonitor) onNodeConnected() {
+	if atomic.AddInt32(&m.nodeConns, 1) == 1 {
+		return
+	}
+	select {
+	case m.nodeConnected <- struct{}{}:
+	default:
+	}
+}
+
+// onBlockDisconnected is called when a block is disconnected by a chain
+// reorganization.  The tickets are resynchronized with the wallet on the
+// next connection check, once the blocks of the new main chain have been
+// connected.
+func (m *connMonitor) onBlockDisconnected(height int64) {
+	atomic.StoreInt64(&m.bestHeight, height-1)
+	atomic.StoreInt32(&m.reorged, 1)
+}
+
+// setBestHeight records the height of the most recently notified block.
+func (m *connMonitor) setBestHeight(height int64) {
+	atomic.StoreInt64(&m.bestHeight, height)
+}
+
+// checkDown tracks how long a connection has been down and logs a critical
+// alert once it has exceeded the alert threshold.
+func (m *connMonitor) checkDown(name string, down bool, downSince *time.Time,
+	alerted *bool) {
+	switch {
+	case !down:
+		if *alerted {
+			log.Infof("Connection to %s restored after %v", name,
+				time.Since(*downSince).Round(time.Second))
+		}
+		*downSince = time.Time{}
+		*alerted = false
+	case downSince.IsZero():
+		log.Warnf("Connection to %s lost", name)
+		*downSince = time.Now()
+	case !*alerted && time.Since(*downSince) > m.alertThreshold:
+		msg := fmt.Sprintf("Connection to %s has been down for %v, tickets "+
+			"will not be voted until it is restored", name,
+			time.Since(*downSince).Round(time.Second))
+		log.Critical(msg)
+		m.alerter.Alert(notify.KindConnection+" "+name, msg)
+		*alerted = true
+	}
+}
+
+// checkWalletLocked logs a critical alert when dcrwallet is locked, since it
+// cannot sign votes, and logs when it is unlocked again.  locked tracks the
+// lock state across calls.
+func (m *connMonitor) checkWalletLocked(ctx context.Context, locked *bool) {
+	info, err := m.spd.WalletConnection.RPCClient().WalletInfo(ctx)
+	if err != nil {
+		// Lost connections are reported by checkDown.
+		log.Debugf("Unable to check whether dcrwallet is locked: %v", err)
+		return
+	}
+	switch {
+	case !info.Unlocked && !*locked:
+		msg := "dcrwallet is locked, tickets will not be voted until it " +
+			"is unlocked"
+		log.Critical(msg)
+		m.alerter.Alert(notify.KindWalletLocked, msg)
+	case info.Unlocked && *locked:
+		log.Info("dcrwallet is unlocked")
+	}
+	*locked = !info.Unlocked
+}
+
+// resync refreshes the ticket data after a reconnection and reports any
+// blocks that were connected while notifications were not being received.
+func (m *connMonitor) resync(ctx context.Context, reregister bool) {
+	nodeConn := m.spd.NodeConnection
+	if reregister {
+		if err := registerNodeNotifications(ctx, nodeConn); err != nil {
+			log.Errorf("Unable to re-register for notifications: %v", err)
+		} else {
+			log.Info("re-subscribed to notifications from dcrd")
+		}
+	}
+
+	_, height, err := nodeConn.GetBestBlock(ctx)
+	if err != nil {
+		log.Errorf("unable to get bestblock from dcrd: %v", err)
+		return
+	}
+	last := atomic.SwapInt64(&m.bestHeight, height)
+	if last > 0 && height > last {
+		log.Warnf("%d block(s) (heights %d-%d) were connected without "+
+			"notifications, winning tickets in them could not be voted",
+			height-last, last+1, height)
+	}
+
+	// Wait for a wallet connection if not connected.
+	select {
+	case <-m.spd.WalletConnection.Connected():
+	case <-ctx.Done():
+		return
+	}
+
+	ignoredLowFeeTickets, liveTickets, err := walletGetTickets(ctx, m.spd)
+	if err != nil {
+		log.Errorf("unable to refresh tickets after reconnect: %v", err)
+		return
+	}
+	m.spd.Lock()
+	m.spd.IgnoredLowFeeTicketsMSA = ignoredLowFeeTickets
+	m.spd.LiveTicketsMSA = liveTickets
+	m.spd.KeepLowFeeReview()
+	m.spd.Unlock()
+	log.Infof("refreshed tickets after reconnect -- live %d ignoredLowFee %d",
+		len(liveTickets), len(ignoredLowFeeTickets))
+}
+
+// run monitors the connections until the context is cancelled.
+//
+// This function must be run as a goroutine.
+func (m *connMonitor) run(ctx context.Context, wg *sync.WaitGroup, spd *stakepool.Stakepoold) {
+	wg.Add(1)
+	defer wg.Done()
+
+	m.spd = spd
+
+	var nodeDownSince, walletDownSince time.Time
+	var nodeAlerted, walletAlerted, walletLocked bool
+	walletWasConnected := true
+
+	ticker := time.NewTicker(connCheckInterval)
+	defer ticker.Stop()
+	lockTicker := time.NewTicker(walletLockCheckInterval)
+	defer lockTicker.Stop()
+
+	for {
+		select {
+		case <-m.nodeConnected:
+			m.resync(ctx, true)
+		case <-ticker.C:
+			m.checkDown("dcrd", spd.NodeConnection.Disconnected(),
+				&nodeDownSince, &nodeAlerted)
+
+			walletConnected := spd.WalletConnection.IsConnected()
+			m.checkDown("dcrwallet", !walletConnected,
+				&walletDownSince, &walletAlerted)
+			switch {
+			case walletConnected && !walletWasConnected:
+				atomic.StoreInt32(&m.reorged, 0)
+				m.resync(ctx, false)
+			case walletConnected && atomic.CompareAndSwapInt32(&m.reorged, 1, 0):
+				log.Info("resynchronizing tickets after chain reorganization")
+				m.resync(ctx, false)
+			}
+			walletWasConnected = walletConnected
+		case <-lockTicker.C:
+			// Votes signed externally do not need an unlocked wallet.
+			if spd.WalletConnection.IsConnected() && spd.VoteSigner == nil {
+				m.checkWalletLocked(ctx, &walletLocked)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"google.golang.org/grpc"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/backend/stakepoold/stakepool"
+	"github.com/decred/dcrstakepool/backend/stakepoold/userdata"
+)
+
+// debugServer provides support engineers with the ability to inspect the
+// in-memory state of stakepoold, e.g. to diagnose divergence between
+// stakepoold instances or between stakepoold and the database.
+type debugServer struct {
+	stakepoold *stakepool.Stakepoold
+}
+
+// StartDebugService creates an implementation of the DebugService and
+// registers it with the gRPC server.
+func StartDebugService(stakepoold *stakepool.Stakepoold, server *grpc.Server) {
+	pb.RegisterDebugServiceServer(server, &debugServer{
+		stakepoold: stakepoold,
+	})
+}
+
+func (s *debugServer) DumpState(ctx context.Context, req *pb.DumpStateRequest) (*pb.DumpStateResponse, error) {
+	s.stakepoold.RLock()
+	defer s.stakepoold.RUnlock()
+	return dumpState(req, s.stakepoold.AddedLowFeeTicketsMSA,
+		s.stakepoold.IgnoredLowFeeTicketsMSA, s.stakepoold.LiveTicketsMSA,
+		s.stakepoold.UserVotingConfig, s.stakepoold.TicketQueueStats()), nil
+}
+
+// dumpState builds the response to a DumpState request from the state of
+// stakepoold.  Entries are sorted so that dumps of different instances can be
+// compared directly.
+func dumpState(req *pb.DumpStateRequest, added, ignored, live map[chainhash.Hash]string,
+	userVotingConfig map[string]userdata.UserVotingConfig,
+	queues []stakepool.TicketQueueStats) *pb.DumpStateResponse {
+
+	resp := &pb.DumpStateResponse{
+		AddedLowFeeTicketsCount:   uint32(len(added)),
+		IgnoredLowFeeTicketsCount: uint32(len(ignored)),
+		LiveTicketsCount:          uint32(len(live)),
+		UserVotingConfigCount:     uint32(len(userVotingConfig)),
+		Queues:                    make([]*pb.TicketQueue, 0, len(queues)),
+	}
+	for _, q := range queues {
+		resp.Queues = append(resp.Queues, &pb.TicketQueue{
+			Name:      q.Name,
+			Depth:     uint32(q.Depth),
+			Capacity:  uint32(q.Capacity),
+			MaxDepth:  uint32(q.MaxDepth),
+			Processed: q.Processed,
+		})
+	}
+	if !req.IncludeContents {
+		return resp
+	}
+
+	address := func(msa string) string {
+		if req.RedactAddresses {
+			return redactAddress(msa)
+		}
+		return msa
+	}
+	dumpTickets := func(ticketsMSA map[chainhash.Hash]string) []*pb.Ticket {
+		tickets := make([]*pb.Ticket, 0, len(ticketsMSA))
+		for hash, msa := range ticketsMSA {
+			tickets = append(tickets, &pb.Ticket{
+				Address: address(msa),
+				Hash:    hash.CloneBytes(),
+			})
+		}
+		sort.Slice(tickets, func(i, j int) bool {
+			return bytes.Compare(tickets[i].Hash, tickets[j].Hash) < 0
+		})
+		return tickets
+	}
+	resp.AddedLowFeeTickets = dumpTickets(added)
+	resp.IgnoredLowFeeTickets = dumpTickets(ignored)
+	resp.LiveTickets = dumpTickets(live)
+
+	resp.UserVotingConfig = make([]*pb.UserVotingConfigEntry, 0, len(userVotingConfig))
+	for msa, config := range userVotingConfig {
+		entry := &pb.UserVotingConfigEntry{
+			UserId:          config.Userid,
+			MultiSigAddress: address(msa),
+			VoteBits:        int64(config.VoteBits),
+			VoteBitsVersion: int64(config.VoteBitsVersion),
+		}
+		if req.RedactUserIds {
+			entry.UserId = 0
+		}
+		resp.UserVotingConfig = append(resp.UserVotingConfig, entry)
+	}
+	sort.Slice(resp.UserVotingConfig, func(i, j int) bool {
+		return resp.UserVotingConfig[i].MultiSigAddress <
+			resp.UserVotingConfig[j].MultiSigAddress
+	})
+
+	return resp
+}
+
+// redactAddress replaces a multisig address with a digest of it, which is the
+// same for equal addresses so that redacted entries can still be correlated.
+func redactAddress(msa string) string {
+	digest := sha256.Sum256([]byte(msa))
+	return "redacted:" + hex.EncodeToString(digest[:8])
+}
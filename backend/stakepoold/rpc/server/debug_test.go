@@ -0,0 +1,92 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/backend/stakepoold/stakepool"
+	"github.com/decred/dcrstakepool/backend/stakepoold/userdata"
+)
+
+func TestDumpState(t *testing.T) {
+	const msa1, msa2 = "TcfdqCrK2fiFJBZnGj5N6xs6rMsbQBsJBYf", "TcrzaAVMbFURm1PpukWru8yE2uBTjvQePoa"
+	live := map[chainhash.Hash]string{
+		{0x02}: msa1,
+		{0x01}: msa2,
+		{0x03}: msa1,
+	}
+	ignored := map[chainhash.Hash]string{{0x04}: msa2}
+	userVotingConfig := map[string]userdata.UserVotingConfig{
+		msa2: {Userid: 2, MultiSigAddress: msa2, VoteBits: 1, VoteBitsVersion: 8},
+		msa1: {Userid: 1, MultiSigAddress: msa1, VoteBits: 5, VoteBitsVersion: 8},
+	}
+
+	queues := []stakepool.TicketQueueStats{{Name: "NewTickets", Depth: 1,
+		Capacity: 32, MaxDepth: 4, Processed: 10}}
+
+	// Only counts and queues are returned by default.
+	resp := dumpState(&pb.DumpStateRequest{}, nil, ignored, live, userVotingConfig,
+		queues)
+	if resp.AddedLowFeeTicketsCount != 0 || resp.IgnoredLowFeeTicketsCount != 1 ||
+		resp.LiveTicketsCount != 3 || resp.UserVotingConfigCount != 2 {
+		t.Fatalf("unexpected counts %v", resp)
+	}
+	if len(resp.Queues) != 1 || resp.Queues[0].Name != "NewTickets" ||
+		resp.Queues[0].Depth != 1 || resp.Queues[0].Capacity != 32 ||
+		resp.Queues[0].MaxDepth != 4 || resp.Queues[0].Processed != 10 {
+		t.Fatalf("unexpected queues %v", resp.Queues)
+	}
+	if resp.LiveTickets != nil || resp.UserVotingConfig != nil {
+		t.Fatal("contents dumped without IncludeContents")
+	}
+
+	resp = dumpState(&pb.DumpStateRequest{IncludeContents: true}, nil,
+		ignored, live, userVotingConfig, nil)
+	if len(resp.AddedLowFeeTickets) != 0 || len(resp.IgnoredLowFeeTickets) != 1 ||
+		len(resp.LiveTickets) != 3 || len(resp.UserVotingConfig) != 2 {
+		t.Fatalf("unexpected contents %v", resp)
+	}
+	for i, want := range []byte{0x01, 0x02, 0x03} {
+		if resp.LiveTickets[i].Hash[0] != want {
+			t.Fatalf("live tickets are not sorted: %v", resp.LiveTickets)
+		}
+	}
+	if resp.LiveTickets[0].Address != msa2 {
+		t.Fatalf("got address %s", resp.LiveTickets[0].Address)
+	}
+	cfg := resp.UserVotingConfig[0]
+	if cfg.MultiSigAddress != msa1 || cfg.UserId != 1 || cfg.VoteBits != 5 ||
+		cfg.VoteBitsVersion != 8 {
+		t.Fatalf("unexpected user voting config %v", cfg)
+	}
+
+	resp = dumpState(&pb.DumpStateRequest{IncludeContents: true,
+		RedactAddresses: true, RedactUserIds: true}, nil, ignored, live,
+		userVotingConfig, nil)
+	for _, ticket := range resp.LiveTickets {
+		if !strings.HasPrefix(ticket.Address, "redacted:") {
+			t.Fatalf("address %s not redacted", ticket.Address)
+		}
+	}
+	// Redacted addresses can still be correlated.
+	if resp.LiveTickets[1].Address != resp.LiveTickets[2].Address ||
+		resp.LiveTickets[0].Address != resp.IgnoredLowFeeTickets[0].Address ||
+		resp.LiveTickets[0].Address == resp.LiveTickets[1].Address {
+		t.Fatal("redacted addresses do not match the original addresses")
+	}
+	for _, cfg := range resp.UserVotingConfig {
+		if cfg.UserId != 0 {
+			t.Fatalf("user id %d not redacted", cfg.UserId)
+		}
+		if cfg.MultiSigAddress != redactAddress(msa1) &&
+			cfg.MultiSigAddress != redactAddress(msa2) {
+			t.Fatalf("address %s not redacted", cfg.MultiSigAddress)
+		}
+	}
+}
@@ -13,11 +13,16 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"sort"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	wallettypes "decred.org/dcrwallet/rpc/jsonrpc/types"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
 	"github.com/decred/dcrstakepool/backend/stakepoold/stakepool"
@@ -34,29 +39,46 @@ const (
 	// collection cycle to also trigger a timeout but the current allocation
 	// pattern of stakepoold is not known to cause such conditions at this time.
 	GRPCCommandTimeout = time.Millisecond * 1200
-	semverString       = "10.0.0"
+	semverString       = "10.17.0"
 	semverMajor        = 10
-	semverMinor        = 0
+	semverMinor        = 17
 	semverPatch        = 0
 )
 
+// maxTicketAmountsHashes is the maximum number of tickets and votes which can
+// be looked up by a single GetTicketAmounts request.
+const maxTicketAmountsHashes = 100
+
+// maxLookupTicketsKeys is the maximum number of tickets and multisig addresses
+// which can be looked up by a single LookupTickets request.
+const maxLookupTicketsKeys = 100
+
+// maxBatchStakePoolUserInfoAddrs is the maximum number of multisig addresses
+// whose user info can be requested by a single BatchStakePoolUserInfo request.
+const maxBatchStakePoolUserInfoAddrs = 500
+
 // versionServer provides RPC clients with the ability to query the RPC server
 // version.
 type versionServer struct {
+	maxMessageSize int
 }
 
 // StartVersionService creates an implementation of the VersionService and
-// registers it with the gRPC server.
-func StartVersionService(server *grpc.Server) {
-	pb.RegisterVersionServiceServer(server, &versionServer{})
+// registers it with the gRPC server.  The maximum size of the messages the
+// server receives is advertised so that clients do not send larger ones.
+func StartVersionService(server *grpc.Server, maxMessageSize int) {
+	pb.RegisterVersionServiceServer(server, &versionServer{
+		maxMessageSize: maxMessageSize,
+	})
 }
 
 func (v *versionServer) Version(ctx context.Context, req *pb.VersionRequest) (*pb.VersionResponse, error) {
 	return &pb.VersionResponse{
-		VersionString: semverString,
-		Major:         semverMajor,
-		Minor:         semverMinor,
-		Patch:         semverPatch,
+		VersionString:  semverString,
+		Major:          semverMajor,
+		Minor:          semverMinor,
+		Patch:          semverPatch,
+		MaxMessageSize: uint32(v.maxMessageSize),
 	}, nil
 }
 
@@ -94,13 +116,44 @@ func (s *stakepooldServer) GetAddedLowFeeTickets(c context.Context, req *pb.GetA
 	return &pb.GetAddedLowFeeTicketsResponse{Tickets: tickets}, nil
 }
 
+// ticketPage returns up to limit of tickets from offset in the order of their
+// hashes.
+func ticketPage(tickets []*pb.Ticket, offset, limit uint32) []*pb.Ticket {
+	sort.Slice(tickets, func(i, j int) bool {
+		return bytes.Compare(tickets[i].Hash, tickets[j].Hash) < 0
+	})
+
+	if offset > uint32(len(tickets)) {
+		offset = uint32(len(tickets))
+	}
+	end := offset + limit
+	if end > uint32(len(tickets)) || end < offset {
+		end = uint32(len(tickets))
+	}
+	return tickets[offset:end]
+}
+
+// GetIgnoredLowFeeTickets returns the ignored low fee tickets, or only a page
+// of them or their number, so that the admin page does not have to get all of
+// them on every load.
 func (s *stakepooldServer) GetIgnoredLowFeeTickets(c context.Context, req *pb.GetIgnoredLowFeeTicketsRequest) (*pb.GetIgnoredLowFeeTicketsResponse, error) {
 	s.stakepoold.RLock()
 	ticketsMSA := s.stakepoold.IgnoredLowFeeTicketsMSA
+	total := uint32(len(ticketsMSA))
 	s.stakepoold.RUnlock()
 
+	if req.CountOnly {
+		return &pb.GetIgnoredLowFeeTicketsResponse{Total: total}, nil
+	}
+
 	tickets := processTickets(ticketsMSA)
-	return &pb.GetIgnoredLowFeeTicketsResponse{Tickets: tickets}, nil
+	if req.Limit > 0 {
+		tickets = ticketPage(tickets, req.Offset, req.Limit)
+	}
+	return &pb.GetIgnoredLowFeeTicketsResponse{
+		Tickets: tickets,
+		Total:   total,
+	}, nil
 }
 
 func (s *stakepooldServer) GetLiveTickets(c context.Context, req *pb.GetLiveTicketsRequest) (*pb.GetLiveTicketsResponse, error) {
@@ -112,6 +165,107 @@ func (s *stakepooldServer) GetLiveTickets(c context.Context, req *pb.GetLiveTick
 	return &pb.GetLiveTicketsResponse{Tickets: tickets}, nil
 }
 
+func (s *stakepooldServer) GetLiveTicketCounts(ctx context.Context, req *pb.GetLiveTicketCountsRequest) (*pb.GetLiveTicketCountsResponse, error) {
+	counts := make(map[string]uint32)
+	s.stakepoold.RLock()
+	for _, msa := range s.stakepoold.LiveTicketsMSA {
+		counts[msa]++
+	}
+	s.stakepoold.RUnlock()
+
+	resp := &pb.GetLiveTicketCountsResponse{
+		Counts: make([]*pb.LiveTicketCount, 0, len(counts)),
+	}
+	for msa, count := range counts {
+		resp.Counts = append(resp.Counts, &pb.LiveTicketCount{
+			MultiSigAddress: msa,
+			Count:           count,
+		})
+	}
+	return resp, nil
+}
+
+func (s *stakepooldServer) GetLowFeeReview(ctx context.Context, req *pb.GetLowFeeReviewRequest) (*pb.GetLowFeeReviewResponse, error) {
+	paused, ticketsMSA := s.stakepoold.LowFeeReview()
+	return &pb.GetLowFeeReviewResponse{
+		Paused:  paused,
+		Tickets: processTickets(ticketsMSA),
+	}, nil
+}
+
+func (s *stakepooldServer) ResumeLowFeeClassification(ctx context.Context, req *pb.ResumeLowFeeClassificationRequest) (*pb.ResumeLowFeeClassificationResponse, error) {
+	released := s.stakepoold.ResumeLowFeeClassification()
+	return &pb.ResumeLowFeeClassificationResponse{Released: uint32(released)}, nil
+}
+
+func (s *stakepooldServer) PromoteStandby(ctx context.Context, req *pb.PromoteStandbyRequest) (*pb.PromoteStandbyResponse, error) {
+	wasStandby := s.stakepoold.Promote("requested over RPC")
+	return &pb.PromoteStandbyResponse{WasStandby: wasStandby}, nil
+}
+
+func (s *stakepooldServer) LookupTickets(ctx context.Context, req *pb.LookupTicketsRequest) (*pb.LookupTicketsResponse, error) {
+	if len(req.Tickets)+len(req.MultiSigAddresses) > maxLookupTicketsKeys {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"at most %d tickets and addresses may be looked up",
+			maxLookupTicketsKeys)
+	}
+
+	tickets := make([]chainhash.Hash, 0, len(req.Tickets))
+	for _, b := range req.Tickets {
+		hash, err := chainhash.NewHash(b)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"invalid hash %x: %v", b, err)
+		}
+		tickets = append(tickets, *hash)
+	}
+
+	found := s.stakepoold.LookupTickets(tickets, req.MultiSigAddresses)
+	resp := &pb.LookupTicketsResponse{
+		Tickets: make([]*pb.TicketLookup, 0, len(found)),
+	}
+	for _, f := range found {
+		resp.Tickets = append(resp.Tickets, &pb.TicketLookup{
+			Hash:    f.Ticket.CloneBytes(),
+			Address: f.MultiSigAddress,
+			Set:     f.Set,
+		})
+	}
+	return resp, nil
+}
+
+func (s *stakepooldServer) GetTicketAmounts(ctx context.Context, req *pb.GetTicketAmountsRequest) (*pb.GetTicketAmountsResponse, error) {
+	if len(req.Hashes) > maxTicketAmountsHashes {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"at most %d hashes may be requested", maxTicketAmountsHashes)
+	}
+
+	hashes := make([]*chainhash.Hash, 0, len(req.Hashes))
+	for _, b := range req.Hashes {
+		hash, err := chainhash.NewHash(b)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"invalid hash %x: %v", b, err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	amounts := s.stakepoold.GetTicketAmounts(ctx, hashes)
+	resp := &pb.GetTicketAmountsResponse{
+		Amounts: make([]*pb.TicketAmounts, 0, len(amounts)),
+	}
+	for hash, a := range amounts {
+		resp.Amounts = append(resp.Amounts, &pb.TicketAmounts{
+			Hash:        hash.CloneBytes(),
+			TicketPrice: a.TicketPrice,
+			Subsidy:     a.Subsidy,
+			PoolPayout:  a.PoolPayout,
+			UserPayout:  a.UserPayout,
+		})
+	}
+	return resp, nil
+}
+
 func (s *stakepooldServer) SetAddedLowFeeTickets(ctx context.Context, req *pb.SetAddedLowFeeTicketsRequest) (*pb.SetAddedLowFeeTicketsResponse, error) {
 	addedLowFeeTickets := make(map[chainhash.Hash]string)
 
@@ -139,7 +293,7 @@ func (s *stakepooldServer) SetUserVotingPrefs(ctx context.Context, req *pb.SetUs
 		}
 	}
 
-	s.stakepoold.UpdateUserData(userVotingPrefs)
+	s.stakepoold.UpdateUserData(userVotingPrefs, stakepool.UserDataFromDcrstakepool)
 	return &pb.SetUserVotingPrefsResponse{}, nil
 }
 
@@ -203,12 +357,7 @@ func (s *stakepooldServer) AddMissingTicket(ctx context.Context, req *pb.AddMiss
 	return &pb.AddMissingTicketResponse{}, nil
 }
 
-func (s *stakepooldServer) StakePoolUserInfo(ctx context.Context, req *pb.StakePoolUserInfoRequest) (*pb.StakePoolUserInfoResponse, error) {
-	response, err := s.stakepoold.StakePoolUserInfo(ctx, req.MultiSigAddress)
-	if err != nil {
-		return nil, err
-	}
-
+func processStakePoolUserInfo(response *wallettypes.StakePoolUserInfoResult) *pb.StakePoolUserInfoResponse {
 	tickets := make([]*pb.StakePoolUserTicket, 0, len(response.Tickets))
 	for _, t := range response.Tickets {
 		tickets = append(tickets, &pb.StakePoolUserTicket{
@@ -223,6 +372,91 @@ func (s *stakepooldServer) StakePoolUserInfo(ctx context.Context, req *pb.StakeP
 	return &pb.StakePoolUserInfoResponse{
 		Tickets:        tickets,
 		InvalidTickets: response.InvalidTickets,
+	}
+}
+
+func (s *stakepooldServer) StakePoolUserInfo(ctx context.Context, req *pb.StakePoolUserInfoRequest) (*pb.StakePoolUserInfoResponse, error) {
+	response, err := s.stakepoold.StakePoolUserInfo(ctx, req.MultiSigAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return processStakePoolUserInfo(response), nil
+}
+
+func (s *stakepooldServer) BatchStakePoolUserInfo(ctx context.Context, req *pb.BatchStakePoolUserInfoRequest) (*pb.BatchStakePoolUserInfoResponse, error) {
+	if len(req.MultiSigAddresses) > maxBatchStakePoolUserInfoAddrs {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"at most %d addresses may be requested",
+			maxBatchStakePoolUserInfoAddrs)
+	}
+
+	responses, errs := s.stakepoold.BatchStakePoolUserInfo(ctx, req.MultiSigAddresses)
+	results := make([]*pb.StakePoolUserInfoResult, 0, len(responses))
+	for i, msa := range req.MultiSigAddresses {
+		result := &pb.StakePoolUserInfoResult{MultiSigAddress: msa}
+		if errs[i] != nil {
+			result.Error = errs[i].Error()
+		} else {
+			result.Info = processStakePoolUserInfo(responses[i])
+		}
+		results = append(results, result)
+	}
+
+	return &pb.BatchStakePoolUserInfoResponse{Results: results}, nil
+}
+
+func (s *stakepooldServer) GetBestBlock(ctx context.Context, req *pb.GetBestBlockRequest) (*pb.GetBestBlockResponse, error) {
+	hash, height, err := s.stakepoold.GetBestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetBestBlockResponse{
+		Hash:   hash[:],
+		Height: height,
+	}, nil
+}
+
+func (s *stakepooldServer) GetChainParams(ctx context.Context, req *pb.GetChainParamsRequest) (*pb.GetChainParamsResponse, error) {
+	params := s.stakepoold.Params
+	feeMode := stakepool.FeeModeCommitment
+	if s.stakepoold.DeferredFees {
+		feeMode = stakepool.FeeModeDeferred
+	}
+	return &pb.GetChainParamsResponse{
+		NetName:     params.Name,
+		GenesisHash: params.GenesisHash[:],
+		PoolFees:    s.stakepoold.PoolFees,
+		FeeMode:     feeMode,
+	}, nil
+}
+
+func (s *stakepooldServer) SendRawTransaction(ctx context.Context, req *pb.SendRawTransactionRequest) (*pb.SendRawTransactionResponse, error) {
+	hash, err := s.stakepoold.SendRawTransaction(ctx, req.Tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.SendRawTransactionResponse{Hash: hash[:]}, nil
+}
+
+func (s *stakepooldServer) GetTransactionConfirmations(ctx context.Context, req *pb.GetTransactionConfirmationsRequest) (*pb.GetTransactionConfirmationsResponse, error) {
+	hash, err := chainhash.NewHash(req.Hash)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"invalid hash %x: %v", req.Hash, err)
+	}
+	confirmations, err := s.stakepoold.GetTransactionConfirmations(ctx, hash)
+	if err == stakepool.ErrTxNotFound {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetTransactionConfirmationsResponse{
+		Confirmations: confirmations,
 	}, nil
 }
 
@@ -232,11 +466,34 @@ func (s *stakepooldServer) WalletInfo(ctx context.Context, req *pb.WalletInfoReq
 		return nil, err
 	}
 
+	rescan := s.stakepoold.RescanStatus()
+	var rescanStarted int64
+	if rescan.Rescanning {
+		rescanStarted = rescan.Started.Unix()
+	}
+
+	// The time offset of the wallet is best effort, since its dcrd may be
+	// unreachable while the wallet is.
+	walletTimeOffset, err := s.stakepoold.WalletTimeOffset(ctx)
+	if err != nil {
+		log.Debugf("WalletInfo: unable to get wallet time offset: %v", err)
+	}
+
 	return &pb.WalletInfoResponse{
-		VoteVersion:     response.VoteVersion,
-		DaemonConnected: response.DaemonConnected,
-		Unlocked:        response.Unlocked,
-		Voting:          response.Voting,
+		VoteVersion:      response.VoteVersion,
+		DaemonConnected:  response.DaemonConnected,
+		Unlocked:         response.Unlocked,
+		Voting:           response.Voting,
+		Standby:          s.stakepoold.IsStandby(),
+		InvalidVoteBits:  s.stakepoold.InvalidVoteBits,
+		Rescanning:       rescan.Rescanning,
+		RescanFromHeight: rescan.FromHeight,
+		RescanStarted:    rescanStarted,
+		ServerTime:       time.Now().UnixNano(),
+		WalletTimeOffset: walletTimeOffset,
+		TxFee:            response.TxFee,
+		TicketFee:        response.TicketFee,
+		TicketPurchasing: response.TicketPurchasing,
 	}, nil
 }
 
@@ -294,3 +551,34 @@ func (s *stakepooldServer) GetColdWalletExtPub(ctx context.Context, req *pb.GetC
 		ColdWalletExtPub: s.stakepoold.ColdWalletExtPub,
 	}, nil
 }
+
+func (s *stakepooldServer) GetAccountExtPub(ctx context.Context, req *pb.GetAccountExtPubRequest) (*pb.GetAccountExtPubResponse, error) {
+	extPub, err := s.stakepoold.GetAccountExtPub(ctx, req.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetAccountExtPubResponse{ExtPub: extPub}, nil
+}
+
+func (s *stakepooldServer) ExistsAddress(ctx context.Context, req *pb.ExistsAddressRequest) (*pb.ExistsAddressResponse, error) {
+	exists, err := s.stakepoold.ExistsAddress(ctx, req.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ExistsAddressResponse{
+		Exists: exists,
+	}, nil
+}
+
+func (s *stakepooldServer) VerifyMessage(ctx context.Context, req *pb.VerifyMessageRequest) (*pb.VerifyMessageResponse, error) {
+	valid, err := s.stakepoold.VerifyMessage(ctx, req.Address, req.Message, req.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.VerifyMessageResponse{
+		Valid: valid,
+	}, nil
+}
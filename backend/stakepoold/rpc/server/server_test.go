@@ -0,0 +1,57 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/backend/stakepoold/stakepool"
+)
+
+func TestGetIgnoredLowFeeTicketsPage(t *testing.T) {
+	s := &stakepooldServer{stakepoold: &stakepool.Stakepoold{
+		IgnoredLowFeeTicketsMSA: map[chainhash.Hash]string{
+			{0x03}: "c",
+			{0x01}: "a",
+			{0x02}: "b",
+		},
+	}}
+	ctx := context.Background()
+
+	// Without a limit all tickets are returned, as before paging.
+	resp, err := s.GetIgnoredLowFeeTickets(ctx, &pb.GetIgnoredLowFeeTicketsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Tickets) != 3 || resp.Total != 3 {
+		t.Fatalf("got %d of %d tickets", len(resp.Tickets), resp.Total)
+	}
+
+	resp, err = s.GetIgnoredLowFeeTickets(ctx, &pb.GetIgnoredLowFeeTicketsRequest{
+		Offset: 1,
+		Limit:  5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Tickets) != 2 || resp.Total != 3 ||
+		resp.Tickets[0].Address != "b" || resp.Tickets[1].Address != "c" {
+		t.Fatalf("unexpected page %v", resp)
+	}
+
+	resp, err = s.GetIgnoredLowFeeTickets(ctx, &pb.GetIgnoredLowFeeTicketsRequest{
+		CountOnly: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Tickets) != 0 || resp.Total != 3 {
+		t.Fatalf("got %d of %d tickets for the count only",
+			len(resp.Tickets), resp.Total)
+	}
+}
@@ -95,6 +95,9 @@ func (m *GetAddedLowFeeTicketsResponse) GetTickets() []*Ticket {
 }
 
 type GetIgnoredLowFeeTicketsRequest struct {
+	Offset               uint32   `protobuf:"varint,1,opt,name=Offset,proto3" json:"Offset,omitempty"`
+	Limit                uint32   `protobuf:"varint,2,opt,name=Limit,proto3" json:"Limit,omitempty"`
+	CountOnly            bool     `protobuf:"varint,3,opt,name=CountOnly,proto3" json:"CountOnly,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -125,8 +128,30 @@ func (m *GetIgnoredLowFeeTicketsRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_GetIgnoredLowFeeTicketsRequest proto.InternalMessageInfo
 
+func (m *GetIgnoredLowFeeTicketsRequest) GetOffset() uint32 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *GetIgnoredLowFeeTicketsRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *GetIgnoredLowFeeTicketsRequest) GetCountOnly() bool {
+	if m != nil {
+		return m.CountOnly
+	}
+	return false
+}
+
 type GetIgnoredLowFeeTicketsResponse struct {
 	Tickets              []*Ticket `protobuf:"bytes,1,rep,name=tickets,proto3" json:"tickets,omitempty"`
+	Total                uint32    `protobuf:"varint,2,opt,name=Total,proto3" json:"Total,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
 	XXX_unrecognized     []byte    `json:"-"`
 	XXX_sizecache        int32     `json:"-"`
@@ -164,6 +189,13 @@ func (m *GetIgnoredLowFeeTicketsResponse) GetTickets() []*Ticket {
 	return nil
 }
 
+func (m *GetIgnoredLowFeeTicketsResponse) GetTotal() uint32 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
 type GetLiveTicketsRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -956,6 +988,16 @@ type WalletInfoResponse struct {
 	DaemonConnected      bool     `protobuf:"varint,2,opt,name=DaemonConnected,proto3" json:"DaemonConnected,omitempty"`
 	Unlocked             bool     `protobuf:"varint,3,opt,name=Unlocked,proto3" json:"Unlocked,omitempty"`
 	Voting               bool     `protobuf:"varint,4,opt,name=Voting,proto3" json:"Voting,omitempty"`
+	Standby              bool     `protobuf:"varint,5,opt,name=Standby,proto3" json:"Standby,omitempty"`
+	InvalidVoteBits      uint32   `protobuf:"varint,6,opt,name=InvalidVoteBits,proto3" json:"InvalidVoteBits,omitempty"`
+	Rescanning           bool     `protobuf:"varint,7,opt,name=Rescanning,proto3" json:"Rescanning,omitempty"`
+	RescanFromHeight     int64    `protobuf:"varint,8,opt,name=RescanFromHeight,proto3" json:"RescanFromHeight,omitempty"`
+	RescanStarted        int64    `protobuf:"varint,9,opt,name=RescanStarted,proto3" json:"RescanStarted,omitempty"`
+	ServerTime           int64    `protobuf:"varint,10,opt,name=ServerTime,proto3" json:"ServerTime,omitempty"`
+	WalletTimeOffset     int64    `protobuf:"varint,11,opt,name=WalletTimeOffset,proto3" json:"WalletTimeOffset,omitempty"`
+	TxFee                float64  `protobuf:"fixed64,12,opt,name=TxFee,proto3" json:"TxFee,omitempty"`
+	TicketFee            float64  `protobuf:"fixed64,13,opt,name=TicketFee,proto3" json:"TicketFee,omitempty"`
+	TicketPurchasing     bool     `protobuf:"varint,14,opt,name=TicketPurchasing,proto3" json:"TicketPurchasing,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -1014,6 +1056,76 @@ func (m *WalletInfoResponse) GetVoting() bool {
 	return false
 }
 
+func (m *WalletInfoResponse) GetStandby() bool {
+	if m != nil {
+		return m.Standby
+	}
+	return false
+}
+
+func (m *WalletInfoResponse) GetInvalidVoteBits() uint32 {
+	if m != nil {
+		return m.InvalidVoteBits
+	}
+	return 0
+}
+
+func (m *WalletInfoResponse) GetRescanning() bool {
+	if m != nil {
+		return m.Rescanning
+	}
+	return false
+}
+
+func (m *WalletInfoResponse) GetRescanFromHeight() int64 {
+	if m != nil {
+		return m.RescanFromHeight
+	}
+	return 0
+}
+
+func (m *WalletInfoResponse) GetRescanStarted() int64 {
+	if m != nil {
+		return m.RescanStarted
+	}
+	return 0
+}
+
+func (m *WalletInfoResponse) GetServerTime() int64 {
+	if m != nil {
+		return m.ServerTime
+	}
+	return 0
+}
+
+func (m *WalletInfoResponse) GetWalletTimeOffset() int64 {
+	if m != nil {
+		return m.WalletTimeOffset
+	}
+	return 0
+}
+
+func (m *WalletInfoResponse) GetTxFee() float64 {
+	if m != nil {
+		return m.TxFee
+	}
+	return 0
+}
+
+func (m *WalletInfoResponse) GetTicketFee() float64 {
+	if m != nil {
+		return m.TicketFee
+	}
+	return 0
+}
+
+func (m *WalletInfoResponse) GetTicketPurchasing() bool {
+	if m != nil {
+		return m.TicketPurchasing
+	}
+	return false
+}
+
 type ValidateAddressRequest struct {
 	Address              string   `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -1405,6 +1517,7 @@ type VersionResponse struct {
 	Patch                uint32   `protobuf:"varint,4,opt,name=patch,proto3" json:"patch,omitempty"`
 	Prerelease           string   `protobuf:"bytes,5,opt,name=prerelease,proto3" json:"prerelease,omitempty"`
 	BuildMetadata        string   `protobuf:"bytes,6,opt,name=build_metadata,json=buildMetadata,proto3" json:"build_metadata,omitempty"`
+	MaxMessageSize       uint32   `protobuf:"varint,7,opt,name=max_message_size,json=maxMessageSize,proto3" json:"max_message_size,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -1477,6 +1590,13 @@ func (m *VersionResponse) GetBuildMetadata() string {
 	return ""
 }
 
+func (m *VersionResponse) GetMaxMessageSize() uint32 {
+	if m != nil {
+		return m.MaxMessageSize
+	}
+	return 0
+}
+
 type GetStakeInfoRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -1737,278 +1857,1973 @@ func (m *GetColdWalletExtPubResponse) GetColdWalletExtPub() string {
 	return ""
 }
 
-func init() {
-	proto.RegisterType((*GetAddedLowFeeTicketsRequest)(nil), "stakepoolrpc.GetAddedLowFeeTicketsRequest")
-	proto.RegisterType((*GetAddedLowFeeTicketsResponse)(nil), "stakepoolrpc.GetAddedLowFeeTicketsResponse")
-	proto.RegisterType((*GetIgnoredLowFeeTicketsRequest)(nil), "stakepoolrpc.GetIgnoredLowFeeTicketsRequest")
-	proto.RegisterType((*GetIgnoredLowFeeTicketsResponse)(nil), "stakepoolrpc.GetIgnoredLowFeeTicketsResponse")
-	proto.RegisterType((*GetLiveTicketsRequest)(nil), "stakepoolrpc.GetLiveTicketsRequest")
-	proto.RegisterType((*GetLiveTicketsResponse)(nil), "stakepoolrpc.GetLiveTicketsResponse")
-	proto.RegisterType((*SetAddedLowFeeTicketsRequest)(nil), "stakepoolrpc.SetAddedLowFeeTicketsRequest")
-	proto.RegisterType((*SetAddedLowFeeTicketsResponse)(nil), "stakepoolrpc.SetAddedLowFeeTicketsResponse")
-	proto.RegisterType((*SetUserVotingPrefsResponse)(nil), "stakepoolrpc.SetUserVotingPrefsResponse")
-	proto.RegisterType((*SetUserVotingPrefsRequest)(nil), "stakepoolrpc.SetUserVotingPrefsRequest")
-	proto.RegisterType((*AddMissingTicketRequest)(nil), "stakepoolrpc.AddMissingTicketRequest")
-	proto.RegisterType((*AddMissingTicketResponse)(nil), "stakepoolrpc.AddMissingTicketResponse")
-	proto.RegisterType((*GetTicketsRequest)(nil), "stakepoolrpc.GetTicketsRequest")
-	proto.RegisterType((*GetTicketsResponse)(nil), "stakepoolrpc.GetTicketsResponse")
-	proto.RegisterType((*ListImportedAddressesRequest)(nil), "stakepoolrpc.ListImportedAddressesRequest")
-	proto.RegisterType((*ListImportedAddressesResponse)(nil), "stakepoolrpc.ListImportedAddressesResponse")
-	proto.RegisterType((*AccountSyncAddressIndexRequest)(nil), "stakepoolrpc.AccountSyncAddressIndexRequest")
-	proto.RegisterType((*AccountSyncAddressIndexResponse)(nil), "stakepoolrpc.AccountSyncAddressIndexResponse")
-	proto.RegisterType((*ImportMissingScriptsRequest)(nil), "stakepoolrpc.ImportMissingScriptsRequest")
-	proto.RegisterType((*ImportMissingScriptsResponse)(nil), "stakepoolrpc.ImportMissingScriptsResponse")
-	proto.RegisterType((*ImportNewScriptRequest)(nil), "stakepoolrpc.ImportNewScriptRequest")
-	proto.RegisterType((*ImportNewScriptResponse)(nil), "stakepoolrpc.ImportNewScriptResponse")
-	proto.RegisterType((*StakePoolUserInfoRequest)(nil), "stakepoolrpc.StakePoolUserInfoRequest")
-	proto.RegisterType((*StakePoolUserInfoResponse)(nil), "stakepoolrpc.StakePoolUserInfoResponse")
-	proto.RegisterType((*WalletInfoRequest)(nil), "stakepoolrpc.WalletInfoRequest")
-	proto.RegisterType((*WalletInfoResponse)(nil), "stakepoolrpc.WalletInfoResponse")
-	proto.RegisterType((*ValidateAddressRequest)(nil), "stakepoolrpc.ValidateAddressRequest")
-	proto.RegisterType((*ValidateAddressResponse)(nil), "stakepoolrpc.ValidateAddressResponse")
-	proto.RegisterType((*CreateMultisigRequest)(nil), "stakepoolrpc.CreateMultisigRequest")
-	proto.RegisterType((*CreateMultisigResponse)(nil), "stakepoolrpc.CreateMultisigResponse")
-	proto.RegisterType((*StakePoolUserTicket)(nil), "stakepoolrpc.StakePoolUserTicket")
-	proto.RegisterType((*Ticket)(nil), "stakepoolrpc.Ticket")
-	proto.RegisterType((*UserVotingConfigEntry)(nil), "stakepoolrpc.UserVotingConfigEntry")
-	proto.RegisterType((*VersionRequest)(nil), "stakepoolrpc.VersionRequest")
-	proto.RegisterType((*VersionResponse)(nil), "stakepoolrpc.VersionResponse")
-	proto.RegisterType((*GetStakeInfoRequest)(nil), "stakepoolrpc.GetStakeInfoRequest")
-	proto.RegisterType((*GetStakeInfoResponse)(nil), "stakepoolrpc.GetStakeInfoResponse")
-	proto.RegisterType((*GetColdWalletExtPubRequest)(nil), "stakepoolrpc.GetColdWalletExtPubRequest")
-	proto.RegisterType((*GetColdWalletExtPubResponse)(nil), "stakepoolrpc.GetColdWalletExtPubResponse")
+type ExistsAddressRequest struct {
+	Address              string   `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func init() {
-	proto.RegisterFile("api.proto", fileDescriptor_00212fb1f9d3bf1c)
+func (m *ExistsAddressRequest) Reset()         { *m = ExistsAddressRequest{} }
+func (m *ExistsAddressRequest) String() string { return proto.CompactTextString(m) }
+func (*ExistsAddressRequest) ProtoMessage()    {}
+func (*ExistsAddressRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{39}
 }
 
-var fileDescriptor_00212fb1f9d3bf1c = []byte{
-	// 1536 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x58, 0x4f, 0x53, 0xdc, 0xc6,
-	0x12, 0xaf, 0x05, 0x0c, 0x6c, 0xc3, 0x02, 0x96, 0x61, 0x91, 0x65, 0xfe, 0xac, 0xe5, 0x7f, 0x6b,
-	0xde, 0x33, 0xf5, 0x1e, 0xaf, 0xea, 0x5d, 0x52, 0x3e, 0x00, 0xb6, 0xf1, 0x56, 0x8c, 0x8d, 0x25,
-	0x43, 0x52, 0xe5, 0xaa, 0x50, 0x42, 0x1a, 0x96, 0xb1, 0xb5, 0x92, 0x22, 0xcd, 0xae, 0x21, 0xa7,
-	0x7c, 0x80, 0x1c, 0x73, 0xcf, 0x39, 0x1f, 0x23, 0x1f, 0x23, 0xdf, 0x26, 0x35, 0x33, 0xad, 0x5d,
-	0x69, 0x24, 0x2d, 0xd8, 0x37, 0xf5, 0x6f, 0x7a, 0x7a, 0xba, 0x7b, 0xba, 0x7b, 0xba, 0x05, 0x75,
-	0x27, 0xa2, 0xdb, 0x51, 0x1c, 0xb2, 0x50, 0x9b, 0x4f, 0x98, 0xf3, 0x99, 0x44, 0x61, 0xe8, 0xc7,
-	0x91, 0x6b, 0x6e, 0xc0, 0xda, 0x01, 0x61, 0xbb, 0x9e, 0x47, 0xbc, 0x37, 0xe1, 0x97, 0x57, 0x84,
-	0x7c, 0xa0, 0xee, 0x67, 0xc2, 0x12, 0x8b, 0xfc, 0xdc, 0x27, 0x09, 0x33, 0xdf, 0xc1, 0x7a, 0xc5,
-	0x7a, 0x12, 0x85, 0x41, 0x42, 0xb4, 0x6d, 0x98, 0x61, 0x12, 0xd2, 0x6b, 0xad, 0xc9, 0xf6, 0xdc,
-	0xce, 0xf2, 0x76, 0xf6, 0x80, 0x6d, 0xc9, 0x6f, 0xa5, 0x4c, 0x66, 0x0b, 0x36, 0x0e, 0x08, 0xeb,
-	0x74, 0x83, 0x30, 0xae, 0x38, 0xf2, 0x3d, 0x6c, 0x56, 0x72, 0x7c, 0xe3, 0xa1, 0xab, 0xb0, 0x72,
-	0x40, 0xd8, 0x1b, 0x3a, 0x50, 0xcf, 0x7a, 0x0d, 0x4d, 0x75, 0xe1, 0x1b, 0x8f, 0x78, 0x0b, 0x6b,
-	0xf6, 0x18, 0x47, 0x7e, 0xb5, 0xbc, 0x4d, 0x58, 0xb7, 0xc7, 0x39, 0xde, 0x5c, 0x03, 0xc3, 0x26,
-	0xec, 0x38, 0x21, 0xf1, 0x49, 0xc8, 0x68, 0xd0, 0x3d, 0x8a, 0xc9, 0xf9, 0x68, 0x35, 0x80, 0xbb,
-	0x65, 0xab, 0x52, 0x97, 0xf7, 0xa0, 0xf5, 0x13, 0x12, 0x9f, 0x0e, 0xc4, 0xd2, 0xa9, 0x1b, 0x06,
-	0xe7, 0xb4, 0x8b, 0x6a, 0x3d, 0xc8, 0xab, 0x35, 0x92, 0xb0, 0x2f, 0xb8, 0x5e, 0x06, 0x2c, 0xbe,
-	0xb2, 0x96, 0xfa, 0x0a, 0x6c, 0x3e, 0x83, 0xd5, 0x5d, 0xcf, 0x3b, 0xa4, 0x49, 0x42, 0x83, 0x2e,
-	0xda, 0x82, 0xa7, 0x69, 0x30, 0xf5, 0xda, 0x49, 0x2e, 0xf4, 0x5a, 0xab, 0xd6, 0x9e, 0xb7, 0xc4,
-	0xb7, 0x69, 0x80, 0x5e, 0x64, 0x47, 0xd5, 0x9f, 0xc3, 0xed, 0x03, 0xc2, 0x14, 0xf7, 0xb5, 0x61,
-	0xb1, 0x13, 0xb8, 0x7e, 0xdf, 0x23, 0x9d, 0x5e, 0xcf, 0x61, 0xfd, 0x98, 0x08, 0x79, 0xb3, 0x96,
-	0x0a, 0x9b, 0xdb, 0xa0, 0x65, 0xb7, 0xe3, 0x75, 0xea, 0x30, 0xf3, 0x21, 0xe3, 0xfe, 0x79, 0x2b,
-	0x25, 0x79, 0x06, 0xbc, 0xa1, 0x09, 0xeb, 0xf4, 0xa2, 0x30, 0x66, 0xc4, 0xdb, 0xf5, 0xbc, 0x98,
-	0x24, 0x09, 0x19, 0x86, 0xc8, 0x73, 0x58, 0xaf, 0x58, 0x47, 0xd1, 0x6b, 0x50, 0x1f, 0x82, 0x42,
-	0x78, 0xdd, 0x1a, 0x01, 0xe6, 0x05, 0x6c, 0xec, 0xba, 0x6e, 0xd8, 0x0f, 0x98, 0x7d, 0x15, 0xb8,
-	0x88, 0x77, 0x02, 0x8f, 0x5c, 0xa6, 0xa6, 0xe9, 0x30, 0x83, 0x1c, 0xc2, 0xa4, 0xba, 0x95, 0x92,
-	0x5a, 0x13, 0xa6, 0xf7, 0x62, 0x27, 0x70, 0x2f, 0xf4, 0x89, 0x56, 0xad, 0xdd, 0xb0, 0x90, 0xd2,
-	0x96, 0xe1, 0x96, 0x90, 0xa0, 0x4f, 0xb6, 0x6a, 0xed, 0x49, 0x4b, 0x12, 0xe6, 0x7d, 0xd8, 0xac,
-	0x3c, 0x09, 0x5d, 0xfb, 0x11, 0xee, 0x49, 0x3b, 0xd0, 0xf3, 0xb6, 0x1b, 0xd3, 0x68, 0xe4, 0x64,
-	0x1d, 0x66, 0x10, 0x49, 0x9d, 0x84, 0xa4, 0x66, 0xc2, 0xbc, 0x45, 0x12, 0xd7, 0x09, 0x5e, 0x13,
-	0xda, 0xbd, 0x60, 0x42, 0x9f, 0x49, 0x2b, 0x87, 0x71, 0x47, 0x96, 0x0b, 0xc7, 0xc3, 0xff, 0x03,
-	0x4d, 0xb9, 0xfe, 0x96, 0x7c, 0x91, 0x6b, 0xe9, 0xb9, 0x4d, 0x98, 0x96, 0x00, 0xc6, 0x08, 0x52,
-	0xe6, 0x2e, 0xac, 0x16, 0x76, 0xa0, 0xd3, 0x1f, 0xc3, 0x82, 0x3c, 0x36, 0xbd, 0x17, 0xb1, 0x75,
-	0xd2, 0x52, 0x50, 0xf3, 0x05, 0xe8, 0x36, 0x8f, 0xe7, 0xa3, 0x30, 0xf4, 0x79, 0x2c, 0x77, 0x82,
-	0xf3, 0x30, 0x13, 0x53, 0x87, 0x7d, 0x9f, 0x51, 0x9b, 0x76, 0xd1, 0x5b, 0x78, 0x01, 0x2a, 0x6c,
-	0xfe, 0x5a, 0x83, 0xbb, 0x25, 0x62, 0x50, 0x97, 0xef, 0xf2, 0xb1, 0x35, 0xb7, 0x73, 0x3f, 0x9f,
-	0x43, 0xb9, 0x9d, 0x69, 0x9e, 0xe3, 0x0e, 0x6e, 0x48, 0x27, 0x18, 0x38, 0x3e, 0xf5, 0x52, 0x19,
-	0x13, 0x22, 0x84, 0x14, 0xd4, 0xbc, 0x03, 0xb7, 0x7f, 0x70, 0x7c, 0x9f, 0xb0, 0x8c, 0x05, 0xe6,
-	0xef, 0x35, 0xd0, 0xb2, 0x28, 0x2a, 0xd4, 0x82, 0xb9, 0x93, 0x90, 0x91, 0x13, 0x12, 0x27, 0x34,
-	0x0c, 0x84, 0x51, 0x0d, 0x2b, 0x0b, 0x71, 0xd3, 0x5f, 0x38, 0xa4, 0x17, 0x06, 0xfb, 0x61, 0x10,
-	0x10, 0x97, 0xfb, 0x6f, 0x42, 0xa6, 0x93, 0x02, 0x6b, 0x06, 0xcc, 0x1e, 0x07, 0x7e, 0xe8, 0x7e,
-	0x26, 0x9e, 0x08, 0xb7, 0x59, 0x6b, 0x48, 0xf3, 0x7b, 0x93, 0x45, 0x40, 0x9f, 0x12, 0x2b, 0x48,
-	0x99, 0x3b, 0xd0, 0x3c, 0xe1, 0xba, 0x3b, 0x8c, 0xa0, 0x07, 0xb3, 0xb1, 0x9e, 0x73, 0x75, 0x4a,
-	0x9a, 0xef, 0x61, 0xb5, 0xb0, 0x07, 0xcd, 0x69, 0xc2, 0x74, 0x27, 0x39, 0xa4, 0x41, 0x9a, 0xf2,
-	0x48, 0x69, 0x1b, 0x00, 0x47, 0xfd, 0xb3, 0xef, 0xc9, 0x15, 0xdf, 0x20, 0xf4, 0xaf, 0x5b, 0x19,
-	0xc4, 0xfc, 0x2f, 0xac, 0xec, 0xc7, 0xc4, 0x61, 0x44, 0x5c, 0x67, 0x42, 0xbb, 0xa5, 0x5a, 0x4c,
-	0x66, 0xb5, 0x38, 0x81, 0xa6, 0xba, 0x05, 0x95, 0x10, 0x19, 0xe0, 0x11, 0xd2, 0xcb, 0x44, 0x6a,
-	0xdd, 0xca, 0x61, 0x59, 0xb9, 0x13, 0x79, 0xeb, 0xfe, 0xac, 0xc1, 0x9d, 0x92, 0x30, 0x10, 0x91,
-	0xcf, 0x1c, 0xd6, 0x4f, 0xdd, 0x81, 0x14, 0xc7, 0x25, 0x07, 0x0a, 0x42, 0x8a, 0x6b, 0x21, 0xbf,
-	0x30, 0x0f, 0x27, 0xc5, 0xd5, 0xe6, 0x30, 0x91, 0xc5, 0x11, 0x09, 0xd8, 0xde, 0x95, 0xb8, 0x96,
-	0xba, 0x95, 0x92, 0xda, 0x43, 0x68, 0xe0, 0x27, 0x6e, 0xbf, 0x25, 0xb6, 0xe7, 0x41, 0xf3, 0xff,
-	0xe9, 0xd9, 0xd5, 0xb7, 0x35, 0xac, 0xe9, 0x13, 0x99, 0x9a, 0xfe, 0x47, 0x0d, 0x56, 0x4a, 0x9f,
-	0x0b, 0x6e, 0x8d, 0x48, 0x9a, 0x34, 0x49, 0x91, 0x2a, 0x4b, 0xc0, 0x89, 0xd2, 0x04, 0xe4, 0x51,
-	0xc8, 0xc3, 0x77, 0x8f, 0xb2, 0x04, 0x8b, 0xde, 0x90, 0xe6, 0x52, 0xd2, 0xef, 0x34, 0xe2, 0xa7,
-	0x04, 0x8b, 0x0a, 0x9b, 0x4b, 0xb0, 0x80, 0x9f, 0x69, 0x02, 0xfd, 0x55, 0x83, 0xc5, 0x21, 0x84,
-	0x37, 0xfd, 0x08, 0x16, 0x06, 0x12, 0x3a, 0x4d, 0x58, 0xcc, 0xa3, 0x5b, 0x1a, 0xdf, 0x40, 0xd4,
-	0x16, 0x20, 0x2f, 0xc2, 0x3d, 0xe7, 0x53, 0x18, 0x63, 0x6d, 0x96, 0x84, 0x40, 0x69, 0x10, 0xc6,
-	0x78, 0x33, 0x92, 0xe0, 0x68, 0xe4, 0x30, 0xf7, 0x42, 0x28, 0xd6, 0xb0, 0x24, 0xc1, 0xe3, 0x37,
-	0x8a, 0x49, 0x4c, 0x7c, 0xe2, 0x24, 0x44, 0xdc, 0x45, 0xdd, 0xca, 0x20, 0x5c, 0x91, 0xb3, 0x3e,
-	0xf5, 0xbd, 0xd3, 0x1e, 0x61, 0x8e, 0xe7, 0x30, 0x47, 0x9f, 0x96, 0x8a, 0x08, 0xf4, 0x10, 0x41,
-	0x73, 0x05, 0xee, 0x1c, 0x10, 0x26, 0xa2, 0x2b, 0x5b, 0x1b, 0x7e, 0x9b, 0x82, 0xe5, 0x3c, 0x3e,
-	0xaa, 0x0e, 0x7b, 0x3c, 0x81, 0x31, 0x06, 0xe4, 0x95, 0x64, 0x21, 0xae, 0xd8, 0x0b, 0x7a, 0x7e,
-	0x4e, 0xdd, 0xbe, 0xcf, 0xae, 0x84, 0x7d, 0x35, 0x2b, 0x83, 0x88, 0x28, 0x0c, 0x99, 0xe3, 0xdb,
-	0xfd, 0xb3, 0x84, 0x7a, 0x57, 0xc2, 0xd6, 0x9a, 0x95, 0xc3, 0x78, 0xac, 0xbd, 0xfb, 0x12, 0x1c,
-	0x92, 0x1e, 0xaf, 0x82, 0x1f, 0xe8, 0x25, 0x9a, 0x9e, 0x07, 0xf9, 0xbd, 0x0e, 0xdf, 0x73, 0x19,
-	0x8c, 0x43, 0x9a, 0x47, 0xdf, 0x71, 0x90, 0xf0, 0xd0, 0x14, 0x76, 0x37, 0xac, 0x94, 0xe4, 0xee,
-	0xe4, 0x57, 0xeb, 0xe9, 0x33, 0xd2, 0x9d, 0x82, 0xe0, 0xfc, 0x16, 0x19, 0x84, 0xbc, 0x50, 0xcd,
-	0x4a, 0x7e, 0x24, 0x79, 0x8d, 0xc5, 0xad, 0x2f, 0x2f, 0x23, 0x1a, 0x13, 0x4f, 0xaf, 0x0b, 0x06,
-	0x05, 0xe5, 0xda, 0xf0, 0xfc, 0xb4, 0xe9, 0x2f, 0x44, 0x07, 0xa9, 0x4d, 0x4a, 0x73, 0x7b, 0x76,
-	0x7d, 0x3f, 0x63, 0xcf, 0x9c, 0xb4, 0x27, 0x07, 0xf2, 0xbc, 0xe0, 0xcd, 0xa4, 0x3e, 0x2f, 0x16,
-	0xc5, 0x37, 0x3f, 0xfd, 0x28, 0x0e, 0xf9, 0x7b, 0x44, 0xc3, 0x40, 0xac, 0x36, 0x84, 0xbf, 0x14,
-	0x94, 0x67, 0x09, 0x7f, 0x39, 0x89, 0xa7, 0x2f, 0xc8, 0xd7, 0x5e, 0x52, 0xda, 0x16, 0x2c, 0x8d,
-	0x38, 0x91, 0x63, 0x51, 0x48, 0x28, 0xe0, 0xdc, 0x07, 0xa9, 0x89, 0x4b, 0xd2, 0x07, 0x48, 0xf2,
-	0x76, 0xf1, 0x80, 0xb0, 0xfd, 0xd0, 0xf7, 0xe4, 0x83, 0xf1, 0xf2, 0x92, 0x1d, 0xf5, 0xcf, 0xd2,
-	0x60, 0xe9, 0xc0, 0xbd, 0xd2, 0x55, 0x0c, 0x99, 0x2d, 0x58, 0x52, 0xd7, 0x30, 0x29, 0x0a, 0xf8,
-	0xce, 0xdf, 0x0d, 0xb8, 0x6d, 0xa7, 0xcf, 0x9f, 0x67, 0x93, 0x78, 0x40, 0x5d, 0xa2, 0x45, 0xa2,
-	0x03, 0x2f, 0xb6, 0xb3, 0xda, 0x56, 0xfe, 0xad, 0x1c, 0x37, 0x8c, 0x18, 0xff, 0xba, 0x11, 0x2f,
-	0xea, 0x3c, 0x80, 0xd5, 0x8a, 0x31, 0x42, 0xfb, 0x77, 0x41, 0xce, 0x98, 0x79, 0xc4, 0x78, 0x76,
-	0x43, 0x6e, 0x3c, 0xf7, 0x23, 0x2c, 0xe4, 0x47, 0x0a, 0xed, 0x41, 0x41, 0x40, 0x71, 0x12, 0x31,
-	0x1e, 0x8e, 0x67, 0x42, 0xe1, 0x11, 0xac, 0xd8, 0x37, 0x71, 0xa3, 0xfd, 0x15, 0x6e, 0x1c, 0x3b,
-	0x66, 0x68, 0x5d, 0xd0, 0x8a, 0x83, 0x84, 0xf6, 0xa4, 0x20, 0xa2, 0x7c, 0xd4, 0x30, 0xda, 0xd7,
-	0x33, 0xe2, 0x41, 0x3f, 0xc1, 0xa2, 0xd2, 0xec, 0x69, 0x8a, 0x4f, 0xca, 0xbb, 0x47, 0xe3, 0xd1,
-	0x35, 0x5c, 0x28, 0xbf, 0x07, 0xcb, 0x65, 0xed, 0xa9, 0xf6, 0xb4, 0x6c, 0x7b, 0x69, 0x7f, 0x6c,
-	0x6c, 0xdd, 0x84, 0x15, 0x8f, 0xf3, 0x30, 0x0b, 0xb2, 0x1d, 0xa3, 0xf6, 0x78, 0x4c, 0x63, 0x98,
-	0xa9, 0xdd, 0xc6, 0x93, 0x6b, 0xf9, 0xf0, 0x94, 0x77, 0x00, 0xa3, 0xfe, 0x4f, 0xdb, 0xcc, 0x6f,
-	0x2b, 0xf4, 0x8b, 0x46, 0xab, 0x9a, 0x61, 0x74, 0x0b, 0x4a, 0x1b, 0xa6, 0xde, 0x42, 0x79, 0x67,
-	0xa7, 0xde, 0x42, 0x55, 0x2f, 0xe7, 0xc0, 0x92, 0x3a, 0xf8, 0x69, 0xca, 0xd6, 0x8a, 0x39, 0xd2,
-	0x78, 0x7c, 0x1d, 0xdb, 0xc8, 0x27, 0xa3, 0x01, 0x50, 0xf5, 0x49, 0x61, 0xb2, 0x54, 0x7d, 0x52,
-	0x32, 0x3b, 0x46, 0xb0, 0x52, 0x3a, 0x01, 0xaa, 0x49, 0x37, 0x6e, 0x8c, 0x54, 0x93, 0x6e, 0xfc,
-	0x48, 0x39, 0x80, 0xd5, 0x8a, 0x51, 0x4e, 0xad, 0x5d, 0xe3, 0x67, 0x4b, 0xb5, 0x76, 0x5d, 0x33,
-	0x1f, 0xf2, 0xda, 0x95, 0x6f, 0x7f, 0xd5, 0xda, 0x55, 0xda, 0x4f, 0xab, 0xb5, 0xab, 0xa2, 0x83,
-	0x3e, 0x86, 0xf9, 0x6c, 0x3f, 0xa2, 0xdd, 0x2f, 0x38, 0x5e, 0xed, 0x61, 0x0c, 0x73, 0x1c, 0x0b,
-	0x8a, 0xfd, 0x24, 0xda, 0x1f, 0xf5, 0x19, 0xd2, 0xda, 0x85, 0xad, 0x15, 0x6f, 0x9f, 0xf1, 0xf4,
-	0x06, 0x9c, 0xf2, 0xac, 0x9d, 0x1f, 0x87, 0x0d, 0x64, 0xfa, 0xae, 0xbd, 0x82, 0x99, 0x74, 0xa6,
-	0x5a, 0x53, 0x32, 0x20, 0xd7, 0x69, 0x1a, 0xeb, 0x15, 0xab, 0x52, 0xf2, 0xd9, 0xb4, 0xf8, 0x39,
-	0xf7, 0xbf, 0x7f, 0x02, 0x00, 0x00, 0xff, 0xff, 0x5a, 0xda, 0xf9, 0x84, 0xa9, 0x13, 0x00, 0x00,
+func (m *ExistsAddressRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExistsAddressRequest.Unmarshal(m, b)
+}
+func (m *ExistsAddressRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExistsAddressRequest.Marshal(b, m, deterministic)
+}
+func (m *ExistsAddressRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExistsAddressRequest.Merge(m, src)
+}
+func (m *ExistsAddressRequest) XXX_Size() int {
+	return xxx_messageInfo_ExistsAddressRequest.Size(m)
+}
+func (m *ExistsAddressRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExistsAddressRequest.DiscardUnknown(m)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConnInterface
+var xxx_messageInfo_ExistsAddressRequest proto.InternalMessageInfo
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion6
+func (m *ExistsAddressRequest) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
 
-// StakepooldServiceClient is the client API for StakepooldService service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type StakepooldServiceClient interface {
-	GetAddedLowFeeTickets(ctx context.Context, in *GetAddedLowFeeTicketsRequest, opts ...grpc.CallOption) (*GetAddedLowFeeTicketsResponse, error)
-	GetIgnoredLowFeeTickets(ctx context.Context, in *GetIgnoredLowFeeTicketsRequest, opts ...grpc.CallOption) (*GetIgnoredLowFeeTicketsResponse, error)
-	GetLiveTickets(ctx context.Context, in *GetLiveTicketsRequest, opts ...grpc.CallOption) (*GetLiveTicketsResponse, error)
-	SetAddedLowFeeTickets(ctx context.Context, in *SetAddedLowFeeTicketsRequest, opts ...grpc.CallOption) (*SetAddedLowFeeTicketsResponse, error)
-	SetUserVotingPrefs(ctx context.Context, in *SetUserVotingPrefsRequest, opts ...grpc.CallOption) (*SetUserVotingPrefsResponse, error)
-	ImportNewScript(ctx context.Context, in *ImportNewScriptRequest, opts ...grpc.CallOption) (*ImportNewScriptResponse, error)
-	ImportMissingScripts(ctx context.Context, in *ImportMissingScriptsRequest, opts ...grpc.CallOption) (*ImportMissingScriptsResponse, error)
-	StakePoolUserInfo(ctx context.Context, in *StakePoolUserInfoRequest, opts ...grpc.CallOption) (*StakePoolUserInfoResponse, error)
-	WalletInfo(ctx context.Context, in *WalletInfoRequest, opts ...grpc.CallOption) (*WalletInfoResponse, error)
-	ValidateAddress(ctx context.Context, in *ValidateAddressRequest, opts ...grpc.CallOption) (*ValidateAddressResponse, error)
-	AddMissingTicket(ctx context.Context, in *AddMissingTicketRequest, opts ...grpc.CallOption) (*AddMissingTicketResponse, error)
-	GetTickets(ctx context.Context, in *GetTicketsRequest, opts ...grpc.CallOption) (*GetTicketsResponse, error)
-	ListImportedAddresses(ctx context.Context, in *ListImportedAddressesRequest, opts ...grpc.CallOption) (*ListImportedAddressesResponse, error)
-	AccountSyncAddressIndex(ctx context.Context, in *AccountSyncAddressIndexRequest, opts ...grpc.CallOption) (*AccountSyncAddressIndexResponse, error)
-	CreateMultisig(ctx context.Context, in *CreateMultisigRequest, opts ...grpc.CallOption) (*CreateMultisigResponse, error)
-	GetStakeInfo(ctx context.Context, in *GetStakeInfoRequest, opts ...grpc.CallOption) (*GetStakeInfoResponse, error)
-	GetColdWalletExtPub(ctx context.Context, in *GetColdWalletExtPubRequest, opts ...grpc.CallOption) (*GetColdWalletExtPubResponse, error)
+type ExistsAddressResponse struct {
+	Exists               bool     `protobuf:"varint,1,opt,name=Exists,proto3" json:"Exists,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-type stakepooldServiceClient struct {
-	cc grpc.ClientConnInterface
+func (m *ExistsAddressResponse) Reset()         { *m = ExistsAddressResponse{} }
+func (m *ExistsAddressResponse) String() string { return proto.CompactTextString(m) }
+func (*ExistsAddressResponse) ProtoMessage()    {}
+func (*ExistsAddressResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{40}
 }
 
-func NewStakepooldServiceClient(cc grpc.ClientConnInterface) StakepooldServiceClient {
-	return &stakepooldServiceClient{cc}
+func (m *ExistsAddressResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExistsAddressResponse.Unmarshal(m, b)
+}
+func (m *ExistsAddressResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExistsAddressResponse.Marshal(b, m, deterministic)
+}
+func (m *ExistsAddressResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExistsAddressResponse.Merge(m, src)
+}
+func (m *ExistsAddressResponse) XXX_Size() int {
+	return xxx_messageInfo_ExistsAddressResponse.Size(m)
+}
+func (m *ExistsAddressResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExistsAddressResponse.DiscardUnknown(m)
 }
 
-func (c *stakepooldServiceClient) GetAddedLowFeeTickets(ctx context.Context, in *GetAddedLowFeeTicketsRequest, opts ...grpc.CallOption) (*GetAddedLowFeeTicketsResponse, error) {
-	out := new(GetAddedLowFeeTicketsResponse)
-	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetAddedLowFeeTickets", in, out, opts...)
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_ExistsAddressResponse proto.InternalMessageInfo
+
+func (m *ExistsAddressResponse) GetExists() bool {
+	if m != nil {
+		return m.Exists
 	}
-	return out, nil
+	return false
 }
 
-func (c *stakepooldServiceClient) GetIgnoredLowFeeTickets(ctx context.Context, in *GetIgnoredLowFeeTicketsRequest, opts ...grpc.CallOption) (*GetIgnoredLowFeeTicketsResponse, error) {
-	out := new(GetIgnoredLowFeeTicketsResponse)
-	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetIgnoredLowFeeTickets", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+type VerifyMessageRequest struct {
+	Address              string   `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
+	Message              string   `protobuf:"bytes,2,opt,name=Message,proto3" json:"Message,omitempty"`
+	Signature            string   `protobuf:"bytes,3,opt,name=Signature,proto3" json:"Signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (c *stakepooldServiceClient) GetLiveTickets(ctx context.Context, in *GetLiveTicketsRequest, opts ...grpc.CallOption) (*GetLiveTicketsResponse, error) {
-	out := new(GetLiveTicketsResponse)
-	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetLiveTickets", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+func (m *VerifyMessageRequest) Reset()         { *m = VerifyMessageRequest{} }
+func (m *VerifyMessageRequest) String() string { return proto.CompactTextString(m) }
+func (*VerifyMessageRequest) ProtoMessage()    {}
+func (*VerifyMessageRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{41}
 }
 
-func (c *stakepooldServiceClient) SetAddedLowFeeTickets(ctx context.Context, in *SetAddedLowFeeTicketsRequest, opts ...grpc.CallOption) (*SetAddedLowFeeTicketsResponse, error) {
-	out := new(SetAddedLowFeeTicketsResponse)
-	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/SetAddedLowFeeTickets", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+func (m *VerifyMessageRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyMessageRequest.Unmarshal(m, b)
+}
+func (m *VerifyMessageRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyMessageRequest.Marshal(b, m, deterministic)
+}
+func (m *VerifyMessageRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyMessageRequest.Merge(m, src)
+}
+func (m *VerifyMessageRequest) XXX_Size() int {
+	return xxx_messageInfo_VerifyMessageRequest.Size(m)
+}
+func (m *VerifyMessageRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyMessageRequest.DiscardUnknown(m)
 }
 
-func (c *stakepooldServiceClient) SetUserVotingPrefs(ctx context.Context, in *SetUserVotingPrefsRequest, opts ...grpc.CallOption) (*SetUserVotingPrefsResponse, error) {
-	out := new(SetUserVotingPrefsResponse)
-	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/SetUserVotingPrefs", in, out, opts...)
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_VerifyMessageRequest proto.InternalMessageInfo
+
+func (m *VerifyMessageRequest) GetAddress() string {
+	if m != nil {
+		return m.Address
 	}
-	return out, nil
+	return ""
 }
 
-func (c *stakepooldServiceClient) ImportNewScript(ctx context.Context, in *ImportNewScriptRequest, opts ...grpc.CallOption) (*ImportNewScriptResponse, error) {
-	out := new(ImportNewScriptResponse)
-	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/ImportNewScript", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *VerifyMessageRequest) GetMessage() string {
+	if m != nil {
+		return m.Message
 	}
-	return out, nil
+	return ""
 }
 
-func (c *stakepooldServiceClient) ImportMissingScripts(ctx context.Context, in *ImportMissingScriptsRequest, opts ...grpc.CallOption) (*ImportMissingScriptsResponse, error) {
-	out := new(ImportMissingScriptsResponse)
-	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/ImportMissingScripts", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *VerifyMessageRequest) GetSignature() string {
+	if m != nil {
+		return m.Signature
 	}
-	return out, nil
+	return ""
 }
 
-func (c *stakepooldServiceClient) StakePoolUserInfo(ctx context.Context, in *StakePoolUserInfoRequest, opts ...grpc.CallOption) (*StakePoolUserInfoResponse, error) {
-	out := new(StakePoolUserInfoResponse)
-	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/StakePoolUserInfo", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+type VerifyMessageResponse struct {
+	Valid                bool     `protobuf:"varint,1,opt,name=Valid,proto3" json:"Valid,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (c *stakepooldServiceClient) WalletInfo(ctx context.Context, in *WalletInfoRequest, opts ...grpc.CallOption) (*WalletInfoResponse, error) {
-	out := new(WalletInfoResponse)
-	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/WalletInfo", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+func (m *VerifyMessageResponse) Reset()         { *m = VerifyMessageResponse{} }
+func (m *VerifyMessageResponse) String() string { return proto.CompactTextString(m) }
+func (*VerifyMessageResponse) ProtoMessage()    {}
+func (*VerifyMessageResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{42}
 }
 
-func (c *stakepooldServiceClient) ValidateAddress(ctx context.Context, in *ValidateAddressRequest, opts ...grpc.CallOption) (*ValidateAddressResponse, error) {
-	out := new(ValidateAddressResponse)
-	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/ValidateAddress", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
+func (m *VerifyMessageResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyMessageResponse.Unmarshal(m, b)
+}
+func (m *VerifyMessageResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyMessageResponse.Marshal(b, m, deterministic)
+}
+func (m *VerifyMessageResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyMessageResponse.Merge(m, src)
+}
+func (m *VerifyMessageResponse) XXX_Size() int {
+	return xxx_messageInfo_VerifyMessageResponse.Size(m)
+}
+func (m *VerifyMessageResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyMessageResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyMessageResponse proto.InternalMessageInfo
+
+func (m *VerifyMessageResponse) GetValid() bool {
+	if m != nil {
+		return m.Valid
+	}
+	return false
+}
+
+type GetLiveTicketCountsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetLiveTicketCountsRequest) Reset()         { *m = GetLiveTicketCountsRequest{} }
+func (m *GetLiveTicketCountsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLiveTicketCountsRequest) ProtoMessage()    {}
+func (*GetLiveTicketCountsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{43}
+}
+
+func (m *GetLiveTicketCountsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLiveTicketCountsRequest.Unmarshal(m, b)
+}
+func (m *GetLiveTicketCountsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLiveTicketCountsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetLiveTicketCountsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLiveTicketCountsRequest.Merge(m, src)
+}
+func (m *GetLiveTicketCountsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetLiveTicketCountsRequest.Size(m)
+}
+func (m *GetLiveTicketCountsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLiveTicketCountsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLiveTicketCountsRequest proto.InternalMessageInfo
+
+type GetLiveTicketCountsResponse struct {
+	Counts               []*LiveTicketCount `protobuf:"bytes,1,rep,name=Counts,proto3" json:"Counts,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *GetLiveTicketCountsResponse) Reset()         { *m = GetLiveTicketCountsResponse{} }
+func (m *GetLiveTicketCountsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetLiveTicketCountsResponse) ProtoMessage()    {}
+func (*GetLiveTicketCountsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{44}
+}
+
+func (m *GetLiveTicketCountsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLiveTicketCountsResponse.Unmarshal(m, b)
+}
+func (m *GetLiveTicketCountsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLiveTicketCountsResponse.Marshal(b, m, deterministic)
+}
+func (m *GetLiveTicketCountsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLiveTicketCountsResponse.Merge(m, src)
+}
+func (m *GetLiveTicketCountsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetLiveTicketCountsResponse.Size(m)
+}
+func (m *GetLiveTicketCountsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLiveTicketCountsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLiveTicketCountsResponse proto.InternalMessageInfo
+
+func (m *GetLiveTicketCountsResponse) GetCounts() []*LiveTicketCount {
+	if m != nil {
+		return m.Counts
+	}
+	return nil
+}
+
+type LiveTicketCount struct {
+	MultiSigAddress      string   `protobuf:"bytes,1,opt,name=MultiSigAddress,proto3" json:"MultiSigAddress,omitempty"`
+	Count                uint32   `protobuf:"varint,2,opt,name=Count,proto3" json:"Count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LiveTicketCount) Reset()         { *m = LiveTicketCount{} }
+func (m *LiveTicketCount) String() string { return proto.CompactTextString(m) }
+func (*LiveTicketCount) ProtoMessage()    {}
+func (*LiveTicketCount) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{45}
+}
+
+func (m *LiveTicketCount) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LiveTicketCount.Unmarshal(m, b)
+}
+func (m *LiveTicketCount) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LiveTicketCount.Marshal(b, m, deterministic)
+}
+func (m *LiveTicketCount) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LiveTicketCount.Merge(m, src)
+}
+func (m *LiveTicketCount) XXX_Size() int {
+	return xxx_messageInfo_LiveTicketCount.Size(m)
+}
+func (m *LiveTicketCount) XXX_DiscardUnknown() {
+	xxx_messageInfo_LiveTicketCount.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LiveTicketCount proto.InternalMessageInfo
+
+func (m *LiveTicketCount) GetMultiSigAddress() string {
+	if m != nil {
+		return m.MultiSigAddress
+	}
+	return ""
+}
+
+func (m *LiveTicketCount) GetCount() uint32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type GetLowFeeReviewRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetLowFeeReviewRequest) Reset()         { *m = GetLowFeeReviewRequest{} }
+func (m *GetLowFeeReviewRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLowFeeReviewRequest) ProtoMessage()    {}
+func (*GetLowFeeReviewRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{46}
+}
+
+func (m *GetLowFeeReviewRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLowFeeReviewRequest.Unmarshal(m, b)
+}
+func (m *GetLowFeeReviewRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLowFeeReviewRequest.Marshal(b, m, deterministic)
+}
+func (m *GetLowFeeReviewRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLowFeeReviewRequest.Merge(m, src)
+}
+func (m *GetLowFeeReviewRequest) XXX_Size() int {
+	return xxx_messageInfo_GetLowFeeReviewRequest.Size(m)
+}
+func (m *GetLowFeeReviewRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLowFeeReviewRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLowFeeReviewRequest proto.InternalMessageInfo
+
+type GetLowFeeReviewResponse struct {
+	Paused               bool      `protobuf:"varint,1,opt,name=Paused,proto3" json:"Paused,omitempty"`
+	Tickets              []*Ticket `protobuf:"bytes,2,rep,name=Tickets,proto3" json:"Tickets,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *GetLowFeeReviewResponse) Reset()         { *m = GetLowFeeReviewResponse{} }
+func (m *GetLowFeeReviewResponse) String() string { return proto.CompactTextString(m) }
+func (*GetLowFeeReviewResponse) ProtoMessage()    {}
+func (*GetLowFeeReviewResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{47}
+}
+
+func (m *GetLowFeeReviewResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLowFeeReviewResponse.Unmarshal(m, b)
+}
+func (m *GetLowFeeReviewResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLowFeeReviewResponse.Marshal(b, m, deterministic)
+}
+func (m *GetLowFeeReviewResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLowFeeReviewResponse.Merge(m, src)
+}
+func (m *GetLowFeeReviewResponse) XXX_Size() int {
+	return xxx_messageInfo_GetLowFeeReviewResponse.Size(m)
+}
+func (m *GetLowFeeReviewResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLowFeeReviewResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLowFeeReviewResponse proto.InternalMessageInfo
+
+func (m *GetLowFeeReviewResponse) GetPaused() bool {
+	if m != nil {
+		return m.Paused
+	}
+	return false
+}
+
+func (m *GetLowFeeReviewResponse) GetTickets() []*Ticket {
+	if m != nil {
+		return m.Tickets
+	}
+	return nil
+}
+
+type ResumeLowFeeClassificationRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResumeLowFeeClassificationRequest) Reset()         { *m = ResumeLowFeeClassificationRequest{} }
+func (m *ResumeLowFeeClassificationRequest) String() string { return proto.CompactTextString(m) }
+func (*ResumeLowFeeClassificationRequest) ProtoMessage()    {}
+func (*ResumeLowFeeClassificationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{48}
+}
+
+func (m *ResumeLowFeeClassificationRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResumeLowFeeClassificationRequest.Unmarshal(m, b)
+}
+func (m *ResumeLowFeeClassificationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResumeLowFeeClassificationRequest.Marshal(b, m, deterministic)
+}
+func (m *ResumeLowFeeClassificationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResumeLowFeeClassificationRequest.Merge(m, src)
+}
+func (m *ResumeLowFeeClassificationRequest) XXX_Size() int {
+	return xxx_messageInfo_ResumeLowFeeClassificationRequest.Size(m)
+}
+func (m *ResumeLowFeeClassificationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResumeLowFeeClassificationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResumeLowFeeClassificationRequest proto.InternalMessageInfo
+
+type ResumeLowFeeClassificationResponse struct {
+	Released             uint32   `protobuf:"varint,1,opt,name=Released,proto3" json:"Released,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResumeLowFeeClassificationResponse) Reset()         { *m = ResumeLowFeeClassificationResponse{} }
+func (m *ResumeLowFeeClassificationResponse) String() string { return proto.CompactTextString(m) }
+func (*ResumeLowFeeClassificationResponse) ProtoMessage()    {}
+func (*ResumeLowFeeClassificationResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{49}
+}
+
+func (m *ResumeLowFeeClassificationResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResumeLowFeeClassificationResponse.Unmarshal(m, b)
+}
+func (m *ResumeLowFeeClassificationResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResumeLowFeeClassificationResponse.Marshal(b, m, deterministic)
+}
+func (m *ResumeLowFeeClassificationResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResumeLowFeeClassificationResponse.Merge(m, src)
+}
+func (m *ResumeLowFeeClassificationResponse) XXX_Size() int {
+	return xxx_messageInfo_ResumeLowFeeClassificationResponse.Size(m)
+}
+func (m *ResumeLowFeeClassificationResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResumeLowFeeClassificationResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResumeLowFeeClassificationResponse proto.InternalMessageInfo
+
+func (m *ResumeLowFeeClassificationResponse) GetReleased() uint32 {
+	if m != nil {
+		return m.Released
+	}
+	return 0
+}
+
+type GetTicketAmountsRequest struct {
+	Hashes               [][]byte `protobuf:"bytes,1,rep,name=Hashes,proto3" json:"Hashes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTicketAmountsRequest) Reset()         { *m = GetTicketAmountsRequest{} }
+func (m *GetTicketAmountsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTicketAmountsRequest) ProtoMessage()    {}
+func (*GetTicketAmountsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{50}
+}
+
+func (m *GetTicketAmountsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTicketAmountsRequest.Unmarshal(m, b)
+}
+func (m *GetTicketAmountsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTicketAmountsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetTicketAmountsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTicketAmountsRequest.Merge(m, src)
+}
+func (m *GetTicketAmountsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTicketAmountsRequest.Size(m)
+}
+func (m *GetTicketAmountsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTicketAmountsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTicketAmountsRequest proto.InternalMessageInfo
+
+func (m *GetTicketAmountsRequest) GetHashes() [][]byte {
+	if m != nil {
+		return m.Hashes
+	}
+	return nil
+}
+
+type GetTicketAmountsResponse struct {
+	Amounts              []*TicketAmounts `protobuf:"bytes,1,rep,name=Amounts,proto3" json:"Amounts,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *GetTicketAmountsResponse) Reset()         { *m = GetTicketAmountsResponse{} }
+func (m *GetTicketAmountsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetTicketAmountsResponse) ProtoMessage()    {}
+func (*GetTicketAmountsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{51}
+}
+
+func (m *GetTicketAmountsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTicketAmountsResponse.Unmarshal(m, b)
+}
+func (m *GetTicketAmountsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTicketAmountsResponse.Marshal(b, m, deterministic)
+}
+func (m *GetTicketAmountsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTicketAmountsResponse.Merge(m, src)
+}
+func (m *GetTicketAmountsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetTicketAmountsResponse.Size(m)
+}
+func (m *GetTicketAmountsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTicketAmountsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTicketAmountsResponse proto.InternalMessageInfo
+
+func (m *GetTicketAmountsResponse) GetAmounts() []*TicketAmounts {
+	if m != nil {
+		return m.Amounts
+	}
+	return nil
+}
+
+type TicketAmounts struct {
+	Hash                 []byte   `protobuf:"bytes,1,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	TicketPrice          int64    `protobuf:"varint,2,opt,name=TicketPrice,proto3" json:"TicketPrice,omitempty"`
+	Subsidy              int64    `protobuf:"varint,3,opt,name=Subsidy,proto3" json:"Subsidy,omitempty"`
+	PoolPayout           int64    `protobuf:"varint,4,opt,name=PoolPayout,proto3" json:"PoolPayout,omitempty"`
+	UserPayout           int64    `protobuf:"varint,5,opt,name=UserPayout,proto3" json:"UserPayout,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TicketAmounts) Reset()         { *m = TicketAmounts{} }
+func (m *TicketAmounts) String() string { return proto.CompactTextString(m) }
+func (*TicketAmounts) ProtoMessage()    {}
+func (*TicketAmounts) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{52}
+}
+
+func (m *TicketAmounts) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TicketAmounts.Unmarshal(m, b)
+}
+func (m *TicketAmounts) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TicketAmounts.Marshal(b, m, deterministic)
+}
+func (m *TicketAmounts) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TicketAmounts.Merge(m, src)
+}
+func (m *TicketAmounts) XXX_Size() int {
+	return xxx_messageInfo_TicketAmounts.Size(m)
+}
+func (m *TicketAmounts) XXX_DiscardUnknown() {
+	xxx_messageInfo_TicketAmounts.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TicketAmounts proto.InternalMessageInfo
+
+func (m *TicketAmounts) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *TicketAmounts) GetTicketPrice() int64 {
+	if m != nil {
+		return m.TicketPrice
+	}
+	return 0
+}
+
+func (m *TicketAmounts) GetSubsidy() int64 {
+	if m != nil {
+		return m.Subsidy
+	}
+	return 0
+}
+
+func (m *TicketAmounts) GetPoolPayout() int64 {
+	if m != nil {
+		return m.PoolPayout
+	}
+	return 0
+}
+
+func (m *TicketAmounts) GetUserPayout() int64 {
+	if m != nil {
+		return m.UserPayout
+	}
+	return 0
+}
+
+type PromoteStandbyRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PromoteStandbyRequest) Reset()         { *m = PromoteStandbyRequest{} }
+func (m *PromoteStandbyRequest) String() string { return proto.CompactTextString(m) }
+func (*PromoteStandbyRequest) ProtoMessage()    {}
+func (*PromoteStandbyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{53}
+}
+
+func (m *PromoteStandbyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PromoteStandbyRequest.Unmarshal(m, b)
+}
+func (m *PromoteStandbyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PromoteStandbyRequest.Marshal(b, m, deterministic)
+}
+func (m *PromoteStandbyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PromoteStandbyRequest.Merge(m, src)
+}
+func (m *PromoteStandbyRequest) XXX_Size() int {
+	return xxx_messageInfo_PromoteStandbyRequest.Size(m)
+}
+func (m *PromoteStandbyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PromoteStandbyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PromoteStandbyRequest proto.InternalMessageInfo
+
+type PromoteStandbyResponse struct {
+	WasStandby           bool     `protobuf:"varint,1,opt,name=WasStandby,proto3" json:"WasStandby,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PromoteStandbyResponse) Reset()         { *m = PromoteStandbyResponse{} }
+func (m *PromoteStandbyResponse) String() string { return proto.CompactTextString(m) }
+func (*PromoteStandbyResponse) ProtoMessage()    {}
+func (*PromoteStandbyResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{54}
+}
+
+func (m *PromoteStandbyResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PromoteStandbyResponse.Unmarshal(m, b)
+}
+func (m *PromoteStandbyResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PromoteStandbyResponse.Marshal(b, m, deterministic)
+}
+func (m *PromoteStandbyResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PromoteStandbyResponse.Merge(m, src)
+}
+func (m *PromoteStandbyResponse) XXX_Size() int {
+	return xxx_messageInfo_PromoteStandbyResponse.Size(m)
+}
+func (m *PromoteStandbyResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PromoteStandbyResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PromoteStandbyResponse proto.InternalMessageInfo
+
+func (m *PromoteStandbyResponse) GetWasStandby() bool {
+	if m != nil {
+		return m.WasStandby
+	}
+	return false
+}
+
+type LookupTicketsRequest struct {
+	Tickets              [][]byte `protobuf:"bytes,1,rep,name=Tickets,proto3" json:"Tickets,omitempty"`
+	MultiSigAddresses    []string `protobuf:"bytes,2,rep,name=MultiSigAddresses,proto3" json:"MultiSigAddresses,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LookupTicketsRequest) Reset()         { *m = LookupTicketsRequest{} }
+func (m *LookupTicketsRequest) String() string { return proto.CompactTextString(m) }
+func (*LookupTicketsRequest) ProtoMessage()    {}
+func (*LookupTicketsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{55}
+}
+
+func (m *LookupTicketsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LookupTicketsRequest.Unmarshal(m, b)
+}
+func (m *LookupTicketsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LookupTicketsRequest.Marshal(b, m, deterministic)
+}
+func (m *LookupTicketsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LookupTicketsRequest.Merge(m, src)
+}
+func (m *LookupTicketsRequest) XXX_Size() int {
+	return xxx_messageInfo_LookupTicketsRequest.Size(m)
+}
+func (m *LookupTicketsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LookupTicketsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LookupTicketsRequest proto.InternalMessageInfo
+
+func (m *LookupTicketsRequest) GetTickets() [][]byte {
+	if m != nil {
+		return m.Tickets
+	}
+	return nil
+}
+
+func (m *LookupTicketsRequest) GetMultiSigAddresses() []string {
+	if m != nil {
+		return m.MultiSigAddresses
+	}
+	return nil
+}
+
+type LookupTicketsResponse struct {
+	Tickets              []*TicketLookup `protobuf:"bytes,1,rep,name=Tickets,proto3" json:"Tickets,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *LookupTicketsResponse) Reset()         { *m = LookupTicketsResponse{} }
+func (m *LookupTicketsResponse) String() string { return proto.CompactTextString(m) }
+func (*LookupTicketsResponse) ProtoMessage()    {}
+func (*LookupTicketsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{56}
+}
+
+func (m *LookupTicketsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LookupTicketsResponse.Unmarshal(m, b)
+}
+func (m *LookupTicketsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LookupTicketsResponse.Marshal(b, m, deterministic)
+}
+func (m *LookupTicketsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LookupTicketsResponse.Merge(m, src)
+}
+func (m *LookupTicketsResponse) XXX_Size() int {
+	return xxx_messageInfo_LookupTicketsResponse.Size(m)
+}
+func (m *LookupTicketsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_LookupTicketsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LookupTicketsResponse proto.InternalMessageInfo
+
+func (m *LookupTicketsResponse) GetTickets() []*TicketLookup {
+	if m != nil {
+		return m.Tickets
+	}
+	return nil
+}
+
+type TicketLookup struct {
+	Hash                 []byte   `protobuf:"bytes,1,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	Address              string   `protobuf:"bytes,2,opt,name=Address,proto3" json:"Address,omitempty"`
+	Set                  string   `protobuf:"bytes,3,opt,name=Set,proto3" json:"Set,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TicketLookup) Reset()         { *m = TicketLookup{} }
+func (m *TicketLookup) String() string { return proto.CompactTextString(m) }
+func (*TicketLookup) ProtoMessage()    {}
+func (*TicketLookup) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{57}
+}
+
+func (m *TicketLookup) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TicketLookup.Unmarshal(m, b)
+}
+func (m *TicketLookup) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TicketLookup.Marshal(b, m, deterministic)
+}
+func (m *TicketLookup) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TicketLookup.Merge(m, src)
+}
+func (m *TicketLookup) XXX_Size() int {
+	return xxx_messageInfo_TicketLookup.Size(m)
+}
+func (m *TicketLookup) XXX_DiscardUnknown() {
+	xxx_messageInfo_TicketLookup.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TicketLookup proto.InternalMessageInfo
+
+func (m *TicketLookup) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *TicketLookup) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *TicketLookup) GetSet() string {
+	if m != nil {
+		return m.Set
+	}
+	return ""
+}
+
+type BatchStakePoolUserInfoRequest struct {
+	MultiSigAddresses    []string `protobuf:"bytes,1,rep,name=MultiSigAddresses,proto3" json:"MultiSigAddresses,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BatchStakePoolUserInfoRequest) Reset()         { *m = BatchStakePoolUserInfoRequest{} }
+func (m *BatchStakePoolUserInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*BatchStakePoolUserInfoRequest) ProtoMessage()    {}
+func (*BatchStakePoolUserInfoRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{58}
+}
+
+func (m *BatchStakePoolUserInfoRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchStakePoolUserInfoRequest.Unmarshal(m, b)
+}
+func (m *BatchStakePoolUserInfoRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchStakePoolUserInfoRequest.Marshal(b, m, deterministic)
+}
+func (m *BatchStakePoolUserInfoRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchStakePoolUserInfoRequest.Merge(m, src)
+}
+func (m *BatchStakePoolUserInfoRequest) XXX_Size() int {
+	return xxx_messageInfo_BatchStakePoolUserInfoRequest.Size(m)
+}
+func (m *BatchStakePoolUserInfoRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchStakePoolUserInfoRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchStakePoolUserInfoRequest proto.InternalMessageInfo
+
+func (m *BatchStakePoolUserInfoRequest) GetMultiSigAddresses() []string {
+	if m != nil {
+		return m.MultiSigAddresses
+	}
+	return nil
+}
+
+type BatchStakePoolUserInfoResponse struct {
+	Results              []*StakePoolUserInfoResult `protobuf:"bytes,1,rep,name=Results,proto3" json:"Results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *BatchStakePoolUserInfoResponse) Reset()         { *m = BatchStakePoolUserInfoResponse{} }
+func (m *BatchStakePoolUserInfoResponse) String() string { return proto.CompactTextString(m) }
+func (*BatchStakePoolUserInfoResponse) ProtoMessage()    {}
+func (*BatchStakePoolUserInfoResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{59}
+}
+
+func (m *BatchStakePoolUserInfoResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchStakePoolUserInfoResponse.Unmarshal(m, b)
+}
+func (m *BatchStakePoolUserInfoResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchStakePoolUserInfoResponse.Marshal(b, m, deterministic)
+}
+func (m *BatchStakePoolUserInfoResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchStakePoolUserInfoResponse.Merge(m, src)
+}
+func (m *BatchStakePoolUserInfoResponse) XXX_Size() int {
+	return xxx_messageInfo_BatchStakePoolUserInfoResponse.Size(m)
+}
+func (m *BatchStakePoolUserInfoResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchStakePoolUserInfoResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchStakePoolUserInfoResponse proto.InternalMessageInfo
+
+func (m *BatchStakePoolUserInfoResponse) GetResults() []*StakePoolUserInfoResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type StakePoolUserInfoResult struct {
+	MultiSigAddress      string                     `protobuf:"bytes,1,opt,name=MultiSigAddress,proto3" json:"MultiSigAddress,omitempty"`
+	Info                 *StakePoolUserInfoResponse `protobuf:"bytes,2,opt,name=Info,proto3" json:"Info,omitempty"`
+	Error                string                     `protobuf:"bytes,3,opt,name=Error,proto3" json:"Error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *StakePoolUserInfoResult) Reset()         { *m = StakePoolUserInfoResult{} }
+func (m *StakePoolUserInfoResult) String() string { return proto.CompactTextString(m) }
+func (*StakePoolUserInfoResult) ProtoMessage()    {}
+func (*StakePoolUserInfoResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{60}
+}
+
+func (m *StakePoolUserInfoResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StakePoolUserInfoResult.Unmarshal(m, b)
+}
+func (m *StakePoolUserInfoResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StakePoolUserInfoResult.Marshal(b, m, deterministic)
+}
+func (m *StakePoolUserInfoResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StakePoolUserInfoResult.Merge(m, src)
+}
+func (m *StakePoolUserInfoResult) XXX_Size() int {
+	return xxx_messageInfo_StakePoolUserInfoResult.Size(m)
+}
+func (m *StakePoolUserInfoResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_StakePoolUserInfoResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StakePoolUserInfoResult proto.InternalMessageInfo
+
+func (m *StakePoolUserInfoResult) GetMultiSigAddress() string {
+	if m != nil {
+		return m.MultiSigAddress
+	}
+	return ""
+}
+
+func (m *StakePoolUserInfoResult) GetInfo() *StakePoolUserInfoResponse {
+	if m != nil {
+		return m.Info
+	}
+	return nil
+}
+
+func (m *StakePoolUserInfoResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type GetBestBlockRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBestBlockRequest) Reset()         { *m = GetBestBlockRequest{} }
+func (m *GetBestBlockRequest) String() string { return proto.CompactTextString(m) }
+func (*GetBestBlockRequest) ProtoMessage()    {}
+func (*GetBestBlockRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{61}
+}
+
+func (m *GetBestBlockRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBestBlockRequest.Unmarshal(m, b)
+}
+func (m *GetBestBlockRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBestBlockRequest.Marshal(b, m, deterministic)
+}
+func (m *GetBestBlockRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBestBlockRequest.Merge(m, src)
+}
+func (m *GetBestBlockRequest) XXX_Size() int {
+	return xxx_messageInfo_GetBestBlockRequest.Size(m)
+}
+func (m *GetBestBlockRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBestBlockRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBestBlockRequest proto.InternalMessageInfo
+
+type GetBestBlockResponse struct {
+	Hash                 []byte   `protobuf:"bytes,1,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	Height               int64    `protobuf:"varint,2,opt,name=Height,proto3" json:"Height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBestBlockResponse) Reset()         { *m = GetBestBlockResponse{} }
+func (m *GetBestBlockResponse) String() string { return proto.CompactTextString(m) }
+func (*GetBestBlockResponse) ProtoMessage()    {}
+func (*GetBestBlockResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{62}
+}
+
+func (m *GetBestBlockResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBestBlockResponse.Unmarshal(m, b)
+}
+func (m *GetBestBlockResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBestBlockResponse.Marshal(b, m, deterministic)
+}
+func (m *GetBestBlockResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBestBlockResponse.Merge(m, src)
+}
+func (m *GetBestBlockResponse) XXX_Size() int {
+	return xxx_messageInfo_GetBestBlockResponse.Size(m)
+}
+func (m *GetBestBlockResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBestBlockResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBestBlockResponse proto.InternalMessageInfo
+
+func (m *GetBestBlockResponse) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *GetBestBlockResponse) GetHeight() int64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+type GetChainParamsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetChainParamsRequest) Reset()         { *m = GetChainParamsRequest{} }
+func (m *GetChainParamsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetChainParamsRequest) ProtoMessage()    {}
+func (*GetChainParamsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{63}
+}
+
+func (m *GetChainParamsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetChainParamsRequest.Unmarshal(m, b)
+}
+func (m *GetChainParamsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetChainParamsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetChainParamsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetChainParamsRequest.Merge(m, src)
+}
+func (m *GetChainParamsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetChainParamsRequest.Size(m)
+}
+func (m *GetChainParamsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetChainParamsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetChainParamsRequest proto.InternalMessageInfo
+
+type GetChainParamsResponse struct {
+	NetName              string   `protobuf:"bytes,1,opt,name=NetName,proto3" json:"NetName,omitempty"`
+	GenesisHash          []byte   `protobuf:"bytes,2,opt,name=GenesisHash,proto3" json:"GenesisHash,omitempty"`
+	PoolFees             float64  `protobuf:"fixed64,3,opt,name=PoolFees,proto3" json:"PoolFees,omitempty"`
+	FeeMode              string   `protobuf:"bytes,4,opt,name=FeeMode,proto3" json:"FeeMode,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetChainParamsResponse) Reset()         { *m = GetChainParamsResponse{} }
+func (m *GetChainParamsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetChainParamsResponse) ProtoMessage()    {}
+func (*GetChainParamsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{64}
+}
+
+func (m *GetChainParamsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetChainParamsResponse.Unmarshal(m, b)
+}
+func (m *GetChainParamsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetChainParamsResponse.Marshal(b, m, deterministic)
+}
+func (m *GetChainParamsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetChainParamsResponse.Merge(m, src)
+}
+func (m *GetChainParamsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetChainParamsResponse.Size(m)
+}
+func (m *GetChainParamsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetChainParamsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetChainParamsResponse proto.InternalMessageInfo
+
+func (m *GetChainParamsResponse) GetNetName() string {
+	if m != nil {
+		return m.NetName
+	}
+	return ""
+}
+
+func (m *GetChainParamsResponse) GetGenesisHash() []byte {
+	if m != nil {
+		return m.GenesisHash
+	}
+	return nil
+}
+
+func (m *GetChainParamsResponse) GetPoolFees() float64 {
+	if m != nil {
+		return m.PoolFees
+	}
+	return 0
+}
+
+func (m *GetChainParamsResponse) GetFeeMode() string {
+	if m != nil {
+		return m.FeeMode
+	}
+	return ""
+}
+
+type SendRawTransactionRequest struct {
+	Tx                   []byte   `protobuf:"bytes,1,opt,name=Tx,proto3" json:"Tx,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SendRawTransactionRequest) Reset()         { *m = SendRawTransactionRequest{} }
+func (m *SendRawTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*SendRawTransactionRequest) ProtoMessage()    {}
+func (*SendRawTransactionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{65}
+}
+
+func (m *SendRawTransactionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SendRawTransactionRequest.Unmarshal(m, b)
+}
+func (m *SendRawTransactionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SendRawTransactionRequest.Marshal(b, m, deterministic)
+}
+func (m *SendRawTransactionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SendRawTransactionRequest.Merge(m, src)
+}
+func (m *SendRawTransactionRequest) XXX_Size() int {
+	return xxx_messageInfo_SendRawTransactionRequest.Size(m)
+}
+func (m *SendRawTransactionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SendRawTransactionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SendRawTransactionRequest proto.InternalMessageInfo
+
+func (m *SendRawTransactionRequest) GetTx() []byte {
+	if m != nil {
+		return m.Tx
+	}
+	return nil
+}
+
+type SendRawTransactionResponse struct {
+	Hash                 []byte   `protobuf:"bytes,1,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SendRawTransactionResponse) Reset()         { *m = SendRawTransactionResponse{} }
+func (m *SendRawTransactionResponse) String() string { return proto.CompactTextString(m) }
+func (*SendRawTransactionResponse) ProtoMessage()    {}
+func (*SendRawTransactionResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{66}
+}
+
+func (m *SendRawTransactionResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SendRawTransactionResponse.Unmarshal(m, b)
+}
+func (m *SendRawTransactionResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SendRawTransactionResponse.Marshal(b, m, deterministic)
+}
+func (m *SendRawTransactionResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SendRawTransactionResponse.Merge(m, src)
+}
+func (m *SendRawTransactionResponse) XXX_Size() int {
+	return xxx_messageInfo_SendRawTransactionResponse.Size(m)
+}
+func (m *SendRawTransactionResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SendRawTransactionResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SendRawTransactionResponse proto.InternalMessageInfo
+
+func (m *SendRawTransactionResponse) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+type GetTransactionConfirmationsRequest struct {
+	Hash                 []byte   `protobuf:"bytes,1,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTransactionConfirmationsRequest) Reset()         { *m = GetTransactionConfirmationsRequest{} }
+func (m *GetTransactionConfirmationsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTransactionConfirmationsRequest) ProtoMessage()    {}
+func (*GetTransactionConfirmationsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{67}
+}
+
+func (m *GetTransactionConfirmationsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTransactionConfirmationsRequest.Unmarshal(m, b)
+}
+func (m *GetTransactionConfirmationsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTransactionConfirmationsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetTransactionConfirmationsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTransactionConfirmationsRequest.Merge(m, src)
+}
+func (m *GetTransactionConfirmationsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTransactionConfirmationsRequest.Size(m)
+}
+func (m *GetTransactionConfirmationsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTransactionConfirmationsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTransactionConfirmationsRequest proto.InternalMessageInfo
+
+func (m *GetTransactionConfirmationsRequest) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+type GetTransactionConfirmationsResponse struct {
+	Confirmations        int64    `protobuf:"varint,1,opt,name=Confirmations,proto3" json:"Confirmations,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTransactionConfirmationsResponse) Reset()         { *m = GetTransactionConfirmationsResponse{} }
+func (m *GetTransactionConfirmationsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetTransactionConfirmationsResponse) ProtoMessage()    {}
+func (*GetTransactionConfirmationsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{68}
+}
+
+func (m *GetTransactionConfirmationsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTransactionConfirmationsResponse.Unmarshal(m, b)
+}
+func (m *GetTransactionConfirmationsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTransactionConfirmationsResponse.Marshal(b, m, deterministic)
+}
+func (m *GetTransactionConfirmationsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTransactionConfirmationsResponse.Merge(m, src)
+}
+func (m *GetTransactionConfirmationsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetTransactionConfirmationsResponse.Size(m)
+}
+func (m *GetTransactionConfirmationsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTransactionConfirmationsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTransactionConfirmationsResponse proto.InternalMessageInfo
+
+func (m *GetTransactionConfirmationsResponse) GetConfirmations() int64 {
+	if m != nil {
+		return m.Confirmations
+	}
+	return 0
+}
+
+type GetAccountExtPubRequest struct {
+	Account              string   `protobuf:"bytes,1,opt,name=Account,proto3" json:"Account,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAccountExtPubRequest) Reset()         { *m = GetAccountExtPubRequest{} }
+func (m *GetAccountExtPubRequest) String() string { return proto.CompactTextString(m) }
+func (*GetAccountExtPubRequest) ProtoMessage()    {}
+func (*GetAccountExtPubRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{69}
+}
+
+func (m *GetAccountExtPubRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAccountExtPubRequest.Unmarshal(m, b)
+}
+func (m *GetAccountExtPubRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAccountExtPubRequest.Marshal(b, m, deterministic)
+}
+func (m *GetAccountExtPubRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAccountExtPubRequest.Merge(m, src)
+}
+func (m *GetAccountExtPubRequest) XXX_Size() int {
+	return xxx_messageInfo_GetAccountExtPubRequest.Size(m)
+}
+func (m *GetAccountExtPubRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAccountExtPubRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAccountExtPubRequest proto.InternalMessageInfo
+
+func (m *GetAccountExtPubRequest) GetAccount() string {
+	if m != nil {
+		return m.Account
+	}
+	return ""
+}
+
+type GetAccountExtPubResponse struct {
+	ExtPub               string   `protobuf:"bytes,1,opt,name=ExtPub,proto3" json:"ExtPub,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAccountExtPubResponse) Reset()         { *m = GetAccountExtPubResponse{} }
+func (m *GetAccountExtPubResponse) String() string { return proto.CompactTextString(m) }
+func (*GetAccountExtPubResponse) ProtoMessage()    {}
+func (*GetAccountExtPubResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{70}
+}
+
+func (m *GetAccountExtPubResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAccountExtPubResponse.Unmarshal(m, b)
+}
+func (m *GetAccountExtPubResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAccountExtPubResponse.Marshal(b, m, deterministic)
+}
+func (m *GetAccountExtPubResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAccountExtPubResponse.Merge(m, src)
+}
+func (m *GetAccountExtPubResponse) XXX_Size() int {
+	return xxx_messageInfo_GetAccountExtPubResponse.Size(m)
+}
+func (m *GetAccountExtPubResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAccountExtPubResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAccountExtPubResponse proto.InternalMessageInfo
+
+func (m *GetAccountExtPubResponse) GetExtPub() string {
+	if m != nil {
+		return m.ExtPub
+	}
+	return ""
+}
+
+type DumpStateRequest struct {
+	IncludeContents      bool     `protobuf:"varint,1,opt,name=IncludeContents,proto3" json:"IncludeContents,omitempty"`
+	RedactAddresses      bool     `protobuf:"varint,2,opt,name=RedactAddresses,proto3" json:"RedactAddresses,omitempty"`
+	RedactUserIds        bool     `protobuf:"varint,3,opt,name=RedactUserIds,proto3" json:"RedactUserIds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DumpStateRequest) Reset()         { *m = DumpStateRequest{} }
+func (m *DumpStateRequest) String() string { return proto.CompactTextString(m) }
+func (*DumpStateRequest) ProtoMessage()    {}
+func (*DumpStateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{71}
+}
+
+func (m *DumpStateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DumpStateRequest.Unmarshal(m, b)
+}
+func (m *DumpStateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DumpStateRequest.Marshal(b, m, deterministic)
+}
+func (m *DumpStateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DumpStateRequest.Merge(m, src)
+}
+func (m *DumpStateRequest) XXX_Size() int {
+	return xxx_messageInfo_DumpStateRequest.Size(m)
+}
+func (m *DumpStateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DumpStateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DumpStateRequest proto.InternalMessageInfo
+
+func (m *DumpStateRequest) GetIncludeContents() bool {
+	if m != nil {
+		return m.IncludeContents
+	}
+	return false
+}
+
+func (m *DumpStateRequest) GetRedactAddresses() bool {
+	if m != nil {
+		return m.RedactAddresses
+	}
+	return false
+}
+
+func (m *DumpStateRequest) GetRedactUserIds() bool {
+	if m != nil {
+		return m.RedactUserIds
+	}
+	return false
+}
+
+type DumpStateResponse struct {
+	AddedLowFeeTicketsCount   uint32                   `protobuf:"varint,1,opt,name=AddedLowFeeTicketsCount,proto3" json:"AddedLowFeeTicketsCount,omitempty"`
+	IgnoredLowFeeTicketsCount uint32                   `protobuf:"varint,2,opt,name=IgnoredLowFeeTicketsCount,proto3" json:"IgnoredLowFeeTicketsCount,omitempty"`
+	LiveTicketsCount          uint32                   `protobuf:"varint,3,opt,name=LiveTicketsCount,proto3" json:"LiveTicketsCount,omitempty"`
+	UserVotingConfigCount     uint32                   `protobuf:"varint,4,opt,name=UserVotingConfigCount,proto3" json:"UserVotingConfigCount,omitempty"`
+	AddedLowFeeTickets        []*Ticket                `protobuf:"bytes,5,rep,name=AddedLowFeeTickets,proto3" json:"AddedLowFeeTickets,omitempty"`
+	IgnoredLowFeeTickets      []*Ticket                `protobuf:"bytes,6,rep,name=IgnoredLowFeeTickets,proto3" json:"IgnoredLowFeeTickets,omitempty"`
+	LiveTickets               []*Ticket                `protobuf:"bytes,7,rep,name=LiveTickets,proto3" json:"LiveTickets,omitempty"`
+	UserVotingConfig          []*UserVotingConfigEntry `protobuf:"bytes,8,rep,name=UserVotingConfig,proto3" json:"UserVotingConfig,omitempty"`
+	Queues                    []*TicketQueue           `protobuf:"bytes,9,rep,name=Queues,proto3" json:"Queues,omitempty"`
+	XXX_NoUnkeyedLiteral      struct{}                 `json:"-"`
+	XXX_unrecognized          []byte                   `json:"-"`
+	XXX_sizecache             int32                    `json:"-"`
+}
+
+func (m *DumpStateResponse) Reset()         { *m = DumpStateResponse{} }
+func (m *DumpStateResponse) String() string { return proto.CompactTextString(m) }
+func (*DumpStateResponse) ProtoMessage()    {}
+func (*DumpStateResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{72}
+}
+
+func (m *DumpStateResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DumpStateResponse.Unmarshal(m, b)
+}
+func (m *DumpStateResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DumpStateResponse.Marshal(b, m, deterministic)
+}
+func (m *DumpStateResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DumpStateResponse.Merge(m, src)
+}
+func (m *DumpStateResponse) XXX_Size() int {
+	return xxx_messageInfo_DumpStateResponse.Size(m)
+}
+func (m *DumpStateResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DumpStateResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DumpStateResponse proto.InternalMessageInfo
+
+func (m *DumpStateResponse) GetAddedLowFeeTicketsCount() uint32 {
+	if m != nil {
+		return m.AddedLowFeeTicketsCount
+	}
+	return 0
+}
+
+func (m *DumpStateResponse) GetIgnoredLowFeeTicketsCount() uint32 {
+	if m != nil {
+		return m.IgnoredLowFeeTicketsCount
+	}
+	return 0
+}
+
+func (m *DumpStateResponse) GetLiveTicketsCount() uint32 {
+	if m != nil {
+		return m.LiveTicketsCount
+	}
+	return 0
+}
+
+func (m *DumpStateResponse) GetUserVotingConfigCount() uint32 {
+	if m != nil {
+		return m.UserVotingConfigCount
+	}
+	return 0
+}
+
+func (m *DumpStateResponse) GetAddedLowFeeTickets() []*Ticket {
+	if m != nil {
+		return m.AddedLowFeeTickets
+	}
+	return nil
+}
+
+func (m *DumpStateResponse) GetIgnoredLowFeeTickets() []*Ticket {
+	if m != nil {
+		return m.IgnoredLowFeeTickets
+	}
+	return nil
+}
+
+func (m *DumpStateResponse) GetLiveTickets() []*Ticket {
+	if m != nil {
+		return m.LiveTickets
+	}
+	return nil
+}
+
+func (m *DumpStateResponse) GetUserVotingConfig() []*UserVotingConfigEntry {
+	if m != nil {
+		return m.UserVotingConfig
+	}
+	return nil
+}
+
+func (m *DumpStateResponse) GetQueues() []*TicketQueue {
+	if m != nil {
+		return m.Queues
+	}
+	return nil
+}
+
+type TicketQueue struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Depth                uint32   `protobuf:"varint,2,opt,name=Depth,proto3" json:"Depth,omitempty"`
+	Capacity             uint32   `protobuf:"varint,3,opt,name=Capacity,proto3" json:"Capacity,omitempty"`
+	MaxDepth             uint32   `protobuf:"varint,4,opt,name=MaxDepth,proto3" json:"MaxDepth,omitempty"`
+	Processed            uint64   `protobuf:"varint,5,opt,name=Processed,proto3" json:"Processed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TicketQueue) Reset()         { *m = TicketQueue{} }
+func (m *TicketQueue) String() string { return proto.CompactTextString(m) }
+func (*TicketQueue) ProtoMessage()    {}
+func (*TicketQueue) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{73}
+}
+
+func (m *TicketQueue) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TicketQueue.Unmarshal(m, b)
+}
+func (m *TicketQueue) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TicketQueue.Marshal(b, m, deterministic)
+}
+func (m *TicketQueue) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TicketQueue.Merge(m, src)
+}
+func (m *TicketQueue) XXX_Size() int {
+	return xxx_messageInfo_TicketQueue.Size(m)
+}
+func (m *TicketQueue) XXX_DiscardUnknown() {
+	xxx_messageInfo_TicketQueue.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TicketQueue proto.InternalMessageInfo
+
+func (m *TicketQueue) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *TicketQueue) GetDepth() uint32 {
+	if m != nil {
+		return m.Depth
+	}
+	return 0
+}
+
+func (m *TicketQueue) GetCapacity() uint32 {
+	if m != nil {
+		return m.Capacity
+	}
+	return 0
+}
+
+func (m *TicketQueue) GetMaxDepth() uint32 {
+	if m != nil {
+		return m.MaxDepth
+	}
+	return 0
+}
+
+func (m *TicketQueue) GetProcessed() uint64 {
+	if m != nil {
+		return m.Processed
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*GetAddedLowFeeTicketsRequest)(nil), "stakepoolrpc.GetAddedLowFeeTicketsRequest")
+	proto.RegisterType((*GetAddedLowFeeTicketsResponse)(nil), "stakepoolrpc.GetAddedLowFeeTicketsResponse")
+	proto.RegisterType((*GetIgnoredLowFeeTicketsRequest)(nil), "stakepoolrpc.GetIgnoredLowFeeTicketsRequest")
+	proto.RegisterType((*GetIgnoredLowFeeTicketsResponse)(nil), "stakepoolrpc.GetIgnoredLowFeeTicketsResponse")
+	proto.RegisterType((*GetLiveTicketsRequest)(nil), "stakepoolrpc.GetLiveTicketsRequest")
+	proto.RegisterType((*GetLiveTicketsResponse)(nil), "stakepoolrpc.GetLiveTicketsResponse")
+	proto.RegisterType((*SetAddedLowFeeTicketsRequest)(nil), "stakepoolrpc.SetAddedLowFeeTicketsRequest")
+	proto.RegisterType((*SetAddedLowFeeTicketsResponse)(nil), "stakepoolrpc.SetAddedLowFeeTicketsResponse")
+	proto.RegisterType((*SetUserVotingPrefsResponse)(nil), "stakepoolrpc.SetUserVotingPrefsResponse")
+	proto.RegisterType((*SetUserVotingPrefsRequest)(nil), "stakepoolrpc.SetUserVotingPrefsRequest")
+	proto.RegisterType((*AddMissingTicketRequest)(nil), "stakepoolrpc.AddMissingTicketRequest")
+	proto.RegisterType((*AddMissingTicketResponse)(nil), "stakepoolrpc.AddMissingTicketResponse")
+	proto.RegisterType((*GetTicketsRequest)(nil), "stakepoolrpc.GetTicketsRequest")
+	proto.RegisterType((*GetTicketsResponse)(nil), "stakepoolrpc.GetTicketsResponse")
+	proto.RegisterType((*ListImportedAddressesRequest)(nil), "stakepoolrpc.ListImportedAddressesRequest")
+	proto.RegisterType((*ListImportedAddressesResponse)(nil), "stakepoolrpc.ListImportedAddressesResponse")
+	proto.RegisterType((*AccountSyncAddressIndexRequest)(nil), "stakepoolrpc.AccountSyncAddressIndexRequest")
+	proto.RegisterType((*AccountSyncAddressIndexResponse)(nil), "stakepoolrpc.AccountSyncAddressIndexResponse")
+	proto.RegisterType((*ImportMissingScriptsRequest)(nil), "stakepoolrpc.ImportMissingScriptsRequest")
+	proto.RegisterType((*ImportMissingScriptsResponse)(nil), "stakepoolrpc.ImportMissingScriptsResponse")
+	proto.RegisterType((*ImportNewScriptRequest)(nil), "stakepoolrpc.ImportNewScriptRequest")
+	proto.RegisterType((*ImportNewScriptResponse)(nil), "stakepoolrpc.ImportNewScriptResponse")
+	proto.RegisterType((*StakePoolUserInfoRequest)(nil), "stakepoolrpc.StakePoolUserInfoRequest")
+	proto.RegisterType((*StakePoolUserInfoResponse)(nil), "stakepoolrpc.StakePoolUserInfoResponse")
+	proto.RegisterType((*WalletInfoRequest)(nil), "stakepoolrpc.WalletInfoRequest")
+	proto.RegisterType((*WalletInfoResponse)(nil), "stakepoolrpc.WalletInfoResponse")
+	proto.RegisterType((*ValidateAddressRequest)(nil), "stakepoolrpc.ValidateAddressRequest")
+	proto.RegisterType((*ValidateAddressResponse)(nil), "stakepoolrpc.ValidateAddressResponse")
+	proto.RegisterType((*CreateMultisigRequest)(nil), "stakepoolrpc.CreateMultisigRequest")
+	proto.RegisterType((*CreateMultisigResponse)(nil), "stakepoolrpc.CreateMultisigResponse")
+	proto.RegisterType((*StakePoolUserTicket)(nil), "stakepoolrpc.StakePoolUserTicket")
+	proto.RegisterType((*Ticket)(nil), "stakepoolrpc.Ticket")
+	proto.RegisterType((*UserVotingConfigEntry)(nil), "stakepoolrpc.UserVotingConfigEntry")
+	proto.RegisterType((*VersionRequest)(nil), "stakepoolrpc.VersionRequest")
+	proto.RegisterType((*VersionResponse)(nil), "stakepoolrpc.VersionResponse")
+	proto.RegisterType((*GetStakeInfoRequest)(nil), "stakepoolrpc.GetStakeInfoRequest")
+	proto.RegisterType((*GetStakeInfoResponse)(nil), "stakepoolrpc.GetStakeInfoResponse")
+	proto.RegisterType((*GetColdWalletExtPubRequest)(nil), "stakepoolrpc.GetColdWalletExtPubRequest")
+	proto.RegisterType((*GetColdWalletExtPubResponse)(nil), "stakepoolrpc.GetColdWalletExtPubResponse")
+	proto.RegisterType((*ExistsAddressRequest)(nil), "stakepoolrpc.ExistsAddressRequest")
+	proto.RegisterType((*ExistsAddressResponse)(nil), "stakepoolrpc.ExistsAddressResponse")
+	proto.RegisterType((*VerifyMessageRequest)(nil), "stakepoolrpc.VerifyMessageRequest")
+	proto.RegisterType((*VerifyMessageResponse)(nil), "stakepoolrpc.VerifyMessageResponse")
+	proto.RegisterType((*GetLiveTicketCountsRequest)(nil), "stakepoolrpc.GetLiveTicketCountsRequest")
+	proto.RegisterType((*GetLiveTicketCountsResponse)(nil), "stakepoolrpc.GetLiveTicketCountsResponse")
+	proto.RegisterType((*LiveTicketCount)(nil), "stakepoolrpc.LiveTicketCount")
+	proto.RegisterType((*GetLowFeeReviewRequest)(nil), "stakepoolrpc.GetLowFeeReviewRequest")
+	proto.RegisterType((*GetLowFeeReviewResponse)(nil), "stakepoolrpc.GetLowFeeReviewResponse")
+	proto.RegisterType((*ResumeLowFeeClassificationRequest)(nil), "stakepoolrpc.ResumeLowFeeClassificationRequest")
+	proto.RegisterType((*ResumeLowFeeClassificationResponse)(nil), "stakepoolrpc.ResumeLowFeeClassificationResponse")
+	proto.RegisterType((*GetTicketAmountsRequest)(nil), "stakepoolrpc.GetTicketAmountsRequest")
+	proto.RegisterType((*GetTicketAmountsResponse)(nil), "stakepoolrpc.GetTicketAmountsResponse")
+	proto.RegisterType((*TicketAmounts)(nil), "stakepoolrpc.TicketAmounts")
+	proto.RegisterType((*PromoteStandbyRequest)(nil), "stakepoolrpc.PromoteStandbyRequest")
+	proto.RegisterType((*PromoteStandbyResponse)(nil), "stakepoolrpc.PromoteStandbyResponse")
+	proto.RegisterType((*LookupTicketsRequest)(nil), "stakepoolrpc.LookupTicketsRequest")
+	proto.RegisterType((*LookupTicketsResponse)(nil), "stakepoolrpc.LookupTicketsResponse")
+	proto.RegisterType((*TicketLookup)(nil), "stakepoolrpc.TicketLookup")
+	proto.RegisterType((*BatchStakePoolUserInfoRequest)(nil), "stakepoolrpc.BatchStakePoolUserInfoRequest")
+	proto.RegisterType((*BatchStakePoolUserInfoResponse)(nil), "stakepoolrpc.BatchStakePoolUserInfoResponse")
+	proto.RegisterType((*StakePoolUserInfoResult)(nil), "stakepoolrpc.StakePoolUserInfoResult")
+	proto.RegisterType((*GetBestBlockRequest)(nil), "stakepoolrpc.GetBestBlockRequest")
+	proto.RegisterType((*GetBestBlockResponse)(nil), "stakepoolrpc.GetBestBlockResponse")
+	proto.RegisterType((*GetChainParamsRequest)(nil), "stakepoolrpc.GetChainParamsRequest")
+	proto.RegisterType((*GetChainParamsResponse)(nil), "stakepoolrpc.GetChainParamsResponse")
+	proto.RegisterType((*SendRawTransactionRequest)(nil), "stakepoolrpc.SendRawTransactionRequest")
+	proto.RegisterType((*SendRawTransactionResponse)(nil), "stakepoolrpc.SendRawTransactionResponse")
+	proto.RegisterType((*GetTransactionConfirmationsRequest)(nil), "stakepoolrpc.GetTransactionConfirmationsRequest")
+	proto.RegisterType((*GetTransactionConfirmationsResponse)(nil), "stakepoolrpc.GetTransactionConfirmationsResponse")
+	proto.RegisterType((*GetAccountExtPubRequest)(nil), "stakepoolrpc.GetAccountExtPubRequest")
+	proto.RegisterType((*GetAccountExtPubResponse)(nil), "stakepoolrpc.GetAccountExtPubResponse")
+	proto.RegisterType((*DumpStateRequest)(nil), "stakepoolrpc.DumpStateRequest")
+	proto.RegisterType((*DumpStateResponse)(nil), "stakepoolrpc.DumpStateResponse")
+	proto.RegisterType((*TicketQueue)(nil), "stakepoolrpc.TicketQueue")
+}
+
+func init() {
+	proto.RegisterFile("api.proto", fileDescriptor_00212fb1f9d3bf1c)
+}
+
+var fileDescriptor_00212fb1f9d3bf1c = []byte{
+	// 2826 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9d, 0x1a, 0xcb, 0x72, 0xdc, 0xc6,
+	0xb1, 0x96, 0x4b, 0x3d, 0x38, 0x7c, 0x88, 0x82, 0xf8, 0x58, 0x41, 0x22, 0x25, 0x41, 0x96, 0x4c,
+	0xcb, 0xb2, 0x2c, 0xd1, 0x89, 0xcb, 0x55, 0x8e, 0x2b, 0x11, 0x1f, 0xb2, 0x58, 0x26, 0x45, 0x0a,
+	0x4b, 0xc9, 0xae, 0x92, 0xcb, 0x2a, 0x70, 0x77, 0x48, 0xc2, 0xda, 0x05, 0x36, 0x00, 0x96, 0x22,
+	0x73, 0x49, 0x2a, 0xc7, 0x94, 0x73, 0xcd, 0x35, 0xe7, 0x7c, 0x42, 0xbe, 0x29, 0x55, 0x39, 0xe6,
+	0x9c, 0xee, 0x99, 0x9e, 0xc5, 0x60, 0x30, 0x58, 0xae, 0x7c, 0xe2, 0xf6, 0x63, 0x7a, 0x7a, 0x7a,
+	0xba, 0x7b, 0xba, 0x1b, 0x64, 0x13, 0x41, 0x2f, 0x7c, 0xd4, 0x4b, 0xe2, 0x2c, 0x76, 0xa6, 0xd2,
+	0x2c, 0x78, 0xc7, 0x7b, 0x71, 0xdc, 0x49, 0x7a, 0x2d, 0x6f, 0x99, 0xdd, 0xfc, 0x96, 0x67, 0x4f,
+	0xdb, 0x6d, 0xde, 0xde, 0x8e, 0xdf, 0x3f, 0xe3, 0x7c, 0x3f, 0x6c, 0xbd, 0xe3, 0x59, 0xea, 0xf3,
+	0x3f, 0xf6, 0x79, 0x9a, 0x79, 0xbb, 0x6c, 0xa9, 0x82, 0x9e, 0xf6, 0xe2, 0x28, 0xe5, 0xce, 0x23,
+	0x76, 0x29, 0x93, 0xa8, 0x46, 0xed, 0x76, 0x7d, 0x65, 0x72, 0x75, 0xee, 0x91, 0xbe, 0xc1, 0x23,
+	0xc9, 0xef, 0x2b, 0x26, 0xaf, 0xc3, 0x96, 0x41, 0xe0, 0xd6, 0x51, 0x14, 0x27, 0xf6, 0x2d, 0x9d,
+	0x05, 0x76, 0x71, 0xf7, 0xf0, 0x30, 0xe5, 0x19, 0x08, 0xac, 0xad, 0x4c, 0xfb, 0x04, 0x39, 0x73,
+	0xec, 0xc2, 0x76, 0xd8, 0x0d, 0xb3, 0xc6, 0x98, 0x40, 0x4b, 0xc0, 0xb9, 0xc9, 0x26, 0xd6, 0xe3,
+	0x7e, 0x94, 0xed, 0x46, 0x9d, 0xb3, 0x46, 0x1d, 0x28, 0x97, 0xfd, 0x1c, 0xe1, 0x1d, 0xb1, 0x5b,
+	0x95, 0xbb, 0xfd, 0xba, 0x03, 0xa0, 0x1a, 0xfb, 0x71, 0x16, 0x74, 0x94, 0x1a, 0x02, 0xf0, 0x16,
+	0xd9, 0x3c, 0x6c, 0xb4, 0x1d, 0x9e, 0x98, 0x06, 0x7c, 0xce, 0x16, 0x4c, 0xc2, 0xaf, 0xb4, 0xdc,
+	0x0b, 0x76, 0xb3, 0x39, 0xe4, 0xaa, 0x3e, 0x58, 0xde, 0x2d, 0xb6, 0xd4, 0x1c, 0x76, 0xb5, 0xde,
+	0x4d, 0xe6, 0x02, 0xc3, 0xab, 0x94, 0x27, 0xaf, 0xe3, 0x2c, 0x8c, 0x8e, 0xf6, 0x12, 0x7e, 0x98,
+	0x53, 0x23, 0x76, 0xdd, 0x46, 0x95, 0xba, 0xbc, 0x64, 0x4e, 0x1f, 0x28, 0x6f, 0x4f, 0x04, 0xe9,
+	0x6d, 0x2b, 0x8e, 0x0e, 0xc3, 0x23, 0x52, 0xeb, 0x6e, 0x51, 0xad, 0x5c, 0xc2, 0xba, 0xe0, 0xda,
+	0x8c, 0xb2, 0xe4, 0xcc, 0x9f, 0xed, 0x1b, 0x68, 0xef, 0x33, 0xb6, 0x08, 0xba, 0xee, 0x84, 0x69,
+	0x0a, 0x38, 0x3a, 0x0b, 0xed, 0xe6, 0xb0, 0xf1, 0xe7, 0x41, 0x7a, 0x2c, 0xfc, 0x65, 0xca, 0x17,
+	0xbf, 0x3d, 0x97, 0x35, 0xca, 0xec, 0xa4, 0xfa, 0x37, 0xec, 0x2a, 0xdc, 0x89, 0x61, 0xbe, 0x15,
+	0x76, 0x65, 0x2b, 0x6a, 0x75, 0xfa, 0x6d, 0xbe, 0xd5, 0xed, 0x06, 0x59, 0x3f, 0xe1, 0x42, 0xde,
+	0x65, 0xdf, 0x44, 0x7b, 0x8f, 0x98, 0xa3, 0x2f, 0xa7, 0xeb, 0x6c, 0xb0, 0x4b, 0xfb, 0x9a, 0xf9,
+	0xa7, 0x7c, 0x05, 0x62, 0x8c, 0x6d, 0x87, 0x69, 0xb6, 0xd5, 0xed, 0xc5, 0x49, 0xc6, 0xdb, 0xa0,
+	0x56, 0xc2, 0xd3, 0x94, 0x0f, 0x5c, 0xe4, 0x1b, 0xb6, 0x54, 0x41, 0x27, 0xd1, 0xe0, 0xe3, 0x03,
+	0xa4, 0x10, 0x3e, 0xe1, 0xe7, 0x08, 0xef, 0x98, 0x2d, 0x3f, 0x6d, 0xb5, 0xd0, 0xe5, 0x9b, 0x67,
+	0x51, 0x8b, 0xf0, 0x5b, 0x51, 0x9b, 0x9f, 0xaa, 0xa3, 0x81, 0x6a, 0xc4, 0x21, 0x8e, 0x34, 0xe1,
+	0x2b, 0x10, 0x63, 0x6d, 0x2d, 0x09, 0xa2, 0xd6, 0x31, 0x79, 0x33, 0x41, 0xe8, 0xe4, 0x42, 0x82,
+	0x88, 0xa8, 0xba, 0x2f, 0x01, 0xef, 0x0e, 0xbb, 0x55, 0xb9, 0x13, 0x99, 0xf6, 0x0d, 0xbb, 0x21,
+	0xcf, 0x41, 0x96, 0x6f, 0xb6, 0x92, 0xb0, 0x97, 0x1b, 0x19, 0x34, 0x21, 0x8c, 0x32, 0x12, 0x81,
+	0x8e, 0xc7, 0xa6, 0x40, 0x48, 0x2b, 0x88, 0x9e, 0xf3, 0xf0, 0xe8, 0x58, 0x06, 0x79, 0xdd, 0x2f,
+	0xe0, 0xd0, 0x90, 0x76, 0xe1, 0xb4, 0xf9, 0x63, 0xb6, 0x20, 0xe9, 0x2f, 0xf8, 0x7b, 0x49, 0xd3,
+	0x72, 0x8a, 0x44, 0x90, 0x8f, 0x10, 0xe4, 0x3d, 0x65, 0x8b, 0xa5, 0x15, 0x64, 0xf4, 0xfb, 0x6c,
+	0x46, 0x6e, 0xab, 0xee, 0x45, 0x2c, 0xad, 0xfb, 0x06, 0xd6, 0xdb, 0x60, 0x8d, 0x26, 0xfa, 0xf3,
+	0x1e, 0xf8, 0x33, 0xfa, 0xf2, 0x56, 0x74, 0x18, 0x6b, 0x3e, 0xb5, 0xd3, 0xef, 0x64, 0x61, 0x33,
+	0x3c, 0x22, 0x6b, 0xd1, 0x05, 0x98, 0x68, 0xef, 0x2f, 0x35, 0x08, 0xa7, 0xb2, 0x18, 0xd2, 0xe5,
+	0xeb, 0xa2, 0x6f, 0x4d, 0xae, 0xde, 0x29, 0xc6, 0x50, 0x61, 0xa5, 0x8a, 0x73, 0x5a, 0x81, 0x07,
+	0xd9, 0x8a, 0x4e, 0x82, 0x4e, 0xd8, 0x56, 0x32, 0xc6, 0x84, 0x0b, 0x19, 0x58, 0xef, 0x1a, 0xbb,
+	0xfa, 0x7d, 0xd0, 0xe9, 0x40, 0xba, 0xcc, 0x4f, 0xe0, 0xfd, 0xaf, 0xce, 0x1c, 0x1d, 0x4b, 0x0a,
+	0xdd, 0x66, 0x93, 0x10, 0x9c, 0xfc, 0x35, 0x4f, 0xd2, 0x30, 0x8e, 0x28, 0x51, 0xeb, 0x28, 0x3c,
+	0xfa, 0x46, 0xc0, 0xbb, 0x71, 0x04, 0xe1, 0x1b, 0xf1, 0x16, 0xda, 0x6f, 0x4c, 0x86, 0x93, 0x81,
+	0x76, 0x5c, 0x76, 0xf9, 0x55, 0xd4, 0x89, 0x41, 0x89, 0x36, 0x25, 0xf0, 0x01, 0x8c, 0xf7, 0x26,
+	0x93, 0x40, 0x63, 0x5c, 0x50, 0x08, 0x12, 0x7e, 0x94, 0x05, 0x51, 0xfb, 0xe0, 0xac, 0x71, 0x41,
+	0x10, 0x14, 0x28, 0xc3, 0x58, 0x9c, 0x0b, 0xb5, 0x59, 0x0b, 0xe1, 0xb8, 0x17, 0x85, 0x76, 0x26,
+	0xda, 0x59, 0x66, 0x4c, 0x7a, 0x57, 0x84, 0xf2, 0x2f, 0x09, 0x31, 0x1a, 0xc6, 0x79, 0xc0, 0x66,
+	0x25, 0xf4, 0x2c, 0x89, 0xbb, 0xe4, 0x95, 0x97, 0x85, 0x0b, 0x94, 0xf0, 0xce, 0x47, 0x6c, 0x5a,
+	0xe2, 0x40, 0x0d, 0xe1, 0x2b, 0x13, 0x82, 0xb1, 0x88, 0xc4, 0x1d, 0x9b, 0x3c, 0x39, 0xc1, 0x2b,
+	0xea, 0xf2, 0x06, 0x13, 0x2c, 0x1a, 0x06, 0x77, 0x94, 0xb6, 0x46, 0x88, 0xde, 0xc0, 0x49, 0xb9,
+	0xa3, 0x89, 0x17, 0xcf, 0xd0, 0x29, 0x24, 0xed, 0xc6, 0x14, 0x30, 0xd4, 0x7c, 0x09, 0x60, 0xa6,
+	0x90, 0xd7, 0x89, 0x94, 0x69, 0x41, 0xc9, 0x11, 0x28, 0x5f, 0x02, 0x7b, 0xfd, 0xa4, 0x75, 0x1c,
+	0x60, 0x08, 0x35, 0x66, 0xc4, 0xb9, 0x4b, 0x78, 0x6f, 0x95, 0x2d, 0xbc, 0x46, 0x73, 0x05, 0x19,
+	0x27, 0x1f, 0xd5, 0xb3, 0x49, 0xc1, 0x99, 0x15, 0xe8, 0xbd, 0x64, 0x8b, 0xa5, 0x35, 0xe4, 0x30,
+	0x70, 0x91, 0x5b, 0xe9, 0x4e, 0x18, 0xa9, 0xa4, 0x4a, 0x10, 0x9a, 0x64, 0xaf, 0x7f, 0xf0, 0x1d,
+	0x3f, 0xc3, 0x05, 0xc2, 0x43, 0x26, 0x7c, 0x0d, 0xe3, 0x3d, 0x61, 0xf3, 0xeb, 0x09, 0x07, 0x81,
+	0x22, 0x60, 0xd2, 0xf0, 0xc8, 0xaa, 0x45, 0x5d, 0xd7, 0xe2, 0x35, 0x5b, 0x30, 0x97, 0x90, 0x12,
+	0x22, 0xc7, 0xb4, 0x39, 0xef, 0x6a, 0xb9, 0x60, 0xc2, 0x2f, 0xe0, 0x74, 0xb9, 0x63, 0xc5, 0xd3,
+	0xfd, 0xab, 0xc6, 0xae, 0x59, 0x02, 0x4d, 0xe4, 0x96, 0x0c, 0x5e, 0x06, 0x65, 0x0e, 0x82, 0x10,
+	0x2f, 0x39, 0x48, 0x10, 0x41, 0xa8, 0x85, 0xfc, 0x45, 0x3e, 0x55, 0x17, 0xee, 0x59, 0xc0, 0x09,
+	0xff, 0xee, 0xf1, 0x28, 0x5b, 0x3b, 0x13, 0x8e, 0x0f, 0x5a, 0x10, 0x88, 0x9e, 0x46, 0x3f, 0x69,
+	0xf9, 0x05, 0xb1, 0xbc, 0x88, 0xf4, 0xbe, 0x54, 0x7b, 0x57, 0xdf, 0xd6, 0xe0, 0xd5, 0x1c, 0xd3,
+	0x5e, 0xcd, 0x7f, 0xd6, 0xd8, 0xbc, 0xf5, 0x41, 0xc6, 0xd3, 0x88, 0xb4, 0xa4, 0xd2, 0x20, 0x41,
+	0xb6, 0x14, 0x37, 0x66, 0x4d, 0x71, 0x18, 0xe7, 0x83, 0x90, 0x94, 0xcf, 0xca, 0x00, 0x46, 0x29,
+	0xea, 0xb7, 0xca, 0x29, 0xe3, 0x82, 0xc5, 0x44, 0x7b, 0xb3, 0x6c, 0x86, 0x7e, 0xaa, 0x14, 0xf5,
+	0x9f, 0x1a, 0x2c, 0x56, 0x28, 0xba, 0xe9, 0x7b, 0x6c, 0xe6, 0x44, 0xa2, 0xde, 0xa6, 0x59, 0x82,
+	0x7e, 0x2e, 0x0f, 0x3f, 0x4d, 0xd8, 0xa6, 0x40, 0x62, 0x10, 0x75, 0x83, 0x9f, 0xe3, 0x44, 0xd5,
+	0x72, 0x02, 0x10, 0xd8, 0x10, 0x2a, 0x46, 0xba, 0x19, 0x09, 0x20, 0xb6, 0x17, 0x64, 0xf0, 0x52,
+	0x8e, 0x4b, 0xac, 0x00, 0xd0, 0x7f, 0x7b, 0x09, 0x4f, 0x78, 0x87, 0x07, 0x29, 0x17, 0x77, 0x01,
+	0xfe, 0x9b, 0x63, 0x50, 0x91, 0x83, 0x7e, 0xd8, 0x69, 0xbf, 0xed, 0xf2, 0x2c, 0x80, 0xc0, 0x08,
+	0x44, 0x36, 0x02, 0x45, 0x04, 0x76, 0x87, 0x90, 0x70, 0xfe, 0xd9, 0x6e, 0x70, 0x0a, 0x4c, 0x69,
+	0x1a, 0x1c, 0xf1, 0xb7, 0x69, 0xf8, 0x27, 0x2e, 0x32, 0xd2, 0xb4, 0x3f, 0x03, 0xf8, 0x1d, 0x89,
+	0x6e, 0x02, 0xd6, 0x9b, 0x67, 0xd7, 0xa0, 0xf8, 0x10, 0x7e, 0xa8, 0xe7, 0xe9, 0x5f, 0xc6, 0xd9,
+	0x5c, 0x11, 0x9f, 0x67, 0xea, 0x35, 0x4c, 0xa6, 0xe4, 0x2d, 0xf2, 0xf2, 0x74, 0x14, 0x1e, 0x61,
+	0x23, 0x3c, 0x3c, 0x0c, 0x5b, 0x70, 0x5f, 0x67, 0xc2, 0x12, 0x35, 0x5f, 0xc3, 0x08, 0x7f, 0xc5,
+	0x1a, 0xb7, 0xd9, 0x3f, 0x48, 0xc3, 0xb6, 0x2c, 0xb2, 0x6b, 0x7e, 0x01, 0x87, 0x5e, 0xb9, 0xfb,
+	0x3e, 0xda, 0xe1, 0x5d, 0x7c, 0x91, 0xf6, 0xc3, 0x53, 0x32, 0x52, 0x11, 0x89, 0x1e, 0x30, 0xa8,
+	0xad, 0xa4, 0xdb, 0x0e, 0x60, 0xf4, 0xd3, 0x57, 0x51, 0x8a, 0x4e, 0x4c, 0xf9, 0x5a, 0x81, 0x68,
+	0x78, 0x74, 0x82, 0x36, 0x19, 0x44, 0x02, 0xc8, 0xef, 0xf3, 0x93, 0x18, 0x1f, 0x8d, 0xcb, 0x92,
+	0x9f, 0x40, 0x7c, 0xef, 0x68, 0xe9, 0xe6, 0x69, 0x2f, 0x4c, 0x28, 0x19, 0x83, 0x25, 0x8b, 0x58,
+	0xd4, 0x06, 0x23, 0x19, 0xad, 0x2a, 0x72, 0x31, 0x68, 0xa3, 0x60, 0x3c, 0xcf, 0xd3, 0x4e, 0x47,
+	0x3b, 0xcf, 0xa4, 0x3c, 0x4f, 0x01, 0x89, 0x11, 0x84, 0x85, 0xbd, 0x48, 0xc1, 0xd3, 0xbe, 0xf8,
+	0x8d, 0xbb, 0xef, 0x25, 0x31, 0xd6, 0x06, 0xe0, 0x66, 0x82, 0x2a, 0xd3, 0xb0, 0x81, 0xc5, 0x78,
+	0xc2, 0x2a, 0x06, 0xb4, 0x9b, 0x91, 0x95, 0x97, 0x84, 0x30, 0x47, 0xe7, 0x9c, 0xc4, 0x71, 0x45,
+	0x48, 0x28, 0xe1, 0xd1, 0x06, 0xea, 0x88, 0xb3, 0xd2, 0x06, 0x04, 0x62, 0xe9, 0x0e, 0xde, 0xb0,
+	0x1e, 0x77, 0xda, 0xf2, 0xe1, 0xd8, 0x3c, 0x85, 0xdc, 0x7e, 0xa0, 0x9c, 0x65, 0x8b, 0xdd, 0xb0,
+	0x52, 0xc9, 0x65, 0x40, 0x05, 0x93, 0x46, 0xe1, 0x53, 0xc2, 0x43, 0xc9, 0x35, 0xb7, 0x79, 0x0a,
+	0xc5, 0x6b, 0x3a, 0xf2, 0x23, 0xf1, 0x39, 0x9b, 0x37, 0x56, 0xe4, 0x4f, 0x84, 0x24, 0xa8, 0x27,
+	0x42, 0x42, 0x50, 0xdf, 0xce, 0x41, 0x78, 0x87, 0x87, 0x67, 0x14, 0x06, 0xe7, 0x6e, 0x81, 0x14,
+	0xe2, 0x55, 0x39, 0x9c, 0x40, 0x7c, 0x1f, 0x21, 0x23, 0x45, 0xd2, 0x05, 0xeb, 0x82, 0x96, 0x23,
+	0xa0, 0xc5, 0x98, 0x37, 0x76, 0x22, 0xd5, 0xd0, 0x05, 0xf1, 0x61, 0x23, 0xcd, 0x24, 0x40, 0x46,
+	0xce, 0x5b, 0x3b, 0xd1, 0x76, 0x0e, 0xaa, 0xfa, 0x7d, 0x61, 0xe4, 0x32, 0x95, 0x44, 0xfe, 0x96,
+	0x5d, 0x94, 0x18, 0xaa, 0xe8, 0x96, 0x8a, 0x15, 0x9d, 0xb1, 0xce, 0x27, 0x66, 0x78, 0x62, 0xaf,
+	0x18, 0xa4, 0xd1, 0x8b, 0x4c, 0x3c, 0x86, 0x58, 0xa2, 0xd2, 0x9d, 0x00, 0xbc, 0x86, 0xec, 0x50,
+	0x45, 0x0b, 0x08, 0x31, 0x14, 0xf2, 0xf7, 0xea, 0x08, 0x01, 0x5b, 0x2c, 0x51, 0xf2, 0xcb, 0xda,
+	0x0b, 0xfa, 0x29, 0x57, 0x26, 0x21, 0x08, 0x9b, 0x50, 0xbd, 0xca, 0xac, 0x6c, 0x42, 0x55, 0xd1,
+	0x79, 0x97, 0xdd, 0x01, 0x99, 0xfd, 0x2e, 0x97, 0xbb, 0xac, 0x77, 0x02, 0xa8, 0xec, 0x21, 0xf3,
+	0x04, 0x99, 0x96, 0xe1, 0xff, 0xc0, 0xbc, 0x61, 0x4c, 0xa4, 0x12, 0xc4, 0xb3, 0x2f, 0xb3, 0x6e,
+	0x9b, 0x0a, 0xd2, 0x01, 0x0c, 0x65, 0xc4, 0xe2, 0xa0, 0x65, 0x7b, 0xda, 0xd5, 0xef, 0x09, 0x4f,
+	0x82, 0x4f, 0x1f, 0x57, 0x1d, 0x09, 0x41, 0x60, 0xe9, 0x46, 0x79, 0xc9, 0xe0, 0xf2, 0x2e, 0x11,
+	0x8a, 0x6e, 0xef, 0x86, 0xed, 0x94, 0x6a, 0x95, 0xe2, 0xc5, 0xd7, 0x75, 0xba, 0x40, 0xb2, 0x75,
+	0xae, 0x98, 0xb1, 0xa9, 0x1a, 0x4b, 0xc2, 0x16, 0xa7, 0x46, 0x48, 0x47, 0x89, 0xea, 0x40, 0x4b,
+	0xc6, 0x75, 0x5f, 0x81, 0xa2, 0x9c, 0x02, 0x1d, 0xf6, 0x82, 0xb3, 0xb8, 0x9f, 0xd1, 0x13, 0xaa,
+	0x61, 0x90, 0x8e, 0xef, 0x36, 0xd1, 0x2f, 0x48, 0x7a, 0x8e, 0xc1, 0x31, 0x06, 0x64, 0x99, 0x2e,
+	0x64, 0x58, 0xaa, 0xa7, 0xd5, 0x15, 0x7c, 0xc5, 0x16, 0x4c, 0x02, 0xd9, 0x02, 0x44, 0x7e, 0x1f,
+	0xa4, 0xaa, 0x1a, 0x97, 0xde, 0xa0, 0x61, 0xbc, 0x9f, 0xd8, 0xdc, 0x76, 0x1c, 0xbf, 0xeb, 0xf7,
+	0x8c, 0x7e, 0xbb, 0xb2, 0x5f, 0x76, 0x1e, 0xb2, 0xab, 0x86, 0xe7, 0x72, 0xd5, 0xb3, 0x94, 0x09,
+	0xde, 0x0e, 0x9b, 0x37, 0xe4, 0x93, 0x62, 0xbf, 0x31, 0x9b, 0x26, 0xd7, 0x76, 0x49, 0x72, 0x6d,
+	0xee, 0x90, 0x2f, 0x54, 0x75, 0x26, 0x09, 0xd6, 0x1b, 0xaa, 0xac, 0x11, 0x9d, 0x59, 0x56, 0x6f,
+	0xf2, 0x8c, 0x32, 0x0b, 0xfe, 0x04, 0xf5, 0x96, 0xd6, 0xb0, 0x52, 0xa8, 0xec, 0x11, 0xad, 0xa7,
+	0xad, 0x55, 0x9d, 0x36, 0x60, 0xcb, 0x55, 0xe2, 0xe8, 0xd8, 0xbf, 0xc7, 0x87, 0x31, 0x85, 0x85,
+	0xea, 0xd8, 0xf7, 0x86, 0xf4, 0x8a, 0xb4, 0x12, 0xb8, 0x7d, 0xb5, 0xca, 0xfb, 0x47, 0x8d, 0x2d,
+	0x56, 0x30, 0x7d, 0x40, 0xae, 0xf9, 0x9a, 0x8d, 0xe3, 0x3a, 0x61, 0xa0, 0xc9, 0xd5, 0x8f, 0xcf,
+	0xd7, 0x41, 0x68, 0xef, 0x8b, 0x45, 0x98, 0xa8, 0x36, 0x93, 0x84, 0x2a, 0xb0, 0x09, 0x5f, 0x02,
+	0x54, 0xfa, 0xac, 0x81, 0xd1, 0x44, 0xf9, 0xa2, 0x5c, 0x73, 0x4d, 0x54, 0x3e, 0x1a, 0x9a, 0x0c,
+	0x61, 0xbb, 0x39, 0x0c, 0x76, 0x7d, 0xbe, 0x40, 0x10, 0x8d, 0xef, 0xd6, 0x8f, 0x83, 0x30, 0xda,
+	0x0b, 0x92, 0xa0, 0x3b, 0xc8, 0xe2, 0x7f, 0xab, 0x89, 0xec, 0x58, 0xa0, 0xe4, 0x03, 0x9f, 0x17,
+	0x3c, 0x7b, 0x11, 0x74, 0xb9, 0x7a, 0x7f, 0x08, 0xc4, 0x08, 0xfe, 0x96, 0x47, 0x3c, 0x0d, 0x53,
+	0xad, 0xc0, 0xd6, 0x51, 0xaa, 0xf6, 0x80, 0x64, 0x96, 0x52, 0x3d, 0x35, 0x80, 0x51, 0x2e, 0xfc,
+	0xdd, 0x89, 0xdb, 0x5c, 0xd5, 0xfe, 0x04, 0x7a, 0x9f, 0xe2, 0xc8, 0x2d, 0x6a, 0xfb, 0xc1, 0xfb,
+	0xfd, 0x24, 0x88, 0xd2, 0xa0, 0xa5, 0x25, 0x49, 0x67, 0x86, 0x8d, 0xed, 0x9f, 0xd2, 0x61, 0xe1,
+	0x17, 0xbc, 0xcc, 0xae, 0x8d, 0xb9, 0xda, 0x38, 0x10, 0xe3, 0x1e, 0x66, 0xbc, 0x9c, 0x5b, 0xd4,
+	0xff, 0x49, 0x57, 0xa4, 0xd9, 0x74, 0xd8, 0xb0, 0xed, 0x3b, 0x76, 0x77, 0xe8, 0x4a, 0xda, 0x14,
+	0xaa, 0xaa, 0x02, 0x81, 0xaa, 0xd1, 0x22, 0xd2, 0xfb, 0x42, 0xe4, 0x6a, 0x1a, 0x34, 0x15, 0x0a,
+	0x97, 0xea, 0x41, 0x16, 0xb4, 0xab, 0x8d, 0xf2, 0x22, 0xbd, 0xb0, 0xd0, 0xaa, 0x18, 0x82, 0xbc,
+	0x5f, 0x6a, 0x6c, 0x76, 0xa3, 0xdf, 0xed, 0x61, 0xbf, 0xc6, 0xcb, 0x63, 0x40, 0xd0, 0x2a, 0xe3,
+	0xd1, 0xa0, 0x1c, 0x31, 0xd1, 0xc8, 0x09, 0x9d, 0x23, 0x9c, 0x57, 0x4f, 0x52, 0x82, 0xd3, 0x40,
+	0xcb, 0xe9, 0x00, 0xa2, 0x64, 0xcf, 0x94, 0xd2, 0x98, 0xa3, 0x88, 0xf4, 0xfe, 0x3d, 0xce, 0xae,
+	0x6a, 0xea, 0x90, 0xf2, 0x5f, 0x89, 0xb1, 0xa7, 0x31, 0xa2, 0x5d, 0x1f, 0x98, 0x60, 0xda, 0xaf,
+	0x22, 0x3b, 0xbf, 0x63, 0xd7, 0x6d, 0x83, 0x6f, 0xbd, 0x02, 0xa8, 0x66, 0xc0, 0x22, 0x50, 0x1b,
+	0x5a, 0xcb, 0x45, 0xb2, 0x1f, 0x2a, 0xe1, 0x21, 0xd3, 0x96, 0x9a, 0x46, 0xb9, 0x40, 0x76, 0x01,
+	0x76, 0xa2, 0xb3, 0xc1, 0x9c, 0xb2, 0xea, 0xf0, 0x26, 0x55, 0x57, 0x0d, 0x16, 0x7e, 0xe7, 0x39,
+	0x9b, 0xb3, 0x1d, 0x02, 0x9a, 0x88, 0x6a, 0x39, 0xd6, 0x15, 0xce, 0x97, 0x6c, 0x52, 0x3b, 0x19,
+	0x74, 0x1b, 0xd5, 0x02, 0x74, 0x46, 0x67, 0x97, 0xcd, 0x9a, 0x07, 0x84, 0x96, 0x64, 0xf4, 0x49,
+	0xb7, 0x89, 0x76, 0x9e, 0xb0, 0x8b, 0x2f, 0xfb, 0x1c, 0xbc, 0x11, 0x1a, 0x17, 0x14, 0x73, 0xdd,
+	0xa6, 0x83, 0xe0, 0xf0, 0x89, 0xd1, 0xfb, 0x7b, 0x4d, 0x15, 0x0d, 0x02, 0x81, 0x41, 0xaa, 0x25,
+	0x26, 0xf1, 0x1b, 0x93, 0xea, 0x06, 0xef, 0x65, 0x6a, 0xd4, 0x2b, 0x01, 0xcc, 0x44, 0xeb, 0x41,
+	0x2f, 0x68, 0x85, 0xd9, 0x19, 0xdd, 0xef, 0x00, 0x46, 0xda, 0x4e, 0x70, 0x2a, 0x17, 0xc9, 0xab,
+	0x1c, 0xc0, 0x58, 0x49, 0x43, 0x41, 0xd0, 0xe2, 0xa2, 0x41, 0xc1, 0x42, 0x62, 0xdc, 0xcf, 0x11,
+	0xab, 0xff, 0x6d, 0xb0, 0xab, 0x4d, 0xa5, 0x74, 0x1b, 0x47, 0x5c, 0x58, 0xb7, 0xf4, 0x44, 0x96,
+	0xb5, 0x5c, 0xe2, 0x83, 0xe2, 0x09, 0x87, 0x7d, 0x91, 0x72, 0x3f, 0x1d, 0x89, 0x97, 0xa2, 0xe7,
+	0x44, 0xe4, 0x12, 0xeb, 0x75, 0x3f, 0x2c, 0xc9, 0x19, 0xf2, 0x51, 0xca, 0xfd, 0x6c, 0x44, 0x6e,
+	0xda, 0xf7, 0x0d, 0x9b, 0x29, 0x7e, 0xf5, 0x71, 0xee, 0x96, 0x04, 0x94, 0x3f, 0x16, 0xb9, 0x1f,
+	0x0d, 0x67, 0x22, 0xe1, 0x60, 0xc6, 0xe6, 0x28, 0x66, 0x6c, 0x7e, 0x80, 0x19, 0x87, 0x7e, 0x09,
+	0x72, 0x8e, 0x98, 0x53, 0xfe, 0xd6, 0xe3, 0x7c, 0x5c, 0x12, 0x61, 0xff, 0x1a, 0xe4, 0xae, 0x9c,
+	0xcf, 0x48, 0x1b, 0xfd, 0x04, 0xd9, 0xb7, 0x38, 0x8f, 0x77, 0x0c, 0x9b, 0xd8, 0x07, 0xfc, 0xee,
+	0xbd, 0x73, 0xb8, 0x48, 0x7e, 0x17, 0xb2, 0x85, 0xe5, 0x0b, 0x82, 0xf3, 0x89, 0x6d, 0xb9, 0xf5,
+	0x13, 0x86, 0xfb, 0x60, 0x14, 0x56, 0xda, 0xae, 0x4d, 0x51, 0xa0, 0x97, 0x3a, 0xce, 0xfd, 0x73,
+	0x6b, 0x21, 0xb9, 0xd1, 0xa8, 0x35, 0x13, 0x24, 0x20, 0x96, 0x8f, 0xe8, 0x9d, 0x5b, 0xc5, 0x65,
+	0xa5, 0x91, 0xbe, 0x7b, 0xbb, 0x9a, 0x21, 0xbf, 0x05, 0x63, 0x8e, 0x6b, 0xde, 0x82, 0x7d, 0x34,
+	0x6c, 0xde, 0x42, 0xd5, 0x30, 0x38, 0x60, 0xb3, 0xe6, 0xb7, 0x39, 0xc7, 0x58, 0x5a, 0xf1, 0xa9,
+	0xcf, 0xbd, 0x7f, 0x1e, 0x5b, 0x6e, 0x93, 0xfc, 0x1b, 0x9d, 0x69, 0x93, 0xd2, 0xc7, 0x3f, 0xd3,
+	0x26, 0x96, 0xcf, 0x7b, 0x10, 0x74, 0xd6, 0x8f, 0x74, 0x66, 0xd0, 0x0d, 0xfb, 0xd2, 0x67, 0x06,
+	0xdd, 0xf0, 0xaf, 0x7e, 0x90, 0xbb, 0x2a, 0xbe, 0xb6, 0x99, 0xb9, 0x6b, 0xf8, 0xe7, 0x3f, 0x33,
+	0x77, 0x9d, 0xf3, 0x09, 0x0f, 0x73, 0x57, 0x71, 0x7e, 0x6e, 0xe6, 0x2e, 0xeb, 0x40, 0xde, 0xcc,
+	0x5d, 0x15, 0x23, 0xf8, 0x57, 0x6c, 0x4a, 0x1f, 0x53, 0x3a, 0x77, 0x4a, 0x86, 0x37, 0x47, 0x9b,
+	0xae, 0x37, 0x8c, 0x85, 0xc4, 0xfe, 0x2c, 0x5a, 0x03, 0x73, 0x3a, 0xe5, 0xac, 0x94, 0x96, 0x56,
+	0x8c, 0xc4, 0xdc, 0x4f, 0x46, 0xe0, 0xa4, 0xbd, 0x7e, 0x60, 0xd3, 0x85, 0x01, 0x96, 0x63, 0x28,
+	0x68, 0x9b, 0x87, 0xb9, 0x77, 0x87, 0xf2, 0xe4, 0x92, 0x0b, 0xf3, 0x27, 0x53, 0xb2, 0x6d, 0x0c,
+	0x66, 0x4a, 0xb6, 0x0f, 0xb0, 0xa4, 0x7d, 0xcc, 0x61, 0x94, 0xc5, 0x3e, 0x15, 0xd3, 0x2c, 0x8b,
+	0x7d, 0x2a, 0x27, 0x5b, 0x90, 0x3d, 0x8c, 0xa9, 0x91, 0x63, 0x79, 0xd7, 0xca, 0xe3, 0x26, 0x33,
+	0x7b, 0x54, 0x8d, 0x9e, 0xfe, 0xcc, 0xdc, 0xea, 0x69, 0x90, 0xf3, 0x79, 0x51, 0xc8, 0xb9, 0xc3,
+	0x25, 0xf7, 0xf1, 0xe8, 0x0b, 0xf2, 0xf4, 0x65, 0x4e, 0x86, 0x9c, 0x7b, 0x15, 0x09, 0xa4, 0x38,
+	0x6c, 0x32, 0xd3, 0x57, 0xe5, 0x80, 0xe9, 0x8d, 0x98, 0x22, 0x6b, 0xe3, 0x16, 0x33, 0x06, 0xad,
+	0x53, 0x1a, 0x33, 0x06, 0x2b, 0x26, 0x36, 0xe0, 0x66, 0x85, 0x89, 0x89, 0xe9, 0x66, 0xb6, 0x71,
+	0x8d, 0xe9, 0x66, 0xf6, 0x91, 0x4b, 0xca, 0x16, 0xec, 0xd3, 0x09, 0xc7, 0xc8, 0x7c, 0x43, 0x47,
+	0x22, 0xee, 0xc3, 0xd1, 0x98, 0x0b, 0x29, 0x65, 0xd0, 0xff, 0x5b, 0x52, 0x8a, 0x39, 0x32, 0xb0,
+	0xa4, 0x94, 0xf2, 0xf8, 0x40, 0x96, 0x70, 0x5a, 0xe3, 0x6f, 0x29, 0xe1, 0xca, 0x03, 0x03, 0x4b,
+	0x09, 0x67, 0x9b, 0x1d, 0x88, 0x82, 0xca, 0x6c, 0xce, 0xcb, 0x05, 0x55, 0x45, 0xaf, 0x5f, 0x2e,
+	0xa8, 0x2a, 0xfb, 0xfc, 0xbf, 0xd6, 0xc4, 0x18, 0xba, 0xaa, 0x35, 0x77, 0x1e, 0x97, 0x1d, 0x72,
+	0x78, 0xff, 0xef, 0x3e, 0xf9, 0x80, 0x15, 0x85, 0x80, 0x29, 0x34, 0xe7, 0x96, 0x80, 0xb1, 0x75,
+	0xfc, 0x96, 0x80, 0xb1, 0xf6, 0xf8, 0xab, 0x3f, 0x0c, 0x3e, 0x0b, 0xaa, 0x66, 0xe3, 0x19, 0xbb,
+	0xa4, 0xfe, 0x17, 0xe1, 0x66, 0x29, 0x45, 0x6a, 0xdf, 0x0f, 0xdd, 0xa5, 0x0a, 0x2a, 0x49, 0xfe,
+	0x91, 0x4d, 0x6d, 0xf0, 0x83, 0xfe, 0x91, 0x92, 0xbb, 0xcd, 0x26, 0x06, 0x5d, 0xba, 0xb3, 0x5c,
+	0x5c, 0x6b, 0x4e, 0x13, 0xdc, 0x5b, 0x95, 0x74, 0x29, 0xfd, 0xe0, 0xa2, 0xf8, 0xa7, 0xbc, 0x2f,
+	0xfe, 0x0f, 0x63, 0xd4, 0x87, 0x25, 0xa1, 0x27, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// StakepooldServiceClient is the client API for StakepooldService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type StakepooldServiceClient interface {
+	GetAddedLowFeeTickets(ctx context.Context, in *GetAddedLowFeeTicketsRequest, opts ...grpc.CallOption) (*GetAddedLowFeeTicketsResponse, error)
+	GetIgnoredLowFeeTickets(ctx context.Context, in *GetIgnoredLowFeeTicketsRequest, opts ...grpc.CallOption) (*GetIgnoredLowFeeTicketsResponse, error)
+	GetLiveTickets(ctx context.Context, in *GetLiveTicketsRequest, opts ...grpc.CallOption) (*GetLiveTicketsResponse, error)
+	SetAddedLowFeeTickets(ctx context.Context, in *SetAddedLowFeeTicketsRequest, opts ...grpc.CallOption) (*SetAddedLowFeeTicketsResponse, error)
+	SetUserVotingPrefs(ctx context.Context, in *SetUserVotingPrefsRequest, opts ...grpc.CallOption) (*SetUserVotingPrefsResponse, error)
+	ImportNewScript(ctx context.Context, in *ImportNewScriptRequest, opts ...grpc.CallOption) (*ImportNewScriptResponse, error)
+	ImportMissingScripts(ctx context.Context, in *ImportMissingScriptsRequest, opts ...grpc.CallOption) (*ImportMissingScriptsResponse, error)
+	StakePoolUserInfo(ctx context.Context, in *StakePoolUserInfoRequest, opts ...grpc.CallOption) (*StakePoolUserInfoResponse, error)
+	WalletInfo(ctx context.Context, in *WalletInfoRequest, opts ...grpc.CallOption) (*WalletInfoResponse, error)
+	ValidateAddress(ctx context.Context, in *ValidateAddressRequest, opts ...grpc.CallOption) (*ValidateAddressResponse, error)
+	AddMissingTicket(ctx context.Context, in *AddMissingTicketRequest, opts ...grpc.CallOption) (*AddMissingTicketResponse, error)
+	GetTickets(ctx context.Context, in *GetTicketsRequest, opts ...grpc.CallOption) (*GetTicketsResponse, error)
+	ListImportedAddresses(ctx context.Context, in *ListImportedAddressesRequest, opts ...grpc.CallOption) (*ListImportedAddressesResponse, error)
+	AccountSyncAddressIndex(ctx context.Context, in *AccountSyncAddressIndexRequest, opts ...grpc.CallOption) (*AccountSyncAddressIndexResponse, error)
+	CreateMultisig(ctx context.Context, in *CreateMultisigRequest, opts ...grpc.CallOption) (*CreateMultisigResponse, error)
+	GetStakeInfo(ctx context.Context, in *GetStakeInfoRequest, opts ...grpc.CallOption) (*GetStakeInfoResponse, error)
+	GetColdWalletExtPub(ctx context.Context, in *GetColdWalletExtPubRequest, opts ...grpc.CallOption) (*GetColdWalletExtPubResponse, error)
+	ExistsAddress(ctx context.Context, in *ExistsAddressRequest, opts ...grpc.CallOption) (*ExistsAddressResponse, error)
+	VerifyMessage(ctx context.Context, in *VerifyMessageRequest, opts ...grpc.CallOption) (*VerifyMessageResponse, error)
+	GetLiveTicketCounts(ctx context.Context, in *GetLiveTicketCountsRequest, opts ...grpc.CallOption) (*GetLiveTicketCountsResponse, error)
+	GetLowFeeReview(ctx context.Context, in *GetLowFeeReviewRequest, opts ...grpc.CallOption) (*GetLowFeeReviewResponse, error)
+	ResumeLowFeeClassification(ctx context.Context, in *ResumeLowFeeClassificationRequest, opts ...grpc.CallOption) (*ResumeLowFeeClassificationResponse, error)
+	GetTicketAmounts(ctx context.Context, in *GetTicketAmountsRequest, opts ...grpc.CallOption) (*GetTicketAmountsResponse, error)
+	PromoteStandby(ctx context.Context, in *PromoteStandbyRequest, opts ...grpc.CallOption) (*PromoteStandbyResponse, error)
+	LookupTickets(ctx context.Context, in *LookupTicketsRequest, opts ...grpc.CallOption) (*LookupTicketsResponse, error)
+	BatchStakePoolUserInfo(ctx context.Context, in *BatchStakePoolUserInfoRequest, opts ...grpc.CallOption) (*BatchStakePoolUserInfoResponse, error)
+	GetBestBlock(ctx context.Context, in *GetBestBlockRequest, opts ...grpc.CallOption) (*GetBestBlockResponse, error)
+	GetChainParams(ctx context.Context, in *GetChainParamsRequest, opts ...grpc.CallOption) (*GetChainParamsResponse, error)
+	SendRawTransaction(ctx context.Context, in *SendRawTransactionRequest, opts ...grpc.CallOption) (*SendRawTransactionResponse, error)
+	GetTransactionConfirmations(ctx context.Context, in *GetTransactionConfirmationsRequest, opts ...grpc.CallOption) (*GetTransactionConfirmationsResponse, error)
+	GetAccountExtPub(ctx context.Context, in *GetAccountExtPubRequest, opts ...grpc.CallOption) (*GetAccountExtPubResponse, error)
+}
+
+type stakepooldServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStakepooldServiceClient(cc grpc.ClientConnInterface) StakepooldServiceClient {
+	return &stakepooldServiceClient{cc}
+}
+
+func (c *stakepooldServiceClient) GetAddedLowFeeTickets(ctx context.Context, in *GetAddedLowFeeTicketsRequest, opts ...grpc.CallOption) (*GetAddedLowFeeTicketsResponse, error) {
+	out := new(GetAddedLowFeeTicketsResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetAddedLowFeeTickets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) GetIgnoredLowFeeTickets(ctx context.Context, in *GetIgnoredLowFeeTicketsRequest, opts ...grpc.CallOption) (*GetIgnoredLowFeeTicketsResponse, error) {
+	out := new(GetIgnoredLowFeeTicketsResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetIgnoredLowFeeTickets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) GetLiveTickets(ctx context.Context, in *GetLiveTicketsRequest, opts ...grpc.CallOption) (*GetLiveTicketsResponse, error) {
+	out := new(GetLiveTicketsResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetLiveTickets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) SetAddedLowFeeTickets(ctx context.Context, in *SetAddedLowFeeTicketsRequest, opts ...grpc.CallOption) (*SetAddedLowFeeTicketsResponse, error) {
+	out := new(SetAddedLowFeeTicketsResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/SetAddedLowFeeTickets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) SetUserVotingPrefs(ctx context.Context, in *SetUserVotingPrefsRequest, opts ...grpc.CallOption) (*SetUserVotingPrefsResponse, error) {
+	out := new(SetUserVotingPrefsResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/SetUserVotingPrefs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) ImportNewScript(ctx context.Context, in *ImportNewScriptRequest, opts ...grpc.CallOption) (*ImportNewScriptResponse, error) {
+	out := new(ImportNewScriptResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/ImportNewScript", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) ImportMissingScripts(ctx context.Context, in *ImportMissingScriptsRequest, opts ...grpc.CallOption) (*ImportMissingScriptsResponse, error) {
+	out := new(ImportMissingScriptsResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/ImportMissingScripts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) StakePoolUserInfo(ctx context.Context, in *StakePoolUserInfoRequest, opts ...grpc.CallOption) (*StakePoolUserInfoResponse, error) {
+	out := new(StakePoolUserInfoResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/StakePoolUserInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) WalletInfo(ctx context.Context, in *WalletInfoRequest, opts ...grpc.CallOption) (*WalletInfoResponse, error) {
+	out := new(WalletInfoResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/WalletInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) ValidateAddress(ctx context.Context, in *ValidateAddressRequest, opts ...grpc.CallOption) (*ValidateAddressResponse, error) {
+	out := new(ValidateAddressResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/ValidateAddress", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
 	return out, nil
 }
 
@@ -2075,6 +3890,132 @@ func (c *stakepooldServiceClient) GetColdWalletExtPub(ctx context.Context, in *G
 	return out, nil
 }
 
+func (c *stakepooldServiceClient) ExistsAddress(ctx context.Context, in *ExistsAddressRequest, opts ...grpc.CallOption) (*ExistsAddressResponse, error) {
+	out := new(ExistsAddressResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/ExistsAddress", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) VerifyMessage(ctx context.Context, in *VerifyMessageRequest, opts ...grpc.CallOption) (*VerifyMessageResponse, error) {
+	out := new(VerifyMessageResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/VerifyMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) GetLiveTicketCounts(ctx context.Context, in *GetLiveTicketCountsRequest, opts ...grpc.CallOption) (*GetLiveTicketCountsResponse, error) {
+	out := new(GetLiveTicketCountsResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetLiveTicketCounts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) GetLowFeeReview(ctx context.Context, in *GetLowFeeReviewRequest, opts ...grpc.CallOption) (*GetLowFeeReviewResponse, error) {
+	out := new(GetLowFeeReviewResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetLowFeeReview", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) ResumeLowFeeClassification(ctx context.Context, in *ResumeLowFeeClassificationRequest, opts ...grpc.CallOption) (*ResumeLowFeeClassificationResponse, error) {
+	out := new(ResumeLowFeeClassificationResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/ResumeLowFeeClassification", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) GetTicketAmounts(ctx context.Context, in *GetTicketAmountsRequest, opts ...grpc.CallOption) (*GetTicketAmountsResponse, error) {
+	out := new(GetTicketAmountsResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetTicketAmounts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) PromoteStandby(ctx context.Context, in *PromoteStandbyRequest, opts ...grpc.CallOption) (*PromoteStandbyResponse, error) {
+	out := new(PromoteStandbyResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/PromoteStandby", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) LookupTickets(ctx context.Context, in *LookupTicketsRequest, opts ...grpc.CallOption) (*LookupTicketsResponse, error) {
+	out := new(LookupTicketsResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/LookupTickets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) BatchStakePoolUserInfo(ctx context.Context, in *BatchStakePoolUserInfoRequest, opts ...grpc.CallOption) (*BatchStakePoolUserInfoResponse, error) {
+	out := new(BatchStakePoolUserInfoResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/BatchStakePoolUserInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) GetBestBlock(ctx context.Context, in *GetBestBlockRequest, opts ...grpc.CallOption) (*GetBestBlockResponse, error) {
+	out := new(GetBestBlockResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetBestBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) GetChainParams(ctx context.Context, in *GetChainParamsRequest, opts ...grpc.CallOption) (*GetChainParamsResponse, error) {
+	out := new(GetChainParamsResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetChainParams", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) SendRawTransaction(ctx context.Context, in *SendRawTransactionRequest, opts ...grpc.CallOption) (*SendRawTransactionResponse, error) {
+	out := new(SendRawTransactionResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/SendRawTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) GetTransactionConfirmations(ctx context.Context, in *GetTransactionConfirmationsRequest, opts ...grpc.CallOption) (*GetTransactionConfirmationsResponse, error) {
+	out := new(GetTransactionConfirmationsResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetTransactionConfirmations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stakepooldServiceClient) GetAccountExtPub(ctx context.Context, in *GetAccountExtPubRequest, opts ...grpc.CallOption) (*GetAccountExtPubResponse, error) {
+	out := new(GetAccountExtPubResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.StakepooldService/GetAccountExtPub", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StakepooldServiceServer is the server API for StakepooldService service.
 type StakepooldServiceServer interface {
 	GetAddedLowFeeTickets(context.Context, *GetAddedLowFeeTicketsRequest) (*GetAddedLowFeeTicketsResponse, error)
@@ -2094,6 +4035,20 @@ type StakepooldServiceServer interface {
 	CreateMultisig(context.Context, *CreateMultisigRequest) (*CreateMultisigResponse, error)
 	GetStakeInfo(context.Context, *GetStakeInfoRequest) (*GetStakeInfoResponse, error)
 	GetColdWalletExtPub(context.Context, *GetColdWalletExtPubRequest) (*GetColdWalletExtPubResponse, error)
+	ExistsAddress(context.Context, *ExistsAddressRequest) (*ExistsAddressResponse, error)
+	VerifyMessage(context.Context, *VerifyMessageRequest) (*VerifyMessageResponse, error)
+	GetLiveTicketCounts(context.Context, *GetLiveTicketCountsRequest) (*GetLiveTicketCountsResponse, error)
+	GetLowFeeReview(context.Context, *GetLowFeeReviewRequest) (*GetLowFeeReviewResponse, error)
+	ResumeLowFeeClassification(context.Context, *ResumeLowFeeClassificationRequest) (*ResumeLowFeeClassificationResponse, error)
+	GetTicketAmounts(context.Context, *GetTicketAmountsRequest) (*GetTicketAmountsResponse, error)
+	PromoteStandby(context.Context, *PromoteStandbyRequest) (*PromoteStandbyResponse, error)
+	LookupTickets(context.Context, *LookupTicketsRequest) (*LookupTicketsResponse, error)
+	BatchStakePoolUserInfo(context.Context, *BatchStakePoolUserInfoRequest) (*BatchStakePoolUserInfoResponse, error)
+	GetBestBlock(context.Context, *GetBestBlockRequest) (*GetBestBlockResponse, error)
+	GetChainParams(context.Context, *GetChainParamsRequest) (*GetChainParamsResponse, error)
+	SendRawTransaction(context.Context, *SendRawTransactionRequest) (*SendRawTransactionResponse, error)
+	GetTransactionConfirmations(context.Context, *GetTransactionConfirmationsRequest) (*GetTransactionConfirmationsResponse, error)
+	GetAccountExtPub(context.Context, *GetAccountExtPubRequest) (*GetAccountExtPubResponse, error)
 }
 
 // UnimplementedStakepooldServiceServer can be embedded to have forward compatible implementations.
@@ -2148,8 +4103,50 @@ func (*UnimplementedStakepooldServiceServer) CreateMultisig(ctx context.Context,
 func (*UnimplementedStakepooldServiceServer) GetStakeInfo(ctx context.Context, req *GetStakeInfoRequest) (*GetStakeInfoResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStakeInfo not implemented")
 }
-func (*UnimplementedStakepooldServiceServer) GetColdWalletExtPub(ctx context.Context, req *GetColdWalletExtPubRequest) (*GetColdWalletExtPubResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetColdWalletExtPub not implemented")
+func (*UnimplementedStakepooldServiceServer) GetColdWalletExtPub(ctx context.Context, req *GetColdWalletExtPubRequest) (*GetColdWalletExtPubResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetColdWalletExtPub not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) ExistsAddress(ctx context.Context, req *ExistsAddressRequest) (*ExistsAddressResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExistsAddress not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) VerifyMessage(ctx context.Context, req *VerifyMessageRequest) (*VerifyMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyMessage not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) GetLiveTicketCounts(ctx context.Context, req *GetLiveTicketCountsRequest) (*GetLiveTicketCountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLiveTicketCounts not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) GetLowFeeReview(ctx context.Context, req *GetLowFeeReviewRequest) (*GetLowFeeReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLowFeeReview not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) ResumeLowFeeClassification(ctx context.Context, req *ResumeLowFeeClassificationRequest) (*ResumeLowFeeClassificationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeLowFeeClassification not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) GetTicketAmounts(ctx context.Context, req *GetTicketAmountsRequest) (*GetTicketAmountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTicketAmounts not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) PromoteStandby(ctx context.Context, req *PromoteStandbyRequest) (*PromoteStandbyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PromoteStandby not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) LookupTickets(ctx context.Context, req *LookupTicketsRequest) (*LookupTicketsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupTickets not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) BatchStakePoolUserInfo(ctx context.Context, req *BatchStakePoolUserInfoRequest) (*BatchStakePoolUserInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchStakePoolUserInfo not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) GetBestBlock(ctx context.Context, req *GetBestBlockRequest) (*GetBestBlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBestBlock not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) GetChainParams(ctx context.Context, req *GetChainParamsRequest) (*GetChainParamsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChainParams not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) SendRawTransaction(ctx context.Context, req *SendRawTransactionRequest) (*SendRawTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendRawTransaction not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) GetTransactionConfirmations(ctx context.Context, req *GetTransactionConfirmationsRequest) (*GetTransactionConfirmationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransactionConfirmations not implemented")
+}
+func (*UnimplementedStakepooldServiceServer) GetAccountExtPub(ctx context.Context, req *GetAccountExtPubRequest) (*GetAccountExtPubResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountExtPub not implemented")
 }
 
 func RegisterStakepooldServiceServer(s *grpc.Server, srv StakepooldServiceServer) {
@@ -2462,6 +4459,258 @@ func _StakepooldService_GetColdWalletExtPub_Handler(srv interface{}, ctx context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StakepooldService_ExistsAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExistsAddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).ExistsAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/ExistsAddress",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).ExistsAddress(ctx, req.(*ExistsAddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_VerifyMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).VerifyMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/VerifyMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).VerifyMessage(ctx, req.(*VerifyMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_GetLiveTicketCounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLiveTicketCountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).GetLiveTicketCounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/GetLiveTicketCounts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).GetLiveTicketCounts(ctx, req.(*GetLiveTicketCountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_GetLowFeeReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLowFeeReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).GetLowFeeReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/GetLowFeeReview",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).GetLowFeeReview(ctx, req.(*GetLowFeeReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_ResumeLowFeeClassification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeLowFeeClassificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).ResumeLowFeeClassification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/ResumeLowFeeClassification",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).ResumeLowFeeClassification(ctx, req.(*ResumeLowFeeClassificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_GetTicketAmounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTicketAmountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).GetTicketAmounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/GetTicketAmounts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).GetTicketAmounts(ctx, req.(*GetTicketAmountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_PromoteStandby_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PromoteStandbyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).PromoteStandby(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/PromoteStandby",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).PromoteStandby(ctx, req.(*PromoteStandbyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_LookupTickets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupTicketsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).LookupTickets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/LookupTickets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).LookupTickets(ctx, req.(*LookupTicketsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_BatchStakePoolUserInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchStakePoolUserInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).BatchStakePoolUserInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/BatchStakePoolUserInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).BatchStakePoolUserInfo(ctx, req.(*BatchStakePoolUserInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_GetBestBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBestBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).GetBestBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/GetBestBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).GetBestBlock(ctx, req.(*GetBestBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_GetChainParams_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetChainParamsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).GetChainParams(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/GetChainParams",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).GetChainParams(ctx, req.(*GetChainParamsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_SendRawTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendRawTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).SendRawTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/SendRawTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).SendRawTransaction(ctx, req.(*SendRawTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_GetTransactionConfirmations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionConfirmationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).GetTransactionConfirmations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/GetTransactionConfirmations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).GetTransactionConfirmations(ctx, req.(*GetTransactionConfirmationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StakepooldService_GetAccountExtPub_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountExtPubRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StakepooldServiceServer).GetAccountExtPub(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.StakepooldService/GetAccountExtPub",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StakepooldServiceServer).GetAccountExtPub(ctx, req.(*GetAccountExtPubRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _StakepooldService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "stakepoolrpc.StakepooldService",
 	HandlerType: (*StakepooldServiceServer)(nil),
@@ -2534,6 +4783,62 @@ var _StakepooldService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetColdWalletExtPub",
 			Handler:    _StakepooldService_GetColdWalletExtPub_Handler,
 		},
+		{
+			MethodName: "ExistsAddress",
+			Handler:    _StakepooldService_ExistsAddress_Handler,
+		},
+		{
+			MethodName: "VerifyMessage",
+			Handler:    _StakepooldService_VerifyMessage_Handler,
+		},
+		{
+			MethodName: "GetLiveTicketCounts",
+			Handler:    _StakepooldService_GetLiveTicketCounts_Handler,
+		},
+		{
+			MethodName: "GetLowFeeReview",
+			Handler:    _StakepooldService_GetLowFeeReview_Handler,
+		},
+		{
+			MethodName: "ResumeLowFeeClassification",
+			Handler:    _StakepooldService_ResumeLowFeeClassification_Handler,
+		},
+		{
+			MethodName: "GetTicketAmounts",
+			Handler:    _StakepooldService_GetTicketAmounts_Handler,
+		},
+		{
+			MethodName: "PromoteStandby",
+			Handler:    _StakepooldService_PromoteStandby_Handler,
+		},
+		{
+			MethodName: "LookupTickets",
+			Handler:    _StakepooldService_LookupTickets_Handler,
+		},
+		{
+			MethodName: "BatchStakePoolUserInfo",
+			Handler:    _StakepooldService_BatchStakePoolUserInfo_Handler,
+		},
+		{
+			MethodName: "GetBestBlock",
+			Handler:    _StakepooldService_GetBestBlock_Handler,
+		},
+		{
+			MethodName: "GetChainParams",
+			Handler:    _StakepooldService_GetChainParams_Handler,
+		},
+		{
+			MethodName: "SendRawTransaction",
+			Handler:    _StakepooldService_SendRawTransaction_Handler,
+		},
+		{
+			MethodName: "GetTransactionConfirmations",
+			Handler:    _StakepooldService_GetTransactionConfirmations_Handler,
+		},
+		{
+			MethodName: "GetAccountExtPub",
+			Handler:    _StakepooldService_GetAccountExtPub_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
@@ -2610,3 +4915,75 @@ var _VersionService_serviceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
 }
+
+// DebugServiceClient is the client API for DebugService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type DebugServiceClient interface {
+	DumpState(ctx context.Context, in *DumpStateRequest, opts ...grpc.CallOption) (*DumpStateResponse, error)
+}
+
+type debugServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDebugServiceClient(cc grpc.ClientConnInterface) DebugServiceClient {
+	return &debugServiceClient{cc}
+}
+
+func (c *debugServiceClient) DumpState(ctx context.Context, in *DumpStateRequest, opts ...grpc.CallOption) (*DumpStateResponse, error) {
+	out := new(DumpStateResponse)
+	err := c.cc.Invoke(ctx, "/stakepoolrpc.DebugService/DumpState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DebugServiceServer is the server API for DebugService service.
+type DebugServiceServer interface {
+	DumpState(context.Context, *DumpStateRequest) (*DumpStateResponse, error)
+}
+
+// UnimplementedDebugServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedDebugServiceServer struct {
+}
+
+func (*UnimplementedDebugServiceServer) DumpState(ctx context.Context, req *DumpStateRequest) (*DumpStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DumpState not implemented")
+}
+
+func RegisterDebugServiceServer(s *grpc.Server, srv DebugServiceServer) {
+	s.RegisterService(&_DebugService_serviceDesc, srv)
+}
+
+func _DebugService_DumpState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DumpStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebugServiceServer).DumpState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stakepoolrpc.DebugService/DumpState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebugServiceServer).DumpState(ctx, req.(*DumpStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _DebugService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "stakepoolrpc.DebugService",
+	HandlerType: (*DebugServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DumpState",
+			Handler:    _DebugService_DumpState_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api.proto",
+}
@@ -18,7 +18,7 @@ import (
 var requiredChainServerAPI = semver{major: 6, minor: 1, patch: 1}
 var requiredWalletAPI = semver{major: 8, minor: 0, patch: 0}
 
-func connectNodeRPC(ctx context.Context, spd *stakepool.Stakepoold, cfg *config) (*rpcclient.Client, semver, error) {
+func connectNodeRPC(ctx context.Context, spd *stakepool.Stakepoold, connMon *connMonitor, cfg *config) (*rpcclient.Client, semver, error) {
 	var nodeVer semver
 
 	dcrdCert, err := ioutil.ReadFile(cfg.DcrdCert)
@@ -40,7 +40,7 @@ func connectNodeRPC(ctx context.Context, spd *stakepool.Stakepoold, cfg *config)
 		Certificates: dcrdCert,
 	}
 
-	ntfnHandlers := getNodeNtfnHandlers(spd)
+	ntfnHandlers := getNodeNtfnHandlers(spd, connMon)
 	dcrdClient, err := rpcclient.New(connCfgDaemon, ntfnHandlers)
 	if err != nil {
 		log.Errorf("Failed to start dcrd RPC client: %s\n", err.Error())
@@ -133,6 +133,7 @@ func walletGetTickets(ctx context.Context, spd *stakepool.Stakepoold) (map[chain
 
 	ignoredLowFeeTickets := make(map[chainhash.Hash]string)
 	liveTickets := make(map[chainhash.Hash]string)
+	normalFeeHeights := make(map[chainhash.Hash]int64)
 	var normalFee int
 
 	log.Info("Calling GetTickets...")
@@ -235,7 +236,8 @@ func walletGetTickets(ctx context.Context, spd *stakepool.Stakepoold) (map[chain
 					ticketBlockHeight = int32(gbh.Height)
 				}
 
-				ticketFeesValid, err := spd.EvaluateStakePoolTicket(msgTx, ticketBlockHeight)
+				ticketFeesValid, err := spd.EvaluateStakePoolTicket(msgTx,
+					userVotingConfig[addr].MultiSigAddress, ticketBlockHeight)
 
 				if err != nil {
 					log.Warnf("ignoring ticket %v for multisig %v due to error: %v",
@@ -244,8 +246,9 @@ func walletGetTickets(ctx context.Context, spd *stakepool.Stakepoold) (map[chain
 				} else if ticketFeesValid {
 					normalFee++
 					liveTickets[*hash] = userVotingConfig[addr].MultiSigAddress
+					normalFeeHeights[*hash] = int64(ticketBlockHeight)
 				} else {
-					log.Warnf("ignoring ticket %v for multisig %v due to invalid fee",
+					log.Warnf("ignoring ticket %v for multisig %v due to invalid fee or ticket policy",
 						*hash, spd.UserVotingConfig[addr].MultiSigAddress)
 					ignoredLowFeeTickets[*hash] = userVotingConfig[addr].MultiSigAddress
 				}
@@ -254,6 +257,32 @@ func walletGetTickets(ctx context.Context, spd *stakepool.Stakepoold) (map[chain
 		}
 	}
 
+	// Tickets of users over the limit of live tickets are ignored like low
+	// fee tickets, keeping the earliest tickets of each user.  Tickets added
+	// by an admin count towards the limit but are always voted.
+	if spd.MaxUserLiveTickets > 0 {
+		counts := make(map[string]int)
+		candidates := make(map[chainhash.Hash]string, len(normalFeeHeights))
+		for ticket, msa := range liveTickets {
+			if _, ok := normalFeeHeights[ticket]; ok {
+				candidates[ticket] = msa
+				continue
+			}
+			counts[msa]++
+		}
+		overLimit := stakepool.OverUserTicketLimit(candidates,
+			normalFeeHeights, counts, spd.MaxUserLiveTickets)
+		for ticket, msa := range overLimit {
+			delete(liveTickets, ticket)
+			ignoredLowFeeTickets[ticket] = msa
+		}
+		normalFee -= len(overLimit)
+		if len(overLimit) > 0 {
+			log.Infof("ignoring %d tickets over the limit of %d live "+
+				"tickets per user", len(overLimit), spd.MaxUserLiveTickets)
+		}
+	}
+
 	log.Infof("tickets loaded -- addedLowFee %v ignoredLowFee %v normalFee %v "+
 		"live %v total %v", len(spd.AddedLowFeeTicketsMSA),
 		len(ignoredLowFeeTickets), normalFee, len(liveTickets),
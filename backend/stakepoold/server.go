@@ -25,6 +25,7 @@ import (
 	"github.com/decred/dcrstakepool/backend/stakepoold/stakepool"
 	"github.com/decred/dcrstakepool/backend/stakepoold/userdata"
 	"github.com/decred/dcrstakepool/helpers"
+	"github.com/decred/dcrstakepool/internal/notify"
 	"github.com/decred/dcrstakepool/signal"
 
 	// register database driver
@@ -68,7 +69,22 @@ var (
 // addresses for this public key for the address indexes [0,end). The branch
 // used for the derivation is always the external branch.
 func calculateFeeAddresses(xpubStr string, params *chaincfg.Params) (map[string]struct{}, error) {
+	addrs, err := extendFeeAddresses(xpubStr, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	return feeAddressMap(addrs), nil
+}
+
+// extendFeeAddresses returns the addresses of the external branch of the
+// extended public key for the indexes [0,numServicePaymentFeeAddresses),
+// given the previously derived addresses for the first indexes in addrs.
+// Only the addresses which are missing from addrs are derived.
+func extendFeeAddresses(xpubStr string, params *chaincfg.Params, addrs []string) ([]string, error) {
 	end := numServicePaymentFeeAddresses
+	if uint32(len(addrs)) >= end {
+		return addrs[:end], nil
+	}
 
 	// Parse the extended public key and ensure it's the right network.
 	key, err := hdkeychain.NewKeyFromString(xpubStr, params)
@@ -82,37 +98,57 @@ func calculateFeeAddresses(xpubStr string, params *chaincfg.Params) (map[string]
 		return nil, err
 	}
 
-	// Derive the addresses from [0, end) for this extended public key.
-	// deriveChildAddresses takes the start index and the count.
-	addrs, err := deriveChildAddresses(branchKey, 0, end, params)
+	// Derive the addresses from [len(addrs), end) for this extended public
+	// key.  deriveChildAddresses takes the start index and the count.  The
+	// next child is at index len(addrs) unless an invalid child was skipped,
+	// in which case the last address is not derived from the child at its
+	// position and all addresses are derived again.
+	start := uint32(len(addrs))
+	if start > 0 {
+		last, _, err := helpers.ChildAddress(branchKey, start-1, params)
+		if err != nil {
+			return nil, err
+		}
+		if last.Address() != addrs[start-1] {
+			addrs, start = nil, 0
+		}
+	}
+	derived, err := deriveChildAddresses(branchKey, start, end-start, params)
 	if err != nil {
 		return nil, err
 	}
 
-	addrMap := make(map[string]struct{})
-	for i := range addrs {
-		addrMap[addrs[i].Address()] = struct{}{}
+	extended := make([]string, 0, end)
+	extended = append(extended, addrs...)
+	for i := range derived {
+		extended = append(extended, derived[i].Address())
 	}
+	return extended, nil
+}
 
-	return addrMap, nil
+// feeAddressMap returns the set of the passed fee addresses.
+func feeAddressMap(addrs []string) map[string]struct{} {
+	addrMap := make(map[string]struct{}, len(addrs))
+	for _, addr := range addrs {
+		addrMap[addr] = struct{}{}
+	}
+	return addrMap
 }
 
+// deriveChildAddresses derives count addresses from the valid children of key,
+// starting with the first valid child at or after startIndex.  Invalid children
+// are skipped, so the addresses at each position match the addresses
+// dcrstakepool derives for the users with the same ids.
 func deriveChildAddresses(key *hdkeychain.ExtendedKey, startIndex, count uint32, params *chaincfg.Params) ([]dcrutil.Address, error) {
 	addresses := make([]dcrutil.Address, 0, count)
-	for i := uint32(0); i < count; {
-		child, err := key.Child(startIndex + i)
-		if errors.Is(err, hdkeychain.ErrInvalidChild) {
-			continue
-		}
-		if err != nil {
-			return nil, err
-		}
-		addr, err := helpers.DCRUtilAddressFromExtendedKey(child, params)
+	index := startIndex
+	for i := uint32(0); i < count; i++ {
+		addr, child, err := helpers.ChildAddress(key, index, params)
 		if err != nil {
 			return nil, err
 		}
 		addresses = append(addresses, addr)
-		i++
+		index = child + 1
 	}
 	return addresses, nil
 }
@@ -124,6 +160,9 @@ func runMain(ctx context.Context) error {
 	// Load configuration and parse command line.  This function also
 	// initializes logging and configures it accordingly.
 	loadedCfg, _, err := loadConfig()
+	if validateConfigRequested() {
+		return runValidateConfig(ctx, loadedCfg, err)
+	}
 	if err != nil {
 		return err
 	}
@@ -145,10 +184,7 @@ func runMain(ctx context.Context) error {
 		return err
 	}
 
-	log.Infof("Please wait, deriving %v voting service fees addresses "+
-		"for extended public key %s", numServicePaymentFeeAddresses,
-		cfg.ColdWalletExtPub)
-	feeAddrs, err := calculateFeeAddresses(cfg.ColdWalletExtPub,
+	feeAddrs, err := loadFeeAddresses(cfg.DataDir, cfg.ColdWalletExtPub,
 		activeNetParams.Params)
 	if err != nil {
 		log.Errorf("Error calculating fee payment addresses: %v", err)
@@ -188,6 +224,10 @@ func runMain(ctx context.Context) error {
 
 	var userData = &userdata.UserData{}
 	userData.DBSetConfig(cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	defer userData.Close()
+	if err = userData.CheckSchema(ctx, cfg.CreateMissingIndexes); err != nil {
+		log.Errorf("database schema check failed: %v", err)
+	}
 
 	addedLowFeeTicketsMSA, errMySQLFetchAddedLowFeeTickets := userData.MySQLFetchAddedLowFeeTickets()
 	if errMySQLFetchAddedLowFeeTickets != nil {
@@ -209,25 +249,85 @@ func runMain(ctx context.Context) error {
 		return err
 	}
 
+	ticketPolicies := make([]stakepool.TicketPolicy, 0, len(cfg.TicketPolicies))
+	for _, spec := range cfg.TicketPolicies {
+		policy, err := stakepool.NewTicketPolicy(activeNetParams.Params, spec)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		ticketPolicies = append(ticketPolicies, policy)
+	}
+
+	forcedChoices, err := helpers.ParseForcedChoices(activeNetParams.Params,
+		cfg.ForcedChoice)
+	if err != nil {
+		err = fmt.Errorf("invalid forcedchoice: %v", err)
+		log.Error(err)
+		return err
+	}
+
+	// Critical alerts are also sent to the chat rooms of the operators when
+	// bots are configured.  The options were checked by loadConfig.
+	notifiers, _ := cfg.alertConfig().Notifiers()
+	hostname, _ := os.Hostname()
+	alerter := notify.NewAlerter("stakepoold "+hostname, cfg.AlertCooldown,
+		notifiers...)
+	if alerter != nil {
+		log.Infof("Sending critical alerts with %d bots", len(notifiers))
+	}
+	winningWatch := stakepool.NewWinningWatchdog(activeNetParams.Params,
+		cfg.WinningNtfnAlert, alerter)
+
 	spd := &stakepool.Stakepoold{
 		AddedLowFeeTicketsMSA:  addedLowFeeTicketsMSA,
+		Alerter:                alerter,
 		DataPath:               cfg.DataDir,
 		ColdWalletExtPub:       cfg.ColdWalletExtPub,
+		DeferredFees:           cfg.FeeMode == stakepool.FeeModeDeferred,
 		FeeAddrs:               feeAddrs,
+		ForcedChoices:          forcedChoices,
+		MaxLowFeePerBlock:      cfg.MaxLowFeePerBlock,
+		MaxUserLiveTickets:     cfg.MaxUserLiveTickets,
 		PoolFees:               cfg.PoolFees,
-		NewTicketsChan:         make(chan stakepool.NewTicketsForBlock),
+		NewTicketsChan:         make(chan stakepool.NewTicketsForBlock, stakepool.TicketQueueSize),
 		Params:                 activeNetParams.Params,
-		SpentmissedTicketsChan: make(chan stakepool.SpentMissedTicketsForBlock),
+		PauseOnLowFeeSurge:     cfg.PauseOnLowFeeSurge,
+		SpentmissedTicketsChan: make(chan stakepool.SpentMissedTicketsForBlock, stakepool.TicketQueueSize),
+		Standby:                cfg.Standby,
+		StandbyFailoverMisses:  cfg.StandbyFailoverMisses,
+		TicketPolicies:         ticketPolicies,
 		UserData:               userData,
+		UserDataStaleAlert:     cfg.UserDataStaleAlert,
 		UserVotingConfig:       userVotingConfig,
+		VoteErrorAlert:         cfg.VoteErrorAlert,
 		VotingConfig:           &votingConfig,
 		WalletConnection:       walletConn,
-		WinningTicketsChan:     make(chan stakepool.WinningTicketsForBlock),
+		WinningTicketsChan:     make(chan stakepool.WinningTicketsForBlock, stakepool.TicketQueueSize),
+		WinningWatch:           winningWatch,
 		Testing:                false,
 	}
 
+	if cfg.VoteSigner != "" {
+		signer := stakepool.NewExternalVoteSigner(cfg.VoteSigner,
+			cfg.VoteSignerTimeout)
+		if err := signer.Check(ctx); err != nil {
+			log.Warnf("Unable to connect to the vote signer, tickets will "+
+				"not be voted until it is started: %v", err)
+		}
+		log.Infof("Votes are signed by the external signer at %s",
+			cfg.VoteSigner)
+		spd.VoteSigner = signer
+	}
+
+	if cfg.Standby {
+		log.Infof("Starting as a warm standby, votes and revocations are " +
+			"not broadcast until promoted to active")
+	}
+
 	// Daemon client connection
-	nodeConn, nodeVer, err := connectNodeRPC(ctx, spd, cfg)
+	connMon := newConnMonitor(cfg.ReconnectAlert, alerter)
+	nodeConn, nodeVer, err := connectNodeRPC(ctx, spd, connMon, cfg)
 	if err != nil || nodeConn == nil {
 		log.Infof("Connection to dcrd failed: %v", err)
 		return err
@@ -251,7 +351,7 @@ func runMain(ctx context.Context) error {
 
 	// load AddedLowFeeTicketsMSA from disk cache if necessary
 	if len(spd.AddedLowFeeTicketsMSA) == 0 && errMySQLFetchAddedLowFeeTickets != nil {
-		err = loadData(spd, "AddedLowFeeTickets")
+		_, err = loadData(spd, "AddedLowFeeTickets")
 		if err != nil {
 			// might not have any so continue
 			log.Warnf("unable to load added low fee tickets from disk "+
@@ -263,8 +363,10 @@ func runMain(ctx context.Context) error {
 	}
 
 	// load userVotingConfig from disk cache if necessary
-	if len(spd.UserVotingConfig) == 0 && errMySQLFetchUserVotingConfig != nil {
-		err = loadData(spd, "UserVotingConfig")
+	if errMySQLFetchUserVotingConfig == nil {
+		spd.SetUserDataFreshness(stakepool.UserDataFromMySQL, time.Now(), false)
+	} else if len(spd.UserVotingConfig) == 0 {
+		saved, err := loadData(spd, "UserVotingConfig")
 		if err != nil {
 			// we could possibly die out here but it's probably better
 			// to let stakepoold vote with default preferences rather than
@@ -275,14 +377,25 @@ func runMain(ctx context.Context) error {
 			log.Infof("Loaded UserVotingConfig for %d users from disk cache",
 				len(spd.UserVotingConfig))
 		}
+		spd.SetUserDataFreshness(stakepool.UserDataFromDiskCache, saved, true)
 	}
 
 	if len(spd.UserVotingConfig) == 0 {
 		log.Warn("0 active users")
 	}
 
+	// Invalid vote bits would otherwise only be noticed when the tickets of
+	// the users are called to vote.
+	spd.InvalidVoteBits = stakepool.ValidateUserVotingConfig(spd.Params,
+		spd.VotingConfig, spd.UserVotingConfig)
+	if spd.InvalidVoteBits > 0 {
+		log.Warnf("reset the votebits of %d users to the defaults",
+			spd.InvalidVoteBits)
+	}
+
 	// refresh the ticket list and make sure a block didn't come in
 	// while we were getting it
+	var bestHeight int64
 	for {
 		curHash, curHeight, err := nodeConn.GetBestBlock(ctx)
 		if err != nil {
@@ -306,30 +419,17 @@ func runMain(ctx context.Context) error {
 		// if a block didn't come in while we were processing tickets
 		// then we're fine
 		if curHash.IsEqual(afterHash) && curHeight == afterHeight {
+			bestHeight = afterHeight
 			break
 		}
 		log.Infof("block %v hash %v came in during GetTickets, refreshing...",
 			afterHeight, afterHash)
 	}
 
-	if err = nodeConn.NotifyBlocks(ctx); err != nil {
-		fmt.Printf("Failed to register daemon RPC client for "+
-			"block notifications: %s\n", err.Error())
-		return err
-	}
-	if err = nodeConn.NotifyWinningTickets(ctx); err != nil {
-		fmt.Printf("Failed to register daemon RPC client for "+
-			"winning tickets notifications: %s\n", err.Error())
-		return err
-	}
-	if err = nodeConn.NotifyNewTickets(ctx); err != nil {
-		fmt.Printf("Failed to register daemon RPC client for "+
-			"new tickets notifications: %s\n", err.Error())
-		return err
-	}
-	if err = nodeConn.NotifySpentAndMissedTickets(ctx); err != nil {
-		fmt.Printf("Failed to register daemon RPC client for "+
-			"spent/missed tickets notifications: %s\n", err.Error())
+	connMon.setBestHeight(bestHeight)
+
+	if err = registerNodeNotifications(ctx, nodeConn); err != nil {
+		fmt.Printf("%v\n", err)
 		return err
 	}
 	log.Info("subscribed to notifications from dcrd")
@@ -339,11 +439,32 @@ func runMain(ctx context.Context) error {
 			fmt.Printf("Failed to start GRPCServers: %s\n", err.Error())
 			return err
 		}
+		if grpcAudit != nil {
+			defer grpcAudit.Close()
+		}
+	}
+
+	if cfg.MetricsListen != "" {
+		err = startMetricsServer(ctx, wg, spd, cfg.MetricsListen)
+		if err != nil {
+			log.Errorf("Failed to start metrics server: %v", err)
+			return err
+		}
+	}
+
+	if cfg.DBPingInterval > 0 {
+		go userData.KeepAlive(ctx, cfg.DBPingInterval)
+	}
+	// Without the gRPC server the user voting preferences are refreshed on
+	// the ticker below, otherwise until dcrstakepool sends them.
+	if errMySQLFetchUserVotingConfig != nil && !cfg.NoRPCListen {
+		go spd.RetryUserDataFromMySQL(ctx)
 	}
 
 	go spd.NewTicketHandler(ctx, wg)
 	go spd.SpentmissedTicketHandler(ctx, wg)
 	go spd.WinningTicketHandler(ctx, wg)
+	go connMon.run(ctx, wg, spd)
 
 	if cfg.NoRPCListen {
 		// Start reloading when a ticker fires
@@ -442,8 +563,9 @@ func pruneData(spd *stakepool.Stakepoold) error {
 }
 
 // loadData looks for and attempts to load into memory the most recent save
-// file for a passed data kind.
-func loadData(spd *stakepool.Stakepoold, dataKind string) error {
+// file for a passed data kind.  It returns when the file was saved, which is
+// zero when there was none.
+func loadData(spd *stakepool.Stakepoold, dataKind string) (time.Time, error) {
 	var dataVersion string
 	found := false
 	saveFiles := getDataNames()
@@ -456,16 +578,16 @@ func loadData(spd *stakepool.Stakepoold, dataKind string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("unhandled data kind of %s", dataKind)
+		return time.Time{}, fmt.Errorf("unhandled data kind of %s", dataKind)
 	}
 
 	if !fileExists(spd.DataPath) {
-		return fmt.Errorf("loadData - path %s does not exist", spd.DataPath)
+		return time.Time{}, fmt.Errorf("loadData - path %s does not exist", spd.DataPath)
 	}
 
 	files, err := ioutil.ReadDir(spd.DataPath)
 	if err != nil {
-		return err
+		return time.Time{}, err
 	}
 
 	var lastseen string
@@ -483,18 +605,25 @@ func loadData(spd *stakepool.Stakepoold, dataKind string) error {
 	// maybe the admin deleted the gob files to reset the cache
 	// or the cache hasn't been initialized yet.
 	if lastseen == "" {
-		return nil
+		return time.Time{}, nil
 	}
 
 	fullPath := filepath.Join(spd.DataPath, lastseen)
 
+	start := time.Now()
 	r, err := os.Open(fullPath)
 	if err != nil {
-		return err
+		observeGob("load", dataKind, start, err)
+		return time.Time{}, err
 	}
 
 	defer r.Close()
 
+	var saved time.Time
+	if info, err := r.Stat(); err == nil {
+		saved = info.ModTime()
+	}
+
 	dec := gob.NewDecoder(r)
 	switch dataKind {
 	case "AddedLowFeeTickets":
@@ -504,12 +633,13 @@ func loadData(spd *stakepool.Stakepoold, dataKind string) error {
 	case "UserVotingConfig":
 		err = dec.Decode(&spd.UserVotingConfig)
 	}
+	observeGob("load", dataKind, start, err)
 	if err != nil {
-		return err
+		return time.Time{}, err
 	}
 
 	log.Infof("Loaded %s from %s", dataKind, fullPath)
-	return nil
+	return saved, nil
 }
 
 // saveData saves some stakepoold fields to a file so they can be loaded back
@@ -549,8 +679,10 @@ func saveData(spd *stakepool.Stakepoold) {
 			continue
 		}
 
+		start := time.Now()
 		w, err := os.Create(destPath)
 		if err != nil {
+			observeGob("save", filenameprefix, start, err)
 			log.Errorf("Error opening file %s: %v", spd.DataPath, err)
 			continue
 		}
@@ -559,22 +691,18 @@ func saveData(spd *stakepool.Stakepoold) {
 		switch filenameprefix {
 		case "AddedLowFeeTickets":
 			enc := gob.NewEncoder(w)
-			if err := enc.Encode(&spd.AddedLowFeeTicketsMSA); err != nil {
-				log.Errorf("Failed to encode file %s: %v", spd.DataPath, err)
-				continue
-			}
+			err = enc.Encode(&spd.AddedLowFeeTicketsMSA)
 		case "LiveTickets":
 			enc := gob.NewEncoder(w)
-			if err := enc.Encode(&spd.LiveTicketsMSA); err != nil {
-				log.Errorf("Failed to encode file %s: %v", spd.DataPath, err)
-				continue
-			}
+			err = enc.Encode(&spd.LiveTicketsMSA)
 		case "UserVotingConfig":
 			enc := gob.NewEncoder(w)
-			if err := enc.Encode(&spd.UserVotingConfig); err != nil {
-				log.Errorf("Failed to encode file %s: %v", spd.DataPath, err)
-				continue
-			}
+			err = enc.Encode(&spd.UserVotingConfig)
+		}
+		observeGob("save", filenameprefix, start, err)
+		if err != nil {
+			log.Errorf("Failed to encode file %s: %v", spd.DataPath, err)
+			continue
 		}
 
 		log.Infof("saveData: successfully saved %v data to %s",
@@ -0,0 +1,87 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"context"
+	"errors"
+
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+// TicketAmounts are the amounts, in atoms, of a ticket or of the vote which
+// spent it.  Subsidy, PoolPayout and UserPayout are only set for votes.
+type TicketAmounts struct {
+	TicketPrice int64
+	Subsidy     int64
+	PoolPayout  int64
+	UserPayout  int64
+}
+
+// ticketAmounts returns the amounts of tx, which must be a ticket or a vote.
+func ticketAmounts(tx *wire.MsgTx) (*TicketAmounts, error) {
+	if stake.IsSStx(tx) {
+		return &TicketAmounts{TicketPrice: tx.TxOut[0].Value}, nil
+	}
+	if !stake.IsSSGen(tx, false) && !stake.IsSSGen(tx, true) {
+		return nil, errors.New("transaction is neither a ticket nor a vote")
+	}
+
+	// The first input of a vote is the stakebase, which creates the vote
+	// subsidy, and the second input spends the ticket.  The first two
+	// outputs reference the block and hold the vote bits, and are followed
+	// by one payout for each commitment of the ticket.  The first
+	// commitment of a voting service ticket pays the voting service fee
+	// and the others return the stake of the user.  The treasury vote
+	// output, if any, has no value.
+	a := &TicketAmounts{
+		Subsidy:     tx.TxIn[0].ValueIn,
+		TicketPrice: tx.TxIn[1].ValueIn,
+	}
+	for i, out := range tx.TxOut[2:] {
+		if i == 0 {
+			a.PoolPayout = out.Value
+			continue
+		}
+		a.UserPayout += out.Value
+	}
+	return a, nil
+}
+
+// GetTicketAmounts looks up tickets and votes in the wallet and returns their
+// amounts.  Transactions which are not found or are neither a ticket nor a
+// vote are omitted, and so are the remaining transactions once ctx is done so
+// that the amounts found so far are returned before the request times out.
+func (spd *Stakepoold) GetTicketAmounts(ctx context.Context, hashes []*chainhash.Hash) map[chainhash.Hash]*TicketAmounts {
+	amounts := make(map[chainhash.Hash]*TicketAmounts, len(hashes))
+	for _, hash := range hashes {
+		res, err := spd.WalletConnection.RPCClient().GetTransaction(ctx, hash)
+		if err != nil {
+			if ctx.Err() != nil {
+				log.Warnf("GetTicketAmounts: looked up %d of %d "+
+					"transactions: %v", len(amounts), len(hashes), ctx.Err())
+				break
+			}
+			log.Debugf("GetTicketAmounts: GetTransaction failed for %v: %v",
+				hash, err)
+			continue
+		}
+		tx, err := MsgTxFromHex(res.Hex)
+		if err != nil {
+			log.Warnf("GetTicketAmounts: MsgTxFromHex failed for %v: %v",
+				hash, err)
+			continue
+		}
+		a, err := ticketAmounts(tx)
+		if err != nil {
+			log.Warnf("GetTicketAmounts: %v: %v", hash, err)
+			continue
+		}
+		amounts[*hash] = a
+	}
+	return amounts
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+// taggedP2PKH returns a pay to pubkey hash script tagged with the stake opcode
+// tag.
+func taggedP2PKH(tag byte) []byte {
+	script := []byte{tag, 0x76, 0xa9, 0x14} // OP_DUP OP_HASH160 OP_DATA_20
+	script = append(script, bytes.Repeat([]byte{2}, 20)...)
+	return append(script, 0x88, 0xac) // OP_EQUALVERIFY OP_CHECKSIG
+}
+
+func TestTicketAmounts(t *testing.T) {
+	const (
+		opSSTX       = 0xba
+		opSSGEN      = 0xbb
+		opSSTXCHANGE = 0xbd
+	)
+
+	ticket := wire.NewMsgTx()
+	ticket.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0, wire.TxTreeRegular), 1e6, nil))
+	ticket.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 0, wire.TxTreeRegular), 100e8, nil))
+	ticket.AddTxOut(wire.NewTxOut(100e8, taggedP2PKH(opSSTX)))
+	ticket.AddTxOut(wire.NewTxOut(0, commitmentScript(bytes.Repeat([]byte{1}, 20))))
+	ticket.AddTxOut(wire.NewTxOut(0, taggedP2PKH(opSSTXCHANGE)))
+	ticket.AddTxOut(wire.NewTxOut(0, commitmentScript(bytes.Repeat([]byte{2}, 20))))
+	ticket.AddTxOut(wire.NewTxOut(0, taggedP2PKH(opSSTXCHANGE)))
+
+	a, err := ticketAmounts(ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *a != (TicketAmounts{TicketPrice: 100e8}) {
+		t.Fatalf("got ticket amounts %+v", a)
+	}
+
+	vote := wire.NewMsgTx()
+	vote.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex, wire.TxTreeRegular), 1.5e8, []byte{0, 0}))
+	vote.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{3}, 0,
+		wire.TxTreeStake), 100e8, nil))
+	blockRef := append([]byte{0x6a, 0x24}, make([]byte, 36)...) // OP_RETURN OP_DATA_36
+	vote.AddTxOut(wire.NewTxOut(0, blockRef))
+	vote.AddTxOut(wire.NewTxOut(0, []byte{0x6a, 0x06, 1, 0, 8, 0, 0, 0})) // OP_RETURN OP_DATA_6
+	vote.AddTxOut(wire.NewTxOut(1.01e6, taggedP2PKH(opSSGEN)))
+	vote.AddTxOut(wire.NewTxOut(101.4999e8, taggedP2PKH(opSSGEN)))
+
+	a, err = ticketAmounts(vote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TicketAmounts{
+		TicketPrice: 100e8,
+		Subsidy:     1.5e8,
+		PoolPayout:  1.01e6,
+		UserPayout:  101.4999e8,
+	}
+	if *a != want {
+		t.Fatalf("got vote amounts %+v, want %+v", a, want)
+	}
+
+	// Other transactions have no ticket amounts.
+	regular := wire.NewMsgTx()
+	regular.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{4}, 0, wire.TxTreeRegular), 1e8, nil))
+	regular.AddTxOut(wire.NewTxOut(1e8, taggedP2PKH(0x76)[1:]))
+	if _, err := ticketAmounts(regular); err == nil {
+		t.Fatal("got amounts of a regular transaction")
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// voteAuditDepth is the number of blocks to wait after a block's winning
+	// tickets were notified before checking that their votes were mined.
+	voteAuditDepth = 3
+
+	// MissedByPool is the cause recorded for a winning ticket whose vote
+	// could not be created by this voting service for a reason not covered
+	// by the causes below.
+	MissedByPool = "pool"
+
+	// MissedWalletLocked is the cause recorded for a winning ticket whose
+	// vote could not be created because the voting wallet was locked.
+	MissedWalletLocked = "walletlocked"
+
+	// MissedBroadcast is the cause recorded for a winning ticket whose vote
+	// was created but could not be broadcast.
+	MissedBroadcast = "broadcast"
+
+	// MissedUntracked is the cause recorded for a winning ticket of a user
+	// which was not voted because it was missing from LiveTicketsMSA.
+	MissedUntracked = "untracked"
+
+	// MissedByNetwork is the cause recorded for a winning ticket whose vote
+	// was broadcast successfully but was not included in the next block.
+	MissedByNetwork = "network"
+
+	// errWalletLockedCode is the prefix of the error returned by dcrwallet
+	// when it must be unlocked to sign a vote.
+	errWalletLockedCode = "-13: "
+)
+
+// voteAudit is a managed winning ticket awaiting verification that its vote
+// was mined.
+type voteAudit struct {
+	blockHash   chainhash.Hash
+	blockHeight int64
+	ticket      chainhash.Hash
+	msa         string
+	userid      int64
+	voteErr     error
+
+	// signed is set when the vote was signed, so that voteErr is the error
+	// returned when broadcasting it.
+	signed bool
+
+	// untracked is set for a winning ticket which was not voted because it
+	// was missing from LiveTicketsMSA.  Its msa and userid are only looked
+	// up when its vote was not mined, since most such tickets belong to
+	// other voting services.
+	untracked bool
+
+	// standby is set when the ticket was not voted because the instance
+	// was in standby.
+	standby bool
+}
+
+// queueVoteAudits records the outcome of the votes for a block's winning
+// tickets so that they can be verified voteAuditDepth blocks later, along with
+// the untracked winning tickets which were not voted.  When standby is set the
+// tickets were not voted, and the audit instead checks whether the active
+// instance voted them.
+func (spd *Stakepoold) queueVoteAudits(wt WinningTicketsForBlock, winners []*ticketMetadata, untracked []*chainhash.Hash, standby bool) {
+	if len(winners) == 0 && len(untracked) == 0 {
+		return
+	}
+
+	audits := make([]voteAudit, 0, len(winners)+len(untracked))
+	for _, w := range winners {
+		voteErr := w.err
+		// A duplicate vote means another voting wallet already sent it.
+		if voteErr != nil && strings.HasPrefix(voteErr.Error(), errDuplicateVote) {
+			voteErr = nil
+		}
+		audits = append(audits, voteAudit{
+			blockHash:   *wt.BlockHash,
+			blockHeight: wt.BlockHeight,
+			ticket:      *w.ticket,
+			msa:         w.msa,
+			userid:      w.config.Userid,
+			voteErr:     voteErr,
+			signed:      w.signed,
+			standby:     standby,
+		})
+	}
+	for _, ticket := range untracked {
+		audits = append(audits, voteAudit{
+			blockHash:   *wt.BlockHash,
+			blockHeight: wt.BlockHeight,
+			ticket:      *ticket,
+			untracked:   true,
+		})
+	}
+
+	spd.auditMtx.Lock()
+	if spd.pendingAudits == nil {
+		spd.pendingAudits = make(map[int64][]voteAudit)
+	}
+	spd.pendingAudits[wt.BlockHeight] = append(spd.pendingAudits[wt.BlockHeight],
+		audits...)
+	spd.auditMtx.Unlock()
+}
+
+// votedTickets returns the tickets spent by the votes in block.
+func votedTickets(block *wire.MsgBlock) map[chainhash.Hash]struct{} {
+	voted := make(map[chainhash.Hash]struct{})
+	for _, stx := range block.STransactions {
+		if !stake.IsSSGen(stx, false) && !stake.IsSSGen(stx, true) {
+			continue
+		}
+		voted[stx.TxIn[1].PreviousOutPoint.Hash] = struct{}{}
+	}
+	return voted
+}
+
+// missCause returns the cause and reason recorded for the winning ticket of
+// audit a, whose vote was not included in the next block, which had voters of
+// votesPerBlock votes.
+func missCause(a voteAudit, voters, votesPerBlock uint16) (string, string) {
+	switch {
+	case a.untracked:
+		return MissedUntracked, "ticket was not in the live tickets of " +
+			"the voting wallet"
+	case a.voteErr != nil && a.signed:
+		return MissedBroadcast, "vote could not be broadcast: " +
+			a.voteErr.Error()
+	case a.voteErr != nil &&
+		strings.HasPrefix(a.voteErr.Error(), errWalletLockedCode):
+		return MissedWalletLocked, a.voteErr.Error()
+	case a.voteErr != nil:
+		return MissedByPool, a.voteErr.Error()
+	case voters < votesPerBlock:
+		return MissedByNetwork, fmt.Sprintf("vote was not included in "+
+			"block, which included only %d of %d votes", voters,
+			votesPerBlock)
+	}
+	return MissedByNetwork, "vote was not included in block"
+}
+
+// lookupUntracked sets the msa and userid of the untracked winning ticket of
+// audit a from the voting wallet.  It returns false when the ticket does not belong to
+// a user of the voting service.
+func (spd *Stakepoold) lookupUntracked(ctx context.Context, a *voteAudit) bool {
+	res, err := spd.WalletConnection.RPCClient().GetTransaction(ctx, &a.ticket)
+	if err != nil {
+		if !strings.HasPrefix(err.Error(), errNoTxInfo) {
+			log.Warnf("auditVotes: unexpected GetTransaction error: '%v' "+
+				"for %v", err, a.ticket)
+		}
+		return false
+	}
+	spd.RLock()
+	defer spd.RUnlock()
+	for i := range res.Details {
+		cfg, ok := spd.UserVotingConfig[res.Details[i].Address]
+		if ok {
+			a.msa, a.userid = cfg.MultiSigAddress, cfg.Userid
+			return true
+		}
+	}
+	return false
+}
+
+// auditVotes verifies that the votes for all winning tickets that were
+// notified at least voteAuditDepth blocks before height were mined.  Tickets
+// without a mined vote are logged and recorded as missed along with the cause
+// of the miss, see missCause.
+func (spd *Stakepoold) auditVotes(ctx context.Context, height int64) {
+	spd.auditMtx.Lock()
+	var due [][]voteAudit
+	for h, audits := range spd.pendingAudits {
+		if h <= height-voteAuditDepth {
+			due = append(due, audits)
+			delete(spd.pendingAudits, h)
+		}
+	}
+	spd.auditMtx.Unlock()
+
+	// Audit in block order so that consecutive misses of the active
+	// instance are counted correctly while in standby.
+	sort.Slice(due, func(i, j int) bool {
+		return due[i][0].blockHeight < due[j][0].blockHeight
+	})
+
+	for _, audits := range due {
+		// Votes for the winners of block N are included in block N+1.
+		winHeight := audits[0].blockHeight
+		hash, err := spd.NodeConnection.GetBlockHash(ctx, winHeight+1)
+		if err != nil {
+			log.Errorf("auditVotes: GetBlockHash(%d) failed: %v",
+				winHeight+1, err)
+			continue
+		}
+		block, err := spd.NodeConnection.GetBlock(ctx, hash)
+		if err != nil {
+			log.Errorf("auditVotes: GetBlock(%v) failed: %v", hash, err)
+			continue
+		}
+
+		// When the block the tickets won in was reorganized out of the
+		// chain the tickets are still live and will be selected again.
+		if block.Header.PrevBlock != audits[0].blockHash {
+			log.Infof("auditVotes: block %v at height %d is no longer in "+
+				"the main chain, skipping audit of %d tickets",
+				audits[0].blockHash, winHeight, len(audits))
+			continue
+		}
+
+		voted := votedTickets(block)
+		var missedByPool, missedByNetwork int
+		for _, a := range audits {
+			_, ok := voted[a.ticket]
+			if a.standby {
+				spd.observeStandbyAudit(a, ok)
+				continue
+			}
+			if ok {
+				continue
+			}
+			if a.untracked && !spd.lookupUntracked(ctx, &a) {
+				continue
+			}
+
+			cause, reason := missCause(a, block.Header.Voters,
+				spd.Params.TicketsPerBlock)
+			if cause == MissedByNetwork {
+				missedByNetwork++
+				log.Warnf("auditVotes: ticket %v (userid %d multisig %v) "+
+					"winning in block %d was voted but the vote was not "+
+					"mined: %v", a.ticket, a.userid, a.msa, winHeight,
+					reason)
+			} else {
+				missedByPool++
+				log.Criticalf("auditVotes: ticket %v (userid %d multisig %v) "+
+					"winning in block %d was not voted (%s): %v", a.ticket,
+					a.userid, a.msa, winHeight, cause, reason)
+			}
+
+			if spd.UserData == nil {
+				continue
+			}
+			err := spd.UserData.MySQLInsertMissedTicket(a.userid,
+				a.ticket.String(), a.blockHash.String(), winHeight, cause,
+				reason)
+			if err != nil {
+				log.Errorf("auditVotes: unable to record missed ticket %v: %v",
+					a.ticket, err)
+			}
+		}
+
+		log.Infof("auditVotes: height %d audited %d winning tickets, "+
+			"missed by pool %d, missed by network %d", winHeight,
+			len(audits), missedByPool, missedByNetwork)
+	}
+}
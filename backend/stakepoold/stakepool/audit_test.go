@@ -0,0 +1,43 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMissCause(t *testing.T) {
+	locked := errors.New("-13: the wallet must be unlocked")
+	tests := []struct {
+		name   string
+		audit  voteAudit
+		voters uint16
+		cause  string
+		reason string
+	}{
+		{"untracked", voteAudit{untracked: true}, 5, MissedUntracked,
+			"ticket was not in the live tickets of the voting wallet"},
+		{"locked", voteAudit{voteErr: locked}, 5, MissedWalletLocked,
+			locked.Error()},
+		{"broadcast", voteAudit{voteErr: errors.New("-22: rejected"),
+			signed: true}, 5, MissedBroadcast,
+			"vote could not be broadcast: -22: rejected"},
+		{"pool", voteAudit{voteErr: errors.New("timeout")}, 5, MissedByPool,
+			"timeout"},
+		{"network", voteAudit{}, 5, MissedByNetwork,
+			"vote was not included in block"},
+		{"network-wide", voteAudit{}, 3, MissedByNetwork,
+			"vote was not included in block, which included only 3 of 5 " +
+				"votes"},
+	}
+	for _, test := range tests {
+		cause, reason := missCause(test.audit, test.voters, 5)
+		if cause != test.cause || reason != test.reason {
+			t.Errorf("%s: got %q, %q, want %q, %q", test.name, cause,
+				reason, test.cause, test.reason)
+		}
+	}
+}
@@ -69,11 +69,12 @@ func (c *Client) IsConnected() bool {
 }
 
 // RPCClient allows access to the underlying rpcclient by providing a copy of
-// its address.
+// its address.  The duration of the RPCs made with it is recorded in the
+// wallet RPC metrics.
 func (c *Client) RPCClient() *dcrwallet.Client {
 	c.mux.RLock()
 	defer c.mux.RUnlock()
-	raw := dcrwallet.RawRequestCaller(c.client)
+	raw := dcrwallet.RawRequestCaller(timedRequester{c.client})
 	return dcrwallet.NewClient(raw, c.params)
 }
 
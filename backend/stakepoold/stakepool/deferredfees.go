@@ -0,0 +1,39 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"context"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// The ways users pay the pool fee.  With FeeModeCommitment the first
+// commitment of each ticket pays the fee to a pool fee address.  With
+// FeeModeDeferred users pay a separate fee transaction for each ticket, which
+// the web frontend records in the TicketFee table.
+const (
+	FeeModeCommitment = "commitment"
+	FeeModeDeferred   = "deferred"
+)
+
+// feePaidTickets returns which of the winning tickets had their fee paid when
+// fees are deferred.  It returns nil, meaning that all tickets are voted, when
+// fees are paid by the ticket commitments or when the fee payments can not be
+// looked up, since missing the votes of paying users is worse than voting a
+// ticket whose fee is not paid.  The lookup is not retried so that it does not
+// delay the votes.
+func (spd *Stakepoold) feePaidTickets(ctx context.Context, tickets []*chainhash.Hash) map[chainhash.Hash]struct{} {
+	if !spd.DeferredFees || spd.UserData == nil {
+		return nil
+	}
+	paid, err := spd.UserData.MySQLFetchFeePaidTickets(ctx, tickets)
+	if err != nil {
+		log.Errorf("feePaidTickets: unable to look up the fees of %d "+
+			"winning tickets, voting all of them: %v", len(tickets), err)
+		return nil
+	}
+	return paid
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+func TestEvaluateDeferredFeeTicket(t *testing.T) {
+	// A ticket whose first commitment does not pay the pool fee.
+	tx := wire.NewMsgTx()
+	tx.AddTxOut(wire.NewTxOut(0, nil))
+	tx.AddTxOut(wire.NewTxOut(0, commitmentScript(bytes.Repeat([]byte{2}, 20))))
+	tx.AddTxOut(wire.NewTxOut(0, nil))
+
+	spd := &Stakepoold{
+		FeeAddrs: map[string]struct{}{},
+		Params:   chaincfg.MainNetParams(),
+	}
+	ok, err := spd.EvaluateStakePoolTicket(tx, "msa", 100)
+	if err != nil || ok {
+		t.Fatalf("ticket without a pool fee accepted: %v, %v", ok, err)
+	}
+
+	spd.DeferredFees = true
+	ok, err = spd.EvaluateStakePoolTicket(tx, "msa", 100)
+	if err != nil || !ok {
+		t.Fatalf("ticket with deferred fee rejected: %v, %v", ok, err)
+	}
+	if paid := spd.feePaidTickets(context.Background(), nil); paid != nil {
+		t.Fatalf("fee payments %v looked up without a database", paid)
+	}
+}
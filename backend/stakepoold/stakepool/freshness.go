@@ -0,0 +1,122 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/decred/dcrstakepool/internal/notify"
+)
+
+// Sources of the user voting config.
+const (
+	UserDataFromMySQL        = "MySQL"
+	UserDataFromDcrstakepool = "dcrstakepool"
+	UserDataFromDiskCache    = "disk cache"
+)
+
+const (
+	// userDataRetryInitialDelay is how long after failing the user voting
+	// config is first fetched again from MySQL when stakepoold started
+	// without it.  The delay doubles after every failure up to
+	// userDataRetryMaxDelay.
+	userDataRetryInitialDelay = 30 * time.Second
+	userDataRetryMaxDelay     = 10 * time.Minute
+)
+
+// userDataFreshness describes where the user voting config came from and
+// when.  Failing is set while the config could not be refreshed from MySQL,
+// so that voting relies on an older copy.
+type userDataFreshness struct {
+	Updated time.Time
+	Source  string
+	Failing bool
+}
+
+// stale returns the age of the user voting config at now, and whether it is
+// older than threshold while it cannot be refreshed.  A zero threshold
+// disables the check.
+func (f userDataFreshness) stale(now time.Time, threshold time.Duration) (time.Duration, bool) {
+	age := now.Sub(f.Updated)
+	return age, f.Failing && threshold > 0 && age > threshold
+}
+
+// SetUserDataFreshness records that the user voting config was obtained from
+// source at updated, and whether refreshing it from MySQL failed.  It is used
+// on startup, e.g. when the config was loaded from the disk cache.
+func (spd *Stakepoold) SetUserDataFreshness(source string, updated time.Time, failing bool) {
+	spd.Lock()
+	spd.userDataFreshness = userDataFreshness{
+		Updated: updated,
+		Source:  source,
+		Failing: failing,
+	}
+	spd.Unlock()
+}
+
+// userDataRefreshFailed records that the user voting config could not be
+// refreshed from MySQL.
+func (spd *Stakepoold) userDataRefreshFailed() {
+	spd.Lock()
+	spd.userDataFreshness.Failing = true
+	spd.Unlock()
+}
+
+// checkUserDataFreshness alerts when the user voting config used to vote on
+// the block at height was older than UserDataStaleAlert and could not be
+// refreshed.
+func (spd *Stakepoold) checkUserDataFreshness(freshness userDataFreshness, height int64) {
+	age, stale := freshness.stale(time.Now(), spd.UserDataStaleAlert)
+	if !stale {
+		return
+	}
+	msg := fmt.Sprintf("Voted on block %d with user voting preferences "+
+		"from %s which are %v old and cannot be refreshed from MySQL, "+
+		"changes made by users since are not applied", height,
+		freshness.Source, age.Round(time.Second))
+	if freshness.Updated.IsZero() {
+		msg = fmt.Sprintf("Voted on block %d without user voting "+
+			"preferences, which cannot be fetched from MySQL, all "+
+			"tickets vote with the defaults", height)
+	}
+	log.Warn(msg)
+	spd.Alerter.Alert(notify.KindStaleUsers, msg)
+}
+
+// RetryUserDataFromMySQL fetches the user voting config from MySQL with
+// backoff until it succeeds, the config was refreshed otherwise, e.g. by
+// dcrstakepool, or ctx is done.  It is used when the config could not be
+// fetched on startup.
+func (spd *Stakepoold) RetryUserDataFromMySQL(ctx context.Context) {
+	delay := userDataRetryInitialDelay
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		spd.RLock()
+		failing := spd.userDataFreshness.Failing
+		spd.RUnlock()
+		if !failing {
+			return
+		}
+
+		err := spd.UpdateUserDataFromMySQL()
+		if err == nil {
+			log.Infof("Refreshed the user voting preferences from MySQL")
+			return
+		}
+		delay *= 2
+		if delay > userDataRetryMaxDelay {
+			delay = userDataRetryMaxDelay
+		}
+		log.Warnf("Unable to refresh the user voting preferences from "+
+			"MySQL, retrying in %v: %v", delay, err)
+	}
+}
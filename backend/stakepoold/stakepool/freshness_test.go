@@ -0,0 +1,43 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/decred/dcrstakepool/backend/stakepoold/userdata"
+)
+
+func TestUserDataFreshness(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		freshness userDataFreshness
+		threshold time.Duration
+		stale     bool
+	}{
+		{userDataFreshness{now.Add(-time.Hour), UserDataFromMySQL, false}, time.Minute, false},
+		{userDataFreshness{now.Add(-time.Hour), UserDataFromMySQL, true}, time.Minute, true},
+		{userDataFreshness{now.Add(-time.Hour), UserDataFromMySQL, true}, 2 * time.Hour, false},
+		{userDataFreshness{now.Add(-time.Hour), UserDataFromDiskCache, true}, 0, false},
+		{userDataFreshness{time.Time{}, UserDataFromDiskCache, true}, time.Minute, true},
+	}
+	for i, test := range tests {
+		if _, stale := test.freshness.stale(now, test.threshold); stale != test.stale {
+			t.Errorf("test %d: got stale %v, want %v", i, stale, test.stale)
+		}
+	}
+
+	// Data pushed by dcrstakepool is fresh again.
+	spd := &Stakepoold{}
+	spd.SetUserDataFreshness(UserDataFromDiskCache, now.Add(-time.Hour), true)
+	spd.userDataRefreshFailed()
+	spd.UpdateUserData(map[string]userdata.UserVotingConfig{},
+		UserDataFromDcrstakepool)
+	f := spd.userDataFreshness
+	if f.Failing || f.Source != UserDataFromDcrstakepool || now.After(f.Updated) {
+		t.Fatalf("got freshness %+v after an update", f)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// The sets of tickets a looked up ticket can be found in.
+const (
+	TicketSetLive    = "live"
+	TicketSetAdded   = "added"
+	TicketSetIgnored = "ignored"
+	TicketSetHeld    = "held"
+)
+
+// TicketLookup is a ticket found by LookupTickets along with its multisig
+// address and the set of tickets it was found in.
+type TicketLookup struct {
+	Ticket          chainhash.Hash
+	MultiSigAddress string
+	Set             string
+}
+
+// LookupTickets returns the live, added low fee, ignored low fee and held
+// tickets which are either one of the passed tickets or belong to one of the
+// passed multisig addresses.  A ticket in several sets is returned once for
+// each set.
+func (spd *Stakepoold) LookupTickets(tickets []chainhash.Hash, msas []string) []TicketLookup {
+	ticketSet := make(map[chainhash.Hash]struct{}, len(tickets))
+	for _, ticket := range tickets {
+		ticketSet[ticket] = struct{}{}
+	}
+	msaSet := make(map[string]struct{}, len(msas))
+	for _, msa := range msas {
+		msaSet[msa] = struct{}{}
+	}
+
+	spd.RLock()
+	defer spd.RUnlock()
+
+	var found []TicketLookup
+	lookup := func(ticketsMSA map[chainhash.Hash]string, set string) {
+		for ticket, msa := range ticketsMSA {
+			_, ticketMatch := ticketSet[ticket]
+			_, msaMatch := msaSet[msa]
+			if ticketMatch || msaMatch {
+				found = append(found, TicketLookup{
+					Ticket:          ticket,
+					MultiSigAddress: msa,
+					Set:             set,
+				})
+			}
+		}
+	}
+	lookup(spd.LiveTicketsMSA, TicketSetLive)
+	lookup(spd.AddedLowFeeTicketsMSA, TicketSetAdded)
+	lookup(spd.IgnoredLowFeeTicketsMSA, TicketSetIgnored)
+	lookup(spd.LowFeeReviewMSA, TicketSetHeld)
+	return found
+}
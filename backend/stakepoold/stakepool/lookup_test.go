@@ -0,0 +1,44 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+func TestLookupTickets(t *testing.T) {
+	spd := &Stakepoold{
+		LiveTicketsMSA:          map[chainhash.Hash]string{{1}: "a", {2}: "b"},
+		AddedLowFeeTicketsMSA:   map[chainhash.Hash]string{{2}: "b"},
+		IgnoredLowFeeTicketsMSA: map[chainhash.Hash]string{{3}: "c"},
+		LowFeeReviewMSA:         map[chainhash.Hash]string{{4}: "a"},
+	}
+
+	found := spd.LookupTickets([]chainhash.Hash{{3}}, []string{"a"})
+	sets := make(map[chainhash.Hash]string)
+	for _, f := range found {
+		sets[f.Ticket] = f.Set
+	}
+	want := map[chainhash.Hash]string{
+		{1}: TicketSetLive,
+		{3}: TicketSetIgnored,
+		{4}: TicketSetHeld,
+	}
+	if len(found) != len(want) {
+		t.Fatalf("found %d tickets, want %d", len(found), len(want))
+	}
+	for ticket, set := range want {
+		if sets[ticket] != set {
+			t.Errorf("ticket %v found in set %q, want %q", ticket, sets[ticket], set)
+		}
+	}
+
+	// A ticket in several sets is returned for each of them.
+	if found := spd.LookupTickets([]chainhash.Hash{{2}}, nil); len(found) != 2 {
+		t.Fatalf("found ticket in %d sets, want 2", len(found))
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// lowFeeMessageKind is the kind of the in-app messages telling users that
+// their tickets failed the fee or ticket policy checks.  It matches the kind
+// used by the web frontend.
+const lowFeeMessageKind = "lowfee"
+
+// addLowFeeTickets adds the tickets of a block which failed the fee or ticket
+// policy checks to the ignored low fee tickets.  A misconfigured fee xpub or
+// pool fee makes every new ticket fail these checks, so when there are more
+// than MaxLowFeePerBlock of them an alert is returned, and automatic
+// classification is paused if PauseOnLowFeeSurge is set.  While paused, the
+// tickets are held for review in LowFeeReviewMSA until an admin calls
+// ResumeLowFeeClassification.  It returns whether the limit was exceeded and
+// whether the tickets were held.
+//
+// This function MUST be called with the stakepoold lock held (for writes).
+func (spd *Stakepoold) addLowFeeTickets(tickets map[chainhash.Hash]string) (surge, held bool) {
+	surge = spd.MaxLowFeePerBlock > 0 && len(tickets) > spd.MaxLowFeePerBlock
+	if surge && spd.PauseOnLowFeeSurge {
+		spd.LowFeePaused = true
+	}
+
+	if !spd.LowFeePaused {
+		for ticket, msa := range tickets {
+			spd.IgnoredLowFeeTicketsMSA[ticket] = msa
+		}
+		return surge, false
+	}
+
+	if spd.LowFeeReviewMSA == nil {
+		spd.LowFeeReviewMSA = make(map[chainhash.Hash]string)
+	}
+	for ticket, msa := range tickets {
+		spd.LowFeeReviewMSA[ticket] = msa
+	}
+	return surge, true
+}
+
+// LowFeeReview returns whether automatic classification of low fee tickets is
+// paused and the tickets held for review.
+func (spd *Stakepoold) LowFeeReview() (bool, map[chainhash.Hash]string) {
+	spd.RLock()
+	defer spd.RUnlock()
+
+	held := make(map[chainhash.Hash]string, len(spd.LowFeeReviewMSA))
+	for ticket, msa := range spd.LowFeeReviewMSA {
+		held[ticket] = msa
+	}
+	return spd.LowFeePaused, held
+}
+
+// ResumeLowFeeClassification moves the tickets held for review to the ignored
+// low fee tickets, from where an admin can add them to be voted, and resumes
+// automatic classification of low fee tickets.  It returns the number of
+// tickets which were held.
+func (spd *Stakepoold) ResumeLowFeeClassification() int {
+	spd.Lock()
+	defer spd.Unlock()
+
+	n := len(spd.LowFeeReviewMSA)
+	owners := spd.ticketOwners(spd.LowFeeReviewMSA)
+	for ticket, msa := range spd.LowFeeReviewMSA {
+		spd.IgnoredLowFeeTicketsMSA[ticket] = msa
+	}
+	spd.LowFeeReviewMSA = nil
+	spd.LowFeePaused = false
+
+	log.Infof("resumed automatic classification of low fee tickets, "+
+		"%d held tickets are now ignored", n)
+	go spd.notifyLowFeeTickets(owners)
+	return n
+}
+
+// ticketOwners groups tickets by the user ids of the owners of their multisig
+// addresses.  Tickets of unknown addresses are left out.
+//
+// This function MUST be called with the stakepoold lock held (for reads).
+func (spd *Stakepoold) ticketOwners(tickets map[chainhash.Hash]string) map[int64][]chainhash.Hash {
+	owners := make(map[int64][]chainhash.Hash)
+	for ticket, msa := range tickets {
+		voteCfg, ok := spd.UserVotingConfig[msa]
+		if !ok {
+			continue
+		}
+		owners[voteCfg.Userid] = append(owners[voteCfg.Userid], ticket)
+	}
+	return owners
+}
+
+// notifyLowFeeTickets delivers an in-app message to each user listing their
+// tickets which are ignored because they failed the fee or ticket policy
+// checks.
+func (spd *Stakepoold) notifyLowFeeTickets(owners map[int64][]chainhash.Hash) {
+	spd.notifyTickets(owners, lowFeeMessageKind, "Tickets flagged as low fee",
+		"The following tickets did not pay the voting service fee or do "+
+			"not follow the ticket policy and will not be voted unless an "+
+			"admin adds them: %s")
+}
+
+// KeepLowFeeReview keeps the tickets held for review out of a freshly loaded
+// list of ignored low fee tickets, and stops holding tickets which are no
+// longer ignored, e.g. because they were added by an admin or were spent.
+//
+// This function MUST be called with the stakepoold lock held (for writes).
+func (spd *Stakepoold) KeepLowFeeReview() {
+	for ticket := range spd.LowFeeReviewMSA {
+		if _, ok := spd.IgnoredLowFeeTicketsMSA[ticket]; ok {
+			delete(spd.IgnoredLowFeeTicketsMSA, ticket)
+			continue
+		}
+		delete(spd.LowFeeReviewMSA, ticket)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrstakepool/backend/stakepoold/userdata"
+)
+
+func TestLowFeeSurge(t *testing.T) {
+	spd := &Stakepoold{
+		IgnoredLowFeeTicketsMSA: make(map[chainhash.Hash]string),
+		LiveTicketsMSA:          make(map[chainhash.Hash]string),
+		MaxLowFeePerBlock:       2,
+	}
+
+	// Tickets up to the limit are ignored without an alert.
+	surge, held := spd.addLowFeeTickets(map[chainhash.Hash]string{{1}: "a", {2}: "b"})
+	if surge || held || len(spd.IgnoredLowFeeTicketsMSA) != 2 {
+		t.Fatalf("got surge %v held %v with %d ignored tickets", surge, held,
+			len(spd.IgnoredLowFeeTicketsMSA))
+	}
+
+	// Without PauseOnLowFeeSurge a surge only alerts.
+	surge, held = spd.addLowFeeTickets(map[chainhash.Hash]string{{3}: "a", {4}: "b", {5}: "c"})
+	if !surge || held || len(spd.IgnoredLowFeeTicketsMSA) != 5 || spd.LowFeePaused {
+		t.Fatalf("got surge %v held %v with %d ignored tickets", surge, held,
+			len(spd.IgnoredLowFeeTicketsMSA))
+	}
+
+	// With it, tickets are held from the surge on until classification is
+	// resumed.
+	spd.PauseOnLowFeeSurge = true
+	surge, held = spd.addLowFeeTickets(map[chainhash.Hash]string{{6}: "a", {7}: "b", {8}: "c"})
+	if !surge || !held || len(spd.IgnoredLowFeeTicketsMSA) != 5 {
+		t.Fatalf("got surge %v held %v with %d ignored tickets", surge, held,
+			len(spd.IgnoredLowFeeTicketsMSA))
+	}
+	surge, held = spd.addLowFeeTickets(map[chainhash.Hash]string{{9}: "a"})
+	if surge || !held {
+		t.Fatalf("got surge %v held %v while paused", surge, held)
+	}
+	paused, tickets := spd.LowFeeReview()
+	if !paused || len(tickets) != 4 || tickets[chainhash.Hash{9}] != "a" {
+		t.Fatalf("got paused %v with held tickets %v", paused, tickets)
+	}
+
+	// Refreshing the tickets from the wallet keeps held tickets out of the
+	// ignored list, and stops holding tickets which are no longer ignored.
+	spd.IgnoredLowFeeTicketsMSA[chainhash.Hash{6}] = "a"
+	spd.IgnoredLowFeeTicketsMSA[chainhash.Hash{7}] = "b"
+	spd.IgnoredLowFeeTicketsMSA[chainhash.Hash{8}] = "c"
+	spd.LiveTicketsMSA[chainhash.Hash{9}] = "a"
+	spd.KeepLowFeeReview()
+	if len(spd.IgnoredLowFeeTicketsMSA) != 5 || len(spd.LowFeeReviewMSA) != 3 {
+		t.Fatalf("got %d ignored and %d held tickets after refresh",
+			len(spd.IgnoredLowFeeTicketsMSA), len(spd.LowFeeReviewMSA))
+	}
+
+	if n := spd.ResumeLowFeeClassification(); n != 3 {
+		t.Fatalf("released %d tickets, want 3", n)
+	}
+	paused, tickets = spd.LowFeeReview()
+	if paused || len(tickets) != 0 || len(spd.IgnoredLowFeeTicketsMSA) != 8 {
+		t.Fatalf("got paused %v with %d held and %d ignored tickets after "+
+			"resuming", paused, len(tickets), len(spd.IgnoredLowFeeTicketsMSA))
+	}
+}
+
+func TestTicketOwners(t *testing.T) {
+	spd := &Stakepoold{
+		UserVotingConfig: map[string]userdata.UserVotingConfig{
+			"a": {Userid: 1, MultiSigAddress: "a"},
+			"b": {Userid: 2, MultiSigAddress: "b"},
+		},
+	}
+
+	owners := spd.ticketOwners(map[chainhash.Hash]string{
+		{1}: "a", {2}: "a", {3}: "b", {4}: "unknown",
+	})
+	if len(owners) != 2 || len(owners[1]) != 2 || len(owners[2]) != 1 {
+		t.Fatalf("got owners %v", owners)
+	}
+	if owners[2][0] != (chainhash.Hash{3}) {
+		t.Fatalf("got ticket %v for user 2, want %v", owners[2][0],
+			chainhash.Hash{3})
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// maxMessageTickets is the number of tickets listed by an in-app message, so
+// that the messages about large batches of tickets fit their column.
+const maxMessageTickets = 20
+
+// sortedTicketHashes returns the hashes of tickets as sorted strings.
+func sortedTicketHashes(tickets []chainhash.Hash) []string {
+	hashes := make([]string, 0, len(tickets))
+	for i := range tickets {
+		hashes = append(hashes, tickets[i].String())
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// messageTicketList lists the ticket hashes in an in-app message, up to
+// maxMessageTickets of them.
+func messageTicketList(hashes []string) string {
+	if len(hashes) <= maxMessageTickets {
+		return strings.Join(hashes, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(
+		hashes[:maxMessageTickets], ", "), len(hashes)-maxMessageTickets)
+}
+
+// messageDedupeKey returns the key identifying the in-app message of kind
+// about the sorted ticket hashes to the user, which is the same on every
+// stakepoold instance so that the message is only delivered once.
+func messageDedupeKey(userid int64, kind string, hashes []string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:%s:%s", userid, kind, strings.Join(hashes, ","))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// notifyTickets delivers an in-app message of kind to each user listing their
+// tickets in owners.  bodyFormat has a single %s verb for the ticket list.
+// Every stakepoold instance sends the same messages, which are only delivered
+// once.
+func (spd *Stakepoold) notifyTickets(owners map[int64][]chainhash.Hash, kind,
+	subject, bodyFormat string) {
+	if spd.UserData == nil {
+		return
+	}
+	for userid, tickets := range owners {
+		hashes := sortedTicketHashes(tickets)
+		body := fmt.Sprintf(bodyFormat, messageTicketList(hashes))
+		err := spd.UserData.MySQLInsertMessage(userid, kind, subject, body,
+			messageDedupeKey(userid, kind, hashes))
+		if err != nil {
+			log.Errorf("notifyTickets: unable to deliver %s message to "+
+				"user %d about %d tickets: %v", kind, userid,
+				len(tickets), err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+func TestMessageTickets(t *testing.T) {
+	tickets := make([]chainhash.Hash, maxMessageTickets+2)
+	for i := range tickets {
+		tickets[i] = chainhash.Hash{byte(len(tickets) - i)}
+	}
+	hashes := sortedTicketHashes(tickets)
+	if !sort.StringsAreSorted(hashes) {
+		t.Fatalf("hashes %v are not sorted", hashes)
+	}
+
+	list := messageTicketList(hashes)
+	if !strings.HasSuffix(list, " and 2 more") ||
+		strings.Count(list, ", ") != maxMessageTickets-1 {
+		t.Fatalf("got ticket list %q", list)
+	}
+	if list := messageTicketList(hashes[:2]); list != hashes[0]+", "+hashes[1] {
+		t.Fatalf("got ticket list %q", list)
+	}
+
+	// Every instance derives the same key from the same tickets, whatever
+	// their order.
+	reversed := make([]chainhash.Hash, len(tickets))
+	for i := range tickets {
+		reversed[len(tickets)-1-i] = tickets[i]
+	}
+	key := messageDedupeKey(1, lowFeeMessageKind, hashes)
+	if messageDedupeKey(1, lowFeeMessageKind, sortedTicketHashes(reversed)) != key {
+		t.Fatal("keys of the same tickets differ")
+	}
+	if len(key) != 64 {
+		t.Fatalf("got key %q", key)
+	}
+	if messageDedupeKey(2, lowFeeMessageKind, hashes) == key ||
+		messageDedupeKey(1, "userlimit", hashes) == key ||
+		messageDedupeKey(1, lowFeeMessageKind, hashes[1:]) == key {
+		t.Fatal("keys of different messages are equal")
+	}
+}
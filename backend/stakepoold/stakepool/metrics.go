@@ -0,0 +1,130 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"decred.org/dcrwallet/rpc/client/dcrwallet"
+	"github.com/decred/dcrstakepool/backend/stakepoold/metrics"
+)
+
+var (
+	// walletRPCDuration and walletRPCErrors track the wallet RPCs by
+	// method.
+	walletRPCDuration = metrics.NewHistogramVec(
+		"stakepoold_wallet_rpc_duration_seconds",
+		"Duration of dcrwallet RPCs.",
+		metrics.DefaultBuckets, "method")
+	walletRPCErrors = metrics.NewCounterVec(
+		"stakepoold_wallet_rpc_errors_total",
+		"Number of dcrwallet RPCs which returned an error.", "method")
+
+	// votesTotal counts the votes on winning tickets by result, which is
+	// one of voted, duplicate or error.
+	votesTotal = metrics.NewCounterVec("stakepoold_votes_total",
+		"Number of votes on managed winning tickets by result.", "result")
+
+	// missedTicketsTotal counts the managed tickets which were missed.
+	missedTicketsTotal = metrics.NewCounterVec(
+		"stakepoold_missed_tickets_total",
+		"Number of managed tickets which were missed.")
+
+	// blockProcessingDuration tracks how long each ticket handler takes to
+	// process a block.
+	blockProcessingDuration = metrics.NewHistogramVec(
+		"stakepoold_block_processing_duration_seconds",
+		"Duration of processing a block by ticket handler.",
+		metrics.DefaultBuckets, "handler")
+
+	// blocksConnectedTotal, winningTicketsNtfnsTotal and
+	// managedWinnersTotal track the blocks and winning tickets notified by
+	// dcrd for the WinningWatchdog.
+	blocksConnectedTotal = metrics.NewCounterVec(
+		"stakepoold_blocks_connected_total",
+		"Number of blocks connected notifications received from dcrd.")
+	winningTicketsNtfnsTotal = metrics.NewCounterVec(
+		"stakepoold_winning_tickets_notifications_total",
+		"Number of winning tickets notifications received from dcrd.")
+	managedWinnersTotal = metrics.NewCounterVec(
+		"stakepoold_managed_winning_tickets_total",
+		"Number of managed tickets which were selected to vote.")
+)
+
+// timedRequester records the duration and errors of the wallet RPCs it
+// forwards.
+type timedRequester struct {
+	dcrwallet.RawRequester
+}
+
+// RawRequest performs the wallet RPC and records its duration.
+func (t timedRequester) RawRequest(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
+	start := time.Now()
+	res, err := t.RawRequester.RawRequest(ctx, method, params)
+	walletRPCDuration.ObserveDuration(time.Since(start), method)
+	if err != nil {
+		walletRPCErrors.Inc(method)
+	}
+	return res, err
+}
+
+// RegisterMetrics adds the metrics of the ticket handlers, their queues and
+// the wallet RPCs to r.
+func (spd *Stakepoold) RegisterMetrics(r *metrics.Registry) {
+	queueGauge := func(value func(TicketQueueStats) float64) func() []metrics.Sample {
+		return func() []metrics.Sample {
+			stats := spd.TicketQueueStats()
+			samples := make([]metrics.Sample, 0, len(stats))
+			for _, s := range stats {
+				samples = append(samples, metrics.Sample{
+					LabelValues: []string{s.Name},
+					Value:       value(s),
+				})
+			}
+			return samples
+		}
+	}
+
+	r.Register(
+		walletRPCDuration,
+		walletRPCErrors,
+		votesTotal,
+		missedTicketsTotal,
+		blockProcessingDuration,
+		blocksConnectedTotal,
+		winningTicketsNtfnsTotal,
+		managedWinnersTotal,
+		metrics.NewGaugeFunc("stakepoold_blocks_without_winning_tickets",
+			"Number of blocks connected since the last winning tickets "+
+				"notification.",
+			func() []metrics.Sample {
+				return []metrics.Sample{{
+					Value: float64(spd.WinningWatch.silentBlockCount()),
+				}}
+			}),
+		metrics.NewGaugeFunc("stakepoold_ticket_queue_depth",
+			"Number of blocks waiting for a ticket handler.",
+			queueGauge(func(s TicketQueueStats) float64 {
+				return float64(s.Depth)
+			}), "queue"),
+		metrics.NewGaugeFunc("stakepoold_ticket_queue_capacity",
+			"Maximum number of blocks a ticket handler queue holds.",
+			queueGauge(func(s TicketQueueStats) float64 {
+				return float64(s.Capacity)
+			}), "queue"),
+		metrics.NewGaugeFunc("stakepoold_ticket_queue_max_depth",
+			"Highest number of blocks seen waiting for a ticket handler.",
+			queueGauge(func(s TicketQueueStats) float64 {
+				return float64(s.MaxDepth)
+			}), "queue"),
+		metrics.NewGaugeFunc("stakepoold_ticket_queue_processed",
+			"Number of blocks processed by a ticket handler.",
+			queueGauge(func(s TicketQueueStats) float64 {
+				return float64(s.Processed)
+			}), "queue"),
+	)
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// PolicyTicket describes a ticket checked by a TicketPolicy.
+type PolicyTicket struct {
+	Tx              *wire.MsgTx
+	MultiSigAddress string
+	BlockHeight     int32
+}
+
+// TicketPolicy is a custom check which tickets must pass, in addition to
+// paying the voting service fee, to be voted by the voting service.
+type TicketPolicy interface {
+	// CheckTicket returns a description of why the ticket is rejected, or an
+	// empty string when it is accepted.  An error is returned when the ticket
+	// could not be checked, which also causes it to be rejected.
+	CheckTicket(ticket *PolicyTicket) (string, error)
+}
+
+// TicketPolicyFactory creates a TicketPolicy from the arguments given to it
+// in the configuration.
+type TicketPolicyFactory func(params *chaincfg.Params, args string) (TicketPolicy, error)
+
+var (
+	ticketPoliciesMtx sync.Mutex
+	ticketPolicies    = make(map[string]TicketPolicyFactory)
+)
+
+// RegisterTicketPolicy makes a ticket policy available under name so that it
+// can be selected with the ticketpolicy option.  It is intended to be called
+// from the init function of the package implementing the policy and panics if
+// name is already registered.
+func RegisterTicketPolicy(name string, factory TicketPolicyFactory) {
+	ticketPoliciesMtx.Lock()
+	defer ticketPoliciesMtx.Unlock()
+
+	if _, ok := ticketPolicies[name]; ok {
+		panic(fmt.Sprintf("ticket policy %q registered twice", name))
+	}
+	ticketPolicies[name] = factory
+}
+
+// TicketPolicyNames returns the sorted names of the registered ticket
+// policies.
+func TicketPolicyNames() []string {
+	ticketPoliciesMtx.Lock()
+	defer ticketPoliciesMtx.Unlock()
+
+	names := make([]string, 0, len(ticketPolicies))
+	for name := range ticketPolicies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewTicketPolicy creates the registered ticket policy described by spec,
+// which is the policy name optionally followed by a colon and the arguments
+// passed to the policy.
+func NewTicketPolicy(params *chaincfg.Params, spec string) (TicketPolicy, error) {
+	name, args := spec, ""
+	if i := strings.Index(spec, ":"); i != -1 {
+		name, args = spec[:i], spec[i+1:]
+	}
+
+	ticketPoliciesMtx.Lock()
+	factory, ok := ticketPolicies[name]
+	ticketPoliciesMtx.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown ticket policy %q (available: %s)",
+			name, strings.Join(TicketPolicyNames(), ", "))
+	}
+
+	policy, err := factory(params, args)
+	if err != nil {
+		return nil, fmt.Errorf("ticket policy %q: %v", name, err)
+	}
+	return policy, nil
+}
+
+func init() {
+	RegisterTicketPolicy("denyaddrs", newDenyAddrsPolicy)
+}
+
+// denyAddrsPolicy rejects tickets which commit to any of a list of addresses,
+// e.g. addresses subject to sanctions.
+type denyAddrsPolicy struct {
+	params *chaincfg.Params
+	addrs  map[string]struct{}
+}
+
+// newDenyAddrsPolicy reads the denied addresses from the file named by args,
+// which lists one address per line.  Empty lines and lines starting with # are
+// ignored.
+func newDenyAddrsPolicy(params *chaincfg.Params, args string) (TicketPolicy, error) {
+	if args == "" {
+		return nil, fmt.Errorf("no address file, use denyaddrs:<file>")
+	}
+
+	f, err := os.Open(args)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p := &denyAddrsPolicy{
+		params: params,
+		addrs:  make(map[string]struct{}),
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.addrs[line] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	log.Infof("Loaded %d denied addresses from %s", len(p.addrs), args)
+	return p, nil
+}
+
+// CheckTicket rejects the ticket when one of its commitment outputs pays to a
+// denied address.
+func (p *denyAddrsPolicy) CheckTicket(ticket *PolicyTicket) (string, error) {
+	for i := 1; i < len(ticket.Tx.TxOut); i += 2 {
+		addr, err := stake.AddrFromSStxPkScrCommitment(
+			ticket.Tx.TxOut[i].PkScript, p.params)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse commit out addr for "+
+				"vout %d: %v", i, err)
+		}
+		if _, ok := p.addrs[addr.Address()]; ok {
+			return fmt.Sprintf("commitment to denied address %s", addr), nil
+		}
+	}
+	return "", nil
+}
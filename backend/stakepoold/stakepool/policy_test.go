@@ -0,0 +1,79 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// commitmentScript returns a ticket commitment output script paying to the
+// pubkey hash hash160.
+func commitmentScript(hash160 []byte) []byte {
+	script := []byte{0x6a, 0x1e} // OP_RETURN OP_DATA_30
+	script = append(script, hash160...)
+	script = append(script, make([]byte, 8+2)...) // amount and fee limits
+	return script
+}
+
+func TestDenyAddrsPolicy(t *testing.T) {
+	params := chaincfg.MainNetParams()
+	denied, err := dcrutil.NewAddressPubKeyHash(bytes.Repeat([]byte{1}, 20),
+		params, dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "denied.txt")
+	err = ioutil.WriteFile(file, []byte("# sanctioned\n\n"+denied.Address()+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewTicketPolicy(params, "unknown"); err == nil ||
+		!strings.Contains(err.Error(), "denyaddrs") {
+		t.Fatalf("unexpected error for unknown policy: %v", err)
+	}
+	if _, err := NewTicketPolicy(params, "denyaddrs"); err == nil {
+		t.Fatal("no error for denyaddrs without a file")
+	}
+	policy, err := NewTicketPolicy(params, "denyaddrs:"+file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := wire.NewMsgTx()
+	tx.AddTxOut(wire.NewTxOut(0, nil))
+	tx.AddTxOut(wire.NewTxOut(0, commitmentScript(bytes.Repeat([]byte{2}, 20))))
+	tx.AddTxOut(wire.NewTxOut(0, nil))
+	tx.AddTxOut(wire.NewTxOut(0, commitmentScript(bytes.Repeat([]byte{3}, 20))))
+	tx.AddTxOut(wire.NewTxOut(0, nil))
+	ticket := &PolicyTicket{Tx: tx}
+
+	reason, err := policy.CheckTicket(ticket)
+	if err != nil || reason != "" {
+		t.Fatalf("ticket without denied address rejected: %q, %v", reason, err)
+	}
+
+	tx.TxOut[3].PkScript = commitmentScript(denied.Hash160()[:])
+	reason, err = policy.CheckTicket(ticket)
+	if err != nil || !strings.Contains(reason, denied.Address()) {
+		t.Fatalf("ticket with denied address not rejected: %q, %v", reason, err)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"sync/atomic"
+)
+
+// TicketQueueSize is the capacity of the channels which queue notifications
+// for the ticket handlers.  Each handler processes one block at a time in the
+// order the blocks were notified, so a burst of blocks is queued, and dcrd
+// notifications are not read while a queue is full.
+const TicketQueueSize = 32
+
+// Names of the ticket handler queues used in logs and queue stats.
+const (
+	newTicketsQueueName  = "NewTickets"
+	spentMissedQueueName = "SpentMissedTickets"
+	winningQueueName     = "WinningTickets"
+)
+
+// TicketQueueStats describes the queue of notifications waiting for one of the
+// ticket handlers.
+type TicketQueueStats struct {
+	Name      string
+	Depth     int
+	Capacity  int
+	MaxDepth  int
+	Processed uint64
+}
+
+// queueMetrics tracks the depth of a ticket handler queue.  Its fields are
+// accessed atomically.
+type queueMetrics struct {
+	maxDepth  int64
+	processed uint64
+}
+
+// dequeued records that a notification was taken off a queue which still holds
+// depth notifications, and warns when the queue is filling up.
+func (m *queueMetrics) dequeued(name string, depth, capacity int) {
+	atomic.AddUint64(&m.processed, 1)
+	for {
+		max := atomic.LoadInt64(&m.maxDepth)
+		if int64(depth) <= max ||
+			atomic.CompareAndSwapInt64(&m.maxDepth, max, int64(depth)) {
+			break
+		}
+	}
+	if capacity > 0 && depth >= capacity/2 {
+		log.Warnf("%s queue holds %d of at most %d blocks, processing is "+
+			"falling behind", name, depth, capacity)
+	}
+}
+
+// stats returns the current state of a queue.
+func (m *queueMetrics) stats(name string, depth, capacity int) TicketQueueStats {
+	return TicketQueueStats{
+		Name:      name,
+		Depth:     depth,
+		Capacity:  capacity,
+		MaxDepth:  int(atomic.LoadInt64(&m.maxDepth)),
+		Processed: atomic.LoadUint64(&m.processed),
+	}
+}
+
+// TicketQueueStats returns the state of the new, spent and missed, and winning
+// ticket handler queues.
+func (spd *Stakepoold) TicketQueueStats() []TicketQueueStats {
+	return []TicketQueueStats{
+		spd.newTicketsQueue.stats(newTicketsQueueName,
+			len(spd.NewTicketsChan), cap(spd.NewTicketsChan)),
+		spd.spentMissedQueue.stats(spentMissedQueueName,
+			len(spd.SpentmissedTicketsChan), cap(spd.SpentmissedTicketsChan)),
+		spd.winningQueue.stats(winningQueueName,
+			len(spd.WinningTicketsChan), cap(spd.WinningTicketsChan)),
+	}
+}
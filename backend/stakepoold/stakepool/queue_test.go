@@ -0,0 +1,38 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"testing"
+)
+
+func TestTicketQueueStats(t *testing.T) {
+	spd := &Stakepoold{
+		NewTicketsChan: make(chan NewTicketsForBlock, TicketQueueSize),
+	}
+	spd.NewTicketsChan <- NewTicketsForBlock{BlockHeight: 1}
+	spd.NewTicketsChan <- NewTicketsForBlock{BlockHeight: 2}
+
+	spd.newTicketsQueue.dequeued(newTicketsQueueName, 5, TicketQueueSize)
+	spd.newTicketsQueue.dequeued(newTicketsQueueName, 2, TicketQueueSize)
+
+	stats := spd.TicketQueueStats()
+	if len(stats) != 3 {
+		t.Fatalf("got %d queues", len(stats))
+	}
+	want := TicketQueueStats{
+		Name:      newTicketsQueueName,
+		Depth:     2,
+		Capacity:  TicketQueueSize,
+		MaxDepth:  5,
+		Processed: 2,
+	}
+	if stats[0] != want {
+		t.Fatalf("got stats %+v, want %+v", stats[0], want)
+	}
+	if stats[2] != (TicketQueueStats{Name: winningQueueName}) {
+		t.Fatalf("got stats %+v for an unused queue", stats[2])
+	}
+}
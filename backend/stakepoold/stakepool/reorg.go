@@ -0,0 +1,206 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// maxReorgDepth is the number of most recent blocks whose ticket changes are
+// kept so that they can be rolled back when the blocks are disconnected by a
+// chain reorganization.  Deeper reorganizations are only corrected by the
+// resynchronization with the wallet which follows them.
+const maxReorgDepth = 32
+
+// blockTicketChanges are the changes made to the ticket maps when processing
+// the new and the spent and missed tickets of a block.
+type blockTicketChanges struct {
+	height int64
+
+	// added are the tickets which matured in the block and were added to
+	// the live, ignored low fee or held tickets.
+	added []chainhash.Hash
+
+	// removedLive, removedIgnored and removedHeld are the tickets spent or
+	// missed in the block and the multisig addresses they were removed
+	// with from the live, ignored low fee and held tickets.
+	removedLive    map[chainhash.Hash]string
+	removedIgnored map[chainhash.Hash]string
+	removedHeld    map[chainhash.Hash]string
+}
+
+// blockChanges returns the journal of ticket changes of the block, creating it
+// if needed, and drops the journals and orphan records of blocks too deep to
+// be reorganized.
+//
+// This function MUST be called with the stakepoold lock held (for writes).
+func (spd *Stakepoold) blockChanges(blockHash *chainhash.Hash, height int64) *blockTicketChanges {
+	if spd.ticketJournal == nil {
+		spd.ticketJournal = make(map[chainhash.Hash]*blockTicketChanges)
+	}
+	changes, ok := spd.ticketJournal[*blockHash]
+	if ok {
+		return changes
+	}
+
+	for hash, c := range spd.ticketJournal {
+		if c.height <= height-maxReorgDepth {
+			delete(spd.ticketJournal, hash)
+		}
+	}
+	for hash, h := range spd.orphanedBlocks {
+		if h <= height-maxReorgDepth {
+			delete(spd.orphanedBlocks, hash)
+		}
+	}
+
+	changes = &blockTicketChanges{
+		height:         height,
+		removedLive:    make(map[chainhash.Hash]string),
+		removedIgnored: make(map[chainhash.Hash]string),
+		removedHeld:    make(map[chainhash.Hash]string),
+	}
+	spd.ticketJournal[*blockHash] = changes
+	return changes
+}
+
+// isOrphaned returns whether the block was disconnected from the main chain.
+// Notifications are processed concurrently, so those of a block may still be
+// in flight when it is disconnected and must then be discarded.
+//
+// This function MUST be called with the stakepoold lock held (for reads).
+func (spd *Stakepoold) isOrphaned(blockHash *chainhash.Hash) bool {
+	_, ok := spd.orphanedBlocks[*blockHash]
+	return ok
+}
+
+// connectNewTickets adds the tickets which matured in a block to the live
+// tickets, the low fee tickets to the ignored or held tickets and the tickets
+// over the limit of live tickets per user to the ignored tickets, recording
+// the changes so that they can be rolled back.  Nothing is changed when the
+// block was already disconnected.  It returns whether the low fee tickets
+// exceeded the per block limit and whether they were held, as
+// addLowFeeTickets, and whether the block was orphaned.
+//
+// This function MUST be called with the stakepoold lock held (for writes).
+func (spd *Stakepoold) connectNewTickets(blockHash *chainhash.Hash, height int64,
+	live, lowFee, overLimit map[chainhash.Hash]string) (surge, held, orphaned bool) {
+
+	if spd.isOrphaned(blockHash) {
+		return false, false, true
+	}
+
+	changes := spd.blockChanges(blockHash, height)
+	surge, held = spd.addLowFeeTickets(lowFee)
+	for ticket := range lowFee {
+		changes.added = append(changes.added, ticket)
+	}
+	for ticket, msa := range overLimit {
+		spd.IgnoredLowFeeTicketsMSA[ticket] = msa
+		changes.added = append(changes.added, ticket)
+	}
+	for ticket, msa := range live {
+		spd.LiveTicketsMSA[ticket] = msa
+		changes.added = append(changes.added, ticket)
+	}
+	return surge, held, false
+}
+
+// connectSpentMissedTickets removes the tickets spent or missed in a block,
+// recording the changes so that they can be rolled back.  Nothing is changed
+// and false is returned when the block was already disconnected.
+//
+// This function MUST be called with the stakepoold lock held (for writes).
+func (spd *Stakepoold) connectSpentMissedTickets(blockHash *chainhash.Hash, height int64,
+	tickets []*chainhash.Hash) bool {
+
+	if spd.isOrphaned(blockHash) {
+		return false
+	}
+
+	changes := spd.blockChanges(blockHash, height)
+	remove := func(ticketsMSA, removed map[chainhash.Hash]string, ticket chainhash.Hash) {
+		if msa, ok := ticketsMSA[ticket]; ok {
+			removed[ticket] = msa
+			delete(ticketsMSA, ticket)
+		}
+	}
+	for _, ticket := range tickets {
+		remove(spd.LiveTicketsMSA, changes.removedLive, *ticket)
+		remove(spd.IgnoredLowFeeTicketsMSA, changes.removedIgnored, *ticket)
+		remove(spd.LowFeeReviewMSA, changes.removedHeld, *ticket)
+	}
+	return true
+}
+
+// ConnectBlock records that a block was connected to the main chain.  A block
+// disconnected by a chain reorganization is connected again when the
+// reorganization is reversed, and is then no longer orphaned, so that its
+// tickets are processed and its winning tickets voted.
+func (spd *Stakepoold) ConnectBlock(blockHash *chainhash.Hash, height int64) {
+	spd.Lock()
+	defer spd.Unlock()
+
+	if _, ok := spd.orphanedBlocks[*blockHash]; !ok {
+		return
+	}
+	delete(spd.orphanedBlocks, *blockHash)
+	log.Infof("ConnectBlock: orphaned block %v (height %d) was connected "+
+		"again", blockHash, height)
+}
+
+// DisconnectBlock rolls back the ticket changes made by a block which was
+// disconnected from the main chain: tickets which matured in it are removed
+// and tickets spent or missed in it are restored.  The tickets are evaluated
+// again when they mature, are spent or are missed in the blocks of the new
+// main chain.  It returns the number of tickets rolled back.
+func (spd *Stakepoold) DisconnectBlock(blockHash *chainhash.Hash, height int64) int {
+	spd.Lock()
+	defer spd.Unlock()
+
+	if spd.orphanedBlocks == nil {
+		spd.orphanedBlocks = make(map[chainhash.Hash]int64)
+	}
+	spd.orphanedBlocks[*blockHash] = height
+
+	changes, ok := spd.ticketJournal[*blockHash]
+	if !ok {
+		log.Infof("DisconnectBlock: no ticket changes recorded for block "+
+			"%v (height %d)", blockHash, height)
+		return 0
+	}
+	delete(spd.ticketJournal, *blockHash)
+
+	for _, ticket := range changes.added {
+		delete(spd.LiveTicketsMSA, ticket)
+		delete(spd.IgnoredLowFeeTicketsMSA, ticket)
+		delete(spd.LowFeeReviewMSA, ticket)
+	}
+	for ticket, msa := range changes.removedLive {
+		spd.LiveTicketsMSA[ticket] = msa
+	}
+	for ticket, msa := range changes.removedIgnored {
+		spd.IgnoredLowFeeTicketsMSA[ticket] = msa
+	}
+	// Held tickets were moved to the ignored tickets if classification was
+	// resumed in the meantime.
+	heldMSA := spd.IgnoredLowFeeTicketsMSA
+	if spd.LowFeePaused {
+		if spd.LowFeeReviewMSA == nil {
+			spd.LowFeeReviewMSA = make(map[chainhash.Hash]string)
+		}
+		heldMSA = spd.LowFeeReviewMSA
+	}
+	for ticket, msa := range changes.removedHeld {
+		heldMSA[ticket] = msa
+	}
+
+	restored := len(changes.removedLive) + len(changes.removedIgnored) +
+		len(changes.removedHeld)
+	log.Infof("DisconnectBlock: rolled back block %v (height %d) -- removed "+
+		"%d matured tickets, restored %d spent or missed tickets", blockHash,
+		height, len(changes.added), restored)
+	return len(changes.added) + restored
+}
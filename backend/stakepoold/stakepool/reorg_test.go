@@ -0,0 +1,134 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+func TestDisconnectBlock(t *testing.T) {
+	spd := &Stakepoold{
+		IgnoredLowFeeTicketsMSA: map[chainhash.Hash]string{{1}: "a"},
+		LiveTicketsMSA:          map[chainhash.Hash]string{{2}: "b", {3}: "c"},
+	}
+	copyMSA := func(m map[chainhash.Hash]string) map[chainhash.Hash]string {
+		c := make(map[chainhash.Hash]string, len(m))
+		for k, v := range m {
+			c[k] = v
+		}
+		return c
+	}
+	wantIgnored := copyMSA(spd.IgnoredLowFeeTicketsMSA)
+	wantLive := copyMSA(spd.LiveTicketsMSA)
+
+	// Block 100 matures a live, a low fee and an over limit ticket, then
+	// block 101 spends a live and an ignored ticket.
+	block100, block101 := &chainhash.Hash{100}, &chainhash.Hash{101}
+	spd.Lock()
+	_, _, orphaned := spd.connectNewTickets(block100, 100,
+		map[chainhash.Hash]string{{4}: "d"}, map[chainhash.Hash]string{{5}: "e"},
+		map[chainhash.Hash]string{{7}: "d"})
+	if orphaned {
+		t.Fatal("block 100 is orphaned")
+	}
+	if !spd.connectSpentMissedTickets(block101, 101,
+		[]*chainhash.Hash{{1}, {2}, {9}}) {
+		t.Fatal("block 101 is orphaned")
+	}
+	spd.Unlock()
+	if len(spd.LiveTicketsMSA) != 2 || len(spd.IgnoredLowFeeTicketsMSA) != 2 {
+		t.Fatalf("got live %v ignored %v", spd.LiveTicketsMSA,
+			spd.IgnoredLowFeeTicketsMSA)
+	}
+
+	// Disconnecting both blocks restores the tickets from before them.
+	if n := spd.DisconnectBlock(block101, 101); n != 2 {
+		t.Fatalf("rolled back %d tickets of block 101", n)
+	}
+	if n := spd.DisconnectBlock(block100, 100); n != 3 {
+		t.Fatalf("rolled back %d tickets of block 100", n)
+	}
+	if !reflect.DeepEqual(spd.LiveTicketsMSA, wantLive) {
+		t.Fatalf("got live tickets %v, want %v", spd.LiveTicketsMSA, wantLive)
+	}
+	if !reflect.DeepEqual(spd.IgnoredLowFeeTicketsMSA, wantIgnored) {
+		t.Fatalf("got ignored tickets %v, want %v",
+			spd.IgnoredLowFeeTicketsMSA, wantIgnored)
+	}
+
+	// Notifications of the orphaned blocks which are processed late are
+	// discarded.
+	spd.Lock()
+	_, _, orphaned = spd.connectNewTickets(block100, 100,
+		map[chainhash.Hash]string{{6}: "f"}, nil, nil)
+	connected := spd.connectSpentMissedTickets(block101, 101,
+		[]*chainhash.Hash{{3}})
+	spd.Unlock()
+	if !orphaned || connected {
+		t.Fatalf("got orphaned %v connected %v for disconnected blocks",
+			orphaned, connected)
+	}
+	if !reflect.DeepEqual(spd.LiveTicketsMSA, wantLive) {
+		t.Fatalf("got live tickets %v, want %v", spd.LiveTicketsMSA, wantLive)
+	}
+
+	// Blocks without recorded changes are only marked as orphaned.
+	if n := spd.DisconnectBlock(&chainhash.Hash{99}, 99); n != 0 {
+		t.Fatalf("rolled back %d tickets of an unknown block", n)
+	}
+
+	// Journals of blocks too deep to be reorganized are dropped.
+	spd.Lock()
+	spd.connectNewTickets(&chainhash.Hash{200}, 200, nil, nil, nil)
+	spd.Unlock()
+	if len(spd.ticketJournal) != 1 || len(spd.orphanedBlocks) != 0 {
+		t.Fatalf("kept %d journals and %d orphaned blocks",
+			len(spd.ticketJournal), len(spd.orphanedBlocks))
+	}
+}
+
+func TestReconnectBlock(t *testing.T) {
+	spd := &Stakepoold{
+		IgnoredLowFeeTicketsMSA: make(map[chainhash.Hash]string),
+		LiveTicketsMSA:          make(map[chainhash.Hash]string),
+	}
+
+	// Block 100 is disconnected by a reorganization which is then
+	// reversed, connecting it again.
+	block100 := &chainhash.Hash{100}
+	spd.DisconnectBlock(block100, 100)
+	spd.RLock()
+	orphaned := spd.isOrphaned(block100)
+	spd.RUnlock()
+	if !orphaned {
+		t.Fatal("disconnected block is not orphaned")
+	}
+	spd.ConnectBlock(block100, 100)
+
+	// The notifications of the reconnected block are processed.
+	spd.Lock()
+	_, _, orphaned = spd.connectNewTickets(block100, 100,
+		map[chainhash.Hash]string{{1}: "a"}, nil, nil)
+	connected := spd.connectSpentMissedTickets(block100, 100,
+		[]*chainhash.Hash{{1}})
+	spd.Unlock()
+	if orphaned || !connected {
+		t.Fatalf("got orphaned %v connected %v for the reconnected block",
+			orphaned, connected)
+	}
+	if len(spd.orphanedBlocks) != 0 || len(spd.LiveTicketsMSA) != 0 {
+		t.Fatalf("got orphaned blocks %v live tickets %v",
+			spd.orphanedBlocks, spd.LiveTicketsMSA)
+	}
+
+	// Connecting a block which was never disconnected changes nothing.
+	spd.ConnectBlock(&chainhash.Hash{101}, 101)
+	if len(spd.orphanedBlocks) != 0 {
+		t.Fatalf("got orphaned blocks %v", spd.orphanedBlocks)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RescanStatus describes the wallet rescan in progress, if any.  dcrwallet
+// does not report how far a rescan has come over JSON-RPC, so only where it
+// started is known.
+type RescanStatus struct {
+	Rescanning bool
+	FromHeight int64
+	Started    time.Time
+}
+
+// rescanState tracks the wallet rescan started by stakepoold.  Rescans
+// requested while one is running are merged into a single rescan from the
+// lowest requested height, which starts once the running one is done.
+type rescanState struct {
+	mtx        sync.Mutex
+	status     RescanStatus
+	queued     bool
+	queuedFrom int64
+}
+
+// start records a rescan from height and returns whether the caller must run
+// it, or false when it was queued behind the running rescan.
+func (r *rescanState) start(height int64, now time.Time) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.status.Rescanning {
+		if !r.queued || height < r.queuedFrom {
+			r.queuedFrom = height
+		}
+		r.queued = true
+		return false
+	}
+	r.status = RescanStatus{Rescanning: true, FromHeight: height, Started: now}
+	return true
+}
+
+// finish records the end of the running rescan.  It returns the height of the
+// queued rescan, which is then running, and whether there was one.
+func (r *rescanState) finish(now time.Time) (int64, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if !r.queued {
+		r.status = RescanStatus{}
+		return 0, false
+	}
+	r.queued = false
+	r.status = RescanStatus{Rescanning: true, FromHeight: r.queuedFrom, Started: now}
+	return r.queuedFrom, true
+}
+
+// RescanStatus returns the state of the wallet rescan.
+func (spd *Stakepoold) RescanStatus() RescanStatus {
+	spd.rescan.mtx.Lock()
+	defer spd.rescan.mtx.Unlock()
+	return spd.rescan.status
+}
+
+// rescanWallet rescans the wallet from height in the background.  The wallet
+// is slow to answer during rescans, so the callers of ImportMissingScripts
+// are not kept waiting for it.
+func (spd *Stakepoold) rescanWallet(height int64) {
+	if !spd.rescan.start(height, time.Now()) {
+		log.Infof("Wallet rescan from height %d queued behind the "+
+			"running rescan", height)
+		return
+	}
+
+	go func() {
+		for {
+			log.Infof("Wallet rescan from height %d started", height)
+			start := time.Now()
+			// The rescanwallet RPC returns once the rescan is done.
+			err := spd.WalletConnection.RPCClient().Call(context.Background(),
+				"rescanwallet", nil, height)
+			if err != nil {
+				log.Errorf("Wallet rescan from height %d failed: %v",
+					height, err)
+			} else {
+				log.Infof("Wallet rescan from height %d finished after %v",
+					height, time.Since(start).Round(time.Second))
+			}
+
+			var more bool
+			height, more = spd.rescan.finish(time.Now())
+			if !more {
+				return
+			}
+		}
+	}()
+}
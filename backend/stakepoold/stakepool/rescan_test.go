@@ -0,0 +1,45 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRescanState(t *testing.T) {
+	var r rescanState
+	now := time.Unix(1600000000, 0)
+
+	if !r.start(500, now) {
+		t.Fatal("first rescan was not started")
+	}
+	// Rescans requested meanwhile are merged into one from the lowest
+	// height.
+	if r.start(400, now) || r.start(450, now) {
+		t.Fatal("rescan started during a running rescan")
+	}
+	if r.status != (RescanStatus{true, 500, now}) {
+		t.Fatalf("got status %+v during the first rescan", r.status)
+	}
+
+	later := now.Add(time.Hour)
+	height, more := r.finish(later)
+	if !more || height != 400 {
+		t.Fatalf("got queued rescan %d, %v, want 400", height, more)
+	}
+	if r.status != (RescanStatus{true, 400, later}) {
+		t.Fatalf("got status %+v during the queued rescan", r.status)
+	}
+	if _, more := r.finish(later); more {
+		t.Fatal("rescan queued twice")
+	}
+	if r.status.Rescanning {
+		t.Fatal("still rescanning after the last rescan")
+	}
+	if !r.start(600, later) {
+		t.Fatal("rescan not started after the last rescan")
+	}
+}
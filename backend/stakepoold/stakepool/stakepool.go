@@ -25,11 +25,14 @@ import (
 	"github.com/decred/dcrd/rpcclient/v6"
 	"github.com/decred/dcrd/wire"
 	"github.com/decred/dcrstakepool/backend/stakepoold/userdata"
+	"github.com/decred/dcrstakepool/helpers"
+	"github.com/decred/dcrstakepool/internal/notify"
 )
 
 var (
 	errSuccess            = errors.New("success")
 	errNoTxInfo           = "-5: no information for transaction"
+	errNoTxInfoCode       = "-5: "
 	errDuplicateVote      = "-32603: already have transaction "
 	ticketTypeNew         = "New"
 	ticketTypeSpentMissed = "SpentMissed"
@@ -44,20 +47,61 @@ type Stakepoold struct {
 	IgnoredLowFeeTicketsMSA map[chainhash.Hash]string            // [ticket]multisigaddr
 	LiveTicketsMSA          map[chainhash.Hash]string            // [ticket]multisigaddr
 	UserVotingConfig        map[string]userdata.UserVotingConfig // [multisigaddr]
+	LowFeeReviewMSA         map[chainhash.Hash]string            // [ticket]multisigaddr
+	LowFeePaused            bool
+
+	// userDataFreshness describes where UserVotingConfig came from and when.
+	userDataFreshness userDataFreshness
+
+	// Standby is set while the instance is a warm standby, and
+	// primaryMisses counts the consecutive winning tickets whose votes
+	// were not mined while in standby.
+	Standby       bool
+	primaryMisses int
+
+	// ticketJournal and orphanedBlocks record the ticket changes of recent
+	// blocks and the blocks disconnected by chain reorganizations.
+	ticketJournal  map[chainhash.Hash]*blockTicketChanges
+	orphanedBlocks map[chainhash.Hash]int64 // [block]height
+
+	// The ticket handler queue metrics are accessed atomically.
+	newTicketsQueue  queueMetrics
+	spentMissedQueue queueMetrics
+	winningQueue     queueMetrics
+
+	// pendingAudits is protected by auditMtx.
+	auditMtx      sync.Mutex
+	pendingAudits map[int64][]voteAudit // [winning block height]
+
+	// rescan tracks the background wallet rescan.
+	rescan rescanState
 
 	// no locking required
+	Alerter                *notify.Alerter
 	DataPath               string
 	ColdWalletExtPub       string
+	DeferredFees           bool
 	FeeAddrs               map[string]struct{}
+	ForcedChoices          map[string]string
+	InvalidVoteBits        uint32 // users reset to default votebits at startup
+	MaxLowFeePerBlock      int
+	MaxUserLiveTickets     int
 	PoolFees               float64
 	NewTicketsChan         chan NewTicketsForBlock
 	NodeConnection         *rpcclient.Client
 	Params                 *chaincfg.Params
+	PauseOnLowFeeSurge     bool
 	SpentmissedTicketsChan chan SpentMissedTicketsForBlock
+	StandbyFailoverMisses  int
+	TicketPolicies         []TicketPolicy
 	UserData               *userdata.UserData
+	UserDataStaleAlert     time.Duration // alert when voting with older user data
+	VoteErrorAlert         int
+	VoteSigner             VoteSigner // signs votes instead of the wallet when set
 	VotingConfig           *VotingConfig
 	WalletConnection       *Client
 	WinningTicketsChan     chan WinningTicketsForBlock
+	WinningWatch           *WinningWatchdog
 	Testing                bool // enabled only for testing
 }
 
@@ -105,13 +149,20 @@ type ticketMetadata struct {
 	ticketType   string                    // new or spentmissed
 	signDuration time.Duration             // time to generatevote
 	sendDuration time.Duration             // time to sendrawtransaction
+	signed       bool                      // vote signed before err
 	err          error                     // log errors along the way
 }
 
 // EvaluateStakePoolTicket evaluates a voting service ticket to see if it's
 // acceptable to the voting service. The ticket must pay out to the voting
-// service cold wallet, and must have a sufficient fee.
-func (spd *Stakepoold) EvaluateStakePoolTicket(tx *wire.MsgTx, blockHeight int32) (bool, error) {
+// service cold wallet, must have a sufficient fee, and must pass the checks of
+// all configured ticket policies.  With deferred fees the fee is paid by a
+// separate transaction and only the ticket policies are checked.
+func (spd *Stakepoold) EvaluateStakePoolTicket(tx *wire.MsgTx, msa string, blockHeight int32) (bool, error) {
+	if spd.DeferredFees {
+		return spd.checkTicketPolicies(tx, msa, blockHeight)
+	}
+
 	// Check the first commitment output (txOuts[1])
 	// and ensure that the address found there exists
 	// in the list of approved addresses. Also ensure
@@ -175,6 +226,29 @@ func (spd *Stakepoold) EvaluateStakePoolTicket(tx *wire.MsgTx, blockHeight int32
 		return false, nil
 	}
 
+	return spd.checkTicketPolicies(tx, msa, blockHeight)
+}
+
+// checkTicketPolicies reports whether tx passes the checks of all configured
+// ticket policies.
+func (spd *Stakepoold) checkTicketPolicies(tx *wire.MsgTx, msa string, blockHeight int32) (bool, error) {
+	policyTicket := &PolicyTicket{
+		Tx:              tx,
+		MultiSigAddress: msa,
+		BlockHeight:     blockHeight,
+	}
+	for _, policy := range spd.TicketPolicies {
+		reason, err := policy.CheckTicket(policyTicket)
+		if err != nil {
+			return false, err
+		}
+		if reason != "" {
+			log.Warnf("Ticket %v of multisig %v rejected by ticket "+
+				"policy: %s", tx.TxHash(), msa, reason)
+			return false, nil
+		}
+	}
+
 	log.Debugf("Accepted valid voting service ticket %v committing %v in fees",
 		tx.TxHash(), tx.TxOut[0].Value)
 
@@ -305,13 +379,11 @@ func (spd *Stakepoold) ImportNewScript(ctx context.Context, script []byte) (int6
 }
 
 // ImportMissingScripts accepts a list of redeem scripts and a rescan height. It
-// will import all but one of the scripts without triggering a wallet rescan,
-// and finally trigger a rescan from the provided height after importing the
-// last one.
+// will import the scripts without triggering a wallet rescan, and then start
+// a rescan from the provided height in the background.  The progress of the
+// rescan is reported by RescanStatus.
 func (spd *Stakepoold) ImportMissingScripts(ctx context.Context, scripts [][]byte, rescanHeight int) error {
-	// Import n-1 scripts without a rescan.
-	allButOne := scripts[:len(scripts)-1]
-	for _, script := range allButOne {
+	for _, script := range scripts {
 		err := spd.WalletConnection.RPCClient().ImportScriptRescanFrom(ctx, script, false, 0)
 		if err != nil {
 			log.Errorf("ImportMissingScripts: ImportScript rpc failed: %v", err)
@@ -319,14 +391,7 @@ func (spd *Stakepoold) ImportMissingScripts(ctx context.Context, scripts [][]byt
 		}
 	}
 
-	// Import the last script and trigger a rescan
-	lastOne := scripts[len(scripts)-1]
-	err := spd.WalletConnection.RPCClient().ImportScriptRescanFrom(ctx, lastOne, true, rescanHeight)
-	if err != nil {
-		log.Errorf("ImportMissingScripts: ImportScriptRescanFrom rpc failed: %v", err)
-		return err
-	}
-
+	spd.rescanWallet(int64(rescanHeight))
 	log.Infof("ImportMissingScripts: Imported %d scripts and triggered a rescan from height %d", len(scripts), rescanHeight)
 
 	return nil
@@ -410,6 +475,18 @@ func (spd *Stakepoold) AccountSyncAddressIndex(ctx context.Context, account stri
 	return nil
 }
 
+// GetAccountExtPub performs the getmasterpubkey command on dcrwallet and
+// returns the extended public key of account.
+func (spd *Stakepoold) GetAccountExtPub(ctx context.Context, account string) (string, error) {
+	key, err := spd.WalletConnection.RPCClient().GetMasterPubkey(ctx, account)
+	if err != nil {
+		log.Errorf("GetAccountExtPub: GetMasterPubkey rpc failed: %v", err)
+		return "", err
+	}
+
+	return key.String(), nil
+}
+
 // GetTickets performs the gettickets command on dcrwallet and returns the result.
 func (spd *Stakepoold) GetTickets(ctx context.Context, includeImmature bool) ([]*chainhash.Hash, error) {
 	tickets, err := spd.WalletConnection.RPCClient().GetTickets(ctx, includeImmature)
@@ -440,10 +517,50 @@ func (spd *Stakepoold) StakePoolUserInfo(ctx context.Context, multisigAddress st
 	return &response, nil
 }
 
+// maxConcurrentUserInfo is the maximum number of stakepooluserinfo commands
+// BatchStakePoolUserInfo has dcrwallet perform at once.
+const maxConcurrentUserInfo = 8
+
+// BatchStakePoolUserInfo performs the rpc command stakepooluserinfo on
+// dcrwallet for each of the multisig addresses and returns the results and
+// errors in the same order.  Only one of the result and error of an address is
+// set.
+func (spd *Stakepoold) BatchStakePoolUserInfo(ctx context.Context, multisigAddresses []string) ([]*wallettypes.StakePoolUserInfoResult, []error) {
+	results := make([]*wallettypes.StakePoolUserInfoResult, len(multisigAddresses))
+	errs := make([]error, len(multisigAddresses))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentUserInfo)
+	for i := range multisigAddresses {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i], errs[i] = spd.StakePoolUserInfo(ctx, multisigAddresses[i])
+		}(i)
+	}
+	wg.Wait()
+
+	return results, errs
+}
+
+// WalletInfoResult is the result of the rpc command walletinfo, along with the
+// ticket fee and ticket purchasing fields which older dcrwallet versions
+// report and which are left unset otherwise.
+type WalletInfoResult struct {
+	wallettypes.WalletInfoResult
+	TicketFee        float64 `json:"ticketfee"`
+	TicketPurchasing bool    `json:"ticketpurchasing"`
+}
+
 // WalletInfo performs the rpc command walletinfo on dcrwallet and returns the
 // result.
-func (spd *Stakepoold) WalletInfo(ctx context.Context) (*wallettypes.WalletInfoResult, error) {
-	response, err := spd.WalletConnection.RPCClient().WalletInfo(ctx)
+func (spd *Stakepoold) WalletInfo(ctx context.Context) (*WalletInfoResult, error) {
+	response := new(WalletInfoResult)
+	err := spd.WalletConnection.RPCClient().Call(ctx, "walletinfo", response)
 	if err != nil {
 		log.Errorf("WalletInfo: WalletInfo rpc failed: %v", err)
 		return nil, err
@@ -452,6 +569,17 @@ func (spd *Stakepoold) WalletInfo(ctx context.Context) (*wallettypes.WalletInfoR
 	return response, nil
 }
 
+// WalletTimeOffset performs the rpc command getinfo on dcrwallet and returns
+// the offset in seconds of the time of its dcrd from the time of the network,
+// as measured by dcrd from its peers.
+func (spd *Stakepoold) WalletTimeOffset(ctx context.Context) (int64, error) {
+	response, err := spd.WalletConnection.RPCClient().GetInfo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return response.TimeOffset, nil
+}
+
 // ValidateAddress performs the validateaddress command on dcrwallet and returns
 // the result.
 func (spd *Stakepoold) ValidateAddress(ctx context.Context, address string) (*wallettypes.ValidateAddressWalletResult, error) {
@@ -470,6 +598,102 @@ func (spd *Stakepoold) ValidateAddress(ctx context.Context, address string) (*wa
 	return response, nil
 }
 
+// ExistsAddress reports whether address has been used on the blockchain
+// according to dcrd's exists address index.  Pubkey addresses are checked
+// using their pubkey hash form, which is how they appear in standard scripts.
+func (spd *Stakepoold) ExistsAddress(ctx context.Context, address string) (bool, error) {
+	addr, err := dcrutil.DecodeAddress(address, spd.Params)
+	if err != nil {
+		log.Errorf("ExistsAddress: Address could not be decoded %v: %v", address, err)
+		return false, err
+	}
+	if pk, ok := addr.(*dcrutil.AddressSecpPubKey); ok {
+		addr = pk.AddressPubKeyHash()
+	}
+
+	exists, err := spd.NodeConnection.ExistsAddress(ctx, addr)
+	if err != nil {
+		log.Errorf("ExistsAddress: ExistsAddress rpc failed: %v", err)
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// VerifyMessage performs the verifymessage command on dcrwallet and reports
+// whether signature is a valid signature of message by the key of address.
+// Pubkey addresses are verified using their pubkey hash form, which is the
+// form wallets sign messages with.
+func (spd *Stakepoold) VerifyMessage(ctx context.Context, address, message, signature string) (bool, error) {
+	addr, err := dcrutil.DecodeAddress(address, spd.Params)
+	if err != nil {
+		log.Errorf("VerifyMessage: Address could not be decoded %v: %v", address, err)
+		return false, err
+	}
+	if pk, ok := addr.(*dcrutil.AddressSecpPubKey); ok {
+		addr = pk.AddressPubKeyHash()
+	}
+
+	valid, err := spd.WalletConnection.RPCClient().VerifyMessage(ctx, addr, signature, message)
+	if err != nil {
+		log.Errorf("VerifyMessage: VerifyMessage rpc failed: %v", err)
+		return false, err
+	}
+
+	return valid, nil
+}
+
+// GetBestBlock returns the hash and height of the best block of dcrd.
+func (spd *Stakepoold) GetBestBlock(ctx context.Context) (*chainhash.Hash, int64, error) {
+	hash, height, err := spd.NodeConnection.GetBestBlock(ctx)
+	if err != nil {
+		log.Errorf("GetBestBlock: GetBestBlock rpc failed: %v", err)
+		return nil, 0, err
+	}
+
+	return hash, height, nil
+}
+
+// ErrTxNotFound is returned by GetTransactionConfirmations when dcrd does not
+// know the transaction.
+var ErrTxNotFound = errors.New("transaction not found")
+
+// SendRawTransaction broadcasts the serialized transaction txBytes through
+// dcrd and returns its hash.
+func (spd *Stakepoold) SendRawTransaction(ctx context.Context, txBytes []byte) (*chainhash.Hash, error) {
+	var tx wire.MsgTx
+	if err := tx.FromBytes(txBytes); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %v", err)
+	}
+	hash, err := spd.NodeConnection.SendRawTransaction(ctx, &tx, false)
+	if err != nil {
+		log.Errorf("SendRawTransaction: SendRawTransaction rpc failed for %v: %v",
+			tx.TxHash(), err)
+		return nil, err
+	}
+
+	return hash, nil
+}
+
+// GetTransactionConfirmations returns the number of confirmations of the
+// transaction with the given hash, which is 0 while it is in the mempool.
+// dcrd must be run with txindex for mined transactions not paying the voting
+// wallet to be found.
+func (spd *Stakepoold) GetTransactionConfirmations(ctx context.Context, hash *chainhash.Hash) (int64, error) {
+	res, err := spd.NodeConnection.GetRawTransactionVerbose(ctx, hash)
+	if err != nil {
+		// dcrd words the error differently than dcrwallet.
+		if strings.HasPrefix(err.Error(), errNoTxInfoCode) {
+			return 0, ErrTxNotFound
+		}
+		log.Errorf("GetTransactionConfirmations: GetRawTransactionVerbose "+
+			"rpc failed for %v: %v", hash, err)
+		return 0, err
+	}
+
+	return res.Confirmations, nil
+}
+
 // GetStakeInfo performs the rpc command GetStakeInfo.
 func (spd *Stakepoold) GetStakeInfo(ctx context.Context) (*wallettypes.GetStakeInfoResult, error) {
 	response, err := spd.WalletConnection.RPCClient().GetStakeInfo(ctx)
@@ -481,10 +705,12 @@ func (spd *Stakepoold) GetStakeInfo(ctx context.Context) (*wallettypes.GetStakeI
 	return response, nil
 }
 
-// UpdateUserData replaces the user voting config in memory with newUserVotingConfig.
-func (spd *Stakepoold) UpdateUserData(newUserVotingConfig map[string]userdata.UserVotingConfig) {
+// UpdateUserData replaces the user voting config in memory with
+// newUserVotingConfig, which was just obtained from source.
+func (spd *Stakepoold) UpdateUserData(newUserVotingConfig map[string]userdata.UserVotingConfig, source string) {
 	spd.Lock()
 	spd.UserVotingConfig = newUserVotingConfig
+	spd.userDataFreshness = userDataFreshness{Updated: time.Now(), Source: source}
 	spd.Unlock()
 }
 
@@ -496,12 +722,22 @@ func (spd *Stakepoold) UpdateUserDataFromMySQL() error {
 	log.Infof("MySQLFetchUserVotingConfig took %v",
 		time.Since(start))
 	if err != nil {
+		spd.userDataRefreshFailed()
 		return err
 	}
-	spd.UpdateUserData(newUserVotingConfig)
+	spd.UpdateUserData(newUserVotingConfig, UserDataFromMySQL)
 	return nil
 }
 
+// voteSigner returns the external vote signer, or dcrwallet when there is
+// none.
+func (spd *Stakepoold) voteSigner() VoteSigner {
+	if spd.VoteSigner != nil {
+		return spd.VoteSigner
+	}
+	return walletVoteSigner{wallet: spd.WalletConnection}
+}
+
 // vote Generates a vote and send it off to the network.  This is a go routine!
 func (spd *Stakepoold) vote(ctx context.Context, wg *sync.WaitGroup, blockHash *chainhash.Hash, blockHeight int64, w *ticketMetadata) {
 	start := time.Now()
@@ -511,26 +747,15 @@ func (spd *Stakepoold) vote(ctx context.Context, wg *sync.WaitGroup, blockHash *
 		wg.Done()
 	}()
 
-	// Ask wallet to generate vote result.
-	var res *wallettypes.GenerateVoteResult
-	res, w.err = spd.WalletConnection.RPCClient().GenerateVote(ctx, blockHash, blockHeight,
+	// Ask the wallet or the external signer to sign the vote.
+	var newTx *wire.MsgTx
+	newTx, w.err = spd.voteSigner().SignVote(ctx, blockHash, blockHeight,
 		w.ticket, w.config.VoteBits, spd.VotingConfig.VoteBitsExtended)
-	if w.err != nil || res.Hex == "" {
-		return
-	}
-	w.signDuration = time.Since(start)
-
-	// Create raw transaction.
-	var buf []byte
-	buf, w.err = hex.DecodeString(res.Hex)
-	if w.err != nil {
-		return
-	}
-	newTx := wire.NewMsgTx()
-	w.err = newTx.FromBytes(buf)
 	if w.err != nil {
 		return
 	}
+	w.signed = true
+	w.signDuration = time.Since(start)
 
 	// Ask node to transmit raw transaction.
 	startSend := time.Now()
@@ -590,7 +815,7 @@ func (spd *Stakepoold) processNewTickets(ctx context.Context, nt NewTicketsForBl
 			continue
 		}
 
-		ticketFeesValid, err := spd.EvaluateStakePoolTicket(msgTx, int32(nt.BlockHeight))
+		ticketFeesValid, err := spd.EvaluateStakePoolTicket(msgTx, n.msa, int32(nt.BlockHeight))
 
 		if err != nil {
 			log.Warnf("ignoring ticket %v for multisig %v due to error: %v", n.ticket, n.msa, err)
@@ -598,28 +823,61 @@ func (spd *Stakepoold) processNewTickets(ctx context.Context, nt NewTicketsForBl
 		} else if ticketFeesValid {
 			newLiveTickets[*n.ticket] = n.msa
 		} else {
-			log.Warnf("ignoring ticket %v for multisig %v due to invalid fee", n.ticket, n.msa)
+			log.Warnf("ignoring ticket %v for multisig %v due to invalid fee or ticket policy", n.ticket, n.msa)
 			newIgnoredLowFeeTickets[*n.ticket] = n.msa
 		}
 	}
 
 	spd.Lock()
-	// update ignored low fee tickets
-	for ticket, msa := range newIgnoredLowFeeTickets {
-		spd.IgnoredLowFeeTicketsMSA[ticket] = msa
+	// Tickets of users who already have the maximum number of live tickets
+	// are ignored like low fee tickets.
+	overLimitTickets := spd.limitNewUserTickets(newLiveTickets)
+
+	// update live and ignored low fee tickets
+	surge, held, orphaned := spd.connectNewTickets(nt.BlockHash,
+		nt.BlockHeight, newLiveTickets, newIgnoredLowFeeTickets,
+		overLimitTickets)
+	if orphaned {
+		spd.Unlock()
+		log.Infof("processNewTickets: block %v (height %d) was disconnected, "+
+			"discarding %d new tickets", nt.BlockHash, nt.BlockHeight,
+			len(newLiveTickets)+len(newIgnoredLowFeeTickets)+
+				len(overLimitTickets))
+		return
 	}
 
-	// update live tickets
-	for ticket, msa := range newLiveTickets {
-		spd.LiveTicketsMSA[ticket] = msa
+	// Find the owners of the new low fee tickets to tell them that their
+	// tickets will not be voted.  Held tickets most likely result from a
+	// misconfiguration of the voting service, so their owners are only told
+	// if an admin later ignores them.
+	var lowFeeOwners map[int64][]chainhash.Hash
+	if !held {
+		lowFeeOwners = spd.ticketOwners(newIgnoredLowFeeTickets)
 	}
+	overLimitOwners := spd.ticketOwners(overLimitTickets)
 
 	// update counts
 	addedLowFeeTicketsCount := len(spd.AddedLowFeeTicketsMSA)
 	ignoredLowFeeTicketsCount := len(spd.IgnoredLowFeeTicketsMSA)
 	liveTicketsCount := len(spd.LiveTicketsMSA)
+	heldTicketsCount := len(spd.LowFeeReviewMSA)
 	spd.Unlock()
 
+	if surge {
+		msg := fmt.Sprintf("%d tickets in block %v (height %d) failed the "+
+			"fee or ticket policy checks, more than the limit of %d -- "+
+			"check the coldwalletextpub, poolfees and ticketpolicy settings",
+			len(newIgnoredLowFeeTickets), nt.BlockHash, nt.BlockHeight,
+			spd.MaxLowFeePerBlock)
+		log.Critical("processNewTickets: " + msg)
+		spd.Alerter.Alert(notify.KindLowFeeFlood, msg)
+	}
+	if held {
+		log.Criticalf("processNewTickets: automatic classification of low "+
+			"fee tickets is paused, %d tickets are held until an admin "+
+			"resumes it", heldTicketsCount)
+	}
+
 	// Log ticket information outside of the handler.
 	go func() {
 		for ticket, msa := range newLiveTickets {
@@ -627,18 +885,33 @@ func (spd *Stakepoold) processNewTickets(ctx context.Context, nt NewTicketsForBl
 		}
 
 		for ticket, msa := range newIgnoredLowFeeTickets {
+			if held {
+				log.Infof("processNewTickets: held new low fee ticket %v multisig %v", ticket, msa)
+				continue
+			}
 			log.Infof("processNewTickets: added new ignored ticket %v multisig %v", ticket, msa)
 		}
 
+		for ticket, msa := range overLimitTickets {
+			log.Infof("processNewTickets: added new ignored ticket %v multisig %v "+
+				"over the limit of %d live tickets per user", ticket, msa,
+				spd.MaxUserLiveTickets)
+		}
+
 		log.Infof("processNewTickets: height %v block %v duration %v "+
 			"ignored %v live %v notours %v", nt.BlockHeight,
-			nt.BlockHash, time.Since(start), len(newIgnoredLowFeeTickets),
+			nt.BlockHash, time.Since(start),
+			len(newIgnoredLowFeeTickets)+len(overLimitTickets),
 			len(newLiveTickets),
-			len(nt.NewTickets)-len(newIgnoredLowFeeTickets)-len(newLiveTickets))
+			len(nt.NewTickets)-len(newIgnoredLowFeeTickets)-
+				len(overLimitTickets)-len(newLiveTickets))
 		log.Infof("processNewTickets: tickets loaded -- addedLowFee %v ignoredLowFee %v live %v "+
 			"total %v", addedLowFeeTicketsCount, ignoredLowFeeTicketsCount,
 			liveTicketsCount,
 			addedLowFeeTicketsCount+ignoredLowFeeTicketsCount+liveTicketsCount)
+
+		spd.notifyLowFeeTickets(lowFeeOwners)
+		spd.notifyOverLimitTickets(overLimitOwners)
 	}()
 }
 
@@ -694,17 +967,21 @@ func (spd *Stakepoold) processSpentMissedTickets(ctx context.Context, smt SpentM
 
 	spd.Lock()
 	ticketCountOld = len(spd.LiveTicketsMSA)
-	for _, ticket := range missedtickets {
-		delete(spd.IgnoredLowFeeTicketsMSA, *ticket)
-		delete(spd.LiveTicketsMSA, *ticket)
-	}
-	for _, ticket := range spenttickets {
-		delete(spd.IgnoredLowFeeTicketsMSA, *ticket)
-		delete(spd.LiveTicketsMSA, *ticket)
-	}
+	connected := spd.connectSpentMissedTickets(smt.BlockHash, smt.BlockHeight,
+		append(missedtickets, spenttickets...))
 	ticketCountNew = len(spd.LiveTicketsMSA)
 	spd.Unlock()
 
+	if !connected {
+		log.Infof("processSpentMissedTickets: block %v (height %d) was "+
+			"disconnected, discarding %d spent and %d missed tickets",
+			smt.BlockHash, smt.BlockHeight, len(spenttickets),
+			len(missedtickets))
+		return
+	}
+
+	missedTicketsTotal.Add(float64(len(missedtickets)))
+
 	// Log ticket information outside of the handler.
 	go func() {
 		for _, ticket := range missedtickets {
@@ -736,7 +1013,21 @@ func (spd *Stakepoold) ProcessWinningTickets(ctx context.Context, wt WinningTick
 
 	var wg sync.WaitGroup // wait group for go routine exits
 
+	// Look up the fee payments before taking the lock so that the database
+	// query does not hold up the other ticket handlers.
+	feePaid := spd.feePaidTickets(ctx, wt.WinningTickets)
+
 	spd.RLock()
+	if spd.isOrphaned(wt.BlockHash) {
+		spd.RUnlock()
+		log.Infof("ProcessWinningTickets: block %v (height %d) was "+
+			"disconnected, not voting on it", wt.BlockHash, wt.BlockHeight)
+		return
+	}
+	standby := spd.Standby
+	userData := spd.userDataFreshness
+	var untracked []*chainhash.Hash
+	var managed int
 	for _, ticket := range wt.WinningTickets {
 		// Look up multi sig address.
 		msa, ok := spd.LiveTicketsMSA[*ticket]
@@ -745,6 +1036,18 @@ func (spd *Stakepoold) ProcessWinningTickets(ctx context.Context, wt WinningTick
 			if spd.Testing {
 				panic("boom")
 			}
+			// The audit tells whether the ticket belongs to a user
+			// when its vote is not mined.
+			if _, ignored := spd.IgnoredLowFeeTicketsMSA[*ticket]; !ignored && !standby {
+				untracked = append(untracked, ticket)
+			}
+			continue
+		}
+		managed++
+
+		if _, ok := feePaid[*ticket]; feePaid != nil && !ok {
+			log.Infof("ProcessWinningTickets: fee of ticket %v of "+
+				"multisig %v not paid, not voting", ticket, msa)
 			continue
 		}
 
@@ -773,6 +1076,9 @@ func (spd *Stakepoold) ProcessWinningTickets(ctx context.Context, wt WinningTick
 				spd.VotingConfig.VoteVersion,
 				voteCfg.VoteBits)
 		}
+		voteCfg.VoteBits = helpers.ForceChoices(spd.Params,
+			spd.VotingConfig.VoteVersion, voteCfg.VoteBits,
+			spd.ForcedChoices)
 
 		w := &ticketMetadata{
 			msa:    msa,
@@ -781,8 +1087,8 @@ func (spd *Stakepoold) ProcessWinningTickets(ctx context.Context, wt WinningTick
 		}
 		winners = append(winners, w)
 
-		// When testing we don't send the tickets.
-		if spd.Testing {
+		// When testing or in standby we don't send the tickets.
+		if spd.Testing || standby {
 			continue
 		}
 
@@ -793,17 +1099,41 @@ func (spd *Stakepoold) ProcessWinningTickets(ctx context.Context, wt WinningTick
 			spd.VotingConfig.VoteBitsExtended)
 		go spd.vote(ctx, &wg, wt.BlockHash, wt.BlockHeight, w)
 	}
+	live := len(spd.LiveTicketsMSA)
 	spd.RUnlock()
 
+	spd.WinningWatch.managedWinners(wt.BlockHeight, managed, live)
+
 	wg.Wait()
 
-	// Revoke any expired tickets
-	go func() {
-		err := spd.WalletConnection.RPCClient().RevokeTickets(ctx)
-		if err != nil {
-			log.Errorf("Failed to revoke tickets: %v", err)
-		}
-	}()
+	// Verify that the votes are mined a few blocks from now.
+	if !spd.Testing {
+		spd.queueVoteAudits(wt, winners, untracked, standby)
+		go spd.auditVotes(ctx, wt.BlockHeight)
+	}
+
+	// A standby instance leaves voting and revoking to the active one.
+	if standby {
+		log.Infof("ProcessWinningTickets: height %v block %v standby, not "+
+			"voting %d managed winning tickets", wt.BlockHeight,
+			wt.BlockHash, len(winners))
+		return
+	}
+
+	if len(winners) > 0 {
+		spd.checkUserDataFreshness(userData, wt.BlockHeight)
+	}
+
+	// Revoke any expired tickets.  A wallet which only watches the tickets
+	// of an external signer cannot sign the revocations.
+	if spd.VoteSigner == nil {
+		go func() {
+			err := spd.WalletConnection.RPCClient().RevokeTickets(ctx)
+			if err != nil {
+				log.Errorf("Failed to revoke tickets: %v", err)
+			}
+		}()
+	}
 
 	// Log ticket information outside of the handler.
 	go func() {
@@ -829,15 +1159,26 @@ func (spd *Stakepoold) ProcessWinningTickets(ctx context.Context, wt WinningTick
 				"(%v + %v): %v", w.ticket, w.txid, w.config.VoteBits, w.msa,
 				w.duration, w.signDuration, w.sendDuration, w.err)
 		}
+		votesTotal.Add(float64(votedCount), "voted")
+		votesTotal.Add(float64(dupeCount), "duplicate")
+		votesTotal.Add(float64(errorCount), "error")
 		log.Infof("ProcessWinningTickets: height %v block %v "+
 			"duration %v newvotes %v duplicatevotes %v errors %v",
 			wt.BlockHeight, wt.BlockHash, time.Since(start), votedCount,
 			dupeCount, errorCount)
+		if spd.VoteErrorAlert > 0 && errorCount >= spd.VoteErrorAlert {
+			spd.Alerter.Alert(notify.KindVoteErrors, fmt.Sprintf("%d of "+
+				"%d votes on block %v (height %d) failed, check the "+
+				"stakepoold log", errorCount, len(winners), wt.BlockHash,
+				wt.BlockHeight))
+		}
 	}()
 }
 
 // NewTicketHandler is a go routine that waits for NewTicket notifications from
-// dcrd.
+// dcrd.  The ticket handlers process one block at a time, in the order the
+// blocks were notified, so that the changes of consecutive blocks do not
+// race.
 func (spd *Stakepoold) NewTicketHandler(ctx context.Context, wg *sync.WaitGroup) {
 	wg.Add(1)
 	defer wg.Done()
@@ -845,7 +1186,12 @@ func (spd *Stakepoold) NewTicketHandler(ctx context.Context, wg *sync.WaitGroup)
 	for {
 		select {
 		case nt := <-spd.NewTicketsChan:
-			go spd.processNewTickets(ctx, nt)
+			spd.newTicketsQueue.dequeued(newTicketsQueueName,
+				len(spd.NewTicketsChan), cap(spd.NewTicketsChan))
+			start := time.Now()
+			spd.processNewTickets(ctx, nt)
+			blockProcessingDuration.ObserveDuration(time.Since(start),
+				newTicketsQueueName)
 		case <-ctx.Done():
 			return
 		}
@@ -861,7 +1207,12 @@ func (spd *Stakepoold) SpentmissedTicketHandler(ctx context.Context, wg *sync.Wa
 	for {
 		select {
 		case smt := <-spd.SpentmissedTicketsChan:
-			go spd.processSpentMissedTickets(ctx, smt)
+			spd.spentMissedQueue.dequeued(spentMissedQueueName,
+				len(spd.SpentmissedTicketsChan), cap(spd.SpentmissedTicketsChan))
+			start := time.Now()
+			spd.processSpentMissedTickets(ctx, smt)
+			blockProcessingDuration.ObserveDuration(time.Since(start),
+				spentMissedQueueName)
 		case <-ctx.Done():
 			return
 		}
@@ -877,7 +1228,12 @@ func (spd *Stakepoold) WinningTicketHandler(ctx context.Context, wg *sync.WaitGr
 	for {
 		select {
 		case wt := <-spd.WinningTicketsChan:
-			go spd.ProcessWinningTickets(ctx, wt)
+			spd.winningQueue.dequeued(winningQueueName,
+				len(spd.WinningTicketsChan), cap(spd.WinningTicketsChan))
+			start := time.Now()
+			spd.ProcessWinningTickets(ctx, wt)
+			blockProcessingDuration.ObserveDuration(time.Since(start),
+				winningQueueName)
 		case <-ctx.Done():
 			return
 		}
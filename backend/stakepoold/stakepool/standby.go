@@ -0,0 +1,64 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"fmt"
+)
+
+// IsStandby returns whether the instance is a warm standby which does not
+// broadcast votes or revocations.
+func (spd *Stakepoold) IsStandby() bool {
+	spd.RLock()
+	defer spd.RUnlock()
+	return spd.Standby
+}
+
+// Promote makes a warm standby instance active, so that it votes on the
+// winning tickets of the next blocks.  It returns whether the instance was in
+// standby.
+func (spd *Stakepoold) Promote(reason string) bool {
+	spd.Lock()
+	wasStandby := spd.Standby
+	spd.Standby = false
+	spd.primaryMisses = 0
+	spd.Unlock()
+
+	if wasStandby {
+		log.Criticalf("Promoted from standby to active: %s", reason)
+	}
+	return wasStandby
+}
+
+// observeStandbyAudit records whether the vote for a winning ticket which was
+// not voted because the instance was in standby was mined by another voting
+// wallet.  When the votes of StandbyFailoverMisses consecutive winning tickets
+// were not mined, the active instance is assumed to be down and the instance
+// promotes itself.  The votes of those tickets can no longer be sent.
+func (spd *Stakepoold) observeStandbyAudit(a voteAudit, voted bool) {
+	spd.Lock()
+	if voted {
+		spd.primaryMisses = 0
+	} else {
+		spd.primaryMisses++
+	}
+	misses := spd.primaryMisses
+	failover := spd.Standby && spd.StandbyFailoverMisses > 0 &&
+		misses >= spd.StandbyFailoverMisses
+	spd.Unlock()
+
+	if voted {
+		return
+	}
+
+	log.Warnf("auditVotes: ticket %v (userid %d multisig %v) winning in "+
+		"block %d was not voted by the active instance, %d consecutive "+
+		"misses", a.ticket, a.userid, a.msa, a.blockHeight, misses)
+
+	if failover {
+		spd.Promote(fmt.Sprintf("the votes of %d consecutive winning "+
+			"tickets were not mined", misses))
+	}
+}
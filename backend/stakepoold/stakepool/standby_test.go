@@ -0,0 +1,47 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"testing"
+)
+
+func TestStandbyFailover(t *testing.T) {
+	spd := &Stakepoold{
+		Standby:               true,
+		StandbyFailoverMisses: 2,
+	}
+
+	// A mined vote resets the count of consecutive misses.
+	spd.observeStandbyAudit(voteAudit{standby: true}, false)
+	spd.observeStandbyAudit(voteAudit{standby: true}, true)
+	spd.observeStandbyAudit(voteAudit{standby: true}, false)
+	if !spd.IsStandby() {
+		t.Fatal("promoted without consecutive misses")
+	}
+
+	spd.observeStandbyAudit(voteAudit{standby: true}, false)
+	if spd.IsStandby() {
+		t.Fatalf("still in standby after %d consecutive misses",
+			spd.StandbyFailoverMisses)
+	}
+	if spd.Promote("test") {
+		t.Fatal("Promote reported an active instance as standby")
+	}
+}
+
+func TestStandbyNoFailover(t *testing.T) {
+	spd := &Stakepoold{Standby: true}
+
+	for i := 0; i < 10; i++ {
+		spd.observeStandbyAudit(voteAudit{standby: true}, false)
+	}
+	if !spd.IsStandby() {
+		t.Fatal("promoted with automatic failover disabled")
+	}
+	if !spd.Promote("test") || spd.IsStandby() {
+		t.Fatal("Promote did not make the standby instance active")
+	}
+}
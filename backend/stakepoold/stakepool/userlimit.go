@@ -0,0 +1,88 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// userLimitMessageKind is the kind of the in-app messages telling users that
+// their tickets exceed the limit of live tickets per user.  It matches the
+// kind used by the web frontend.
+const userLimitMessageKind = "userlimit"
+
+// UserTicketCounts returns the number of tickets of each multisig address in
+// ticketsMSA.
+func UserTicketCounts(ticketsMSA map[chainhash.Hash]string) map[string]int {
+	counts := make(map[string]int)
+	for _, msa := range ticketsMSA {
+		counts[msa]++
+	}
+	return counts
+}
+
+// OverUserTicketLimit returns the tickets of candidates which exceed the limit
+// of max live tickets per multisig address, given the counts of the live
+// tickets each address already has.  Candidates are admitted in order of their
+// height in heights, which may be nil when they are all of the same height,
+// and then of their hash, so that the earliest tickets of a user are voted.
+// counts is updated with the admitted tickets.
+func OverUserTicketLimit(candidates map[chainhash.Hash]string,
+	heights map[chainhash.Hash]int64, counts map[string]int, max int) map[chainhash.Hash]string {
+
+	tickets := make([]chainhash.Hash, 0, len(candidates))
+	for ticket := range candidates {
+		tickets = append(tickets, ticket)
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		hi, hj := heights[tickets[i]], heights[tickets[j]]
+		if hi != hj {
+			return hi < hj
+		}
+		return bytes.Compare(tickets[i][:], tickets[j][:]) < 0
+	})
+
+	over := make(map[chainhash.Hash]string)
+	for _, ticket := range tickets {
+		msa := candidates[ticket]
+		if counts[msa] >= max {
+			over[ticket] = msa
+			continue
+		}
+		counts[msa]++
+	}
+	return over
+}
+
+// limitNewUserTickets removes the tickets which exceed MaxUserLiveTickets from
+// the new live tickets and returns them.
+//
+// This function MUST be called with the stakepoold lock held (for reads).
+func (spd *Stakepoold) limitNewUserTickets(live map[chainhash.Hash]string) map[chainhash.Hash]string {
+	if spd.MaxUserLiveTickets <= 0 || len(live) == 0 {
+		return nil
+	}
+	over := OverUserTicketLimit(live, nil, UserTicketCounts(spd.LiveTicketsMSA),
+		spd.MaxUserLiveTickets)
+	for ticket := range over {
+		delete(live, ticket)
+	}
+	return over
+}
+
+// notifyOverLimitTickets delivers an in-app message to each user listing their
+// tickets which are ignored because the user already had MaxUserLiveTickets
+// live tickets.
+func (spd *Stakepoold) notifyOverLimitTickets(owners map[int64][]chainhash.Hash) {
+	spd.notifyTickets(owners, userLimitMessageKind,
+		"Tickets over the live ticket limit",
+		fmt.Sprintf("The following tickets exceed the limit of %d live "+
+			"tickets per user and will not be voted unless an admin adds "+
+			"them: %%s", spd.MaxUserLiveTickets))
+}
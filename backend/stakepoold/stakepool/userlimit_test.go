@@ -0,0 +1,52 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+func TestOverUserTicketLimit(t *testing.T) {
+	counts := UserTicketCounts(map[chainhash.Hash]string{
+		{1}: "a", {2}: "a", {3}: "b",
+	})
+	candidates := map[chainhash.Hash]string{
+		{4}: "a", {5}: "b", {6}: "b", {7}: "b", {8}: "c",
+	}
+	// The later ticket of b is over the limit even though its hash sorts
+	// first.
+	heights := map[chainhash.Hash]int64{{5}: 101, {6}: 100, {7}: 100, {8}: 100}
+	over := OverUserTicketLimit(candidates, heights, counts, 2)
+	want := map[chainhash.Hash]string{{4}: "a", {5}: "b", {7}: "b"}
+	if !reflect.DeepEqual(over, want) {
+		t.Fatalf("got over limit tickets %v, want %v", over, want)
+	}
+	wantCounts := map[string]int{"a": 2, "b": 2, "c": 1}
+	if !reflect.DeepEqual(counts, wantCounts) {
+		t.Fatalf("got counts %v, want %v", counts, wantCounts)
+	}
+}
+
+func TestLimitNewUserTickets(t *testing.T) {
+	spd := &Stakepoold{
+		LiveTicketsMSA: map[chainhash.Hash]string{{1}: "a"},
+	}
+	live := map[chainhash.Hash]string{{2}: "a", {3}: "b"}
+	if over := spd.limitNewUserTickets(live); len(over) != 0 {
+		t.Fatalf("tickets %v over the limit without a limit", over)
+	}
+
+	spd.MaxUserLiveTickets = 1
+	over := spd.limitNewUserTickets(live)
+	if !reflect.DeepEqual(over, map[chainhash.Hash]string{{2}: "a"}) {
+		t.Fatalf("got over limit tickets %v", over)
+	}
+	if !reflect.DeepEqual(live, map[chainhash.Hash]string{{3}: "b"}) {
+		t.Fatalf("got live tickets %v", live)
+	}
+}
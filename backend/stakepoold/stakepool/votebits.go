@@ -0,0 +1,99 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrstakepool/backend/stakepoold/userdata"
+	"github.com/decred/dcrstakepool/helpers"
+)
+
+// ValidVoteBits returns whether voteBits approve the previous block and
+// choose one of the choices of every agenda of voteVersion without setting
+// any other bits.  It matches the check of the web frontend on the voting
+// preferences of users.
+func ValidVoteBits(params *chaincfg.Params, voteVersion uint32, voteBits uint16) bool {
+	// All blocks valid is OK
+	if voteBits == 1 {
+		return true
+	}
+
+	// check if last block invalid is set at all
+	if voteBits&1 == 0 {
+		return false
+	}
+
+	usedBits := uint16(1)
+	deployments := params.Deployments[voteVersion]
+	for i := range deployments {
+		d := &deployments[i]
+		masked := voteBits & d.Vote.Mask
+		var valid bool
+		for choice := range d.Vote.Choices {
+			usedBits |= d.Vote.Choices[choice].Bits
+			if masked == d.Vote.Choices[choice].Bits {
+				valid = true
+			}
+		}
+		if !valid {
+			return false
+		}
+	}
+
+	return voteBits&^usedBits == 0
+}
+
+// ValidateUserVotingConfig migrates the vote bits of the users in
+// userVotingConfig which are of another vote version than votingConfig to its
+// vote version with helpers.MigrateVoteBits, keeping their choices on agendas
+// which are voted on again.  Vote bits which are invalid for the agendas of
+// votingConfig, or which keep no choices, are reset to the default vote bits
+// of votingConfig.  It returns the number of users reset.  Otherwise invalid
+// vote bits are only noticed when the tickets of the users are called to
+// vote.
+func ValidateUserVotingConfig(params *chaincfg.Params, votingConfig *VotingConfig,
+	userVotingConfig map[string]userdata.UserVotingConfig) uint32 {
+
+	var invalid, migrated uint32
+	for msa, cfg := range userVotingConfig {
+		switch {
+		case cfg.VoteBitsVersion != votingConfig.VoteVersion:
+			voteBits, kept := helpers.MigrateVoteBits(params,
+				cfg.VoteBitsVersion, votingConfig.VoteVersion, cfg.VoteBits)
+			if len(kept) > 0 && ValidVoteBits(params, votingConfig.VoteVersion, voteBits) {
+				log.Infof("userid %v multisigaddress %v vote version %v "+
+					"migrated to wallet vote version %v, using votebits "+
+					"%d keeping choices on agendas %v", cfg.Userid, msa,
+					cfg.VoteBitsVersion, votingConfig.VoteVersion, voteBits,
+					kept)
+				cfg.VoteBits = voteBits
+				cfg.VoteBitsVersion = votingConfig.VoteVersion
+				userVotingConfig[msa] = cfg
+				migrated++
+				continue
+			}
+			log.Warnf("userid %v multisigaddress %v vote version %v does not "+
+				"match wallet vote version %v, using votebits %d",
+				cfg.Userid, msa, cfg.VoteBitsVersion,
+				votingConfig.VoteVersion, votingConfig.VoteBits)
+		case !ValidVoteBits(params, votingConfig.VoteVersion, cfg.VoteBits):
+			log.Warnf("userid %v multisigaddress %v votebits %d invalid for "+
+				"the agendas of vote version %v, using votebits %d",
+				cfg.Userid, msa, cfg.VoteBits, votingConfig.VoteVersion,
+				votingConfig.VoteBits)
+		default:
+			continue
+		}
+		cfg.VoteBits = votingConfig.VoteBits
+		cfg.VoteBitsVersion = votingConfig.VoteVersion
+		userVotingConfig[msa] = cfg
+		invalid++
+	}
+	if migrated > 0 || invalid > 0 {
+		log.Infof("Vote bits of %d users migrated and %d users reset to "+
+			"vote version %v", migrated, invalid, votingConfig.VoteVersion)
+	}
+	return invalid
+}
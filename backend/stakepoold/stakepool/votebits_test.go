@@ -0,0 +1,84 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrstakepool/backend/stakepoold/userdata"
+)
+
+func TestValidateUserVotingConfig(t *testing.T) {
+	params := &chaincfg.Params{
+		Deployments: map[uint32][]chaincfg.ConsensusDeployment{
+			// The agenda moved to bits 1 and 2 in version 7.
+			6: {{
+				Vote: chaincfg.Vote{
+					Id:   "agenda",
+					Mask: 0x0018,
+					Choices: []chaincfg.Choice{
+						{Id: "abstain", Bits: 0x0000, IsAbstain: true},
+						{Id: "no", Bits: 0x0008, IsNo: true},
+						{Id: "yes", Bits: 0x0010},
+					},
+				},
+			}},
+			7: {{
+				Vote: chaincfg.Vote{
+					Id:   "agenda",
+					Mask: 0x0006,
+					Choices: []chaincfg.Choice{
+						{Id: "abstain", Bits: 0x0000, IsAbstain: true},
+						{Id: "no", Bits: 0x0002, IsNo: true},
+						{Id: "yes", Bits: 0x0004},
+					},
+				},
+			}},
+		},
+	}
+
+	tests := []struct {
+		voteBits uint16
+		valid    bool
+	}{
+		{0x0001, true},
+		{0x0003, true},
+		{0x0005, true},
+		{0x0000, false}, // previous block invalid
+		{0x0007, false}, // not a choice
+		{0x0009, false}, // bit outside of the agendas
+	}
+	for _, test := range tests {
+		if valid := ValidVoteBits(params, 7, test.voteBits); valid != test.valid {
+			t.Errorf("ValidVoteBits(%#04x) = %v, want %v", test.voteBits,
+				valid, test.valid)
+		}
+	}
+
+	votingConfig := &VotingConfig{VoteBits: 0x0001, VoteVersion: 7}
+	users := map[string]userdata.UserVotingConfig{
+		"valid":   {Userid: 1, VoteBits: 0x0005, VoteBitsVersion: 7},
+		"invalid": {Userid: 2, VoteBits: 0x0007, VoteBitsVersion: 7},
+		"old":     {Userid: 3, VoteBits: 0x0005, VoteBitsVersion: 5},
+		"moved":   {Userid: 4, VoteBits: 0x0011, VoteBitsVersion: 6},
+	}
+	if n := ValidateUserVotingConfig(params, votingConfig, users); n != 2 {
+		t.Fatalf("reset %d users, want 2", n)
+	}
+	if users["valid"].VoteBits != 0x0005 {
+		t.Errorf("valid votebits reset to %#04x", users["valid"].VoteBits)
+	}
+	if cfg := users["moved"]; cfg.VoteBits != 0x0005 || cfg.VoteBitsVersion != 7 {
+		t.Errorf("votebits not migrated to the moved agenda: %+v", cfg)
+	}
+	for _, msa := range []string{"invalid", "old"} {
+		cfg := users[msa]
+		if cfg.VoteBits != votingConfig.VoteBits ||
+			cfg.VoteBitsVersion != votingConfig.VoteVersion {
+			t.Errorf("%s user not reset to defaults: %+v", msa, cfg)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+// VoteSigner creates the signed votes of winning tickets, which stakepoold
+// then broadcasts.
+type VoteSigner interface {
+	SignVote(ctx context.Context, blockHash *chainhash.Hash, blockHeight int64,
+		ticket *chainhash.Hash, voteBits uint16, voteBitsExt string) (*wire.MsgTx, error)
+}
+
+// walletVoteSigner signs votes with the GenerateVote RPC of dcrwallet, which
+// must hold the voting keys.  It is used when no external signer is set.
+type walletVoteSigner struct {
+	wallet *Client
+}
+
+func (s walletVoteSigner) SignVote(ctx context.Context, blockHash *chainhash.Hash, blockHeight int64,
+	ticket *chainhash.Hash, voteBits uint16, voteBitsExt string) (*wire.MsgTx, error) {
+	res, err := s.wallet.RPCClient().GenerateVote(ctx, blockHash, blockHeight,
+		ticket, voteBits, voteBitsExt)
+	if err != nil {
+		return nil, err
+	}
+	if res.Hex == "" {
+		return nil, errors.New("dcrwallet returned no vote")
+	}
+	return decodeVote(res.Hex)
+}
+
+// signVoteRequest is a request of stakepoold to an external vote signer.
+type signVoteRequest struct {
+	BlockHash   string `json:"blockhash"`
+	BlockHeight int64  `json:"blockheight"`
+	Ticket      string `json:"ticket"`
+	VoteBits    uint16 `json:"votebits"`
+	VoteBitsExt string `json:"votebitsext"`
+}
+
+// signVoteResponse is the response of an external vote signer, holding either
+// the hex encoded signed vote or why it was not signed.
+type signVoteResponse struct {
+	Hex   string `json:"hex"`
+	Error string `json:"error"`
+}
+
+// ExternalVoteSigner delegates signing votes to a separate signer process
+// listening on a local unix socket, so that the voting keys can be kept in an
+// isolated signer and dcrwallet only has to watch the tickets.  For each vote
+// stakepoold connects to the socket, writes a JSON signVoteRequest followed
+// by a newline and reads a JSON signVoteResponse.  The signed votes are
+// checked before they are broadcast.
+type ExternalVoteSigner struct {
+	Socket  string
+	Timeout time.Duration
+}
+
+// NewExternalVoteSigner returns a signer which requests votes from the signer
+// listening on socket and gives up after timeout.
+func NewExternalVoteSigner(socket string, timeout time.Duration) *ExternalVoteSigner {
+	return &ExternalVoteSigner{Socket: socket, Timeout: timeout}
+}
+
+// dial connects to the signer, with a deadline for the whole request.
+func (s *ExternalVoteSigner) dial(ctx context.Context) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", s.Socket)
+	if err != nil {
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Check returns an error when the signer does not accept connections, e.g.
+// because it is not running.
+func (s *ExternalVoteSigner) Check(ctx context.Context) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// SignVote requests the vote of ticket on the block from the signer.
+func (s *ExternalVoteSigner) SignVote(ctx context.Context, blockHash *chainhash.Hash, blockHeight int64,
+	ticket *chainhash.Hash, voteBits uint16, voteBitsExt string) (*wire.MsgTx, error) {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("vote signer: %v", err)
+	}
+	defer conn.Close()
+
+	req := signVoteRequest{
+		BlockHash:   blockHash.String(),
+		BlockHeight: blockHeight,
+		Ticket:      ticket.String(),
+		VoteBits:    voteBits,
+		VoteBitsExt: voteBitsExt,
+	}
+	if err := json.NewEncoder(conn).Encode(&req); err != nil {
+		return nil, fmt.Errorf("vote signer: %v", err)
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return nil, fmt.Errorf("vote signer: %v", err)
+	}
+	var resp signVoteResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("vote signer: invalid response: %v", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("vote signer: %s", resp.Error)
+	}
+
+	tx, err := decodeVote(resp.Hex)
+	if err != nil {
+		return nil, fmt.Errorf("vote signer: %v", err)
+	}
+	if err := checkSignedVote(tx, blockHash, blockHeight, ticket, voteBits); err != nil {
+		return nil, fmt.Errorf("vote signer: %v", err)
+	}
+	return tx, nil
+}
+
+// decodeVote decodes a hex encoded vote.
+func decodeVote(voteHex string) (*wire.MsgTx, error) {
+	buf, err := hex.DecodeString(voteHex)
+	if err != nil {
+		return nil, err
+	}
+	tx := wire.NewMsgTx()
+	if err := tx.FromBytes(buf); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// checkSignedVote returns an error unless tx is a vote of ticket on the block
+// with voteBits, so that a faulty or compromised signer cannot make
+// stakepoold broadcast anything else.
+func checkSignedVote(tx *wire.MsgTx, blockHash *chainhash.Hash, blockHeight int64,
+	ticket *chainhash.Hash, voteBits uint16) error {
+	if !stake.IsSSGen(tx, false) && !stake.IsSSGen(tx, true) {
+		return errors.New("signed transaction is not a vote")
+	}
+	if tx.TxIn[1].PreviousOutPoint.Hash != *ticket {
+		return fmt.Errorf("signed vote spends %v instead of ticket %v",
+			tx.TxIn[1].PreviousOutPoint.Hash, ticket)
+	}
+	votedHash, votedHeight := stake.SSGenBlockVotedOn(tx)
+	if votedHash != *blockHash || int64(votedHeight) != blockHeight {
+		return fmt.Errorf("signed vote is on block %v (height %d) instead "+
+			"of %v (height %d)", votedHash, votedHeight, blockHash,
+			blockHeight)
+	}
+	if bits := stake.SSGenVoteBits(tx); bits != voteBits {
+		return fmt.Errorf("signed vote has votebits %d instead of %d",
+			bits, voteBits)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+// testVote returns a vote of ticket on the block with voteBits.
+func testVote(blockHash *chainhash.Hash, blockHeight int64, ticket *chainhash.Hash, voteBits uint16) *wire.MsgTx {
+	const opSSGEN = 0xbb
+
+	vote := wire.NewMsgTx()
+	vote.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex, wire.TxTreeRegular), 1.5e8, []byte{0, 0}))
+	vote.AddTxIn(wire.NewTxIn(wire.NewOutPoint(ticket, 0,
+		wire.TxTreeStake), 100e8, nil))
+	blockRef := []byte{0x6a, 0x24} // OP_RETURN OP_DATA_36
+	blockRef = append(blockRef, blockHash[:]...)
+	blockRef = append(blockRef, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(blockRef[34:], uint32(blockHeight))
+	vote.AddTxOut(wire.NewTxOut(0, blockRef))
+	bits := []byte{0x6a, 0x06, 0, 0, 8, 0, 0, 0} // OP_RETURN OP_DATA_6
+	binary.LittleEndian.PutUint16(bits[2:], voteBits)
+	vote.AddTxOut(wire.NewTxOut(0, bits))
+	vote.AddTxOut(wire.NewTxOut(101.5e8, taggedP2PKH(opSSGEN)))
+	return vote
+}
+
+// serveVoteSigner answers the requests on l with the vote returned by sign.
+func serveVoteSigner(t *testing.T, l net.Listener, sign func(signVoteRequest) signVoteResponse) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		var req signVoteRequest
+		line, err := bufio.NewReader(conn).ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			// Connections of Check do not send a request.
+			conn.Close()
+			continue
+		}
+		if err == nil {
+			err = json.Unmarshal(line, &req)
+		}
+		if err != nil {
+			t.Errorf("signer got invalid request: %v", err)
+		}
+		json.NewEncoder(conn).Encode(sign(req))
+		conn.Close()
+	}
+}
+
+func TestExternalVoteSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "votesigner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "signer.sock")
+
+	signer := NewExternalVoteSigner(socket, time.Second)
+	if err := signer.Check(context.Background()); err == nil {
+		t.Fatal("no error without a signer")
+	}
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer l.Close()
+
+	blockHash, ticket := &chainhash.Hash{1}, &chainhash.Hash{2}
+	const blockHeight, voteBits = 500, 5
+	// The signer returns the vote of the ticket of the request, but a vote
+	// of another ticket for ticket 3 and an error for ticket 4.
+	go serveVoteSigner(t, l, func(req signVoteRequest) signVoteResponse {
+		th, _ := chainhash.NewHashFromStr(req.Ticket)
+		bh, _ := chainhash.NewHashFromStr(req.BlockHash)
+		switch *th {
+		case chainhash.Hash{3}:
+			th = ticket
+		case chainhash.Hash{4}:
+			return signVoteResponse{Error: "ticket not found"}
+		}
+		var buf bytes.Buffer
+		testVote(bh, req.BlockHeight, th, req.VoteBits).Serialize(&buf)
+		return signVoteResponse{Hex: hex.EncodeToString(buf.Bytes())}
+	})
+
+	ctx := context.Background()
+	if err := signer.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := signer.SignVote(ctx, blockHash, blockHeight, ticket, voteBits, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.TxIn[1].PreviousOutPoint.Hash != *ticket {
+		t.Fatalf("got vote of %v", tx.TxIn[1].PreviousOutPoint.Hash)
+	}
+
+	_, err = signer.SignVote(ctx, blockHash, blockHeight, &chainhash.Hash{3}, voteBits, "")
+	if err == nil || !strings.Contains(err.Error(), "instead of ticket") {
+		t.Fatalf("got error %v for the vote of another ticket", err)
+	}
+	_, err = signer.SignVote(ctx, blockHash, blockHeight, &chainhash.Hash{4}, voteBits, "")
+	if err == nil || !strings.Contains(err.Error(), "ticket not found") {
+		t.Fatalf("got error %v for a refused vote", err)
+	}
+}
+
+func TestCheckSignedVote(t *testing.T) {
+	blockHash, ticket := &chainhash.Hash{1}, &chainhash.Hash{2}
+	vote := testVote(blockHash, 500, ticket, 5)
+	if err := checkSignedVote(vote, blockHash, 500, ticket, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSignedVote(vote, &chainhash.Hash{9}, 500, ticket, 5); err == nil {
+		t.Fatal("accepted the vote on another block")
+	}
+	if err := checkSignedVote(vote, blockHash, 501, ticket, 5); err == nil {
+		t.Fatal("accepted the vote at another height")
+	}
+	if err := checkSignedVote(vote, blockHash, 500, ticket, 1); err == nil {
+		t.Fatal("accepted the vote with other votebits")
+	}
+	vote.TxOut = vote.TxOut[:1]
+	if err := checkSignedVote(vote, blockHash, 500, ticket, 5); err == nil {
+		t.Fatal("accepted a transaction which is not a vote")
+	}
+}
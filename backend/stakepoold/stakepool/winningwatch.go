@@ -0,0 +1,164 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrstakepool/internal/notify"
+)
+
+// noWinnersProbability is the probability of no managed ticket being selected
+// over a run of blocks below which the run is alerted as an anomaly.  With
+// 5 tickets per block it is reached after about 14 expected winners, e.g.
+// after 280 blocks for a pool holding 1% of the live tickets.
+const noWinnersProbability = 1e-6
+
+// WinningWatchdog alerts when dcrd keeps connecting blocks but stakepoold
+// stops receiving their winning tickets, e.g. since the notification
+// registration was lost without the connection dropping, and when no managed
+// ticket was selected for far longer than the number of live tickets
+// explains.  Either way the tickets of the users miss their votes silently.
+//
+// A nil *WinningWatchdog is disabled, but still counts the notifications.
+type WinningWatchdog struct {
+	alertBlocks     int
+	minHeight       int64
+	ticketsPerBlock float64
+	alerter         *notify.Alerter
+
+	mtx sync.Mutex
+
+	// silentBlocks is the number of blocks connected since the last
+	// winning tickets notification, including the last block, whose
+	// notification may still be on its way.
+	silentBlocks  int
+	silentAlerted bool
+
+	// poolSize is the size of the ticket pool of the last connected
+	// block, and expectedWinners the number of managed winning tickets
+	// expected since the last one.
+	poolSize        uint32
+	noWinnerBlocks  int
+	expectedWinners float64
+	noWinnerAlerted bool
+}
+
+// NewWinningWatchdog returns a watchdog of the network of params which alerts
+// with alerter when more than alertBlocks blocks are connected without a
+// winning tickets notification.  It returns nil when alertBlocks is not
+// positive.
+func NewWinningWatchdog(params *chaincfg.Params, alertBlocks int, alerter *notify.Alerter) *WinningWatchdog {
+	if alertBlocks <= 0 {
+		return nil
+	}
+	return &WinningWatchdog{
+		alertBlocks: alertBlocks,
+		// Winning tickets are first notified for the block before
+		// the stake validation height.
+		minHeight:       params.StakeValidationHeight - 1,
+		ticketsPerBlock: float64(params.TicketsPerBlock),
+		alerter:         alerter,
+	}
+}
+
+// BlockConnected records that dcrd connected the block at height, whose
+// ticket pool holds poolSize tickets.  The winning tickets notification of a
+// block follows its block connected notification, so the block being
+// connected is not counted as missing its notification yet.
+func (w *WinningWatchdog) BlockConnected(height int64, poolSize uint32) {
+	blocksConnectedTotal.Inc()
+	if w == nil || height < w.minHeight {
+		return
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.poolSize = poolSize
+	w.silentBlocks++
+	if w.silentBlocks-1 <= w.alertBlocks || w.silentAlerted {
+		return
+	}
+	w.silentAlerted = true
+	msg := fmt.Sprintf("dcrd connected %d blocks up to height %d without "+
+		"notifying their winning tickets, tickets are not voted.  "+
+		"Restart stakepoold to register for the notifications again.",
+		w.silentBlocks-1, height)
+	log.Critical(msg)
+	w.alerter.Alert(notify.KindWinningNtfns, msg)
+}
+
+// WinningTicketsNotified records a winning tickets notification of the block
+// at height.
+func (w *WinningWatchdog) WinningTicketsNotified(height int64) {
+	winningTicketsNtfnsTotal.Inc()
+	if w == nil {
+		return
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.silentAlerted {
+		log.Infof("Winning tickets notifications resumed at height %d "+
+			"after %d blocks", height, w.silentBlocks-1)
+	}
+	w.silentBlocks = 0
+	w.silentAlerted = false
+}
+
+// silentBlockCount returns the number of blocks connected since the last
+// winning tickets notification.
+func (w *WinningWatchdog) silentBlockCount() int {
+	if w == nil {
+		return 0
+	}
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.silentBlocks
+}
+
+// managedWinners records that managed of the winning tickets of the block at
+// height were managed tickets, of which there were live.  Blocks without
+// managed winners add the chance of one being selected to the expected
+// winners, assuming a pool of the size of the last connected block.
+func (w *WinningWatchdog) managedWinners(height int64, managed, live int) {
+	managedWinnersTotal.Add(float64(managed))
+	if w == nil {
+		return
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if managed > 0 {
+		if w.noWinnerAlerted {
+			log.Infof("Managed ticket selected at height %d after %d "+
+				"blocks without", height, w.noWinnerBlocks)
+		}
+		w.noWinnerBlocks = 0
+		w.expectedWinners = 0
+		w.noWinnerAlerted = false
+		return
+	}
+	if live == 0 || w.poolSize == 0 {
+		return
+	}
+	w.noWinnerBlocks++
+	w.expectedWinners += w.ticketsPerBlock * float64(live) /
+		float64(w.poolSize)
+	if w.noWinnerAlerted || math.Exp(-w.expectedWinners) >= noWinnersProbability {
+		return
+	}
+	w.noWinnerAlerted = true
+	msg := fmt.Sprintf("No managed ticket was selected in the %d blocks "+
+		"up to height %d although %.1f were expected with %d live "+
+		"tickets, winning tickets notifications may be incomplete or "+
+		"live tickets untracked", w.noWinnerBlocks, height,
+		w.expectedWinners, live)
+	log.Critical(msg)
+	w.alerter.Alert(notify.KindWinningNtfns, msg)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepool
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+)
+
+func TestWinningWatchdogNotifications(t *testing.T) {
+	params := chaincfg.SimNetParams()
+	w := NewWinningWatchdog(params, 3, nil)
+	svh := params.StakeValidationHeight
+
+	// Blocks before winning tickets are notified are not counted.
+	for h := int64(0); h < svh-1; h++ {
+		w.BlockConnected(h, 0)
+	}
+	if n := w.silentBlockCount(); n != 0 {
+		t.Fatalf("counted %d blocks before stake validation", n)
+	}
+
+	// Each notification following its block resets the count.
+	height := svh - 1
+	for ; height < svh+10; height++ {
+		w.BlockConnected(height, 100)
+		w.WinningTicketsNotified(height)
+	}
+	if w.silentAlerted || w.silentBlockCount() != 0 {
+		t.Fatal("alerted while notifications were received")
+	}
+
+	// Three blocks without notifications are tolerated, the fourth alerts
+	// once the next block is connected.
+	for i := 0; i < 4; i++ {
+		w.BlockConnected(height, 100)
+		height++
+	}
+	if w.silentAlerted {
+		t.Fatal("alerted after 3 blocks without notifications")
+	}
+	w.BlockConnected(height, 100)
+	if !w.silentAlerted {
+		t.Fatal("no alert after 4 blocks without notifications")
+	}
+	w.WinningTicketsNotified(height)
+	if w.silentAlerted || w.silentBlockCount() != 0 {
+		t.Fatal("notification did not reset the watchdog")
+	}
+}
+
+func TestWinningWatchdogManagedWinners(t *testing.T) {
+	w := NewWinningWatchdog(chaincfg.SimNetParams(), 3, nil)
+	w.BlockConnected(1000, 10000)
+
+	// A pool with 1% of the live tickets expects 0.05 winners per block,
+	// so 275 blocks without one are unlikely but not yet anomalous.
+	for i := 0; i < 275; i++ {
+		w.managedWinners(int64(i), 0, 100)
+	}
+	if w.noWinnerAlerted {
+		t.Fatalf("alerted after %d blocks, %.2f expected winners",
+			w.noWinnerBlocks, w.expectedWinners)
+	}
+	for i := 0; i < 10; i++ {
+		w.managedWinners(int64(i), 0, 100)
+	}
+	if !w.noWinnerAlerted {
+		t.Fatalf("no alert after %d blocks, %.2f expected winners",
+			w.noWinnerBlocks, w.expectedWinners)
+	}
+
+	w.managedWinners(2000, 1, 100)
+	if w.noWinnerAlerted || w.noWinnerBlocks != 0 || w.expectedWinners != 0 {
+		t.Fatal("managed winner did not reset the watchdog")
+	}
+
+	// Without live tickets none are expected to win.
+	for i := 0; i < 1000; i++ {
+		w.managedWinners(int64(i), 0, 0)
+	}
+	if w.noWinnerAlerted {
+		t.Fatal("alerted without live tickets")
+	}
+}
+
+func TestWinningWatchdogDisabled(t *testing.T) {
+	w := NewWinningWatchdog(chaincfg.SimNetParams(), 0, nil)
+	if w != nil {
+		t.Fatal("watchdog created with no alert threshold")
+	}
+	w.BlockConnected(1000, 100)
+	w.WinningTicketsNotified(1000)
+	w.managedWinners(1000, 0, 10)
+	if n := w.silentBlockCount(); n != 0 {
+		t.Fatalf("disabled watchdog counted %d blocks", n)
+	}
+}
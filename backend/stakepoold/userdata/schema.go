@@ -0,0 +1,63 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package userdata
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/decred/dcrstakepool/internal/dbschema"
+)
+
+// schemaTables are the tables and columns queried by stakepoold.  They are
+// created and migrated by dcrstakepool, so they are missing when stakepoold is
+// newer than dcrstakepool.
+var schemaTables = []dbschema.Table{
+	{Name: "LowFeeTicket", Columns: []string{"TicketHash", "TicketAddress"}},
+	{Name: "Message", Columns: []string{"UserId", "Kind", "Subject", "Body",
+		"Created", "Read", "DedupeKey"}},
+	{Name: "MissedTicket", Columns: []string{"UserId", "TicketHash",
+		"BlockHash", "BlockHeight", "Cause", "Reason", "Created"}},
+	{Name: "TicketFee", Columns: []string{"TicketHash", "Status"}},
+	{Name: "Users", Columns: []string{"UserId", "MultiSigAddress", "VoteBits",
+		"VoteBitsVersion"}},
+	{Name: "VotingFreeze", Columns: []string{"VotingFreezeID", "Frozen",
+		"VoteBits"}},
+}
+
+// schemaIndexes are the indexes which the queries of stakepoold rely on.
+var schemaIndexes = []dbschema.Index{
+	{Name: "idx_TicketFee_TicketHash", Table: "TicketFee",
+		Columns: []string{"TicketHash"},
+		Reason:  "checking the fees of new tickets in the deferred fee mode"},
+}
+
+// CheckSchema verifies that the database has the tables, columns and indexes
+// queried by stakepoold and logs the problems found.  Missing indexes are
+// created when createIndexes is true.
+func (u *UserData) CheckSchema(ctx context.Context, createIndexes bool) error {
+	u.RLock()
+	cfg := *u.DBConfig
+	u.RUnlock()
+
+	db, err := sql.Open("mysql", fmt.Sprint(cfg.DBUser, ":", cfg.DBPassword, "@(", cfg.DBHost, ":", cfg.DBPort, ")/", cfg.DBName, "?charset=utf8mb4"))
+	if err != nil {
+		return fmt.Errorf("unable to open db: %v", err)
+	}
+	defer db.Close()
+
+	r, err := dbschema.Verify(ctx, db, cfg.DBName, schemaTables,
+		schemaIndexes, createIndexes, log)
+	if err != nil {
+		return fmt.Errorf("unable to check database schema: %v", err)
+	}
+	if r.Broken() {
+		return fmt.Errorf("database schema is missing %d tables and %d "+
+			"columns; upgrade dcrstakepool to migrate it",
+			len(r.MissingTables), len(r.MissingColumns))
+	}
+	return nil
+}
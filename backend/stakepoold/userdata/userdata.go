@@ -1,13 +1,48 @@
 package userdata
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
 )
 
+const (
+	// dbConnMaxLifetime is how long a connection to MySQL is reused.  It is
+	// shorter than the idle timeouts of MySQL and of firewalls, which close
+	// idle connections so that the next query on them fails with "invalid
+	// connection".
+	dbConnMaxLifetime = 3 * time.Minute
+	dbMaxIdleConns    = 2
+
+	// dbPingTimeout is how long a liveness ping of MySQL may take.
+	dbPingTimeout = 5 * time.Second
+
+	// dbDialTimeout and dbIOTimeout are the connect, read and write
+	// timeouts of the connections to MySQL, so that a connection which
+	// hangs fails the query instead of blocking stakepoold.
+	dbDialTimeout = 5 * time.Second
+	dbIOTimeout   = 30 * time.Second
+
+	// fetchTimeout is how long an attempt of a fetch may take.
+	fetchTimeout = 10 * time.Second
+
+	// fetchAttempts is how many times a fetch is tried before its error is
+	// returned.  The delay between the attempts starts at fetchRetryDelay
+	// and doubles after each one.
+	fetchAttempts   = 3
+	fetchRetryDelay = 250 * time.Millisecond
+
+	// feePaidTimeout is how long the fee payments of winning tickets may be
+	// looked up.  They are looked up while voting, so the lookup is tried
+	// once with a short deadline rather than delaying the votes.
+	feePaidTimeout = 2 * time.Second
+)
+
 // DBConfig stores DB login information.
 type DBConfig struct {
 	DBHost     string
@@ -22,6 +57,105 @@ type UserData struct {
 	sync.RWMutex
 	DBConfig         *DBConfig
 	UserVotingConfig map[string]UserVotingConfig // [multisigaddr]
+
+	// db is the pool of connections to MySQL, opened by the first query.
+	db *sql.DB
+}
+
+// open returns the pool of connections to MySQL, opening it when needed.
+func (u *UserData) open() (*sql.DB, error) {
+	u.Lock()
+	defer u.Unlock()
+	if u.db != nil {
+		return u.db, nil
+	}
+	db, err := sql.Open("mysql", fmt.Sprint(u.DBConfig.DBUser, ":", u.DBConfig.DBPassword, "@(", u.DBConfig.DBHost, ":", u.DBConfig.DBPort, ")/", u.DBConfig.DBName, "?charset=utf8mb4",
+		"&timeout=", dbDialTimeout, "&readTimeout=", dbIOTimeout,
+		"&writeTimeout=", dbIOTimeout))
+	if err != nil {
+		log.Errorf("Unable to open db: %v", err)
+		return nil, err
+	}
+	db.SetConnMaxLifetime(dbConnMaxLifetime)
+	db.SetMaxIdleConns(dbMaxIdleConns)
+	u.db = db
+	return db, nil
+}
+
+// fetch runs the query op with fn up to attempts times, retrying it with
+// backoff when it fails, e.g. on a connection which MySQL closed while it was
+// idle.  Each attempt is given a context which is done after timeout.
+func (u *UserData) fetch(ctx context.Context, op string, attempts int,
+	timeout time.Duration, fn func(ctx context.Context, db *sql.DB) error) error {
+	delay := fetchRetryDelay
+	for attempt := 1; ; attempt++ {
+		db, err := u.open()
+		if err == nil {
+			attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+			err = fn(attemptCtx, db)
+			cancel()
+		}
+		if err == nil || attempt >= attempts || ctx.Err() != nil {
+			return err
+		}
+		log.Warnf("%s failed (attempt %d of %d), retrying in %v: %v", op,
+			attempt, attempts, delay, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// Ping checks that MySQL is reachable.
+func (u *UserData) Ping(ctx context.Context) error {
+	db, err := u.open()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+	defer cancel()
+	return db.PingContext(ctx)
+}
+
+// KeepAlive pings MySQL every interval until ctx is done, so that connections
+// broken while idle are replaced before the next query needs them.  It logs
+// when MySQL becomes unreachable and reachable again.
+func (u *UserData) KeepAlive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var down bool
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := u.Ping(ctx)
+		switch {
+		case err != nil && !down:
+			log.Errorf("MySQL is unreachable: %v", err)
+			down = true
+		case err == nil && down:
+			log.Infof("MySQL is reachable again")
+			down = false
+		}
+	}
+}
+
+// Close closes the connections to MySQL.
+func (u *UserData) Close() error {
+	u.Lock()
+	defer u.Unlock()
+	if u.db == nil {
+		return nil
+	}
+	err := u.db.Close()
+	u.db = nil
+	return err
 }
 
 // UserVotingConfig contains per-user voting preferences.
@@ -35,109 +169,191 @@ type UserVotingConfig struct {
 // MySQLFetchAddedLowFeeTickets fetches any low fee tickets that were
 // manually added by the admin.
 func (u *UserData) MySQLFetchAddedLowFeeTickets() (map[chainhash.Hash]string, error) {
-	var (
-		ticketHashString string
-		ticketAddress    string
-	)
-
 	tickets := make(map[chainhash.Hash]string)
+	err := u.fetch(context.Background(), "MySQLFetchAddedLowFeeTickets", fetchAttempts, fetchTimeout, func(ctx context.Context, db *sql.DB) error {
+		var (
+			ticketHashString string
+			ticketAddress    string
+		)
 
-	db, err := sql.Open("mysql", fmt.Sprint(u.DBConfig.DBUser, ":", u.DBConfig.DBPassword, "@(", u.DBConfig.DBHost, ":", u.DBConfig.DBPort, ")/", u.DBConfig.DBName, "?charset=utf8mb4"))
-	if err != nil {
-		log.Errorf("Unable to open db: %v", err)
-		return tickets, err
-	}
+		tickets = make(map[chainhash.Hash]string)
+
+		rows, err := db.QueryContext(ctx, "SELECT TicketHash, TicketAddress FROM LowFeeTicket")
+		if err != nil {
+			log.Errorf("Unable to query db: %v", err)
+			return err
+		}
+
+		defer rows.Close()
+		for rows.Next() {
+			err := rows.Scan(&ticketHashString, &ticketAddress)
+			if err != nil {
+				log.Errorf("Unable to scan row %v", err)
+				continue
+			}
+			ticketHash, err := chainhash.NewHashFromStr(ticketHashString)
+			if err != nil {
+				log.Warnf("NewHashFromStr failed for %v: %v", ticketHashString, err)
+				continue
+			}
+			tickets[*ticketHash] = ticketAddress
+		}
+		return rows.Err()
+	})
+	return tickets, err
+}
 
-	// sql.Open just validates its arguments without creating a connection
-	// Verify that the data source name is valid with Ping:
-	if err = db.Ping(); err != nil {
-		log.Errorf("Unable to establish connection to db: %v", err)
-		db.Close()
-		return tickets, err
+// MySQLFetchFeePaidTickets returns which of tickets have a ticket fee that
+// was paid in the deferred fee mode.  It is called while voting, so it is not
+// retried and fails after feePaidTimeout.
+func (u *UserData) MySQLFetchFeePaidTickets(ctx context.Context, tickets []*chainhash.Hash) (map[chainhash.Hash]struct{}, error) {
+	if len(tickets) == 0 {
+		return make(map[chainhash.Hash]struct{}), nil
 	}
 
-	rows, err := db.Query("SELECT TicketHash, TicketAddress FROM LowFeeTicket")
-	if err != nil {
-		log.Errorf("Unable to query db: %v", err)
-		db.Close()
-		return tickets, err
+	args := make([]interface{}, 0, len(tickets)+1)
+	args = append(args, "paid")
+	for _, ticket := range tickets {
+		args = append(args, ticket.String())
 	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(tickets)), ", ")
 
-	for rows.Next() {
-		err := rows.Scan(&ticketHashString, &ticketAddress)
+	var paid map[chainhash.Hash]struct{}
+	err := u.fetch(ctx, "MySQLFetchFeePaidTickets", 1, feePaidTimeout, func(ctx context.Context, db *sql.DB) error {
+		paid = make(map[chainhash.Hash]struct{})
+		rows, err := db.QueryContext(ctx, "SELECT TicketHash FROM TicketFee WHERE Status = ? "+
+			"AND TicketHash IN ("+placeholders+")", args...)
 		if err != nil {
-			log.Errorf("Unable to scan row %v", err)
-			continue
+			log.Errorf("Unable to query db: %v", err)
+			return err
 		}
-		ticketHash, err := chainhash.NewHashFromStr(ticketHashString)
-		if err != nil {
-			log.Warnf("NewHashFromStr failed for %v: %v", err)
-			continue
+
+		defer rows.Close()
+		for rows.Next() {
+			var ticketHashString string
+			if err := rows.Scan(&ticketHashString); err != nil {
+				log.Errorf("Unable to scan row %v", err)
+				continue
+			}
+			ticketHash, err := chainhash.NewHashFromStr(ticketHashString)
+			if err != nil {
+				log.Warnf("NewHashFromStr failed for %v: %v", ticketHashString, err)
+				continue
+			}
+			paid[*ticketHash] = struct{}{}
 		}
-		tickets[*ticketHash] = ticketAddress
-	}
-	if err = rows.Err(); err != nil {
-		db.Close()
-		return tickets, err
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return tickets, db.Close()
+	return paid, nil
 }
 
 // MySQLFetchUserVotingConfig fetches the voting preferences of all users
 // who have completed registration of the pool by submitting an address
 // and generating a multisig ticket address.
 func (u *UserData) MySQLFetchUserVotingConfig() (map[string]UserVotingConfig, error) {
-	var (
-		userid          int64
-		multiSigAddress string
-		voteBits        int64
-		voteBitsVersion int64
-	)
-
 	userInfo := map[string]UserVotingConfig{}
+	err := u.fetch(context.Background(), "MySQLFetchUserVotingConfig", fetchAttempts, fetchTimeout, func(ctx context.Context, db *sql.DB) error {
+		var (
+			userid          int64
+			multiSigAddress string
+			voteBits        int64
+			voteBitsVersion int64
+		)
+
+		userInfo = map[string]UserVotingConfig{}
+
+		rows, err := db.QueryContext(ctx, "SELECT UserId, MultiSigAddress, VoteBits, VoteBitsVersion FROM Users WHERE MultiSigAddress <> ''")
+		if err != nil {
+			log.Errorf("Unable to query db: %v", err)
+			return err
+		}
+
+		defer rows.Close()
+		for rows.Next() {
+			err := rows.Scan(&userid, &multiSigAddress, &voteBits, &voteBitsVersion)
+			if err != nil {
+				log.Errorf("Unable to scan row %v", err)
+				continue
+			}
+			userInfo[multiSigAddress] = UserVotingConfig{
+				Userid:          userid,
+				MultiSigAddress: multiSigAddress,
+				VoteBits:        uint16(voteBits),
+				VoteBitsVersion: uint32(voteBitsVersion),
+			}
+		}
+		if err = rows.Err(); err != nil {
+			return err
+		}
 
-	db, err := sql.Open("mysql", fmt.Sprint(u.DBConfig.DBUser, ":", u.DBConfig.DBPassword, "@(", u.DBConfig.DBHost, ":", u.DBConfig.DBPort, ")/", u.DBConfig.DBName, "?charset=utf8mb4"))
+		// Override the preferences of all users while an admin froze
+		// voting.
+		var frozen, frozenVoteBits int64
+		err = db.QueryRowContext(ctx, "SELECT Frozen, VoteBits FROM VotingFreeze "+
+			"ORDER BY VotingFreezeID DESC LIMIT 1").Scan(&frozen, &frozenVoteBits)
+		switch {
+		case err == sql.ErrNoRows:
+		case err != nil:
+			log.Warnf("Unable to query voting freeze: %v", err)
+		case frozen != 0:
+			log.Warnf("VOTING IS FROZEN: all %d users vote with votebits %d",
+				len(userInfo), frozenVoteBits)
+			for msa, config := range userInfo {
+				config.VoteBits = uint16(frozenVoteBits)
+				userInfo[msa] = config
+			}
+		}
+		return nil
+	})
+	return userInfo, err
+}
+
+// MySQLInsertMissedTicket records a managed winning ticket whose vote was not
+// mined along with the cause of the miss.  Every stakepoold instance audits
+// the votes, so a miss which was already recorded is ignored.
+func (u *UserData) MySQLInsertMissedTicket(userid int64, ticketHash string,
+	blockHash string, blockHeight int64, cause string, reason string) error {
+	db, err := u.open()
 	if err != nil {
-		log.Errorf("Unable to open db: %v", err)
-		return userInfo, err
+		return err
 	}
 
-	// sql.Open just validates its arguments without creating a connection
-	// Verify that the data source name is valid with Ping:
-	if err = db.Ping(); err != nil {
-		log.Errorf("Unable to establish connection to db: %v", err)
-		db.Close()
-		return userInfo, err
+	_, err = db.Exec("INSERT IGNORE INTO MissedTicket (UserId, TicketHash, "+
+		"BlockHash, BlockHeight, Cause, Reason, Created) "+
+		"VALUES (?, ?, ?, ?, ?, ?, UNIX_TIMESTAMP())", userid, ticketHash,
+		blockHash, blockHeight, cause, reason)
+	if err != nil {
+		log.Errorf("Unable to insert missed ticket: %v", err)
+		return err
 	}
 
-	rows, err := db.Query("SELECT UserId, MultiSigAddress, VoteBits, VoteBitsVersion FROM Users WHERE MultiSigAddress <> ''")
+	return nil
+}
+
+// MySQLInsertMessage delivers an in-app message to a user.  Every stakepoold
+// instance delivers the same messages, so a message whose dedupeKey was
+// already delivered is ignored.  An empty dedupeKey never matches.
+func (u *UserData) MySQLInsertMessage(userid int64, kind string,
+	subject string, body string, dedupeKey string) error {
+	db, err := u.open()
 	if err != nil {
-		log.Errorf("Unable to query db: %v", err)
-		db.Close()
-		return userInfo, err
+		return err
 	}
 
-	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&userid, &multiSigAddress, &voteBits, &voteBitsVersion)
-		if err != nil {
-			log.Errorf("Unable to scan row %v", err)
-			continue
-		}
-		userInfo[multiSigAddress] = UserVotingConfig{
-			Userid:          userid,
-			MultiSigAddress: multiSigAddress,
-			VoteBits:        uint16(voteBits),
-			VoteBitsVersion: uint32(voteBitsVersion),
-		}
-	}
-	if err = rows.Err(); err != nil {
-		db.Close()
-		return userInfo, err
+	_, err = db.Exec("INSERT INTO Message (UserId, Kind, Subject, Body, "+
+		"Created, `Read`, DedupeKey) VALUES (?, ?, ?, ?, UNIX_TIMESTAMP(), "+
+		"0, ?) ON DUPLICATE KEY UPDATE MessageID = MessageID", userid,
+		kind, subject, body,
+		sql.NullString{String: dedupeKey, Valid: dedupeKey != ""})
+	if err != nil {
+		log.Errorf("Unable to insert message: %v", err)
+		return err
 	}
 
-	return userInfo, db.Close()
+	return nil
 }
 
 // DBSetConfig sets the database configuration.
@@ -151,5 +367,9 @@ func (u *UserData) DBSetConfig(DBUser string, DBPassword string, DBHost string,
 	}
 	u.Lock()
 	u.DBConfig = dbconfig
+	if u.db != nil {
+		u.db.Close()
+		u.db = nil
+	}
 	u.Unlock()
 }
@@ -0,0 +1,88 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrstakepool/backend/stakepoold/stakepool"
+	"github.com/decred/dcrstakepool/internal/configcheck"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// validateProbeTimeout is how long validateprobe waits for each connection.
+const validateProbeTimeout = 5 * time.Second
+
+// validateConfigRequested returns whether the validateconfig option was passed
+// on the command line.  It is checked apart from loadConfig so that a config
+// which fails to load is reported as well.
+func validateConfigRequested() bool {
+	var opts struct {
+		ValidateConfig bool `long:"validateconfig"`
+	}
+	parser := flags.NewParser(&opts, flags.IgnoreUnknown)
+	parser.Parse()
+	return opts.ValidateConfig
+}
+
+// validateConfig returns the report of the checks of the config loaded by
+// loadConfig, which failed to load when loadErr is not nil.
+func validateConfig(ctx context.Context, cfg *config, loadErr error) *configcheck.Report {
+	report := configcheck.NewReport()
+	report.Add("load config", loadErr)
+	if loadErr != nil {
+		return report
+	}
+
+	_, err := hdkeychain.NewKeyFromString(cfg.ColdWalletExtPub,
+		activeNetParams.Params)
+	report.Add("coldwalletextpub", err)
+	report.Add("resolve dbhost", configcheck.ResolveHost(ctx, cfg.DBHost))
+	report.Add("resolve dcrdhost", configcheck.ResolveHost(ctx, cfg.DcrdHost))
+	report.Add("resolve wallethost", configcheck.ResolveHost(ctx, cfg.WalletHost))
+	report.Add("dcrdcert", configcheck.CertFile(cfg.DcrdCert))
+	report.Add("walletcert", configcheck.CertFile(cfg.WalletCert))
+	// A missing RPC key pair is generated on startup.
+	if !cfg.NoRPCListen && fileExists(cfg.RPCKey) {
+		report.Add("rpccert and rpckey",
+			configcheck.KeyPair(cfg.RPCCert, cfg.RPCKey))
+	}
+
+	if !cfg.ValidateProbe {
+		return report
+	}
+	dsn := fmt.Sprintf("%s:%s@(%s:%s)/%s?charset=utf8mb4", cfg.DBUser,
+		cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	report.Add("connect to database",
+		configcheck.PingMySQL(ctx, dsn, validateProbeTimeout))
+	report.Add("connect to dcrdhost",
+		configcheck.Dial(ctx, cfg.DcrdHost, validateProbeTimeout))
+	report.Add("connect to wallethost",
+		configcheck.Dial(ctx, cfg.WalletHost, validateProbeTimeout))
+	if cfg.VoteSigner != "" {
+		signer := stakepool.NewExternalVoteSigner(cfg.VoteSigner,
+			validateProbeTimeout)
+		report.Add("connect to votesigner", signer.Check(ctx))
+	}
+	return report
+}
+
+// runValidateConfig prints the report of validateConfig to stdout and returns
+// an error when the config is not valid.
+func runValidateConfig(ctx context.Context, cfg *config, loadErr error) error {
+	report := validateConfig(ctx, cfg, loadErr)
+	if err := report.Write(os.Stdout); err != nil {
+		return err
+	}
+	if !report.Valid {
+		return errors.New("config is not valid")
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrstakepool/internal/notify"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+)
+
+// backendCheckInterval is how often the stakepoold instances are checked for
+// conditions which are alerted to operators.
+const backendCheckInterval = time.Minute
+
+// backendAlerts returns the messages of the alerts raised by the status of the
+// stakepoold instances, keyed by alert kind.  An alert is raised when no
+// instance answers, since no user can then be served, and when the wallet of
+// an instance is locked, since it cannot vote.
+func backendAlerts(statuses []manager.BackendStatus) map[string]string {
+	alerts := make(map[string]string)
+	var down, locked []string
+	for _, s := range statuses {
+		switch {
+		case s.WalletStatus == nil:
+			down = append(down, s.Host)
+		case !s.Unlocked:
+			locked = append(locked, s.Host)
+		}
+	}
+	if len(statuses) > 0 && len(down) == len(statuses) {
+		alerts[notify.KindBackendsDown] = fmt.Sprintf("All %d stakepoold "+
+			"instances are unreachable: %s", len(down),
+			strings.Join(down, ", "))
+	}
+	if len(locked) > 0 {
+		alerts[notify.KindWalletLocked] = fmt.Sprintf("The voting wallet "+
+			"of stakepoold %s is locked", strings.Join(locked, ", "))
+	}
+	return alerts
+}
+
+// monitorBackends checks the stakepoold instances every backendCheckInterval
+// and sends the alerts of backendAlerts with alerter until ctx is cancelled.
+func monitorBackends(ctx context.Context, wg *sync.WaitGroup,
+	servers manager.Manager, alerter *notify.Alerter) {
+	defer wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backendCheckInterval):
+			for kind, msg := range backendAlerts(servers.BackendStatus(ctx)) {
+				if alerter.Alert(kind, msg) {
+					log.Critical(msg)
+				}
+			}
+		}
+	}
+}
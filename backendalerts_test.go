@@ -0,0 +1,54 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/decred/dcrstakepool/internal/notify"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+)
+
+func TestBackendAlerts(t *testing.T) {
+	up := &manager.WalletStatus{Unlocked: true}
+	locked := &manager.WalletStatus{}
+	tests := []struct {
+		name     string
+		statuses []manager.BackendStatus
+		want     []string
+	}{{
+		name: "no instances",
+	}, {
+		name: "all up",
+		statuses: []manager.BackendStatus{{Host: "a", WalletStatus: up},
+			{Host: "b", WalletStatus: up}},
+	}, {
+		name: "one down",
+		statuses: []manager.BackendStatus{{Host: "a"},
+			{Host: "b", WalletStatus: up}},
+	}, {
+		name:     "all down",
+		statuses: []manager.BackendStatus{{Host: "a"}, {Host: "b"}},
+		want:     []string{notify.KindBackendsDown},
+	}, {
+		name: "one down one locked",
+		statuses: []manager.BackendStatus{{Host: "a"},
+			{Host: "b", WalletStatus: locked}},
+		want: []string{notify.KindWalletLocked},
+	}}
+	for _, test := range tests {
+		alerts := backendAlerts(test.statuses)
+		if len(alerts) != len(test.want) {
+			t.Errorf("%s: got alerts %v, want kinds %v", test.name,
+				alerts, test.want)
+			continue
+		}
+		for _, kind := range test.want {
+			if _, ok := alerts[kind]; !ok {
+				t.Errorf("%s: no %s alert in %v", test.name, kind, alerts)
+			}
+		}
+	}
+}
@@ -12,41 +12,116 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/decred/dcrd/chaincfg/v3"
 	"github.com/decred/dcrd/dcrutil/v3"
 	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrstakepool/helpers"
+	"github.com/decred/dcrstakepool/internal/netparams"
+	"github.com/decred/dcrstakepool/internal/notify"
+	"github.com/decred/dcrstakepool/internal/secrets"
 	"github.com/decred/dcrstakepool/internal/version"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/system"
 	flags "github.com/jessevdk/go-flags"
 )
 
 const (
-	defaultBaseURL         = "http://127.0.0.1:8000"
-	defaultClosePoolMsg    = "The voting service is temporarily closed to new signups."
-	defaultConfigFilename  = "dcrstakepool.conf"
-	defaultLogLevel        = "info"
-	defaultLogDirname      = "logs"
-	defaultLogFilename     = "dcrstakepool.log"
-	defaultCookieSecure    = false
-	defaultDBHost          = "localhost"
-	defaultDBName          = "stakepool"
-	defaultDBPort          = "3306"
-	defaultDBUser          = "stakepool"
-	defaultListen          = ":8000"
-	defaultPoolEmail       = "admin@example.com"
-	defaultPoolFees        = 7.5
-	defaultPoolLink        = "https://forum.decred.org/threads/rfp-6-setup-and-operate-10-stake-pools.1361/"
-	defaultPublicPath      = "public"
-	defaultTemplatePath    = "views"
-	defaultSMTPHost        = ""
-	defaultMaxVotedTickets = 1000
-	defaultDescription     = ""
-	defaultDesignation     = ""
+	defaultBaseURL          = "http://127.0.0.1:8000"
+	defaultClosePoolMsg     = "The voting service is temporarily closed to new signups."
+	defaultConfigFilename   = "dcrstakepool.conf"
+	defaultLogLevel         = "info"
+	defaultLogDirname       = "logs"
+	defaultLogFilename      = "dcrstakepool.log"
+	defaultCookieSecure     = false
+	defaultCookieSameSite   = "lax"
+	defaultCookiePath       = "/"
+	defaultDBHost           = "localhost"
+	defaultDBName           = "stakepool"
+	defaultDBPort           = "3306"
+	defaultDBUser           = "stakepool"
+	defaultListen           = ":8000"
+	defaultPoolEmail        = "admin@example.com"
+	defaultPoolFees         = 7.5
+	defaultFeeMode          = models.FeeModeCommitment
+	defaultFeeDeadline      = time.Hour * 24
+	defaultPoolLink         = "https://forum.decred.org/threads/rfp-6-setup-and-operate-10-stake-pools.1361/"
+	defaultPublicPath       = "public"
+	defaultTemplatePath     = "views"
+	defaultSMTPHost         = ""
+	defaultMaxVotedTickets  = 1000
+	defaultFreezeVoteBits   = 1
+	defaultDescription      = ""
+	defaultDesignation      = ""
+	defaultAPITokenLifetime = time.Hour * 24 * 365
+	defaultEmailTokenLife   = time.Hour * 24
+	defaultDownloadLinkLife = time.Hour
+	defaultAdminResetLife   = time.Minute * 15
+	defaultLoginTokenLife   = time.Minute * 15
+	defaultRefreshTokenLife = time.Hour * 24 * 30
+	defaultEmailCooldown    = time.Hour * 48
+	defaultScriptGrace      = time.Hour * 24 * 30
+	defaultAutoCertDirname  = "autocert"
+	defaultArgon2Time       = 3
+	defaultArgon2Memory     = 64 * 1024
+	defaultArgon2Threads    = 4
+	defaultHTTPReadTimeout  = time.Second * 30
+	defaultHTTPWriteTimeout = time.Minute * 2
+	defaultHTTPIdleTimeout  = time.Minute * 2
+	defaultHTTPMaxHeader    = 64 * 1024
+	defaultMaxBodyBytes     = 64 * 1024
+	defaultMaxBulkBodyBytes = 1024 * 1024
+	defaultGoroutineWarn    = 10000
+	defaultVotingAccount    = "default"
+
+	defaultStakepooldKeepalive        = time.Minute
+	defaultStakepooldKeepaliveTimeout = time.Second * 20
+	defaultStakepooldMaxMessageSize   = 64 << 20
+	defaultStakepooldBreakerFailures  = 5
+	defaultStakepooldBreakerBackoff   = time.Second * 30
+	defaultMaxClockSkew               = time.Second * 30
+
+	defaultCaptchaWidth     = 257
+	defaultCaptchaHeight    = 127
+	defaultCaptchaDigits    = 6
+	defaultCaptchaAudioLang = "en"
+
+	defaultFeeWatchInterval = time.Hour * 6
+
+	defaultAlertMissedVotes       = 3
+	defaultAlertMissedVotesWindow = time.Hour
+	defaultAlertBackendDown       = time.Minute * 5
+	defaultAlertDBErrors          = 3
+
+	// minStakepooldKeepalive is the shortest keepalive interval accepted by
+	// stakepoold, which closes connections pinging more often.
+	minStakepooldKeepalive = time.Second * 10
+
+	// The bounds of the captcha image dimensions and number of digits, which
+	// keep the digits legible.
+	minCaptchaWidth  = 150
+	maxCaptchaWidth  = 1000
+	minCaptchaHeight = 60
+	maxCaptchaHeight = 500
+	minCaptchaDigits = 4
+	maxCaptchaDigits = 10
+
+	// minFeeWatchInterval is the shortest interval of the fee watcher, which
+	// looks up many transactions on dcrdata in each run.
+	minFeeWatchInterval = time.Minute * 10
+
+	// minStakepooldMaxMessageSize and maxStakepooldMaxMessageSize bound the
+	// maximum size of stakepoold messages.  The minimum is the default of
+	// gRPC.
+	minStakepooldMaxMessageSize = 4 << 20
+	maxStakepooldMaxMessageSize = 1 << 30
 )
 
 var (
@@ -57,6 +132,10 @@ var (
 	votingWalletVoteKey *hdkeychain.ExtendedKey
 )
 
+// activeNetParams is a pointer to the parameters specific to the
+// currently active decred network.
+var activeNetParams = &netparams.MainNet
+
 // runServiceCommand is only set to a real function on Windows.  It is used
 // to parse and execute service commands specified via the -s flag.
 var runServiceCommand func(string) error
@@ -65,47 +144,158 @@ var runServiceCommand func(string) error
 //
 // See loadConfig for details on the configuration load process.
 type config struct {
-	ShowVersion        bool    `short:"V" long:"version" description:"Display version information and exit"`
-	ConfigFile         string  `short:"C" long:"configfile" description:"Path to configuration file"`
-	LogDir             string  `long:"logdir" description:"Directory to log output."`
-	Listen             string  `long:"listen" description:"Listen for connections on the specified interface/port (default all interfaces port: 9113, testnet: 19113)"`
-	TestNet            bool    `long:"testnet" description:"Use the test network"`
-	SimNet             bool    `long:"simnet" description:"Use the simulation test network"`
-	DebugLevel         string  `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
-	APISecret          string  `long:"apisecret" description:"Secret string used to encrypt API tokens."`
-	BaseURL            string  `long:"baseurl" description:"BaseURL to use when sending links via email"`
-	ColdWalletExtPub   string  `long:"coldwalletextpub" description:"The extended public key for addresses to which voting service user fees are sent."`
-	ClosePool          bool    `long:"closepool" description:"Disable user registration actions (sign-ups and submitting addresses)"`
-	ClosePoolMsg       string  `long:"closepoolmsg" description:"Message to display when closepool is set."`
-	CookieSecret       string  `long:"cookiesecret" description:"Secret string used to encrypt session data."`
-	CookieSecure       bool    `long:"cookiesecure" description:"Set whether cookies can be sent in clear text or not."`
-	DBHost             string  `long:"dbhost" description:"Hostname for database connection"`
-	DBUser             string  `long:"dbuser" description:"Username for database connection"`
-	DBPassword         string  `long:"dbpassword" description:"Password for database connection"`
-	DBPort             string  `long:"dbport" description:"Port for database connection"`
-	DBName             string  `long:"dbname" description:"Name of database"`
-	PublicPath         string  `long:"publicpath" description:"Path to the public folder which contains css/fonts/images/javascript."`
-	TemplatePath       string  `long:"templatepath" description:"Path to the views folder which contains html files."`
-	PoolEmail          string  `long:"poolemail" description:"Email address to for support inquiries"`
-	PoolFees           float64 `long:"poolfees" description:"The per-ticket fees the user must send to the pool with their tickets"`
-	PoolLink           string  `long:"poollink" description:"URL for support inquiries such as forum, IRC, etc"`
-	RealIPHeader       string  `long:"realipheader" description:"The name of an HTTP request header containing the actual remote client IP address, typically set by a reverse proxy. An empty string (default) indicates to use net/Request.RemodeAddr."`
-	SMTPFrom           string  `long:"smtpfrom" description:"From address to use on outbound mail"`
-	SMTPHost           string  `long:"smtphost" description:"SMTP hostname/ip and port, e.g. mail.example.com:25"`
-	SMTPUsername       string  `long:"smtpusername" description:"SMTP username for authentication if required"`
-	SMTPPassword       string  `long:"smtppassword" description:"SMTP password for authentication if required"`
-	UseSMTPS           bool    `long:"usesmtps" description:"Connect to the SMTP server using smtps."`
-	SMTPSkipVerify     bool    `long:"smtpskipverify" description:"Skip SMTP TLS cert verification. Will only skip if SMTPCert is empty"`
-	SMTPCert           string  `long:"smtpcert" description:"Path for the smtp certificate file"`
-	SystemCerts        *x509.CertPool
-	StakepooldHosts    []string `long:"stakepooldhosts" description:"Hostnames for stakepoold servers"`
-	StakepooldCerts    []string `long:"stakepooldcerts" description:"Certificate paths for stakepoold servers"`
-	VotingWalletExtPub string   `long:"votingwalletextpub" description:"The extended public key of the default account of the voting wallet"`
-	AdminIPs           []string `long:"adminips" description:"Expected admin host"`
-	AdminUserIDs       []string `long:"adminuserids" description:"User IDs of users who are allowed to access administrative functions."`
-	MaxVotedTickets    int      `long:"maxvotedtickets" description:"Maximum number of voted tickets to show on tickets page."`
-	Description        string   `long:"description" description:"Operators own description of their VSP"`
-	Designation        string   `long:"designation" description:"VSP designation (eg. Alpha, Bravo, etc)"`
+	ShowVersion          bool          `short:"V" long:"version" description:"Display version information and exit"`
+	ConfigFile           string        `short:"C" long:"configfile" description:"Path to configuration file"`
+	ValidateConfig       bool          `long:"validateconfig" description:"Check the configuration, print a JSON report to stdout and exit with status 1 when a check fails"`
+	ValidateProbe        bool          `long:"validateprobe" description:"With validateconfig, also check that the database, SMTP server and stakepoold instances accept connections"`
+	ExportUsers          string        `long:"exportusers" description:"Write the users who submitted an address, with their scripts and tickets, to this JSON file to migrate them to another voting service, and exit"`
+	ImportUsers          string        `long:"importusers" description:"Add the users of a JSON file written by exportusers of another voting service whose voting wallets are also used by this one, and exit"`
+	LogDir               string        `long:"logdir" description:"Directory to log output."`
+	Listen               string        `long:"listen" description:"Listen for connections on the specified interface/port (default all interfaces port: 9113, testnet: 19113)"`
+	Network              string        `long:"network" description:"Network to use {mainnet, testnet, simnet} (default: mainnet)"`
+	TestNet              bool          `long:"testnet" description:"DEPRECATED: Use the test network, use network=testnet instead"`
+	SimNet               bool          `long:"simnet" description:"DEPRECATED: Use the simulation test network, use network=simnet instead"`
+	DebugLevel           string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
+	APISecret            string        `long:"apisecret" description:"Secret string used to encrypt API tokens."`
+	APISecretPrevious    []string      `long:"apisecretprevious" description:"Retired API secrets whose tokens are still accepted until they expire (may be repeated)"`
+	APITokenLifetime     time.Duration `long:"apitokenlifetime" description:"Lifetime of newly issued API tokens"`
+	LoginTokenLifetime   time.Duration `long:"apilogintokenlifetime" description:"Lifetime of the API tokens issued by the API login to apps"`
+	RefreshTokenLifetime time.Duration `long:"apirefreshtokenlifetime" description:"Lifetime of the refresh tokens issued by the API login, after which apps must log in again"`
+	EmailTokenLifetime   time.Duration `long:"emailtokenlifetime" description:"Lifetime of email verification links sent to new users"`
+	DownloadLinkLifetime time.Duration `long:"downloadlinklifetime" description:"Lifetime of the signed download links of admin exports"`
+	EmailCooldown        time.Duration `long:"emailchangecooldown" description:"Block changing the read-only API token and submitting a voting address for this long after the email address of a user was changed. 0 disables the cooldown."`
+	EmailConfirmOld      bool          `long:"emailchangeconfirmold" description:"Require email changes to be confirmed from the current email address of the user as well as from the new one"`
+	UnverifiedMaxAge     time.Duration `long:"unverifiedmaxage" description:"Delete accounts whose email address has not been verified this long after registration. 0 keeps them indefinitely."`
+	ScriptExpiry         time.Duration `long:"scriptexpiry" description:"Disable the multisig scripts which no tickets were bought with this long after they were set up, after warning their users by email. Users can reactivate them. 0 keeps them indefinitely. Requires smtphost."`
+	ScriptExpiryGrace    time.Duration `long:"scriptexpirygrace" description:"Time between warning a user by email that their unused multisig script expires and disabling it"`
+	LegacyAPITokensUntil string        `long:"legacyapitokensuntil" description:"Date (YYYY-MM-DD) after which API tokens issued without an expiry are rejected. Empty accepts them indefinitely."`
+	LegacyAPITokenCutoff time.Time
+	Cookies              system.CookieConfig
+	BaseURL              string  `long:"baseurl" description:"BaseURL to use when sending links via email"`
+	ColdWalletExtPub     string  `long:"coldwalletextpub" description:"The extended public key for addresses to which voting service user fees are sent."`
+	ClosePool            bool    `long:"closepool" description:"Disable user registration actions (sign-ups and submitting addresses)"`
+	ClosePoolMsg         string  `long:"closepoolmsg" description:"Message to display when closepool is set."`
+	InviteOnly           bool    `long:"inviteonly" description:"Require an invite code generated by an admin to register a new account"`
+	CookieSecret         string  `long:"cookiesecret" description:"Secret string used to encrypt session data."`
+	CookieSecure         bool    `long:"cookiesecure" description:"Set whether cookies can be sent in clear text or not."`
+	CookieSameSite       string  `long:"cookiesamesite" description:"SameSite attribute of the session and CSRF cookies: default, lax, strict or none. None requires cookiesecure."`
+	CookieDomain         string  `long:"cookiedomain" description:"Domain attribute of the session and CSRF cookies, e.g. example.com to share them with its subdomains. Empty restricts them to the host which set them."`
+	CookiePath           string  `long:"cookiepath" description:"Path attribute of the session and CSRF cookies, e.g. the path the voting service is served under by a reverse proxy."`
+	CookiePrefix         string  `long:"cookieprefix" description:"Prefix of the names of the session and CSRF cookies, e.g. __Host- or __Secure-."`
+	DBHost               string  `long:"dbhost" description:"Hostname for database connection"`
+	DBUser               string  `long:"dbuser" description:"Username for database connection"`
+	DBPassword           string  `long:"dbpassword" description:"Password for database connection"`
+	DBPort               string  `long:"dbport" description:"Port for database connection"`
+	DBName               string  `long:"dbname" description:"Name of database"`
+	DBReplicaDSN         string  `long:"dbreplicadsn" description:"Data source name of a read-only MySQL replica used for stats, user lists and ticket history, e.g. user:password@(host:3306)/stakepool?charset=utf8mb4. The primary database is used while the replica is unavailable."`
+	CreateMissingIndexes bool    `long:"createmissingindexes" description:"Create the database indexes found missing by the schema check on startup instead of only warning about the queries they slow down"`
+	PublicPath           string  `long:"publicpath" description:"Path to the public folder which contains css/fonts/images/javascript."`
+	TemplatePath         string  `long:"templatepath" description:"Path to the views folder which contains html files."`
+	PoolEmail            string  `long:"poolemail" description:"Email address to for support inquiries"`
+	PoolFees             float64 `long:"poolfees" description:"The per-ticket fees the user must send to the pool with their tickets"`
+	PoolLink             string  `long:"poollink" description:"URL for support inquiries such as forum, IRC, etc"`
+	RealIPHeader         string  `long:"realipheader" description:"The name of an HTTP request header containing the actual remote client IP address, typically set by a reverse proxy. An empty string (default) indicates to use net/Request.RemodeAddr."`
+	SMTPFrom             string  `long:"smtpfrom" description:"From address to use on outbound mail"`
+	SMTPHost             string  `long:"smtphost" description:"SMTP hostname/ip and port, e.g. mail.example.com:25"`
+	SMTPUsername         string  `long:"smtpusername" description:"SMTP username for authentication if required"`
+	SMTPPassword         string  `long:"smtppassword" description:"SMTP password for authentication if required"`
+	UseSMTPS             bool    `long:"usesmtps" description:"Connect to the SMTP server using smtps."`
+	SMTPSkipVerify       bool    `long:"smtpskipverify" description:"Skip SMTP TLS cert verification. Will only skip if SMTPCert is empty"`
+	SMTPCert             string  `long:"smtpcert" description:"Path for the smtp certificate file"`
+	SystemCerts          *x509.CertPool
+	StakepooldHosts      []string `long:"stakepooldhosts" description:"Hostnames for stakepoold servers"`
+	StakepooldCerts      []string `long:"stakepooldcerts" description:"Certificate paths for stakepoold servers"`
+	VotingWalletExtPub   string   `long:"votingwalletextpub" description:"The extended public key of the votingaccount account of the voting wallet"`
+	VotingAccount        string   `long:"votingaccount" description:"Name of the account of the voting wallets the ticket addresses of users are derived from"`
+	VotingBranch         uint32   `long:"votingbranch" description:"Branch of votingaccount the ticket addresses of users are derived from {0 external, 1 internal}"`
+	AdminIPs             []string `long:"adminips" description:"Expected admin host"`
+	TorMode              bool     `long:"tormode" description:"Deploy as a Tor hidden service: make no requests to external services such as dcrdata, link to no clearnet block explorer and restrict administrative functions by adminuserids only since all clients connect from the local Tor daemon. adminips is ignored."`
+	AdminUserIDs         []string `long:"adminuserids" description:"User IDs of users who are allowed to access administrative functions."`
+	Maintenance          bool     `long:"maintenance" description:"Start in maintenance mode, which serves the maintenancepage to everyone but admins and maintenanceallowips, e.g. during a migration. Admins can toggle it at runtime with the maintenance API command."`
+	ReadOnly             bool     `long:"readonly" description:"Start in read-only mode, which refuses all changes with a page asking users to try again later while pages and the API keep serving, e.g. during a planned database failover. It is also entered automatically while the database refuses writes. Admins can toggle it at runtime with the readonly API command."`
+	MaintenancePage      string   `long:"maintenancepage" description:"Path to a static HTML page served in maintenance mode. A built-in page is served when empty."`
+	MaintenancePageHTML  string
+	MaintenanceAllowIPs  []string `long:"maintenanceallowips" description:"Client IPs which are served as usual in maintenance mode, besides adminips. Multiple values are separated by a comma."`
+	MaxVotedTickets      int      `long:"maxvotedtickets" description:"Maximum number of voted tickets to show on tickets page."`
+	MaxUserLiveTickets   int      `long:"maxuserlivetickets" description:"Limit of live tickets per user set with maxuserlivetickets on stakepoold, shown on the address and tickets pages. 0 shows no limit."`
+	RejectReusedAddrs    bool     `long:"rejectreusedaddrs" description:"Reject user pubkey addresses which were already submitted by another account or used on the blockchain instead of only warning the user."`
+	HideAgendaStats      bool     `long:"hideagendastats" description:"Do not publish the anonymous breakdown of the vote choices of users per agenda on the stats and voting pages and the agendastats API command."`
+	Webhooks             bool     `long:"webhooks" description:"Allow users to register a webhook URL on their settings page which is sent signed JSON events when their tickets go live, vote, are missed, expire or are ignored as low fee tickets."`
+	FreezeVoteBits       uint16   `long:"freezevotebits" description:"Vote bits every ticket votes with while an admin freezes the voting preferences of all users, e.g. during a consensus emergency. 1 approves the previous block and abstains on all agendas."`
+	ForcedChoice         []string `long:"forcedchoice" description:"Choice every ticket votes with on an agenda regardless of the voting preferences of users, given as agendaid:choiceid, e.g. to abstain on an agenda. The agenda is shown as locked on the voting page. May be repeated."`
+	ForcedChoices        map[string]string
+	Description          string   `long:"description" description:"Operators own description of their VSP"`
+	Designation          string   `long:"designation" description:"VSP designation (eg. Alpha, Bravo, etc)"`
+	TLSCert              string   `long:"tlscert" description:"Path to a TLS certificate file. Serves HTTPS on the listen address when set together with tlskey."`
+	TLSKey               string   `long:"tlskey" description:"Path to the TLS key file for tlscert"`
+	AutoCertHosts        []string `long:"autocerthost" description:"Hostname to obtain a TLS certificate for using ACME (Let's Encrypt). Serves HTTPS on the listen address. May be repeated."`
+	AutoCertCacheDir     string   `long:"autocertcachedir" description:"Directory to store certificates obtained using ACME"`
+	AutoCertEmail        string   `long:"autocertemail" description:"Contact email address given to the ACME certificate authority"`
+	HTTPRedirectListen   string   `long:"httpredirectlisten" description:"Listen for plain HTTP connections on the specified interface/port and redirect them to HTTPS, e.g. :80. Required to answer ACME HTTP challenges."`
+	TOSVersion           string   `long:"tosversion" description:"Version of the terms of service users must accept to register and use the voting service. Changing it requires all users to accept the new version. Empty disables terms of service acceptance."`
+	TOSURL               string   `long:"tosurl" description:"URL of the terms of service document for tosversion"`
+	Argon2Time           uint32   `long:"argon2time" description:"Number of passes over the memory used by argon2id when hashing passwords"`
+	Argon2Memory         uint32   `long:"argon2memory" description:"Memory in KiB used by argon2id when hashing passwords"`
+	Argon2Threads        uint8    `long:"argon2threads" description:"Number of threads used by argon2id when hashing passwords"`
+
+	// Fee payment
+	FeeMode            string        `long:"feemode" description:"How users pay the pool fees {commitment, deferred} -- With deferred, tickets do not commit to the fee and users pay a separate fee transaction for each ticket from the tickets page. Must match the feemode of stakepoold."`
+	FeePaymentDeadline time.Duration `long:"feepaymentdeadline" description:"With feemode=deferred, how long users have to submit the fee transaction of a ticket after it is first listed on the tickets page"`
+
+	// Operator alerts
+	TelegramToken    string        `long:"telegramtoken" description:"Token of a Telegram bot which sends critical alerts, such as all stakepoold instances being unreachable, to operators"`
+	TelegramChatID   string        `long:"telegramchatid" description:"Telegram chat, group or channel the bot of telegramtoken sends alerts to"`
+	MatrixHomeServer string        `long:"matrixhomeserver" description:"Address of the homeserver of a Matrix bot account which sends critical alerts to operators, e.g. https://matrix.example.com"`
+	MatrixToken      string        `long:"matrixtoken" description:"Access token of the Matrix bot account"`
+	MatrixRoomID     string        `long:"matrixroomid" description:"Matrix room the bot of matrixtoken sends alerts to, e.g. !abcdefg:example.com"`
+	AlertCooldown    time.Duration `long:"alertcooldown" description:"Minimum time between two alerts of the same kind"`
+
+	// Alert thresholds
+	AlertMissedVotes       int64         `long:"alertmissedvotes" description:"Number of tickets missed within alertmissedvoteswindow which fires an alert. 0 disables the alert."`
+	AlertMissedVotesWindow time.Duration `long:"alertmissedvoteswindow" description:"Period the missed tickets of alertmissedvotes are counted over"`
+	AlertBackendDown       time.Duration `long:"alertbackenddown" description:"How long a stakepoold instance must be unreachable to fire an alert. 0 disables the alert."`
+	AlertDBErrors          int           `long:"alertdberrors" description:"Number of consecutive failed database checks, made every minute, which fires an alert. 0 disables the alert."`
+
+	// HTTP server limits
+	HTTPReadTimeout    time.Duration `long:"httpreadtimeout" description:"Maximum duration for reading an entire HTTP request, including the body"`
+	HTTPWriteTimeout   time.Duration `long:"httpwritetimeout" description:"Maximum duration before timing out writes of an HTTP response"`
+	HTTPIdleTimeout    time.Duration `long:"httpidletimeout" description:"Maximum time to wait for the next request on a keep-alive HTTP connection"`
+	HTTPMaxHeaderBytes int           `long:"httpmaxheaderbytes" description:"Maximum size in bytes of HTTP request headers"`
+	MaxBodyBytes       int64         `long:"maxbodybytes" description:"Maximum size in bytes of the body of form and API posts other than those of maxbulkbodybytes. Larger requests are rejected."`
+	MaxBulkBodyBytes   int64         `long:"maxbulkbodybytes" description:"Maximum size in bytes of the body of the posts importing voting preferences and ticket history and of the ticket lists of the admin tickets page"`
+
+	// Diagnostics
+	Profile       string `long:"profile" description:"Serve pprof profiles and expvar variables on this port of localhost, or loopback host:port, to diagnose leaks and load problems"`
+	GoroutineWarn int    `long:"goroutinewarn" description:"Log a warning when more goroutines than this are running, which suggests leaking handlers. 0 disables the warning."`
+
+	// stakepoold connection health
+	StakepooldKeepalive                    time.Duration `long:"stakepooldkeepalive" description:"Ping stakepoold after this much inactivity on a connection to detect connections which broke silently (minimum 10s). 0 disables the pings."`
+	StakepooldKeepaliveTimeout             time.Duration `long:"stakepooldkeepalivetimeout" description:"Close and reconnect stakepoold connections when a keepalive ping is not answered within this time"`
+	StakepooldKeepalivePermitWithoutStream bool          `long:"stakepooldkeepalivepermitwithoutstream" description:"Also ping stakepoold while no RPCs are in progress. Requires grpckeepalivepermitwithoutstream on stakepoold."`
+	StakepooldMaxMessageSize               int           `long:"stakepooldmaxmessagesize" description:"Maximum size in bytes of the messages received from and sent to stakepoold (4 MiB to 1 GiB). Requests are also limited to the grpcmaxmessagesize of each stakepoold."`
+	StakepooldBreakerFailures              int           `long:"stakepooldbreakerfailures" description:"Stop sending RPCs to a stakepoold after this many consecutive RPCs failed because it is unreachable or unresponsive, serving from the other instances. 0 disables the circuit breakers."`
+	StakepooldBreakerBackoff               time.Duration `long:"stakepooldbreakerbackoff" description:"How long RPCs to a stakepoold are stopped for before a single RPC probes whether it recovered. Doubles after every failed probe, up to 10m."`
+	MaxClockSkew                           time.Duration `long:"maxclockskew" description:"Warn on the status page when the clock of a stakepoold or of the dcrd of its wallet is off by more than this, and accept API tokens which expired or were issued this long ago or ahead"`
+
+	// captcha
+	CaptchaWidth     int    `long:"captchawidth" description:"Width in pixels of the captcha images (150 to 1000)"`
+	CaptchaHeight    int    `long:"captchaheight" description:"Height in pixels of the captcha images (60 to 500)"`
+	CaptchaDigits    int    `long:"captchadigits" description:"Number of digits of the captchas, which makes them harder to guess (4 to 10)"`
+	CaptchaAudio     bool   `long:"captchaaudio" description:"Offer the captchas as audio recordings of the digits for visually impaired users"`
+	CaptchaAudioLang string `long:"captchaaudiolang" description:"Language of the audio captchas {en, ja, ru, zh}"`
+
+	// Fee watcher
+	FeeWatch         bool          `long:"feewatch" description:"Periodically reconcile the fees of all tickets against the payments to the fee addresses found on dcrdata, for the fee report on the admin fees page"`
+	FeeWatchURL      string        `long:"feewatchurl" description:"URL of the dcrdata instance the fee watcher looks up transactions on, e.g. a private instance (default: the public dcrdata of the network, required in tormode)"`
+	FeeWatchInterval time.Duration `long:"feewatchinterval" description:"How often the fee watcher updates the fee report (at least 10m)"`
+
+	// Support
+	AdminResetLinkLifetime time.Duration `long:"adminresetlinklifetime" description:"Lifetime of the password reset links admins issue on behalf of users from the admin user page (at most 1h, the lifetime of the links users request by email)"`
+
+	// Secret references
+	SecretsDir string `long:"secretsdir" description:"Directory holding one file per secret, for file:<name> references in place of sensitive option values"`
+	VaultAddr  string `long:"vaultaddr" description:"Address of the HashiCorp Vault server, for vault:<path>#<key> references in place of sensitive option values"`
+	VaultToken string `long:"vaulttoken" description:"Token used to authenticate to Vault, which may itself be an env: or file: reference (default: VAULT_TOKEN environment variable)"`
 }
 
 // serviceOptions defines the configuration options for the daemon as a service
@@ -256,6 +446,16 @@ func fileExists(name string) bool {
 	return true
 }
 
+// minStakepooldHosts returns the number of stakepoold servers a voting service
+// on the passed network needs, at least two on mainnet so that tickets are
+// still voted when one of them is down.
+func minStakepooldHosts(params *netparams.Params) int {
+	if params.Net == netparams.MainNet.Net {
+		return 2
+	}
+	return 1
+}
+
 // validate pub vote and fee keys as belonging to the network
 func (c *config) parsePubKeys(params *chaincfg.Params) error {
 	// Parse the extended public key and the pool fees.
@@ -272,6 +472,41 @@ func (c *config) parsePubKeys(params *chaincfg.Params) error {
 	return nil
 }
 
+// resolveSecrets replaces the values of the sensitive options which reference
+// a secret, given as env:<variable>, file:<name> or vault:<path>#<key>, with
+// the referenced secret.
+func (c *config) resolveSecrets() error {
+	var dir string
+	if c.SecretsDir != "" {
+		dir = cleanAndExpandPath(c.SecretsDir)
+	}
+	r, err := secrets.NewResolver(dir, c.VaultAddr, c.VaultToken)
+	if err != nil {
+		return err
+	}
+
+	names := []string{"apisecret", "cookiesecret", "dbpassword",
+		"dbreplicadsn", "smtppassword", "telegramtoken", "matrixtoken"}
+	values := []*string{&c.APISecret, &c.CookieSecret, &c.DBPassword,
+		&c.DBReplicaDSN, &c.SMTPPassword, &c.TelegramToken, &c.MatrixToken}
+	for i := range c.APISecretPrevious {
+		names = append(names, "apisecretprevious")
+		values = append(values, &c.APISecretPrevious[i])
+	}
+	return r.ResolveAll(names, values)
+}
+
+// alertConfig returns the options of the bots which send alerts to operators.
+func (c *config) alertConfig() *notify.Config {
+	return &notify.Config{
+		TelegramToken:    c.TelegramToken,
+		TelegramChatID:   c.TelegramChatID,
+		MatrixHomeServer: c.MatrixHomeServer,
+		MatrixToken:      c.MatrixToken,
+		MatrixRoomID:     c.MatrixRoomID,
+	}
+}
+
 // newConfigParser returns a new command line flags parser.
 func newConfigParser(cfg *config, so *serviceOptions, options flags.Options) *flags.Parser {
 	parser := flags.NewParser(cfg, options)
@@ -285,10 +520,10 @@ func newConfigParser(cfg *config, so *serviceOptions, options flags.Options) *fl
 // line options.
 //
 // The configuration proceeds as follows:
-// 	1) Start with a default config with sane settings
-// 	2) Pre-parse the command line to check for an alternative config file
-// 	3) Load configuration file overwriting defaults with any specified options
-// 	4) Parse CLI options and overwrite/add any specified options
+//  1. Start with a default config with sane settings
+//  2. Pre-parse the command line to check for an alternative config file
+//  3. Load configuration file overwriting defaults with any specified options
+//  4. Parse CLI options and overwrite/add any specified options
 //
 // The above results in daemon functioning properly without any config settings
 // while still allowing the user to override settings with config files and
@@ -296,27 +531,77 @@ func newConfigParser(cfg *config, so *serviceOptions, options flags.Options) *fl
 func loadConfig() (*config, []string, error) {
 	// Default config.
 	cfg := config{
-		BaseURL:         defaultBaseURL,
-		ClosePool:       false,
-		ClosePoolMsg:    defaultClosePoolMsg,
-		ConfigFile:      defaultConfigFile,
-		DebugLevel:      defaultLogLevel,
-		LogDir:          defaultLogDir,
-		CookieSecure:    defaultCookieSecure,
-		DBHost:          defaultDBHost,
-		DBName:          defaultDBName,
-		DBPort:          defaultDBPort,
-		DBUser:          defaultDBUser,
-		Listen:          defaultListen,
-		PoolEmail:       defaultPoolEmail,
-		PoolFees:        defaultPoolFees,
-		PoolLink:        defaultPoolLink,
-		PublicPath:      defaultPublicPath,
-		TemplatePath:    defaultTemplatePath,
-		SMTPHost:        defaultSMTPHost,
-		MaxVotedTickets: defaultMaxVotedTickets,
-		Description:     defaultDescription,
-		Designation:     defaultDesignation,
+		BaseURL:            defaultBaseURL,
+		ClosePool:          false,
+		ClosePoolMsg:       defaultClosePoolMsg,
+		ConfigFile:         defaultConfigFile,
+		DebugLevel:         defaultLogLevel,
+		LogDir:             defaultLogDir,
+		CookieSecure:       defaultCookieSecure,
+		CookieSameSite:     defaultCookieSameSite,
+		CookiePath:         defaultCookiePath,
+		DBHost:             defaultDBHost,
+		DBName:             defaultDBName,
+		DBPort:             defaultDBPort,
+		DBUser:             defaultDBUser,
+		Listen:             defaultListen,
+		PoolEmail:          defaultPoolEmail,
+		PoolFees:           defaultPoolFees,
+		FeeMode:            defaultFeeMode,
+		FeePaymentDeadline: defaultFeeDeadline,
+		AlertCooldown:      notify.DefaultCooldown,
+		PoolLink:           defaultPoolLink,
+		PublicPath:         defaultPublicPath,
+		TemplatePath:       defaultTemplatePath,
+		SMTPHost:           defaultSMTPHost,
+		MaxVotedTickets:    defaultMaxVotedTickets,
+		FreezeVoteBits:     defaultFreezeVoteBits,
+		APITokenLifetime:   defaultAPITokenLifetime,
+		EmailTokenLifetime: defaultEmailTokenLife,
+		EmailCooldown:      defaultEmailCooldown,
+		ScriptExpiryGrace:  defaultScriptGrace,
+		AutoCertCacheDir:   filepath.Join(dcrstakepoolHomeDir, defaultAutoCertDirname),
+		Description:        defaultDescription,
+		Designation:        defaultDesignation,
+		Argon2Time:         defaultArgon2Time,
+		Argon2Memory:       defaultArgon2Memory,
+		Argon2Threads:      defaultArgon2Threads,
+		HTTPReadTimeout:    defaultHTTPReadTimeout,
+		HTTPWriteTimeout:   defaultHTTPWriteTimeout,
+		HTTPIdleTimeout:    defaultHTTPIdleTimeout,
+		HTTPMaxHeaderBytes: defaultHTTPMaxHeader,
+		MaxBodyBytes:       defaultMaxBodyBytes,
+		MaxBulkBodyBytes:   defaultMaxBulkBodyBytes,
+		VotingAccount:      defaultVotingAccount,
+
+		StakepooldKeepalive:        defaultStakepooldKeepalive,
+		StakepooldKeepaliveTimeout: defaultStakepooldKeepaliveTimeout,
+		StakepooldMaxMessageSize:   defaultStakepooldMaxMessageSize,
+		StakepooldBreakerFailures:  defaultStakepooldBreakerFailures,
+		StakepooldBreakerBackoff:   defaultStakepooldBreakerBackoff,
+		MaxClockSkew:               defaultMaxClockSkew,
+
+		CaptchaWidth:     defaultCaptchaWidth,
+		CaptchaHeight:    defaultCaptchaHeight,
+		CaptchaDigits:    defaultCaptchaDigits,
+		CaptchaAudioLang: defaultCaptchaAudioLang,
+
+		FeeWatchInterval: defaultFeeWatchInterval,
+
+		AlertMissedVotes:       defaultAlertMissedVotes,
+		AlertMissedVotesWindow: defaultAlertMissedVotesWindow,
+		AlertBackendDown:       defaultAlertBackendDown,
+		AlertDBErrors:          defaultAlertDBErrors,
+
+		// Short-lived tokens of the API login.
+		LoginTokenLifetime:   defaultLoginTokenLife,
+		RefreshTokenLifetime: defaultRefreshTokenLife,
+
+		DownloadLinkLifetime: defaultDownloadLinkLife,
+
+		AdminResetLinkLifetime: defaultAdminResetLife,
+
+		GoroutineWarn: defaultGoroutineWarn,
 	}
 
 	// Service options which are only added on Windows.
@@ -361,7 +646,8 @@ func loadConfig() (*config, []string, error) {
 
 	// Load additional config from file.
 	parser := newConfigParser(&cfg, &serviceOpts, flags.Default)
-	if !(preCfg.SimNet) || preCfg.ConfigFile != defaultConfigFile {
+	simNet := preCfg.SimNet || preCfg.Network == netparams.SimNetName
+	if !simNet || preCfg.ConfigFile != defaultConfigFile {
 		err := flags.NewIniParser(parser).ParseFile(preCfg.ConfigFile)
 		if err != nil {
 			var e *os.PathError
@@ -406,36 +692,20 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// Multiple networks can't be selected simultaneously.
-	var numNets int
-
-	// Assign active network params and min required backend servers
-	var minRequiredBackendServers = 2
-	activeNetParams = &mainNetParams
-	if cfg.TestNet {
-		numNets++
-		activeNetParams = &testNet3Params
-		minRequiredBackendServers = 1
-	}
-	if cfg.SimNet {
-		numNets++
-		// Also disable dns seeding on the simulation test network.
-		activeNetParams = &simNetParams
-		minRequiredBackendServers = 1
-	}
-	if numNets > 1 {
-		str := "%s: The testnet and simnet params can't be " +
-			"used together -- choose one of the three"
-		err := fmt.Errorf(str, funcName)
+	// Assign active network params and min required backend servers.
+	activeNetParams, err = netparams.Select(cfg.Network, cfg.TestNet, cfg.SimNet)
+	if err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
 		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr, usageMessage)
 		return nil, nil, err
 	}
+	var minRequiredBackendServers = minStakepooldHosts(activeNetParams)
 
 	// Append the network type to the log directory so it is "namespaced"
 	// per network in the same fashion as the data directory.
 	cfg.LogDir = cleanAndExpandPath(cfg.LogDir)
-	cfg.LogDir = filepath.Join(cfg.LogDir, netName(activeNetParams))
+	cfg.LogDir = filepath.Join(cfg.LogDir, activeNetParams.DirName())
 
 	// Special show command to list supported subsystems and exit.
 	if cfg.DebugLevel == "show" {
@@ -455,6 +725,14 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Replace references to secrets in sensitive options with the secrets
+	// so that they are validated below.
+	if err := cfg.resolveSecrets(); err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	if cfg.APISecret == "" {
 		str := "%s: APIsecret is not set in config"
 		err := fmt.Errorf(str, funcName)
@@ -462,6 +740,383 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	if cfg.APITokenLifetime <= 0 {
+		str := "%s: apitokenlifetime must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.LoginTokenLifetime <= 0 || cfg.LoginTokenLifetime > cfg.APITokenLifetime {
+		str := "%s: apilogintokenlifetime must be positive and at most " +
+			"apitokenlifetime"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.RefreshTokenLifetime < cfg.LoginTokenLifetime {
+		str := "%s: apirefreshtokenlifetime must be at least " +
+			"apilogintokenlifetime"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.EmailTokenLifetime <= 0 {
+		str := "%s: emailtokenlifetime must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.DownloadLinkLifetime <= 0 {
+		str := "%s: downloadlinklifetime must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.AdminResetLinkLifetime <= 0 || cfg.AdminResetLinkLifetime > time.Hour {
+		str := "%s: adminresetlinklifetime must be positive and at most 1h"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.UnverifiedMaxAge < 0 {
+		str := "%s: unverifiedmaxage must not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.EmailCooldown < 0 {
+		str := "%s: emailchangecooldown must not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.ScriptExpiry < 0 || cfg.ScriptExpiryGrace <= 0 {
+		str := "%s: scriptexpiry must not be negative and " +
+			"scriptexpirygrace must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.ScriptExpiry > 0 && cfg.SMTPHost == "" {
+		str := "%s: scriptexpiry requires smtphost to warn users"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.LegacyAPITokensUntil != "" {
+		cfg.LegacyAPITokenCutoff, err = time.Parse("2006-01-02",
+			cfg.LegacyAPITokensUntil)
+		if err != nil {
+			str := "%s: invalid legacyapitokensuntil date: %v"
+			err := fmt.Errorf(str, funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	sameSite, err := system.ParseSameSite(cfg.CookieSameSite)
+	if err != nil {
+		str := "%s: invalid cookiesamesite: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	cfg.Cookies = system.CookieConfig{
+		Secure:     cfg.CookieSecure,
+		SameSite:   sameSite,
+		Domain:     cfg.CookieDomain,
+		Path:       cfg.CookiePath,
+		NamePrefix: cfg.CookiePrefix,
+	}
+	if err := cfg.Cookies.Validate(); err != nil {
+		str := "%s: invalid cookie configuration: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.FreezeVoteBits&1 == 0 {
+		str := "%s: freezevotebits must approve the previous block"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	cfg.ForcedChoices, err = helpers.ParseForcedChoices(activeNetParams.Params,
+		cfg.ForcedChoice)
+	if err != nil {
+		str := "%s: invalid forcedchoice: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		str := "%s: tlscert and tlskey must be set together"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.TLSCert != "" && len(cfg.AutoCertHosts) > 0 {
+		str := "%s: tlscert and autocerthost may not be used together"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.TLSCert != "" || len(cfg.AutoCertHosts) > 0 {
+		if cfg.TLSCert != "" {
+			cfg.TLSCert = cleanAndExpandPath(cfg.TLSCert)
+			cfg.TLSKey = cleanAndExpandPath(cfg.TLSKey)
+		}
+		cfg.AutoCertCacheDir = cleanAndExpandPath(cfg.AutoCertCacheDir)
+		if !cfg.CookieSecure {
+			log.Warn("Serving HTTPS but cookiesecure is not set")
+		}
+	} else if cfg.HTTPRedirectListen != "" {
+		str := "%s: httpredirectlisten requires tlscert or autocerthost"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.TorMode {
+		if len(cfg.AutoCertHosts) > 0 {
+			str := "%s: autocerthost may not be used with tormode since " +
+				"onion services cannot be validated by ACME"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.Webhooks {
+			str := "%s: webhooks may not be used with tormode since " +
+				"onion services make no requests to external services"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if len(cfg.AdminIPs) > 0 {
+			log.Warn("adminips is ignored in tormode")
+		}
+		u, err := url.Parse(cfg.BaseURL)
+		if err != nil || !strings.HasSuffix(u.Hostname(), ".onion") {
+			log.Warnf("baseurl %q is not an onion address; links sent "+
+				"by email will not point to the hidden service", cfg.BaseURL)
+		}
+	}
+
+	if cfg.FeeMode != models.FeeModeCommitment &&
+		cfg.FeeMode != models.FeeModeDeferred {
+		str := "%s: feemode must be %s or %s, not %q"
+		err := fmt.Errorf(str, funcName, models.FeeModeCommitment,
+			models.FeeModeDeferred, cfg.FeeMode)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.FeePaymentDeadline <= 0 {
+		str := "%s: feepaymentdeadline must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if _, err := cfg.alertConfig().Notifiers(); err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.AlertCooldown < 0 {
+		str := "%s: alertcooldown may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.AlertMissedVotes < 0 || cfg.AlertBackendDown < 0 ||
+		cfg.AlertDBErrors < 0 {
+		str := "%s: alertmissedvotes, alertbackenddown and alertdberrors " +
+			"may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.AlertMissedVotes > 0 && cfg.AlertMissedVotesWindow <= 0 {
+		str := "%s: alertmissedvoteswindow must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.MaxUserLiveTickets < 0 {
+		str := "%s: maxuserlivetickets may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.TOSVersion != "" && cfg.TOSURL == "" {
+		str := "%s: tosversion requires tosurl to be set"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.Argon2Time < 1 || cfg.Argon2Threads < 1 {
+		str := "%s: argon2time and argon2threads must be at least 1"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.Argon2Memory < 8*uint32(cfg.Argon2Threads) {
+		str := "%s: argon2memory must be at least 8 KiB per argon2thread"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.HTTPReadTimeout < 0 || cfg.HTTPWriteTimeout < 0 ||
+		cfg.HTTPIdleTimeout < 0 {
+		str := "%s: httpreadtimeout, httpwritetimeout and httpidletimeout " +
+			"may not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.StakepooldKeepalive != 0 && cfg.StakepooldKeepalive < minStakepooldKeepalive {
+		str := "%s: stakepooldkeepalive must be 0 or at least %v"
+		err := fmt.Errorf(str, funcName, minStakepooldKeepalive)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.StakepooldKeepalive > 0 && cfg.StakepooldKeepaliveTimeout <= 0 {
+		str := "%s: stakepooldkeepalivetimeout must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.StakepooldMaxMessageSize < minStakepooldMaxMessageSize ||
+		cfg.StakepooldMaxMessageSize > maxStakepooldMaxMessageSize {
+		str := "%s: stakepooldmaxmessagesize must be between %d and %d"
+		err := fmt.Errorf(str, funcName, minStakepooldMaxMessageSize,
+			maxStakepooldMaxMessageSize)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.StakepooldBreakerFailures < 0 {
+		str := "%s: stakepooldbreakerfailures must not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.StakepooldBreakerFailures > 0 && cfg.StakepooldBreakerBackoff <= 0 {
+		str := "%s: stakepooldbreakerbackoff must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.MaxClockSkew < 0 || cfg.MaxClockSkew >= cfg.LoginTokenLifetime {
+		str := "%s: maxclockskew must not be negative and must be shorter " +
+			"than apilogintokenlifetime"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.CaptchaWidth < minCaptchaWidth || cfg.CaptchaWidth > maxCaptchaWidth ||
+		cfg.CaptchaHeight < minCaptchaHeight || cfg.CaptchaHeight > maxCaptchaHeight {
+		str := "%s: captchawidth must be between %d and %d and " +
+			"captchaheight between %d and %d"
+		err := fmt.Errorf(str, funcName, minCaptchaWidth, maxCaptchaWidth,
+			minCaptchaHeight, maxCaptchaHeight)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.CaptchaDigits < minCaptchaDigits || cfg.CaptchaDigits > maxCaptchaDigits {
+		str := "%s: captchadigits must be between %d and %d"
+		err := fmt.Errorf(str, funcName, minCaptchaDigits, maxCaptchaDigits)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	switch cfg.CaptchaAudioLang {
+	case "en", "ja", "ru", "zh":
+	default:
+		str := "%s: captchaaudiolang must be one of en, ja, ru or zh"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.FeeWatch {
+		if cfg.FeeWatchInterval < minFeeWatchInterval {
+			str := "%s: feewatchinterval must be at least %v"
+			err := fmt.Errorf(str, funcName, minFeeWatchInterval)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.FeeWatchURL != "" {
+			u, err := url.Parse(cfg.FeeWatchURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+				u.Host == "" {
+				str := "%s: feewatchurl %q is not an http or https URL"
+				err := fmt.Errorf(str, funcName, cfg.FeeWatchURL)
+				fmt.Fprintln(os.Stderr, err)
+				return nil, nil, err
+			}
+		} else if cfg.TorMode {
+			str := "%s: feewatch requires feewatchurl in tormode since " +
+				"onion services make no requests to the public dcrdata"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+	if cfg.HTTPMaxHeaderBytes < 1 || cfg.MaxBodyBytes < 1 ||
+		cfg.MaxBulkBodyBytes < 1 {
+		str := "%s: httpmaxheaderbytes, maxbodybytes and maxbulkbodybytes " +
+			"must be at least 1"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.ExportUsers != "" && cfg.ImportUsers != "" {
+		str := "%s: exportusers and importusers can not be used together"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.ExportUsers != "" {
+		cfg.ExportUsers = cleanAndExpandPath(cfg.ExportUsers)
+	}
+	if cfg.ImportUsers != "" {
+		cfg.ImportUsers = cleanAndExpandPath(cfg.ImportUsers)
+	}
+
+	if cfg.Profile != "" {
+		if _, err := profileListenAddr(cfg.Profile); err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+	if cfg.GoroutineWarn < 0 {
+		str := "%s: goroutinewarn must not be negative"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	if cfg.CookieSecret == "" {
 		str := "%s: cookiesecret is not set in config"
 		err := fmt.Errorf(str, funcName)
@@ -483,7 +1138,7 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	if len(cfg.AdminIPs) == 0 {
+	if len(cfg.AdminIPs) == 0 && !cfg.TorMode {
 		str := "%s: adminips is not set in config"
 		err := fmt.Errorf(str, funcName)
 		fmt.Fprintln(os.Stderr, err)
@@ -503,6 +1158,20 @@ func loadConfig() (*config, []string, error) {
 		fmt.Fprintln(os.Stderr, err)
 		return nil, nil, err
 	}
+	if cfg.VotingAccount == "" {
+		str := "%s: votingaccount must not be empty"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.VotingBranch != helpers.ExternalBranch &&
+		cfg.VotingBranch != helpers.InternalBranch {
+		str := "%s: votingbranch must be %d (external) or %d (internal)"
+		err := fmt.Errorf(str, funcName, helpers.ExternalBranch,
+			helpers.InternalBranch)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
 
 	if err := cfg.parsePubKeys(activeNetParams.Params); err != nil {
 		err := fmt.Errorf("%s: failed to parse extended public keys: %v", funcName, err)
@@ -511,8 +1180,24 @@ func loadConfig() (*config, []string, error) {
 	}
 
 	// Convert comma separated list into a slice
-	cfg.AdminIPs = strings.Split(cfg.AdminIPs[0], ",")
+	if len(cfg.AdminIPs) > 0 {
+		cfg.AdminIPs = strings.Split(cfg.AdminIPs[0], ",")
+	}
 	cfg.AdminUserIDs = strings.Split(cfg.AdminUserIDs[0], ",")
+	if len(cfg.MaintenanceAllowIPs) > 0 {
+		cfg.MaintenanceAllowIPs = strings.Split(cfg.MaintenanceAllowIPs[0], ",")
+	}
+
+	// Read the maintenance page now so that a bad path is not found only when
+	// maintenance is enabled.
+	if cfg.MaintenancePage != "" {
+		cfg.MaintenancePage = cleanAndExpandPath(cfg.MaintenancePage)
+		b, err := ioutil.ReadFile(cfg.MaintenancePage)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read maintenancepage: %v", err)
+		}
+		cfg.MaintenancePageHTML = string(b)
+	}
 
 	if len(cfg.StakepooldHosts) == 0 {
 		str := "%s: stakepooldhosts is not set in config"
@@ -9,6 +9,7 @@ import (
 
 	"github.com/decred/dcrd/chaincfg/v3"
 	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrstakepool/internal/netparams"
 )
 
 const (
@@ -41,17 +42,17 @@ type keyParse struct {
 //in keys, expected out keys, and error value
 var keyTestValues = []keyParse{
 	//testnet
-	{testNet3Params.Params, keysIn{testnetXPub1, testnetXPub2}, keysOut{hd(testnetXPub1, testNet3Params.Params), hd(testnetXPub2, testNet3Params.Params)}, false},
-	{testNet3Params.Params, keysIn{testnetXPub1, mainnetXPub2}, keysOut{hd(testnetXPub1, testNet3Params.Params), hd(mainnetXPub2, testNet3Params.Params)}, true},
-	{testNet3Params.Params, keysIn{"", mainnetXPub2}, keysOut{hd("", testNet3Params.Params), hd(mainnetXPub2, testNet3Params.Params)}, true},
+	{netparams.TestNet3.Params, keysIn{testnetXPub1, testnetXPub2}, keysOut{hd(testnetXPub1, netparams.TestNet3.Params), hd(testnetXPub2, netparams.TestNet3.Params)}, false},
+	{netparams.TestNet3.Params, keysIn{testnetXPub1, mainnetXPub2}, keysOut{hd(testnetXPub1, netparams.TestNet3.Params), hd(mainnetXPub2, netparams.TestNet3.Params)}, true},
+	{netparams.TestNet3.Params, keysIn{"", mainnetXPub2}, keysOut{hd("", netparams.TestNet3.Params), hd(mainnetXPub2, netparams.TestNet3.Params)}, true},
 	//mainnet
-	{mainNetParams.Params, keysIn{mainnetXPub1, mainnetXPub2}, keysOut{hd(mainnetXPub1, mainNetParams.Params), hd(mainnetXPub2, mainNetParams.Params)}, false},
-	{mainNetParams.Params, keysIn{simnetXPub1, mainnetXPub2}, keysOut{hd(simnetXPub1, mainNetParams.Params), hd(mainnetXPub2, mainNetParams.Params)}, true},
-	{mainNetParams.Params, keysIn{mainnetXPub1, mainnetXPub2 + "a"}, keysOut{hd(mainnetXPub1, mainNetParams.Params), hd(mainnetXPub2+"a", mainNetParams.Params)}, true},
+	{netparams.MainNet.Params, keysIn{mainnetXPub1, mainnetXPub2}, keysOut{hd(mainnetXPub1, netparams.MainNet.Params), hd(mainnetXPub2, netparams.MainNet.Params)}, false},
+	{netparams.MainNet.Params, keysIn{simnetXPub1, mainnetXPub2}, keysOut{hd(simnetXPub1, netparams.MainNet.Params), hd(mainnetXPub2, netparams.MainNet.Params)}, true},
+	{netparams.MainNet.Params, keysIn{mainnetXPub1, mainnetXPub2 + "a"}, keysOut{hd(mainnetXPub1, netparams.MainNet.Params), hd(mainnetXPub2+"a", netparams.MainNet.Params)}, true},
 	//simnnet
-	{simNetParams.Params, keysIn{simnetXPub1, simnetXPub2}, keysOut{hd(simnetXPub1, simNetParams.Params), hd(simnetXPub2, simNetParams.Params)}, false},
-	{simNetParams.Params, keysIn{testnetXPub1, simnetXPub2}, keysOut{hd(testnetXPub1, simNetParams.Params), hd(simnetXPub2, simNetParams.Params)}, true},
-	{simNetParams.Params, keysIn{simnetXPub1[:len(simnetXPub1)-1], simnetXPub2}, keysOut{hd(simnetXPub1[:len(simnetXPub1)-1], simNetParams.Params), hd(simnetXPub2, simNetParams.Params)}, true},
+	{netparams.SimNet.Params, keysIn{simnetXPub1, simnetXPub2}, keysOut{hd(simnetXPub1, netparams.SimNet.Params), hd(simnetXPub2, netparams.SimNet.Params)}, false},
+	{netparams.SimNet.Params, keysIn{testnetXPub1, simnetXPub2}, keysOut{hd(testnetXPub1, netparams.SimNet.Params), hd(simnetXPub2, netparams.SimNet.Params)}, true},
+	{netparams.SimNet.Params, keysIn{simnetXPub1[:len(simnetXPub1)-1], simnetXPub2}, keysOut{hd(simnetXPub1[:len(simnetXPub1)-1], netparams.SimNet.Params), hd(simnetXPub2, netparams.SimNet.Params)}, true},
 }
 
 //helper func string to extended key
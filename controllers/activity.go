@@ -0,0 +1,166 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	// userActivityRetention is how long the actions users take on their
+	// accounts are kept and shown in their activity timeline.
+	userActivityRetention = time.Hour * 24 * 90
+
+	// maxActivityEvents is the number of events shown in the activity
+	// timeline on the settings page.
+	maxActivityEvents = 50
+)
+
+// The actions recorded in the activity timeline of users.
+const (
+	activityLogin          = "log in"
+	activitySignIn         = "sign in with address"
+	activityAPILogin       = "log in to the API"
+	activityPasswordReset  = "request password reset"
+	activityPasswordChange = "change password"
+	activityAddress        = "submit address"
+	activityAddressReplace = "replace address"
+	activityVoting         = "change voting preferences"
+	activityAPIToken       = "create API token"
+	activityReadOnlyToken  = "generate read-only API token"
+	activityReadOnlyRevoke = "revoke read-only API token"
+	activityRegister       = "register"
+	activityTOS            = "accept terms of service"
+)
+
+// activityEvent is an event of the activity timeline of a user, which
+// combines the actions recorded for the timeline with those recorded by the
+// admin audit trail and the terms of service acceptances.
+type activityEvent struct {
+	Time   int64
+	Action string
+	Detail string
+	IP     string
+}
+
+// recordUserActivity records that the user with userID took action on their
+// account, with detail describing it.  Failing to record it is logged but does
+// not undo the action.
+func (controller *MainController) recordUserActivity(c web.C, r *http.Request, userID int64, action, detail string) {
+	activity := &models.UserActivity{
+		UserID:    userID,
+		Action:    action,
+		Detail:    truncateString(detail, 255),
+		IP:        getClientIP(r, controller.Cfg.RealIPHeader),
+		UserAgent: truncateString(r.UserAgent(), maxUserAgentLength),
+		Created:   time.Now().Unix(),
+	}
+	if err := models.InsertUserActivity(controller.GetDbMap(c), activity); err != nil {
+		log.Errorf("unable to record user %d activity %q: %v", userID,
+			action, err)
+	}
+}
+
+// voteBitsChange describes a change of the vote bits of a user from old to
+// new for the activity timeline.
+func voteBitsChange(old int64, new uint16) string {
+	return fmt.Sprintf("vote bits %d to %d", old, new)
+}
+
+// userActivityTimeline merges the activity of user since the unix time since
+// into the limit most recent events, most recent first.
+func userActivityTimeline(user *models.User, activities []models.UserActivity,
+	audits []models.AdminAudit, acceptances []models.TOSAcceptance,
+	since int64, limit int) []activityEvent {
+	events := make([]activityEvent, 0, len(activities)+len(audits)+
+		len(acceptances)+1)
+	for i := range activities {
+		a := &activities[i]
+		events = append(events, activityEvent{
+			Time:   a.Created,
+			Action: a.Action,
+			Detail: a.Detail,
+			IP:     a.IP,
+		})
+	}
+	for i := range audits {
+		a := &audits[i]
+		events = append(events, activityEvent{
+			Time:   a.Created,
+			Action: a.Action,
+			Detail: a.Targets,
+			IP:     a.IP,
+		})
+	}
+	for i := range acceptances {
+		a := &acceptances[i]
+		events = append(events, activityEvent{
+			Time:   a.Accepted,
+			Action: activityTOS,
+			Detail: "version " + a.Version,
+			IP:     a.IP,
+		})
+	}
+	if user.Created >= since {
+		events = append(events, activityEvent{
+			Time:   user.Created,
+			Action: activityRegister,
+			IP:     user.RegistrationIP,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time > events[j].Time
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
+
+// setActivityEnv sets the activity timeline of user rendered by the settings
+// page.
+func (controller *MainController) setActivityEnv(c web.C, dbMap *gorp.DbMap, user *models.User) {
+	since := time.Now().Add(-userActivityRetention).Unix()
+	activities, err := models.GetUserActivities(dbMap, user.ID, since,
+		maxActivityEvents)
+	if err != nil {
+		log.Errorf("unable to get activity of user %d: %v", user.ID, err)
+	}
+	audits, err := models.GetAdminAuditsByUserID(dbMap, user.ID, since,
+		maxActivityEvents)
+	if err != nil {
+		log.Errorf("unable to get audited actions of user %d: %v", user.ID, err)
+	}
+	acceptances, err := models.GetTOSAcceptancesByUserID(dbMap, user.ID, since,
+		maxActivityEvents)
+	if err != nil {
+		log.Errorf("unable to get TOS acceptances of user %d: %v", user.ID, err)
+	}
+	c.Env["Activity"] = userActivityTimeline(user, activities, audits,
+		acceptances, since, maxActivityEvents)
+	c.Env["ActivityRetentionDays"] = int(userActivityRetention.Hours() / 24)
+}
+
+// PruneUserActivity removes the actions of users recorded longer than
+// userActivityRetention ago.
+func (controller *MainController) PruneUserActivity(dbMap *gorp.DbMap) {
+	before := time.Now().Add(-userActivityRetention).Unix()
+	n, err := models.DeleteUserActivitiesBefore(dbMap, before)
+	if err != nil {
+		log.Errorf("unable to prune user activity: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Debugf("Pruned %d user activity records", n)
+	}
+}
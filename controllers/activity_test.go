@@ -0,0 +1,49 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrstakepool/models"
+)
+
+func TestUserActivityTimeline(t *testing.T) {
+	user := &models.User{ID: 1, Created: 100, RegistrationIP: "10.0.0.1"}
+	activities := []models.UserActivity{
+		{Action: activityVoting, Detail: voteBitsChange(1, 5), IP: "10.0.0.3", Created: 400},
+		{Action: activityLogin, IP: "10.0.0.2", Created: 200},
+	}
+	audits := []models.AdminAudit{
+		{AdminUID: 1, Action: "change email", Targets: "new@example.com", IP: "10.0.0.4", Created: 300},
+	}
+	acceptances := []models.TOSAcceptance{
+		{UserID: 1, Version: "2", IP: "10.0.0.1", Accepted: 100},
+	}
+
+	want := []activityEvent{
+		{400, activityVoting, "vote bits 1 to 5", "10.0.0.3"},
+		{300, "change email", "new@example.com", "10.0.0.4"},
+		{200, activityLogin, "", "10.0.0.2"},
+		{100, activityTOS, "version 2", "10.0.0.1"},
+		{100, activityRegister, "", "10.0.0.1"},
+	}
+	got := userActivityTimeline(user, activities, audits, acceptances, 0, 10)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want timeline %+v, got %+v", want, got)
+	}
+
+	// The registration is left out once it is older than the timeline, and
+	// only the most recent events are kept.
+	got = userActivityTimeline(user, activities, audits, acceptances, 150, 2)
+	if !reflect.DeepEqual(got, want[:2]) {
+		t.Fatalf("want timeline %+v, got %+v", want[:2], got)
+	}
+	got = userActivityTimeline(user, nil, nil, nil, 150, 10)
+	if len(got) != 0 {
+		t.Fatalf("want empty timeline, got %+v", got)
+	}
+}
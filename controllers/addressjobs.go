@@ -0,0 +1,314 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+)
+
+// addressJobTimeout is how long a run of an address setup job may take.  It
+// runs after the request which started it was answered.
+const addressJobTimeout = 2 * time.Minute
+
+// addressJobs tracks the address setup jobs running in the background, so
+// that a job is not run twice at once.  The zero value is ready to use.
+type addressJobs struct {
+	mtx     sync.Mutex
+	running map[int64]struct{} // [user id]
+}
+
+// start marks the job of a user as running and returns whether it was not
+// running already.
+func (jobs *addressJobs) start(userID int64) bool {
+	jobs.mtx.Lock()
+	defer jobs.mtx.Unlock()
+	if _, ok := jobs.running[userID]; ok {
+		return false
+	}
+	if jobs.running == nil {
+		jobs.running = make(map[int64]struct{})
+	}
+	jobs.running[userID] = struct{}{}
+	return true
+}
+
+// done marks the job of a user as no longer running.
+func (jobs *addressJobs) done(userID int64) {
+	jobs.mtx.Lock()
+	delete(jobs.running, userID)
+	jobs.mtx.Unlock()
+}
+
+// isRunning returns whether the job of a user is running.
+func (jobs *addressJobs) isRunning(userID int64) bool {
+	jobs.mtx.Lock()
+	defer jobs.mtx.Unlock()
+	_, ok := jobs.running[userID]
+	return ok
+}
+
+// runAddressJob sets up the multisig script of the address of job: it creates
+// the script, imports it into the wallet of every stakepoold instance and
+// stores it with the user.  Steps which succeeded in a previous run, i.e. the
+// creation of the script and the imports recorded in job, are not repeated.
+// save is called with the progress after each step.  A failed job has the
+// failed status and an error to show to the user.
+func (controller *MainController) runAddressJob(ctx context.Context, dbMap *gorp.DbMap,
+	job *models.AddressJob, save func(*models.AddressJob)) {
+
+	fail := func(msg string, err error) {
+		if err != nil {
+			log.Errorf("address setup of user %d failed: %s: %v", job.UserID,
+				msg, err)
+		}
+		job.Status = models.AddressJobFailed
+		job.Error = msg
+		save(job)
+	}
+
+	job.Status = models.AddressJobPending
+	job.Error = ""
+	save(job)
+
+	// Only one address may be set up per account, even by jobs which were
+	// submitted at once.
+	user, err := models.GetUserByID(dbMap, job.UserID)
+	if err != nil {
+		fail("Unable to set up the address", err)
+		return
+	}
+	switch user.UserPubKeyAddr {
+	case "":
+	case job.UserPubKeyAddr:
+		// A previous run stored the address but not its completion.
+		job.Status = models.AddressJobComplete
+		save(job)
+		return
+	default:
+		fail("The voting service is currently limited to one address per "+
+			"account", nil)
+		return
+	}
+
+	// Create the multisig script of the address and a pool address unless a
+	// previous run did.
+	if job.MultiSigScript == "" {
+		pooladdress, err := controller.TicketAddressForUserID(dbMap, int(job.UserID))
+		if err != nil {
+			fail("Unable to derive ticket address", err)
+			return
+		}
+
+		// From new address (pkh), get pubkey address
+		poolValidateAddress, err := controller.Cfg.StakepooldServers.ValidateAddress(ctx, pooladdress)
+		if err != nil {
+			fail("Unable to validate pool ticket address", err)
+			return
+		}
+		if !poolValidateAddress.IsMine {
+			fail("Unable to validate pool ticket address",
+				fmt.Errorf("%s is not mine", pooladdress))
+			return
+		}
+		poolPubKeyAddr := poolValidateAddress.PubKeyAddr
+
+		// Get back Address from pool's new pubkey address
+		if _, err = dcrutil.DecodeAddress(poolPubKeyAddr, controller.Cfg.NetParams); err != nil {
+			fail("Unable to validate pool ticket address", err)
+			return
+		}
+
+		// Create the the multisig script. Result includes a P2SH and redeem
+		// script.
+		createMultiSig, err := controller.Cfg.StakepooldServers.CreateMultisig(ctx,
+			[]string{poolPubKeyAddr, job.UserPubKeyAddr})
+		if err != nil {
+			fail("Unable to create the multisig script", err)
+			return
+		}
+		job.PoolPubKeyAddr = poolPubKeyAddr
+		job.MultiSigAddress = createMultiSig.Address
+		job.MultiSigScript = createMultiSig.RedeemScript
+	}
+
+	// Serialize the redeem script (hex string -> []byte)
+	serializedScript, err := hex.DecodeString(job.MultiSigScript)
+	if err != nil {
+		fail("Unable to create the multisig script", err)
+		return
+	}
+
+	// Import the redeem script into the wallets which did not import it
+	// yet.  Because this is a new script, no rescan is necessary.
+	hosts := controller.Cfg.StakepooldServers.Hosts()
+	job.Wallets = int64(len(hosts))
+	job.Status = models.AddressJobImporting
+	save(job)
+	imported := make(map[string]struct{})
+	for _, host := range job.Imported() {
+		imported[host] = struct{}{}
+	}
+	for i, host := range hosts {
+		if _, ok := imported[host]; ok {
+			continue
+		}
+		height, err := controller.Cfg.StakepooldServers.ImportNewScriptOn(ctx,
+			host, serializedScript)
+		if errors.Is(err, manager.ErrWalletRescanning) {
+			fail(fmt.Sprintf("Voting wallet %d of %d is syncing, please "+
+				"retry once it is done", i+1, len(hosts)), err)
+			return
+		}
+		if err != nil {
+			fail(fmt.Sprintf("Unable to import the multisig script into "+
+				"voting wallet %d of %d", i+1, len(hosts)), err)
+			return
+		}
+		job.AddImported(host)
+		job.HeightImported = height
+		save(job)
+	}
+
+	// Get the pool fees address for this user
+	userFeeAddr, err := controller.FeeAddressForUserID(dbMap, int(job.UserID))
+	if err != nil {
+		fail("Unable to derive fee address", err)
+		return
+	}
+
+	// Store the script and the addresses with the user and complete the
+	// job at once.  stakepoold is updated with the user from the outbox.
+	err = models.SetUserScript(dbMap, job.UserID, &models.UserScript{
+		MultiSigAddress:  job.MultiSigAddress,
+		MultiSigScript:   job.MultiSigScript,
+		PoolPubKeyAddr:   job.PoolPubKeyAddr,
+		UserPubKeyAddr:   job.UserPubKeyAddr,
+		UserFeeAddr:      userFeeAddr.Address(),
+		HeightRegistered: job.HeightImported,
+	}, job, time.Now().Unix())
+	if errors.Is(err, models.ErrAddressSubmitted) {
+		fail("The voting service is currently limited to one address per "+
+			"account", nil)
+		return
+	}
+	if err != nil {
+		fail("Unable to set up the address", err)
+		return
+	}
+	notifyFeeAddress(dbMap, job.UserID, userFeeAddr.Address())
+
+	if err = controller.SendStakepooldUpdates(ctx, dbMap); err != nil {
+		log.Errorf("unable to update users on stakepoold, retrying in "+
+			"the background: %v", err)
+	}
+}
+
+// startAddressJob runs the address setup job in the background unless it is
+// already running.  Its progress is recorded in the DB.
+func (controller *MainController) startAddressJob(dbMap *gorp.DbMap, job *models.AddressJob) {
+	if !controller.addressJobs.start(job.UserID) {
+		return
+	}
+	go func() {
+		defer controller.addressJobs.done(job.UserID)
+		ctx, cancel := context.WithTimeout(context.Background(), addressJobTimeout)
+		defer cancel()
+		controller.runAddressJob(ctx, dbMap, job, func(job *models.AddressJob) {
+			job.Updated = time.Now().Unix()
+			if err := models.UpdateAddressJob(dbMap, job); err != nil {
+				log.Errorf("unable to record address setup of user %d: %v",
+					job.UserID, err)
+			}
+		})
+		if job.Status == models.AddressJobComplete {
+			log.Infof("set up address %s of user %d", job.UserPubKeyAddr,
+				job.UserID)
+		}
+	}()
+}
+
+// AddressStatus renders the progress of the address setup job of the user.
+func (controller *MainController) AddressStatus(c web.C, r *http.Request) (string, int) {
+	t := controller.GetTemplate(c)
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	user := controller.GetUser(c)
+	if user == nil {
+		return "/", http.StatusSeeOther
+	}
+	uid64 := user.ID
+
+	job, err := models.GetAddressJobByUserID(controller.GetDbMap(c), uid64)
+	if err != nil {
+		log.Errorf("unable to get address setup of user %d: %v", uid64, err)
+		return "/error", http.StatusSeeOther
+	}
+	if job == nil {
+		return "/address", http.StatusSeeOther
+	}
+
+	running := controller.addressJobs.isRunning(uid64)
+	c.Env["Admin"], _ = controller.isAdmin(c, r)
+	c.Env["IsAddress"] = true
+	c.Env["Job"] = job
+	c.Env["Imported"] = len(job.Imported())
+	c.Env["Running"] = running
+	// A job which is neither running nor finished was interrupted by a
+	// restart and may be retried like a failed one.
+	c.Env["Retry"] = !running && job.Status != models.AddressJobComplete
+	c.Env["Flash"] = session.Flashes("address")
+
+	widgets := controller.Parse(t, "addressstatus", c.Env)
+
+	c.Env["Title"] = "Decred VSP - Address Setup"
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// AddressRetryPost resumes the failed or interrupted address setup job of the
+// user from its last successful step.
+func (controller *MainController) AddressRetryPost(c web.C, r *http.Request) (string, int) {
+	user := controller.GetUser(c)
+	if user == nil {
+		return "/", http.StatusSeeOther
+	}
+	uid64 := user.ID
+
+	dbMap := controller.GetDbMap(c)
+	job, err := models.GetAddressJobByUserID(dbMap, uid64)
+	if err != nil {
+		log.Errorf("unable to get address setup of user %d: %v", uid64, err)
+		return "/error", http.StatusSeeOther
+	}
+	if job == nil {
+		return "/address", http.StatusSeeOther
+	}
+	if job.Status == models.AddressJobComplete {
+		return "/tickets", http.StatusSeeOther
+	}
+
+	log.Infof("user %d retried the address setup of %s", uid64,
+		job.UserPubKeyAddr)
+	controller.startAddressJob(dbMap, job)
+	return "/address/status", http.StatusSeeOther
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+)
+
+// scriptHasTickets returns whether any tickets were bought with the multisig
+// script of user, as known to the voting wallets or, when fees are deferred,
+// recorded with a ticket fee.
+func (controller *MainController) scriptHasTickets(ctx context.Context,
+	dbMap *gorp.DbMap, user *models.User) (bool, error) {
+	spui, err := controller.Cfg.StakepooldServers.StakePoolUserInfo(ctx,
+		user.MultiSigAddress)
+	if err != nil {
+		return false, err
+	}
+	if spui != nil && len(spui.Tickets)+len(spui.InvalidTickets) > 0 {
+		return true, nil
+	}
+	if controller.Cfg.FeeMode == models.FeeModeDeferred {
+		fees, err := models.GetTicketFeesByUserID(dbMap, user.ID)
+		if err != nil {
+			return false, err
+		}
+		return len(fees) > 0, nil
+	}
+	return false, nil
+}
+
+// AddressReplacePost replaces the address submitted by the user, e.g. after a
+// typo, as long as no tickets were bought with its multisig script.  The old
+// script is retired and removed from the users of the voting wallets, and a
+// multisig script is set up for the new address as by AddressPost.
+func (controller *MainController) AddressReplacePost(c web.C, r *http.Request) (string, int) {
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+
+	user := controller.GetUser(c)
+	if user == nil {
+		return "/", http.StatusSeeOther
+	}
+	uid64 := user.ID
+
+	dbMap := controller.GetDbMap(c)
+	if user.MultiSigAddress == "" {
+		return "/address", http.StatusSeeOther
+	}
+	if controller.addressJobs.isRunning(uid64) {
+		return "/address/status", http.StatusSeeOther
+	}
+	if controller.walletsSyncing() > 0 {
+		session.AddFlash(walletSyncingMessage, "address")
+		return controller.Address(c, r)
+	}
+
+	userPubKeyAddr := r.FormValue("UserPubKeyAddr")
+
+	log.Infof("Address replace POST from %v, user %d, pubkeyaddr %v",
+		remoteIP, uid64, userPubKeyAddr)
+
+	u, err := validateUserPubKeyAddr(userPubKeyAddr, controller.Cfg.NetParams)
+	if err != nil {
+		session.AddFlash(err.Error(), "address")
+		return controller.Address(c, r)
+	}
+	if userPubKeyAddr == user.UserPubKeyAddr {
+		session.AddFlash("This address is already your submitted address",
+			"address")
+		return controller.Address(c, r)
+	}
+
+	hasTickets, err := controller.scriptHasTickets(r.Context(), dbMap, user)
+	if err != nil {
+		log.Errorf("unable to get tickets of user %d: %v", uid64, err)
+		session.AddFlash("Unable to check for tickets bought with your "+
+			"address, please try again later", "address")
+		return controller.Address(c, r)
+	}
+	if hasTickets {
+		session.AddFlash("Your address can not be replaced since tickets "+
+			"were bought with it", "address")
+		return controller.Address(c, r)
+	}
+
+	reuse, err := controller.addressReuse(r.Context(), dbMap, uid64, u)
+	if err != nil {
+		log.Warnf("unable to check reuse of address %s: %v", userPubKeyAddr, err)
+	}
+	if reuse != "" {
+		log.Warnf("User %d submitted reused address %s: %s", uid64,
+			userPubKeyAddr, reuse)
+		if controller.Cfg.RejectReusedAddrs {
+			session.AddFlash(reuse+". Please generate a new address "+
+				"in the wallet you will purchase tickets with.", "address")
+			return controller.Address(c, r)
+		}
+	}
+
+	now := time.Now().Unix()
+	job := &models.AddressJob{
+		UserID:         uid64,
+		UserPubKeyAddr: userPubKeyAddr,
+		Status:         models.AddressJobPending,
+		Created:        now,
+		Updated:        now,
+	}
+	rs, err := models.RetireUserScript(dbMap, user, job, now)
+	if err != nil {
+		log.Errorf("unable to retire script %s of user %d: %v",
+			user.MultiSigAddress, uid64, err)
+		session.AddFlash("Unable to replace your address, please try again",
+			"address")
+		return controller.Address(c, r)
+	}
+	if rs == nil {
+		// The script changed since it was checked.
+		return "/address", http.StatusSeeOther
+	}
+	log.Infof("User %d retired script %s of address %s to submit address %s",
+		uid64, rs.MultiSigAddress, rs.UserPubKeyAddr, userPubKeyAddr)
+	controller.recordUserActivity(c, r, uid64, activityAddressReplace,
+		rs.UserPubKeyAddr+" to "+userPubKeyAddr)
+
+	// The voting wallets keep the retired script, but it no longer belongs
+	// to a user.
+	if err := controller.SendStakepooldUpdates(r.Context(), dbMap); err != nil {
+		log.Errorf("unable to update users on stakepoold: %v", err)
+	}
+
+	controller.startAddressJob(dbMap, job)
+	controller.recordUserActivity(c, r, uid64, activityAddress, userPubKeyAddr)
+
+	if reuse != "" {
+		session.AddFlash("Warning: "+reuse+". Please check that it was "+
+			"generated by the wallet you will purchase tickets with.",
+			"address")
+	}
+
+	return "/address/status", http.StatusSeeOther
+}
@@ -0,0 +1,608 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+)
+
+func (controller *MainController) isAdmin(c web.C, r *http.Request) (bool, error) {
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	user := controller.GetUser(c)
+	if user == nil {
+		return false, fmt.Errorf("%s request with no session from %s",
+			r.URL, remoteIP)
+	}
+
+	uidstr := strconv.FormatInt(user.ID, 10)
+
+	// All clients of an onion service connect from the local Tor daemon.
+	if !controller.Cfg.TorMode &&
+		!stringSliceContains(controller.Cfg.AdminIPs, remoteIP) {
+		return false, fmt.Errorf("%s request from %s "+
+			"userid %s failed AdminIPs check", r.URL, remoteIP, uidstr)
+	}
+
+	if !stringSliceContains(controller.Cfg.AdminUserIDs, uidstr) {
+		return false, fmt.Errorf("%s request from %s "+
+			"userid %s failed adminUserIDs check", r.URL, remoteIP, uidstr)
+	}
+
+	return true, nil
+}
+
+// clockSkewWarnings returns a warning for each stakepoold instance whose clock,
+// or the clock of the dcrd of its wallet, is off by more than max.  Token
+// expiry, ticket expiry estimates and cache timers assume synced clocks.
+func clockSkewWarnings(statuses []manager.BackendStatus, max time.Duration) []string {
+	var warnings []string
+	for _, s := range statuses {
+		if s.WalletStatus == nil || !s.ClockSkewExceeds(max) {
+			continue
+		}
+		skew := "unknown"
+		if s.ClockSkewKnown {
+			skew = s.ClockSkew.Round(time.Millisecond).String()
+		}
+		warnings = append(warnings, fmt.Sprintf("The clock of stakepoold %s "+
+			"is off by %s and the clock of the dcrd of its wallet by %v, "+
+			"more than the %v allowed by maxclockskew", s.Host, skew,
+			s.WalletTimeOffset, max))
+	}
+	return warnings
+}
+
+// walletFeeWarnings returns a warning for each stakepoold instance whose wallet
+// purchases tickets, which spends the funds of the voting wallet, or whose fee
+// rates differ from those of most wallets, along with the hosts of the
+// wallets with diverging fee rates.  Voting wallets are expected to be set up
+// alike, so a divergence usually means that one of them was misconfigured.
+func walletFeeWarnings(statuses []manager.BackendStatus) ([]string, map[string]bool) {
+	type fees struct{ tx, ticket float64 }
+	counts := make(map[fees]int)
+	var common fees
+	for _, s := range statuses {
+		if s.WalletStatus == nil || !s.FeesKnown {
+			continue
+		}
+		f := fees{s.TxFee, s.TicketFee}
+		counts[f]++
+		if counts[f] > counts[common] {
+			common = f
+		}
+	}
+
+	var warnings []string
+	diverged := make(map[string]bool)
+	for _, s := range statuses {
+		if s.WalletStatus == nil || !s.FeesKnown {
+			continue
+		}
+		if s.TicketPurchasing {
+			warnings = append(warnings, fmt.Sprintf("The voting wallet of "+
+				"stakepoold %s has ticket purchasing enabled", s.Host))
+		}
+		if f := (fees{s.TxFee, s.TicketFee}); f != common {
+			diverged[s.Host] = true
+			warnings = append(warnings, fmt.Sprintf("The voting wallet of "+
+				"stakepoold %s has a transaction fee of %v DCR/kB and a "+
+				"ticket fee of %v DCR/kB, unlike the %v DCR/kB and %v "+
+				"DCR/kB of the other wallets", s.Host, f.tx, f.ticket,
+				common.tx, common.ticket))
+		}
+	}
+	return warnings, diverged
+}
+
+// hostBreakerEvent is a state change of the circuit breaker of the connection
+// to a stakepoold instance.
+type hostBreakerEvent struct {
+	Host string
+	manager.BreakerEvent
+}
+
+// breakerEvents returns the recent circuit breaker state changes of all
+// stakepoold instances, most recent first.
+func breakerEvents(statuses []manager.BackendStatus) []hostBreakerEvent {
+	var events []hostBreakerEvent
+	for _, s := range statuses {
+		for _, e := range s.BreakerEvents {
+			events = append(events, hostBreakerEvent{s.Host, e})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.After(events[j].Time)
+	})
+	return events
+}
+
+// AdminStatus renders the status page.
+func (controller *MainController) AdminStatus(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	backendStatus := controller.Cfg.StakepooldServers.BackendStatus(r.Context())
+
+	// Compare the scripts and tickets of the back-ends with the database and
+	// each other.
+	users, err := models.GetUsersWithMultiSigAddress(controller.GetReadDbMap(c))
+	if err != nil {
+		log.Errorf("unable to get users with a multisig address: %v", err)
+		return "/error", http.StatusSeeOther
+	}
+	msas := make([]string, len(users))
+	for i := range users {
+		msas[i] = users[i].MultiSigAddress
+	}
+	syncStatus := controller.Cfg.StakepooldServers.SyncStatus(r.Context(), msas)
+
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminStatus"] = true
+	c.Env["Title"] = "Decred Voting Service - Status (Admin)"
+
+	c.Env["FlashError"] = session.Flashes("adminStatusError")
+	c.Env["FlashSuccess"] = session.Flashes("adminStatusSuccess")
+
+	// Set info to be used by admins on /status page.
+	c.Env["Alerts"] = controller.alerts.current()
+	c.Env["BackendStatus"] = backendStatus
+	c.Env["MaxClockSkew"] = controller.Cfg.MaxClockSkew
+	c.Env["ClockSkewWarnings"] = clockSkewWarnings(backendStatus,
+		controller.Cfg.MaxClockSkew)
+	c.Env["WalletFeeWarnings"], c.Env["WalletFeesDiverged"] =
+		walletFeeWarnings(backendStatus)
+	c.Env["BreakerEvents"] = breakerEvents(backendStatus)
+	c.Env["SyncStatus"] = syncStatus
+	c.Env["VoteBitsMigration"] = controller.voteBitsMigration.current()
+	c.Env["DBScripts"] = len(msas)
+	scriptsURL, err := controller.downloadURL(scriptsDownloadPath)
+	if err != nil {
+		log.Errorf("unable to sign redeem script download link: %v", err)
+	}
+	c.Env["ScriptsDownloadURL"] = scriptsURL
+	c.Env["DownloadLinkLifetime"] = controller.Cfg.DownloadLinkLifetime
+
+	widgets := controller.Parse(t, "admin/status", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// AdminStatusPost promotes the warm standby stakepoold instance posted from
+// AdminStatus to active.
+func (controller *MainController) AdminStatusPost(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	host := r.FormValue("host")
+
+	wasStandby, err := controller.Cfg.StakepooldServers.PromoteStandby(r.Context(), host)
+	switch {
+	case err != nil:
+		log.Errorf("unable to promote stakepoold %s: %v", host, err)
+		session.AddFlash("Unable to promote "+host+": "+err.Error(), "adminStatusError")
+	case !wasStandby:
+		session.AddFlash(host+" was already active", "adminStatusSuccess")
+	default:
+		log.Infof("admin user %d promoted stakepoold %s to active",
+			controller.GetUser(c).ID, host)
+		controller.auditAdminAction(c, r, "promote stakepoold", host)
+		session.AddFlash(host+" was promoted to active and now votes",
+			"adminStatusSuccess")
+	}
+
+	return "/status", http.StatusSeeOther
+}
+
+const (
+	// adminUsersPerPage is the number of users listed on each page of the
+	// admin users page.
+	adminUsersPerPage = 100
+
+	// adminSignupStatsPeriod is the period the signup analytics of the
+	// admin users page cover, and adminSignupStatsTop the number of IPs and
+	// referral codes they list.
+	adminSignupStatsPeriod = time.Hour * 24 * 30
+	adminSignupStatsTop    = 10
+)
+
+// adminUserTickets holds the number of tickets of a user in each of the
+// statuses listed on the admin users page.  Live includes immature tickets and
+// Missed includes expired tickets.
+type adminUserTickets struct {
+	Live   int
+	Voted  int
+	Missed int
+}
+
+// userTicketCounts returns the ticket counts of the users which have submitted
+// an address, keyed by user ID, using a single stakepoold request per batch
+// of users.
+func (controller *MainController) userTicketCounts(ctx context.Context, users []models.User) (map[int64]*adminUserTickets, error) {
+	msas := make([]string, 0, len(users))
+	for i := range users {
+		if users[i].MultiSigAddress != "" {
+			msas = append(msas, users[i].MultiSigAddress)
+		}
+	}
+	if len(msas) == 0 {
+		return nil, nil
+	}
+
+	infos, err := controller.Cfg.StakepooldServers.BatchStakePoolUserInfo(ctx, msas)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int64]*adminUserTickets, len(infos))
+	for i := range users {
+		info, ok := infos[users[i].MultiSigAddress]
+		if !ok {
+			continue
+		}
+		userTickets := new(adminUserTickets)
+		for _, ticket := range info.Tickets {
+			switch ticket.Status {
+			case "live", "immature":
+				userTickets.Live++
+			case "voted":
+				userTickets.Voted++
+			case "missed", "expired":
+				userTickets.Missed++
+			}
+		}
+		counts[users[i].ID] = userTickets
+	}
+	return counts, nil
+}
+
+// AdminUsers renders the administrative users page.  Users are listed in
+// pages of adminUsersPerPage along with their ticket counts, terms of service
+// acceptance and registration metadata, below the signups per day, and the IPs
+// and referral codes with the most signups over adminSignupStatsPeriod.
+func (controller *MainController) AdminUsers(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	page, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	dbMap := controller.GetReadDbMap(c)
+	users, err := models.GetUsers(dbMap, (page-1)*adminUsersPerPage,
+		adminUsersPerPage)
+	if err != nil {
+		log.Errorf("unable to get users: %v", err)
+		return "/error", http.StatusSeeOther
+	}
+	userCount := models.GetUserCount(dbMap)
+
+	userTickets, err := controller.userTicketCounts(r.Context(), users)
+	if err != nil {
+		log.Warnf("unable to get ticket counts of users: %v", err)
+	}
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminUsers"] = true
+	c.Env["Title"] = "Decred Voting Service - Users (Admin)"
+
+	c.Env["Users"] = users
+	c.Env["UserTickets"] = userTickets
+	c.Env["UserCount"] = userCount
+	c.Env["TOSVersion"] = controller.Cfg.TOSVersion
+	if controller.Cfg.TOSVersion != "" {
+		c.Env["TOSAcceptedCount"] = models.GetUserCountTOSAccepted(dbMap,
+			controller.Cfg.TOSVersion)
+	}
+	if page > 1 {
+		c.Env["PrevPage"] = page - 1
+	}
+	if page*adminUsersPerPage < userCount {
+		c.Env["NextPage"] = page + 1
+	}
+
+	since := time.Now().Add(-adminSignupStatsPeriod).Unix()
+	c.Env["SignupStatsDays"] = int(adminSignupStatsPeriod / (time.Hour * 24))
+	signupsPerDay, err := models.GetSignupsPerDay(dbMap, since)
+	if err != nil {
+		log.Errorf("unable to get signups per day: %v", err)
+	}
+	c.Env["SignupsPerDay"] = signupsPerDay
+	topIPs, err := models.GetTopRegistrationIPs(dbMap, since,
+		adminSignupStatsTop)
+	if err != nil {
+		log.Errorf("unable to get top registration IPs: %v", err)
+	}
+	c.Env["TopRegistrationIPs"] = topIPs
+	topReferralCodes, err := models.GetTopReferralCodes(dbMap, since,
+		adminSignupStatsTop)
+	if err != nil {
+		log.Errorf("unable to get top referral codes: %v", err)
+	}
+	c.Env["TopReferralCodes"] = topReferralCodes
+
+	widgets := controller.Parse(t, "admin/users", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// AdminTickets renders the administrative tickets page.
+// Tickets purchased with an incorrect VSP fee will be listed on this page.
+// Admin users can choose whether the pool should vote these tickets or not.
+func (controller *MainController) AdminTickets(c web.C, r *http.Request) (string, int) {
+	t := controller.GetTemplate(c)
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	dbMap := controller.GetDbMap(c)
+
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	// The ignored and added low fee tickets are listed in pages of
+	// adminLowFeeTicketsPerPage, or only counted in summary mode.
+	summary := r.URL.Query().Get("summary") != ""
+	ignoredPage := queryPage(r, "ignoredpage")
+	addedPage := queryPage(r, "addedpage")
+	if summary {
+		ignoredPage, addedPage = 0, 0
+	}
+
+	addedLowFeeTickets, addedCount, err := controller.addedLowFeeTickets(dbMap, addedPage)
+	if err != nil {
+		log.Errorf("Could not retrieve added low fee tickets: %v", err)
+		session.AddFlash("Could not retrieve added low fee tickets", "adminTicketsError")
+	}
+
+	ignoredLowFeeTickets, ignoredCount, err := controller.ignoredLowFeeTickets(r.Context(), ignoredPage)
+	if err != nil {
+		log.Errorf("Could not retrieve ignored low fee tickets from stakepoold: %v", err)
+		session.AddFlash("Could not retrieve ignored low fee tickets from stakepoold", "adminTicketsError")
+	}
+
+	lowFeePaused, heldLowFeeTickets, err := controller.Cfg.StakepooldServers.GetLowFeeReview(r.Context())
+	if err != nil {
+		log.Errorf("Could not retrieve low fee tickets held for review from stakepoold: %v", err)
+		session.AddFlash("Could not retrieve low fee tickets held for review from stakepoold", "adminTicketsError")
+	}
+
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminTickets"] = true
+	c.Env["DCRDataURL"] = controller.DCRDataURL
+
+	c.Env["FlashError"] = session.Flashes("adminTicketsError")
+	c.Env["FlashSuccess"] = session.Flashes("adminTicketsSuccess")
+
+	// Search the tickets by ticket hash, multisig address or user email,
+	// listing the results in pages of adminTicketSearchPerPage.
+	if query := strings.TrimSpace(r.URL.Query().Get("q")); query != "" {
+		c.Env["SearchQuery"] = query
+		results, err := controller.searchTickets(r.Context(),
+			controller.GetReadDbMap(c), query)
+		if err != nil {
+			c.Env["SearchError"] = err.Error()
+		}
+
+		page := queryPage(r, "page")
+		start, end := pageBounds(page, adminTicketSearchPerPage, len(results))
+		c.Env["SearchResults"] = results[start:end]
+		c.Env["SearchCount"] = len(results)
+		if page > 1 {
+			c.Env["PrevPage"] = page - 1
+		}
+		if end < len(results) {
+			c.Env["NextPage"] = page + 1
+		}
+	}
+
+	c.Env["LowFeeSummary"] = summary
+	c.Env["AddedLowFeeTickets"] = addedLowFeeTickets
+	c.Env["AddedCount"] = addedCount
+	c.Env["AddedPage"] = addedPage
+	if addedPage > 1 {
+		c.Env["AddedPrevPage"] = addedPage - 1
+	}
+	if addedPage*adminLowFeeTicketsPerPage < addedCount {
+		c.Env["AddedNextPage"] = addedPage + 1
+	}
+	c.Env["IgnoredLowFeeTickets"] = ignoredLowFeeTickets
+	c.Env["IgnoredCount"] = ignoredCount
+	c.Env["IgnoredPage"] = ignoredPage
+	if ignoredPage > 1 {
+		c.Env["IgnoredPrevPage"] = ignoredPage - 1
+	}
+	if ignoredPage*adminLowFeeTicketsPerPage < ignoredCount {
+		c.Env["IgnoredNextPage"] = ignoredPage + 1
+	}
+	c.Env["LowFeePaused"] = lowFeePaused
+	c.Env["HeldLowFeeTickets"] = heldLowFeeTickets
+
+	widgets := controller.Parse(t, "admin/tickets", c.Env)
+
+	c.Env["Title"] = "Decred Voting Service - Tickets (Admin)"
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// AdminTicketsPost validates and processes the form posted from AdminTickets.
+func (controller *MainController) AdminTicketsPost(c web.C, r *http.Request) (string, int) {
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	dbMap := controller.GetDbMap(c)
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+	userID := controller.GetUser(c).ID
+
+	if err := r.ParseForm(); err != nil {
+		session.AddFlash("unable to parse form: "+err.Error(),
+			"adminTicketsError")
+		return "/admintickets", http.StatusSeeOther
+	}
+
+	// Resuming automatic classification of low fee tickets releases the
+	// tickets held for review to the ignored list, so no tickets are
+	// selected.
+	if strings.ToLower(r.PostFormValue("action")) == "resume" {
+		err := controller.Cfg.StakepooldServers.ResumeLowFeeClassification(r.Context())
+		if err != nil {
+			session.AddFlash("ResumeLowFeeClassification error: "+err.Error(),
+				"adminTicketsError")
+			return "/admintickets", http.StatusSeeOther
+		}
+		controller.lowFeeTickets.reset()
+		log.Infof("ip %s userid %d resumed low fee ticket classification",
+			remoteIP, userID)
+		controller.auditAdminAction(c, r, "resume low fee classification")
+		session.AddFlash("Resumed automatic classification of low fee tickets",
+			"adminTicketsSuccess")
+		return "/admintickets", http.StatusSeeOther
+	}
+
+	ticketList := r.PostForm["tickets[]"]
+
+	if len(ticketList) == 0 {
+		session.AddFlash("no tickets selected to modify", "adminTicketsError")
+		return "/admintickets", http.StatusSeeOther
+	}
+
+	// Validate each of the ticket hash strings.
+	ticketHashes, err := models.DecodeHashList(ticketList)
+	if err != nil {
+		session.AddFlash("Invalid ticket in form data: "+err.Error(),
+			"adminTicketsError")
+		return "/admintickets", http.StatusSeeOther
+	}
+
+	action := strings.ToLower(r.PostFormValue("action"))
+	switch action {
+	case "add", "remove":
+		// recognized action
+	default:
+		session.AddFlash("invalid or unknown form action type", "adminTicketsError")
+		return "/admintickets", http.StatusSeeOther
+	}
+
+	actionVerb := "unknown"
+	switch action {
+	case "add":
+		actionVerb = "added"
+		ignoredLowFeeTickets, err := controller.Cfg.StakepooldServers.GetIgnoredLowFeeTickets(r.Context())
+		if err != nil {
+			session.AddFlash("GetIgnoredLowFeeTickets error: "+err.Error(),
+				"adminTicketsError")
+			return "/admintickets", http.StatusSeeOther
+		}
+
+		for i, tickethash := range ticketHashes {
+			t := ticketList[i]
+
+			// TODO check if it is already present in the database
+			// and error out if so
+
+			msa, exists := ignoredLowFeeTickets[tickethash]
+			if !exists {
+				session.AddFlash("ticket " + t + " is no longer present")
+				return "/admintickets", http.StatusSeeOther
+			}
+
+			expires := controller.CalcEstimatedTicketExpiry()
+
+			lowFeeTicket := &models.LowFeeTicket{
+				AddedByUID:    userID,
+				TicketAddress: msa,
+				TicketHash:    t,
+				TicketExpiry:  0,
+				Voted:         0,
+				Created:       time.Now().Unix(),
+				Expires:       expires.Unix(),
+			}
+
+			err = models.InsertLowFeeTicket(dbMap, lowFeeTicket)
+			if err != nil {
+				session.AddFlash("Database error occurred while adding ticket "+
+					t, "adminTicketsError")
+				log.Warnf("Adding ticket %v failed: %v", tickethash, err)
+				return "/admintickets", http.StatusSeeOther
+			}
+		}
+
+	case "remove":
+		actionVerb = "removed"
+		// To use gorm's slice expansion, use a mapper with a ticketList. For
+		// three tickets in the list, gorm will expand this to:
+		//     "... IN (:Tickets0,:Tickets1,:Tickets2)".
+		// This allows each string in the list two be it's own argument.
+		query := "DELETE FROM LowFeeTicket WHERE TicketHash IN (:Tickets)"
+		ticketListMapper := map[string]interface{}{
+			"Tickets": ticketList,
+		}
+		_, err := dbMap.Exec(query, ticketListMapper)
+		if err != nil {
+			session.AddFlash("failed to execute delete query: "+err.Error(),
+				"adminTicketsError")
+			return "/admintickets", http.StatusSeeOther
+		}
+	}
+
+	controller.auditAdminAction(c, r, action+" low fee tickets", ticketList...)
+	controller.lowFeeTickets.reset()
+
+	err = controller.StakepooldUpdateTickets(r.Context(), dbMap)
+	if err != nil {
+		session.AddFlash("StakepooldUpdateAll error: "+err.Error(), "adminTicketsError")
+	}
+
+	log.Infof("ip %s userid %d %s for %d ticket(s)", remoteIP, userID,
+		actionVerb, len(ticketList))
+	session.AddFlash(fmt.Sprintf("Successfully %s %d ticket(s)", actionVerb,
+		len(ticketList)), "adminTicketsSuccess")
+
+	return "/admintickets", http.StatusSeeOther
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/sessions"
+	"github.com/zenazn/goji/web"
+)
+
+// tSessionContext returns the context of a request from the signed in user
+// with userID, or from a signed out user when userID is 0.  It has no database
+// or templates, so handlers must not get that far.
+func tSessionContext(userID int64) (web.C, *sessions.Session) {
+	session := sessions.NewSession(nil, "session")
+	c := web.C{Env: map[interface{}]interface{}{
+		"Session":   session,
+		"DbMap":     (*gorp.DbMap)(nil),
+		"ReadDbMap": (*gorp.DbMap)(nil),
+		"Template":  (*template.Template)(nil),
+	}}
+	if userID != 0 {
+		session.Values["UserId"] = userID
+		c.Env["User"] = &models.User{ID: userID}
+	}
+	return c, session
+}
+
+func TestIsAdmin(t *testing.T) {
+	controller := &MainController{Cfg: &Config{
+		AdminIPs:     []string{"10.0.0.1"},
+		AdminUserIDs: []string{"1"},
+	}}
+	tests := []struct {
+		name       string
+		userID     int64
+		remoteAddr string
+		torMode    bool
+		want       bool
+	}{
+		{"signed out", 0, "10.0.0.1:1234", false, false},
+		{"admin", 1, "10.0.0.1:1234", false, true},
+		{"admin from other ip", 1, "10.0.0.2:1234", false, false},
+		{"admin from other ip in tormode", 1, "10.0.0.2:1234", true, true},
+		{"user", 2, "10.0.0.1:1234", false, false},
+		{"user in tormode", 2, "10.0.0.1:1234", true, false},
+	}
+	for _, test := range tests {
+		controller.Cfg.TorMode = test.torMode
+		c, _ := tSessionContext(test.userID)
+		r := httptest.NewRequest(http.MethodGet, "/admintickets", nil)
+		r.RemoteAddr = test.remoteAddr
+		isAdmin, err := controller.isAdmin(c, r)
+		if isAdmin != test.want || (err == nil) != test.want {
+			t.Errorf("%s: got %v, %v, want %v", test.name, isAdmin, err,
+				test.want)
+		}
+	}
+}
+
+func TestAdminPagesRequireAdmin(t *testing.T) {
+	controller := &MainController{Cfg: &Config{
+		AdminIPs:     []string{"10.0.0.1"},
+		AdminUserIDs: []string{"1"},
+	}}
+	handlers := map[string]func(web.C, *http.Request) (string, int){
+		"AdminStatus":      controller.AdminStatus,
+		"AdminStatusPost":  controller.AdminStatusPost,
+		"AdminUsers":       controller.AdminUsers,
+		"AdminTickets":     controller.AdminTickets,
+		"AdminTicketsPost": controller.AdminTicketsPost,
+	}
+	for name, handler := range handlers {
+		c, _ := tSessionContext(2)
+		r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		if _, code := handler(c, r); code != http.StatusUnauthorized {
+			t.Errorf("%s answered a user with status %d", name, code)
+		}
+	}
+}
+
+func TestClockSkewWarnings(t *testing.T) {
+	statuses := []manager.BackendStatus{
+		{Host: "synced", WalletStatus: &manager.WalletStatus{
+			ClockSkewKnown: true, ClockSkew: -time.Second,
+			WalletTimeOffset: 2 * time.Second}},
+		{Host: "ahead", WalletStatus: &manager.WalletStatus{
+			ClockSkewKnown: true, ClockSkew: 45 * time.Second}},
+		{Host: "behind", WalletStatus: &manager.WalletStatus{
+			ClockSkewKnown: true, ClockSkew: -45 * time.Second}},
+		{Host: "dcrd", WalletStatus: &manager.WalletStatus{
+			WalletTimeOffset: -time.Minute}},
+		{Host: "old", WalletStatus: &manager.WalletStatus{}},
+		{Host: "down"},
+	}
+	warnings := clockSkewWarnings(statuses, 30*time.Second)
+	if len(warnings) != 3 {
+		t.Fatalf("want 3 warnings, got %q", warnings)
+	}
+	for i, host := range []string{"ahead", "behind", "dcrd"} {
+		if !strings.Contains(warnings[i], "stakepoold "+host+" ") {
+			t.Errorf("warning %d is not about %s: %q", i, host, warnings[i])
+		}
+	}
+}
+
+func TestWalletFeeWarnings(t *testing.T) {
+	statuses := []manager.BackendStatus{
+		{Host: "a", WalletStatus: &manager.WalletStatus{FeesKnown: true,
+			TxFee: 0.0001, TicketFee: 0.0001}},
+		{Host: "buyer", WalletStatus: &manager.WalletStatus{FeesKnown: true,
+			TxFee: 0.0001, TicketFee: 0.0001, TicketPurchasing: true}},
+		{Host: "high", WalletStatus: &manager.WalletStatus{FeesKnown: true,
+			TxFee: 0.001, TicketFee: 0.0001}},
+		{Host: "old", WalletStatus: &manager.WalletStatus{}},
+		{Host: "down"},
+	}
+	warnings, diverged := walletFeeWarnings(statuses)
+	if len(warnings) != 2 {
+		t.Fatalf("want 2 warnings, got %q", warnings)
+	}
+	for i, host := range []string{"buyer", "high"} {
+		if !strings.Contains(warnings[i], "stakepoold "+host+" ") {
+			t.Errorf("warning %d is not about %s: %q", i, host, warnings[i])
+		}
+	}
+	if len(diverged) != 1 || !diverged["high"] {
+		t.Errorf("unexpected diverged wallets %v", diverged)
+	}
+}
+
+func TestBreakerEvents(t *testing.T) {
+	at := func(sec int64) time.Time { return time.Unix(1600000000+sec, 0) }
+	statuses := []manager.BackendStatus{
+		{Host: "a", Breaker: "closed", BreakerEvents: []manager.BreakerEvent{
+			{Time: at(30), State: "closed"},
+			{Time: at(10), State: "open"},
+		}},
+		{Host: "b", Breaker: "open", BreakerEvents: []manager.BreakerEvent{
+			{Time: at(20), State: "open"},
+		}},
+		{Host: "disabled", Breaker: "disabled"},
+	}
+	events := breakerEvents(statuses)
+	want := []struct {
+		host, state string
+	}{{"a", "closed"}, {"b", "open"}, {"a", "open"}}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if e.Host != want[i].host || e.State != want[i].state {
+			t.Errorf("event %d is %s %s, want %s %s", i, e.Host, e.State,
+				want[i].host, want[i].state)
+		}
+	}
+}
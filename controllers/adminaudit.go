@@ -0,0 +1,126 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	// adminAuditPerPage is the number of recorded admin actions listed on
+	// each page of the admin audit page.
+	adminAuditPerPage = 100
+
+	// maxAuditTargetsLength is the size of the Targets column of the
+	// AdminAudit table.
+	maxAuditTargetsLength = 1000
+)
+
+// auditTargets joins the targets of an admin action so that they fit in
+// maxAuditTargetsLength, replacing the targets which do not fit with their
+// count.
+func auditTargets(targets []string) string {
+	joined := strings.Join(targets, ", ")
+	if len(joined) <= maxAuditTargetsLength {
+		return joined
+	}
+
+	// Keep the targets which fit alongside the count of the rest.
+	kept, length := 0, 0
+	for _, target := range targets {
+		l := length + len(target)
+		if kept > 0 {
+			l += len(", ")
+		}
+		if l+len(" and 99999 more") > maxAuditTargetsLength {
+			break
+		}
+		kept, length = kept+1, l
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s and %d more",
+		strings.Join(targets[:kept], ", "), len(targets)-kept))
+}
+
+// auditAdminAction records that the admin of the session took action on
+// targets from the admin pages.  Failing to record it is logged but does not
+// undo the action, which has already been taken.
+func (controller *MainController) auditAdminAction(c web.C, r *http.Request, action string, targets ...string) {
+	var adminID int64
+	if admin := controller.GetUser(c); admin != nil {
+		adminID = admin.ID
+	}
+	controller.auditUserAction(c, r, adminID, action, targets...)
+}
+
+// auditUserAction records that the user with userID took action on targets,
+// such as changing the email address of their account, in the same trail as
+// the actions of admins.  Failing to record it is logged but does not undo the
+// action.
+func (controller *MainController) auditUserAction(c web.C, r *http.Request, userID int64, action string, targets ...string) {
+	audit := &models.AdminAudit{
+		AdminUID: userID,
+		IP:       getClientIP(r, controller.Cfg.RealIPHeader),
+		Action:   truncateString(action, 255),
+		Targets:  auditTargets(targets),
+		Created:  time.Now().Unix(),
+	}
+	if err := models.InsertAdminAudit(controller.GetDbMap(c), audit); err != nil {
+		log.Errorf("unable to record user %d action %q on %q: %v",
+			userID, audit.Action, audit.Targets, err)
+	}
+}
+
+// AdminAudit renders the read-only administrative page listing the actions
+// taken by all admins, most recent first, in pages of adminAuditPerPage.
+func (controller *MainController) AdminAudit(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	page, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	dbMap := controller.GetReadDbMap(c)
+	audits, err := models.GetAdminAudits(dbMap, (page-1)*adminAuditPerPage,
+		adminAuditPerPage)
+	if err != nil {
+		log.Errorf("unable to get admin audits: %v", err)
+		return "/error", http.StatusSeeOther
+	}
+	auditCount := models.GetAdminAuditCount(dbMap)
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminAudit"] = true
+	c.Env["Title"] = "Decred Voting Service - Audit (Admin)"
+
+	c.Env["Audits"] = audits
+	c.Env["AuditCount"] = auditCount
+	if page > 1 {
+		c.Env["PrevPage"] = page - 1
+	}
+	if page*adminAuditPerPage < auditCount {
+		c.Env["NextPage"] = page + 1
+	}
+
+	widgets := controller.Parse(t, "admin/audit", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
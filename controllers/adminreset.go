@@ -0,0 +1,92 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/zenazn/goji/web"
+)
+
+// parseAdminPasswordReset validates a password reset link request posted by an
+// admin for user, and returns its optional support ticket reference trimmed.
+// The admin must confirm that the identity of the user was verified through
+// another channel, since the link is handed to whoever the admin gives it to.
+func parseAdminPasswordReset(user *models.User, verified, ticket string) (string, error) {
+	// Accounts bound to an address have no email address or password.
+	if user.Email == "" {
+		return "", errors.New("The user signs in with an address and has " +
+			"no password")
+	}
+	if verified == "" {
+		return "", errors.New("Confirm that the identity of the user was " +
+			"verified before issuing a password reset link")
+	}
+	ticket = strings.TrimSpace(ticket)
+	if utf8.RuneCountInString(ticket) > maxSupportTicketLength {
+		return "", errors.New("The ticket reference is longer than " +
+			strconv.Itoa(maxSupportTicketLength) + " characters")
+	}
+	return ticket, nil
+}
+
+// adminPasswordReset issues a password reset link on behalf of user, as
+// posted from AdminUser by an admin who verified the identity of the user
+// out-of-band, e.g. when the user lost access to their email account.  The
+// link uses the tokens of the emailed password reset links but expires after
+// AdminResetLinkLifetime, and is only shown on the rendered page rather than
+// kept in the session.  It is recorded on the admin audit page.
+func (controller *MainController) adminPasswordReset(c web.C, r *http.Request, user *models.User) (string, int) {
+	session := controller.GetSession(c)
+	dbMap := controller.GetDbMap(c)
+	adminID := controller.GetUser(c).ID
+
+	ticket, err := parseAdminPasswordReset(user, r.FormValue("verified"),
+		r.FormValue("ticket"))
+	if err != nil {
+		session.AddFlash(err.Error(), "adminUserError")
+		return adminUserURL(user.ID), http.StatusSeeOther
+	}
+
+	t := time.Now()
+	expires := t.Add(controller.Cfg.AdminResetLinkLifetime)
+
+	token := models.NewUserToken()
+	passReset := &models.PasswordReset{
+		UserID:  user.ID,
+		Token:   token.String(),
+		Created: t.Unix(),
+		Expires: expires.Unix(),
+	}
+	if err := models.InsertPasswordReset(dbMap, passReset); err != nil {
+		log.Errorf("unable to add reset token of user %d: %v", user.ID, err)
+		session.AddFlash("Unable to issue a password reset link",
+			"adminUserError")
+		return adminUserURL(user.ID), http.StatusSeeOther
+	}
+
+	log.Infof("admin user %d issued a password reset link for user %d",
+		adminID, user.ID)
+	targets := []string{strconv.FormatInt(user.ID, 10)}
+	if ticket != "" {
+		targets = append(targets, ticket)
+	}
+	controller.auditAdminAction(c, r, "issue password reset link", targets...)
+
+	c.Env["PasswordResetLink"] = controller.Cfg.BaseURL +
+		"/passwordupdate?t=" + token.String()
+	c.Env["PasswordResetExpires"] = expires.Unix()
+
+	// Render the page of the user rather than redirecting to it, so that
+	// the link is not stored in the session.
+	r.URL.RawQuery = "user=" + strconv.FormatInt(user.ID, 10)
+	return controller.AdminUser(c, r)
+}
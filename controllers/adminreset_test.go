@@ -0,0 +1,40 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrstakepool/models"
+)
+
+func TestParseAdminPasswordReset(t *testing.T) {
+	withEmail := &models.User{ID: 1, Email: "user@example.com"}
+	withAddress := &models.User{ID: 2}
+	tests := []struct {
+		user             *models.User
+		verified, ticket string
+		wantTicket       string
+		wantErr          bool
+	}{
+		{withEmail, "1", " #1234 ", "#1234", false},
+		{withEmail, "1", "", "", false},
+		{withEmail, "", "#1234", "", true},
+		{withEmail, "1", strings.Repeat("1", maxSupportTicketLength+1), "", true},
+		{withAddress, "1", "", "", true},
+	}
+	for i, test := range tests {
+		ticket, err := parseAdminPasswordReset(test.user, test.verified,
+			test.ticket)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%d: want error %v, got %v", i, test.wantErr, err)
+			continue
+		}
+		if ticket != test.wantTicket {
+			t.Errorf("%d: want ticket %q, got %q", i, test.wantTicket, ticket)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/go-gorp/gorp"
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
+)
+
+// agendaStatsMinUsers is the number of active users below which the vote
+// choices of users are not published, since a breakdown of few users could
+// reveal the choices of individual users.
+const agendaStatsMinUsers = 5
+
+// votingStats aggregates the number of active users per vote bits into the
+// number of users voting for each choice of the deployments of voteVersion.
+// Percentages are of all active users.  It returns nil when there are fewer
+// than agendaStatsMinUsers active users.
+func votingStats(deployments []chaincfg.ConsensusDeployment, voteVersion uint32,
+	counts []models.VoteBitsCount) *poolapi.VotingStats {
+	var users int64
+	for _, c := range counts {
+		users += c.Count
+	}
+	if users < agendaStatsMinUsers {
+		return nil
+	}
+
+	stats := &poolapi.VotingStats{
+		VoteVersion: voteVersion,
+		Users:       users,
+		Agendas:     make([]poolapi.AgendaStats, 0, len(deployments)),
+	}
+	for i := range deployments {
+		vote := &deployments[i].Vote
+		agenda := poolapi.AgendaStats{
+			Agenda:      vote.Id,
+			Description: vote.Description,
+			Choices:     make([]poolapi.AgendaChoiceStats, len(vote.Choices)),
+		}
+		for j, choice := range vote.Choices {
+			agenda.Choices[j].Choice = choice.Id
+			agenda.Choices[j].Description = choice.Description
+			for _, c := range counts {
+				if uint16(c.VoteBits)&vote.Mask == choice.Bits {
+					agenda.Choices[j].Users += c.Count
+				}
+			}
+			agenda.Choices[j].Percent = float64(agenda.Choices[j].Users) *
+				100 / float64(users)
+		}
+		stats.Agendas = append(stats.Agendas, agenda)
+	}
+	return stats
+}
+
+// agendaStats returns how the active users vote on the agendas of the current
+// vote version, or nil if the operator does not publish it or there are too
+// few users to publish it anonymously.
+func (controller *MainController) agendaStats(dbMap *gorp.DbMap) (*poolapi.VotingStats, error) {
+	if controller.Cfg.HideAgendaStats {
+		return nil, nil
+	}
+	counts, err := models.GetVoteBitsCounts(dbMap)
+	if err != nil {
+		return nil, err
+	}
+	return votingStats(controller.getAgendas(), controller.voteVersion, counts), nil
+}
+
+// APIAgendaStats returns the anonymous breakdown of the vote choices of the
+// users on each agenda of the current vote version.
+func (controller *MainController) APIAgendaStats(c web.C, r *http.Request) (*poolapi.VotingStats, codes.Code, string, error) {
+	if controller.Cfg.HideAgendaStats {
+		return nil, codes.Unavailable, "agendastats error",
+			newAPIError(poolapi.ErrNotPublished, "", "agenda statistics are not published")
+	}
+	stats, err := controller.agendaStats(controller.GetReadDbMap(c))
+	if err != nil {
+		log.Errorf("unable to get agenda stats: %v", err)
+		return nil, codes.Internal, "agendastats error",
+			newAPIError(poolapi.ErrInternal, "", "unable to get agenda statistics")
+	}
+	if stats == nil {
+		return nil, codes.Unavailable, "agendastats error",
+			newAPIError(poolapi.ErrNotPublished, "",
+				"too few users to publish agenda statistics")
+	}
+	return stats, codes.OK, "agendastats successfully retrieved", nil
+}
+
+// setAgendaStatsEnv sets the agenda statistics shown on the stats and voting
+// pages, leaving them unset when they are not published.
+func (controller *MainController) setAgendaStatsEnv(c web.C) {
+	stats, err := controller.agendaStats(controller.GetReadDbMap(c))
+	if err != nil {
+		log.Warnf("unable to get agenda stats: %v", err)
+		return
+	}
+	if stats != nil {
+		c.Env["VotingStats"] = stats
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrstakepool/internal/notify"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+	"github.com/go-gorp/gorp"
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
+)
+
+// Names of the alerts evaluated by EvaluateAlerts.
+const (
+	alertMissedVotes = "missedvotes"
+	alertBackendDown = "backenddown"
+	alertDBErrors    = "dberrors"
+)
+
+// alertNames orders the alerts on the status page and in the API.
+var alertNames = []string{alertMissedVotes, alertBackendDown, alertDBErrors}
+
+// alertKinds are the kinds the alerts are sent to operators as.
+var alertKinds = map[string]string{
+	alertMissedVotes: notify.KindMissedVotes,
+	alertBackendDown: notify.KindBackendDown,
+	alertDBErrors:    notify.KindDatabase,
+}
+
+// AlertConfig holds the thresholds of the alerts evaluated by EvaluateAlerts.
+// An alert whose threshold is 0 is disabled.
+type AlertConfig struct {
+	// MissedVotes is the number of tickets missed within
+	// MissedVotesWindow which fires the missedvotes alert.
+	MissedVotes       int64
+	MissedVotesWindow time.Duration
+	// BackendDown is how long a stakepoold instance must be unreachable
+	// to fire the backenddown alert.
+	BackendDown time.Duration
+	// DBErrors is the number of consecutive evaluations whose database
+	// query failed which fires the dberrors alert.
+	DBErrors int
+}
+
+// alertObservation holds what an evaluation of the alerts is based on: the
+// number of tickets missed within the window of the missedvotes alert, or the
+// error of the database query counting them, and the status of the stakepoold
+// instances.
+type alertObservation struct {
+	now         time.Time
+	missedVotes int64
+	dbErr       error
+	backends    []manager.BackendStatus
+}
+
+// alertEvaluator holds the state of the alerts between evaluations.  The zero
+// value has evaluated no alert yet.
+type alertEvaluator struct {
+	mtx       sync.Mutex
+	states    map[string]*poolapi.AlertState
+	downSince map[string]time.Time // [host]
+	dbErrors  int
+}
+
+// update sets the state of the alert name as evaluated at now, and returns
+// whether it started firing.
+func (e *alertEvaluator) update(name string, now time.Time, firing bool, threshold, value, msg string) bool {
+	s, ok := e.states[name]
+	if !ok {
+		s = &poolapi.AlertState{Name: name}
+		e.states[name] = s
+	}
+	s.Threshold = threshold
+	s.Value = value
+	started := firing && !s.Firing
+	switch {
+	case started:
+		s.Since = now.Unix()
+	case !firing && s.Firing:
+		log.Infof("Alert %s resolved after %v", name,
+			now.Sub(time.Unix(s.Since, 0)).Round(time.Second))
+		s.Since = now.Unix()
+		msg = ""
+	case !firing:
+		msg = ""
+	}
+	s.Firing = firing
+	s.Message = msg
+	return started
+}
+
+// evaluate updates the alerts enabled by cfg with obs and returns the alerts
+// which started firing.  The missedvotes alert keeps its state while its
+// database query fails.
+func (e *alertEvaluator) evaluate(cfg *AlertConfig, obs *alertObservation) []poolapi.AlertState {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.states == nil {
+		e.states = make(map[string]*poolapi.AlertState)
+		e.downSince = make(map[string]time.Time)
+	}
+	now := obs.now
+
+	var fired []string
+	if cfg.MissedVotes > 0 && obs.dbErr == nil {
+		firing := obs.missedVotes >= cfg.MissedVotes
+		msg := fmt.Sprintf("%d tickets were missed within the last %v",
+			obs.missedVotes, cfg.MissedVotesWindow)
+		if e.update(alertMissedVotes, now, firing,
+			fmt.Sprintf("%d missed within %v", cfg.MissedVotes,
+				cfg.MissedVotesWindow),
+			fmt.Sprint(obs.missedVotes), msg) {
+			fired = append(fired, alertMissedVotes)
+		}
+	}
+
+	if cfg.BackendDown > 0 {
+		seen := make(map[string]bool, len(obs.backends))
+		var down []string
+		var longest time.Duration
+		for _, s := range obs.backends {
+			seen[s.Host] = true
+			if s.WalletStatus != nil {
+				delete(e.downSince, s.Host)
+				continue
+			}
+			since, ok := e.downSince[s.Host]
+			if !ok {
+				since = now
+				e.downSince[s.Host] = now
+			}
+			d := now.Sub(since)
+			if d > longest {
+				longest = d
+			}
+			if d >= cfg.BackendDown {
+				down = append(down, s.Host)
+			}
+		}
+		for host := range e.downSince {
+			if !seen[host] {
+				delete(e.downSince, host)
+			}
+		}
+		sort.Strings(down)
+		msg := fmt.Sprintf("stakepoold %s unreachable for more than %v, "+
+			"the longest for %v", strings.Join(down, ", "), cfg.BackendDown,
+			longest.Round(time.Second))
+		if e.update(alertBackendDown, now, len(down) > 0,
+			fmt.Sprintf("unreachable for %v", cfg.BackendDown),
+			longest.Round(time.Second).String(), msg) {
+			fired = append(fired, alertBackendDown)
+		}
+	}
+
+	if cfg.DBErrors > 0 {
+		if obs.dbErr != nil {
+			e.dbErrors++
+		} else {
+			e.dbErrors = 0
+		}
+		msg := fmt.Sprintf("%d consecutive database queries failed, last: %v",
+			e.dbErrors, obs.dbErr)
+		if e.update(alertDBErrors, now, e.dbErrors >= cfg.DBErrors,
+			fmt.Sprintf("%d consecutive errors", cfg.DBErrors),
+			fmt.Sprint(e.dbErrors), msg) {
+			fired = append(fired, alertDBErrors)
+		}
+	}
+
+	states := make([]poolapi.AlertState, len(fired))
+	for i, name := range fired {
+		states[i] = *e.states[name]
+	}
+	return states
+}
+
+// current returns the states of the evaluated alerts in the order of
+// alertNames.
+func (e *alertEvaluator) current() []poolapi.AlertState {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	states := make([]poolapi.AlertState, 0, len(alertNames))
+	for _, name := range alertNames {
+		if s, ok := e.states[name]; ok {
+			states = append(states, *s)
+		}
+	}
+	return states
+}
+
+// EvaluateAlerts evaluates the alerts enabled by the alert thresholds and sends
+// those which started firing to the operators with the configured alerter.
+// Their states are shown on the status page and returned by the alerts API
+// command, so that operators without a monitoring system can check them.
+func (controller *MainController) EvaluateAlerts(ctx context.Context, dbMap *gorp.DbMap) {
+	cfg := &controller.Cfg.Alerts
+	obs := &alertObservation{now: time.Now()}
+	since := obs.now.Add(-cfg.MissedVotesWindow).Unix()
+	obs.missedVotes, obs.dbErr = models.GetMissedTicketCountSince(dbMap, since)
+	if obs.dbErr != nil {
+		log.Errorf("unable to count missed tickets: %v", obs.dbErr)
+	}
+	if cfg.BackendDown > 0 {
+		obs.backends = controller.Cfg.StakepooldServers.BackendStatus(ctx)
+	}
+
+	for _, s := range controller.alerts.evaluate(cfg, obs) {
+		log.Critical(s.Message)
+		controller.Cfg.Alerter.Alert(alertKinds[s.Name], s.Message)
+	}
+}
+
+// APIAlerts returns the states of the alerts to admins.
+func (controller *MainController) APIAlerts(c web.C, r *http.Request) ([]poolapi.AlertState, codes.Code, string, error) {
+	if _, err := controller.apiAdminID(c, r); err != nil {
+		return nil, codes.PermissionDenied, "alerts error", err
+	}
+	return controller.alerts.current(), codes.OK, "alerts", nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+)
+
+func TestAlertEvaluator(t *testing.T) {
+	cfg := &AlertConfig{
+		MissedVotes:       3,
+		MissedVotesWindow: time.Hour,
+		BackendDown:       5 * time.Minute,
+		DBErrors:          2,
+	}
+	up := manager.BackendStatus{Host: "a", WalletStatus: &manager.WalletStatus{}}
+	down := manager.BackendStatus{Host: "b"}
+	now := time.Unix(1600000000, 0)
+	var e alertEvaluator
+	evaluate := func(missed int64, dbErr error, backends ...manager.BackendStatus) []string {
+		t.Helper()
+		var names []string
+		for _, s := range e.evaluate(cfg, &alertObservation{
+			now:         now,
+			missedVotes: missed,
+			dbErr:       dbErr,
+			backends:    backends,
+		}) {
+			if !s.Firing || s.Message == "" || s.Since != now.Unix() {
+				t.Fatalf("fired alert %+v", s)
+			}
+			names = append(names, s.Name)
+		}
+		now = now.Add(time.Minute)
+		return names
+	}
+	firing := func() map[string]bool {
+		m := make(map[string]bool)
+		for _, s := range e.current() {
+			m[s.Name] = s.Firing
+		}
+		return m
+	}
+
+	if fired := evaluate(2, nil, up, down); fired != nil {
+		t.Fatalf("fired %v below the thresholds", fired)
+	}
+	if states := e.current(); len(states) != 3 {
+		t.Fatalf("got %d alert states, want 3", len(states))
+	}
+
+	// The missed votes alert fires once on reaching the threshold.
+	if fired := evaluate(3, nil, up, down); len(fired) != 1 ||
+		fired[0] != alertMissedVotes {
+		t.Fatalf("fired %v, want missedvotes", fired)
+	}
+	if fired := evaluate(4, nil, up, down); fired != nil {
+		t.Fatalf("fired %v again", fired)
+	}
+
+	// The backend has been down for 3 minutes, it fires after 5.
+	evaluate(4, nil, up, down)
+	evaluate(4, nil, up, down)
+	if fired := evaluate(4, nil, up, down); len(fired) != 1 ||
+		fired[0] != alertBackendDown {
+		t.Fatalf("fired %v, want backenddown", fired)
+	}
+
+	// Failed queries keep the missed votes alert and fire the database
+	// alert on the second one.
+	dbErr := errors.New("connection refused")
+	if fired := evaluate(0, dbErr, up, down); fired != nil {
+		t.Fatalf("fired %v after a single database error", fired)
+	}
+	if fired := evaluate(0, dbErr, up, down); len(fired) != 1 ||
+		fired[0] != alertDBErrors {
+		t.Fatalf("fired %v, want dberrors", fired)
+	}
+	if f := firing(); !f[alertMissedVotes] || !f[alertBackendDown] ||
+		!f[alertDBErrors] {
+		t.Fatalf("got firing alerts %v, want all", f)
+	}
+
+	// All alerts resolve, and a backend going down again starts over.
+	evaluate(0, nil, up, manager.BackendStatus{Host: "b",
+		WalletStatus: &manager.WalletStatus{}})
+	evaluate(0, nil, up, down)
+	for name, firing := range firing() {
+		if firing {
+			t.Errorf("alert %s still firing", name)
+		}
+	}
+	var resolved poolapi.AlertState
+	for _, s := range e.current() {
+		if s.Name == alertBackendDown {
+			resolved = s
+		}
+	}
+	if resolved.Since == 0 || resolved.Message != "" {
+		t.Errorf("resolved alert %+v", resolved)
+	}
+}
+
+func TestAlertEvaluatorDisabled(t *testing.T) {
+	var e alertEvaluator
+	fired := e.evaluate(&AlertConfig{}, &alertObservation{
+		now:         time.Now(),
+		missedVotes: 100,
+		dbErr:       errors.New("connection refused"),
+		backends:    []manager.BackendStatus{{Host: "a"}},
+	})
+	if len(fired) != 0 || len(e.current()) != 0 {
+		t.Fatalf("disabled alerts evaluated: %v", e.current())
+	}
+}
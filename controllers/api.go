@@ -0,0 +1,554 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrstakepool/helpers"
+	"github.com/decred/dcrstakepool/internal/version"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/decred/dcrstakepool/system"
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
+)
+
+// apiCacheMaxAge is how long clients may cache the responses of the GET API
+// commands polled by wallets and dashboards.  Responses to the commands of a
+// user are private to them.
+var apiCacheMaxAge = map[string]time.Duration{
+	"getpurchaseinfo": 5 * time.Minute,
+	"stats":           time.Minute,
+	"tickets":         30 * time.Second,
+	"agendastats":     5 * time.Minute,
+}
+
+// apiCache returns how clients may cache the successful response to the GET
+// API command, or nil if it may not be cached.
+func apiCache(c web.C, r *http.Request, command string) *system.APICache {
+	maxAge, ok := apiCacheMaxAge[command]
+	if !ok {
+		return nil
+	}
+	cache := &system.APICache{Key: r.URL.Path, MaxAge: maxAge}
+	if userID, ok := c.Env["APIUserID"].(int64); ok {
+		cache.Key = fmt.Sprintf("%s:%d:%t", cache.Key, userID, apiReadOnly(c))
+		cache.Private = true
+	}
+	return cache
+}
+
+// API is the main frontend that handles all API requests.
+func (controller *MainController) API(c web.C, r *http.Request) *system.APIResponse {
+	command := c.URLParams["command"]
+
+	// poolapi.Response comprises a status, code, message, and a data struct
+	var code codes.Code
+	var response, status string
+	var data interface{}
+
+	var err error
+
+	switch r.Method {
+	case "GET":
+		switch command {
+		case "getpurchaseinfo":
+			data, code, response, err = controller.APIPurchaseInfo(c, r)
+		case "estimate":
+			data, code, response, err = controller.APIEstimate(c, r)
+		case "stats":
+			data, code, response, err = controller.APIStats(c, r)
+		case "tickets":
+			data, code, response, err = controller.APITickets(c, r)
+		case "messages":
+			data, code, response, err = controller.APIMessages(c, r)
+		case "votingprefs":
+			data, code, response, err = controller.APIVotingPrefs(c, r)
+		case "maintenance":
+			data, code, response, err = controller.APIMaintenance(c, r)
+		case "readonly":
+			data, code, response, err = controller.APIReadOnly(c, r)
+		case "agendastats":
+			data, code, response, err = controller.APIAgendaStats(c, r)
+		case "alerts":
+			data, code, response, err = controller.APIAlerts(c, r)
+		default:
+			return nil
+		}
+	case "POST":
+		// Read-only tokens are only usable with the GET commands, so
+		// that POST commands cannot forget to refuse them.
+		if apiReadOnly(c) {
+			code, response, err = codes.PermissionDenied,
+				command+" error", errAPIReadOnlyToken
+			break
+		}
+		switch command {
+		case "address":
+			_, code, response, err = controller.APIAddress(c, r)
+		case "voting":
+			_, code, response, err = controller.APIVoting(c, r)
+		case "validateaddress":
+			data, code, response, err = controller.APIValidateAddress(c, r)
+		case "messagesread":
+			_, code, response, err = controller.APIMessagesRead(c, r)
+		case "votingprefs":
+			data, code, response, err = controller.APIVotingPrefsImport(c, r)
+		case "maintenance":
+			data, code, response, err = controller.APIMaintenanceSet(c, r)
+		case "readonly":
+			data, code, response, err = controller.APIReadOnlySet(c, r)
+		case "debuglevel":
+			data, code, response, err = controller.APIDebugLevelSet(c, r)
+		case "login":
+			data, code, response, err = controller.APILogin(c, r)
+		case "refresh":
+			data, code, response, err = controller.APIRefresh(c, r)
+		case "logout":
+			_, code, response, err = controller.APILogout(c, r)
+		default:
+			return nil
+		}
+	}
+
+	if err != nil {
+		status = "error"
+		response = response + " - " + err.Error()
+	} else {
+		status = "success"
+	}
+
+	resp := system.NewAPIResponse(status, code, response, data)
+	if err != nil {
+		resp.Errors = apiErrors(code, err)
+	} else if r.Method == "GET" {
+		resp.Cache = apiCache(c, r, command)
+	}
+	return resp
+}
+
+// apiReadOnly returns whether the API request was made with a read-only token.
+func apiReadOnly(c web.C) bool {
+	readOnly, _ := c.Env["APIReadOnly"].(bool)
+	return readOnly
+}
+
+// APIAddress is the API version of AddressPost
+func (controller *MainController) APIAddress(c web.C, r *http.Request) ([]string, codes.Code, string, error) {
+	dbMap := controller.GetDbMap(c)
+
+	if c.Env["APIUserID"] == nil {
+		return nil, codes.Unauthenticated, "address error", errAPIInvalidToken
+	}
+
+	user, _ := models.GetUserByID(dbMap, c.Env["APIUserID"].(int64))
+
+	if controller.Cfg.TOSVersion != "" && user.TOSVersion != controller.Cfg.TOSVersion {
+		return nil, codes.FailedPrecondition, "address error",
+			newAPIError(poolapi.ErrTOSNotAccepted, "", "terms of service not accepted")
+	}
+
+	if len(user.UserPubKeyAddr) > 0 {
+		return nil, codes.AlreadyExists, "address error",
+			newAPIError(poolapi.ErrAddressExists, "", "address already submitted")
+	}
+	if ends, cooldown := emailCooldownEnds(user, controller.Cfg.EmailCooldown, time.Now()); cooldown {
+		return nil, codes.FailedPrecondition, "address error",
+			newAPIError(poolapi.ErrEmailCooldown, "", emailCooldownMessage(ends))
+	}
+	if controller.walletsSyncing() > 0 {
+		return nil, codes.Unavailable, "address error",
+			newAPIError(poolapi.ErrWalletSyncing, "", walletSyncingMessage)
+	}
+
+	userPubKeyAddr := r.FormValue("UserPubKeyAddr")
+
+	u, err := validateUserPubKeyAddr(userPubKeyAddr, controller.Cfg.NetParams)
+	if err != nil {
+		return nil, codes.InvalidArgument, "address error",
+			wrapAPIError(poolapi.ErrInvalidAddress, "UserPubKeyAddr", err)
+	}
+
+	reuse, err := controller.addressReuse(r.Context(), dbMap, user.ID, u)
+	if err != nil {
+		log.Warnf("unable to check reuse of address %s: %v", userPubKeyAddr, err)
+	}
+	if reuse != "" {
+		log.Warnf("User %d submitted reused address %s: %s", user.ID,
+			userPubKeyAddr, reuse)
+		if controller.Cfg.RejectReusedAddrs {
+			return nil, codes.InvalidArgument, "address error",
+				newAPIError(poolapi.ErrAddressReused, "UserPubKeyAddr", reuse)
+		}
+	}
+
+	// Get the ticket address for this user
+	pooladdress, err := controller.TicketAddressForUserID(dbMap, int(user.ID))
+	if err != nil {
+		log.Errorf("unable to derive ticket address: %v", err)
+		return nil, codes.Unavailable, "system error", errAPIWallet
+	}
+
+	poolValidateAddress, err := controller.Cfg.StakepooldServers.ValidateAddress(r.Context(), pooladdress)
+	if err != nil {
+		log.Errorf("unable to validate address: %v", err)
+		return nil, codes.Unavailable, "system error", errAPIWallet
+	}
+	if !poolValidateAddress.IsMine {
+		log.Errorf("unable to validate ismine for pool ticket address: %s",
+			pooladdress.String())
+		return nil, codes.Unavailable, "system error", errAPIWallet
+	}
+
+	poolPubKeyAddr := poolValidateAddress.PubKeyAddr
+
+	if _, err = dcrutil.DecodeAddress(poolPubKeyAddr, controller.Cfg.NetParams); err != nil {
+		return nil, codes.Unavailable, "system error", errAPIWallet
+	}
+
+	createMultiSig, err := controller.Cfg.StakepooldServers.CreateMultisig(r.Context(), []string{poolPubKeyAddr, userPubKeyAddr})
+	if err != nil {
+		return nil, codes.Unavailable, "system error", errAPIWallet
+	}
+
+	// Serialize the redeem script (hex string -> []byte)
+	serializedScript, err := hex.DecodeString(createMultiSig.RedeemScript)
+	if err != nil {
+		return nil, codes.Unavailable, "system error", errAPIWallet
+	}
+
+	// Import the redeem script
+	var importedHeight int64
+	importedHeight, err = controller.Cfg.StakepooldServers.ImportNewScript(r.Context(), serializedScript)
+	if err != nil && !writeApplied(err) {
+		return nil, codes.Unavailable, "system error", errAPIWallet
+	}
+
+	userFeeAddr, err := controller.FeeAddressForUserID(dbMap, int(user.ID))
+	if err != nil {
+		log.Warnf("unexpected error deriving pool addr: %s", err.Error())
+		return nil, codes.Unavailable, "system error", errAPIWallet
+	}
+
+	err = models.SetUserScript(dbMap, user.ID, &models.UserScript{
+		MultiSigAddress:  createMultiSig.Address,
+		MultiSigScript:   createMultiSig.RedeemScript,
+		PoolPubKeyAddr:   poolPubKeyAddr,
+		UserPubKeyAddr:   userPubKeyAddr,
+		UserFeeAddr:      userFeeAddr.Address(),
+		HeightRegistered: importedHeight,
+	}, nil, time.Now().Unix())
+	if errors.Is(err, models.ErrAddressSubmitted) {
+		return nil, codes.AlreadyExists, "address error",
+			newAPIError(poolapi.ErrAddressExists, "", "address already submitted")
+	}
+	if err != nil {
+		log.Errorf("unable to store the script of user %d: %v", user.ID, err)
+		return nil, codes.Internal, "system error",
+			newAPIError(poolapi.ErrInternal, "", "failed to store address")
+	}
+	notifyFeeAddress(dbMap, user.ID, userFeeAddr.Address())
+
+	log.Infof("successfully create multisigaddress for user %d", c.Env["APIUserID"])
+	controller.recordUserActivity(c, r, user.ID, activityAddress, userPubKeyAddr)
+
+	err = controller.SendStakepooldUpdates(r.Context(), dbMap)
+	if err != nil {
+		log.Warnf("failure to update users: %v", err)
+	}
+
+	if reuse != "" {
+		return nil, codes.OK, "address successfully imported, warning: " + reuse, nil
+	}
+	return nil, codes.OK, "address successfully imported", nil
+}
+
+// APIValidateAddress checks the UserPubKeyAddr form value as the address
+// command would, without submitting it, so that the address page and clients
+// can tell users about an invalid address before they submit it.  Invalid
+// addresses fail with the error code of the failed check, i.e. whether the
+// address is too short or long, cannot be decoded, is for another network or
+// is not a public key address.  It needs no API token.
+func (controller *MainController) APIValidateAddress(c web.C, r *http.Request) (*poolapi.ValidAddress, codes.Code, string, error) {
+	userPubKeyAddr := r.FormValue("UserPubKeyAddr")
+	u, err := validateUserPubKeyAddr(userPubKeyAddr, controller.Cfg.NetParams)
+	if err != nil {
+		return nil, codes.InvalidArgument, "invalid address", err
+	}
+	return &poolapi.ValidAddress{
+		Address: userPubKeyAddr,
+		Network: controller.Cfg.NetParams.Name,
+		PubKey:  hex.EncodeToString(u.ScriptAddress()),
+	}, codes.OK, "valid address", nil
+}
+
+// APIPurchaseInfo fetches and returns the user's info or an error
+func (controller *MainController) APIPurchaseInfo(c web.C,
+	r *http.Request) (*poolapi.PurchaseInfo, codes.Code, string, error) {
+	dbMap := controller.GetDbMap(c)
+
+	if c.Env["APIUserID"] == nil {
+		return nil, codes.Unauthenticated, "purchaseinfo error", errAPIInvalidToken
+	}
+
+	user, _ := models.GetUserByID(dbMap, c.Env["APIUserID"].(int64))
+
+	if len(user.UserPubKeyAddr) == 0 {
+		return nil, codes.FailedPrecondition, "purchaseinfo error", errAPINoAddress
+	}
+
+	// Refuse to hand out a multisig which does not match the keys it was
+	// created from, e.g. after database corruption, since tickets bought
+	// with it could not be voted.
+	err := helpers.VerifyMultisigScript(user.MultiSigScript,
+		user.MultiSigAddress, user.PoolPubKeyAddr, user.UserPubKeyAddr,
+		controller.Cfg.NetParams)
+	if err != nil {
+		log.Errorf("multisig of UserId %v does not verify: %v", user.ID, err)
+		return nil, codes.Internal, "purchaseinfo error",
+			newAPIError(poolapi.ErrInternal, "", "multisig script does not verify")
+	}
+	scriptHash, _ := helpers.MultisigScriptHash(user.MultiSigScript)
+
+	purchaseInfo := &poolapi.PurchaseInfo{
+		PoolAddress:       user.UserFeeAddr,
+		PoolFees:          controller.Cfg.PoolFees,
+		Script:            user.MultiSigScript,
+		ScriptHash:        scriptHash,
+		PoolPubKeyAddress: user.PoolPubKeyAddr,
+		UserPubKeyAddress: user.UserPubKeyAddr,
+		TicketAddress:     user.MultiSigAddress,
+		VoteBits:          uint16(user.VoteBits),
+	}
+
+	// The redeem script is only needed to purchase tickets, which a
+	// dashboard using a read-only token has no business doing.
+	if apiReadOnly(c) {
+		purchaseInfo.Script = ""
+	}
+
+	return purchaseInfo, codes.OK, "purchaseinfo successfully retrieved", nil
+}
+
+// APIEstimate returns the expected time until a ticket purchased now votes
+// and the reward it earns.
+func (controller *MainController) APIEstimate(c web.C,
+	r *http.Request) (*poolapi.Estimate, codes.Code, string, error) {
+	gsi, err := controller.Cfg.StakepooldServers.GetStakeInfo(r.Context())
+	if err != nil {
+		log.Infof("RPC GetStakeInfo failed: %v", err)
+		return nil, codes.Unavailable, "estimate error", errAPIRPCServer
+	}
+
+	estimate, err := estimateTicket(controller.Cfg.NetParams, gsi.BlockHeight,
+		gsi.Difficulty, gsi.PoolSize, controller.Cfg.PoolFees)
+	if err != nil {
+		return nil, codes.Unavailable, "estimate error",
+			wrapAPIError(poolapi.ErrUnavailable, "", err)
+	}
+
+	return estimate, codes.OK, "estimate successfully retrieved", nil
+}
+
+// APIStats is an API version of the stats page
+func (controller *MainController) APIStats(c web.C,
+	r *http.Request) (*poolapi.Stats, codes.Code, string, error) {
+	dbMap := controller.GetReadDbMap(c)
+	userCount := models.GetUserCount(dbMap)
+	userCountActive := models.GetUserCountActive(dbMap)
+
+	gsi, err := controller.Cfg.StakepooldServers.GetStakeInfo(r.Context())
+	if err != nil {
+		log.Infof("RPC GetStakeInfo failed: %v", err)
+		return nil, codes.Unavailable, "stats error", errAPIRPCServer
+	}
+
+	luck, err := controller.luck(dbMap)
+	if err != nil {
+		log.Warnf("unable to get luck: %v", err)
+	}
+
+	var poolStatus string
+	if controller.Cfg.ClosePool {
+		poolStatus = "Closed"
+	} else {
+		poolStatus = "Open"
+	}
+
+	stats := &poolapi.Stats{
+		AllMempoolTix:        gsi.AllMempoolTix,
+		APIVersionsSupported: controller.Cfg.APIVersionsSupported,
+		BlockHeight:          gsi.BlockHeight,
+		Difficulty:           gsi.Difficulty,
+		Expired:              gsi.Expired,
+		Immature:             gsi.Immature,
+		Live:                 gsi.Live,
+		Missed:               gsi.Missed,
+		OwnMempoolTix:        gsi.OwnMempoolTix,
+		PoolSize:             gsi.PoolSize,
+		ProportionLive:       gsi.ProportionLive,
+		ProportionMissed:     gsi.ProportionMissed,
+		Revoked:              gsi.Revoked,
+		TotalSubsidy:         gsi.TotalSubsidy,
+		Voted:                gsi.Voted,
+		Network:              controller.Cfg.NetParams.Name,
+		PoolEmail:            controller.Cfg.PoolEmail,
+		PoolFees:             controller.Cfg.PoolFees,
+		PoolStatus:           poolStatus,
+		UserCount:            userCount,
+		UserCountActive:      userCountActive,
+		Version:              version.String(),
+		Luck:                 luck,
+	}
+
+	return stats, codes.OK, "stats successfully retrieved", nil
+}
+
+// APIVSPInfo writes the unwrapped VSPInfo capabilities document, served at
+// /api/vspinfo like the vspinfo endpoint of vspd.
+func (controller *MainController) APIVSPInfo(c web.C, w http.ResponseWriter, r *http.Request) {
+	gsi, err := controller.Cfg.StakepooldServers.GetStakeInfo(r.Context())
+	if err != nil {
+		log.Infof("RPC GetStakeInfo failed: %v", err)
+		resp := system.NewAPIResponse("error", codes.Unavailable,
+			"vspinfo error - RPC server error", nil)
+		resp.Errors = apiErrors(codes.Unavailable, errAPIRPCServer)
+		system.WriteAPIResponse(resp, http.StatusServiceUnavailable, w)
+		return
+	}
+
+	info := &poolapi.VSPInfo{
+		APIVersions:   controller.Cfg.APIVersionsSupported,
+		Timestamp:     time.Now().Unix(),
+		FeePercentage: controller.Cfg.PoolFees,
+		VspClosed:     controller.Cfg.ClosePool,
+		Network:       controller.Cfg.NetParams.Name,
+		VspdVersion:   version.String(),
+		VoteVersion:   controller.voteVersion,
+		Voting:        gsi.Live,
+		Voted:         gsi.Voted,
+		Revoked:       gsi.Revoked,
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		log.Warnf("JSON encode error: %v", err)
+	}
+}
+
+// APITickets returns the tickets of the authenticated user, including an
+// estimate of when immature tickets go live.
+func (controller *MainController) APITickets(c web.C,
+	r *http.Request) (*poolapi.Tickets, codes.Code, string, error) {
+	dbMap := controller.GetDbMap(c)
+
+	if c.Env["APIUserID"] == nil {
+		return nil, codes.Unauthenticated, "tickets error", errAPIInvalidToken
+	}
+
+	user, _ := models.GetUserByID(dbMap, c.Env["APIUserID"].(int64))
+
+	if user.MultiSigAddress == "" {
+		return nil, codes.FailedPrecondition, "tickets error", errAPINoAddress
+	}
+
+	spui, err := controller.Cfg.StakepooldServers.StakePoolUserInfo(r.Context(), user.MultiSigAddress)
+	if err != nil {
+		log.Errorf("RPC StakePoolUserInfo failed: %v", err)
+		return nil, codes.Unavailable, "tickets error", errAPIRPCServer
+	}
+
+	gsi, err := controller.Cfg.StakepooldServers.GetStakeInfo(r.Context())
+	if err != nil {
+		log.Errorf("RPC GetStakeInfo failed: %v", err)
+		return nil, codes.Unavailable, "tickets error", errAPIRPCServer
+	}
+
+	tickets := &poolapi.Tickets{
+		BlockHeight:    gsi.BlockHeight,
+		Tickets:        make([]poolapi.Ticket, 0),
+		InvalidTickets: make([]string, 0),
+	}
+	if spui != nil {
+		for _, ticket := range spui.Tickets {
+			t := poolapi.Ticket{
+				Ticket:        ticket.Ticket,
+				Status:        ticket.Status,
+				TicketHeight:  ticket.TicketHeight,
+				SpentBy:       ticket.SpentBy,
+				SpentByHeight: ticket.SpentByHeight,
+			}
+			if ticket.Status == "immature" {
+				liveHeight, liveTime := controller.ticketLiveEstimate(
+					ticket.TicketHeight, gsi.BlockHeight)
+				t.LiveHeight = liveHeight
+				t.LiveTime = liveTime.Unix()
+			}
+			tickets.Tickets = append(tickets.Tickets, t)
+		}
+		tickets.InvalidTickets = append(tickets.InvalidTickets,
+			spui.InvalidTickets...)
+		tickets.Summary = controller.userTicketSummary(r.Context(), spui.Tickets)
+	}
+
+	return tickets, codes.OK, "tickets successfully retrieved", nil
+}
+
+// APIVoting is the API version of Voting
+func (controller *MainController) APIVoting(c web.C, r *http.Request) ([]string, codes.Code, string, error) {
+	dbMap := controller.GetDbMap(c)
+
+	if c.Env["APIUserID"] == nil {
+		return nil, codes.Unauthenticated, "voting error", errAPIInvalidToken
+	}
+
+	user, _ := models.GetUserByID(dbMap, c.Env["APIUserID"].(int64))
+	oldVoteBits := user.VoteBits
+
+	vb := r.FormValue("VoteBits")
+	vbi, err := strconv.Atoi(vb)
+	if err != nil {
+		return nil, codes.InvalidArgument, "voting error",
+			newAPIError(poolapi.ErrInvalidVoteBits, "VoteBits",
+				"unable to convert votebits to uint16")
+	}
+	userVoteBits := uint16(vbi)
+
+	if !controller.IsValidVoteBits(userVoteBits) {
+		return nil, codes.InvalidArgument, "voting error",
+			newAPIError(poolapi.ErrInvalidVoteBits, "VoteBits",
+				"votebits invalid for current agendas")
+	}
+	userVoteBits = controller.forceChoices(userVoteBits)
+
+	user, err = helpers.UpdateVoteBitsByID(dbMap, user.ID, userVoteBits,
+		controller.voteVersion)
+	if err != nil {
+		return nil, codes.Internal, "voting error",
+			newAPIError(poolapi.ErrInternal, "",
+				"failed to update voting prefs in database")
+	}
+
+	if uint16(oldVoteBits) != userVoteBits {
+		controller.recordUserActivity(c, r, user.ID, activityVoting,
+			voteBitsChange(oldVoteBits, userVoteBits))
+		if err := controller.SendStakepooldUpdates(r.Context(), dbMap); err != nil {
+			log.Warnf("APIVoting: SendStakepooldUpdates failed: %v", err)
+		}
+	}
+
+	log.Infof("updated voteBits for user %d from %d to %d",
+		user.ID, oldVoteBits, userVoteBits)
+
+	return nil, codes.OK, "successfully updated voting preferences", nil
+}
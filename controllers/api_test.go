@@ -0,0 +1,232 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+	"github.com/go-gorp/gorp"
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
+)
+
+// tAPIContext returns the context of an API request for command made with the
+// token of userID, which is read-only when readOnly is set.  A userID of 0
+// means no token was sent.
+func tAPIContext(command string, userID int64, readOnly bool) web.C {
+	c := web.C{
+		URLParams: map[string]string{"command": command},
+		Env: map[interface{}]interface{}{
+			"DbMap":     (*gorp.DbMap)(nil),
+			"ReadDbMap": (*gorp.DbMap)(nil),
+		},
+	}
+	if userID != 0 {
+		c.Env["APIUserID"] = userID
+		c.Env["APIReadOnly"] = readOnly
+	}
+	return c
+}
+
+func TestAPIReadOnly(t *testing.T) {
+	if apiReadOnly(tAPIContext("stats", 0, false)) {
+		t.Error("request without a token is read-only")
+	}
+	if apiReadOnly(tAPIContext("stats", 1, false)) {
+		t.Error("request with a read-write token is read-only")
+	}
+	if !apiReadOnly(tAPIContext("stats", 1, true)) {
+		t.Error("request with a read-only token is not read-only")
+	}
+}
+
+func TestAPIUnknownCommand(t *testing.T) {
+	controller := &MainController{Cfg: &Config{}}
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		r := httptest.NewRequest(method, "/api/v3/unknown", nil)
+		if resp := controller.API(tAPIContext("unknown", 1, false), r); resp != nil {
+			t.Errorf("%s of an unknown command answered with %+v", method,
+				resp)
+		}
+	}
+}
+
+func TestAPIWriteCommandsRequireToken(t *testing.T) {
+	controller := &MainController{Cfg: &Config{}}
+	tests := []struct {
+		command  string
+		userID   int64
+		readOnly bool
+		code     codes.Code
+		errCode  string
+	}{
+		{"address", 0, false, codes.Unauthenticated, poolapi.ErrInvalidAPIToken},
+		{"address", 1, true, codes.PermissionDenied, poolapi.ErrReadOnlyAPIToken},
+		{"voting", 0, false, codes.Unauthenticated, poolapi.ErrInvalidAPIToken},
+		{"voting", 1, true, codes.PermissionDenied, poolapi.ErrReadOnlyAPIToken},
+		{"votingprefs", 1, true, codes.PermissionDenied, poolapi.ErrReadOnlyAPIToken},
+		{"messagesread", 1, true, codes.PermissionDenied, poolapi.ErrReadOnlyAPIToken},
+		{"debuglevel", 1, true, codes.PermissionDenied, poolapi.ErrReadOnlyAPIToken},
+	}
+	for _, test := range tests {
+		c := tAPIContext(test.command, test.userID, test.readOnly)
+		r := httptest.NewRequest(http.MethodPost, "/api/v3/"+test.command, nil)
+		resp := controller.API(c, r)
+		if resp == nil || resp.Status != "error" || resp.Code != test.code ||
+			len(resp.Errors) != 1 || resp.Errors[0].Code != test.errCode {
+			t.Errorf("%s with user %d read-only %v: got %+v, want code "+
+				"%v and error %s", test.command, test.userID,
+				test.readOnly, resp, test.code, test.errCode)
+		}
+	}
+}
+
+func TestAPIValidateAddress(t *testing.T) {
+	controller := &MainController{Cfg: &Config{
+		NetParams: chaincfg.TestNet3Params(),
+	}}
+	// The compressed public key of the secp256k1 generator point.
+	pubKey, _ := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfc" +
+		"db2dce28d959f2815b16f81798")
+	address := func(params *chaincfg.Params) string {
+		t.Helper()
+		addr, err := dcrutil.NewAddressSecpPubKey(pubKey, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return addr.String()
+	}
+	testnetAddr := address(chaincfg.TestNet3Params())
+	schnorrAddr, err := dcrutil.NewAddressSecSchnorrPubKey(pubKey,
+		chaincfg.TestNet3Params())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		address string
+		errCode string
+	}{
+		{testnetAddr, ""},
+		{"TskTcbmvjYxduojxjAnTGxLArnGo4EFnoi3", poolapi.ErrAddressLength},
+		{testnetAddr + strings.Repeat("1", 20), poolapi.ErrAddressLength},
+		{testnetAddr[:len(testnetAddr)-1] + "1", poolapi.ErrAddressEncoding},
+		{address(chaincfg.MainNetParams()), poolapi.ErrAddressNetwork},
+		{schnorrAddr.String(), poolapi.ErrAddressType},
+	}
+	for _, test := range tests {
+		form := url.Values{"UserPubKeyAddr": {test.address}}
+		r := httptest.NewRequest(http.MethodPost, "/api/v3/validateaddress",
+			strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp := controller.API(tAPIContext("validateaddress", 0, false), r)
+		if resp == nil {
+			t.Fatalf("%s: no response", test.address)
+		}
+		if test.errCode == "" {
+			valid, ok := resp.Data.(*poolapi.ValidAddress)
+			if resp.Status != "success" || !ok || valid.Network != "testnet3" ||
+				valid.PubKey != hex.EncodeToString(pubKey) {
+				t.Errorf("%s: got %+v, want valid", test.address, resp)
+			}
+			continue
+		}
+		if resp.Status != "error" || resp.Code != codes.InvalidArgument ||
+			len(resp.Errors) != 1 || resp.Errors[0].Code != test.errCode ||
+			resp.Errors[0].Field != "UserPubKeyAddr" {
+			t.Errorf("%s: got %+v, want error %s", test.address, resp,
+				test.errCode)
+		}
+	}
+}
+
+func TestAPIVSPInfo(t *testing.T) {
+	mock := &manager.Mock{
+		GetStakeInfoFunc: func(context.Context) (*pb.GetStakeInfoResponse, error) {
+			return &pb.GetStakeInfoResponse{Live: 5, Voted: 3, Revoked: 1}, nil
+		},
+	}
+	controller := &MainController{
+		Cfg: &Config{
+			APIVersionsSupported: []int{2, 3},
+			PoolFees:             7.5,
+			NetParams:            chaincfg.TestNet3Params(),
+			StakepooldServers:    mock,
+		},
+		voteVersion: 8,
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/vspinfo", nil)
+	controller.APIVSPInfo(web.C{}, w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// The response must decode with the field names of the vspinfo
+	// response of vspd, and the unused pubkey is omitted.
+	var info map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"apiversions":   []interface{}{2.0, 3.0},
+		"feepercentage": 7.5,
+		"vspclosed":     false,
+		"network":       "testnet3",
+		"voteversion":   8.0,
+		"voting":        5.0,
+		"voted":         3.0,
+		"revoked":       1.0,
+	}
+	for field, value := range want {
+		if !reflect.DeepEqual(info[field], value) {
+			t.Errorf("got %s %v, want %v", field, info[field], value)
+		}
+	}
+	for _, field := range []string{"timestamp", "vspdversion"} {
+		if _, ok := info[field]; !ok {
+			t.Errorf("missing %s", field)
+		}
+	}
+	if _, ok := info["pubkey"]; ok {
+		t.Error("got a pubkey, want none")
+	}
+	if len(info) != len(want)+2 {
+		t.Errorf("got fields %v", info)
+	}
+
+	// An unavailable stakepoold is reported as a wrapped API error.
+	mock.GetStakeInfoFunc = func(context.Context) (*pb.GetStakeInfoResponse, error) {
+		return nil, errors.New("unavailable")
+	}
+	w = httptest.NewRecorder()
+	controller.APIVSPInfo(web.C{}, w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got status %d, want %d", w.Code,
+			http.StatusServiceUnavailable)
+	}
+	var resp poolapi.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "error" || len(resp.Errors) != 1 ||
+		resp.Errors[0].Code != poolapi.ErrUnavailable {
+		t.Errorf("got %+v, want an unavailable error", resp)
+	}
+}
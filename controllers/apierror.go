@@ -0,0 +1,83 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"errors"
+
+	"github.com/decred/dcrstakepool/poolapi"
+	"google.golang.org/grpc/codes"
+)
+
+// apiError is an error of an API handler along with the machine-readable code
+// and the form value it is about, which are returned to clients in the errors
+// of the response.
+type apiError struct {
+	code  string
+	field string
+	err   error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+// newAPIError returns an error with msg, code and the name of the form value
+// it is about, or "".
+func newAPIError(code, field, msg string) error {
+	return &apiError{code: code, field: field, err: errors.New(msg)}
+}
+
+// wrapAPIError returns err with code and the name of the form value it is
+// about, or "".
+func wrapAPIError(code, field string, err error) error {
+	return &apiError{code: code, field: field, err: err}
+}
+
+// Errors shared by the API handlers.
+var (
+	errAPIInvalidToken  = newAPIError(poolapi.ErrInvalidAPIToken, "", "invalid api token")
+	errAPIReadOnlyToken = newAPIError(poolapi.ErrReadOnlyAPIToken, "", "read-only api token")
+	errAPINotAdmin      = newAPIError(poolapi.ErrNotAdmin, "", "not an admin")
+	errAPINoAddress     = newAPIError(poolapi.ErrNoAddress, "", "no address submitted")
+	errAPIRPCServer     = newAPIError(poolapi.ErrUnavailable, "", "RPC server error")
+	errAPIWallet        = newAPIError(poolapi.ErrUnavailable, "", "unable to process wallet commands")
+	errAPIMaintenance   = newAPIError(poolapi.ErrMaintenance, "", "voting service is down for maintenance")
+	errAPIReadOnly      = newAPIError(poolapi.ErrReadOnly, "", "voting service is temporarily read-only")
+)
+
+// apiErrorCodes are the error codes of errors returned by API handlers
+// without one, by their status code.
+var apiErrorCodes = map[codes.Code]string{
+	codes.Unauthenticated:    poolapi.ErrInvalidAPIToken,
+	codes.InvalidArgument:    poolapi.ErrInvalidArgument,
+	codes.Unavailable:        poolapi.ErrUnavailable,
+	codes.Internal:           poolapi.ErrInternal,
+	codes.PermissionDenied:   poolapi.ErrNotAdmin,
+	codes.AlreadyExists:      poolapi.ErrInvalidArgument,
+	codes.FailedPrecondition: poolapi.ErrInvalidArgument,
+}
+
+// apiErrors returns the errors of the response to an API request which failed
+// with code and err.
+func apiErrors(code codes.Code, err error) []poolapi.Error {
+	var ae *apiError
+	if errors.As(err, &ae) {
+		return []poolapi.Error{{
+			Code:    ae.code,
+			Field:   ae.field,
+			Message: ae.Error(),
+		}}
+	}
+	errCode, ok := apiErrorCodes[code]
+	if !ok {
+		errCode = poolapi.ErrInternal
+	}
+	return []poolapi.Error{{Code: errCode, Message: err.Error()}}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/decred/dcrstakepool/helpers"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/go-gorp/gorp"
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
+)
+
+// Errors of the API login.
+var (
+	errAPIInvalidLogin = newAPIError(poolapi.ErrInvalidLogin, "",
+		"invalid email or password")
+	errAPIEmailNotVerified = newAPIError(poolapi.ErrEmailNotVerified, "",
+		"email address not verified")
+	errAPIInvalidRefresh = newAPIError(poolapi.ErrInvalidRefresh,
+		"refresh_token", "invalid or expired refresh token")
+)
+
+// issueLoginTokens issues a short-lived API token and a refresh token to an
+// app logged in as the user with the passed id.  A refresh token which was
+// already issued may be passed in refresh, with its expiry.
+func (controller *MainController) issueLoginTokens(dbMap *gorp.DbMap, userID int64,
+	refresh string, refreshExpires time.Time) (*poolapi.LoginTokens, error) {
+	if refresh == "" {
+		refreshExpires = time.Now().Add(controller.Cfg.RefreshTokenLifetime)
+		var err error
+		refresh, err = models.InsertAPIRefreshToken(dbMap, userID,
+			refreshExpires.Unix())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	token, expires, err := controller.Cfg.APIKeys.SignShortLivedToken(
+		controller.Cfg.BaseURL, userID, controller.Cfg.LoginTokenLifetime)
+	if err != nil {
+		return nil, err
+	}
+
+	return &poolapi.LoginTokens{
+		AccessToken:         token,
+		AccessTokenExpires:  expires.Unix(),
+		RefreshToken:        refresh,
+		RefreshTokenExpires: refreshExpires.Unix(),
+	}, nil
+}
+
+// APILogin is the API version of LoginPost for apps.  It authenticates the
+// email and password form values and returns a short-lived API token with a
+// refresh token, so that apps do not need to store the password.
+func (controller *MainController) APILogin(c web.C, r *http.Request) (*poolapi.LoginTokens, codes.Code, string, error) {
+	email, password := r.FormValue("email"), r.FormValue("password")
+	dbMap := controller.GetDbMap(c)
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+
+	user, err := helpers.Login(dbMap, email, password)
+	if err != nil {
+		log.Infof(email+" API login failed %v, %v", err, remoteIP)
+		return nil, codes.Unauthenticated, "login error", errAPIInvalidLogin
+	}
+
+	log.Infof("API login from %v, email %v", remoteIP, user.Email)
+
+	rehashPassword(dbMap, user, password)
+
+	if user.EmailVerified == 0 {
+		return nil, codes.FailedPrecondition, "login error", errAPIEmailNotVerified
+	}
+
+	tokens, err := controller.issueLoginTokens(dbMap, user.ID, "", time.Time{})
+	if err != nil {
+		log.Errorf("APILogin: unable to issue tokens for user %d: %v",
+			user.ID, err)
+		return nil, codes.Internal, "login error", err
+	}
+	controller.recordUserActivity(c, r, user.ID, activityAPILogin, "")
+
+	return tokens, codes.OK, "logged in", nil
+}
+
+// APIRefresh exchanges the refresh_token form value for a new API token and
+// refresh token.  Each refresh token can only be exchanged once, and
+// presenting it again revokes every refresh token of the user, who must then
+// log in again.
+func (controller *MainController) APIRefresh(c web.C, r *http.Request) (*poolapi.LoginTokens, codes.Code, string, error) {
+	dbMap := controller.GetDbMap(c)
+	refreshExpires := time.Now().Add(controller.Cfg.RefreshTokenLifetime)
+
+	userID, refresh, err := models.RotateAPIRefreshToken(dbMap,
+		r.FormValue("refresh_token"), refreshExpires.Unix())
+	switch err {
+	case nil:
+	case models.ErrInvalidRefreshToken:
+		return nil, codes.Unauthenticated, "refresh error", errAPIInvalidRefresh
+	case models.ErrRefreshTokenReused:
+		log.Warnf("APIRefresh: reused refresh token of user %d from %v, "+
+			"revoked all of them", userID,
+			getClientIP(r, controller.Cfg.RealIPHeader))
+		return nil, codes.Unauthenticated, "refresh error", errAPIInvalidRefresh
+	default:
+		log.Errorf("APIRefresh: RotateAPIRefreshToken failed: %v", err)
+		return nil, codes.Internal, "refresh error", err
+	}
+
+	tokens, err := controller.issueLoginTokens(dbMap, userID, refresh,
+		refreshExpires)
+	if err != nil {
+		log.Errorf("APIRefresh: unable to issue tokens for user %d: %v",
+			userID, err)
+		return nil, codes.Internal, "refresh error", err
+	}
+
+	return tokens, codes.OK, "tokens refreshed", nil
+}
+
+// APILogout revokes the refresh_token form value when an app logs out.  The
+// API token issued with it remains valid until it expires shortly after.
+func (controller *MainController) APILogout(c web.C, r *http.Request) (interface{}, codes.Code, string, error) {
+	err := models.RevokeAPIRefreshToken(controller.GetDbMap(c),
+		r.FormValue("refresh_token"))
+	if err != nil {
+		log.Errorf("APILogout: RevokeAPIRefreshToken failed: %v", err)
+		return nil, codes.Internal, "logout error", err
+	}
+	return nil, codes.OK, "logged out", nil
+}
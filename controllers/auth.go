@@ -0,0 +1,692 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrstakepool/helpers"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/system"
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+)
+
+// EmailUpdate validates the passed token and updates the user's email address.
+func (controller *MainController) EmailUpdate(c web.C, r *http.Request) (string, int) {
+	t := controller.GetTemplate(c)
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	dbMap := controller.GetDbMap(c)
+
+	render := func() string {
+		c.Env["Title"] = "Decred Voting Service - Email Update"
+		c.Env["FlashError"] = session.Flashes("emailupdateError")
+		c.Env["FlashSuccess"] = session.Flashes("emailupdateSuccess")
+		c.Env["IsEmailUpdate"] = true
+		widgets := controller.Parse(t, "emailupdate", c.Env)
+		c.Env["Designation"] = controller.Cfg.Designation
+
+		c.Env["Content"] = template.HTML(widgets)
+		return controller.Parse(t, "main", c.Env)
+	}
+
+	// Validate that the token is set.
+	tokenStr := r.URL.Query().Get("t")
+	if tokenStr == "" {
+		session.AddFlash("No email verification token present",
+			"emailupdateError")
+		return render(), http.StatusOK
+	}
+
+	// Validate that the token is valid.
+	token, err := models.UserTokenFromStr(tokenStr)
+	if err != nil {
+		session.AddFlash("Email verification token not valid.",
+			"emailupdateError")
+		return render(), http.StatusOK
+	}
+
+	// Validate that the token is recognized.
+	emailChange, err := helpers.EmailChangeTokenExists(dbMap, token)
+	if err != nil {
+		session.AddFlash("Email verification token not recognized.",
+			"emailupdateError")
+		return render(), http.StatusOK
+	}
+
+	// Validate that the token is not expired.
+	expTime := time.Unix(emailChange.Expires, 0)
+	if expTime.Before(time.Now()) {
+		session.AddFlash("Email change token has expired.",
+			"emailupdateError")
+		return render(), http.StatusOK
+	}
+
+	// possible that someone signed up with this email in the time between
+	// when the token was generated and now.
+	userExists := models.GetUserByEmail(dbMap, emailChange.NewEmail)
+	if userExists != nil {
+		session.AddFlash("Email address is in use", "emailupdateError")
+		return render(), http.StatusOK
+	}
+
+	// When required, the change must be confirmed with both the token sent
+	// to the new address and the one sent to the current address.
+	now := time.Now().Unix()
+	old := emailChange.OldToken == token.String()
+	err = models.ConfirmEmailChange(dbMap, emailChange, old, now)
+	if err != nil {
+		session.AddFlash("Error occurred while changing email address",
+			"emailupdateError")
+		log.Errorf("EmailUpdate: ConfirmEmailChange failed %v", err)
+		return render(), http.StatusOK
+	}
+	if !emailChange.Confirmed() {
+		from := "current"
+		if old {
+			from = "new"
+		}
+		controller.auditUserAction(c, r, emailChange.UserID,
+			"confirm email change", emailChange.NewEmail)
+		session.AddFlash("Email change confirmed. It completes once it is "+
+			"also confirmed with the link sent to your "+from+" email address.",
+			"emailupdateSuccess")
+		return render(), http.StatusOK
+	}
+
+	err = helpers.EmailChangeComplete(dbMap, token, now)
+	if err != nil {
+		session.AddFlash("Error occurred while changing email address",
+			"emailupdateError")
+		log.Errorf("EmailUpdate: EmailChangeComplete failed %v", err)
+	} else {
+		controller.auditUserAction(c, r, emailChange.UserID, "change email",
+			emailChange.NewEmail)
+
+		// destroy session data and force re-login
+		session.Options.MaxAge = -1
+		if err := system.DestroySessionsForUserID(dbMap, emailChange.UserID); err != nil {
+			log.Warnf("EmailUpdate: DestroySessionsForUserID '%v' failed: %v",
+				emailChange.UserID, err)
+		}
+		if err := models.RevokeUserAPIRefreshTokens(dbMap, emailChange.UserID); err != nil {
+			log.Warnf("EmailUpdate: RevokeUserAPIRefreshTokens '%v' failed: %v",
+				emailChange.UserID, err)
+		}
+
+		session.AddFlash("Email successfully updated",
+			"emailupdateSuccess")
+	}
+	return render(), http.StatusOK
+}
+
+// EmailVerify renders the email verification page.
+func (controller *MainController) EmailVerify(c web.C, r *http.Request) (string, int) {
+	t := controller.GetTemplate(c)
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	dbMap := controller.GetDbMap(c)
+
+	render := func() string {
+		c.Env["Title"] = "Decred Voting Service - Email Verification"
+		c.Env["FlashError"] = session.Flashes("emailverifyError")
+		c.Env["FlashSuccess"] = session.Flashes("emailverifySuccess")
+		c.Env["IsEmailVerify"] = true
+		widgets := controller.Parse(t, "emailverify", c.Env)
+		c.Env["Designation"] = controller.Cfg.Designation
+
+		c.Env["Content"] = template.HTML(widgets)
+		return controller.Parse(t, "main", c.Env)
+	}
+
+	// Validate that the token is set.
+	tokenStr := r.URL.Query().Get("t")
+	if tokenStr == "" {
+		session.AddFlash("No email verification token present.",
+			"emailverifyError")
+		return render(), http.StatusOK
+	}
+
+	// Validate that the token is valid.
+	token, err := models.UserTokenFromStr(tokenStr)
+	if err != nil {
+		session.AddFlash("Email verification token not valid.",
+			"emailverifyError")
+		return render(), http.StatusOK
+	}
+
+	// Validate that the token is recognized.
+	user, err := helpers.EmailVerificationTokenExists(dbMap, token)
+	if err != nil {
+		session.AddFlash("Email verification token not recognized.",
+			"emailverifyError")
+		return render(), http.StatusOK
+	}
+
+	// Validate that the token is not expired.
+	expTime := time.Unix(user.EmailTokenExpires, 0)
+	if expTime.Before(time.Now()) {
+		session.AddFlash("Email verification token has expired. Log in to "+
+			"request a new verification email.", "emailverifyError")
+		return render(), http.StatusOK
+	}
+
+	// Set the email as verified.
+	err = helpers.EmailVerificationComplete(dbMap, token)
+	if err != nil {
+		session.AddFlash("Unable to set email to verified status.",
+			"emailverifyError")
+		log.Errorf("could not set email to verified %v", err)
+		return render(), http.StatusInternalServerError
+	}
+
+	session.AddFlash("Email successfully verified.",
+		"emailverifySuccess")
+	return render(), http.StatusOK
+}
+
+// EmailVerifyResendPost sends a new email verification link to an unverified
+// user.  A new link can be requested once every emailVerifyResendInterval.
+func (controller *MainController) EmailVerifyResendPost(c web.C, r *http.Request) (string, int) {
+	email := r.FormValue("email")
+	session := controller.GetSession(c)
+	dbMap := controller.GetDbMap(c)
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+
+	user := models.GetUserByEmail(dbMap, email)
+	if user == nil || user.EmailVerified != 0 {
+		log.Infof("request to resend verification email to %v from IP %v "+
+			"for non-existent or verified account", email, remoteIP)
+		session.AddFlash("A new verification email has been sent to "+email+
+			" if it belongs to an unverified account.", "loginSuccess")
+		return "/login", http.StatusSeeOther
+	}
+
+	now := time.Now()
+	nextResend := time.Unix(user.EmailTokenSent, 0).Add(emailVerifyResendInterval)
+	if now.Before(nextResend) {
+		session.AddFlash("A verification email was sent recently. Please "+
+			"wait a few minutes before requesting another.", "loginError")
+		return "/login", http.StatusSeeOther
+	}
+
+	log.Infof("Resend verification email POST from %v, email %v", remoteIP,
+		user.Email)
+
+	token := models.NewUserToken()
+	err := models.UpdateEmailToken(dbMap, user.ID, token.String(), now.Unix(),
+		now.Add(controller.Cfg.EmailTokenLifetime).Unix())
+	if err != nil {
+		log.Errorf("unable to update email token for user %d: %v", user.ID, err)
+		session.AddFlash("Unable to send verification email", "loginError")
+		return "/login", http.StatusSeeOther
+	}
+
+	err = controller.Cfg.EmailSender.Registration(user.Email,
+		controller.Cfg.BaseURL, remoteIP, token.String())
+	if err != nil {
+		log.Errorf("error sending verification email %v", err)
+		session.AddFlash("Unable to send verification email", "loginError")
+		return "/login", http.StatusSeeOther
+	}
+
+	session.AddFlash("A new verification email has been sent to "+email+
+		" if it belongs to an unverified account.", "loginSuccess")
+	return "/login", http.StatusSeeOther
+}
+
+// PasswordReset renders the password reset page. This shows the form where the
+// user enters their email address.
+func (controller *MainController) PasswordReset(c web.C, r *http.Request) (string, int) {
+	c.Env["Title"] = "Decred Voting Service - Password Reset"
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	c.Env["FlashError"] = session.Flashes("passwordresetError")
+	c.Env["FlashSuccess"] = session.Flashes("passwordresetSuccess")
+	c.Env["IsPasswordReset"] = true
+	controller.setCaptchaEnv(c, captchaReset,
+		"To reset your password, first complete the captcha:")
+
+	t := controller.GetTemplate(c)
+	widgets := controller.Parse(t, "passwordreset", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// PasswordResetPost handles the posted password reset form. This submits the
+// data entered into the email address form. If the email is recognized a
+// password reset token is generated and the user will check their email for a
+// link. The link will take them to the password update page with a token
+// specified on the URL.
+func (controller *MainController) PasswordResetPost(c web.C, r *http.Request) (string, int) {
+	email := r.FormValue("email")
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	dbMap := controller.GetDbMap(c)
+
+	if !controller.consumeCaptcha(c, captchaReset) {
+		session.AddFlash("You must complete the captcha.", "passwordresetError")
+		return controller.PasswordReset(c, r)
+	}
+
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+	user, err := helpers.EmailExists(dbMap, email)
+	// Accounts bound to an address have no email address or password.
+	if err == nil && user.Email != "" {
+		log.Infof("PasswordReset POST from %v, email %v", remoteIP,
+			user.Email)
+
+		t := time.Now()
+		expires := t.Add(time.Hour * 1)
+
+		token := models.NewUserToken()
+		passReset := &models.PasswordReset{
+			UserID:  user.ID,
+			Token:   token.String(),
+			Created: t.Unix(),
+			Expires: expires.Unix(),
+		}
+
+		if err := models.InsertPasswordReset(dbMap, passReset); err != nil {
+			session.AddFlash("Unable to add reset token to database", "passwordresetError")
+			log.Errorf("Unable to add reset token to database: %v", err)
+			return controller.PasswordReset(c, r)
+		}
+
+		err := controller.Cfg.EmailSender.PasswordChangeRequest(user.Email, remoteIP, controller.Cfg.BaseURL, token.String())
+		if err != nil {
+			session.AddFlash("Unable to send password reset email", "passwordresetError")
+			log.Errorf("error sending password reset email %v", err)
+			return controller.PasswordReset(c, r)
+		}
+		controller.recordUserActivity(c, r, user.ID, activityPasswordReset, "")
+	} else {
+		log.Infof("request to reset non-existent account %v from IP %v",
+			email, remoteIP)
+	}
+
+	session.AddFlash("An email containing password reset instructions has "+
+		"been sent to "+email+" if it was a registered account here.",
+		"passwordresetSuccess")
+
+	return controller.PasswordReset(c, r)
+}
+
+// PasswordUpdate renders the password update page. When a user clicks the link
+// containing a token in the password reset email, this handler will validate
+// the token. When the token is valid, the user will be presented with forms to
+// enter their new password. PasswordUpdatePost handles the submission of these
+// forms, and calls PasswordUpdate again for page rendering.
+func (controller *MainController) PasswordUpdate(c web.C, r *http.Request) (string, int) {
+	t := controller.GetTemplate(c)
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	render := func() string {
+		c.Env["Title"] = "Decred Voting Service - Password Update"
+		c.Env["FlashError"] = session.Flashes("passwordupdateError")
+		c.Env["FlashSuccess"] = session.Flashes("passwordupdateSuccess")
+		c.Env["IsPasswordUpdate"] = true
+		widgets := controller.Parse(t, "passwordupdate", c.Env)
+		c.Env["Designation"] = controller.Cfg.Designation
+
+		c.Env["Content"] = template.HTML(widgets)
+		return controller.Parse(t, "main", c.Env)
+	}
+
+	// Just render the page if the POST handler already checked the token.
+	_, tokenChecked := c.Env["TokenValid"].(bool)
+	if tokenChecked {
+		return render(), http.StatusOK
+	}
+
+	// Use CheckPasswordResetToken to set relevant flash messages.
+	controller.CheckPasswordResetToken(r.URL.Query().Get("t"), c)
+	return render(), http.StatusOK
+}
+
+// PasswordUpdatePost handles updating passwords. The token in the URL is from
+// the password reset email. The token is validated and the password is changed.
+func (controller *MainController) PasswordUpdatePost(c web.C, r *http.Request) (string, int) {
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	dbMap := controller.GetDbMap(c)
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+
+	// Ensure a valid password reset token is provided. If the token is valid,
+	// return the decoded UserToken and PasswordReset data for the token.
+	token, resetData, tokenOK := controller.CheckPasswordResetToken(
+		r.URL.Query().Get("t"), c)
+	c.Env["TokenValid"] = tokenOK
+	// tokenChecked will be true regardless of token validity.
+	if !tokenOK {
+		return controller.PasswordUpdate(c, r)
+	}
+
+	// Given a valid password reset token, process the password change.
+	password := r.FormValue("password")
+	if password == "" {
+		session.AddFlash("Password cannot be empty.", "passwordupdateError")
+		return controller.PasswordUpdate(c, r)
+	}
+	passwordRepeat := r.FormValue("passwordrepeat")
+	if password != passwordRepeat {
+		session.AddFlash("Passwords do not match.", "passwordupdateError")
+		return controller.PasswordUpdate(c, r)
+	}
+
+	user, err := helpers.UserIDExists(dbMap, resetData.UserID)
+	if err != nil {
+		log.Infof("UserIDExists failure %v, %v", err, remoteIP)
+		session.AddFlash("Unable to find User ID.", "passwordupdateError")
+		return controller.PasswordUpdate(c, r)
+	}
+
+	log.Infof("PasswordUpdate POST from %v, email %v", remoteIP,
+		user.Email)
+
+	user.HashPassword(password)
+	_, err = helpers.UpdateUserPasswordByID(dbMap, resetData.UserID,
+		user.Password)
+	if err != nil {
+		log.Errorf("error updating password %v", err)
+		session.AddFlash("Unable to update password.", "passwordupdateError")
+		return controller.PasswordUpdate(c, r)
+	}
+
+	err = helpers.PasswordResetTokenDelete(dbMap, token)
+	if err != nil {
+		log.Errorf("error deleting token %v", err)
+	}
+	controller.recordUserActivity(c, r, user.ID, activityPasswordChange,
+		"with a password reset link")
+
+	// destroy session data
+	if err := system.DestroySessionsForUserID(dbMap, user.ID); err != nil {
+		log.Warnf("PasswordUpdatePost: DestroySessionsForUserID '%v' failed: %v",
+			user.ID, err)
+	}
+	if err := models.RevokeUserAPIRefreshTokens(dbMap, user.ID); err != nil {
+		log.Warnf("PasswordUpdatePost: RevokeUserAPIRefreshTokens '%v' failed: %v",
+			user.ID, err)
+	}
+	session.AddFlash("Password successfully updated", "passwordupdateSuccess")
+	return controller.PasswordUpdate(c, r)
+}
+
+// Login renders the login page.
+func (controller *MainController) Login(c web.C, r *http.Request) (string, int) {
+	t := controller.GetTemplate(c)
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	// Tell main.html what route is being rendered
+	c.Env["isLogin"] = true
+
+	c.Env["FlashError"] = session.Flashes("loginError")
+	c.Env["FlashSuccess"] = session.Flashes("loginSuccess")
+
+	widgets := controller.Parse(t, "auth/login", c.Env)
+
+	c.Env["Title"] = "Decred VSP - Login"
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// rehashPassword upgrades legacy bcrypt hashes and hashes created with
+// outdated parameters after user logged in with password, now that the clear
+// text password is known.
+func rehashPassword(dbMap *gorp.DbMap, user *models.User, password string) {
+	if !user.PasswordNeedsRehash() {
+		return
+	}
+	user.HashPassword(password)
+	_, err := helpers.UpdateUserPasswordByID(dbMap, user.ID, user.Password)
+	if err != nil {
+		log.Warnf("unable to rehash password for user %d: %v", user.ID, err)
+	}
+}
+
+// LoginPost is the form submit route. Logs user in or sets an appropriate message in
+// session if login was not successful.
+func (controller *MainController) LoginPost(c web.C, r *http.Request) (string, int) {
+	email, password := r.FormValue("email"), r.FormValue("password")
+
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	dbMap := controller.GetDbMap(c)
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+
+	// Validate email and password combination.
+	user, err := helpers.Login(dbMap, email, password)
+	if err != nil {
+		log.Infof(email+" login failed %v, %v", err, remoteIP)
+		session.AddFlash("Invalid Email or Password", "loginError")
+		return controller.Login(c, r)
+	}
+
+	log.Infof("Login POST from %v, email %v", remoteIP, user.Email)
+
+	rehashPassword(dbMap, user, password)
+
+	if user.EmailVerified == 0 {
+		session.AddFlash("You must validate your email address", "loginError")
+		c.Env["ResendEmail"] = user.Email
+		return controller.Login(c, r)
+	}
+
+	session.Values["UserId"] = user.ID
+	controller.recordUserActivity(c, r, user.ID, activityLogin, "")
+
+	// Go to Address page if multisig script not yet set up.
+	// GUI users can copy their API Token from here.
+	// CLI users can paste their pubkey address
+	if user.MultiSigAddress == "" {
+		return "/address", http.StatusSeeOther
+	}
+
+	// Go to Tickets page if user already set up.
+	return "/tickets", http.StatusSeeOther
+}
+
+const (
+	// maxUserAgentLength and maxReferralCodeLength are the lengths the
+	// user agent and referral code recorded for new users are truncated to.
+	maxUserAgentLength    = 500
+	maxReferralCodeLength = 64
+)
+
+// Register renders the register page.
+func (controller *MainController) Register(c web.C, r *http.Request) (string, int) {
+	// Tell main.html what route is being rendered
+	c.Env["isRegister"] = true
+	if controller.Cfg.ClosePool {
+		c.Env["IsClosed"] = true
+		c.Env["ClosePoolMsg"] = controller.Cfg.ClosePoolMsg
+	}
+
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	c.Env["FlashError"] = session.Flashes("registrationError")
+	c.Env["FlashSuccess"] = session.Flashes("registrationSuccess")
+	controller.setCaptchaEnv(c, captchaRegister,
+		"To register, first complete the captcha:")
+	c.Env["TOSVersion"] = controller.Cfg.TOSVersion
+	c.Env["TOSURL"] = controller.Cfg.TOSURL
+
+	// Remember the referral code of a referral link until registration.
+	if ref := r.URL.Query().Get("ref"); ref != "" {
+		session.Values["ReferralCode"] = ref
+	}
+
+	// Remember the invite code of an invite link while the captcha is
+	// completed.
+	if controller.Cfg.InviteOnly {
+		if invite := r.URL.Query().Get("invite"); invite != "" {
+			session.Values["InviteCode"] = invite
+		}
+		c.Env["InviteOnly"] = true
+		c.Env["InviteCode"] = session.Values["InviteCode"]
+	}
+
+	t := controller.GetTemplate(c)
+	widgets := controller.Parse(t, "auth/register", c.Env)
+
+	c.Env["Title"] = "Decred VSP - Register"
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// RegisterPost form submit route. Registers new user or shows Registration route with
+// appropriate messages set in session.
+func (controller *MainController) RegisterPost(c web.C, r *http.Request) (string, int) {
+	if controller.Cfg.ClosePool {
+		log.Infof("attempt to register while registration disabled")
+		return "/error", http.StatusSeeOther
+	}
+
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	if !controller.captchaSolved(c, captchaRegister) {
+		session.AddFlash("You must complete the captcha.", "registrationError")
+		return controller.Register(c, r)
+	}
+
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+
+	email, password, passwordRepeat := r.FormValue("email"),
+		r.FormValue("password"), r.FormValue("passwordrepeat")
+
+	if !strings.Contains(email, "@") {
+		session.AddFlash("Email address is invalid", "registrationError")
+		return controller.Register(c, r)
+	}
+
+	if password == "" {
+		session.AddFlash("Password cannot be empty", "registrationError")
+		return controller.Register(c, r)
+	}
+
+	if password != passwordRepeat {
+		session.AddFlash("Passwords do not match", "registrationError")
+		return controller.Register(c, r)
+	}
+
+	if controller.Cfg.TOSVersion != "" && r.FormValue("tos") == "" {
+		session.AddFlash("You must accept the terms of service", "registrationError")
+		return controller.Register(c, r)
+	}
+
+	invite := strings.TrimSpace(r.FormValue("invite"))
+	if controller.Cfg.InviteOnly && invite == "" {
+		session.AddFlash("An invite code is required to register", "registrationError")
+		return controller.Register(c, r)
+	}
+
+	// At this point we have completed all trivial pre-registration checks. The new account
+	// is about to be created, so lets consume the CAPTCHA. Any failure beyond this point
+	// and we want the user to complete another CAPTCHA.
+	if !controller.consumeCaptcha(c, captchaRegister) {
+		session.AddFlash("You must complete the captcha.", "registrationError")
+		return controller.Register(c, r)
+	}
+
+	dbMap := controller.GetDbMap(c)
+	user := models.GetUserByEmail(dbMap, email)
+
+	if user != nil {
+		session.AddFlash("This email address is already registered", "registrationError")
+		return controller.Register(c, r)
+	}
+
+	token := models.NewUserToken()
+	now := time.Now()
+	user = &models.User{
+		Username:          email,
+		Email:             email,
+		EmailToken:        token.String(),
+		EmailTokenSent:    now.Unix(),
+		EmailTokenExpires: now.Add(controller.Cfg.EmailTokenLifetime).Unix(),
+		EmailVerified:     0,
+		VoteBits:          1,
+		VoteBitsVersion:   int64(controller.voteVersion),
+		Created:           now.Unix(),
+
+		RegistrationIP:        remoteIP,
+		RegistrationUserAgent: truncateString(r.UserAgent(), maxUserAgentLength),
+	}
+	if ref, ok := session.Values["ReferralCode"].(string); ok {
+		user.ReferralCode = truncateString(ref, maxReferralCodeLength)
+	}
+	user.HashPassword(password)
+
+	log.Infof("Register POST from %v, email %v. Inserting.", remoteIP, user.Email)
+
+	var err error
+	if controller.Cfg.InviteOnly {
+		err = models.InsertUserWithInviteCode(dbMap, user, invite)
+	} else {
+		err = models.InsertUser(dbMap, user)
+	}
+	if err == models.ErrInvalidInviteCode {
+		log.Infof("Register POST from %v with invalid invite code %q",
+			remoteIP, invite)
+		session.AddFlash("The invite code is invalid, expired or used up",
+			"registrationError")
+		return controller.Register(c, r)
+	}
+	if err != nil {
+		session.AddFlash("Database error occurred while adding user", "registrationError")
+		log.Errorf("Error while registering user: %v", err)
+		return controller.Register(c, r)
+	}
+
+	if controller.Cfg.TOSVersion != "" {
+		err = models.AcceptTOS(dbMap, user.ID, controller.Cfg.TOSVersion, remoteIP)
+		if err != nil {
+			log.Errorf("Error recording terms of service acceptance for user %d: %v",
+				user.ID, err)
+		}
+	}
+
+	delete(session.Values, "InviteCode")
+	delete(session.Values, "ReferralCode")
+
+	err = controller.Cfg.EmailSender.Registration(email, controller.Cfg.BaseURL, remoteIP, token.String())
+	if err != nil {
+		session.AddFlash("Unable to send verification email", "registrationError")
+		log.Errorf("error sending verification email %v", err)
+	} else {
+		session.AddFlash("A verification email has been sent to "+email, "registrationSuccess")
+	}
+
+	return controller.Register(c, r)
+}
+
+// Logout the user.
+func (controller *MainController) Logout(c web.C, r *http.Request) (string, int) {
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	if session.Values["UserId"] == nil {
+		return "/", http.StatusSeeOther
+	}
+
+	session.Options.MaxAge = -1
+
+	return "/", http.StatusSeeOther
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLogout(t *testing.T) {
+	controller := &MainController{Cfg: &Config{}}
+	r := httptest.NewRequest(http.MethodGet, "/logout", nil)
+
+	c, session := tSessionContext(0)
+	if path, code := controller.Logout(c, r); path != "/" ||
+		code != http.StatusSeeOther || session.Options.MaxAge != 0 {
+		t.Errorf("signed out logout got %s %d, session max age %d", path,
+			code, session.Options.MaxAge)
+	}
+
+	// The session cookie is deleted.
+	c, session = tSessionContext(1)
+	if path, code := controller.Logout(c, r); path != "/" ||
+		code != http.StatusSeeOther || session.Options.MaxAge != -1 {
+		t.Errorf("logout got %s %d, session max age %d", path, code,
+			session.Options.MaxAge)
+	}
+}
+
+func TestRegisterPostClosedPool(t *testing.T) {
+	controller := &MainController{Cfg: &Config{ClosePool: true}}
+	c, _ := tSessionContext(0)
+	r := httptest.NewRequest(http.MethodPost, "/register", nil)
+	if path, code := controller.RegisterPost(c, r); path != "/error" ||
+		code != http.StatusSeeOther {
+		t.Errorf("registration on a closed pool got %s %d", path, code)
+	}
+}
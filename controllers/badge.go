@@ -0,0 +1,192 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	// badgeCacheLife is how long the ticket counts shown on the status badge
+	// of a user are cached, and how long clients may cache the badge.
+	badgeCacheLife = 5 * time.Minute
+
+	// badgeErrorCacheLife is how long clients may cache the badge shown
+	// while the ticket counts are unavailable.
+	badgeErrorCacheLife = time.Minute
+
+	// badgeLabel is the left part of every status badge.
+	badgeLabel = "Decred VSP"
+)
+
+// badgeCounts are the cached ticket counts of a user shown on their status
+// badge.
+type badgeCounts struct {
+	tickets adminUserTickets
+	fetched time.Time
+}
+
+// badgeCache holds the ticket counts shown on the status badges, so that
+// badges embedded in frequently loaded pages do not each cost a wallet
+// request.  The zero value is ready to use.
+type badgeCache struct {
+	mtx    sync.Mutex
+	counts map[int64]badgeCounts // [user id]
+}
+
+// get returns the cached ticket counts of a user unless they are older than
+// badgeCacheLife at now.
+func (bc *badgeCache) get(userID int64, now time.Time) (*adminUserTickets, bool) {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+	counts, ok := bc.counts[userID]
+	if !ok || now.Sub(counts.fetched) >= badgeCacheLife {
+		return nil, false
+	}
+	tickets := counts.tickets
+	return &tickets, true
+}
+
+// set caches the ticket counts of a user fetched at now, dropping the
+// expired entries of others.
+func (bc *badgeCache) set(userID int64, tickets adminUserTickets, now time.Time) {
+	bc.mtx.Lock()
+	defer bc.mtx.Unlock()
+	if bc.counts == nil {
+		bc.counts = make(map[int64]badgeCounts)
+	}
+	for id, counts := range bc.counts {
+		if now.Sub(counts.fetched) >= badgeCacheLife {
+			delete(bc.counts, id)
+		}
+	}
+	bc.counts[userID] = badgeCounts{tickets: tickets, fetched: now}
+}
+
+// badgeTickets returns the ticket counts of user from the cache, or from
+// stakepoold when they are not cached.
+func (controller *MainController) badgeTickets(ctx context.Context, user *models.User) (*adminUserTickets, error) {
+	now := time.Now()
+	if tickets, ok := controller.badges.get(user.ID, now); ok {
+		return tickets, nil
+	}
+
+	counts, err := controller.userTicketCounts(ctx, []models.User{*user})
+	if err != nil {
+		return nil, err
+	}
+	tickets, ok := counts[user.ID]
+	if !ok {
+		tickets = new(adminUserTickets)
+	}
+	controller.badges.set(user.ID, *tickets, now)
+	return tickets, nil
+}
+
+// badgeMessage returns the right part of a status badge and its color for
+// the ticket counts of a user, e.g. "Live: 3 tickets, 100% voted".
+func badgeMessage(tickets *adminUserTickets) (string, string) {
+	plural := "s"
+	if tickets.Live == 1 {
+		plural = ""
+	}
+	msg := fmt.Sprintf("Live: %d ticket%s", tickets.Live, plural)
+
+	decided := tickets.Voted + tickets.Missed
+	if decided == 0 {
+		return msg, "#2970ff"
+	}
+	// Round down so that a single miss is never shown as 100%.
+	percent := tickets.Voted * 100 / decided
+	color := "#41bf53"
+	switch {
+	case percent < 90:
+		color = "#ed6d47"
+	case percent < 99:
+		color = "#ffc84e"
+	}
+	return fmt.Sprintf("%s, %d%% voted", msg, percent), color
+}
+
+// badgeTextWidth estimates the width in pixels of text in the 11px font of
+// the badges.
+func badgeTextWidth(text string) int {
+	return len(text)*7 + 10
+}
+
+// renderBadge returns an SVG badge with label on the left and msg on a
+// background of color on the right.
+func renderBadge(label, msg, color string) []byte {
+	lw, mw := badgeTextWidth(label), badgeTextWidth(msg)
+	label, msg = html.EscapeString(label), html.EscapeString(msg)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`,
+		lw+mw, label, msg)
+	fmt.Fprintf(&b, `<title>%s: %s</title>`, label, msg)
+	fmt.Fprintf(&b, `<rect width="%d" height="20" rx="3" fill="#091440"/>`, lw+mw)
+	fmt.Fprintf(&b, `<rect x="%d" width="%d" height="20" rx="3" fill="%s"/>`, lw, mw, color)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,DejaVu Sans,sans-serif" font-size="11">`)
+	fmt.Fprintf(&b, `<text x="%d" y="14">%s</text>`, lw/2, label)
+	fmt.Fprintf(&b, `<text x="%d" y="14">%s</text>`, lw+mw/2, msg)
+	b.WriteString(`</g></svg>`)
+	return []byte(b.String())
+}
+
+// Badge serves the status badge of the user with the badge token in the URL,
+// e.g. /badge/<token>.svg, which users embed in dashboards and forums.  It
+// shows their live tickets and the share of their decided tickets which voted.
+// Badges are cached by clients and proxies for badgeCacheLife and revalidated
+// with their ETag.
+func (controller *MainController) Badge(c web.C, w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSuffix(c.URLParams["token"], ".svg")
+	user, err := models.GetUserByBadgeToken(controller.GetReadDbMap(c), token)
+	if err != nil {
+		log.Errorf("unable to get user by badge token: %v", err)
+		http.Error(w, "unable to get badge", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var svg []byte
+	cacheLife := badgeCacheLife
+	switch tickets, err := controller.badgeTickets(r.Context(), user); {
+	case user.MultiSigAddress == "":
+		svg = renderBadge(badgeLabel, "No address", "#8997a5")
+	case err != nil:
+		log.Warnf("unable to get badge ticket counts of UserId %v: %v",
+			user.ID, err)
+		svg = renderBadge(badgeLabel, "Unavailable", "#8997a5")
+		cacheLife = badgeErrorCacheLife
+	default:
+		msg, color := badgeMessage(tickets)
+		svg = renderBadge(badgeLabel, msg, color)
+	}
+
+	sum := sha256.Sum256(svg)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d",
+		int(cacheLife.Seconds())))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(svg)
+}
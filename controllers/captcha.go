@@ -2,33 +2,184 @@ package controllers
 
 import (
 	"bytes"
+	"errors"
 	"net/http"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dchest/captcha"
+	"github.com/decred/dcrstakepool/models"
 	"github.com/zenazn/goji/web"
 )
 
+// The purposes captchas are solved for.  A solved captcha can only be used
+// for the purpose it was solved for.
+const (
+	captchaRegister = "register"
+	captchaSignIn   = "signin"
+	captchaReset    = "reset"
+	captchaSettings = "settings"
+)
+
+const (
+	// solvedCaptchaLifetime is how long a solved captcha may be used after
+	// it was solved.
+	solvedCaptchaLifetime = 10 * time.Minute
+
+	// maxSolvedCaptchas is the maximum number of solved captchas which have
+	// not been used, which keeps the captcha store from growing without
+	// bound.
+	maxSolvedCaptchas = 10000
+)
+
+// errTooManyCaptchas is returned when a solved captcha cannot be stored
+// because maxSolvedCaptchas are outstanding.
+var errTooManyCaptchas = errors.New("too many outstanding solved captchas")
+
+// captchaPurposes are the purposes a captcha may be solved for.
+var captchaPurposes = map[string]struct{}{
+	captchaRegister: {},
+	captchaSignIn:   {},
+	captchaReset:    {},
+	captchaSettings: {},
+}
+
+// solvedCaptcha is a captcha solved for purpose which may be used before
+// expires.
+type solvedCaptcha struct {
+	purpose string
+	expires time.Time
+}
+
+// solvedCaptchas stores the captchas solved by users until they are used or
+// expire.  Each solved captcha can only be used once.  Users hold the token of
+// a solved captcha in their session.
+type solvedCaptchas struct {
+	mtx    sync.Mutex
+	solved map[string]solvedCaptcha
+}
+
+func newSolvedCaptchas() *solvedCaptchas {
+	return &solvedCaptchas{
+		solved: make(map[string]solvedCaptcha),
+	}
+}
+
+// add stores a captcha solved for purpose and returns its token.
+func (s *solvedCaptchas) add(purpose string, now time.Time) (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for token, solved := range s.solved {
+		if !now.Before(solved.expires) {
+			delete(s.solved, token)
+		}
+	}
+	if len(s.solved) >= maxSolvedCaptchas {
+		return "", errTooManyCaptchas
+	}
+
+	token := models.NewUserToken().String()
+	s.solved[token] = solvedCaptcha{
+		purpose: purpose,
+		expires: now.Add(solvedCaptchaLifetime),
+	}
+	return token, nil
+}
+
+// valid returns whether the captcha with token was solved for purpose and has
+// not been used or expired.
+func (s *solvedCaptchas) valid(token, purpose string, now time.Time) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	solved, ok := s.solved[token]
+	return ok && solved.purpose == purpose && now.Before(solved.expires)
+}
+
+// consume removes the captcha with token and returns whether it was solved for
+// purpose and had not expired.
+func (s *solvedCaptchas) consume(token, purpose string, now time.Time) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	solved, ok := s.solved[token]
+	if !ok {
+		return false
+	}
+	delete(s.solved, token)
+	return solved.purpose == purpose && now.Before(solved.expires)
+}
+
+// captchaSessionKey returns the session value holding the token of the
+// captcha solved for purpose.
+func captchaSessionKey(purpose string) string {
+	return "Captcha." + purpose
+}
+
+// captchaSolved returns whether the user solved a captcha for purpose which
+// has not been used or expired.
+func (controller *MainController) captchaSolved(c web.C, purpose string) bool {
+	token, _ := controller.GetSession(c).Values[captchaSessionKey(purpose)].(string)
+	return controller.solvedCaptchas.valid(token, purpose, time.Now())
+}
+
+// consumeCaptcha uses the captcha the user solved for purpose and returns
+// whether it was valid.  The user must solve another captcha to perform the
+// action again.
+func (controller *MainController) consumeCaptcha(c web.C, purpose string) bool {
+	session := controller.GetSession(c)
+	key := captchaSessionKey(purpose)
+	token, _ := session.Values[key].(string)
+	delete(session.Values, key)
+	c.Env["CaptchaDone"] = false
+	return controller.solvedCaptchas.consume(token, purpose, time.Now())
+}
+
+// setCaptchaEnv sets the values rendered by the captcha template for a page
+// which requires a captcha solved for purpose.  CaptchaDone is set when the
+// user has already solved one.
+func (controller *MainController) setCaptchaEnv(c web.C, purpose, msg string) {
+	h := controller.captchaHandler
+	c.Env["CaptchaID"] = captcha.NewLen(h.Digits)
+	c.Env["CaptchaDigits"] = h.Digits
+	c.Env["CaptchaWidth"] = h.ImgWidth
+	c.Env["CaptchaHeight"] = h.ImgHeight
+	c.Env["CaptchaAudio"] = h.Audio
+	c.Env["CaptchaHelpEmail"] = controller.Cfg.PoolEmail
+	c.Env["CaptchaMsg"] = msg
+	c.Env["CaptchaPurpose"] = purpose
+	c.Env["CaptchaError"] = controller.GetSession(c).Flashes("captchaFailed")
+	c.Env["CaptchaDone"] = controller.captchaSolved(c, purpose)
+}
+
+// captchaHandler holds the settings of the captchas.  Digits is the number of
+// digits of new captchas.  With Audio they are also served as WAV recordings
+// of the digits spoken in AudioLang, for visually impaired users.
 type captchaHandler struct {
 	ImgWidth  int
 	ImgHeight int
+	Digits    int
+	Audio     bool
+	AudioLang string
 }
 
-// CaptchaServe writes and serves captchas.
+// CaptchaServe writes and serves captchas, as PNG images or, when audio
+// captchas are enabled, as WAV recordings.
 func (controller *MainController) CaptchaServe(c web.C, w http.ResponseWriter, r *http.Request) {
+	h := controller.captchaHandler
+
 	// Get the captcha id by stripping the file extension.
 	_, file := path.Split(r.URL.Path)
 	ext := path.Ext(file)
 	id := strings.TrimSuffix(file, ext)
-	if ext != ".png" || id == "" {
+	if (ext != ".png" && (ext != ".wav" || !h.Audio)) || id == "" {
 		http.NotFound(w, r)
 		return
 	}
 
-	h := controller.captchaHandler
-
 	if r.FormValue("reload") != "" {
 		captcha.Reload(id)
 	}
@@ -38,30 +189,51 @@ func (controller *MainController) CaptchaServe(c web.C, w http.ResponseWriter, r
 	w.Header().Set("Expires", "0")
 
 	var content bytes.Buffer
-	w.Header().Set("Content-Type", "image/png")
-	err := captcha.WriteImage(&content, id, h.ImgWidth, h.ImgHeight)
+	var err error
+	switch ext {
+	case ".wav":
+		w.Header().Set("Content-Type", "audio/x-wav")
+		err = captcha.WriteAudio(&content, id, h.AudioLang)
+	default:
+		w.Header().Set("Content-Type", "image/png")
+		err = captcha.WriteImage(&content, id, h.ImgWidth, h.ImgHeight)
+	}
 	if err != nil {
-		http.Error(w, "failed to generate captcha image", http.StatusInternalServerError)
+		http.Error(w, "failed to generate captcha", http.StatusInternalServerError)
+		return
 	}
 
 	http.ServeContent(w, r, id+ext, time.Time{}, bytes.NewReader(content.Bytes()))
 }
 
 // CaptchaVerify verifies that the provided captcha matches the on screen text
-// and sets the CaptchaDone session value.
+// and stores the solved captcha for the posted purpose.
 func (controller *MainController) CaptchaVerify(c web.C, w http.ResponseWriter, r *http.Request) {
 	id, solution := r.FormValue("captchaId"), r.FormValue("captchaSolution")
 	if id == "" {
 		http.Error(w, "invalid captcha id", http.StatusBadRequest)
 		return
 	}
+	purpose := r.FormValue("captchaPurpose")
+	if _, ok := captchaPurposes[purpose]; !ok {
+		http.Error(w, "invalid captcha purpose", http.StatusBadRequest)
+		return
+	}
 
 	session := controller.GetSession(c)
+	key := captchaSessionKey(purpose)
+	delete(session.Values, key)
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if captcha.VerifyString(id, solution) {
-		session.Values["CaptchaDone"] = true
+		token, err := controller.solvedCaptchas.add(purpose, time.Now())
+		if err != nil {
+			log.Warnf("unable to store solved captcha: %v", err)
+			session.AddFlash("Too many captchas are being solved. Please "+
+				"try again later.", "captchaFailed")
+		} else {
+			session.Values[key] = token
+		}
 	} else {
-		session.Values["CaptchaDone"] = false
 		session.AddFlash("Captcha verification failed. Please try again.",
 			"captchaFailed")
 	}
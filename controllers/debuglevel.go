@@ -0,0 +1,52 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
+)
+
+// APIDebugLevelSet changes the log levels without restarting, e.g. to enable
+// tracing of the stakepoold RPCs with debuglevel=SRPC=debug.  It accepts the
+// same values as the debuglevel option and is only available to admins with
+// a full API token.  The change is lost on restart.
+func (controller *MainController) APIDebugLevelSet(c web.C, r *http.Request) (*poolapi.DebugLevel, codes.Code, string, error) {
+	adminID, err := controller.apiAdminID(c, r)
+	if err != nil {
+		return nil, codes.PermissionDenied, "debuglevel error", err
+	}
+	if controller.Cfg.SetDebugLevel == nil {
+		return nil, codes.Unavailable, "debuglevel error",
+			newAPIError(poolapi.ErrUnavailable, "", "log levels cannot be changed")
+	}
+
+	debugLevel := strings.TrimSpace(r.FormValue("debuglevel"))
+	if err := controller.Cfg.SetDebugLevel(debugLevel); err != nil {
+		return nil, codes.InvalidArgument, "debuglevel error",
+			wrapAPIError(poolapi.ErrInvalidArgument, "debuglevel", err)
+	}
+
+	action := "set debuglevel " + debugLevel
+	log.Infof("admin user %d: %s", adminID, action)
+	audit := &models.AdminAudit{
+		AdminUID: adminID,
+		IP:       getClientIP(r, controller.Cfg.RealIPHeader),
+		Action:   action,
+		Created:  time.Now().Unix(),
+	}
+	if err := models.InsertAdminAudit(controller.GetDbMap(c), audit); err != nil {
+		log.Errorf("unable to record admin user %d action %q: %v",
+			adminID, action, err)
+	}
+
+	return &poolapi.DebugLevel{DebugLevel: debugLevel}, codes.OK, "debuglevel set", nil
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/zenazn/goji/web"
+)
+
+// distributionTopAccounts is the number of accounts with the most live
+// tickets listed on the admin distribution page.
+const distributionTopAccounts = 20
+
+// distributionBuckets are the lower bounds of the histogram buckets of the
+// number of live tickets held by each account.
+var distributionBuckets = []uint32{1, 2, 6, 11, 51, 101, 501}
+
+// DistributionBucket is the number of accounts holding between Min and Max
+// live tickets.  Max is zero for the last, unbounded, bucket.
+type DistributionBucket struct {
+	Min      uint32
+	Max      uint32
+	Accounts int
+	Tickets  uint32
+}
+
+// DistributionAccount is the number of live tickets held by a single account.
+type DistributionAccount struct {
+	UserID  int64
+	Name    string
+	Tickets uint32
+	Share   float64
+}
+
+// TicketDistribution describes how the live tickets of the voting service are
+// spread over its accounts.
+type TicketDistribution struct {
+	Tickets  uint32
+	Accounts int
+	Buckets  []DistributionBucket
+	Top      []DistributionAccount
+
+	// Unknown is the number of live tickets whose multisig address does not
+	// belong to any account.
+	Unknown uint32
+
+	// Gini is the Gini coefficient of the number of live tickets held by
+	// each account with live tickets.  It is 0 when all accounts hold the
+	// same number of tickets and approaches 1 as a single account holds
+	// all of them.
+	Gini float64
+}
+
+// ticketDistribution computes the distribution of the live ticket counts of
+// each multisig address over users.
+func ticketDistribution(counts map[string]uint32, users []models.User) *TicketDistribution {
+	d := &TicketDistribution{
+		Buckets: make([]DistributionBucket, len(distributionBuckets)),
+	}
+	for i, min := range distributionBuckets {
+		d.Buckets[i].Min = min
+		if i+1 < len(distributionBuckets) {
+			d.Buckets[i].Max = distributionBuckets[i+1] - 1
+		}
+	}
+
+	var accounts []DistributionAccount
+	seen := make(map[string]struct{}, len(users))
+	for _, user := range users {
+		// Addresses shared by several accounts are only counted once.
+		if _, ok := seen[user.MultiSigAddress]; ok {
+			continue
+		}
+		seen[user.MultiSigAddress] = struct{}{}
+		tickets := counts[user.MultiSigAddress]
+		if tickets == 0 {
+			continue
+		}
+		name := user.Email
+		if name == "" {
+			name = user.Username
+		}
+		accounts = append(accounts, DistributionAccount{
+			UserID:  user.ID,
+			Name:    name,
+			Tickets: tickets,
+		})
+	}
+	for msa, tickets := range counts {
+		d.Tickets += tickets
+		if _, ok := seen[msa]; !ok {
+			d.Unknown += tickets
+		}
+	}
+	d.Accounts = len(accounts)
+	if d.Accounts == 0 {
+		return d
+	}
+
+	// Sort ascending by tickets, which the Gini coefficient requires.
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Tickets != accounts[j].Tickets {
+			return accounts[i].Tickets < accounts[j].Tickets
+		}
+		return accounts[i].UserID > accounts[j].UserID
+	})
+
+	var total, weighted float64
+	for i := range accounts {
+		a := &accounts[i]
+		a.Share = 100 * float64(a.Tickets) / float64(d.Tickets)
+		total += float64(a.Tickets)
+		weighted += float64(i+1) * float64(a.Tickets)
+
+		b := sort.Search(len(distributionBuckets), func(j int) bool {
+			return distributionBuckets[j] > a.Tickets
+		}) - 1
+		d.Buckets[b].Accounts++
+		d.Buckets[b].Tickets += a.Tickets
+	}
+	n := float64(len(accounts))
+	d.Gini = 2*weighted/(n*total) - (n+1)/n
+
+	top := len(accounts)
+	if top > distributionTopAccounts {
+		top = distributionTopAccounts
+	}
+	for i := 0; i < top; i++ {
+		d.Top = append(d.Top, accounts[len(accounts)-1-i])
+	}
+
+	return d
+}
+
+// AdminDistribution renders the administrative ticket distribution page,
+// which shows how the live tickets are spread over the accounts of the voting
+// service so that a single entity dominating the pool can be noticed.
+func (controller *MainController) AdminDistribution(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminDistribution"] = true
+	c.Env["Title"] = "Decred Voting Service - Ticket Distribution (Admin)"
+
+	counts, err := controller.Cfg.StakepooldServers.GetLiveTicketCounts(r.Context())
+	if err != nil {
+		log.Errorf("Could not retrieve live ticket counts from stakepoold: %v", err)
+		c.Env["FlashError"] = []string{"Could not retrieve live tickets from stakepoold"}
+	} else {
+		users, err := models.GetUsersWithMultiSigAddress(controller.GetReadDbMap(c))
+		if err != nil {
+			log.Errorf("unable to get users: %v", err)
+			return "/error", http.StatusSeeOther
+		}
+		c.Env["Distribution"] = ticketDistribution(counts, users)
+	}
+
+	widgets := controller.Parse(t, "admin/distribution", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
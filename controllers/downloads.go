@@ -0,0 +1,89 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/zenazn/goji/web"
+)
+
+// scriptsDownloadPath is the path of the redeem script backup of admins.
+const scriptsDownloadPath = "/download/scripts.csv"
+
+// downloadURL returns the link to the admin export at path, signed to be
+// valid for the configured lifetime of download links.
+func (controller *MainController) downloadURL(path string) (string, error) {
+	signed, err := controller.Cfg.DownloadLinks.Sign(path,
+		time.Now().Add(controller.Cfg.DownloadLinkLifetime))
+	if err != nil {
+		return "", err
+	}
+	return controller.Cfg.BaseURL + signed, nil
+}
+
+// verifyDownload checks the signature of the download link requested by r,
+// answering the request with 403 Forbidden when it is missing, invalid or
+// expired.
+func (controller *MainController) verifyDownload(w http.ResponseWriter, r *http.Request) bool {
+	err := controller.Cfg.DownloadLinks.Verify(r.URL, time.Now())
+	if err != nil {
+		log.Warnf("Rejected download of %s from %v: %v", r.URL.Path,
+			getClientIP(r, controller.Cfg.RealIPHeader), err)
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return false
+	}
+	log.Infof("Download of %s from %v", r.URL.Path,
+		getClientIP(r, controller.Cfg.RealIPHeader))
+	return true
+}
+
+// writeScriptsCSV writes the redeem scripts of users as CSV.
+func writeScriptsCSV(w http.ResponseWriter, users []models.User) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"UserId", "MultiSigAddress", "MultiSigScript",
+		"UserPubKeyAddr", "PoolPubKeyAddr", "HeightRegistered"})
+	for _, user := range users {
+		cw.Write([]string{
+			strconv.FormatInt(user.ID, 10),
+			user.MultiSigAddress,
+			user.MultiSigScript,
+			user.UserPubKeyAddr,
+			user.PoolPubKeyAddr,
+			strconv.FormatInt(user.HeightRegistered, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ScriptsDownload serves the backup of the redeem scripts of all users as
+// CSV.  It is linked from the admin status page with a signed link, so that
+// backup scripts can fetch it without a session until the link expires.
+func (controller *MainController) ScriptsDownload(c web.C, w http.ResponseWriter, r *http.Request) {
+	if !controller.verifyDownload(w, r) {
+		return
+	}
+
+	users, err := models.GetUserScripts(controller.GetReadDbMap(c))
+	if err != nil {
+		log.Errorf("unable to get redeem scripts of users: %v", err)
+		http.Error(w, "unable to get redeem scripts",
+			http.StatusInternalServerError)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/csv; charset=utf-8")
+	h.Set("Content-Disposition", `attachment; filename="scripts.csv"`)
+	h.Set("Cache-Control", "no-store")
+	if err := writeScriptsCSV(w, users); err != nil {
+		log.Errorf("unable to write redeem script backup: %v", err)
+	}
+}
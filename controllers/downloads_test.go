@@ -0,0 +1,70 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrstakepool/internal/signedurl"
+	"github.com/decred/dcrstakepool/models"
+)
+
+func TestDownloadLinks(t *testing.T) {
+	controller := &MainController{Cfg: &Config{
+		BaseURL:              "https://example.com",
+		DownloadLinks:        signedurl.NewSigner("secret", nil),
+		DownloadLinkLifetime: time.Hour,
+	}}
+
+	link, err := controller.downloadURL(scriptsDownloadPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(link, "https://example.com"+scriptsDownloadPath+"?") {
+		t.Fatalf("unexpected link %s", link)
+	}
+
+	for _, test := range []struct {
+		url  string
+		want bool
+	}{
+		{link, true},
+		{"https://example.com" + scriptsDownloadPath, false},
+		{strings.Replace(link, "scripts", "users", 1), false},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", test.url, nil)
+		if got := controller.verifyDownload(w, r); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.url, got, test.want)
+		}
+		if !test.want && w.Code != http.StatusForbidden {
+			t.Errorf("%s: got status %d", test.url, w.Code)
+		}
+	}
+}
+
+func TestWriteScriptsCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := writeScriptsCSV(w, []models.User{{
+		ID:               7,
+		MultiSigAddress:  "Tcaddr",
+		MultiSigScript:   "5221",
+		UserPubKeyAddr:   "Tkuser",
+		PoolPubKeyAddr:   "Tkpool",
+		HeightRegistered: 1000,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "UserId,MultiSigAddress,MultiSigScript,UserPubKeyAddr,PoolPubKeyAddr,HeightRegistered\n" +
+		"7,Tcaddr,5221,Tkuser,Tkpool,1000\n"
+	if w.Body.String() != want {
+		t.Fatalf("got %q, want %q", w.Body.String(), want)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+)
+
+// emailCooldownEnds returns when the cooldown after the last email change of
+// user ends, and whether it has not ended at now.  Changing the read-only API
+// token or submitting a voting address is blocked during the cooldown, so
+// that someone who took over an account by changing its email address cannot
+// also lock the user out of them right away.
+func emailCooldownEnds(user *models.User, cooldown time.Duration, now time.Time) (time.Time, bool) {
+	if user.EmailChanged == 0 || cooldown <= 0 {
+		return time.Time{}, false
+	}
+	ends := time.Unix(user.EmailChanged, 0).Add(cooldown)
+	return ends, now.Before(ends)
+}
+
+// emailCooldownMessage returns the message shown to users whose change is
+// blocked by the cooldown ending at ends.
+func emailCooldownMessage(ends time.Time) string {
+	return "Your email address was changed recently. For your security, " +
+		"this change is possible again after " +
+		ends.UTC().Format("2006-01-02 15:04 MST") + "."
+}
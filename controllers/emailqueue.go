@@ -0,0 +1,198 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	// maxEmailAttempts is the number of attempts to send a queued email
+	// after which it is no longer retried automatically.
+	maxEmailAttempts = 10
+
+	// emailRetryBaseDelay is the delay before the first retry of a queued
+	// email.  It doubles with every failed attempt up to emailRetryMaxDelay.
+	emailRetryBaseDelay = time.Minute
+	emailRetryMaxDelay  = time.Hour * 6
+
+	// emailRetryBatchSize is the maximum number of queued emails retried at
+	// once.
+	emailRetryBatchSize = 50
+
+	// maxEmailErrorLength is the length the recorded errors of failed
+	// attempts are truncated to.
+	maxEmailErrorLength = 1000
+)
+
+// emailRetryDelay returns the delay before the next attempt to send an email
+// after it failed attempts times.
+func emailRetryDelay(attempts int64) time.Duration {
+	return backoffDelay(attempts, emailRetryBaseDelay, emailRetryMaxDelay)
+}
+
+// recordEmailFailure records a failed attempt to send a queued email and
+// schedules the next one, unless the email failed maxEmailAttempts times.
+func recordEmailFailure(dbMap *gorp.DbMap, email *models.QueuedEmail, sendErr error) {
+	email.Attempts++
+	email.LastError = truncateString(sendErr.Error(), maxEmailErrorLength)
+	email.NextAttempt = 0
+	if email.Attempts < maxEmailAttempts {
+		email.NextAttempt = time.Now().Add(emailRetryDelay(email.Attempts)).Unix()
+	} else {
+		log.Errorf("giving up on email %d to %s after %d attempts: %v",
+			email.ID, email.Email, email.Attempts, sendErr)
+	}
+	if err := models.UpdateQueuedEmail(dbMap, email); err != nil {
+		log.Errorf("unable to update queued email %d: %v", email.ID, err)
+	}
+}
+
+// QueueFailedEmails makes the emails which could not be sent queued in the DB
+// to be retried by RetryQueuedEmails, rather than lost.
+func (controller *MainController) QueueFailedEmails(dbMap *gorp.DbMap) {
+	controller.Cfg.EmailSender.SetFailureHandler(func(emailaddress, subject,
+		body string, sendErr error) error {
+		email := &models.QueuedEmail{
+			Email:   emailaddress,
+			Subject: subject,
+			Body:    body,
+			Created: time.Now().Unix(),
+		}
+		if err := models.InsertQueuedEmail(dbMap, email); err != nil {
+			return err
+		}
+		log.Warnf("unable to send email %q to %s, queued to retry: %v",
+			subject, emailaddress, sendErr)
+		recordEmailFailure(dbMap, email, sendErr)
+		return nil
+	})
+}
+
+// RetryQueuedEmails attempts to send the queued emails which are due.  Sent
+// emails are removed from the queue.
+func (controller *MainController) RetryQueuedEmails(dbMap *gorp.DbMap) {
+	emails, err := models.GetDueQueuedEmails(dbMap, time.Now().Unix(),
+		emailRetryBatchSize)
+	if err != nil {
+		log.Errorf("unable to get queued emails: %v", err)
+		return
+	}
+
+	for i := range emails {
+		email := &emails[i]
+		err := controller.Cfg.EmailSender.Send(email.Email, email.Subject,
+			email.Body)
+		if err != nil {
+			recordEmailFailure(dbMap, email, err)
+			continue
+		}
+		log.Infof("sent queued email %d to %s after %d failed attempts",
+			email.ID, email.Email, email.Attempts)
+		if err := models.DeleteQueuedEmail(dbMap, email.ID); err != nil {
+			log.Errorf("unable to remove sent email %d: %v", email.ID, err)
+		}
+	}
+}
+
+// AdminEmails renders the administrative page listing the emails which could
+// not be sent yet.
+func (controller *MainController) AdminEmails(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	emails, err := models.GetQueuedEmails(controller.GetReadDbMap(c))
+	if err != nil {
+		log.Errorf("unable to get queued emails: %v", err)
+		session.AddFlash("Unable to get queued emails", "adminEmailsError")
+	}
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminEmails"] = true
+	c.Env["Title"] = "Decred Voting Service - Emails (Admin)"
+
+	c.Env["FlashError"] = session.Flashes("adminEmailsError")
+	c.Env["FlashSuccess"] = session.Flashes("adminEmailsSuccess")
+
+	c.Env["QueuedEmails"] = emails
+	c.Env["MaxEmailAttempts"] = maxEmailAttempts
+
+	widgets := controller.Parse(t, "admin/emails", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// AdminEmailsPost resends or discards a queued email, as posted from
+// AdminEmails.
+func (controller *MainController) AdminEmailsPost(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	dbMap := controller.GetDbMap(c)
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		session.AddFlash("Invalid email", "adminEmailsError")
+		return "/adminemails", http.StatusSeeOther
+	}
+	email, err := models.GetQueuedEmailByID(dbMap, id)
+	if err != nil {
+		session.AddFlash("The email is no longer queued", "adminEmailsError")
+		return "/adminemails", http.StatusSeeOther
+	}
+
+	switch r.FormValue("action") {
+	case "resend":
+		err := controller.Cfg.EmailSender.Send(email.Email, email.Subject,
+			email.Body)
+		if err != nil {
+			recordEmailFailure(dbMap, email, err)
+			session.AddFlash("Unable to send email: "+err.Error(),
+				"adminEmailsError")
+			return "/adminemails", http.StatusSeeOther
+		}
+		log.Infof("admin user %d resent queued email %d to %s",
+			controller.GetUser(c).ID, email.ID, email.Email)
+		controller.auditAdminAction(c, r, "resend queued email", email.Email)
+		session.AddFlash("Email sent to "+email.Email, "adminEmailsSuccess")
+
+	case "discard":
+		log.Infof("admin user %d discarded queued email %d to %s",
+			controller.GetUser(c).ID, email.ID, email.Email)
+		controller.auditAdminAction(c, r, "discard queued email", email.Email)
+		session.AddFlash("Email discarded", "adminEmailsSuccess")
+
+	default:
+		session.AddFlash("Unknown action", "adminEmailsError")
+		return "/adminemails", http.StatusSeeOther
+	}
+
+	if err := models.DeleteQueuedEmail(dbMap, email.ID); err != nil {
+		log.Errorf("unable to remove queued email %d: %v", email.ID, err)
+	}
+	return "/adminemails", http.StatusSeeOther
+}
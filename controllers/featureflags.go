@@ -0,0 +1,216 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+)
+
+// featureFlagsRefreshInterval is how long the feature flags are cached before
+// they are read from the DB again, and so how long it takes for changes made
+// on another instance of dcrstakepool to apply.
+const featureFlagsRefreshInterval = 30 * time.Second
+
+// featureFlagName matches the valid names of feature flags.
+var featureFlagName = regexp.MustCompile(`^[a-z0-9_.-]{1,64}$`)
+
+// featureFlags caches the feature flags stored in the DB, keyed by name.
+type featureFlags struct {
+	mtx     sync.Mutex
+	flags   map[string]models.FeatureFlag
+	fetched time.Time
+}
+
+// get returns the feature flag with name, reading the flags from the DB when
+// they were cached longer than featureFlagsRefreshInterval.  The cached flags
+// are kept when they cannot be read.
+func (f *featureFlags) get(dbMap *gorp.DbMap, name string, now time.Time) (models.FeatureFlag, bool) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if now.Sub(f.fetched) >= featureFlagsRefreshInterval {
+		f.fetched = now
+		flags, err := models.GetFeatureFlags(dbMap)
+		if err != nil {
+			log.Errorf("unable to get feature flags: %v", err)
+		} else {
+			f.flags = make(map[string]models.FeatureFlag, len(flags))
+			for _, flag := range flags {
+				f.flags[flag.Name] = flag
+			}
+		}
+	}
+
+	flag, ok := f.flags[name]
+	return flag, ok
+}
+
+// invalidate makes the next get read the flags from the DB.
+func (f *featureFlags) invalidate() {
+	f.mtx.Lock()
+	f.fetched = time.Time{}
+	f.mtx.Unlock()
+}
+
+// featureEnabledFor returns whether flag enables its feature for the user
+// with id.  Users are assigned to the rolled out percentage by a hash of their
+// id and the flag name, so the same users keep the feature as the percentage
+// grows.  Visitors who are not logged in, whose id is 0, only get features
+// enabled for all users.
+func featureEnabledFor(flag *models.FeatureFlag, userID int64) bool {
+	if flag.Enabled == 0 || flag.Percent <= 0 {
+		return false
+	}
+	if flag.Percent >= 100 {
+		return true
+	}
+	if userID == 0 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(flag.Name + ":" + strconv.FormatInt(userID, 10)))
+	return int64(h.Sum32()%100) < flag.Percent
+}
+
+// webhooksFeature is the name of the feature flag rolling out webhooks, when
+// the webhooks option is set.  Without the flag all users get webhooks.
+const webhooksFeature = "webhooks"
+
+// FeatureEnabled returns whether the feature flag with name enables its
+// feature for the user of the request, which is the logged in user or the
+// user of the API token.  Features without a flag are enabled when def is
+// set, so that existing features can be rolled back or out again by adding a
+// flag.
+func (controller *MainController) FeatureEnabled(c web.C, name string, def bool) bool {
+	flag, ok := controller.featureFlags.get(controller.GetDbMap(c), name,
+		time.Now())
+	if !ok {
+		return def
+	}
+
+	userID, _ := c.Env["APIUserID"].(int64)
+	if user := controller.GetUser(c); user != nil {
+		userID = user.ID
+	}
+	return featureEnabledFor(&flag, userID)
+}
+
+// AdminFeatures renders the administrative feature flags page.
+func (controller *MainController) AdminFeatures(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	flags, err := models.GetFeatureFlags(controller.GetDbMap(c))
+	if err != nil {
+		log.Errorf("unable to get feature flags: %v", err)
+		session.AddFlash("Unable to get feature flags", "adminFeaturesError")
+	}
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminFeatures"] = true
+	c.Env["Title"] = "Decred Voting Service - Features (Admin)"
+
+	c.Env["FlashError"] = session.Flashes("adminFeaturesError")
+	c.Env["FlashSuccess"] = session.Flashes("adminFeaturesSuccess")
+
+	c.Env["FeatureFlags"] = flags
+	c.Env["RefreshSeconds"] = int(featureFlagsRefreshInterval / time.Second)
+
+	widgets := controller.Parse(t, "admin/features", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// AdminFeaturesPost sets or deletes a feature flag, as posted from
+// AdminFeatures.
+func (controller *MainController) AdminFeaturesPost(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	dbMap := controller.GetDbMap(c)
+	adminID := controller.GetUser(c).ID
+
+	name := r.FormValue("name")
+	if !featureFlagName.MatchString(name) {
+		session.AddFlash("Names must be up to 64 lowercase letters, digits, "+
+			"dots, dashes or underscores", "adminFeaturesError")
+		return "/adminfeatures", http.StatusSeeOther
+	}
+
+	switch r.FormValue("action") {
+	case "set":
+		percent, err := strconv.ParseInt(r.FormValue("percent"), 10, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			session.AddFlash("Percent must be a number from 0 to 100",
+				"adminFeaturesError")
+			return "/adminfeatures", http.StatusSeeOther
+		}
+		flag := &models.FeatureFlag{
+			Name:         name,
+			Description:  truncateString(r.FormValue("description"), 255),
+			Percent:      percent,
+			UpdatedByUID: adminID,
+			Updated:      time.Now().Unix(),
+		}
+		if r.FormValue("enabled") != "" {
+			flag.Enabled = 1
+		}
+		if err := models.SetFeatureFlag(dbMap, flag); err != nil {
+			log.Errorf("unable to set feature flag %s: %v", name, err)
+			session.AddFlash("Unable to save feature flag", "adminFeaturesError")
+			return "/adminfeatures", http.StatusSeeOther
+		}
+
+		log.Infof("admin user %d set feature flag %s to enabled %d for %d%%",
+			adminID, name, flag.Enabled, percent)
+		controller.auditAdminAction(c, r, fmt.Sprintf("set feature flag to "+
+			"enabled %d for %d%%", flag.Enabled, percent), name)
+		session.AddFlash("Feature flag "+name+" saved", "adminFeaturesSuccess")
+
+	case "delete":
+		if err := models.DeleteFeatureFlag(dbMap, name); err != nil {
+			log.Errorf("unable to delete feature flag %s: %v", name, err)
+			session.AddFlash("Unable to delete feature flag", "adminFeaturesError")
+			return "/adminfeatures", http.StatusSeeOther
+		}
+
+		log.Infof("admin user %d deleted feature flag %s", adminID, name)
+		controller.auditAdminAction(c, r, "delete feature flag", name)
+		session.AddFlash("Feature flag "+name+" deleted", "adminFeaturesSuccess")
+
+	default:
+		session.AddFlash("Unknown action", "adminFeaturesError")
+		return "/adminfeatures", http.StatusSeeOther
+	}
+
+	controller.featureFlags.invalidate()
+	return "/adminfeatures", http.StatusSeeOther
+}
@@ -0,0 +1,497 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+	dcrdatatypes "github.com/decred/dcrdata/api/types/v5"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	// maxFeeWatchLookups is the number of transactions the fee watcher looks
+	// up in each run, so that the first runs on a large voting service do
+	// not flood dcrdata.  Mined transactions are cached, so the report is
+	// completed over several runs.
+	maxFeeWatchLookups = 1000
+
+	// maxFeeWatchFlagged is the number of tickets with unrealized fees listed
+	// on the admin fees page.
+	maxFeeWatchFlagged = 500
+
+	// maxFeeWatchResponseSize is the size in bytes of the largest
+	// transaction accepted from dcrdata.
+	maxFeeWatchResponseSize = 1 << 20
+
+	// feeWatchRequestTimeout is how long a transaction lookup may take.
+	feeWatchRequestTimeout = 30 * time.Second
+)
+
+// The fee states of tickets in the fee report.  The fee of a ticket is pending
+// until the ticket is spent, and realized once its vote or revocation, or the
+// fee transaction when fees are deferred, pays the fee address.  It is
+// unrealized when the ticket missed or expired without being revoked, or when
+// it was spent without paying the fee address.  Tickets which commit no fee,
+// such as low fee tickets, have none, and the state of those whose
+// transactions were not looked up yet is unknown.
+const (
+	feeStatePending    = "pending"
+	feeStateRealized   = "realized"
+	feeStateUnrealized = "unrealized"
+	feeStateNone       = "none"
+	feeStateUnknown    = "unknown"
+)
+
+// feeStates orders the fee states on the admin fees page.
+var feeStates = []string{feeStateRealized, feeStatePending,
+	feeStateUnrealized, feeStateNone, feeStateUnknown}
+
+// feeTx holds the outputs of a transaction relevant to the pool fees: the
+// commitments of a ticket, and the payments of votes, revocations and fee
+// transactions, in atoms by address.  BlockTime is the time of the block the
+// transaction was mined in, or 0 while it is unmined.
+type feeTx struct {
+	Commitments map[string]int64
+	Payments    map[string]int64
+	BlockTime   int64
+}
+
+// feeTxSource looks up transactions by hash for the fee watcher.
+type feeTxSource interface {
+	feeTx(ctx context.Context, hash string) (*feeTx, error)
+}
+
+// dcrdataFeeTxs looks up transactions with the API of the dcrdata instance at
+// url.
+type dcrdataFeeTxs struct {
+	url    string
+	client *http.Client
+}
+
+func (d *dcrdataFeeTxs) feeTx(ctx context.Context, hash string) (*feeTx, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		d.url+"/api/tx/"+hash, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dcrdata returned %s for transaction %s",
+			resp.Status, hash)
+	}
+
+	var tx dcrdatatypes.Tx
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxFeeWatchResponseSize)).
+		Decode(&tx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode transaction %s: %v", hash,
+			err)
+	}
+	return parseDcrdataTx(&tx)
+}
+
+// parseDcrdataTx returns the outputs of a transaction returned by dcrdata
+// which are relevant to the pool fees.
+func parseDcrdataTx(tx *dcrdatatypes.Tx) (*feeTx, error) {
+	ftx := &feeTx{
+		Commitments: make(map[string]int64),
+		Payments:    make(map[string]int64),
+	}
+	if tx.Block != nil && tx.Confirmations > 0 {
+		ftx.BlockTime = tx.Block.Time
+	}
+	for i := range tx.Vout {
+		script := &tx.Vout[i].ScriptPubKeyDecoded
+		if len(script.Addresses) == 0 {
+			continue
+		}
+		if script.CommitAmt != nil {
+			amount, err := dcrutil.NewAmount(*script.CommitAmt)
+			if err != nil {
+				return nil, err
+			}
+			ftx.Commitments[script.Addresses[0]] += int64(amount)
+			continue
+		}
+		amount, err := dcrutil.NewAmount(tx.Vout[i].Value)
+		if err != nil {
+			return nil, err
+		}
+		ftx.Payments[script.Addresses[0]] += int64(amount)
+	}
+	return ftx, nil
+}
+
+// feeTicket is a ticket in the fee report.  Expected is the fee in atoms the
+// ticket commits to FeeAddress, or owes when fees are deferred, and Realized
+// the fee paid to FeeAddress by the transaction PaidBy.
+type feeTicket struct {
+	UserID     int64
+	Ticket     string
+	Status     string
+	FeeAddress string
+	PaidBy     string
+	Expected   int64
+	Realized   int64
+	PaidTime   int64
+	State      string
+}
+
+// ExpectedCoin returns the expected fee of the ticket in DCR.
+func (t feeTicket) ExpectedCoin() float64 {
+	return dcrutil.Amount(t.Expected).ToCoin()
+}
+
+// RealizedCoin returns the realized fee of the ticket in DCR.
+func (t feeTicket) RealizedCoin() float64 {
+	return dcrutil.Amount(t.Realized).ToCoin()
+}
+
+// reconcileTicketFee sets the expected and realized fee of a ticket whose fee
+// is committed by the ticket, from its ticket transaction and the vote or
+// revocation spending it.  Either is nil when it was not looked up.
+func reconcileTicketFee(t *feeTicket, ticketTx, spendTx *feeTx) {
+	if ticketTx == nil {
+		t.State = feeStateUnknown
+		return
+	}
+	t.Expected = ticketTx.Commitments[t.FeeAddress]
+	switch {
+	case t.Expected == 0:
+		t.State = feeStateNone
+	case t.PaidBy == "":
+		t.State = feeStatePending
+		if t.Status == "missed" || t.Status == "expired" {
+			t.State = feeStateUnrealized
+		}
+	case spendTx == nil:
+		t.State = feeStateUnknown
+	default:
+		t.Realized = spendTx.Payments[t.FeeAddress]
+		t.PaidTime = spendTx.BlockTime
+		t.State = feeStateRealized
+		if t.Realized == 0 {
+			t.State = feeStateUnrealized
+		}
+	}
+}
+
+// reconcileDeferredFee sets the realized fee of a ticket whose fee is
+// deferred, from the fee transaction tx paying it, which is nil when it was
+// not looked up.  feeStatus is the status of the models.TicketFee.
+func reconcileDeferredFee(t *feeTicket, feeStatus string, tx *feeTx) {
+	switch {
+	case t.PaidBy == "":
+		t.State = feeStatePending
+		if feeStatus == models.TicketFeeExpired {
+			t.State = feeStateUnrealized
+		}
+	case tx == nil:
+		t.State = feeStateUnknown
+	default:
+		t.Realized = tx.Payments[t.FeeAddress]
+		t.PaidTime = tx.BlockTime
+		switch {
+		case t.Realized >= t.Expected && t.PaidTime != 0:
+			t.State = feeStateRealized
+		case feeStatus == models.TicketFeeExpired:
+			t.State = feeStateUnrealized
+		default:
+			t.State = feeStatePending
+		}
+	}
+}
+
+// feeStateCount holds the number of tickets in a fee state and the fees
+// they are expected to pay and have paid.
+type feeStateCount struct {
+	State    string
+	Tickets  int
+	Expected float64
+	Realized float64
+}
+
+// feeMonth holds the fees realized in a month of the revenue report.
+type feeMonth struct {
+	Month    string
+	Tickets  int
+	Realized float64
+}
+
+// feeReport is the report of the fee watcher shown on the admin fees page.
+// Flagged lists up to maxFeeWatchFlagged of the FlaggedTotal tickets with
+// unrealized fees.  Lookups and Errors count the transactions looked up in the
+// run which generated the report and the lookups which failed.
+type feeReport struct {
+	Generated    time.Time
+	Tickets      int
+	States       []feeStateCount
+	Months       []feeMonth
+	Flagged      []feeTicket
+	FlaggedTotal int
+	Lookups      int
+	Errors       int
+}
+
+// buildFeeReport totals the fees of tickets per state and the realized fees
+// per month of the block paying them, most recent month first.
+func buildFeeReport(tickets []feeTicket, now time.Time) *feeReport {
+	report := &feeReport{Generated: now, Tickets: len(tickets)}
+
+	type totals struct {
+		tickets            int
+		expected, realized int64
+	}
+	states := make(map[string]*totals)
+	months := make(map[string]*totals)
+	for i := range tickets {
+		t := &tickets[i]
+		s, ok := states[t.State]
+		if !ok {
+			s = new(totals)
+			states[t.State] = s
+		}
+		s.tickets++
+		s.expected += t.Expected
+		s.realized += t.Realized
+
+		switch t.State {
+		case feeStateRealized:
+			if t.PaidTime == 0 {
+				continue
+			}
+			month := time.Unix(t.PaidTime, 0).UTC().Format("2006-01")
+			m, ok := months[month]
+			if !ok {
+				m = new(totals)
+				months[month] = m
+			}
+			m.tickets++
+			m.realized += t.Realized
+		case feeStateUnrealized:
+			report.FlaggedTotal++
+			if len(report.Flagged) < maxFeeWatchFlagged {
+				report.Flagged = append(report.Flagged, *t)
+			}
+		}
+	}
+
+	for _, state := range feeStates {
+		s, ok := states[state]
+		if !ok {
+			continue
+		}
+		report.States = append(report.States, feeStateCount{
+			State:    state,
+			Tickets:  s.tickets,
+			Expected: dcrutil.Amount(s.expected).ToCoin(),
+			Realized: dcrutil.Amount(s.realized).ToCoin(),
+		})
+	}
+	for month, m := range months {
+		report.Months = append(report.Months, feeMonth{
+			Month:    month,
+			Tickets:  m.tickets,
+			Realized: dcrutil.Amount(m.realized).ToCoin(),
+		})
+	}
+	sort.Slice(report.Months, func(i, j int) bool {
+		return report.Months[i].Month > report.Months[j].Month
+	})
+	return report
+}
+
+// feeWatcher reconciles the fees of the tickets of all users against the
+// payments to the fee addresses, looking up the transactions with source.
+// Mined transactions never change, so they are cached for the lifetime of
+// the process.  It is safe for concurrent use.
+type feeWatcher struct {
+	source feeTxSource
+
+	mtx    sync.Mutex
+	txs    map[string]*feeTx
+	report *feeReport
+}
+
+func newFeeWatcher(source feeTxSource) *feeWatcher {
+	return &feeWatcher{
+		source: source,
+		txs:    make(map[string]*feeTx),
+	}
+}
+
+// feeLookups counts the transaction lookups of a run of the fee watcher.
+type feeLookups struct {
+	remaining int
+	done      int
+	errors    int
+}
+
+// lookup returns the transaction with hash from the cache, or looks it up
+// while lookups remain.  It returns nil when the transaction is unknown.
+func (w *feeWatcher) lookup(ctx context.Context, hash string, lookups *feeLookups) *feeTx {
+	w.mtx.Lock()
+	tx, ok := w.txs[hash]
+	w.mtx.Unlock()
+	if ok {
+		return tx
+	}
+	if lookups.remaining <= 0 || ctx.Err() != nil {
+		return nil
+	}
+	lookups.remaining--
+	lookups.done++
+
+	ctx, cancel := context.WithTimeout(ctx, feeWatchRequestTimeout)
+	defer cancel()
+	tx, err := w.source.feeTx(ctx, hash)
+	if err != nil {
+		lookups.errors++
+		log.Debugf("fee watcher: unable to look up %s: %v", hash, err)
+		return nil
+	}
+	if tx.BlockTime != 0 {
+		w.mtx.Lock()
+		w.txs[hash] = tx
+		w.mtx.Unlock()
+	}
+	return tx
+}
+
+// WatchFees reconciles the fees of the tickets of all users against the
+// payments to the fee addresses and updates the fee report.  It does nothing
+// unless the fee watcher is enabled.
+func (controller *MainController) WatchFees(ctx context.Context, dbMap *gorp.DbMap) {
+	w := controller.feeWatcher
+	if w == nil {
+		return
+	}
+
+	lookups := &feeLookups{remaining: maxFeeWatchLookups}
+	var tickets []feeTicket
+
+	if controller.Cfg.FeeMode == models.FeeModeDeferred {
+		fees, err := models.GetTicketFees(dbMap)
+		if err != nil {
+			log.Errorf("fee watcher: unable to get ticket fees: %v", err)
+			return
+		}
+		for i := range fees {
+			fee := &fees[i]
+			t := feeTicket{
+				UserID:     fee.UserID,
+				Ticket:     fee.TicketHash,
+				Status:     fee.Status,
+				FeeAddress: fee.FeeAddress,
+				PaidBy:     fee.FeeTxHash,
+				Expected:   fee.FeeAmount,
+			}
+			var tx *feeTx
+			if t.PaidBy != "" {
+				tx = w.lookup(ctx, t.PaidBy, lookups)
+			}
+			reconcileDeferredFee(&t, fee.Status, tx)
+			tickets = append(tickets, t)
+		}
+	} else {
+		users, err := models.GetUserFeeAddresses(dbMap)
+		if err != nil {
+			log.Errorf("fee watcher: unable to get users: %v", err)
+			return
+		}
+		msas := make([]string, 0, len(users))
+		for i := range users {
+			msas = append(msas, users[i].MultiSigAddress)
+		}
+		infos, err := controller.Cfg.StakepooldServers.BatchStakePoolUserInfo(ctx, msas)
+		if err != nil {
+			log.Errorf("fee watcher: RPC BatchStakePoolUserInfo failed: %v", err)
+			return
+		}
+		for i := range users {
+			user := &users[i]
+			info, ok := infos[user.MultiSigAddress]
+			if !ok {
+				continue
+			}
+			for _, ticket := range info.Tickets {
+				t := feeTicket{
+					UserID:     user.ID,
+					Ticket:     ticket.Ticket,
+					Status:     ticket.Status,
+					FeeAddress: user.UserFeeAddr,
+					PaidBy:     ticket.SpentBy,
+				}
+				ticketTx := w.lookup(ctx, t.Ticket, lookups)
+				var spendTx *feeTx
+				if ticketTx != nil && t.PaidBy != "" {
+					spendTx = w.lookup(ctx, t.PaidBy, lookups)
+				}
+				reconcileTicketFee(&t, ticketTx, spendTx)
+				tickets = append(tickets, t)
+			}
+		}
+	}
+
+	report := buildFeeReport(tickets, time.Now())
+	report.Lookups = lookups.done
+	report.Errors = lookups.errors
+	if lookups.errors > 0 {
+		log.Warnf("fee watcher: %d of %d transaction lookups failed",
+			lookups.errors, lookups.done)
+	}
+
+	w.mtx.Lock()
+	w.report = report
+	w.mtx.Unlock()
+}
+
+// AdminFees renders the administrative fee report, which shows the fees the
+// tickets of all users are expected to pay and have paid to the fee addresses,
+// the realized fees per month, and the tickets whose fees were not realized.
+func (controller *MainController) AdminFees(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminFees"] = true
+	c.Env["Title"] = "Decred Voting Service - Fees (Admin)"
+
+	if w := controller.feeWatcher; w != nil {
+		c.Env["FeeWatch"] = true
+		w.mtx.Lock()
+		c.Env["Report"] = w.report
+		w.mtx.Unlock()
+	}
+	c.Env["FeeMode"] = controller.Cfg.FeeMode
+	c.Env["DCRDataURL"] = controller.DCRDataURL
+
+	widgets := controller.Parse(t, "admin/fees", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	dcrdatatypes "github.com/decred/dcrdata/api/types/v5"
+	"github.com/decred/dcrstakepool/models"
+)
+
+func TestParseDcrdataTx(t *testing.T) {
+	// A ticket as returned by dcrdata, committing 0.01 DCR to the fee
+	// address and the rest to the user.
+	const ticket = `{"txid":"aa","vout":[
+		{"value":100,"n":0,"scriptPubKey":{"type":"stakesubmission","addresses":["Dcs1"]}},
+		{"value":0,"n":1,"scriptPubKey":{"type":"sstxcommitment","addresses":["DsFee"],"commitamt":0.01}},
+		{"value":0,"n":2,"scriptPubKey":{"type":"sstxchange","addresses":["DsChange"]}},
+		{"value":0,"n":3,"scriptPubKey":{"type":"sstxcommitment","addresses":["DsUser"],"commitamt":99.99}},
+		{"value":0,"n":4,"scriptPubKey":{"type":"nulldata"}}],
+		"confirmations":10,"block":{"blockheight":100,"time":1600000000}}`
+	var tx dcrdatatypes.Tx
+	if err := json.Unmarshal([]byte(ticket), &tx); err != nil {
+		t.Fatal(err)
+	}
+	ftx, err := parseDcrdataTx(&tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &feeTx{
+		Commitments: map[string]int64{"DsFee": 1e6, "DsUser": 9999e6},
+		Payments:    map[string]int64{"Dcs1": 100e8, "DsChange": 0},
+		BlockTime:   1600000000,
+	}
+	if !reflect.DeepEqual(ftx, want) {
+		t.Fatalf("want %+v, got %+v", want, ftx)
+	}
+
+	// Unmined transactions have no block time.
+	tx.Confirmations = 0
+	if ftx, _ = parseDcrdataTx(&tx); ftx.BlockTime != 0 {
+		t.Fatalf("unmined transaction has block time %d", ftx.BlockTime)
+	}
+}
+
+func TestReconcileTicketFee(t *testing.T) {
+	ticketTx := &feeTx{Commitments: map[string]int64{"DsFee": 1e6, "DsUser": 1e8}}
+	lowFeeTx := &feeTx{Commitments: map[string]int64{"DsUser": 1e8}}
+	voteTx := &feeTx{Payments: map[string]int64{"DsFee": 1.1e6}, BlockTime: 1600000000}
+	otherTx := &feeTx{Payments: map[string]int64{"DsOther": 1e6}, BlockTime: 1600000000}
+
+	tests := []struct {
+		name              string
+		status, paidBy    string
+		ticketTx, spendTx *feeTx
+		wantState         string
+		wantRealized      int64
+	}{
+		{"live", "live", "", ticketTx, nil, feeStatePending, 0},
+		{"unknown ticket", "live", "", nil, nil, feeStateUnknown, 0},
+		{"low fee", "voted", "bb", lowFeeTx, voteTx, feeStateNone, 0},
+		{"voted", "voted", "bb", ticketTx, voteTx, feeStateRealized, 1.1e6},
+		{"unknown vote", "voted", "bb", ticketTx, nil, feeStateUnknown, 0},
+		{"missed", "missed", "", ticketTx, nil, feeStateUnrealized, 0},
+		{"revoked", "missed", "bb", ticketTx, voteTx, feeStateRealized, 1.1e6},
+		{"expired", "expired", "", ticketTx, nil, feeStateUnrealized, 0},
+		{"not paid", "voted", "bb", ticketTx, otherTx, feeStateUnrealized, 0},
+	}
+	for _, test := range tests {
+		ticket := feeTicket{Status: test.status, FeeAddress: "DsFee",
+			PaidBy: test.paidBy}
+		reconcileTicketFee(&ticket, test.ticketTx, test.spendTx)
+		if ticket.State != test.wantState || ticket.Realized != test.wantRealized {
+			t.Errorf("%s: want %s %d, got %s %d", test.name, test.wantState,
+				test.wantRealized, ticket.State, ticket.Realized)
+		}
+	}
+}
+
+func TestReconcileDeferredFee(t *testing.T) {
+	minedTx := &feeTx{Payments: map[string]int64{"DsFee": 1e6}, BlockTime: 1600000000}
+	unminedTx := &feeTx{Payments: map[string]int64{"DsFee": 1e6}}
+	shortTx := &feeTx{Payments: map[string]int64{"DsFee": 1e5}, BlockTime: 1600000000}
+
+	tests := []struct {
+		name      string
+		feeStatus string
+		paidBy    string
+		tx        *feeTx
+		wantState string
+	}{
+		{"unpaid", models.TicketFeePending, "", nil, feeStatePending},
+		{"expired", models.TicketFeeExpired, "", nil, feeStateUnrealized},
+		{"paid", models.TicketFeePaid, "cc", minedTx, feeStateRealized},
+		{"unmined", models.TicketFeePending, "cc", unminedTx, feeStatePending},
+		{"unknown", models.TicketFeePaid, "cc", nil, feeStateUnknown},
+		{"short", models.TicketFeeExpired, "cc", shortTx, feeStateUnrealized},
+	}
+	for _, test := range tests {
+		ticket := feeTicket{FeeAddress: "DsFee", PaidBy: test.paidBy,
+			Expected: 1e6}
+		reconcileDeferredFee(&ticket, test.feeStatus, test.tx)
+		if ticket.State != test.wantState {
+			t.Errorf("%s: want %s, got %s", test.name, test.wantState,
+				ticket.State)
+		}
+	}
+}
+
+func TestBuildFeeReport(t *testing.T) {
+	sep := time.Date(2020, 9, 15, 0, 0, 0, 0, time.UTC).Unix()
+	oct := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC).Unix()
+	tickets := []feeTicket{
+		{Ticket: "a", State: feeStateRealized, Expected: 1e6, Realized: 1e6, PaidTime: sep},
+		{Ticket: "b", State: feeStateRealized, Expected: 1e6, Realized: 2e6, PaidTime: oct},
+		{Ticket: "c", State: feeStateRealized, Expected: 1e6, Realized: 1e6, PaidTime: oct},
+		{Ticket: "d", State: feeStateUnrealized, Expected: 3e6},
+		{Ticket: "e", State: feeStatePending, Expected: 1e6},
+		{Ticket: "f", State: feeStateUnknown},
+	}
+	now := time.Unix(oct, 0)
+	report := buildFeeReport(tickets, now)
+
+	wantStates := []feeStateCount{
+		{feeStateRealized, 3, 0.03, 0.04},
+		{feeStatePending, 1, 0.01, 0},
+		{feeStateUnrealized, 1, 0.03, 0},
+		{feeStateUnknown, 1, 0, 0},
+	}
+	if !reflect.DeepEqual(report.States, wantStates) {
+		t.Errorf("want states %+v, got %+v", wantStates, report.States)
+	}
+	wantMonths := []feeMonth{{"2020-10", 2, 0.03}, {"2020-09", 1, 0.01}}
+	if !reflect.DeepEqual(report.Months, wantMonths) {
+		t.Errorf("want months %+v, got %+v", wantMonths, report.Months)
+	}
+	if report.Tickets != 6 || report.FlaggedTotal != 1 ||
+		len(report.Flagged) != 1 || report.Flagged[0].Ticket != "d" {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+// testFeeTxSource serves the transactions in txs and counts the lookups.
+type testFeeTxSource struct {
+	txs     map[string]*feeTx
+	lookups int
+}
+
+func (s *testFeeTxSource) feeTx(ctx context.Context, hash string) (*feeTx, error) {
+	s.lookups++
+	tx, ok := s.txs[hash]
+	if !ok {
+		return nil, errors.New("unknown transaction")
+	}
+	return tx, nil
+}
+
+func TestFeeWatcherLookup(t *testing.T) {
+	source := &testFeeTxSource{txs: map[string]*feeTx{
+		"mined":   {BlockTime: 1600000000},
+		"unmined": {},
+	}}
+	w := newFeeWatcher(source)
+	ctx := context.Background()
+	lookups := &feeLookups{remaining: 3}
+
+	// Mined transactions are only looked up once.
+	for i := 0; i < 2; i++ {
+		if w.lookup(ctx, "mined", lookups) == nil {
+			t.Fatal("mined transaction not found")
+		}
+	}
+	if w.lookup(ctx, "unmined", lookups) == nil {
+		t.Fatal("unmined transaction not found")
+	}
+	if w.lookup(ctx, "unknown", lookups) != nil {
+		t.Fatal("unknown transaction found")
+	}
+	if source.lookups != 3 || lookups.done != 3 || lookups.errors != 1 {
+		t.Fatalf("unexpected lookups %d, %+v", source.lookups, lookups)
+	}
+
+	// No lookups remain, but cached transactions are still returned.
+	if w.lookup(ctx, "unmined", lookups) != nil {
+		t.Fatal("transaction looked up without remaining lookups")
+	}
+	if w.lookup(ctx, "mined", lookups) == nil {
+		t.Fatal("cached transaction not returned")
+	}
+	if source.lookups != 3 {
+		t.Fatalf("unexpected lookups %d", source.lookups)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// Get the client's real IP address using the X-Real-IP header, or if that is
+// empty, http.Request.RemoteAddr. See the sample nginx.conf for using the
+// real_ip module to correctly set the X-Real-IP header.
+func getClientIP(r *http.Request, realIPHeader string) string {
+	// getHost returns the host portion of a string containing either a
+	// host:port formatted name or just a host.
+	getHost := func(hostPort string) string {
+		ip, _, err := net.SplitHostPort(hostPort)
+		if err != nil {
+			return hostPort
+		}
+		return ip
+	}
+
+	// If header not set, return RemoteAddr. Invalid hosts are replaced with "".
+	if realIPHeader == "" {
+		return getHost(r.RemoteAddr)
+	}
+	return getHost(r.Header.Get(realIPHeader))
+}
+
+// backoffDelay returns the delay before the next attempt of something which
+// failed attempts times.  The delay is base after the first failure and
+// doubles with every further one up to max.
+func backoffDelay(attempts int64, base, max time.Duration) time.Duration {
+	delay := base
+	for i := int64(1); i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// truncateString returns s truncated to at most n bytes.  It is cut before
+// the rune spanning byte n so that valid UTF-8 stays valid.
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func stringSliceContains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+)
+
+// parseHistoricScript decodes the hex encoded multisig script of a user at
+// their previous voting service and returns it along with its P2SH address.
+func parseHistoricScript(scriptHex string, params dcrutil.AddressParams) ([]byte, string, error) {
+	script, err := hex.DecodeString(strings.TrimSpace(scriptHex))
+	if err != nil {
+		return nil, "", errors.New("the script is not hex encoded")
+	}
+	if !txscript.IsMultisigScript(script) {
+		return nil, "", errors.New("the script is not a multisig script")
+	}
+	addr, err := dcrutil.NewAddressScriptHash(script, params)
+	if err != nil {
+		return nil, "", err
+	}
+	return script, addr.Address(), nil
+}
+
+// lookupUser returns the user with the ID or email address, or nil if there
+// is none.
+func lookupUser(dbMap *gorp.DbMap, idOrEmail string) *models.User {
+	if id, err := strconv.ParseInt(idOrEmail, 10, 64); err == nil {
+		user, err := models.GetUserByID(dbMap, id)
+		if err != nil {
+			log.Warnf("Can't get user by id: %v", err)
+		}
+		return user
+	}
+	return models.GetUserByEmail(dbMap, idOrEmail)
+}
+
+// backfillHistory stores the voted and missed tickets which the wallets found
+// for the script of hi as the pre-migration history of its user.  It returns
+// the number of tickets stored.
+func (controller *MainController) backfillHistory(ctx context.Context, dbMap *gorp.DbMap, hi *models.HistoryImport) (int, error) {
+	spui, err := controller.Cfg.StakepooldServers.StakePoolUserInfo(ctx,
+		hi.MultiSigAddress)
+	if err != nil {
+		return 0, err
+	}
+
+	var tickets []models.HistoricTicket
+	if spui != nil {
+		for _, t := range spui.Tickets {
+			if t.Status != models.HistoricTicketVoted &&
+				t.Status != models.HistoricTicketMissed {
+				continue
+			}
+			tickets = append(tickets, models.HistoricTicket{
+				TicketHash:    t.Ticket,
+				Status:        t.Status,
+				TicketHeight:  int64(t.TicketHeight),
+				SpentBy:       t.SpentBy,
+				SpentByHeight: int64(t.SpentByHeight),
+			})
+		}
+	}
+
+	err = models.BackfillHistoricTickets(dbMap, hi, tickets, time.Now().Unix())
+	return len(tickets), err
+}
+
+// AdminHistory renders the administrative history import page, listing the
+// multisig scripts which users had at their previous voting service and which
+// were imported to show their tickets as pre-migration history.
+func (controller *MainController) AdminHistory(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	imports, err := models.GetHistoryImports(controller.GetReadDbMap(c))
+	if err != nil {
+		log.Errorf("unable to get history imports: %v", err)
+		session.AddFlash("Unable to get history imports", "adminHistoryError")
+	}
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminHistory"] = true
+	c.Env["Title"] = "Decred Voting Service - History Import (Admin)"
+
+	c.Env["FlashError"] = session.Flashes("adminHistoryError")
+	c.Env["FlashSuccess"] = session.Flashes("adminHistorySuccess")
+
+	c.Env["HistoryImports"] = imports
+
+	widgets := controller.Parse(t, "admin/history", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// AdminHistoryPost imports the multisig script a user had at their previous
+// voting service with a rescan, or backfills the tickets found for an
+// imported script, as posted from AdminHistory.  The tickets are backfilled
+// right after the import as well, but the rescan runs in the background, so
+// they should be backfilled again once it finished.
+func (controller *MainController) AdminHistoryPost(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	dbMap := controller.GetDbMap(c)
+	adminID := controller.GetUser(c).ID
+
+	var hi *models.HistoryImport
+	switch r.FormValue("action") {
+	case "import":
+		user := lookupUser(dbMap, strings.TrimSpace(r.FormValue("user")))
+		if user == nil {
+			session.AddFlash("No user with this ID or email address",
+				"adminHistoryError")
+			return "/adminhistory", http.StatusSeeOther
+		}
+
+		script, msa, err := parseHistoricScript(r.FormValue("script"),
+			controller.Cfg.NetParams)
+		if err != nil {
+			session.AddFlash("Invalid multisig script: "+err.Error(),
+				"adminHistoryError")
+			return "/adminhistory", http.StatusSeeOther
+		}
+		rescanHeight, err := strconv.ParseInt(r.FormValue("height"), 10, 64)
+		if err != nil || rescanHeight < 0 {
+			session.AddFlash("The rescan height must be a block height",
+				"adminHistoryError")
+			return "/adminhistory", http.StatusSeeOther
+		}
+
+		// The tickets of a script may only be shown to a single user, and
+		// never those of an address of this voting service.
+		owners, err := models.GetUsersByMultiSigAddresses(dbMap, []string{msa})
+		if err != nil {
+			log.Errorf("unable to get users of %s: %v", msa, err)
+			session.AddFlash("Unable to import the script", "adminHistoryError")
+			return "/adminhistory", http.StatusSeeOther
+		}
+		if len(owners) > 0 {
+			session.AddFlash("The script belongs to a user of this voting "+
+				"service", "adminHistoryError")
+			return "/adminhistory", http.StatusSeeOther
+		}
+		existing, err := models.GetHistoryImportByAddress(dbMap, msa)
+		if err != nil {
+			log.Errorf("unable to get history import of %s: %v", msa, err)
+			session.AddFlash("Unable to import the script", "adminHistoryError")
+			return "/adminhistory", http.StatusSeeOther
+		}
+		if existing != nil {
+			session.AddFlash(fmt.Sprintf("The script was already imported "+
+				"for user %d", existing.UserID), "adminHistoryError")
+			return "/adminhistory", http.StatusSeeOther
+		}
+
+		err = controller.Cfg.StakepooldServers.ImportHistoricScript(r.Context(),
+			script, rescanHeight)
+		if err != nil && !writeApplied(err) {
+			log.Errorf("unable to import historic script %s: %v", msa, err)
+			session.AddFlash("Unable to import the script into the voting "+
+				"wallets: "+err.Error(), "adminHistoryError")
+			return "/adminhistory", http.StatusSeeOther
+		}
+
+		hi = &models.HistoryImport{
+			UserID:          user.ID,
+			AdminUID:        adminID,
+			MultiSigAddress: msa,
+			MultiSigScript:  hex.EncodeToString(script),
+			RescanHeight:    rescanHeight,
+			Created:         time.Now().Unix(),
+		}
+		if err := models.InsertHistoryImport(dbMap, hi); err != nil {
+			log.Errorf("unable to record history import of %s: %v", msa, err)
+			session.AddFlash("Unable to record the import", "adminHistoryError")
+			return "/adminhistory", http.StatusSeeOther
+		}
+
+		log.Infof("admin user %d imported historic script %s of user %d "+
+			"with a rescan from height %d", adminID, msa, user.ID, rescanHeight)
+		controller.auditAdminAction(c, r, "import history",
+			strconv.FormatInt(user.ID, 10), msa)
+
+	case "backfill":
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err == nil {
+			hi, err = models.GetHistoryImport(dbMap, id)
+		}
+		if err != nil || hi == nil {
+			log.Warnf("unable to get history import %q: %v", r.FormValue("id"), err)
+			session.AddFlash("Invalid history import", "adminHistoryError")
+			return "/adminhistory", http.StatusSeeOther
+		}
+
+	default:
+		session.AddFlash("Unknown action", "adminHistoryError")
+		return "/adminhistory", http.StatusSeeOther
+	}
+
+	n, err := controller.backfillHistory(r.Context(), dbMap, hi)
+	if err != nil {
+		log.Errorf("unable to backfill the history of %s: %v",
+			hi.MultiSigAddress, err)
+		session.AddFlash("Unable to backfill the tickets of "+
+			hi.MultiSigAddress+", try again later", "adminHistoryError")
+		return "/adminhistory", http.StatusSeeOther
+	}
+
+	log.Infof("admin user %d backfilled %d historic tickets of %s for user %d",
+		adminID, n, hi.MultiSigAddress, hi.UserID)
+	session.AddFlash(fmt.Sprintf("Backfilled %d voted and missed tickets of "+
+		"%s. Backfill again once the wallets finished rescanning.", n,
+		hi.MultiSigAddress), "adminHistorySuccess")
+
+	return "/adminhistory", http.StatusSeeOther
+}
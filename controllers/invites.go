@@ -0,0 +1,138 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+)
+
+// maxInviteCodeUses is the largest number of registrations a single invite
+// code may allow.
+const maxInviteCodeUses = 10000
+
+// AdminInvites renders the administrative invite codes page, listing the
+// invite codes along with how often they were used.
+func (controller *MainController) AdminInvites(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	inviteCodes, err := models.GetInviteCodes(controller.GetReadDbMap(c))
+	if err != nil {
+		log.Errorf("unable to get invite codes: %v", err)
+		session.AddFlash("Unable to get invite codes", "adminInvitesError")
+	}
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminInvites"] = true
+	c.Env["Title"] = "Decred Voting Service - Invites (Admin)"
+
+	c.Env["FlashError"] = session.Flashes("adminInvitesError")
+	c.Env["FlashSuccess"] = session.Flashes("adminInvitesSuccess")
+
+	c.Env["InviteOnly"] = controller.Cfg.InviteOnly
+	c.Env["InviteCodes"] = inviteCodes
+	c.Env["BaseURL"] = controller.Cfg.BaseURL
+	c.Env["Now"] = time.Now().Unix()
+
+	widgets := controller.Parse(t, "admin/invites", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// AdminInvitesPost generates a new invite code or expires an existing one, as
+// posted from AdminInvites.
+func (controller *MainController) AdminInvitesPost(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	dbMap := controller.GetDbMap(c)
+	adminID := controller.GetUser(c).ID
+
+	switch r.FormValue("action") {
+	case "generate":
+		uses, err := strconv.ParseInt(r.FormValue("uses"), 10, 64)
+		if err != nil || uses < 1 || uses > maxInviteCodeUses {
+			session.AddFlash("Uses must be a number from 1 to "+
+				strconv.Itoa(maxInviteCodeUses), "adminInvitesError")
+			return "/admininvites", http.StatusSeeOther
+		}
+
+		// An empty number of days creates a code which does not expire.
+		var expires int64
+		now := time.Now()
+		if days := r.FormValue("days"); days != "" {
+			d, err := strconv.Atoi(days)
+			if err != nil || d < 1 {
+				session.AddFlash("Days must be a positive number",
+					"adminInvitesError")
+				return "/admininvites", http.StatusSeeOther
+			}
+			expires = now.AddDate(0, 0, d).Unix()
+		}
+
+		inviteCode := &models.InviteCode{
+			Code:         models.NewUserToken().String(),
+			CreatedByUID: adminID,
+			MaxUses:      uses,
+			Created:      now.Unix(),
+			Expires:      expires,
+		}
+		if err := models.InsertInviteCode(dbMap, inviteCode); err != nil {
+			log.Errorf("unable to insert invite code: %v", err)
+			session.AddFlash("Unable to generate invite code", "adminInvitesError")
+			return "/admininvites", http.StatusSeeOther
+		}
+
+		log.Infof("admin user %d generated invite code %d for %d uses",
+			adminID, inviteCode.ID, uses)
+		controller.auditAdminAction(c, r, "generate invite code",
+			strconv.FormatInt(inviteCode.ID, 10))
+		session.AddFlash("Generated invite code "+inviteCode.Code,
+			"adminInvitesSuccess")
+
+	case "expire":
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err != nil {
+			session.AddFlash("Invalid invite code", "adminInvitesError")
+			return "/admininvites", http.StatusSeeOther
+		}
+		if err := models.ExpireInviteCode(dbMap, id); err != nil {
+			log.Errorf("unable to expire invite code %d: %v", id, err)
+			session.AddFlash("Unable to expire invite code", "adminInvitesError")
+			return "/admininvites", http.StatusSeeOther
+		}
+
+		log.Infof("admin user %d expired invite code %d", adminID, id)
+		controller.auditAdminAction(c, r, "expire invite code",
+			strconv.FormatInt(id, 10))
+		session.AddFlash("Invite code expired", "adminInvitesSuccess")
+
+	default:
+		session.AddFlash("Unknown action", "adminInvitesError")
+	}
+
+	return "/admininvites", http.StatusSeeOther
+}
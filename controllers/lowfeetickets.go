@@ -0,0 +1,190 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+)
+
+const (
+	// adminLowFeeTicketsPerPage is the number of ignored and added low fee
+	// tickets listed per page on the admin tickets page.
+	adminLowFeeTicketsPerPage = 100
+
+	// lowFeeTicketsCacheLife is how long the ignored and added low fee
+	// tickets listed on the admin tickets page are cached.
+	lowFeeTicketsCacheLife = 30 * time.Second
+)
+
+// lowFeeTicketRow is a low fee ticket listed on the admin tickets page.
+type lowFeeTicketRow struct {
+	Ticket          string
+	MultiSigAddress string
+}
+
+// cachedLowFeeTickets is a cached set or page of low fee tickets along with
+// the number of tickets in the whole set.
+type cachedLowFeeTickets struct {
+	tickets map[chainhash.Hash]string // [ticket]multisigaddr
+	total   int
+	fetched time.Time
+}
+
+// lowFeeTicketsCache holds the ignored and added low fee tickets listed on the
+// admin tickets page, so that paging through large sets does not fetch them
+// on every load.  The zero value is ready to use.
+type lowFeeTicketsCache struct {
+	mtx     sync.Mutex
+	entries map[string]cachedLowFeeTickets // [set and page]
+}
+
+// get returns the cached tickets with key unless they are older than
+// lowFeeTicketsCacheLife at now.
+func (lc *lowFeeTicketsCache) get(key string, now time.Time) (*cachedLowFeeTickets, bool) {
+	lc.mtx.Lock()
+	defer lc.mtx.Unlock()
+	entry, ok := lc.entries[key]
+	if !ok || now.Sub(entry.fetched) >= lowFeeTicketsCacheLife {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// set caches the tickets with key, dropping the expired entries.
+func (lc *lowFeeTicketsCache) set(key string, entry cachedLowFeeTickets) {
+	lc.mtx.Lock()
+	defer lc.mtx.Unlock()
+	if lc.entries == nil {
+		lc.entries = make(map[string]cachedLowFeeTickets)
+	}
+	for k, e := range lc.entries {
+		if entry.fetched.Sub(e.fetched) >= lowFeeTicketsCacheLife {
+			delete(lc.entries, k)
+		}
+	}
+	lc.entries[key] = entry
+}
+
+// reset drops all cached tickets after the sets were changed.
+func (lc *lowFeeTicketsCache) reset() {
+	lc.mtx.Lock()
+	lc.entries = nil
+	lc.mtx.Unlock()
+}
+
+// lowFeeTicketRows returns tickets sorted by their hashes, in the order in
+// which stakepoold pages them.
+func lowFeeTicketRows(tickets map[chainhash.Hash]string) []lowFeeTicketRow {
+	hashes := make([]chainhash.Hash, 0, len(tickets))
+	for hash := range tickets {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+	rows := make([]lowFeeTicketRow, len(hashes))
+	for i, hash := range hashes {
+		rows[i] = lowFeeTicketRow{
+			Ticket:          hash.String(),
+			MultiSigAddress: tickets[hash],
+		}
+	}
+	return rows
+}
+
+// queryPage returns the page number in the query parameter name of r, or 1.
+func queryPage(r *http.Request, name string) int {
+	page, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
+
+// pageBounds returns the start and end of page of perPage items out of total.
+func pageBounds(page, perPage, total int) (int, int) {
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// ignoredLowFeeTickets returns the page of the ignored low fee tickets and
+// their number, or only their number when page is 0.  Only the page is
+// fetched from stakepoold.
+func (controller *MainController) ignoredLowFeeTickets(ctx context.Context, page int) ([]lowFeeTicketRow, int, error) {
+	key := fmt.Sprintf("ignored %d", page)
+	now := time.Now()
+	if entry, ok := controller.lowFeeTickets.get(key, now); ok {
+		return lowFeeTicketRows(entry.tickets), entry.total, nil
+	}
+
+	var offset, limit int
+	if page > 0 {
+		offset = (page - 1) * adminLowFeeTicketsPerPage
+		limit = adminLowFeeTicketsPerPage
+	}
+	tickets, total, err := controller.Cfg.StakepooldServers.GetIgnoredLowFeeTicketsPage(ctx,
+		offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	controller.lowFeeTickets.set(key, cachedLowFeeTickets{
+		tickets: tickets,
+		total:   total,
+		fetched: now,
+	})
+	return lowFeeTicketRows(tickets), total, nil
+}
+
+// addedLowFeeTickets returns the page of the added low fee tickets which are
+// still votable and their number, or only their number when page is 0.
+func (controller *MainController) addedLowFeeTickets(dbMap *gorp.DbMap, page int) ([]lowFeeTicketRow, int, error) {
+	now := time.Now()
+	entry, ok := controller.lowFeeTickets.get("added", now)
+	if !ok {
+		gvlft, err := models.GetVotableLowFeeTickets(dbMap)
+		if err != nil {
+			return nil, 0, err
+		}
+		tickets := make(map[chainhash.Hash]string, len(gvlft))
+		for _, t := range gvlft {
+			th, err := chainhash.NewHashFromStr(t.TicketHash)
+			if err != nil {
+				continue
+			}
+			tickets[*th] = t.TicketAddress
+		}
+		entry = &cachedLowFeeTickets{
+			tickets: tickets,
+			total:   len(tickets),
+			fetched: now,
+		}
+		controller.lowFeeTickets.set("added", *entry)
+	}
+	if page == 0 {
+		return nil, entry.total, nil
+	}
+
+	rows := lowFeeTicketRows(entry.tickets)
+	start, end := pageBounds(page, adminLowFeeTicketsPerPage, len(rows))
+	return rows[start:end], entry.total, nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/go-gorp/gorp"
+)
+
+// stakeInfoSnapshotRetention is how long the stake info snapshots are kept,
+// which covers the longest of luckWindows.
+const stakeInfoSnapshotRetention = time.Hour * 24 * 91
+
+// luckWindows are the numbers of days the luck of the voting service is
+// computed over.
+var luckWindows = []int64{7, 30, 90}
+
+// poolLuck compares the tickets selected to vote between the stake info
+// snapshots recorded since the unix time since, which are ordered oldest
+// first, with the number of tickets expected to be selected given the share of
+// the live tickets of the voting service.  Between two snapshots, each block
+// selects ticketsPerBlock tickets and the share is the average of the shares
+// of both snapshots.  Pairs of snapshots whose vote counts decrease, e.g.
+// because a wallet was restored, are left out.
+func poolLuck(snapshots []models.StakeInfoSnapshot, since int64, days int64,
+	ticketsPerBlock uint16) poolapi.Luck {
+	luck := poolapi.Luck{Days: days}
+	var first, last *models.StakeInfoSnapshot
+	for i := range snapshots {
+		s := &snapshots[i]
+		if s.Created < since {
+			continue
+		}
+		if first == nil {
+			first = s
+		} else {
+			voted, missed := s.Voted-last.Voted, s.Missed-last.Missed
+			blocks := s.Height - last.Height
+			if voted >= 0 && missed >= 0 && blocks > 0 &&
+				s.PoolSize > 0 && last.PoolSize > 0 {
+				share := (float64(last.Live)/float64(last.PoolSize) +
+					float64(s.Live)/float64(s.PoolSize)) / 2
+				luck.Voted += voted
+				luck.Missed += missed
+				luck.Expected += float64(blocks) * float64(ticketsPerBlock) *
+					share
+			}
+		}
+		last = s
+	}
+	if first != nil {
+		luck.Covered = float64(last.Created-first.Created) / (60 * 60 * 24)
+	}
+	if luck.Expected > 0 {
+		luck.Luck = float64(luck.Voted+luck.Missed) * 100 / luck.Expected
+	}
+	return luck
+}
+
+// luck returns the luck of the voting service over each of luckWindows.
+func (controller *MainController) luck(dbMap *gorp.DbMap) ([]poolapi.Luck, error) {
+	now := time.Now()
+	longest := luckWindows[len(luckWindows)-1]
+	snapshots, err := models.GetStakeInfoSnapshots(dbMap,
+		now.Add(-time.Duration(longest)*time.Hour*24).Unix())
+	if err != nil {
+		return nil, err
+	}
+	luck := make([]poolapi.Luck, 0, len(luckWindows))
+	for _, days := range luckWindows {
+		since := now.Add(-time.Duration(days) * time.Hour * 24).Unix()
+		luck = append(luck, poolLuck(snapshots, since, days,
+			controller.Cfg.NetParams.TicketsPerBlock))
+	}
+	return luck, nil
+}
+
+// RecordStakeInfoSnapshot records the current stake info of the voting service
+// wallets and removes the snapshots older than stakeInfoSnapshotRetention.
+func (controller *MainController) RecordStakeInfoSnapshot(ctx context.Context, dbMap *gorp.DbMap) {
+	gsi, err := controller.Cfg.StakepooldServers.GetStakeInfo(ctx)
+	if err != nil {
+		log.Warnf("unable to record stake info snapshot: RPC GetStakeInfo "+
+			"failed: %v", err)
+		return
+	}
+	now := time.Now()
+	err = models.InsertStakeInfoSnapshot(dbMap, &models.StakeInfoSnapshot{
+		Height:   gsi.BlockHeight,
+		Live:     int64(gsi.Live),
+		PoolSize: int64(gsi.PoolSize),
+		Voted:    int64(gsi.Voted),
+		Missed:   int64(gsi.Missed),
+		Created:  now.Unix(),
+	})
+	if err != nil {
+		log.Errorf("unable to record stake info snapshot: %v", err)
+		return
+	}
+
+	before := now.Add(-stakeInfoSnapshotRetention).Unix()
+	if _, err := models.DeleteStakeInfoSnapshotsBefore(dbMap, before); err != nil {
+		log.Errorf("unable to prune stake info snapshots: %v", err)
+	}
+}
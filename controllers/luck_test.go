@@ -0,0 +1,51 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
+)
+
+func TestPoolLuck(t *testing.T) {
+	const day = 60 * 60 * 24
+	snapshots := []models.StakeInfoSnapshot{
+		{Height: 100, Live: 400, PoolSize: 40000, Voted: 10, Missed: 1, Created: 0},
+		{Height: 388, Live: 400, PoolSize: 40000, Voted: 22, Missed: 1, Created: day},
+		// 288 blocks at a share of 1.5% expect 21.6 selected tickets.
+		{Height: 676, Live: 800, PoolSize: 40000, Voted: 40, Missed: 2, Created: 2 * day},
+		// A restored wallet counts its votes from 0 again.
+		{Height: 964, Live: 800, PoolSize: 40000, Voted: 5, Missed: 0, Created: 3 * day},
+	}
+
+	tests := []struct {
+		name  string
+		since int64
+		want  poolapi.Luck
+	}{
+		{"all", 0, poolapi.Luck{Days: 7, Covered: 3, Voted: 30, Missed: 1,
+			Expected: 36, Luck: 31 * 100 / 36.0}},
+		{"since second", day, poolapi.Luck{Days: 7, Covered: 2, Voted: 18,
+			Missed: 1, Expected: 21.6, Luck: 19 * 100 / 21.6}},
+		{"single", 3 * day, poolapi.Luck{Days: 7}},
+		{"none", 4 * day, poolapi.Luck{Days: 7}},
+	}
+	for _, test := range tests {
+		got := poolLuck(snapshots, test.since, 7, 5)
+		if got.Days != test.want.Days || got.Covered != test.want.Covered ||
+			got.Voted != test.want.Voted || got.Missed != test.want.Missed ||
+			!almostEqual(got.Expected, test.want.Expected) ||
+			!almostEqual(got.Luck, test.want.Luck) {
+			t.Errorf("%s: want %+v, got %+v", test.name, test.want, got)
+		}
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
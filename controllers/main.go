@@ -6,13 +6,12 @@ package controllers
 
 import (
 	"context"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
 	"io/ioutil"
-	"net"
+	"math"
 	"net/http"
 	"sort"
 	"strconv"
@@ -20,7 +19,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/dchest/captcha"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/chaincfg/v3"
 	"github.com/decred/dcrd/dcrutil/v3"
@@ -28,16 +26,16 @@ import (
 	dcrdatatypes "github.com/decred/dcrdata/api/types/v5"
 	"github.com/decred/dcrstakepool/email"
 	"github.com/decred/dcrstakepool/helpers"
-	"github.com/decred/dcrstakepool/internal/version"
+	"github.com/decred/dcrstakepool/internal/netparams"
+	"github.com/decred/dcrstakepool/internal/notify"
+	"github.com/decred/dcrstakepool/internal/signedurl"
 	"github.com/decred/dcrstakepool/models"
 	"github.com/decred/dcrstakepool/poolapi"
-	"github.com/decred/dcrstakepool/stakepooldclient"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
 	"github.com/decred/dcrstakepool/system"
 	"github.com/go-gorp/gorp"
 	"github.com/gorilla/csrf"
 	"github.com/zenazn/goji/web"
-
-	"google.golang.org/grpc/codes"
 )
 
 const (
@@ -48,30 +46,88 @@ const (
 	MaxUsers = 10000
 	// agendasCacheLife is the amount of time to keep agenda data in memory.
 	agendasCacheLife = time.Hour
+	// emailVerifyResendInterval is the minimum time between two email
+	// verification links sent to the same account.
+	emailVerifyResendInterval = time.Minute * 5
 )
 
 // Config holds all the data used to create a new MainController.
 type Config struct {
 	AdminIPs             []string
 	AdminUserIDs         []string
-	APISecret            string
+	APIKeys              *models.APIKeyring
 	BaseURL              string
 	ClosePool            bool
 	ClosePoolMsg         string
+	InviteOnly           bool
+	Maintenance          bool
+	ReadOnly             bool
+	MaintenanceAllowIPs  []string
+	MaintenancePage      string
+	LoginTokenLifetime   time.Duration
+	RefreshTokenLifetime time.Duration
+	// DownloadLinks signs the download links of admin exports, which are
+	// valid for DownloadLinkLifetime.
+	DownloadLinks        *signedurl.Signer
+	DownloadLinkLifetime time.Duration
+	// AdminResetLinkLifetime is the lifetime of the password reset links
+	// admins issue on behalf of users.
+	AdminResetLinkLifetime time.Duration
+	// MaxClockSkew is the clock skew between dcrstakepool, stakepoold and
+	// the dcrd of the wallets above which the status page warns.
+	MaxClockSkew time.Duration
+	// CaptchaWidth and CaptchaHeight are the dimensions of the captcha
+	// images and CaptchaDigits their number of digits.  With CaptchaAudio
+	// the captchas are also offered as recordings in CaptchaAudioLang.
+	CaptchaWidth         int
+	CaptchaHeight        int
+	CaptchaDigits        int
+	CaptchaAudio         bool
+	CaptchaAudioLang     string
+	EmailTokenLifetime   time.Duration
+	EmailCooldown        time.Duration
+	EmailConfirmOld      bool
+	ScriptExpiry         time.Duration
+	ScriptExpiryGrace    time.Duration
 	PoolEmail            string
 	PoolFees             float64
+	FeeMode              string
+	FeePaymentDeadline   time.Duration
 	PoolLink             string
 	RealIPHeader         string
 	MaxVotedTickets      int
+	MaxUserLiveTickets   int
+	RejectReusedAddrs    bool
+	HideAgendaStats      bool
+	Webhooks             bool
+	FeeWatch             bool
+	FeeWatchURL          string
+	FreezeVoteBits       uint16
+	ForcedChoices        map[string]string
 	Description          string
 	Designation          string
+	TOSVersion           string
+	TOSURL               string
+	TorMode              bool
 	APIVersionsSupported []int
 	FeeXpub              *hdkeychain.ExtendedKey
-	StakepooldServers    stakepooldclient.Manager
+	StakepooldServers    manager.Manager
 	EmailSender          email.Sender
 	VotingXpub           *hdkeychain.ExtendedKey
+	VotingAccount        string
+	VotingBranch         uint32
 
 	NetParams *chaincfg.Params
+
+	// Alerts holds the thresholds of the alerts evaluated by
+	// EvaluateAlerts, which are sent with Alerter.  A nil Alerter only
+	// shows them to admins.
+	Alerts  AlertConfig
+	Alerter *notify.Alerter
+
+	// SetDebugLevel changes the log levels at runtime, accepting the same
+	// values as the debuglevel option.
+	SetDebugLevel func(debugLevel string) error
 }
 
 // MainController is the wallet RPC controller type.  Its methods include the
@@ -80,10 +136,40 @@ type MainController struct {
 	// embed type for c.Env[""] context and ExecuteTemplate helpers
 	system.Controller
 
-	Cfg            *Config
-	captchaHandler *captchaHandler
-	voteVersion    uint32
-	DCRDataURL     string
+	Cfg              *Config
+	captchaHandler   *captchaHandler
+	solvedCaptchas   *solvedCaptchas
+	featureFlags     featureFlags
+	votingFreeze     votingFreezeCache
+	signInChallenges *signInChallenges
+	voteVersion      uint32
+	DCRDataURL       string
+	// amountsCache holds the amounts of the tickets and votes shown in
+	// ticket summaries.
+	amountsCache ticketAmountsCache
+	// addressJobs tracks the address setup jobs running in the background.
+	addressJobs addressJobs
+	// maintenance holds whether the voting service is in maintenance, which
+	// admins may change at runtime.
+	maintenance maintenanceMode
+	// readOnly holds whether the voting service refuses writes, set by
+	// admins or while the database refuses writes.
+	readOnly readOnlyMode
+	// voteBitsMigration tracks the migration of the vote bits of users to the
+	// current vote version.
+	voteBitsMigration voteBitsMigration
+	// badges caches the ticket counts shown on the status badges of users.
+	badges badgeCache
+	// lowFeeTickets caches the low fee tickets listed on the admin tickets
+	// page.
+	lowFeeTickets lowFeeTicketsCache
+	// webhookClient delivers the webhook events of users.
+	webhookClient *http.Client
+	// feeWatcher reconciles the fees of tickets, or is nil when the fee
+	// watcher is disabled.
+	feeWatcher *feeWatcher
+	// alerts holds the states of the alerts evaluated by EvaluateAlerts.
+	alerts alertEvaluator
 }
 
 // agendasCache holds the current available agendas for agendasCacheLife. Should
@@ -105,37 +191,32 @@ type agendasMux struct {
 	agendas *[]agenda
 }
 
-// Get the client's real IP address using the X-Real-IP header, or if that is
-// empty, http.Request.RemoteAddr. See the sample nginx.conf for using the
-// real_ip module to correctly set the X-Real-IP header.
-func getClientIP(r *http.Request, realIPHeader string) string {
-	// getHost returns the host portion of a string containing either a
-	// host:port formatted name or just a host.
-	getHost := func(hostPort string) string {
-		ip, _, err := net.SplitHostPort(hostPort)
-		if err != nil {
-			return hostPort
-		}
-		return ip
-	}
-
-	// If header not set, return RemoteAddr. Invalid hosts are replaced with "".
-	if realIPHeader == "" {
-		return getHost(r.RemoteAddr)
-	}
-	return getHost(r.Header.Get(realIPHeader))
-}
-
 // NewMainController is the constructor for the entire controller routing.
 func NewMainController(ctx context.Context, cfg *Config) (*MainController, error) {
 	ch := &captchaHandler{
-		ImgHeight: 127,
-		ImgWidth:  257,
+		ImgHeight: cfg.CaptchaHeight,
+		ImgWidth:  cfg.CaptchaWidth,
+		Digits:    cfg.CaptchaDigits,
+		Audio:     cfg.CaptchaAudio,
+		AudioLang: cfg.CaptchaAudioLang,
 	}
 
 	mc := &MainController{
-		Cfg:            cfg,
-		captchaHandler: ch,
+		Cfg:              cfg,
+		captchaHandler:   ch,
+		solvedCaptchas:   newSolvedCaptchas(),
+		signInChallenges: newSignInChallenges(),
+	}
+	if cfg.Webhooks {
+		mc.webhookClient = newWebhookClient()
+	}
+	if cfg.Maintenance {
+		mc.maintenance.enabled = true
+		log.Warnf("Voting service is in maintenance")
+	}
+	if cfg.ReadOnly {
+		mc.readOnly.manual = true
+		log.Warnf("Voting service is read-only")
 	}
 
 	walletInfo, err := cfg.StakepooldServers.WalletInfo(ctx)
@@ -167,7 +248,23 @@ func NewMainController(ctx context.Context, cfg *Config) (*MainController, error
 
 	mc.voteVersion = lastVersion
 
-	mc.DCRDataURL = fmt.Sprintf("https://%s.dcrdata.org", mc.getNetworkName())
+	// Onion services make no requests to, and link to no, clearnet block
+	// explorer.
+	if !cfg.TorMode {
+		mc.DCRDataURL = fmt.Sprintf("https://%s.dcrdata.org", mc.getNetworkName())
+	}
+
+	if cfg.FeeWatch {
+		url := cfg.FeeWatchURL
+		if url == "" {
+			url = mc.DCRDataURL
+		}
+		mc.feeWatcher = newFeeWatcher(&dcrdataFeeTxs{
+			url:    strings.TrimSuffix(url, "/"),
+			client: &http.Client{Timeout: feeWatchRequestTimeout},
+		})
+		log.Infof("Fee watcher looking up transactions on %s", url)
+	}
 
 	return mc, nil
 }
@@ -185,7 +282,8 @@ func (controller *MainController) getNetworkName() string {
 
 // agendas returns agendas and their statuses. Fetches agenda status from
 // dcrdata.org if past agenda.Timer limit from previous fetch. Caches agenda
-// data for agendasCacheLife. This method is safe for concurrent use.
+// data for agendasCacheLife. Statuses are left unknown when there is no
+// DCRDataURL. This method is safe for concurrent use.
 func (controller *MainController) agendas() *[]agenda {
 	agendasCache.Lock()
 	defer agendasCache.Unlock()
@@ -194,8 +292,12 @@ func (controller *MainController) agendas() *[]agenda {
 		return agendasCache.agendas
 	}
 	agendasCache.timer = now.Add(agendasCacheLife)
+	var agendaInfos []*dcrdatatypes.AgendasInfo
+	var err error
 	url := fmt.Sprintf("%s/api/agendas", controller.DCRDataURL)
-	agendaInfos, err := dcrDataAgendas(url)
+	if controller.DCRDataURL != "" {
+		agendaInfos, err = dcrDataAgendas(url)
+	}
 	if err != nil {
 		// Ensure the next call tries to fetch statuses again.
 		agendasCache.timer = time.Time{}
@@ -241,268 +343,6 @@ func dcrDataAgendas(url string) ([]*dcrdatatypes.AgendasInfo, error) {
 	return a, nil
 }
 
-// API is the main frontend that handles all API requests.
-func (controller *MainController) API(c web.C, r *http.Request) *system.APIResponse {
-	command := c.URLParams["command"]
-
-	// poolapi.Response comprises a status, code, message, and a data struct
-	var code codes.Code
-	var response, status string
-	var data interface{}
-
-	var err error
-
-	switch r.Method {
-	case "GET":
-		switch command {
-		case "getpurchaseinfo":
-			data, code, response, err = controller.APIPurchaseInfo(c, r)
-		case "stats":
-			data, code, response, err = controller.APIStats(c, r)
-		default:
-			return nil
-		}
-	case "POST":
-		switch command {
-		case "address":
-			_, code, response, err = controller.APIAddress(c, r)
-		case "voting":
-			_, code, response, err = controller.APIVoting(c, r)
-		default:
-			return nil
-		}
-	}
-
-	if err != nil {
-		status = "error"
-		response = response + " - " + err.Error()
-	} else {
-		status = "success"
-	}
-
-	return system.NewAPIResponse(status, code, response, data)
-}
-
-// APIAddress is the API version of AddressPost
-func (controller *MainController) APIAddress(c web.C, r *http.Request) ([]string, codes.Code, string, error) {
-	dbMap := controller.GetDbMap(c)
-
-	if c.Env["APIUserID"] == nil {
-		return nil, codes.Unauthenticated, "address error", errors.New("invalid api token")
-	}
-
-	user, _ := models.GetUserByID(dbMap, c.Env["APIUserID"].(int64))
-
-	if len(user.UserPubKeyAddr) > 0 {
-		return nil, codes.AlreadyExists, "address error", errors.New("address already submitted")
-	}
-
-	userPubKeyAddr := r.FormValue("UserPubKeyAddr")
-
-	if _, err := validateUserPubKeyAddr(userPubKeyAddr, controller.Cfg.NetParams); err != nil {
-		return nil, codes.InvalidArgument, "address error", err
-	}
-
-	// Get the ticket address for this user
-	pooladdress, err := controller.TicketAddressForUserID(int(c.Env["APIUserID"].(int64)))
-	if err != nil {
-		log.Errorf("unable to derive ticket address: %v", err)
-		return nil, codes.Unavailable, "system error", errors.New("unable to process wallet commands")
-	}
-
-	poolValidateAddress, err := controller.Cfg.StakepooldServers.ValidateAddress(r.Context(), pooladdress)
-	if err != nil {
-		log.Errorf("unable to validate address: %v", err)
-		return nil, codes.Unavailable, "system error", errors.New("unable to process wallet commands")
-	}
-	if !poolValidateAddress.IsMine {
-		log.Errorf("unable to validate ismine for pool ticket address: %s",
-			pooladdress.String())
-		return nil, codes.Unavailable, "system error", errors.New("unable to process wallet commands")
-	}
-
-	poolPubKeyAddr := poolValidateAddress.PubKeyAddr
-
-	if _, err = dcrutil.DecodeAddress(poolPubKeyAddr, controller.Cfg.NetParams); err != nil {
-		return nil, codes.Unavailable, "system error", errors.New("unable to process wallet commands")
-	}
-
-	createMultiSig, err := controller.Cfg.StakepooldServers.CreateMultisig(r.Context(), []string{poolPubKeyAddr, userPubKeyAddr})
-	if err != nil {
-		return nil, codes.Unavailable, "system error", errors.New("unable to process wallet commands")
-	}
-
-	// Serialize the redeem script (hex string -> []byte)
-	serializedScript, err := hex.DecodeString(createMultiSig.RedeemScript)
-	if err != nil {
-		return nil, codes.Unavailable, "system error", errors.New("unable to process wallet commands")
-	}
-
-	// Import the redeem script
-	var importedHeight int64
-	importedHeight, err = controller.Cfg.StakepooldServers.ImportNewScript(r.Context(), serializedScript)
-	if err != nil {
-		return nil, codes.Unavailable, "system error", errors.New("unable to process wallet commands")
-	}
-
-	userFeeAddr, err := controller.FeeAddressForUserID(int(user.ID))
-	if err != nil {
-		log.Warnf("unexpected error deriving pool addr: %s", err.Error())
-		return nil, codes.Unavailable, "system error", errors.New("unable to process wallet commands")
-	}
-
-	models.UpdateUserByID(dbMap, user.ID, createMultiSig.Address,
-		createMultiSig.RedeemScript, poolPubKeyAddr, userPubKeyAddr,
-		userFeeAddr.Address(), importedHeight)
-
-	log.Infof("successfully create multisigaddress for user %d", c.Env["APIUserID"])
-
-	err = controller.StakepooldUpdateUsers(r.Context(), dbMap)
-	if err != nil {
-		log.Warnf("failure to update users: %v", err)
-	}
-
-	return nil, codes.OK, "address successfully imported", nil
-}
-
-// APIPurchaseInfo fetches and returns the user's info or an error
-func (controller *MainController) APIPurchaseInfo(c web.C,
-	r *http.Request) (*poolapi.PurchaseInfo, codes.Code, string, error) {
-	dbMap := controller.GetDbMap(c)
-
-	if c.Env["APIUserID"] == nil {
-		return nil, codes.Unauthenticated, "purchaseinfo error", errors.New("invalid api token")
-	}
-
-	user, _ := models.GetUserByID(dbMap, c.Env["APIUserID"].(int64))
-
-	if len(user.UserPubKeyAddr) == 0 {
-		return nil, codes.FailedPrecondition, "purchaseinfo error", errors.New("no address submitted")
-	}
-
-	purchaseInfo := &poolapi.PurchaseInfo{
-		PoolAddress:   user.UserFeeAddr,
-		PoolFees:      controller.Cfg.PoolFees,
-		Script:        user.MultiSigScript,
-		TicketAddress: user.MultiSigAddress,
-		VoteBits:      uint16(user.VoteBits),
-	}
-
-	return purchaseInfo, codes.OK, "purchaseinfo successfully retrieved", nil
-}
-
-// APIStats is an API version of the stats page
-func (controller *MainController) APIStats(c web.C,
-	r *http.Request) (*poolapi.Stats, codes.Code, string, error) {
-	dbMap := controller.GetDbMap(c)
-	userCount := models.GetUserCount(dbMap)
-	userCountActive := models.GetUserCountActive(dbMap)
-
-	gsi, err := controller.Cfg.StakepooldServers.GetStakeInfo(r.Context())
-	if err != nil {
-		log.Infof("RPC GetStakeInfo failed: %v", err)
-		return nil, codes.Unavailable, "stats error", errors.New("RPC server error")
-	}
-
-	var poolStatus string
-	if controller.Cfg.ClosePool {
-		poolStatus = "Closed"
-	} else {
-		poolStatus = "Open"
-	}
-
-	stats := &poolapi.Stats{
-		AllMempoolTix:        gsi.AllMempoolTix,
-		APIVersionsSupported: controller.Cfg.APIVersionsSupported,
-		BlockHeight:          gsi.BlockHeight,
-		Difficulty:           gsi.Difficulty,
-		Expired:              gsi.Expired,
-		Immature:             gsi.Immature,
-		Live:                 gsi.Live,
-		Missed:               gsi.Missed,
-		OwnMempoolTix:        gsi.OwnMempoolTix,
-		PoolSize:             gsi.PoolSize,
-		ProportionLive:       gsi.ProportionLive,
-		ProportionMissed:     gsi.ProportionMissed,
-		Revoked:              gsi.Revoked,
-		TotalSubsidy:         gsi.TotalSubsidy,
-		Voted:                gsi.Voted,
-		Network:              controller.Cfg.NetParams.Name,
-		PoolEmail:            controller.Cfg.PoolEmail,
-		PoolFees:             controller.Cfg.PoolFees,
-		PoolStatus:           poolStatus,
-		UserCount:            userCount,
-		UserCountActive:      userCountActive,
-		Version:              version.String(),
-	}
-
-	return stats, codes.OK, "stats successfully retrieved", nil
-}
-
-// APIVoting is the API version of Voting
-func (controller *MainController) APIVoting(c web.C, r *http.Request) ([]string, codes.Code, string, error) {
-	dbMap := controller.GetDbMap(c)
-
-	if c.Env["APIUserID"] == nil {
-		return nil, codes.Unauthenticated, "voting error", errors.New("invalid api token")
-	}
-
-	user, _ := models.GetUserByID(dbMap, c.Env["APIUserID"].(int64))
-	oldVoteBits := user.VoteBits
-
-	vb := r.FormValue("VoteBits")
-	vbi, err := strconv.Atoi(vb)
-	if err != nil {
-		return nil, codes.InvalidArgument, "voting error", errors.New("unable to convert votebits to uint16")
-	}
-	userVoteBits := uint16(vbi)
-
-	if !controller.IsValidVoteBits(userVoteBits) {
-		return nil, codes.InvalidArgument, "voting error", errors.New("votebits invalid for current agendas")
-	}
-
-	user, err = helpers.UpdateVoteBitsByID(dbMap, user.ID, userVoteBits)
-	if err != nil {
-		return nil, codes.Internal, "voting error", errors.New("failed to update voting prefs in database")
-	}
-
-	if uint16(oldVoteBits) != userVoteBits {
-		if err := controller.StakepooldUpdateUsers(r.Context(), dbMap); err != nil {
-			log.Warnf("APIVoting: StakepooldUpdateUsers failed: %v", err)
-		}
-	}
-
-	log.Infof("updated voteBits for user %d from %d to %d",
-		user.ID, oldVoteBits, userVoteBits)
-
-	return nil, codes.OK, "successfully updated voting preferences", nil
-}
-
-func (controller *MainController) isAdmin(c web.C, r *http.Request) (bool, error) {
-	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-
-	if session.Values["UserId"] == nil {
-		return false, fmt.Errorf("%s request with no session from %s",
-			r.URL, remoteIP)
-	}
-
-	uidstr := strconv.Itoa(int(session.Values["UserId"].(int64)))
-
-	if !stringSliceContains(controller.Cfg.AdminIPs, remoteIP) {
-		return false, fmt.Errorf("%s request from %s "+
-			"userid %s failed AdminIPs check", r.URL, remoteIP, uidstr)
-	}
-
-	if !stringSliceContains(controller.Cfg.AdminUserIDs, uidstr) {
-		return false, fmt.Errorf("%s request from %s "+
-			"userid %s failed adminUserIDs check", r.URL, remoteIP, uidstr)
-	}
-
-	return true, nil
-}
-
 // StakepooldUpdateTickets attempts to trigger all connected stakepoold
 // instances to pull a data update of the specified kind.
 func (controller *MainController) StakepooldUpdateTickets(ctx context.Context, dbMap *gorp.DbMap) error {
@@ -523,15 +363,38 @@ func (controller *MainController) StakepooldUpdateTickets(ctx context.Context, d
 // StakepooldUpdateUsers attempts to trigger all connected stakepoold
 // instances to pull a data update of the specified kind.
 func (controller *MainController) StakepooldUpdateUsers(ctx context.Context, dbMap *gorp.DbMap) error {
-	// reset votebits if Vote Version changed or if the stored VoteBits are
-	// somehow invalid
-	allUsers, err := controller.CheckAndResetUserVoteBits(dbMap)
+	users, err := models.GetUserVotingPrefs(dbMap)
+	if err != nil {
+		return err
+	}
+	// Users who were not migrated to the current vote version yet by
+	// MigrateUserVoteBits, or whose stored VoteBits are somehow invalid, vote
+	// with the VoteBits they will be migrated to.  Choices forced by the
+	// operator override those of the users, also while voting is frozen.
+	allUsers := make(map[int64]*models.User, len(users))
+	for i := range users {
+		user := &users[i]
+		voteBits, _ := controller.currentVoteBits(user)
+		user.VoteBits = int64(controller.forceChoices(voteBits))
+		user.VoteBitsVersion = int64(controller.voteVersion)
+		allUsers[user.ID] = user
+	}
+
+	// override the preferences of all users while voting is frozen
+	freeze, err := models.GetVotingFreeze(dbMap)
 	if err != nil {
 		return err
 	}
+	if freeze != nil && freeze.Frozen != 0 {
+		log.Warnf("VOTING IS FROZEN: all %d users vote with votebits %d "+
+			"since %v: %s", len(allUsers), freeze.VoteBits,
+			time.Unix(freeze.Created, 0), freeze.Reason)
+		freeze.VoteBits = int64(controller.forceChoices(uint16(freeze.VoteBits)))
+		applyVotingFreeze(freeze, allUsers)
+	}
 
 	err = controller.Cfg.StakepooldServers.SetUserVotingPrefs(ctx, allUsers)
-	if err != nil {
+	if err != nil && !writeApplied(err) {
 		log.Errorf("error updating users on stakepoold: %v", err)
 		return err
 	}
@@ -539,59 +402,112 @@ func (controller *MainController) StakepooldUpdateUsers(ctx context.Context, dbM
 	return nil
 }
 
-// FeeAddressForUserID generates a unique payout address per used ID for
-// fees for an individual pool user.
-func (controller *MainController) FeeAddressForUserID(uid int) (dcrutil.Address,
-	error) {
-	if uid+1 > MaxUsers {
-		return nil, fmt.Errorf("bad uid index %v", uid)
+// writeApplied returns whether err is a *manager.PartialWriteError of a write
+// which succeeded on some stakepoold instances, or was only held back from
+// those whose wallet is rescanning.  Such a write is retried on the others in
+// the background, so the failure was already logged and need not be reported
+// to the user.
+func writeApplied(err error) bool {
+	var perr *manager.PartialWriteError
+	if !errors.As(err, &perr) {
+		return false
 	}
-
-	acctKey := controller.Cfg.FeeXpub
-	index := uint32(uid)
-
-	// Derive the appropriate branch key
-	branchKey, err := acctKey.Child(helpers.ExternalBranch)
-	if err != nil {
-		return nil, err
+	if perr.Applied > 0 {
+		return true
 	}
-
-	key, err := branchKey.Child(index)
-	if err != nil {
-		return nil, err
+	for _, err := range perr.Failed {
+		if !errors.Is(err, manager.ErrWalletRescanning) {
+			return false
+		}
 	}
+	return len(perr.Failed) > 0
+}
 
-	return helpers.DCRUtilAddressFromExtendedKey(key, controller.Cfg.NetParams)
+// FeeAddressForUserID generates a unique payout address per used ID for
+// fees for an individual pool user.
+func (controller *MainController) FeeAddressForUserID(dbMap *gorp.DbMap,
+	uid int) (dcrutil.Address, error) {
+	return controller.addressForUserID(dbMap, models.AddressAccountFee,
+		controller.Cfg.FeeXpub, helpers.ExternalBranch, uid)
 }
 
 // TicketAddressForUserID generates a unique ticket address per used ID for
-// generating the 1-of-2 multisig.
-func (controller *MainController) TicketAddressForUserID(uid int) (dcrutil.Address,
-	error) {
-	if uid+1 > MaxUsers {
-		return nil, fmt.Errorf("bad uid index %v", uid)
+// generating the 1-of-2 multisig, from the configured branch of the voting
+// account.
+func (controller *MainController) TicketAddressForUserID(dbMap *gorp.DbMap,
+	uid int) (dcrutil.Address, error) {
+	account := models.AddressAccountVoting
+	if controller.Cfg.VotingBranch == helpers.InternalBranch {
+		account = models.AddressAccountVotingInternal
+	}
+	return controller.addressForUserID(dbMap, account,
+		controller.Cfg.VotingXpub, controller.Cfg.VotingBranch, uid)
+}
+
+// addressForUserID derives the address of a user from branch of the account
+// key acctKey.  Like the fee addresses stakepoold derives, the
+// address of the user with uid is derived from the uid-th valid child, which
+// is the child at index uid unless an invalid child below it was skipped.
+// The child index of every derived address is recorded, so that the valid
+// children only need to be counted from the closest user below.
+func (controller *MainController) addressForUserID(dbMap *gorp.DbMap,
+	account string, acctKey *hdkeychain.ExtendedKey, branch uint32,
+	uid int) (dcrutil.Address, error) {
+	addr, index, recorded, err := controller.deriveAddressForUserID(dbMap,
+		account, acctKey, branch, uid)
+	if err != nil || recorded {
+		return addr, err
+	}
+
+	if index != uint32(uid) {
+		log.Warnf("The %s address of user %d is derived from child %d "+
+			"after skipping invalid children", account, uid, index)
+	}
+	err = models.InsertAddressIndex(dbMap, &models.AddressIndex{
+		Account:    account,
+		UserID:     int64(uid),
+		ChildIndex: int64(index),
+	})
+	if err != nil {
+		// The address is the same when derived again, so only the
+		// shortcut for the next derivations is lost.
+		log.Warnf("unable to record the %s address index of user %d: %v",
+			account, uid, err)
 	}
+	return addr, nil
+}
 
-	acctKey := controller.Cfg.VotingXpub
-	index := uint32(uid)
+// deriveAddressForUserID derives the address of a user like addressForUserID
+// without recording its child index.  It returns the index of the child the
+// address was derived from and whether the index was already recorded.
+func (controller *MainController) deriveAddressForUserID(dbMap *gorp.DbMap,
+	account string, acctKey *hdkeychain.ExtendedKey, branch uint32,
+	uid int) (dcrutil.Address, uint32, bool, error) {
+	if uid < 0 || uid+1 > MaxUsers {
+		return nil, 0, false, fmt.Errorf("bad uid index %v", uid)
+	}
 
 	// Derive the appropriate branch key
-	branchKey, err := acctKey.Child(helpers.ExternalBranch)
+	branchKey, err := acctKey.Child(branch)
 	if err != nil {
-		return nil, err
+		return nil, 0, false, err
 	}
 
-	key, err := branchKey.Child(index)
+	closest, err := models.GetClosestAddressIndex(dbMap, account, int64(uid))
 	if err != nil {
-		return nil, err
+		return nil, 0, false, err
 	}
-
-	addr, err := helpers.DCRUtilAddressFromExtendedKey(key, controller.Cfg.NetParams)
+	var startPosition, start uint32
+	if closest != nil {
+		startPosition = uint32(closest.UserID)
+		start = uint32(closest.ChildIndex)
+	}
+	addr, index, err := helpers.ChildAddressAtPosition(branchKey, uint32(uid),
+		startPosition, start, controller.Cfg.NetParams)
 	if err != nil {
-		return nil, err
+		return nil, 0, false, err
 	}
-
-	return addr, nil
+	return addr, index, closest != nil && closest.UserID == int64(uid), nil
 }
 
 // RPCSync checks to ensure that the wallets are synced on startup.
@@ -601,75 +517,11 @@ func (controller *MainController) RPCSync(ctx context.Context, dbMap *gorp.DbMap
 		return err
 	}
 
-	err = controller.Cfg.StakepooldServers.SyncAll(ctx, multisigScripts, MaxUsers)
+	err = controller.Cfg.StakepooldServers.SyncAll(ctx, multisigScripts,
+		controller.Cfg.VotingAccount, controller.Cfg.VotingBranch, MaxUsers)
 	return err
 }
 
-// CheckAndResetUserVoteBits reset users VoteBits if the VoteVersion has
-// changed or if the stored VoteBits are somehow invalid.
-func (controller *MainController) CheckAndResetUserVoteBits(dbMap *gorp.DbMap) (map[int64]*models.User, error) {
-	defaultVoteBits := uint16(1)
-	userMax := models.GetUserMax(dbMap)
-	for userid := int64(1); userid <= userMax; userid++ {
-		// may have gaps due to users deleted from the database
-		user, err := models.GetUserByID(dbMap, userid)
-		if err != nil {
-			continue
-		}
-
-		// Reset the user's voting preferences if the Vote Version changed
-		// since they no longer apply
-		if uint32(user.VoteBitsVersion) != controller.voteVersion {
-			oldVoteBitsVersion := user.VoteBitsVersion
-			_, err := helpers.UpdateVoteBitsVersionByID(dbMap, userid, controller.voteVersion)
-			if err != nil {
-				return nil, fmt.Errorf("failed to update VoteBitsVersion for uid %v: %v",
-					userid, err)
-			}
-
-			log.Infof("updated VoteBitsVersion from %v to %v for uid %v",
-				oldVoteBitsVersion, controller.voteVersion, userid)
-
-			if uint16(user.VoteBits) != defaultVoteBits {
-				oldVoteBits := user.VoteBits
-				_, err = helpers.UpdateVoteBitsByID(dbMap, userid, defaultVoteBits)
-				if err != nil {
-					return nil, fmt.Errorf("failed to update VoteBits for uid %v: %v",
-						userid, err)
-				}
-
-				log.Infof("updated VoteBits from %v to %v for uid %v",
-					oldVoteBits, defaultVoteBits, userid)
-			}
-		} else if !controller.IsValidVoteBits(uint16(user.VoteBits)) {
-			// Validate that the votebits are valid for the agendas of the current
-			// vote version
-			oldVoteBits := user.VoteBits
-			_, err := helpers.UpdateVoteBitsByID(dbMap, userid, defaultVoteBits)
-			if err != nil {
-				return nil, fmt.Errorf("failed to reset invalid VoteBits for uid %v: %v",
-					userid, err)
-			}
-
-			log.Infof("reset invalid VoteBits from %v to %v for uid %v",
-				oldVoteBits, defaultVoteBits, userid)
-		}
-	}
-
-	allUsers := make(map[int64]*models.User)
-	for userid := int64(1); userid <= userMax; userid++ {
-		// may have gaps due to users deleted from the database
-		user, err := models.GetUserByID(dbMap, userid)
-		if err != nil || user.MultiSigAddress == "" {
-			continue
-		}
-
-		allUsers[user.ID] = user
-	}
-
-	return allUsers, nil
-}
-
 // Address renders the address page.
 func (controller *MainController) Address(c web.C, r *http.Request) (string, int) {
 	t := controller.GetTemplate(c)
@@ -677,26 +529,67 @@ func (controller *MainController) Address(c web.C, r *http.Request) (string, int
 	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
 	dbMap := controller.GetDbMap(c)
 
-	if session.Values["UserId"] == nil {
+	user := controller.GetUser(c)
+	if user == nil {
 		return "/", http.StatusSeeOther
 	}
 
 	c.Env["Admin"], _ = controller.isAdmin(c, r)
 	c.Env["IsAddress"] = true
 	c.Env["PoolFees"] = controller.Cfg.PoolFees
+	c.Env["DeferredFees"] = controller.Cfg.FeeMode == models.FeeModeDeferred
+	c.Env["MaxUserLiveTickets"] = controller.Cfg.MaxUserLiveTickets
 	c.Env["Network"] = controller.getNetworkName()
 
 	c.Env["Flash"] = session.Flashes("address")
-	user, _ := models.GetUserByID(dbMap, session.Values["UserId"].(int64))
 
-	// Generate an API Token for the user on demand if one does not exist and
-	// refresh the user's data before displaying it.
-	if user.APIToken == "" {
-		token, err := models.SetUserAPIToken(dbMap, controller.Cfg.APISecret,
+	// Show the setup of a submitted address while it runs, and offer to
+	// retry it when it did not complete.
+	if user.MultiSigAddress == "" {
+		if controller.addressJobs.isRunning(user.ID) {
+			return "/address/status", http.StatusSeeOther
+		}
+		job, err := models.GetAddressJobByUserID(dbMap, user.ID)
+		if err != nil {
+			log.Warnf("unable to get address setup of user %d: %v", user.ID, err)
+		}
+		if job != nil && job.Status != models.AddressJobComplete {
+			c.Env["AddressJob"] = job
+		}
+
+		// Offer to reactivate a script which expired unused.
+		es, err := models.GetExpiredScriptByUserID(dbMap, user.ID)
+		if err != nil {
+			log.Warnf("unable to get expired script of user %d: %v", user.ID, err)
+		}
+		if es != nil {
+			c.Env["ExpiredScript"] = es
+		}
+	} else {
+		if user.ScriptExpiryWarned != 0 {
+			c.Env["ScriptExpires"] = time.Unix(user.ScriptExpiryWarned, 0).
+				Add(controller.Cfg.ScriptExpiryGrace)
+		}
+
+		// Offer to replace the address until tickets are bought with it.
+		hasTickets, err := controller.scriptHasTickets(r.Context(), dbMap, user)
+		if err != nil {
+			log.Warnf("unable to get tickets of user %d: %v", user.ID, err)
+		}
+		c.Env["CanReplaceAddress"] = err == nil && !hasTickets
+	}
+
+	// Generate an API Token for the user on demand if one does not exist, or
+	// if the existing one is a legacy token, was signed with a retired secret
+	// or is close to expiry, and refresh the user's data before displaying it.
+	if user.APIToken == "" || controller.Cfg.APIKeys.NeedsRenewal(user.APIToken) {
+		token, err := models.SetUserAPIToken(dbMap, controller.Cfg.APIKeys,
 			controller.Cfg.BaseURL, user.ID)
 		if err != nil {
 			session.AddFlash("Unable to set API Token", "settingsError")
 			log.Errorf("could not set API Token for UserId %v", user.ID)
+		} else {
+			controller.recordUserActivity(c, r, user.ID, activityAPIToken, "")
 		}
 
 		c.Env["APIToken"] = token
@@ -714,463 +607,171 @@ func (controller *MainController) Address(c web.C, r *http.Request) (string, int
 	return controller.Parse(t, "main", c.Env), http.StatusOK
 }
 
+// addressReuse checks whether the pubkey address submitted by the user with
+// id was already submitted by another account or has been used on the
+// blockchain, which indicates that it is not a fresh voting key from the
+// user's wallet.  A description of the reuse is returned, or an empty string
+// when the address appears to be unused.
+func (controller *MainController) addressReuse(ctx context.Context, dbMap *gorp.DbMap, id int64, userPubKeyAddr dcrutil.Address) (string, error) {
+	others, err := models.GetUserCountByUserPubKeyAddr(dbMap, userPubKeyAddr.Address(), id)
+	if err != nil {
+		return "", err
+	}
+	if others > 0 {
+		return "Address was already submitted by another account", nil
+	}
+
+	used, err := controller.Cfg.StakepooldServers.ExistsAddress(ctx, userPubKeyAddr)
+	if err != nil {
+		return "", err
+	}
+	if used {
+		return "Address has already been used on the blockchain", nil
+	}
+
+	return "", nil
+}
+
+// addressNetworks are the networks an address failing to decode on the network
+// of the voting service is tried on, to tell users who submitted an address of
+// the wrong network.
+var addressNetworks = []*chaincfg.Params{netparams.MainNet.Params,
+	netparams.TestNet3.Params, netparams.SimNet.Params}
+
+// validateUserPubKeyAddr decodes the public key address submitted by a user on
+// the network of params.  Its errors are shown to users and carry the API
+// error code of the check which failed.
 func validateUserPubKeyAddr(pubKeyAddr string, params *chaincfg.Params) (dcrutil.Address, error) {
 	if len(pubKeyAddr) < 40 {
 		str := "Address is too short"
 		log.Warnf("User submitted invalid address: %s - %s", pubKeyAddr, str)
-		return nil, errors.New(str)
+		return nil, newAPIError(poolapi.ErrAddressLength, "UserPubKeyAddr", str)
 	}
 
 	if len(pubKeyAddr) > 65 {
 		str := "Address is too long"
 		log.Warnf("User submitted invalid address: %s - %s", pubKeyAddr, str)
-		return nil, errors.New(str)
+		return nil, newAPIError(poolapi.ErrAddressLength, "UserPubKeyAddr", str)
 	}
 
 	u, err := dcrutil.DecodeAddress(pubKeyAddr, params)
 	if err != nil {
 		log.Warnf("User submitted invalid address: %s - %v", pubKeyAddr, err)
-		return nil, errors.New("Couldn't decode address")
+		for _, net := range addressNetworks {
+			if net.Net == params.Net {
+				continue
+			}
+			if _, err := dcrutil.DecodeAddress(pubKeyAddr, net); err == nil {
+				return nil, newAPIError(poolapi.ErrAddressNetwork,
+					"UserPubKeyAddr", fmt.Sprintf("Address is for %s, "+
+						"not %s", net.Name, params.Name))
+			}
+		}
+		return nil, newAPIError(poolapi.ErrAddressEncoding, "UserPubKeyAddr",
+			"Couldn't decode address")
 	}
 
 	_, is := u.(*dcrutil.AddressSecpPubKey)
 	if !is {
 		str := "Incorrect address type"
 		log.Warnf("User submitted invalid address: %s - %s", pubKeyAddr, str)
-		return nil, errors.New(str)
+		return nil, newAPIError(poolapi.ErrAddressType, "UserPubKeyAddr", str)
 	}
 
 	return u, nil
 }
 
+// setupUserAddress sets up the multisig script of the address of a user while
+// the request waits, and returns a message for the user when it fails.
+func (controller *MainController) setupUserAddress(ctx context.Context, dbMap *gorp.DbMap, uid64 int64, userPubKeyAddr string) string {
+	job := &models.AddressJob{
+		UserID:         uid64,
+		UserPubKeyAddr: userPubKeyAddr,
+	}
+	controller.runAddressJob(ctx, dbMap, job, func(*models.AddressJob) {})
+	return job.Error
+}
+
 // AddressPost is address form submit route.
 func (controller *MainController) AddressPost(c web.C, r *http.Request) (string, int) {
 	session := controller.GetSession(c)
 	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
 	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
 
-	if session.Values["UserId"] == nil {
+	user := controller.GetUser(c)
+	if user == nil {
 		return "/", http.StatusSeeOther
 	}
-	uid64 := session.Values["UserId"].(int64)
+	uid64 := user.ID
 
 	// Only accept address if user does not already have a PubKeyAddr set.
 	dbMap := controller.GetDbMap(c)
-	user, _ := models.GetUserByID(dbMap, session.Values["UserId"].(int64))
 	if len(user.UserPubKeyAddr) > 0 {
 		session.AddFlash("The voting service is currently limited to one address per account", "address")
 		return controller.Address(c, r)
 	}
-
-	userPubKeyAddr := r.FormValue("UserPubKeyAddr")
-
-	log.Infof("Address POST from %v, pubkeyaddr %v", remoteIP, userPubKeyAddr)
-
-	if _, err := validateUserPubKeyAddr(userPubKeyAddr, controller.Cfg.NetParams); err != nil {
-		session.AddFlash(err.Error(), "address")
+	if ends, cooldown := emailCooldownEnds(user, controller.Cfg.EmailCooldown, time.Now()); cooldown {
+		session.AddFlash(emailCooldownMessage(ends), "address")
 		return controller.Address(c, r)
 	}
-
-	// Get the ticket address for this user
-	pooladdress, err := controller.TicketAddressForUserID(int(uid64))
-	if err != nil {
-		log.Errorf("unable to derive ticket address: %v", err)
-		session.AddFlash("Unable to derive ticket address", "address")
+	if controller.walletsSyncing() > 0 {
+		session.AddFlash(walletSyncingMessage, "address")
 		return controller.Address(c, r)
 	}
 
-	// From new address (pkh), get pubkey address
-	poolValidateAddress, err := controller.Cfg.StakepooldServers.ValidateAddress(r.Context(), pooladdress)
-	if err != nil {
-		return "/error", http.StatusSeeOther
-	}
-	if !poolValidateAddress.IsMine {
-		log.Errorf("unable to validate ismine for pool ticket address: %s",
-			pooladdress.String())
-		session.AddFlash("Unable to validate pool ticket address", "address")
-		return controller.Address(c, r)
-	}
-	poolPubKeyAddr := poolValidateAddress.PubKeyAddr
-
-	// Get back Address from pool's new pubkey address
-	if _, err = dcrutil.DecodeAddress(poolPubKeyAddr, controller.Cfg.NetParams); err != nil {
-		return "/error", http.StatusSeeOther
-	}
-
-	// Create the the multisig script. Result includes a P2SH and redeem script.
-	createMultiSig, err := controller.Cfg.StakepooldServers.CreateMultisig(r.Context(), []string{poolPubKeyAddr, userPubKeyAddr})
-	if err != nil {
-		return "/error", http.StatusSeeOther
-	}
-
-	// Serialize the redeem script (hex string -> []byte)
-	serializedScript, err := hex.DecodeString(createMultiSig.RedeemScript)
-	if err != nil {
-		return "/error", http.StatusSeeOther
-	}
+	userPubKeyAddr := r.FormValue("UserPubKeyAddr")
 
-	// Import the redeem script
-	var importedHeight int64
-	importedHeight, err = controller.Cfg.StakepooldServers.ImportNewScript(r.Context(), serializedScript)
-	if err != nil {
-		return "/error", http.StatusSeeOther
-	}
+	log.Infof("Address POST from %v, pubkeyaddr %v", remoteIP, userPubKeyAddr)
 
-	// Get the pool fees address for this user
-	userFeeAddr, err := controller.FeeAddressForUserID(int(uid64))
+	u, err := validateUserPubKeyAddr(userPubKeyAddr, controller.Cfg.NetParams)
 	if err != nil {
-		log.Errorf("unexpected error deriving fee addr: %s", err.Error())
-		session.AddFlash("Unable to derive fee address", "address")
+		session.AddFlash(err.Error(), "address")
 		return controller.Address(c, r)
 	}
 
-	// Update the user's DB entry with multisig, user and pool pubkey
-	// addresses, and the fee address
-	models.UpdateUserByID(dbMap, uid64, createMultiSig.Address,
-		createMultiSig.RedeemScript, poolPubKeyAddr, userPubKeyAddr,
-		userFeeAddr.Address(), importedHeight)
-
-	if err = controller.StakepooldUpdateUsers(r.Context(), dbMap); err != nil {
-		log.Errorf("unable to update all: %v", err)
-	}
-
-	return "/tickets", http.StatusSeeOther
-}
-
-// AdminStatus renders the status page.
-func (controller *MainController) AdminStatus(c web.C, r *http.Request) (string, int) {
-	isAdmin, err := controller.isAdmin(c, r)
-	if !isAdmin {
-		log.Warnf("isAdmin check failed: %v", err)
-		return "", http.StatusUnauthorized
-	}
-
-	backendStatus := controller.Cfg.StakepooldServers.BackendStatus(r.Context())
-
-	t := controller.GetTemplate(c)
-	c.Env["Admin"] = isAdmin
-	c.Env["IsAdminStatus"] = true
-	c.Env["Title"] = "Decred Voting Service - Status (Admin)"
-
-	// Set info to be used by admins on /status page.
-	c.Env["BackendStatus"] = backendStatus
-
-	widgets := controller.Parse(t, "admin/status", c.Env)
-	c.Env["Designation"] = controller.Cfg.Designation
-
-	c.Env["Content"] = template.HTML(widgets)
-
-	return controller.Parse(t, "main", c.Env), http.StatusOK
-}
-
-// AdminTickets renders the administrative tickets page.
-// Tickets purchased with an incorrect VSP fee will be listed on this page.
-// Admin users can choose whether the pool should vote these tickets or not.
-func (controller *MainController) AdminTickets(c web.C, r *http.Request) (string, int) {
-	t := controller.GetTemplate(c)
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	dbMap := controller.GetDbMap(c)
-
-	isAdmin, err := controller.isAdmin(c, r)
-	if !isAdmin {
-		log.Warnf("isAdmin check failed: %v", err)
-		return "", http.StatusUnauthorized
-	}
-
-	votableLowFeeTickets := make(map[chainhash.Hash]string)
-	gvlft, err := models.GetVotableLowFeeTickets(dbMap)
-	if err == nil {
-		for _, t := range gvlft {
-			th, _ := chainhash.NewHashFromStr(t.TicketHash)
-			votableLowFeeTickets[*th] = t.TicketAddress
-		}
-	}
-
-	ignoredLowFeeTickets, err := controller.Cfg.StakepooldServers.GetIgnoredLowFeeTickets(r.Context())
-	if err != nil {
-		log.Errorf("Could not retrieve ignored low fee tickets from stakepoold: %v", err)
-		session.AddFlash("Could not retrieve ignored low fee tickets from stakepoold", "adminTicketsError")
-	}
-
-	c.Env["Admin"] = isAdmin
-	c.Env["IsAdminTickets"] = true
-	c.Env["DCRDataURL"] = controller.DCRDataURL
-
-	c.Env["FlashError"] = session.Flashes("adminTicketsError")
-	c.Env["FlashSuccess"] = session.Flashes("adminTicketsSuccess")
-
-	c.Env["AddedLowFeeTickets"] = votableLowFeeTickets
-	c.Env["IgnoredLowFeeTickets"] = ignoredLowFeeTickets
-
-	widgets := controller.Parse(t, "admin/tickets", c.Env)
-
-	c.Env["Title"] = "Decred Voting Service - Tickets (Admin)"
-	c.Env["Designation"] = controller.Cfg.Designation
-
-	c.Env["Content"] = template.HTML(widgets)
-
-	return controller.Parse(t, "main", c.Env), http.StatusOK
-}
-
-// AdminTicketsPost validates and processes the form posted from AdminTickets.
-func (controller *MainController) AdminTicketsPost(c web.C, r *http.Request) (string, int) {
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	dbMap := controller.GetDbMap(c)
-	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
-
-	userID, ok := session.Values["UserId"].(int64)
-	if !ok {
-		log.Warnf("UserId not set!")
-		return "", http.StatusUnauthorized
-	}
-
-	isAdmin, err := controller.isAdmin(c, r)
-	if !isAdmin {
-		log.Warnf("isAdmin check failed: %v", err)
-		return "", http.StatusUnauthorized
-	}
-
-	if err := r.ParseForm(); err != nil {
-		session.AddFlash("unable to parse form: "+err.Error(),
-			"adminTicketsError")
-		return "/admintickets", http.StatusSeeOther
-	}
-
-	ticketList := r.PostForm["tickets[]"]
-
-	if len(ticketList) == 0 {
-		session.AddFlash("no tickets selected to modify", "adminTicketsError")
-		return "/admintickets", http.StatusSeeOther
-	}
-
-	// Validate each of the ticket hash strings.
-	ticketHashes, err := models.DecodeHashList(ticketList)
-	if err != nil {
-		session.AddFlash("Invalid ticket in form data: "+err.Error(),
-			"adminTicketsError")
-		return "/admintickets", http.StatusSeeOther
-	}
-
-	action := strings.ToLower(r.PostFormValue("action"))
-	switch action {
-	case "add", "remove":
-		// recognized action
-	default:
-		session.AddFlash("invalid or unknown form action type", "adminTicketsError")
-		return "/admintickets", http.StatusSeeOther
-	}
-
-	actionVerb := "unknown"
-	switch action {
-	case "add":
-		actionVerb = "added"
-		ignoredLowFeeTickets, err := controller.Cfg.StakepooldServers.GetIgnoredLowFeeTickets(r.Context())
-		if err != nil {
-			session.AddFlash("GetIgnoredLowFeeTickets error: "+err.Error(),
-				"adminTicketsError")
-			return "/admintickets", http.StatusSeeOther
-		}
-
-		for i, tickethash := range ticketHashes {
-			t := ticketList[i]
-
-			// TODO check if it is already present in the database
-			// and error out if so
-
-			msa, exists := ignoredLowFeeTickets[tickethash]
-			if !exists {
-				session.AddFlash("ticket " + t + " is no longer present")
-				return "/admintickets", http.StatusSeeOther
-			}
-
-			expires := controller.CalcEstimatedTicketExpiry()
-
-			lowFeeTicket := &models.LowFeeTicket{
-				AddedByUID:    userID,
-				TicketAddress: msa,
-				TicketHash:    t,
-				TicketExpiry:  0,
-				Voted:         0,
-				Created:       time.Now().Unix(),
-				Expires:       expires.Unix(),
-			}
-
-			err = models.InsertLowFeeTicket(dbMap, lowFeeTicket)
-			if err != nil {
-				session.AddFlash("Database error occurred while adding ticket "+
-					t, "adminTicketsError")
-				log.Warnf("Adding ticket %v failed: %v", tickethash, err)
-				return "/admintickets", http.StatusSeeOther
-			}
-		}
-
-	case "remove":
-		actionVerb = "removed"
-		// To use gorm's slice expansion, use a mapper with a ticketList. For
-		// three tickets in the list, gorm will expand this to:
-		//     "... IN (:Tickets0,:Tickets1,:Tickets2)".
-		// This allows each string in the list two be it's own argument.
-		query := "DELETE FROM LowFeeTicket WHERE TicketHash IN (:Tickets)"
-		ticketListMapper := map[string]interface{}{
-			"Tickets": ticketList,
-		}
-		_, err := dbMap.Exec(query, ticketListMapper)
-		if err != nil {
-			session.AddFlash("failed to execute delete query: "+err.Error(),
-				"adminTicketsError")
-			return "/admintickets", http.StatusSeeOther
-		}
-	}
-
-	err = controller.StakepooldUpdateTickets(r.Context(), dbMap)
-	if err != nil {
-		session.AddFlash("StakepooldUpdateAll error: "+err.Error(), "adminTicketsError")
-	}
-
-	log.Infof("ip %s userid %d %s for %d ticket(s)", remoteIP, userID,
-		actionVerb, len(ticketList))
-	session.AddFlash(fmt.Sprintf("Successfully %s %d ticket(s)", actionVerb,
-		len(ticketList)), "adminTicketsSuccess")
-
-	return "/admintickets", http.StatusSeeOther
-}
-
-// EmailUpdate validates the passed token and updates the user's email address.
-func (controller *MainController) EmailUpdate(c web.C, r *http.Request) (string, int) {
-	t := controller.GetTemplate(c)
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	dbMap := controller.GetDbMap(c)
-
-	render := func() string {
-		c.Env["Title"] = "Decred Voting Service - Email Update"
-		c.Env["FlashError"] = session.Flashes("emailupdateError")
-		c.Env["FlashSuccess"] = session.Flashes("emailupdateSuccess")
-		c.Env["IsEmailUpdate"] = true
-		widgets := controller.Parse(t, "emailupdate", c.Env)
-		c.Env["Designation"] = controller.Cfg.Designation
-
-		c.Env["Content"] = template.HTML(widgets)
-		return controller.Parse(t, "main", c.Env)
-	}
-
-	// Validate that the token is set.
-	tokenStr := r.URL.Query().Get("t")
-	if tokenStr == "" {
-		session.AddFlash("No email verification token present",
-			"emailupdateError")
-		return render(), http.StatusOK
-	}
-
-	// Validate that the token is valid.
-	token, err := models.UserTokenFromStr(tokenStr)
-	if err != nil {
-		session.AddFlash("Email verification token not valid.",
-			"emailupdateError")
-		return render(), http.StatusOK
-	}
-
-	// Validate that the token is recognized.
-	emailChange, err := helpers.EmailChangeTokenExists(dbMap, token)
-	if err != nil {
-		session.AddFlash("Email verification token not recognized.",
-			"emailupdateError")
-		return render(), http.StatusOK
-	}
-
-	// Validate that the token is not expired.
-	expTime := time.Unix(emailChange.Expires, 0)
-	if expTime.Before(time.Now()) {
-		session.AddFlash("Email change token has expired.",
-			"emailupdateError")
-		return render(), http.StatusOK
-	}
-
-	// possible that someone signed up with this email in the time between
-	// when the token was generated and now.
-	userExists := models.GetUserByEmail(dbMap, emailChange.NewEmail)
-	if userExists != nil {
-		session.AddFlash("Email address is in use", "emailupdateError")
-		return render(), http.StatusOK
-	}
-
-	err = helpers.EmailChangeComplete(dbMap, token)
-	if err != nil {
-		session.AddFlash("Error occurred while changing email address",
-			"emailupdateError")
-		log.Errorf("EmailUpdate: EmailChangeComplete failed %v", err)
-	} else {
-		// destroy session data and force re-login
-		userID, _ := session.Values["UserId"].(int64)
-		session.Options.MaxAge = -1
-		if err := system.DestroySessionsForUserID(dbMap, userID); err != nil {
-			log.Warnf("EmailUpdate: DestroySessionsForUserID '%v' failed: %v",
-				userID, err)
-		}
-
-		session.AddFlash("Email successfully updated",
-			"emailupdateSuccess")
-	}
-	return render(), http.StatusOK
-}
-
-// EmailVerify renders the email verification page.
-func (controller *MainController) EmailVerify(c web.C, r *http.Request) (string, int) {
-	t := controller.GetTemplate(c)
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	dbMap := controller.GetDbMap(c)
-
-	render := func() string {
-		c.Env["Title"] = "Decred Voting Service - Email Verification"
-		c.Env["FlashError"] = session.Flashes("emailverifyError")
-		c.Env["FlashSuccess"] = session.Flashes("emailverifySuccess")
-		c.Env["IsEmailVerify"] = true
-		widgets := controller.Parse(t, "emailverify", c.Env)
-		c.Env["Designation"] = controller.Cfg.Designation
-
-		c.Env["Content"] = template.HTML(widgets)
-		return controller.Parse(t, "main", c.Env)
-	}
-
-	// Validate that the token is set.
-	tokenStr := r.URL.Query().Get("t")
-	if tokenStr == "" {
-		session.AddFlash("No email verification token present.",
-			"emailverifyError")
-		return render(), http.StatusOK
-	}
-
-	// Validate that the token is valid.
-	token, err := models.UserTokenFromStr(tokenStr)
+	reuse, err := controller.addressReuse(r.Context(), dbMap, uid64, u)
 	if err != nil {
-		session.AddFlash("Email verification token not valid.",
-			"emailverifyError")
-		return render(), http.StatusOK
+		log.Warnf("unable to check reuse of address %s: %v", userPubKeyAddr, err)
+	}
+	if reuse != "" {
+		log.Warnf("User %d submitted reused address %s: %s", uid64,
+			userPubKeyAddr, reuse)
+		if controller.Cfg.RejectReusedAddrs {
+			session.AddFlash(reuse+". Please generate a new address "+
+				"in the wallet you will purchase tickets with.", "address")
+			return controller.Address(c, r)
+		}
 	}
 
-	// Validate that the token is recognized.
-	_, err = helpers.EmailVerificationTokenExists(dbMap, token)
-	if err != nil {
-		session.AddFlash("Email verification token not recognized.",
-			"emailverifyError")
-		return render(), http.StatusOK
+	// The script is created and imported into all wallets in the
+	// background, and the progress is shown on the status page.
+	if controller.addressJobs.isRunning(uid64) {
+		return "/address/status", http.StatusSeeOther
+	}
+	now := time.Now().Unix()
+	job := &models.AddressJob{
+		UserID:         uid64,
+		UserPubKeyAddr: userPubKeyAddr,
+		Status:         models.AddressJobPending,
+		Created:        now,
+		Updated:        now,
+	}
+	if err = models.InsertAddressJob(dbMap, job); err != nil {
+		log.Errorf("unable to record address setup of user %d: %v", uid64, err)
+		session.AddFlash("Unable to set up the address, please try again",
+			"address")
+		return controller.Address(c, r)
 	}
+	controller.startAddressJob(dbMap, job)
+	controller.recordUserActivity(c, r, uid64, activityAddress, userPubKeyAddr)
 
-	// Set the email as verified.
-	err = helpers.EmailVerificationComplete(dbMap, token)
-	if err != nil {
-		session.AddFlash("Unable to set email to verified status.",
-			"emailverifyError")
-		log.Errorf("could not set email to verified %v", err)
-		return render(), http.StatusInternalServerError
+	if reuse != "" {
+		session.AddFlash("Warning: "+reuse+". Please check that it was "+
+			"generated by the wallet you will purchase tickets with.",
+			"address")
 	}
 
-	session.AddFlash("Email successfully verified.",
-		"emailverifySuccess")
-	return render(), http.StatusOK
+	return "/address/status", http.StatusSeeOther
 }
 
 // Error renders the error page.
@@ -1234,189 +835,13 @@ func (controller *MainController) Index(c web.C, r *http.Request) (string, int)
 	return doc, http.StatusOK
 }
 
-// PasswordReset renders the password reset page. This shows the form where the
-// user enters their email address.
-func (controller *MainController) PasswordReset(c web.C, r *http.Request) (string, int) {
-	c.Env["Title"] = "Decred Voting Service - Password Reset"
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	c.Env["FlashError"] = session.Flashes("passwordresetError")
-	c.Env["FlashSuccess"] = session.Flashes("passwordresetSuccess")
-	c.Env["IsPasswordReset"] = true
-	c.Env["CaptchaID"] = captcha.New()
-	c.Env["CaptchaMsg"] = "To reset your password, first complete the captcha:"
-	c.Env["CaptchaError"] = session.Flashes("captchaFailed")
-
-	t := controller.GetTemplate(c)
-	widgets := controller.Parse(t, "passwordreset", c.Env)
-	c.Env["Designation"] = controller.Cfg.Designation
-
-	c.Env["Content"] = template.HTML(widgets)
-
-	return controller.Parse(t, "main", c.Env), http.StatusOK
-}
-
-// PasswordResetPost handles the posted password reset form. This submits the
-// data entered into the email address form. If the email is recognized a
-// password reset token is generated and the user will check their email for a
-// link. The link will take them to the password update page with a token
-// specified on the URL.
-func (controller *MainController) PasswordResetPost(c web.C, r *http.Request) (string, int) {
-	email := r.FormValue("email")
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	dbMap := controller.GetDbMap(c)
-
-	if !controller.IsCaptchaDone(c) {
-		session.AddFlash("You must complete the captcha.", "passwordresetError")
-		return controller.PasswordReset(c, r)
-	}
-	session.Values["CaptchaDone"] = false
-	c.Env["CaptchaDone"] = false
-
-	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
-	user, err := helpers.EmailExists(dbMap, email)
-	if err == nil {
-		log.Infof("PasswordReset POST from %v, email %v", remoteIP,
-			user.Email)
-
-		t := time.Now()
-		expires := t.Add(time.Hour * 1)
-
-		token := models.NewUserToken()
-		passReset := &models.PasswordReset{
-			UserID:  user.ID,
-			Token:   token.String(),
-			Created: t.Unix(),
-			Expires: expires.Unix(),
-		}
-
-		if err := models.InsertPasswordReset(dbMap, passReset); err != nil {
-			session.AddFlash("Unable to add reset token to database", "passwordresetError")
-			log.Errorf("Unable to add reset token to database: %v", err)
-			return controller.PasswordReset(c, r)
-		}
-
-		err := controller.Cfg.EmailSender.PasswordChangeRequest(user.Email, remoteIP, controller.Cfg.BaseURL, token.String())
-		if err != nil {
-			session.AddFlash("Unable to send password reset email", "passwordresetError")
-			log.Errorf("error sending password reset email %v", err)
-			return controller.PasswordReset(c, r)
-		}
-	} else {
-		log.Infof("request to reset non-existent account %v from IP %v",
-			email, remoteIP)
-	}
-
-	session.AddFlash("An email containing password reset instructions has "+
-		"been sent to "+email+" if it was a registered account here.",
-		"passwordresetSuccess")
-
-	return controller.PasswordReset(c, r)
-}
-
-// PasswordUpdate renders the password update page. When a user clicks the link
-// containing a token in the password reset email, this handler will validate
-// the token. When the token is valid, the user will be presented with forms to
-// enter their new password. PasswordUpdatePost handles the submission of these
-// forms, and calls PasswordUpdate again for page rendering.
-func (controller *MainController) PasswordUpdate(c web.C, r *http.Request) (string, int) {
-	t := controller.GetTemplate(c)
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-
-	render := func() string {
-		c.Env["Title"] = "Decred Voting Service - Password Update"
-		c.Env["FlashError"] = session.Flashes("passwordupdateError")
-		c.Env["FlashSuccess"] = session.Flashes("passwordupdateSuccess")
-		c.Env["IsPasswordUpdate"] = true
-		widgets := controller.Parse(t, "passwordupdate", c.Env)
-		c.Env["Designation"] = controller.Cfg.Designation
-
-		c.Env["Content"] = template.HTML(widgets)
-		return controller.Parse(t, "main", c.Env)
-	}
-
-	// Just render the page if the POST handler already checked the token.
-	_, tokenChecked := c.Env["TokenValid"].(bool)
-	if tokenChecked {
-		return render(), http.StatusOK
-	}
-
-	// Use CheckPasswordResetToken to set relevant flash messages.
-	controller.CheckPasswordResetToken(r.URL.Query().Get("t"), c)
-	return render(), http.StatusOK
-}
-
-// PasswordUpdatePost handles updating passwords. The token in the URL is from
-// the password reset email. The token is validated and the password is changed.
-func (controller *MainController) PasswordUpdatePost(c web.C, r *http.Request) (string, int) {
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	dbMap := controller.GetDbMap(c)
-	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
-
-	// Ensure a valid password reset token is provided. If the token is valid,
-	// return the decoded UserToken and PasswordReset data for the token.
-	token, resetData, tokenOK := controller.CheckPasswordResetToken(
-		r.URL.Query().Get("t"), c)
-	c.Env["TokenValid"] = tokenOK
-	// tokenChecked will be true regardless of token validity.
-	if !tokenOK {
-		return controller.PasswordUpdate(c, r)
-	}
-
-	// Given a valid password reset token, process the password change.
-	password := r.FormValue("password")
-	if password == "" {
-		session.AddFlash("Password cannot be empty.", "passwordupdateError")
-		return controller.PasswordUpdate(c, r)
-	}
-	passwordRepeat := r.FormValue("passwordrepeat")
-	if password != passwordRepeat {
-		session.AddFlash("Passwords do not match.", "passwordupdateError")
-		return controller.PasswordUpdate(c, r)
-	}
-
-	user, err := helpers.UserIDExists(dbMap, resetData.UserID)
-	if err != nil {
-		log.Infof("UserIDExists failure %v, %v", err, remoteIP)
-		session.AddFlash("Unable to find User ID.", "passwordupdateError")
-		return controller.PasswordUpdate(c, r)
-	}
-
-	log.Infof("PasswordUpdate POST from %v, email %v", remoteIP,
-		user.Email)
-
-	user.HashPassword(password)
-	_, err = helpers.UpdateUserPasswordByID(dbMap, resetData.UserID,
-		user.Password)
-	if err != nil {
-		log.Errorf("error updating password %v", err)
-		session.AddFlash("Unable to update password.", "passwordupdateError")
-		return controller.PasswordUpdate(c, r)
-	}
-
-	err = helpers.PasswordResetTokenDelete(dbMap, token)
-	if err != nil {
-		log.Errorf("error deleting token %v", err)
-	}
-
-	// destroy session data
-	if err := system.DestroySessionsForUserID(dbMap, user.ID); err != nil {
-		log.Warnf("PasswordUpdatePost: DestroySessionsForUserID '%v' failed: %v",
-			user.ID, err)
-	}
-	session.AddFlash("Password successfully updated", "passwordupdateSuccess")
-	return controller.PasswordUpdate(c, r)
-}
-
 // Settings renders the settings page.
 func (controller *MainController) Settings(c web.C, r *http.Request) (string, int) {
 	session := controller.GetSession(c)
 	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
 
-	if session.Values["UserId"] == nil {
+	user := controller.GetUser(c)
+	if user == nil {
 		return "/", http.StatusSeeOther
 	}
 
@@ -1424,9 +849,23 @@ func (controller *MainController) Settings(c web.C, r *http.Request) (string, in
 	c.Env["FlashError"] = session.Flashes("settingsError")
 	c.Env["FlashSuccess"] = session.Flashes("settingsSuccess")
 	c.Env["IsSettings"] = true
-	c.Env["CaptchaID"] = captcha.New()
-	c.Env["CaptchaMsg"] = "To change your email address, first complete the captcha:"
-	c.Env["CaptchaError"] = session.Flashes("captchaFailed")
+	controller.setCaptchaEnv(c, captchaSettings,
+		"To change your email address, first complete the captcha:")
+
+	// The tokens are read again since SettingsPost renders the page after
+	// changing them.
+	user, err := models.GetUserByID(controller.GetDbMap(c), user.ID)
+	if err != nil {
+		log.Errorf("Settings: GetUserByID failed: %v", err)
+	} else {
+		c.Env["ReadOnlyAPIToken"] = user.ReadOnlyAPIToken
+		c.Env["BadgeToken"] = user.BadgeToken
+		controller.setActivityEnv(c, controller.GetReadDbMap(c), user)
+		if controller.webhooksEnabled(c) {
+			controller.setWebhookEnv(c, user.ID)
+		}
+	}
+	c.Env["BaseURL"] = controller.Cfg.BaseURL
 
 	t := controller.GetTemplate(c)
 	widgets := controller.Parse(t, "settings", c.Env)
@@ -1438,31 +877,103 @@ func (controller *MainController) Settings(c web.C, r *http.Request) (string, in
 	return controller.Parse(t, "main", c.Env), http.StatusOK
 }
 
-// SettingsPost handles changing the user's email address or password.
+// SettingsPost handles changing the user's email address or password,
+// generating or revoking the user's read-only API token or status badge, and
+// registering or removing the user's webhook.
 func (controller *MainController) SettingsPost(c web.C, r *http.Request) (string, int) {
 	session := controller.GetSession(c)
 	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
 	dbMap := controller.GetDbMap(c)
 	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
 
-	if session.Values["UserId"] == nil {
+	user := controller.GetUser(c)
+	if user == nil {
 		return "/", http.StatusSeeOther
 	}
 
+	// A read-only token cannot be used to change the account, so managing
+	// it does not require the password.  It may not be changed right after
+	// an email change though.
+	userID := user.ID
+	if r.FormValue("readOnlyToken") != "" {
+		ends, cooldown := emailCooldownEnds(user, controller.Cfg.EmailCooldown,
+			time.Now())
+		if cooldown {
+			session.AddFlash(emailCooldownMessage(ends), "settingsError")
+			return controller.Settings(c, r)
+		}
+	}
+	switch r.FormValue("readOnlyToken") {
+	case "generate":
+		_, err := models.SetUserReadOnlyAPIToken(dbMap, controller.Cfg.APIKeys,
+			controller.Cfg.BaseURL, userID)
+		if err != nil {
+			log.Errorf("could not set read-only API Token for UserId %v: %v",
+				userID, err)
+			session.AddFlash("Unable to generate read-only API Token", "settingsError")
+		} else {
+			controller.recordUserActivity(c, r, userID, activityReadOnlyToken, "")
+			session.AddFlash("Read-only API Token generated. Any previous "+
+				"read-only token no longer works.", "settingsSuccess")
+		}
+		return controller.Settings(c, r)
+	case "revoke":
+		if err := models.RevokeUserReadOnlyAPIToken(dbMap, userID); err != nil {
+			log.Errorf("could not revoke read-only API Token for UserId %v: %v",
+				userID, err)
+			session.AddFlash("Unable to revoke read-only API Token", "settingsError")
+		} else {
+			controller.recordUserActivity(c, r, userID, activityReadOnlyRevoke, "")
+			session.AddFlash("Read-only API Token revoked", "settingsSuccess")
+		}
+		return controller.Settings(c, r)
+	}
+
+	// Likewise for the token of the status badge, which only shows ticket
+	// counts.
+	switch r.FormValue("badgeToken") {
+	case "generate":
+		if _, err := models.SetUserBadgeToken(dbMap, userID); err != nil {
+			log.Errorf("could not set badge token for UserId %v: %v",
+				userID, err)
+			session.AddFlash("Unable to generate status badge", "settingsError")
+		} else {
+			session.AddFlash("Status badge generated. Any previous badge URL "+
+				"no longer works.", "settingsSuccess")
+		}
+		return controller.Settings(c, r)
+	case "revoke":
+		if err := models.RevokeUserBadgeToken(dbMap, userID); err != nil {
+			log.Errorf("could not revoke badge token for UserId %v: %v",
+				userID, err)
+			session.AddFlash("Unable to revoke status badge", "settingsError")
+		} else {
+			session.AddFlash("Status badge revoked", "settingsSuccess")
+		}
+		return controller.Settings(c, r)
+	}
+
+	// Likewise for the webhook, which only reports the states of tickets.
+	if r.FormValue("webhook") != "" {
+		if !controller.webhooksEnabled(c) {
+			return "/settings", http.StatusSeeOther
+		}
+		controller.webhookPost(c, r, userID)
+		return controller.Settings(c, r)
+	}
+
 	password, updateEmail, updatePassword := r.FormValue("password"),
 		r.FormValue("updateEmail"), r.FormValue("updatePassword")
 
 	if updateEmail == "true" {
-		if !controller.IsCaptchaDone(c) {
+		if !controller.consumeCaptcha(c, captchaSettings) {
 			session.AddFlash("You must complete the captcha.", "settingsError")
 			return controller.Settings(c, r)
 		}
-		session.Values["CaptchaDone"] = false
-		c.Env["CaptchaDone"] = false
 	}
 
 	// Changes to email or password require the current password.
-	user, err := helpers.PasswordValidByID(dbMap, session.Values["UserId"].(int64), password)
+	user, err := helpers.PasswordValidByID(dbMap, userID, password)
 	if err != nil {
 		session.AddFlash("Password not valid", "settingsError")
 		return controller.Settings(c, r)
@@ -1491,6 +1002,11 @@ func (controller *MainController) SettingsPost(c web.C, r *http.Request) (string
 			Created:  t.Unix(),
 			Expires:  expires.Unix(),
 		}
+		var oldToken models.UserToken
+		if controller.Cfg.EmailConfirmOld {
+			oldToken = models.NewUserToken()
+			emailChange.OldToken = oldToken.String()
+		}
 
 		if err := models.InsertEmailChange(dbMap, emailChange); err != nil {
 			session.AddFlash("Unable to add email change token to database", "settingsError")
@@ -1509,8 +1025,20 @@ func (controller *MainController) SettingsPost(c web.C, r *http.Request) (string
 				"settingsSuccess")
 		}
 
-		err = controller.Cfg.EmailSender.EmailChangeNotification(controller.Cfg.BaseURL, user.Email, newEmail, remoteIP)
-		// inform the user.
+		controller.auditUserAction(c, r, user.ID, "request email change",
+			user.Email, newEmail)
+
+		// inform the user, asking to confirm the change if required.
+		if controller.Cfg.EmailConfirmOld {
+			err = controller.Cfg.EmailSender.EmailChangeConfirmation(controller.Cfg.BaseURL,
+				user.Email, newEmail, remoteIP, oldToken.String())
+			if err == nil {
+				session.AddFlash("The change must also be confirmed from "+
+					"your current email address", "settingsSuccess")
+			}
+		} else {
+			err = controller.Cfg.EmailSender.EmailChangeNotification(controller.Cfg.BaseURL, user.Email, newEmail, remoteIP)
+		}
 		if err != nil {
 			log.Errorf("error sending email change token to old address %v %v",
 				user.Email, err)
@@ -1534,11 +1062,17 @@ func (controller *MainController) SettingsPost(c web.C, r *http.Request) (string
 			return controller.Settings(c, r)
 		}
 
+		controller.recordUserActivity(c, r, user.ID, activityPasswordChange, "")
+
 		// destroy session data
 		err := system.DestroySessionsForUserID(dbMap, user.ID)
 		if err != nil {
 			log.Warnf("SettingsPost: DestroySessionsForUserID '%v' failed: %v", user.ID, err)
 		}
+		err = models.RevokeUserAPIRefreshTokens(dbMap, user.ID)
+		if err != nil {
+			log.Warnf("SettingsPost: RevokeUserAPIRefreshTokens '%v' failed: %v", user.ID, err)
+		}
 
 		// send a confirmation email.
 		err = controller.Cfg.EmailSender.PasswordChangeConfirm(user.Email, controller.Cfg.BaseURL, remoteIP)
@@ -1555,170 +1089,99 @@ func (controller *MainController) SettingsPost(c web.C, r *http.Request) (string
 	return controller.Settings(c, r)
 }
 
-// Login renders the login page.
-func (controller *MainController) Login(c web.C, r *http.Request) (string, int) {
-	t := controller.GetTemplate(c)
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-
-	// Tell main.html what route is being rendered
-	c.Env["isLogin"] = true
-
-	c.Env["FlashError"] = session.Flashes("loginError")
-
-	widgets := controller.Parse(t, "auth/login", c.Env)
-
-	c.Env["Title"] = "Decred VSP - Login"
-	c.Env["Designation"] = controller.Cfg.Designation
-
-	c.Env["Content"] = template.HTML(widgets)
-
-	return controller.Parse(t, "main", c.Env), http.StatusOK
+// tosExemptPaths are the pages which users who have not accepted the current
+// terms of service may still visit.
+var tosExemptPaths = map[string]struct{}{
+	"/tos":    {},
+	"/logout": {},
+	"/error":  {},
 }
 
-// LoginPost is the form submit route. Logs user in or sets an appropriate message in
-// session if login was not successful.
-func (controller *MainController) LoginPost(c web.C, r *http.Request) (string, int) {
-	email, password := r.FormValue("email"), r.FormValue("password")
-
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	dbMap := controller.GetDbMap(c)
-	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
-
-	// Validate email and password combination.
-	user, err := helpers.Login(dbMap, email, password)
-	if err != nil {
-		log.Infof(email+" login failed %v, %v", err, remoteIP)
-		session.AddFlash("Invalid Email or Password", "loginError")
-		return controller.Login(c, r)
-	}
-
-	log.Infof("Login POST from %v, email %v", remoteIP, user.Email)
-
-	if user.EmailVerified == 0 {
-		session.AddFlash("You must validate your email address", "loginError")
-		return controller.Login(c, r)
-	}
-
-	session.Values["UserId"] = user.ID
-
-	// Go to Address page if multisig script not yet set up.
-	// GUI users can copy their API Token from here.
-	// CLI users can paste their pubkey address
-	if user.MultiSigAddress == "" {
-		return "/address", http.StatusSeeOther
+// RequireTOS is a middleware that redirects logged in users who have not
+// accepted the current version of the terms of service to the terms of service
+// page.
+func (controller *MainController) RequireTOS(c *web.C, h http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if controller.Cfg.TOSVersion != "" {
+			user := controller.GetUser(*c)
+			_, exempt := tosExemptPaths[r.URL.Path]
+			if user != nil && !exempt && user.TOSVersion != controller.Cfg.TOSVersion {
+				http.Redirect(w, r, "/tos", http.StatusSeeOther)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
 	}
-
-	// Go to Tickets page if user already set up.
-	return "/tickets", http.StatusSeeOther
+	return http.HandlerFunc(fn)
 }
 
-// Register renders the register page.
-func (controller *MainController) Register(c web.C, r *http.Request) (string, int) {
-	// Tell main.html what route is being rendered
-	c.Env["isRegister"] = true
-	if controller.Cfg.ClosePool {
-		c.Env["IsClosed"] = true
-		c.Env["ClosePoolMsg"] = controller.Cfg.ClosePoolMsg
+// TOS renders the terms of service acceptance page.
+func (controller *MainController) TOS(c web.C, r *http.Request) (string, int) {
+	if controller.Cfg.TOSVersion == "" {
+		return "/", http.StatusSeeOther
 	}
 
 	session := controller.GetSession(c)
 	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	c.Env["FlashError"] = session.Flashes("registrationError")
-	c.Env["FlashSuccess"] = session.Flashes("registrationSuccess")
-	c.Env["CaptchaID"] = captcha.New()
-	c.Env["CaptchaMsg"] = "To register, first complete the captcha:"
-	c.Env["CaptchaError"] = session.Flashes("captchaFailed")
+
+	user := controller.GetUser(c)
+	if user == nil {
+		return "/", http.StatusSeeOther
+	}
+
+	c.Env["Admin"], _ = controller.isAdmin(c, r)
+	c.Env["FlashError"] = session.Flashes("tosError")
+	c.Env["TOSVersion"] = controller.Cfg.TOSVersion
+	c.Env["TOSURL"] = controller.Cfg.TOSURL
+	c.Env["TOSAccepted"] = user.TOSVersion == controller.Cfg.TOSVersion
+	c.Env["TOSChanged"] = user.TOSVersion != "" &&
+		user.TOSVersion != controller.Cfg.TOSVersion
 
 	t := controller.GetTemplate(c)
-	widgets := controller.Parse(t, "auth/register", c.Env)
+	widgets := controller.Parse(t, "tos", c.Env)
 
-	c.Env["Title"] = "Decred VSP - Register"
+	c.Env["Title"] = "Decred Voting Service - Terms of Service"
 	c.Env["Designation"] = controller.Cfg.Designation
 
 	c.Env["Content"] = template.HTML(widgets)
 	return controller.Parse(t, "main", c.Env), http.StatusOK
 }
 
-// RegisterPost form submit route. Registers new user or shows Registration route with
-// appropriate messages set in session.
-func (controller *MainController) RegisterPost(c web.C, r *http.Request) (string, int) {
-	if controller.Cfg.ClosePool {
-		log.Infof("attempt to register while registration disabled")
-		return "/error", http.StatusSeeOther
+// TOSPost records the user accepting the current version of the terms of
+// service.
+func (controller *MainController) TOSPost(c web.C, r *http.Request) (string, int) {
+	if controller.Cfg.TOSVersion == "" {
+		return "/", http.StatusSeeOther
 	}
 
 	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	if !controller.IsCaptchaDone(c) {
-		session.AddFlash("You must complete the captcha.", "registrationError")
-		return controller.Register(c, r)
-	}
-
-	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
-
-	email, password, passwordRepeat := r.FormValue("email"),
-		r.FormValue("password"), r.FormValue("passwordrepeat")
-
-	if !strings.Contains(email, "@") {
-		session.AddFlash("Email address is invalid", "registrationError")
-		return controller.Register(c, r)
-	}
-
-	if password == "" {
-		session.AddFlash("Password cannot be empty", "registrationError")
-		return controller.Register(c, r)
+	user := controller.GetUser(c)
+	if user == nil {
+		return "/", http.StatusSeeOther
 	}
 
-	if password != passwordRepeat {
-		session.AddFlash("Passwords do not match", "registrationError")
-		return controller.Register(c, r)
+	if r.FormValue("tos") == "" {
+		session.AddFlash("You must accept the terms of service to continue", "tosError")
+		return "/tos", http.StatusSeeOther
 	}
 
-	// At this point we have completed all trivial pre-registration checks. The new account
-	// is about to be created, so lets consume the CAPTCHA. Any failure beyond this point
-	// and we want the user to complete another CAPTCHA.
-	session.Values["CaptchaDone"] = false
-	c.Env["CaptchaDone"] = false
-
 	dbMap := controller.GetDbMap(c)
-	user := models.GetUserByEmail(dbMap, email)
-
-	if user != nil {
-		session.AddFlash("This email address is already registered", "registrationError")
-		return controller.Register(c, r)
-	}
-
-	token := models.NewUserToken()
-	user = &models.User{
-		Username:        email,
-		Email:           email,
-		EmailToken:      token.String(),
-		EmailVerified:   0,
-		VoteBits:        1,
-		VoteBitsVersion: int64(controller.voteVersion),
-	}
-	user.HashPassword(password)
-
-	log.Infof("Register POST from %v, email %v. Inserting.", remoteIP, user.Email)
-
-	err := models.InsertUser(dbMap, user)
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+	err := models.AcceptTOS(dbMap, user.ID, controller.Cfg.TOSVersion, remoteIP)
 	if err != nil {
-		session.AddFlash("Database error occurred while adding user", "registrationError")
-		log.Errorf("Error while registering user: %v", err)
-		return controller.Register(c, r)
+		log.Errorf("unable to record terms of service acceptance for user %d: %v",
+			user.ID, err)
+		session.AddFlash("Unable to record terms of service acceptance", "tosError")
+		return "/tos", http.StatusSeeOther
 	}
 
-	err = controller.Cfg.EmailSender.Registration(email, controller.Cfg.BaseURL, remoteIP, token.String())
-	if err != nil {
-		session.AddFlash("Unable to send verification email", "registrationError")
-		log.Errorf("error sending verification email %v", err)
-	} else {
-		session.AddFlash("A verification email has been sent to "+email, "registrationSuccess")
-	}
+	log.Infof("User %d accepted terms of service version %s from %v",
+		user.ID, controller.Cfg.TOSVersion, remoteIP)
 
-	return controller.Register(c, r)
+	if user.MultiSigAddress == "" {
+		return "/address", http.StatusSeeOther
+	}
+	return "/tickets", http.StatusSeeOther
 }
 
 // Stats renders the stats page.
@@ -1728,7 +1191,7 @@ func (controller *MainController) Stats(c web.C, r *http.Request) (string, int)
 	c.Env["IsStats"] = true
 	c.Env["Title"] = "Decred VSP - Stats"
 
-	dbMap := controller.GetDbMap(c)
+	dbMap := controller.GetReadDbMap(c)
 
 	userCount := models.GetUserCount(dbMap)
 	userCountActive := models.GetUserCountActive(dbMap)
@@ -1746,6 +1209,21 @@ func (controller *MainController) Stats(c web.C, r *http.Request) (string, int)
 	c.Env["StakeInfo"] = gsi
 	c.Env["UserCount"] = userCount
 	c.Env["UserCountActive"] = userCountActive
+	controller.setMissedStatsEnv(c)
+	controller.setAgendaStatsEnv(c)
+	if luck, err := controller.luck(dbMap); err != nil {
+		log.Warnf("unable to get luck: %v", err)
+	} else {
+		c.Env["Luck"] = luck
+	}
+
+	estimate, err := estimateTicket(controller.Cfg.NetParams, gsi.BlockHeight,
+		gsi.Difficulty, gsi.PoolSize, controller.Cfg.PoolFees)
+	if err != nil {
+		log.Warnf("Unable to estimate ticket reward: %v", err)
+	} else {
+		c.Env["Estimate"] = estimate
+	}
 
 	widgets := controller.Parse(t, "stats", c.Env)
 	c.Env["Designation"] = controller.Cfg.Designation
@@ -1798,10 +1276,33 @@ type TicketInfoInvalid struct {
 }
 
 // TicketInfo represents live or immature tickets that have yet to
-// be spent by either a vote or revocation.
+// be spent by either a vote or revocation.  LiveHeight and LiveTime are only
+// set for immature tickets when the current block height is known.
 type TicketInfo struct {
 	TicketHeight uint32
 	Ticket       string
+	LiveHeight   uint32
+	LiveTime     time.Time
+	// Ignored is set for live tickets which are not voted by the voting
+	// service, e.g. because they exceed the live ticket limit of the user.
+	Ignored bool
+}
+
+// markIgnoredTickets sets Ignored for the tickets found in ignored and returns
+// their number.
+func markIgnoredTickets(tickets []TicketInfo, ignored map[chainhash.Hash]string) int {
+	var n int
+	for i := range tickets {
+		hash, err := chainhash.NewHashFromStr(tickets[i].Ticket)
+		if err != nil {
+			continue
+		}
+		if _, ok := ignored[*hash]; ok {
+			tickets[i].Ignored = true
+			n++
+		}
+	}
+	return n
 }
 
 // Tickets renders the tickets page.
@@ -1816,7 +1317,8 @@ func (controller *MainController) Tickets(c web.C, r *http.Request) (string, int
 	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
 	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
 
-	if session.Values["UserId"] == nil {
+	user := controller.GetUser(c)
+	if user == nil {
 		return "/", http.StatusSeeOther
 	}
 
@@ -1825,7 +1327,6 @@ func (controller *MainController) Tickets(c web.C, r *http.Request) (string, int
 	c.Env["Title"] = "Decred VSP - Tickets"
 
 	dbMap := controller.GetDbMap(c)
-	user, _ := models.GetUserByID(dbMap, session.Values["UserId"].(int64))
 
 	if user.MultiSigAddress == "" {
 		log.Info("Multisigaddress empty")
@@ -1854,15 +1355,33 @@ func (controller *MainController) Tickets(c web.C, r *http.Request) (string, int
 	log.Debugf(":: StakePoolUserInfo (msa = %v) execution time: %v",
 		user.MultiSigAddress, time.Since(start))
 
+	// The current height is needed to estimate when immature tickets go
+	// live.  The page is still shown without the estimates if it is not
+	// available.
+	var blockHeight int64
+	if spui != nil && (len(spui.Tickets) > 0 || len(spui.InvalidTickets) > 0) {
+		gsi, err := controller.Cfg.StakepooldServers.GetStakeInfo(r.Context())
+		if err != nil {
+			log.Warnf("RPC GetStakeInfo failed: %v", err)
+		} else {
+			blockHeight = gsi.BlockHeight
+		}
+	}
+
 	// If the user has tickets, get their info
 	if spui != nil && len(spui.Tickets) > 0 {
 		for _, ticket := range spui.Tickets {
 			switch ticket.Status {
 			case "immature":
-				ticketInfoImmature = append(ticketInfoImmature, TicketInfo{
+				info := TicketInfo{
 					TicketHeight: ticket.TicketHeight,
 					Ticket:       ticket.Ticket,
-				})
+				}
+				if blockHeight > 0 {
+					info.LiveHeight, info.LiveTime = controller.ticketLiveEstimate(
+						ticket.TicketHeight, blockHeight)
+				}
+				ticketInfoImmature = append(ticketInfoImmature, info)
 			case "live":
 				ticketInfoLive = append(ticketInfoLive, TicketInfo{
 					TicketHeight: ticket.TicketHeight,
@@ -1894,11 +1413,70 @@ func (controller *MainController) Tickets(c web.C, r *http.Request) (string, int
 
 	numVoted = len(ticketInfoVoted)
 
-	if spui != nil && len(spui.InvalidTickets) > 0 {
+	// Live tickets over the live ticket limit of the user are ignored by
+	// stakepoold like low fee tickets.
+	if controller.Cfg.MaxUserLiveTickets > 0 && len(ticketInfoLive) > 0 {
+		ignored, err := controller.Cfg.StakepooldServers.GetIgnoredLowFeeTickets(r.Context())
+		if err != nil {
+			log.Warnf("RPC GetIgnoredLowFeeTickets failed: %v", err)
+		} else {
+			c.Env["TicketsLiveIgnored"] = markIgnoredTickets(ticketInfoLive, ignored)
+		}
+	}
+	c.Env["MaxUserLiveTickets"] = controller.Cfg.MaxUserLiveTickets
+
+	if spui != nil && len(spui.Tickets) > 0 {
+		c.Env["Summary"] = controller.userTicketSummary(r.Context(), spui.Tickets)
+	}
+
+	// Winning tickets that were not voted are audited by stakepoold so that
+	// misses caused by the voting service can be told apart from others.
+	missedAudits, err := models.GetMissedTicketsByUserID(
+		controller.GetReadDbMap(c), user.ID)
+	if err != nil {
+		log.Warnf("GetMissedTicketsByUserID failed for UserId %v: %v",
+			user.ID, err)
+	}
+
+	// Tickets from before the user migrated from another voting service are
+	// shown apart from those voted by this one.
+	history, err := models.GetHistoricTicketsByUserID(
+		controller.GetReadDbMap(c), user.ID)
+	if err != nil {
+		log.Warnf("GetHistoricTicketsByUserID failed for UserId %v: %v",
+			user.ID, err)
+	}
+	c.Env["TicketsHistory"] = history
+
+	// Tickets which do not commit to the pool fee are reported invalid by
+	// the wallets, but they only owe their fee when fees are deferred.
+	deferredFees := controller.Cfg.FeeMode == models.FeeModeDeferred
+	if deferredFees {
+		tickets := make(map[string]int64)
+		for _, ticket := range ticketInfoImmature {
+			tickets[ticket.Ticket] = int64(ticket.TicketHeight)
+		}
+		for _, ticket := range ticketInfoLive {
+			tickets[ticket.Ticket] = int64(ticket.TicketHeight)
+		}
+		if spui != nil {
+			for _, ticket := range spui.InvalidTickets {
+				tickets[ticket] = 0
+			}
+		}
+		fees, err := controller.userTicketFees(r.Context(), dbMap, user.ID,
+			tickets, blockHeight)
+		if err != nil {
+			log.Errorf("unable to get the ticket fees of UserId %v: %v",
+				user.ID, err)
+		}
+		c.Env["TicketFees"] = fees
+	} else if spui != nil && len(spui.InvalidTickets) > 0 {
 		for _, ticket := range spui.InvalidTickets {
 			ticketInfoInvalid = append(ticketInfoInvalid, TicketInfoInvalid{ticket})
 		}
 	}
+	c.Env["DeferredFees"] = deferredFees
 
 	// Sort tickets for display. Ideally these would be sorted on the front
 	// end by javascript.
@@ -1919,9 +1497,12 @@ func (controller *MainController) Tickets(c web.C, r *http.Request) (string, int
 	c.Env["TicketsLive"] = ticketInfoLive
 	c.Env["TicketsExpired"] = ticketInfoExpired
 	c.Env["TicketsMissed"] = ticketInfoMissed
+	c.Env["TicketsMissedAudited"] = missedAudits
 	c.Env["TicketsVotedCount"] = numVoted
 	c.Env["TicketsVotedMaxDisplay"] = controller.Cfg.MaxVotedTickets
 	c.Env["TicketsVoted"] = ticketInfoVoted
+	c.Env["FlashError"] = session.Flashes("ticketsError")
+	c.Env["FlashSuccess"] = session.Flashes("ticketsSuccess")
 	widgets := controller.Parse(t, "tickets", c.Env)
 
 	c.Env["Designation"] = controller.Cfg.Designation
@@ -1936,14 +1517,12 @@ func (controller *MainController) Tickets(c web.C, r *http.Request) (string, int
 func (controller *MainController) Voting(c web.C, r *http.Request) (string, int) {
 	session := controller.GetSession(c)
 	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	dbMap := controller.GetDbMap(c)
 
-	if session.Values["UserId"] == nil {
+	user := controller.GetUser(c)
+	if user == nil {
 		return "/", http.StatusSeeOther
 	}
 
-	user, _ := models.GetUserByID(dbMap, session.Values["UserId"].(int64))
-
 	if user.MultiSigAddress == "" {
 		log.Info("Multisigaddress empty")
 		return "/address", http.StatusSeeOther
@@ -1951,7 +1530,8 @@ func (controller *MainController) Voting(c web.C, r *http.Request) (string, int)
 
 	t := controller.GetTemplate(c)
 
-	choicesSelected := controller.choicesForAgendas(uint16(user.VoteBits))
+	voteBits := controller.forceChoices(uint16(user.VoteBits))
+	choicesSelected := controller.choicesForAgendas(voteBits)
 
 	for k, v := range choicesSelected {
 		strk := strconv.Itoa(k)
@@ -1961,8 +1541,16 @@ func (controller *MainController) Voting(c web.C, r *http.Request) (string, int)
 	c.Env["Agendas"] = controller.agendas()
 	c.Env["FlashError"] = session.Flashes("votingError")
 	c.Env["FlashSuccess"] = session.Flashes("votingSuccess")
+	c.Env["ForcedChoices"] = controller.Cfg.ForcedChoices
 	c.Env["IsVoting"] = true
 	c.Env["VoteVersion"] = controller.voteVersion
+	controller.setAgendaStatsEnv(c)
+
+	exported, err := json.MarshalIndent(controller.votingPrefs(voteBits), "", "  ")
+	if err != nil {
+		log.Errorf("unable to encode voting preferences: %v", err)
+	}
+	c.Env["ExportData"] = string(exported)
 
 	widgets := controller.Parse(t, "voting", c.Env)
 	c.Env["Title"] = "Decred Voting Service - Voting"
@@ -1979,20 +1567,23 @@ func (controller *MainController) VotingPost(c web.C, r *http.Request) (string,
 	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
 	dbMap := controller.GetDbMap(c)
 
-	if session.Values["UserId"] == nil {
+	user := controller.GetUser(c)
+	if user == nil {
 		return "/", http.StatusSeeOther
 	}
 
 	var generatedVoteBits uint16
 
-	user, _ := models.GetUserByID(dbMap, session.Values["UserId"].(int64))
-
 	// last block valid
 	generatedVoteBits |= 1
 
 	deployments := controller.getAgendas()
 
 	for i := range deployments {
+		// Locked agendas are not submitted and take the forced choice.
+		if _, ok := controller.Cfg.ForcedChoices[deployments[i].Vote.Id]; ok {
+			continue
+		}
 		agendaVal := r.FormValue("agenda" + strconv.Itoa(i))
 		avi, err := strconv.Atoi(agendaVal)
 		if err != nil {
@@ -2001,6 +1592,7 @@ func (controller *MainController) VotingPost(c web.C, r *http.Request) (string,
 		}
 		generatedVoteBits |= uint16(avi)
 	}
+	generatedVoteBits = controller.forceChoices(generatedVoteBits)
 
 	isValid := controller.IsValidVoteBits(generatedVoteBits)
 	if !isValid {
@@ -2009,7 +1601,8 @@ func (controller *MainController) VotingPost(c web.C, r *http.Request) (string,
 	}
 
 	oldVoteBits := user.VoteBits
-	user, err := helpers.UpdateVoteBitsByID(dbMap, user.ID, generatedVoteBits)
+	user, err := helpers.UpdateVoteBitsByID(dbMap, user.ID, generatedVoteBits,
+		controller.voteVersion)
 	if err != nil {
 		session.AddFlash("unable to save new voting preferences", "votingError")
 		return "/voting", http.StatusSeeOther
@@ -2018,7 +1611,9 @@ func (controller *MainController) VotingPost(c web.C, r *http.Request) (string,
 	log.Infof("updated voteBits for user %d from %d to %d",
 		user.ID, oldVoteBits, generatedVoteBits)
 	if uint16(oldVoteBits) != generatedVoteBits {
-		if err := controller.StakepooldUpdateUsers(r.Context(), dbMap); err != nil {
+		controller.recordUserActivity(c, r, user.ID, activityVoting,
+			voteBitsChange(oldVoteBits, generatedVoteBits))
+		if err := controller.SendStakepooldUpdates(r.Context(), dbMap); err != nil {
 			log.Errorf("unable to update all: %v", err)
 		}
 	}
@@ -2027,19 +1622,6 @@ func (controller *MainController) VotingPost(c web.C, r *http.Request) (string,
 	return "/voting", http.StatusSeeOther
 }
 
-// Logout the user.
-func (controller *MainController) Logout(c web.C, r *http.Request) (string, int) {
-	session := controller.GetSession(c)
-	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
-	if session.Values["UserId"] == nil {
-		return "/", http.StatusSeeOther
-	}
-
-	session.Options.MaxAge = -1
-
-	return "/", http.StatusSeeOther
-}
-
 func (controller *MainController) choicesForAgendas(userVoteBits uint16) map[int]uint16 {
 	choicesSelected := make(map[int]uint16)
 
@@ -2070,6 +1652,76 @@ func (controller *MainController) getAgendas() []chaincfg.ConsensusDeployment {
 	return controller.Cfg.NetParams.Deployments[controller.voteVersion]
 }
 
+// ticketLiveEstimate returns the block height at which a ticket mined at
+// ticketHeight goes live and an estimate of when that block will be mined,
+// given the current block height.
+func (controller *MainController) ticketLiveEstimate(ticketHeight uint32,
+	blockHeight int64) (uint32, time.Time) {
+	params := controller.Cfg.NetParams
+	liveHeight := ticketHeight + uint32(params.TicketMaturity)
+	blocks := int64(liveHeight) - blockHeight
+	if blocks < 0 {
+		blocks = 0
+	}
+	return liveHeight, time.Now().Add(time.Duration(blocks) * params.TargetTimePerBlock)
+}
+
+// voteSubsidy returns the subsidy paid to each vote in the block at height.
+func voteSubsidy(params *chaincfg.Params, height int64) dcrutil.Amount {
+	subsidy := params.BaseSubsidy
+	for i := int64(0); i < height/params.SubsidyReductionInterval; i++ {
+		subsidy *= params.MulSubsidy
+		subsidy /= params.DivSubsidy
+	}
+	subsidy *= int64(params.StakeRewardProportion)
+	subsidy /= int64(params.TotalSubsidyProportions())
+	return dcrutil.Amount(subsidy / int64(params.TicketsPerBlock))
+}
+
+// estimateTicket returns the expected outcome of a ticket purchased at
+// ticketPrice when the network ticket pool holds poolSize tickets.  Each live
+// ticket is selected to vote in a block with probability TicketsPerBlock /
+// poolSize, so the number of blocks until it votes follows a geometric
+// distribution cut off at TicketExpiry.  The VSP fee is estimated as poolFees
+// percent of the vote subsidy.
+func estimateTicket(params *chaincfg.Params, blockHeight int64,
+	ticketPrice float64, poolSize uint32, poolFees float64) (*poolapi.Estimate, error) {
+	if poolSize == 0 {
+		return nil, errors.New("ticket pool is empty")
+	}
+
+	p := math.Min(1, float64(params.TicketsPerBlock)/float64(poolSize))
+	n := float64(params.TicketExpiry)
+	expired := math.Pow(1-p, n)
+	// Mean of the geometric distribution given that the ticket votes within
+	// n blocks.
+	blocks := 1 / p
+	if expired > 0 {
+		blocks -= n * expired / (1 - expired)
+	}
+	blocks += float64(params.TicketMaturity)
+
+	subsidy := voteSubsidy(params, blockHeight+int64(blocks)).ToCoin()
+	fee := subsidy * poolFees / 100
+	net := subsidy - fee
+
+	estimate := &poolapi.Estimate{
+		BlockHeight:      blockHeight,
+		TicketPrice:      ticketPrice,
+		PoolSize:         poolSize,
+		PoolFees:         poolFees,
+		VoteProbability:  1 - expired,
+		ExpectedVoteTime: int64(blocks * params.TargetTimePerBlock.Seconds()),
+		VoteReward:       subsidy,
+		PoolFee:          fee,
+		NetReward:        net,
+	}
+	if ticketPrice > 0 {
+		estimate.ReturnPercent = net / ticketPrice * 100
+	}
+	return estimate, nil
+}
+
 // CalcEstimatedTicketExpiry returns a time.Time reflecting the estimated time
 // that the ticket will expire.  A safety margin of 5% padding is applied to
 // ensure the ticket is not removed prematurely.
@@ -2118,12 +1770,3 @@ func (controller *MainController) IsValidVoteBits(userVoteBits uint16) bool {
 
 	return userVoteBits&^usedBits == 0
 }
-
-func stringSliceContains(s []string, e string) bool {
-	for _, a := range s {
-		if a == e {
-			return true
-		}
-	}
-	return false
-}
@@ -1,27 +1,42 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	mrand "math/rand"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/dchest/captcha"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrec"
 	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
 	dcrdatatypes "github.com/decred/dcrdata/api/types/v5"
 	"github.com/decred/dcrdata/db/dbtypes/v2"
 	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
 	"github.com/decred/dcrstakepool/models"
-	"github.com/decred/dcrstakepool/stakepooldclient"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
 	"github.com/decred/slog"
+	"github.com/go-gorp/gorp"
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
 )
 
 func init() {
@@ -55,6 +70,74 @@ func TestGetNetworkName(t *testing.T) {
 	}
 }
 
+func TestTicketLiveEstimate(t *testing.T) {
+	params := chaincfg.MainNetParams()
+	mc := MainController{
+		Cfg: &Config{NetParams: params},
+	}
+
+	// A ticket mined at height 1000 with the current height 1100 goes live
+	// at 1000 + 256 in about 156 blocks.
+	before := time.Now()
+	liveHeight, liveTime := mc.ticketLiveEstimate(1000, 1100)
+	if want := uint32(1000 + params.TicketMaturity); liveHeight != want {
+		t.Fatalf("live height: got %d, want %d", liveHeight, want)
+	}
+	wantDelay := time.Duration(liveHeight-1100) * params.TargetTimePerBlock
+	if delay := liveTime.Sub(before); delay < wantDelay ||
+		delay > wantDelay+time.Minute {
+		t.Fatalf("live time delay: got %v, want %v", delay, wantDelay)
+	}
+
+	// A ticket past its live height is estimated to be live now.
+	_, liveTime = mc.ticketLiveEstimate(1000, 2000)
+	if liveTime.Before(before) || liveTime.Sub(before) > time.Minute {
+		t.Fatalf("live time for mature ticket: got %v, want about %v",
+			liveTime, before)
+	}
+}
+
+func TestEstimateTicket(t *testing.T) {
+	params := chaincfg.MainNetParams()
+
+	if _, err := estimateTicket(params, 500000, 150, 0, 5); err == nil {
+		t.Fatal("no error for an empty ticket pool")
+	}
+
+	// With a pool of TicketExpiry tickets, a ticket votes in about
+	// TicketExpiry / TicketsPerBlock blocks and expires with probability
+	// close to e^-TicketsPerBlock.
+	estimate, err := estimateTicket(params, 500000, 150,
+		params.TicketExpiry, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := estimate.VoteProbability; p < 0.99 || p > 0.995 {
+		t.Errorf("vote probability %v, want about 0.993", p)
+	}
+	mean := float64(params.TicketExpiry) / float64(params.TicketsPerBlock)
+	blocks := float64(estimate.ExpectedVoteTime) /
+		params.TargetTimePerBlock.Seconds()
+	if want := mean + float64(params.TicketMaturity); blocks > want ||
+		blocks < want*0.9 {
+		t.Errorf("expected vote in %v blocks, want a little under %v",
+			blocks, want)
+	}
+
+	wantSubsidy := voteSubsidy(params, 500000+int64(blocks)).ToCoin()
+	if estimate.VoteReward != wantSubsidy {
+		t.Errorf("vote reward %v, want %v", estimate.VoteReward, wantSubsidy)
+	}
+	if math.Abs(estimate.PoolFee-wantSubsidy*0.05) > 1e-8 ||
+		math.Abs(estimate.NetReward-wantSubsidy*0.95) > 1e-8 {
+		t.Errorf("fee %v and net reward %v for reward %v", estimate.PoolFee,
+			estimate.NetReward, wantSubsidy)
+	}
+	if want := estimate.NetReward / 150 * 100; estimate.ReturnPercent != want {
+		t.Errorf("return %v%%, want %v%%", estimate.ReturnPercent, want)
+	}
+}
+
 func randHashString() string {
 	var b [64]byte
 	const hexvals = "123456789abcdef"
@@ -217,95 +300,26 @@ func TestGetClientIP(t *testing.T) {
 	}
 }
 
-var _ stakepooldclient.Manager = (*tStakepooldManager)(nil)
-
-// tStakepooldManager satisfies the stakepooldClient.Manager interface.
-type tStakepooldManager struct {
-	qItem func() queueItem
-}
-
-func (m *tStakepooldManager) GetAddedLowFeeTickets(_ context.Context) (map[chainhash.Hash]string, error) {
-	item := m.qItem()
-	thing, _ := item.thing.(map[chainhash.Hash]string)
-	return thing, item.err
-}
-func (m *tStakepooldManager) GetIgnoredLowFeeTickets(_ context.Context) (map[chainhash.Hash]string, error) {
-	item := m.qItem()
-	thing, _ := item.thing.(map[chainhash.Hash]string)
-	return thing, item.err
-}
-func (m *tStakepooldManager) GetLiveTickets(_ context.Context) (map[chainhash.Hash]string, error) {
-	item := m.qItem()
-	thing, _ := item.thing.(map[chainhash.Hash]string)
-	return thing, item.err
-}
-func (m *tStakepooldManager) SetAddedLowFeeTickets(_ context.Context, _ []models.LowFeeTicket) error {
-	item := m.qItem()
-	return item.err
-}
-func (m *tStakepooldManager) CreateMultisig(_ context.Context, _ []string) (*pb.CreateMultisigResponse, error) {
-	item := m.qItem()
-	thing, _ := item.thing.(*pb.CreateMultisigResponse)
-	return thing, item.err
-}
-func (m *tStakepooldManager) SyncAll(_ context.Context, _ []models.User, _ int64) error {
-	item := m.qItem()
-	return item.err
-}
-func (m *tStakepooldManager) StakePoolUserInfo(_ context.Context, _ string) (*pb.StakePoolUserInfoResponse, error) {
-	item := m.qItem()
-	thing, _ := item.thing.(*pb.StakePoolUserInfoResponse)
-	return thing, item.err
-}
-func (m *tStakepooldManager) SetUserVotingPrefs(_ context.Context, _ map[int64]*models.User) error {
-	item := m.qItem()
-	return item.err
-}
-func (m *tStakepooldManager) WalletInfo(_ context.Context) ([]*pb.WalletInfoResponse, error) {
-	item := m.qItem()
-	thing, _ := item.thing.([]*pb.WalletInfoResponse)
-	return thing, item.err
-}
-func (m *tStakepooldManager) ValidateAddress(_ context.Context, _ dcrutil.Address) (*pb.ValidateAddressResponse, error) {
-	item := m.qItem()
-	thing, _ := item.thing.(*pb.ValidateAddressResponse)
-	return thing, item.err
-}
-func (m *tStakepooldManager) ImportNewScript(_ context.Context, _ []byte) (heightImported int64, err error) {
-	item := m.qItem()
-	thing, _ := item.thing.(int64)
-	return thing, item.err
-}
-func (m *tStakepooldManager) BackendStatus(_ context.Context) []stakepooldclient.BackendStatus {
-	item := m.qItem()
-	thing, _ := item.thing.([]stakepooldclient.BackendStatus)
-	return thing
-}
-func (m *tStakepooldManager) GetStakeInfo(_ context.Context) (*pb.GetStakeInfoResponse, error) {
-	item := m.qItem()
-	thing, _ := item.thing.(*pb.GetStakeInfoResponse)
-	return thing, item.err
-}
-func (m *tStakepooldManager) CrossCheckColdWalletExtPubs(_ context.Context, _ string) error {
-	item := m.qItem()
-	return item.err
-}
-
 type queueItem struct {
 	thing interface{}
 	err   error
 }
 
-// tManagerWithQueue will return a tStakepooldManager that outputs items in the
-// order of queueItems.
-func tManagerWithQueue(queueItems []queueItem) *tStakepooldManager {
+// tManagerWithQueue will return a manager.Mock whose WalletInfo outputs items
+// in the order of queueItems.
+func tManagerWithQueue(queueItems []queueItem) *manager.Mock {
 	i := 0
 	getItem := func() queueItem {
 		defer func() { i++ }()
 		return queueItems[i]
 	}
-	sm := &tStakepooldManager{getItem}
-	return sm
+	return &manager.Mock{
+		WalletInfoFunc: func(context.Context) ([]*pb.WalletInfoResponse, error) {
+			item := getItem()
+			thing, _ := item.thing.([]*pb.WalletInfoResponse)
+			return thing, item.err
+		},
+	}
 }
 
 func TestNewMainController(t *testing.T) {
@@ -638,3 +652,1104 @@ func TestAgendas(t *testing.T) {
 		agendasCache.Unlock()
 	}
 }
+
+func TestSignInChallenges(t *testing.T) {
+	const addr = "SkQmxbeuEFDByPoTj41TtXat8tWySVuYUQpd4fuNNyUx51tF1csSs"
+	s := newSignInChallenges()
+	now := time.Now()
+
+	nonce, message, err := s.issue(addr, "https://vsp.example.com", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Challenges are bound to the address they were issued for.
+	if _, ok := s.consume(nonce, "other", now); ok {
+		t.Fatal("challenge accepted for another address")
+	}
+	if _, ok := s.consume(nonce, addr, now); ok {
+		t.Fatal("challenge accepted after it was consumed")
+	}
+
+	nonce, message2, err := s.issue(addr, "https://vsp.example.com", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message == message2 {
+		t.Fatal("challenges reuse messages")
+	}
+	got, ok := s.consume(nonce, addr, now.Add(time.Minute))
+	if !ok || got != message2 {
+		t.Fatalf("got %q, %v", got, ok)
+	}
+
+	// Expired challenges are rejected and pruned.
+	nonce, _, _ = s.issue(addr, "https://vsp.example.com", now)
+	if _, ok := s.consume(nonce, addr, now.Add(signInChallengeLifetime)); ok {
+		t.Fatal("expired challenge accepted")
+	}
+	s.issue(addr, "https://vsp.example.com", now)
+	s.issue(addr, "https://vsp.example.com", now.Add(signInChallengeLifetime))
+	if len(s.challenges) != 1 {
+		t.Fatalf("got %d outstanding challenges, want 1", len(s.challenges))
+	}
+}
+
+func TestSolvedCaptchas(t *testing.T) {
+	s := newSolvedCaptchas()
+	now := time.Now()
+
+	token, err := s.add(captchaRegister, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Captchas are bound to the purpose they were solved for.
+	if s.valid(token, captchaReset, now) {
+		t.Fatal("captcha valid for another purpose")
+	}
+	if !s.valid(token, captchaRegister, now) {
+		t.Fatal("captcha not valid for its purpose")
+	}
+	if !s.consume(token, captchaRegister, now.Add(time.Minute)) {
+		t.Fatal("captcha not consumed")
+	}
+
+	// Each captcha can only be used once.
+	if s.valid(token, captchaRegister, now) {
+		t.Fatal("captcha valid after it was consumed")
+	}
+	if s.consume(token, captchaRegister, now) {
+		t.Fatal("captcha consumed twice")
+	}
+
+	// Consuming a captcha for another purpose uses it up.
+	token, _ = s.add(captchaSettings, now)
+	if s.consume(token, captchaRegister, now) {
+		t.Fatal("captcha consumed for another purpose")
+	}
+	if s.consume(token, captchaSettings, now) {
+		t.Fatal("captcha consumed after it was used")
+	}
+
+	// Expired captchas are rejected and pruned.
+	token, _ = s.add(captchaSignIn, now)
+	if s.valid(token, captchaSignIn, now.Add(solvedCaptchaLifetime)) {
+		t.Fatal("expired captcha valid")
+	}
+	if s.consume(token, captchaSignIn, now.Add(solvedCaptchaLifetime)) {
+		t.Fatal("expired captcha consumed")
+	}
+	s.add(captchaSignIn, now)
+	s.add(captchaSignIn, now.Add(solvedCaptchaLifetime))
+	if len(s.solved) != 1 {
+		t.Fatalf("got %d outstanding captchas, want 1", len(s.solved))
+	}
+}
+
+func TestCaptchaServe(t *testing.T) {
+	controller := &MainController{captchaHandler: &captchaHandler{
+		ImgWidth:  200,
+		ImgHeight: 80,
+		Digits:    5,
+		AudioLang: "en",
+	}}
+	id := captcha.NewLen(controller.captchaHandler.Digits)
+
+	tests := []struct {
+		file        string
+		audio       bool
+		wantCode    int
+		contentType string
+	}{
+		{id + ".png", false, http.StatusOK, "image/png"},
+		{id + ".wav", false, http.StatusNotFound, ""},
+		{id + ".wav", true, http.StatusOK, "audio/x-wav"},
+		{id + ".gif", true, http.StatusNotFound, ""},
+		{".png", false, http.StatusNotFound, ""},
+		{"unknown.png", false, http.StatusInternalServerError, ""},
+	}
+	for _, test := range tests {
+		controller.captchaHandler.Audio = test.audio
+		r := httptest.NewRequest("GET", "/captchas/"+test.file, nil)
+		w := httptest.NewRecorder()
+		controller.CaptchaServe(web.C{}, w, r)
+		if w.Code != test.wantCode {
+			t.Fatalf("%s (audio %v): expected code %d but got %d",
+				test.file, test.audio, test.wantCode, w.Code)
+		}
+		if test.contentType != "" && w.Header().Get("Content-Type") != test.contentType {
+			t.Fatalf("%s: expected content type %s but got %s", test.file,
+				test.contentType, w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestTicketDistribution(t *testing.T) {
+	users := []models.User{
+		{ID: 1, Email: "a@example.com", MultiSigAddress: "Tcbvn"},
+		{ID: 2, Username: "DsSigner", MultiSigAddress: "Tcxyz"},
+		{ID: 3, Email: "c@example.com", MultiSigAddress: "Tcabc"},
+		{ID: 4, Email: "d@example.com", MultiSigAddress: "Tcnone"},
+	}
+	counts := map[string]uint32{
+		"Tcbvn":   1,
+		"Tcxyz":   3,
+		"Tcabc":   600,
+		"Tcother": 2,
+	}
+
+	d := ticketDistribution(counts, users)
+	if d.Tickets != 606 || d.Accounts != 3 || d.Unknown != 2 {
+		t.Fatalf("got %d tickets, %d accounts, %d unknown", d.Tickets,
+			d.Accounts, d.Unknown)
+	}
+
+	wantBuckets := map[uint32]int{1: 1, 2: 1, 501: 1}
+	for _, b := range d.Buckets {
+		if b.Accounts != wantBuckets[b.Min] {
+			t.Errorf("bucket %d-%d has %d accounts, want %d", b.Min, b.Max,
+				b.Accounts, wantBuckets[b.Min])
+		}
+	}
+
+	if len(d.Top) != 3 || d.Top[0].UserID != 3 || d.Top[2].UserID != 1 {
+		t.Fatalf("unexpected top accounts %v", d.Top)
+	}
+	if d.Top[1].Name != "DsSigner" {
+		t.Errorf("got name %q for account without email", d.Top[1].Name)
+	}
+
+	// G = 2(1*1 + 2*3 + 3*600)/(3*604) - 4/3
+	wantGini := 2*1807.0/1812 - 4.0/3
+	if math.Abs(d.Gini-wantGini) > 1e-9 {
+		t.Errorf("got Gini %v, want %v", d.Gini, wantGini)
+	}
+
+	// Equal holdings are perfectly fair.
+	d = ticketDistribution(map[string]uint32{"Tcbvn": 5, "Tcxyz": 5},
+		users[:2])
+	if math.Abs(d.Gini) > 1e-9 {
+		t.Errorf("got Gini %v for equal holdings", d.Gini)
+	}
+
+	d = ticketDistribution(nil, users)
+	if d.Tickets != 0 || d.Accounts != 0 || d.Gini != 0 || len(d.Top) != 0 {
+		t.Fatalf("unexpected distribution without tickets %+v", d)
+	}
+}
+
+func TestTicketSummary(t *testing.T) {
+	hash := func(b byte) string {
+		return chainhash.Hash{b}.String()
+	}
+	tickets := []*pb.StakePoolUserTicket{
+		{Status: "immature", Ticket: hash(1)},
+		{Status: "live", Ticket: hash(2)},
+		{Status: "voted", Ticket: hash(3), SpentBy: hash(4)},
+		{Status: "voted", Ticket: hash(5), SpentBy: hash(6)},
+		{Status: "missed", Ticket: hash(7)},
+		{Status: "expired", Ticket: hash(8)},
+	}
+	amounts := map[chainhash.Hash]*pb.TicketAmounts{
+		{1}: {TicketPrice: 100e8},
+		{2}: {TicketPrice: 50e8},
+		{4}: {TicketPrice: 100e8, Subsidy: 2e8, PoolPayout: 1e6, UserPayout: 101.99e8},
+		{6}: {TicketPrice: 48e8, Subsidy: 2e8, PoolPayout: 0, UserPayout: 50e8},
+	}
+
+	var calls int
+	mc := &MainController{Cfg: &Config{StakepooldServers: &manager.Mock{
+		GetTicketAmountsFunc: func(_ context.Context, hashes []chainhash.Hash) (map[chainhash.Hash]*pb.TicketAmounts, error) {
+			calls++
+			found := make(map[chainhash.Hash]*pb.TicketAmounts)
+			for _, h := range hashes {
+				if a, ok := amounts[h]; ok {
+					found[h] = a
+				}
+			}
+			return found, nil
+		},
+	}}}
+
+	summary := mc.userTicketSummary(context.Background(), tickets)
+	want := poolapi.TicketSummary{
+		Total:    6,
+		Immature: 1,
+		Live:     1,
+		Voted:    2,
+		Missed:   1,
+		Expired:  1,
+		// 101.99 DCR * 2 / 102 truncated to atoms, plus 2 DCR.
+		Rewards:     dcrutil.Amount(199980392 + 2e8).ToCoin(),
+		VotePayouts: 151.99,
+		LiveStake:   150,
+	}
+	if *summary != want {
+		t.Fatalf("got summary %+v, want %+v", *summary, want)
+	}
+
+	// Amounts are only looked up once.
+	mc.userTicketSummary(context.Background(), tickets)
+	if calls != 1 {
+		t.Fatalf("amounts were looked up %d times", calls)
+	}
+
+	// Counts are still returned when amounts are missing.
+	delete(amounts, chainhash.Hash{6})
+	mc.amountsCache = ticketAmountsCache{}
+	summary = mc.userTicketSummary(context.Background(), tickets)
+	if !summary.AmountsIncomplete || summary.Voted != 2 ||
+		summary.VotePayouts != 101.99 {
+		t.Fatalf("got summary %+v with a missing vote", *summary)
+	}
+
+	mc.amountsCache = ticketAmountsCache{}
+	mc.Cfg.StakepooldServers = &manager.Mock{
+		GetTicketAmountsFunc: func(context.Context, []chainhash.Hash) (map[chainhash.Hash]*pb.TicketAmounts, error) {
+			return nil, errors.New("unavailable")
+		},
+	}
+	summary = mc.userTicketSummary(context.Background(), tickets)
+	if !summary.AmountsIncomplete || summary.Total != 6 || summary.Rewards != 0 {
+		t.Fatalf("got summary %+v without amounts", *summary)
+	}
+}
+
+func TestParseVotingPrefs(t *testing.T) {
+	params := &chaincfg.Params{Deployments: tDeployments}
+	mc := &MainController{Cfg: &Config{NetParams: params}, voteVersion: 4}
+
+	tests := []struct {
+		name     string
+		data     string
+		voteBits uint16
+		wantErr  bool
+	}{{
+		name:     "all agendas",
+		data:     `{"VoteVersion":4,"VoteChoices":{"` + voteIDSDiffAlgorithm + `":"yes","` + voteIDLNSupport + `":"no"}}`,
+		voteBits: 0x0001 | 0x0004 | 0x0008,
+	}, {
+		name:     "missing agenda abstains",
+		data:     `{"VoteVersion":4,"VoteChoices":{"` + voteIDLNSupport + `":"yes"}}`,
+		voteBits: 0x0001 | 0x0010,
+	}, {
+		name:    "wrong vote version",
+		data:    `{"VoteVersion":5,"VoteChoices":{}}`,
+		wantErr: true,
+	}, {
+		name:    "unknown agenda",
+		data:    `{"VoteVersion":4,"VoteChoices":{"nope":"yes"}}`,
+		wantErr: true,
+	}, {
+		name:    "unknown choice",
+		data:    `{"VoteVersion":4,"VoteChoices":{"` + voteIDLNSupport + `":"maybe"}}`,
+		wantErr: true,
+	}, {
+		name:    "invalid json",
+		data:    `{`,
+		wantErr: true,
+	}}
+	for _, test := range tests {
+		prefs, err := mc.parseVotingPrefs([]byte(test.data))
+		if (err != nil) != test.wantErr {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		if err != nil {
+			continue
+		}
+		if prefs.VoteBits != test.voteBits {
+			t.Fatalf("%s: got votebits %#x, want %#x", test.name,
+				prefs.VoteBits, test.voteBits)
+		}
+		// The exported preferences import to the same votebits.
+		if exported := mc.votingPrefs(prefs.VoteBits); !reflect.DeepEqual(exported, prefs) {
+			t.Fatalf("%s: exported %v, want %v", test.name, exported, prefs)
+		}
+	}
+
+	// Choices forced by the operator override the imported ones.
+	mc.Cfg.ForcedChoices = map[string]string{voteIDLNSupport: "abstain"}
+	prefs, err := mc.parseVotingPrefs([]byte(tests[0].data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefs.VoteBits != 0x0001|0x0004 || prefs.VoteChoices[voteIDLNSupport] != "abstain" {
+		t.Fatalf("got votebits %#x and choices %v with a forced choice",
+			prefs.VoteBits, prefs.VoteChoices)
+	}
+}
+
+func TestEmailRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int64
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, time.Minute * 2},
+		{3, time.Minute * 4},
+		{9, time.Minute * 256},
+		{10, emailRetryMaxDelay},
+		{1000, emailRetryMaxDelay},
+	}
+	for _, test := range tests {
+		if got := emailRetryDelay(test.attempts); got != test.want {
+			t.Errorf("emailRetryDelay(%d): want %v, got %v", test.attempts,
+				test.want, got)
+		}
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"", 5, ""},
+		{"abc", 5, "abc"},
+		{"abcde", 5, "abcde"},
+		{"abcdef", 5, "abcde"},
+		// Multi-byte runes are not split.
+		{"abcdé", 5, "abcd"},
+		{"abcdé", 6, "abcdé"},
+		{"日本語", 5, "日"},
+		{"日本語", 2, ""},
+	}
+	for _, test := range tests {
+		if got := truncateString(test.s, test.n); got != test.want {
+			t.Errorf("truncateString(%q, %d): want %q, got %q", test.s,
+				test.n, test.want, got)
+		}
+	}
+}
+
+func TestUserTicketCounts(t *testing.T) {
+	users := []models.User{
+		{ID: 1, MultiSigAddress: "msa1"},
+		{ID: 2},
+		{ID: 3, MultiSigAddress: "msa3"},
+		{ID: 4, MultiSigAddress: "msa4"},
+	}
+
+	var requested []string
+	mc := &MainController{Cfg: &Config{StakepooldServers: &manager.Mock{
+		BatchStakePoolUserInfoFunc: func(_ context.Context, msas []string) (map[string]*pb.StakePoolUserInfoResponse, error) {
+			requested = msas
+			return map[string]*pb.StakePoolUserInfoResponse{
+				"msa1": {Tickets: []*pb.StakePoolUserTicket{
+					{Status: "immature"},
+					{Status: "live"},
+					{Status: "voted"},
+					{Status: "missed"},
+					{Status: "expired"},
+				}},
+				"msa3": {},
+			}, nil
+		},
+	}}}
+
+	counts, err := mc.userTicketCounts(context.Background(), users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(requested, []string{"msa1", "msa3", "msa4"}) {
+		t.Fatalf("requested the info of %v", requested)
+	}
+	want := map[int64]*adminUserTickets{
+		1: {Live: 2, Voted: 1, Missed: 2},
+		3: {},
+	}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("got counts %v, want %v", counts, want)
+	}
+
+	// No request is made when no user has submitted an address.
+	requested = nil
+	counts, err = mc.userTicketCounts(context.Background(), users[1:2])
+	if err != nil || counts != nil || requested != nil {
+		t.Fatalf("got counts %v, error %v and request %v for users "+
+			"without addresses", counts, err, requested)
+	}
+}
+
+func TestFeatureEnabledFor(t *testing.T) {
+	flag := &models.FeatureFlag{Name: "feature", Enabled: 1, Percent: 100}
+	if !featureEnabledFor(flag, 0) || !featureEnabledFor(flag, 1) {
+		t.Fatal("feature enabled for all users is disabled")
+	}
+
+	flag.Enabled = 0
+	if featureEnabledFor(flag, 1) {
+		t.Fatal("disabled feature is enabled")
+	}
+
+	flag.Enabled = 1
+	flag.Percent = 0
+	if featureEnabledFor(flag, 1) {
+		t.Fatal("feature enabled for no users is enabled")
+	}
+
+	// Users keep a feature as its percentage grows, and about the rolled
+	// out percentage of users get it.
+	prev := make(map[int64]bool)
+	for _, percent := range []int64{10, 50, 90} {
+		flag.Percent = percent
+		if featureEnabledFor(flag, 0) {
+			t.Fatalf("feature enabled for %d%% of users enabled for "+
+				"visitors", percent)
+		}
+		var n int64
+		for id := int64(1); id <= 1000; id++ {
+			enabled := featureEnabledFor(flag, id)
+			if prev[id] && !enabled {
+				t.Fatalf("user %d lost the feature at %d%%", id, percent)
+			}
+			prev[id] = enabled
+			if enabled {
+				n++
+			}
+		}
+		if n < percent*10-50 || n > percent*10+50 {
+			t.Errorf("feature enabled for %d of 1000 users at %d%%", n,
+				percent)
+		}
+	}
+}
+
+func TestWebhooksEnabled(t *testing.T) {
+	mc := &MainController{Cfg: &Config{Webhooks: true}}
+	mc.featureFlags.fetched = time.Now()
+	c := web.C{Env: map[interface{}]interface{}{
+		"DbMap": (*gorp.DbMap)(nil),
+		"User":  &models.User{ID: 1},
+	}}
+
+	// Without a flag, webhooks follow the webhooks option.
+	if !mc.webhooksEnabled(c) {
+		t.Fatal("webhooks disabled without a flag")
+	}
+	mc.Cfg.Webhooks = false
+	if mc.webhooksEnabled(c) {
+		t.Fatal("webhooks enabled without the webhooks option")
+	}
+
+	// A flag limits webhooks to the users it enables them for, but cannot
+	// enable them without the webhooks option.
+	mc.featureFlags.flags = map[string]models.FeatureFlag{
+		webhooksFeature: {Name: webhooksFeature, Enabled: 1, Percent: 100},
+	}
+	if mc.webhooksEnabled(c) {
+		t.Fatal("flag enabled webhooks without the webhooks option")
+	}
+	mc.Cfg.Webhooks = true
+	if !mc.webhooksEnabled(c) {
+		t.Fatal("webhooks disabled by a flag enabling them")
+	}
+	mc.featureFlags.flags[webhooksFeature] = models.FeatureFlag{
+		Name: webhooksFeature, Enabled: 0, Percent: 100,
+	}
+	if mc.webhooksEnabled(c) {
+		t.Fatal("webhooks enabled by a disabled flag")
+	}
+}
+
+func TestApplyVotingFreeze(t *testing.T) {
+	users := map[int64]*models.User{
+		1: {ID: 1, VoteBits: 5},
+		2: {ID: 2, VoteBits: 1},
+	}
+
+	applyVotingFreeze(nil, users)
+	applyVotingFreeze(&models.VotingFreeze{Frozen: 0, VoteBits: 1}, users)
+	if users[1].VoteBits != 5 {
+		t.Fatalf("vote bits changed while not frozen: %d", users[1].VoteBits)
+	}
+
+	applyVotingFreeze(&models.VotingFreeze{Frozen: 1, VoteBits: 3}, users)
+	for id, user := range users {
+		if user.VoteBits != 3 {
+			t.Errorf("user %d votes with %d while frozen, want 3", id,
+				user.VoteBits)
+		}
+	}
+}
+
+func TestAuditTargets(t *testing.T) {
+	if got := auditTargets(nil); got != "" {
+		t.Errorf("no targets joined to %q", got)
+	}
+	if got := auditTargets([]string{"a", "b"}); got != "a, b" {
+		t.Errorf("targets joined to %q, want %q", got, "a, b")
+	}
+
+	// 64 character ticket hashes which do not all fit.
+	tickets := make([]string, 20)
+	for i := range tickets {
+		tickets[i] = strings.Repeat(strconv.Itoa(i%10), 64)
+	}
+	got := auditTargets(tickets)
+	if len(got) > maxAuditTargetsLength {
+		t.Fatalf("joined targets are %d long", len(got))
+	}
+	if !strings.HasPrefix(got, tickets[0]+", "+tickets[1]) ||
+		!strings.HasSuffix(got, " and 6 more") {
+		t.Errorf("unexpected joined targets %q", got)
+	}
+
+	long := strings.Repeat("x", maxAuditTargetsLength+1)
+	if got := auditTargets([]string{long}); got != "and 1 more" {
+		t.Errorf("too long target joined to %q", got)
+	}
+}
+
+func TestMarkIgnoredTickets(t *testing.T) {
+	ignoredHash := chainhash.Hash{1}
+	tickets := []TicketInfo{
+		{Ticket: chainhash.Hash{2}.String()},
+		{Ticket: ignoredHash.String()},
+		{Ticket: "invalid"},
+	}
+	ignored := map[chainhash.Hash]string{ignoredHash: "msa", {3}: "other"}
+	if n := markIgnoredTickets(tickets, ignored); n != 1 {
+		t.Fatalf("marked %d tickets as ignored", n)
+	}
+	for i, ticket := range tickets {
+		if ticket.Ignored != (i == 1) {
+			t.Errorf("ticket %d: ignored %v", i, ticket.Ignored)
+		}
+	}
+}
+
+func TestCheckFeeTx(t *testing.T) {
+	params := chaincfg.TestNet3Params()
+	feeAddr, err := dcrutil.NewAddressPubKeyHash(bytes.Repeat([]byte{1}, 20),
+		params, dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherAddr, err := dcrutil.NewAddressPubKeyHash(bytes.Repeat([]byte{2}, 20),
+		params, dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feeTxHex := func(addr dcrutil.Address, amount int64) string {
+		script, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0,
+			wire.TxTreeRegular), 1e8, nil))
+		tx.AddTxOut(wire.NewTxOut(amount, script))
+		b, err := tx.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hex.EncodeToString(b)
+	}
+
+	tests := []struct {
+		name    string
+		txHex   string
+		wantErr bool
+	}{{
+		name:  "exact fee",
+		txHex: feeTxHex(feeAddr, 5000),
+	}, {
+		name:  "higher fee",
+		txHex: feeTxHex(feeAddr, 6000),
+	}, {
+		name:    "lower fee",
+		txHex:   feeTxHex(feeAddr, 4999),
+		wantErr: true,
+	}, {
+		name:    "other address",
+		txHex:   feeTxHex(otherAddr, 5000),
+		wantErr: true,
+	}, {
+		name:    "not hex",
+		txHex:   "zz",
+		wantErr: true,
+	}, {
+		name:    "not a transaction",
+		txHex:   "0102",
+		wantErr: true,
+	}, {
+		name:    "too large",
+		txHex:   strings.Repeat("00", maxFeeTxSize+1),
+		wantErr: true,
+	}}
+	for _, test := range tests {
+		_, err := checkFeeTx(test.txHex, feeAddr, 5000)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+}
+
+func TestTicketFeeInfo(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	tests := []struct {
+		name    string
+		fee     models.TicketFee
+		payable bool
+	}{{
+		name:    "pending",
+		fee:     models.TicketFee{Status: models.TicketFeePending, Deadline: now.Unix() + 1},
+		payable: true,
+	}, {
+		name: "past deadline",
+		fee:  models.TicketFee{Status: models.TicketFeePending, Deadline: now.Unix()},
+	}, {
+		name: "fee tx submitted",
+		fee: models.TicketFee{Status: models.TicketFeePending,
+			Deadline: now.Unix() + 1, FeeTxHash: "hash"},
+	}, {
+		name: "paid",
+		fee:  models.TicketFee{Status: models.TicketFeePaid, Deadline: now.Unix() + 1},
+	}}
+	for _, test := range tests {
+		info := ticketFeeInfo(&test.fee, now)
+		if info.Payable != test.payable {
+			t.Errorf("%s: payable %v, want %v", test.name, info.Payable,
+				test.payable)
+		}
+	}
+}
+
+func TestAddressJobs(t *testing.T) {
+	var jobs addressJobs
+	if jobs.isRunning(1) {
+		t.Fatal("job running before start")
+	}
+	if !jobs.start(1) {
+		t.Fatal("unable to start job")
+	}
+	if jobs.start(1) {
+		t.Fatal("job started twice")
+	}
+	if !jobs.isRunning(1) || jobs.isRunning(2) {
+		t.Fatal("wrong job running")
+	}
+	jobs.done(1)
+	if jobs.isRunning(1) || !jobs.start(1) {
+		t.Fatal("job not restartable after done")
+	}
+}
+
+func TestMaintenanceGate(t *testing.T) {
+	tests := []struct {
+		name, path, remoteAddr string
+		torMode, enabled       bool
+		apiUserID              int64
+		wantCode               int
+	}{{
+		name:       "disabled",
+		path:       "/tickets",
+		remoteAddr: "240.111.3.145",
+		wantCode:   http.StatusOK,
+	}, {
+		name:       "user",
+		path:       "/tickets",
+		remoteAddr: "240.111.3.145",
+		enabled:    true,
+		wantCode:   http.StatusServiceUnavailable,
+	}, {
+		name:       "api user",
+		path:       "/api/v3/tickets",
+		remoteAddr: "240.111.3.145",
+		enabled:    true,
+		apiUserID:  2,
+		wantCode:   http.StatusServiceUnavailable,
+	}, {
+		name:       "login",
+		path:       "/login",
+		remoteAddr: "240.111.3.145",
+		enabled:    true,
+		wantCode:   http.StatusOK,
+	}, {
+		name:       "allowed ip",
+		path:       "/tickets",
+		remoteAddr: "10.0.0.2",
+		enabled:    true,
+		wantCode:   http.StatusOK,
+	}, {
+		name:       "admin ip",
+		path:       "/tickets",
+		remoteAddr: "10.0.0.1",
+		enabled:    true,
+		wantCode:   http.StatusOK,
+	}, {
+		name:       "admin ip in tormode",
+		path:       "/tickets",
+		remoteAddr: "10.0.0.1",
+		torMode:    true,
+		enabled:    true,
+		wantCode:   http.StatusServiceUnavailable,
+	}, {
+		name:       "admin user in tormode",
+		path:       "/api/v3/maintenance",
+		remoteAddr: "127.0.0.1",
+		torMode:    true,
+		enabled:    true,
+		apiUserID:  1,
+		wantCode:   http.StatusOK,
+	}, {
+		name:       "admin user from other ip",
+		path:       "/api/v3/maintenance",
+		remoteAddr: "240.111.3.145",
+		enabled:    true,
+		apiUserID:  1,
+		wantCode:   http.StatusServiceUnavailable,
+	}}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, test := range tests {
+		controller := &MainController{Cfg: &Config{
+			AdminIPs:            []string{"10.0.0.1"},
+			AdminUserIDs:        []string{"1"},
+			MaintenanceAllowIPs: []string{"10.0.0.2"},
+			TorMode:             test.torMode,
+		}}
+		controller.maintenance.set(test.enabled, time.Now())
+		c := &web.C{Env: make(map[interface{}]interface{})}
+		if test.apiUserID != 0 {
+			c.Env["APIUserID"] = test.apiUserID
+		}
+		r := httptest.NewRequest("GET", test.path, nil)
+		r.RemoteAddr = test.remoteAddr
+		w := httptest.NewRecorder()
+		controller.MaintenanceGate(c, ok).ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Fatalf("%s: expected code %d but got %d", test.name,
+				test.wantCode, w.Code)
+		}
+	}
+}
+
+func TestParseHistoricScript(t *testing.T) {
+	params := chaincfg.TestNet3Params()
+	// 1-of-2 multisig script.
+	pubKey1 := "02" + strings.Repeat("11", 32)
+	pubKey2 := "03" + strings.Repeat("22", 32)
+	multisig := "5121" + pubKey1 + "21" + pubKey2 + "52ae"
+
+	script, msa, err := parseHistoricScript(" "+multisig+"\n", params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hex.EncodeToString(script) != multisig {
+		t.Fatalf("script %x, want %s", script, multisig)
+	}
+	addr, err := dcrutil.DecodeAddress(msa, params)
+	if err != nil {
+		t.Fatalf("invalid address %s: %v", msa, err)
+	}
+	if _, ok := addr.(*dcrutil.AddressScriptHash); !ok {
+		t.Fatalf("address %s is not a P2SH address", msa)
+	}
+
+	for _, bad := range []string{"", "zz", "76a914" + strings.Repeat("00", 20) + "88ac"} {
+		if _, _, err := parseHistoricScript(bad, params); err == nil {
+			t.Errorf("no error for script %q", bad)
+		}
+	}
+}
+
+func TestBadgeMessage(t *testing.T) {
+	tests := []struct {
+		tickets adminUserTickets
+		msg     string
+		color   string
+	}{
+		{adminUserTickets{}, "Live: 0 tickets", "#2970ff"},
+		{adminUserTickets{Live: 1}, "Live: 1 ticket", "#2970ff"},
+		{adminUserTickets{Live: 3, Voted: 10}, "Live: 3 tickets, 100% voted", "#41bf53"},
+		{adminUserTickets{Live: 3, Voted: 199, Missed: 1}, "Live: 3 tickets, 99% voted", "#41bf53"},
+		{adminUserTickets{Live: 2, Voted: 19, Missed: 1}, "Live: 2 tickets, 95% voted", "#ffc84e"},
+		{adminUserTickets{Voted: 1, Missed: 1}, "Live: 0 tickets, 50% voted", "#ed6d47"},
+	}
+	for _, test := range tests {
+		msg, color := badgeMessage(&test.tickets)
+		if msg != test.msg || color != test.color {
+			t.Errorf("badgeMessage(%+v) = %q, %q, want %q, %q", test.tickets,
+				msg, color, test.msg, test.color)
+		}
+	}
+
+	svg := string(renderBadge("<VSP>", "Live: 1 ticket", "#2970ff"))
+	if strings.Contains(svg, "<VSP>") || !strings.Contains(svg, "&lt;VSP&gt;") {
+		t.Errorf("badge text not escaped: %s", svg)
+	}
+}
+
+func TestBadgeCache(t *testing.T) {
+	var bc badgeCache
+	now := time.Now()
+	if _, ok := bc.get(1, now); ok {
+		t.Fatal("empty cache returned counts")
+	}
+	bc.set(1, adminUserTickets{Live: 2}, now)
+	if tickets, ok := bc.get(1, now.Add(badgeCacheLife-time.Second)); !ok || tickets.Live != 2 {
+		t.Fatalf("cached counts %v, %v", tickets, ok)
+	}
+	if _, ok := bc.get(1, now.Add(badgeCacheLife)); ok {
+		t.Fatal("expired counts returned")
+	}
+	bc.set(2, adminUserTickets{}, now.Add(badgeCacheLife))
+	if len(bc.counts) != 1 {
+		t.Fatalf("expired counts not dropped: %v", bc.counts)
+	}
+}
+
+func TestVotingStats(t *testing.T) {
+	deployments := []chaincfg.ConsensusDeployment{{
+		Vote: chaincfg.Vote{
+			Id:   "agenda",
+			Mask: 0x0006,
+			Choices: []chaincfg.Choice{
+				{Id: "abstain", Bits: 0x0000, IsAbstain: true},
+				{Id: "no", Bits: 0x0002, IsNo: true},
+				{Id: "yes", Bits: 0x0004},
+			},
+		},
+	}}
+
+	few := []models.VoteBitsCount{{VoteBits: 1, Count: agendaStatsMinUsers - 1}}
+	if stats := votingStats(deployments, 8, few); stats != nil {
+		t.Fatalf("stats published for %d users", agendaStatsMinUsers-1)
+	}
+
+	counts := []models.VoteBitsCount{
+		{VoteBits: 0x0001, Count: 2},
+		{VoteBits: 0x0003, Count: 2},
+		{VoteBits: 0x0005, Count: 6},
+	}
+	stats := votingStats(deployments, 8, counts)
+	if stats == nil || stats.Users != 10 || stats.VoteVersion != 8 ||
+		len(stats.Agendas) != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	want := map[string]int64{"abstain": 2, "no": 2, "yes": 6}
+	for _, choice := range stats.Agendas[0].Choices {
+		if choice.Users != want[choice.Choice] ||
+			choice.Percent != float64(want[choice.Choice])*10 {
+			t.Errorf("choice %s has %d users (%v%%), want %d", choice.Choice,
+				choice.Users, choice.Percent, want[choice.Choice])
+		}
+	}
+}
+
+func TestMissedStats(t *testing.T) {
+	counts := []models.MissedCauseCount{
+		{Cause: models.MissedCauseNetwork, Count: 5},
+		{Cause: "unknown", Count: 1},
+		{Cause: models.MissedCausePool, Count: 0},
+		{Cause: models.MissedCauseWalletLocked, Count: 4},
+	}
+	stats, byPool, byNetwork := missedStats(counts)
+	if byPool != 5 || byNetwork != 5 {
+		t.Fatalf("got %d missed by pool and %d by network, want 5 and 5",
+			byPool, byNetwork)
+	}
+	var causes []string
+	for _, stat := range stats {
+		causes = append(causes, stat.Cause)
+		if stat.Percent != float64(stat.Count)*10 {
+			t.Errorf("cause %s has %d tickets (%v%%)", stat.Cause,
+				stat.Count, stat.Percent)
+		}
+	}
+	want := []string{models.MissedCauseWalletLocked, models.MissedCauseNetwork,
+		"unknown"}
+	if !reflect.DeepEqual(causes, want) {
+		t.Errorf("got causes %v, want %v", causes, want)
+	}
+	if stats[0].Label != models.MissedCauseLabel(models.MissedCauseWalletLocked) ||
+		stats[2].Label != "unknown" {
+		t.Errorf("unexpected labels %+v", stats)
+	}
+}
+
+func TestNextScriptExpiryStep(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	grace := 30 * 24 * time.Hour
+	warned := now.Add(-grace).Unix()
+	tests := []struct {
+		name    string
+		warned  int64
+		tickets int
+		want    scriptExpiryStep
+	}{
+		{"used", 0, 1, scriptKeep},
+		{"used after warning", warned, 2, scriptClearWarning},
+		{"unused", 0, 0, scriptWarn},
+		{"unused in grace period", warned + 1, 0, scriptKeep},
+		{"unused after grace period", warned, 0, scriptExpire},
+	}
+	for _, test := range tests {
+		got := nextScriptExpiryStep(test.warned, test.tickets, now, grace)
+		if got != test.want {
+			t.Errorf("%s: got step %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestScriptHasTickets(t *testing.T) {
+	infos := map[string]*pb.StakePoolUserInfoResponse{
+		"unused":  {},
+		"live":    {Tickets: []*pb.StakePoolUserTicket{{Status: "live"}}},
+		"invalid": {InvalidTickets: []string{"aa"}},
+	}
+	mc := &MainController{Cfg: &Config{StakepooldServers: &manager.Mock{
+		StakePoolUserInfoFunc: func(_ context.Context, msa string) (*pb.StakePoolUserInfoResponse, error) {
+			if msa == "error" {
+				return nil, errors.New("wallet unavailable")
+			}
+			return infos[msa], nil
+		},
+	}}}
+
+	tests := []struct {
+		msa     string
+		want    bool
+		wantErr bool
+	}{
+		{"unused", false, false},
+		{"unknown", false, false},
+		{"live", true, false},
+		{"invalid", true, false},
+		{"error", false, true},
+	}
+	for _, test := range tests {
+		user := &models.User{MultiSigAddress: test.msa}
+		got, err := mc.scriptHasTickets(context.Background(), nil, user)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("%s: got %v, %v, want %v", test.msa, got, err, test.want)
+		}
+	}
+}
+
+func TestLowFeeTicketsCache(t *testing.T) {
+	var lc lowFeeTicketsCache
+	now := time.Now()
+	lc.set("ignored 1", cachedLowFeeTickets{total: 3, fetched: now})
+	if entry, ok := lc.get("ignored 1", now.Add(lowFeeTicketsCacheLife-time.Second)); !ok || entry.total != 3 {
+		t.Fatalf("cached tickets %v, %v", entry, ok)
+	}
+	if _, ok := lc.get("ignored 1", now.Add(lowFeeTicketsCacheLife)); ok {
+		t.Fatal("expired tickets returned")
+	}
+	lc.set("added", cachedLowFeeTickets{fetched: now.Add(lowFeeTicketsCacheLife)})
+	if len(lc.entries) != 1 {
+		t.Fatalf("expired tickets not dropped: %v", lc.entries)
+	}
+	lc.reset()
+	if _, ok := lc.get("added", now.Add(lowFeeTicketsCacheLife)); ok {
+		t.Fatal("tickets returned after reset")
+	}
+}
+
+func TestLowFeeTicketRows(t *testing.T) {
+	rows := lowFeeTicketRows(map[chainhash.Hash]string{
+		{0x02}: "b",
+		{0x01}: "a",
+		{0x03}: "c",
+	})
+	var addrs string
+	for _, row := range rows {
+		addrs += row.MultiSigAddress
+	}
+	if addrs != "abc" {
+		t.Fatalf("rows not sorted by hash: %v", rows)
+	}
+
+	tests := []struct {
+		page, total, start, end int
+	}{
+		{1, 0, 0, 0},
+		{1, 250, 0, 100},
+		{3, 250, 200, 250},
+		{4, 250, 250, 250},
+	}
+	for _, test := range tests {
+		start, end := pageBounds(test.page, adminLowFeeTicketsPerPage, test.total)
+		if start != test.start || end != test.end {
+			t.Errorf("pageBounds(%d, %d) = %d, %d, want %d, %d", test.page,
+				test.total, start, end, test.start, test.end)
+		}
+	}
+}
+
+func TestEmailCooldownEnds(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	user := &models.User{}
+	if _, cooldown := emailCooldownEnds(user, time.Hour, now); cooldown {
+		t.Fatal("cooldown without an email change")
+	}
+
+	user.EmailChanged = now.Add(-30 * time.Minute).Unix()
+	ends, cooldown := emailCooldownEnds(user, time.Hour, now)
+	if !cooldown || !ends.Equal(now.Add(30*time.Minute)) {
+		t.Fatalf("got cooldown %v until %v", cooldown, ends)
+	}
+	if _, cooldown := emailCooldownEnds(user, 0, now); cooldown {
+		t.Fatal("cooldown although disabled")
+	}
+	if _, cooldown := emailCooldownEnds(user, time.Hour, ends); cooldown {
+		t.Fatal("cooldown after it ended")
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	wrapped := fmt.Errorf("voting error: %w", newAPIError(poolapi.ErrInvalidVoteBits,
+		"VoteBits", "votebits invalid for current agendas"))
+	tests := []struct {
+		code codes.Code
+		err  error
+		want poolapi.Error
+	}{
+		{codes.Unauthenticated, errAPIInvalidToken,
+			poolapi.Error{Code: poolapi.ErrInvalidAPIToken, Message: "invalid api token"}},
+		{codes.InvalidArgument, wrapped,
+			poolapi.Error{Code: poolapi.ErrInvalidVoteBits, Field: "VoteBits",
+				Message: "votebits invalid for current agendas"}},
+		{codes.InvalidArgument, errors.New("bad"),
+			poolapi.Error{Code: poolapi.ErrInvalidArgument, Message: "bad"}},
+		{codes.Unknown, errors.New("oops"),
+			poolapi.Error{Code: poolapi.ErrInternal, Message: "oops"}},
+	}
+	for i, test := range tests {
+		got := apiErrors(test.code, test.err)
+		if len(got) != 1 || got[0] != test.want {
+			t.Errorf("test %d: got %+v, want %+v", i, got, test.want)
+		}
+	}
+}
+
+func TestWriteApplied(t *testing.T) {
+	failed := errors.New("unavailable")
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{failed, false},
+		{&manager.PartialWriteError{Applied: 1,
+			Failed: map[string]error{"a": failed}}, true},
+		{&manager.PartialWriteError{
+			Failed: map[string]error{"a": failed}}, false},
+		// Writes held back from rescanning wallets are applied once the
+		// rescan is done.
+		{&manager.PartialWriteError{
+			Failed: map[string]error{"a": manager.ErrWalletRescanning}}, true},
+		{&manager.PartialWriteError{Failed: map[string]error{
+			"a": manager.ErrWalletRescanning, "b": failed}}, false},
+	}
+	for i, test := range tests {
+		if got := writeApplied(test.err); got != test.want {
+			t.Errorf("test %d: got %v, want %v", i, got, test.want)
+		}
+	}
+}
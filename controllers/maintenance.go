@@ -0,0 +1,202 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/decred/dcrstakepool/system"
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
+)
+
+// maintenanceRetryAfter is the Retry-After header of the responses served
+// while the voting service is in maintenance, in seconds.
+const maintenanceRetryAfter = "300"
+
+// defaultMaintenancePage is served in maintenance mode when no maintenance
+// page is configured.
+const defaultMaintenancePage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Decred Voting Service - Maintenance</title>
+</head>
+<body>
+<h1>Down for maintenance</h1>
+<p>The voting service is undergoing maintenance and will be back shortly.
+Your tickets are still being voted.</p>
+</body>
+</html>
+`
+
+// maintenanceMode holds whether the voting service is in maintenance.  The
+// zero value is not in maintenance.
+type maintenanceMode struct {
+	mtx     sync.Mutex
+	enabled bool
+	since   time.Time
+}
+
+// get returns whether the voting service is in maintenance and when that
+// was last changed.
+func (m *maintenanceMode) get() (bool, time.Time) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.enabled, m.since
+}
+
+// set enables or disables maintenance and returns whether that changed it.
+func (m *maintenanceMode) set(enabled bool, now time.Time) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.enabled == enabled {
+		return false
+	}
+	m.enabled = enabled
+	m.since = now
+	return true
+}
+
+// maintenanceAllowed returns whether the request may be served while the
+// voting service is in maintenance: requests from the maintenance allowlist
+// or adminips, and requests of admins, either signed in or using an API
+// token, are.
+func (controller *MainController) maintenanceAllowed(c web.C, r *http.Request) bool {
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+	if stringSliceContains(controller.Cfg.MaintenanceAllowIPs, remoteIP) {
+		return true
+	}
+	if !controller.Cfg.TorMode {
+		return stringSliceContains(controller.Cfg.AdminIPs, remoteIP)
+	}
+
+	// All clients of an onion service connect from the local Tor daemon, so
+	// admins are told apart by their user ID only, as by isAdmin.
+	var userID int64
+	if user := controller.GetUser(c); user != nil {
+		userID = user.ID
+	}
+	if id, ok := c.Env["APIUserID"].(int64); ok {
+		userID = id
+	}
+	return userID != 0 && stringSliceContains(controller.Cfg.AdminUserIDs,
+		strconv.FormatInt(userID, 10))
+}
+
+// MaintenanceGate is a middleware which answers all requests with the
+// maintenance page, or an API error for API requests, while the voting service
+// is in maintenance, except those allowed by maintenanceAllowed.  The sign in
+// page stays reachable so that admins of an onion service, who are not told
+// apart by IP, can sign in.
+func (controller *MainController) MaintenanceGate(c *web.C, h http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if enabled, _ := controller.maintenance.get(); !enabled ||
+			r.URL.Path == "/login" || controller.maintenanceAllowed(*c, r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", maintenanceRetryAfter)
+		if strings.HasPrefix(r.URL.Path, "/api") {
+			resp := system.NewAPIResponse("error", codes.Unavailable,
+				"voting service is down for maintenance", nil)
+			resp.Errors = apiErrors(codes.Unavailable, errAPIMaintenance)
+			system.WriteAPIResponse(resp, http.StatusServiceUnavailable, w)
+			return
+		}
+		page := controller.Cfg.MaintenancePage
+		if page == "" {
+			page = defaultMaintenancePage
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(page))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// apiAdminID returns the user ID of the API token of the request when it
+// belongs to an admin who may use it from the client IP, or an error.
+func (controller *MainController) apiAdminID(c web.C, r *http.Request) (int64, error) {
+	userID, ok := c.Env["APIUserID"].(int64)
+	if !ok {
+		return 0, errAPIInvalidToken
+	}
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+	if !controller.Cfg.TorMode &&
+		!stringSliceContains(controller.Cfg.AdminIPs, remoteIP) {
+		log.Warnf("%s request from %s userid %d failed AdminIPs check",
+			r.URL, remoteIP, userID)
+		return 0, errAPINotAdmin
+	}
+	if !stringSliceContains(controller.Cfg.AdminUserIDs,
+		strconv.FormatInt(userID, 10)) {
+		log.Warnf("%s request from %s userid %d failed adminUserIDs check",
+			r.URL, remoteIP, userID)
+		return 0, errAPINotAdmin
+	}
+	return userID, nil
+}
+
+// APIMaintenance returns the state of the maintenance mode to admins.
+func (controller *MainController) APIMaintenance(c web.C, r *http.Request) (*poolapi.Maintenance, codes.Code, string, error) {
+	if _, err := controller.apiAdminID(c, r); err != nil {
+		return nil, codes.PermissionDenied, "maintenance error", err
+	}
+
+	enabled, since := controller.maintenance.get()
+	m := &poolapi.Maintenance{Enabled: enabled}
+	if !since.IsZero() {
+		m.Since = since.Unix()
+	}
+	return m, codes.OK, "maintenance mode", nil
+}
+
+// APIMaintenanceSet enables or disables the maintenance mode as requested by
+// an admin with the enabled form value, which is parsed by
+// strconv.ParseBool.  The change applies to this dcrstakepool instance only
+// and is lost on restart, where the maintenance option applies.
+func (controller *MainController) APIMaintenanceSet(c web.C, r *http.Request) (*poolapi.Maintenance, codes.Code, string, error) {
+	adminID, err := controller.apiAdminID(c, r)
+	if err != nil {
+		return nil, codes.PermissionDenied, "maintenance error", err
+	}
+
+	enabled, err := strconv.ParseBool(r.FormValue("enabled"))
+	if err != nil {
+		return nil, codes.InvalidArgument, "maintenance error",
+			newAPIError(poolapi.ErrInvalidArgument, "enabled",
+				"enabled must be true or false")
+	}
+
+	now := time.Now()
+	if controller.maintenance.set(enabled, now) {
+		action := "disable maintenance mode"
+		if enabled {
+			action = "enable maintenance mode"
+		}
+		log.Infof("admin user %d: %s", adminID, action)
+		audit := &models.AdminAudit{
+			AdminUID: adminID,
+			IP:       getClientIP(r, controller.Cfg.RealIPHeader),
+			Action:   action,
+			Created:  now.Unix(),
+		}
+		if err := models.InsertAdminAudit(controller.GetDbMap(c), audit); err != nil {
+			log.Errorf("unable to record admin user %d action %q: %v",
+				adminID, action, err)
+		}
+	}
+
+	return controller.APIMaintenance(c, r)
+}
@@ -0,0 +1,264 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
+)
+
+// maxDisplayedMessages is the number of most recent messages shown on the
+// messages page and returned by the API.
+const maxDisplayedMessages = 100
+
+// Limits on the length of maintenance notices posted by admins.
+const (
+	maxMessageSubjectLength = 200
+	maxMessageBodyLength    = 4000
+)
+
+// notifyUser delivers an in-app message to a user.  Messages are a courtesy in
+// addition to the pages and emails describing the same events, so failing to
+// deliver one is logged and otherwise ignored.
+func notifyUser(dbMap *gorp.DbMap, userID int64, kind, subject, body string) {
+	err := models.InsertMessage(dbMap, &models.Message{
+		UserID:  userID,
+		Kind:    kind,
+		Subject: subject,
+		Body:    body,
+		Created: time.Now().Unix(),
+	})
+	if err != nil {
+		log.Errorf("unable to deliver %s message to user %d: %v", kind,
+			userID, err)
+	}
+}
+
+// notifyFeeAddress tells a user which fee address was set up for them.
+func notifyFeeAddress(dbMap *gorp.DbMap, userID int64, feeAddr string) {
+	notifyUser(dbMap, userID, models.MessageKindFeeAddress,
+		"Fee address updated",
+		"Your voting service fee address is now "+feeAddr+". Tickets "+
+			"must pay the voting service fee to this address to be voted.")
+}
+
+// Messages renders the messages page.
+func (controller *MainController) Messages(c web.C, r *http.Request) (string, int) {
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	user := controller.GetUser(c)
+	if user == nil {
+		return "/", http.StatusSeeOther
+	}
+
+	c.Env["Admin"], _ = controller.isAdmin(c, r)
+	c.Env["IsMessages"] = true
+	c.Env["FlashError"] = session.Flashes("messagesError")
+
+	messages, err := models.GetMessagesByUserID(controller.GetDbMap(c),
+		user.ID, maxDisplayedMessages)
+	if err != nil {
+		log.Errorf("Messages: GetMessagesByUserID failed: %v", err)
+		c.Env["FlashError"] = []interface{}{"Unable to load your messages"}
+	}
+	c.Env["Messages"] = messages
+
+	t := controller.GetTemplate(c)
+	widgets := controller.Parse(t, "messages", c.Env)
+
+	c.Env["Title"] = "Decred Voting Service - Messages"
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// MessagesPost marks one or, when the id is "all", all of the user's
+// messages as read.
+func (controller *MainController) MessagesPost(c web.C, r *http.Request) (string, int) {
+	session := controller.GetSession(c)
+	dbMap := controller.GetDbMap(c)
+
+	user := controller.GetUser(c)
+	if user == nil {
+		return "/", http.StatusSeeOther
+	}
+	userID := user.ID
+
+	if err := markMessagesRead(dbMap, userID, r.FormValue("id")); err != nil {
+		log.Errorf("MessagesPost: unable to mark messages of user %d read: %v",
+			userID, err)
+		session.AddFlash("Unable to mark messages as read", "messagesError")
+	}
+
+	return "/messages", http.StatusSeeOther
+}
+
+// errInvalidMessageID is returned by markMessagesRead for a message id which
+// is neither a number nor "all".
+var errInvalidMessageID = errors.New("invalid message id")
+
+// markMessagesRead marks the message of a user with the passed id, or all of
+// their messages when id is "all", as read.
+func markMessagesRead(dbMap *gorp.DbMap, userID int64, id string) error {
+	if id == "all" {
+		return models.MarkAllMessagesRead(dbMap, userID)
+	}
+	messageID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return errInvalidMessageID
+	}
+	return models.MarkMessageRead(dbMap, userID, messageID)
+}
+
+// AdminMessages renders the administrative page for sending maintenance
+// notices to all users.
+func (controller *MainController) AdminMessages(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminMessages"] = true
+	c.Env["Title"] = "Decred Voting Service - Notices (Admin)"
+
+	c.Env["FlashError"] = session.Flashes("adminMessagesError")
+	c.Env["FlashSuccess"] = session.Flashes("adminMessagesSuccess")
+
+	widgets := controller.Parse(t, "admin/messages", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// AdminMessagesPost validates a maintenance notice posted from AdminMessages
+// and delivers it to all users with a verified email address.
+func (controller *MainController) AdminMessagesPost(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	subject := strings.TrimSpace(r.FormValue("subject"))
+	body := strings.TrimSpace(r.FormValue("body"))
+
+	switch {
+	case subject == "" || body == "":
+		session.AddFlash("A notice needs a subject and a body", "adminMessagesError")
+		return "/adminmessages", http.StatusSeeOther
+	case len(subject) > maxMessageSubjectLength:
+		session.AddFlash("The subject is longer than "+
+			strconv.Itoa(maxMessageSubjectLength)+" characters",
+			"adminMessagesError")
+		return "/adminmessages", http.StatusSeeOther
+	case len(body) > maxMessageBodyLength:
+		session.AddFlash("The body is longer than "+
+			strconv.Itoa(maxMessageBodyLength)+" characters",
+			"adminMessagesError")
+		return "/adminmessages", http.StatusSeeOther
+	}
+
+	sent, err := models.InsertMessageForAllUsers(controller.GetDbMap(c),
+		models.MessageKindMaintenance, subject, body)
+	if err != nil {
+		log.Errorf("unable to send maintenance notice: %v", err)
+		session.AddFlash("Unable to send the notice", "adminMessagesError")
+		return "/adminmessages", http.StatusSeeOther
+	}
+
+	log.Infof("admin user %d sent maintenance notice %q to %d users",
+		controller.GetUser(c).ID, subject, sent)
+	controller.auditAdminAction(c, r, "send maintenance notice", subject)
+	session.AddFlash("Notice sent to "+strconv.FormatInt(sent, 10)+" users",
+		"adminMessagesSuccess")
+	return "/adminmessages", http.StatusSeeOther
+}
+
+// APIMessages returns the most recent messages of the user and their count
+// of unread messages.  Unlike the other GET commands it refuses read-only
+// tokens, since messages are private to the user while read-only tokens are
+// meant to be embedded in public dashboards.
+func (controller *MainController) APIMessages(c web.C,
+	r *http.Request) (*poolapi.Messages, codes.Code, string, error) {
+	dbMap := controller.GetDbMap(c)
+
+	if c.Env["APIUserID"] == nil {
+		return nil, codes.Unauthenticated, "messages error", errAPIInvalidToken
+	}
+
+	if apiReadOnly(c) {
+		return nil, codes.PermissionDenied, "messages error", errAPIReadOnlyToken
+	}
+
+	userID := c.Env["APIUserID"].(int64)
+	messages, err := models.GetMessagesByUserID(dbMap, userID, maxDisplayedMessages)
+	if err != nil {
+		log.Errorf("APIMessages: GetMessagesByUserID failed: %v", err)
+		return nil, codes.Internal, "messages error",
+			newAPIError(poolapi.ErrInternal, "", "failed to get messages from database")
+	}
+
+	resp := &poolapi.Messages{
+		Unread:   models.GetUnreadMessageCount(dbMap, userID),
+		Messages: make([]poolapi.Message, 0, len(messages)),
+	}
+	for _, m := range messages {
+		resp.Messages = append(resp.Messages, poolapi.Message{
+			ID:      m.ID,
+			Kind:    m.Kind,
+			Subject: m.Subject,
+			Body:    m.Body,
+			Created: m.Created,
+			Read:    m.Read,
+		})
+	}
+
+	return resp, codes.OK, "messages successfully retrieved", nil
+}
+
+// APIMessagesRead marks the message with the posted ID, or all messages when
+// the ID is "all", as read.
+func (controller *MainController) APIMessagesRead(c web.C, r *http.Request) ([]string, codes.Code, string, error) {
+	if c.Env["APIUserID"] == nil {
+		return nil, codes.Unauthenticated, "messages error", errAPIInvalidToken
+	}
+
+	err := markMessagesRead(controller.GetDbMap(c), c.Env["APIUserID"].(int64),
+		r.FormValue("ID"))
+	if err == errInvalidMessageID {
+		return nil, codes.InvalidArgument, "messages error",
+			wrapAPIError(poolapi.ErrInvalidArgument, "ID", err)
+	}
+	if err != nil {
+		log.Errorf("APIMessagesRead: unable to mark messages read: %v", err)
+		return nil, codes.Internal, "messages error",
+			newAPIError(poolapi.ErrInternal, "",
+				"failed to update messages in database")
+	}
+
+	return nil, codes.OK, "messages marked as read", nil
+}
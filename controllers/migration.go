@@ -0,0 +1,265 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/hdkeychain/v3"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/helpers"
+	"github.com/decred/dcrstakepool/internal/migration"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+)
+
+// exportedIndex returns the index of the child of branch of acctKey which the
+// address of the user with uid was derived from, or nil when addr is not that
+// address.
+func (controller *MainController) exportedIndex(dbMap *gorp.DbMap,
+	account string, acctKey *hdkeychain.ExtendedKey, branch uint32, uid int64,
+	addr string) *uint32 {
+	if acctKey == nil {
+		return nil
+	}
+	derived, index, _, err := controller.deriveAddressForUserID(dbMap,
+		account, acctKey, branch, int(uid))
+	if err != nil || derived.Address() != addr {
+		return nil
+	}
+	return &index
+}
+
+// exportTickets returns the ticket history of the multisig script of a user:
+// the tickets known to the voting wallets and the tickets whose fee was
+// recorded.
+func exportTickets(info *pb.StakePoolUserInfoResponse, fees []models.TicketFee) []migration.Ticket {
+	var tickets []migration.Ticket
+	byHash := make(map[string]int)
+	if info != nil {
+		for _, t := range info.Tickets {
+			byHash[t.Ticket] = len(tickets)
+			tickets = append(tickets, migration.Ticket{
+				Hash:          t.Ticket,
+				Status:        t.Status,
+				Height:        t.TicketHeight,
+				SpentBy:       t.SpentBy,
+				SpentByHeight: t.SpentByHeight,
+			})
+		}
+		for _, hash := range info.InvalidTickets {
+			byHash[hash] = len(tickets)
+			tickets = append(tickets, migration.Ticket{
+				Hash:   hash,
+				Status: migration.TicketInvalid,
+			})
+		}
+	}
+	for _, fee := range fees {
+		i, ok := byHash[fee.TicketHash]
+		if !ok {
+			i = len(tickets)
+			byHash[fee.TicketHash] = i
+			tickets = append(tickets, migration.Ticket{
+				Hash:   fee.TicketHash,
+				Status: migration.TicketUnknown,
+			})
+		}
+		t := &tickets[i]
+		t.FeeAddress = fee.FeeAddress
+		t.FeeAmount = fee.FeeAmount
+		t.FeeTxHash = fee.FeeTxHash
+		t.FeeStatus = fee.Status
+		t.FeeCreated = fee.Created
+		t.FeePaid = fee.Paid
+	}
+	return tickets
+}
+
+// ExportUsers returns the migration export of all users who submitted an
+// address, with the tickets the voting wallets know of.
+func (controller *MainController) ExportUsers(ctx context.Context, dbMap *gorp.DbMap) (*migration.Export, error) {
+	users, err := models.GetUsersForExport(dbMap)
+	if err != nil {
+		return nil, err
+	}
+	msas := make([]string, len(users))
+	for i := range users {
+		msas[i] = users[i].MultiSigAddress
+	}
+	infos, err := controller.Cfg.StakepooldServers.BatchStakePoolUserInfo(ctx, msas)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the tickets of users: %v", err)
+	}
+
+	votingAccount := models.AddressAccountVoting
+	if controller.Cfg.VotingBranch == helpers.InternalBranch {
+		votingAccount = models.AddressAccountVotingInternal
+	}
+	export := &migration.Export{
+		Version:      migration.Version,
+		Network:      controller.Cfg.NetParams.Name,
+		Created:      time.Now().Unix(),
+		PoolFees:     controller.Cfg.PoolFees,
+		FeeMode:      controller.Cfg.FeeMode,
+		VoteVersion:  controller.voteVersion,
+		VotingBranch: controller.Cfg.VotingBranch,
+		Users:        make([]migration.User, 0, len(users)),
+	}
+	for i := range users {
+		user := &users[i]
+		fees, err := models.GetTicketFeesByUserID(dbMap, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		u := migration.User{
+			ID:               user.ID,
+			Email:            user.Email,
+			EmailVerified:    user.EmailVerified != 0,
+			Created:          user.Created,
+			MultiSigAddress:  user.MultiSigAddress,
+			MultiSigScript:   user.MultiSigScript,
+			UserPubKeyAddr:   user.UserPubKeyAddr,
+			PoolPubKeyAddr:   user.PoolPubKeyAddr,
+			HeightRegistered: user.HeightRegistered,
+			UserFeeAddr:      user.UserFeeAddr,
+			FeeIndex: controller.exportedIndex(dbMap, models.AddressAccountFee,
+				controller.Cfg.FeeXpub, helpers.ExternalBranch, user.ID,
+				user.UserFeeAddr),
+			VoteBits:        uint16(user.VoteBits),
+			VoteBitsVersion: uint32(user.VoteBitsVersion),
+			Tickets:         exportTickets(infos[user.MultiSigAddress], fees),
+		}
+		if ticketAddr, err := u.PoolTicketAddress(controller.Cfg.NetParams); err == nil {
+			u.VotingIndex = controller.exportedIndex(dbMap, votingAccount,
+				controller.Cfg.VotingXpub, controller.Cfg.VotingBranch,
+				user.ID, ticketAddr.Address())
+		}
+		export.Users = append(export.Users, u)
+	}
+	return export, nil
+}
+
+// importedUser returns the user and the paid ticket fees of u to import.
+// Users can not sign in with their password from the other voting service,
+// so they have to reset it.  The fees which were not paid are not imported
+// since they were to be paid to the fee addresses of the other voting
+// service.
+func importedUser(u *migration.User) models.ImportedUser {
+	var verified int64
+	if u.EmailVerified {
+		verified = 1
+	}
+	imported := models.ImportedUser{User: models.User{
+		Email:            u.Email,
+		Username:         u.Email,
+		MultiSigAddress:  u.MultiSigAddress,
+		MultiSigScript:   u.MultiSigScript,
+		PoolPubKeyAddr:   u.PoolPubKeyAddr,
+		UserPubKeyAddr:   u.UserPubKeyAddr,
+		UserFeeAddr:      u.UserFeeAddr,
+		HeightRegistered: u.HeightRegistered,
+		EmailVerified:    verified,
+		VoteBits:         int64(u.VoteBits),
+		VoteBitsVersion:  int64(u.VoteBitsVersion),
+		Created:          u.Created,
+	}}
+	for _, t := range u.Tickets {
+		if t.FeeStatus != models.TicketFeePaid {
+			continue
+		}
+		imported.TicketFees = append(imported.TicketFees, models.TicketFee{
+			TicketHash: t.Hash,
+			FeeAddress: t.FeeAddress,
+			FeeAmount:  t.FeeAmount,
+			FeeTxHash:  t.FeeTxHash,
+			Status:     t.FeeStatus,
+			Created:    t.FeeCreated,
+			Paid:       t.FeePaid,
+		})
+	}
+	return imported
+}
+
+// checkFeeAddress checks that the fee address of u is the child at FeeIndex
+// of the external branch of feeXpub, which stakepoold accepts the ticket
+// commitments of.
+func checkFeeAddress(u *migration.User, feeXpub *hdkeychain.ExtendedKey, params *chaincfg.Params) error {
+	if u.FeeIndex == nil {
+		return fmt.Errorf("fee address %s was not derived from a fee "+
+			"account key", u.UserFeeAddr)
+	}
+	branchKey, err := feeXpub.Child(helpers.ExternalBranch)
+	if err != nil {
+		return err
+	}
+	addr, index, err := helpers.ChildAddress(branchKey, *u.FeeIndex, params)
+	if err != nil {
+		return err
+	}
+	if index != *u.FeeIndex || addr.Address() != u.UserFeeAddr {
+		return fmt.Errorf("fee address %s is not derived from the fee "+
+			"account key of this voting service", u.UserFeeAddr)
+	}
+	return nil
+}
+
+// ImportUsers adds the users of a migration export and returns their number.
+// The export must have the fees of this voting service, every fee address
+// must be derived from its fee account key and the voting wallets must own
+// the pool address of every script, so that the tickets of the users are
+// accepted and can be voted.  No user may have the email address or script
+// of an existing user.  Nothing is imported otherwise.  The scripts are
+// imported into the voting wallets, with a rescan from the height they were
+// registered at, by the wallet sync of the next startup.
+func (controller *MainController) ImportUsers(ctx context.Context, dbMap *gorp.DbMap, export *migration.Export) (int, error) {
+	params := controller.Cfg.NetParams
+	if err := export.Validate(params); err != nil {
+		return 0, err
+	}
+	if export.PoolFees != controller.Cfg.PoolFees ||
+		export.FeeMode != controller.Cfg.FeeMode {
+		return 0, fmt.Errorf("the export has fees of %v%% paid in fee "+
+			"mode %s, not %v%% paid in fee mode %s", export.PoolFees,
+			export.FeeMode, controller.Cfg.PoolFees, controller.Cfg.FeeMode)
+	}
+
+	users := make([]models.ImportedUser, 0, len(export.Users))
+	for i := range export.Users {
+		u := &export.Users[i]
+		if err := checkFeeAddress(u, controller.Cfg.FeeXpub, params); err != nil {
+			return 0, fmt.Errorf("user %d: %v", u.ID, err)
+		}
+		ticketAddr, err := u.PoolTicketAddress(params)
+		if err != nil {
+			return 0, err
+		}
+		if err := controller.checkPoolAddress(ctx, ticketAddr); err != nil {
+			return 0, fmt.Errorf("user %d: %v", u.ID, err)
+		}
+		users = append(users, importedUser(u))
+	}
+	if err := models.ImportUsers(dbMap, users); err != nil {
+		return 0, err
+	}
+	return len(users), nil
+}
+
+// checkPoolAddress checks that the voting wallets own addr.
+func (controller *MainController) checkPoolAddress(ctx context.Context, addr dcrutil.Address) error {
+	resp, err := controller.Cfg.StakepooldServers.ValidateAddress(ctx, addr)
+	if err != nil {
+		return fmt.Errorf("unable to validate pool address %s: %v", addr, err)
+	}
+	if !resp.IsMine {
+		return fmt.Errorf("pool address %s is not owned by the voting "+
+			"wallets, which could not vote the tickets", addr)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/helpers"
+	"github.com/decred/dcrstakepool/internal/migration"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+)
+
+func TestExportTickets(t *testing.T) {
+	info := &pb.StakePoolUserInfoResponse{
+		Tickets: []*pb.StakePoolUserTicket{{
+			Status:       "live",
+			Ticket:       "a",
+			TicketHeight: 100,
+		}},
+		InvalidTickets: []string{"b"},
+	}
+	fees := []models.TicketFee{
+		{TicketHash: "a", FeeAddress: "Tsfee", FeeAmount: 1000,
+			Status: models.TicketFeePaid, Paid: 5},
+		{TicketHash: "c", FeeAddress: "Tsfee2", Status: models.TicketFeePending},
+	}
+	want := []migration.Ticket{
+		{Hash: "a", Status: "live", Height: 100, FeeAddress: "Tsfee",
+			FeeAmount: 1000, FeeStatus: models.TicketFeePaid, FeePaid: 5},
+		{Hash: "b", Status: migration.TicketInvalid},
+		{Hash: "c", Status: migration.TicketUnknown, FeeAddress: "Tsfee2",
+			FeeStatus: models.TicketFeePending},
+	}
+	tickets := exportTickets(info, fees)
+	if !reflect.DeepEqual(tickets, want) {
+		t.Fatalf("got %+v, want %+v", tickets, want)
+	}
+
+	// Only the paid fees are imported.
+	imported := importedUser(&migration.User{Email: "a@example.com",
+		Tickets: tickets})
+	if len(imported.TicketFees) != 1 || imported.TicketFees[0].TicketHash != "a" {
+		t.Fatalf("unexpected imported fees %+v", imported.TicketFees)
+	}
+}
+
+func TestImportUsersFees(t *testing.T) {
+	params := chaincfg.TestNet3Params()
+	feeXpub, err := hdkeychain.NewKeyFromString("tpubVpQL1h9UcY9c1BPZYfjYEt"+
+		"w5froRAvqZEo6sn5Tji6VkhcpfMaQ6id9Spf5iNvprRTcpdF5pj7m5Suyu1E8iC4xn"+
+		"b6MkjUnCJureTsmdXfG", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	branchKey, err := feeXpub.Child(helpers.ExternalBranch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feeAddr, feeIndex, err := helpers.ChildAddress(branchKey, 2, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pks []*dcrutil.AddressSecpPubKey
+	for _, s := range []string{
+		"0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		"02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5",
+	} {
+		b, _ := hex.DecodeString(s)
+		pk, err := dcrutil.NewAddressSecpPubKey(b, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pks = append(pks, pk)
+	}
+	script, err := txscript.MultiSigScript(pks, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2sh, err := dcrutil.NewAddressScriptHash(script, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The pool address is never owned, so that an export passing the fee
+	// checks fails there, before anything is imported.
+	mc := &MainController{Cfg: &Config{
+		NetParams: params,
+		PoolFees:  7.5,
+		FeeMode:   models.FeeModeDeferred,
+		FeeXpub:   feeXpub,
+		StakepooldServers: &manager.Mock{
+			ValidateAddressFunc: func(context.Context, dcrutil.Address) (*pb.ValidateAddressResponse, error) {
+				return &pb.ValidateAddressResponse{}, nil
+			},
+		},
+	}}
+	newExport := func() *migration.Export {
+		index := feeIndex
+		return &migration.Export{
+			Version:  migration.Version,
+			Network:  params.Name,
+			PoolFees: 7.5,
+			FeeMode:  models.FeeModeDeferred,
+			Users: []migration.User{{
+				ID:              1,
+				Email:           "user@example.com",
+				MultiSigAddress: p2sh.Address(),
+				MultiSigScript:  hex.EncodeToString(script),
+				PoolPubKeyAddr:  pks[0].String(),
+				UserPubKeyAddr:  pks[1].String(),
+				UserFeeAddr:     feeAddr.Address(),
+				FeeIndex:        &index,
+			}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(e *migration.Export)
+		want   string
+	}{
+		{"valid", func(e *migration.Export) {}, "not owned"},
+		{"pool fees", func(e *migration.Export) { e.PoolFees = 5 }, "fees"},
+		{"fee mode", func(e *migration.Export) {
+			e.FeeMode = models.FeeModeCommitment
+		}, "fee mode"},
+		{"foreign fee address", func(e *migration.Export) {
+			e.Users[0].UserFeeAddr = pks[1].AddressPubKeyHash().Address()
+		}, "not derived from the fee account key"},
+		{"fee index", func(e *migration.Export) {
+			*e.Users[0].FeeIndex++
+		}, "not derived from the fee account key"},
+		{"no fee index", func(e *migration.Export) {
+			e.Users[0].FeeIndex = nil
+		}, "not derived from a fee account key"},
+	}
+	for _, test := range tests {
+		e := newExport()
+		test.modify(e)
+		n, err := mc.ImportUsers(context.Background(), nil, e)
+		if err == nil || n != 0 || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %d users and error %v, want error %q",
+				test.name, n, err, test.want)
+		}
+	}
+}
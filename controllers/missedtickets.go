@@ -0,0 +1,137 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/zenazn/goji/web"
+)
+
+// adminMissedPerPage is the number of missed tickets listed on each page of the
+// admin missed tickets page.
+const adminMissedPerPage = 100
+
+// missedCauseStat holds the number of missed tickets with the same cause and
+// their share of all missed tickets.
+type missedCauseStat struct {
+	Cause   string
+	Label   string
+	Count   int64
+	Percent float64
+}
+
+// missedStats orders the counts of missed tickets per cause as
+// models.MissedCauses, followed by any unknown causes, and returns them along
+// with the number of tickets missed by the voting service and by the network.
+// Causes without missed tickets are left out.
+func missedStats(counts []models.MissedCauseCount) ([]missedCauseStat, int64, int64) {
+	order := make(map[string]int, len(models.MissedCauses))
+	for i, cause := range models.MissedCauses {
+		order[cause] = i
+	}
+	rank := func(cause string) int {
+		if i, ok := order[cause]; ok {
+			return i
+		}
+		return len(order)
+	}
+
+	var total, byPool, byNetwork int64
+	stats := make([]missedCauseStat, 0, len(counts))
+	for _, c := range counts {
+		if c.Count == 0 {
+			continue
+		}
+		total += c.Count
+		if c.Cause == models.MissedCauseNetwork {
+			byNetwork += c.Count
+		} else {
+			byPool += c.Count
+		}
+		stats = append(stats, missedCauseStat{
+			Cause: c.Cause,
+			Label: models.MissedCauseLabel(c.Cause),
+			Count: c.Count,
+		})
+	}
+	for i := range stats {
+		stats[i].Percent = float64(stats[i].Count) * 100 / float64(total)
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		ri, rj := rank(stats[i].Cause), rank(stats[j].Cause)
+		if ri != rj {
+			return ri < rj
+		}
+		return stats[i].Cause < stats[j].Cause
+	})
+	return stats, byPool, byNetwork
+}
+
+// setMissedStatsEnv sets the anonymous breakdown of the causes of the tickets
+// missed by all users shown on the stats and admin missed tickets pages.
+func (controller *MainController) setMissedStatsEnv(c web.C) {
+	counts, err := models.GetMissedTicketCauseCounts(controller.GetReadDbMap(c))
+	if err != nil {
+		log.Warnf("unable to get missed ticket causes: %v", err)
+		return
+	}
+	stats, byPool, byNetwork := missedStats(counts)
+	c.Env["MissedStats"] = stats
+	c.Env["MissedByPoolCount"] = byPool
+	c.Env["MissedByNetworkCount"] = byNetwork
+}
+
+// AdminMissed renders the read-only administrative page listing the winning
+// tickets of all users whose vote was not mined along with the cause recorded
+// by stakepoold, most recent first, in pages of adminMissedPerPage.
+func (controller *MainController) AdminMissed(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	page, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	dbMap := controller.GetReadDbMap(c)
+	missed, err := models.GetMissedTickets(dbMap, (page-1)*adminMissedPerPage,
+		adminMissedPerPage)
+	if err != nil {
+		log.Errorf("unable to get missed tickets: %v", err)
+		return "/error", http.StatusSeeOther
+	}
+	missedCount := models.GetMissedTicketTotal(dbMap)
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminMissed"] = true
+	c.Env["Title"] = "Decred Voting Service - Missed Tickets (Admin)"
+
+	c.Env["DCRDataURL"] = controller.DCRDataURL
+	c.Env["MissedTickets"] = missed
+	c.Env["MissedCount"] = missedCount
+	controller.setMissedStatsEnv(c)
+	if page > 1 {
+		c.Env["PrevPage"] = page - 1
+	}
+	if page*adminMissedPerPage < missedCount {
+		c.Env["NextPage"] = page + 1
+	}
+
+	widgets := controller.Parse(t, "admin/missed", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
@@ -0,0 +1,264 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/decred/dcrstakepool/system"
+	"github.com/go-gorp/gorp"
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// readOnlyProbeTimeout is how long the database has to answer the write
+	// probe before it is considered to refuse writes.
+	readOnlyProbeTimeout = 5 * time.Second
+
+	// readOnlyRecoveryProbes is the number of successive write probes which
+	// must succeed before the automatic read-only mode is left, so that it
+	// does not flap while a failover is in progress.
+	readOnlyRecoveryProbes = 3
+
+	// readOnlyRetryAfter is the Retry-After header of the responses to the
+	// writes refused in read-only mode, in seconds.
+	readOnlyRetryAfter = "60"
+)
+
+// readOnlyPage is served instead of the pages which write to the database in
+// read-only mode.
+const readOnlyPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Decred Voting Service - Read-only</title>
+</head>
+<body>
+<h1>Changes are temporarily disabled</h1>
+<p>The voting service is read-only while its database is being switched over,
+so your change was not saved.  Please go back and try again in a few minutes.
+Your tickets are still being voted.</p>
+</body>
+</html>
+`
+
+// readOnlyWritePages are the pages which write to the database on GET, from the
+// links sent by email.
+var readOnlyWritePages = map[string]struct{}{
+	"/emailverify": {},
+	"/emailupdate": {},
+}
+
+// readOnlyAPICommands are the POST API commands which are allowed in read-only
+// mode: those of admins, so that it can be left and debugged, and
+// validateaddress, which does not write.
+var readOnlyAPICommands = map[string]struct{}{
+	"readonly":        {},
+	"maintenance":     {},
+	"debuglevel":      {},
+	"validateaddress": {},
+}
+
+// readOnlyMode holds whether the voting service refuses writes, either because
+// an admin enabled it or automatically while the database refuses writes.  The
+// zero value is not read-only.
+type readOnlyMode struct {
+	mtx       sync.Mutex
+	manual    bool
+	automatic bool
+	reason    string
+	since     time.Time
+	recovered int // successive successful probes while automatic
+}
+
+// enabled returns whether the voting service is read-only.
+func (m *readOnlyMode) enabled() bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.manual || m.automatic
+}
+
+// state returns the read-only mode as returned by the API.
+func (m *readOnlyMode) state() *poolapi.ReadOnly {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	s := &poolapi.ReadOnly{
+		Enabled:   m.manual || m.automatic,
+		Manual:    m.manual,
+		Automatic: m.automatic,
+		Reason:    m.reason,
+	}
+	if !m.since.IsZero() {
+		s.Since = m.since.Unix()
+	}
+	return s
+}
+
+// setManual enables or disables the manual read-only mode and returns whether
+// that changed it.
+func (m *readOnlyMode) setManual(enabled bool, now time.Time) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.manual == enabled {
+		return false
+	}
+	if !m.automatic {
+		m.since = now
+	}
+	m.manual = enabled
+	return true
+}
+
+// probed records the outcome of a write probe and returns whether it entered
+// or left the automatic read-only mode.  It is entered on the first failure
+// and left after readOnlyRecoveryProbes successes.
+func (m *readOnlyMode) probed(err error, now time.Time) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if err != nil {
+		m.recovered = 0
+		m.reason = err.Error()
+		if m.automatic {
+			return false
+		}
+		m.automatic = true
+		if !m.manual {
+			m.since = now
+		}
+		return true
+	}
+
+	if !m.automatic {
+		return false
+	}
+	m.recovered++
+	if m.recovered < readOnlyRecoveryProbes {
+		return false
+	}
+	m.automatic = false
+	m.reason = ""
+	m.recovered = 0
+	if !m.manual {
+		m.since = now
+	}
+	return true
+}
+
+// ReadOnly returns whether the voting service is read-only, so that background
+// jobs which write to the database are skipped.
+func (controller *MainController) ReadOnly() bool {
+	return controller.readOnly.enabled()
+}
+
+// CheckDBWrites probes whether the database accepts writes, and enters the
+// automatic read-only mode when it does not, e.g. during a MySQL failover.
+func (controller *MainController) CheckDBWrites(ctx context.Context, dbMap *gorp.DbMap) {
+	ctx, cancel := context.WithTimeout(ctx, readOnlyProbeTimeout)
+	defer cancel()
+	err := models.ProbeWrites(ctx, dbMap)
+	if !controller.readOnly.probed(err, time.Now()) {
+		return
+	}
+	if err != nil {
+		log.Errorf("Database refuses writes, entering read-only mode: %v", err)
+		return
+	}
+	log.Infof("Database accepts writes again, leaving read-only mode")
+}
+
+// readOnlyRefused returns whether r would write to the database and is refused
+// in read-only mode: all requests but GET and HEAD, except the API commands
+// in readOnlyAPICommands, and the pages in readOnlyWritePages.
+func readOnlyRefused(r *http.Request) bool {
+	path := r.URL.Path
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		_, ok := readOnlyWritePages[path]
+		return ok
+	}
+	if strings.HasPrefix(path, "/api/") {
+		command := path[strings.LastIndex(path, "/")+1:]
+		_, ok := readOnlyAPICommands[command]
+		return !ok
+	}
+	return true
+}
+
+// ReadOnlyGate is a middleware which refuses the requests which write to the
+// database while the voting service is read-only, answering them with a page
+// asking to try again later, or an API error for API requests.  Other
+// requests are served as usual, with a banner on the pages.
+func (controller *MainController) ReadOnlyGate(c *web.C, h http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if !controller.readOnly.enabled() {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if !readOnlyRefused(r) {
+			c.Env["ReadOnly"] = true
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", readOnlyRetryAfter)
+		if strings.HasPrefix(r.URL.Path, "/api") {
+			resp := system.NewAPIResponse("error", codes.Unavailable,
+				"voting service is temporarily read-only", nil)
+			resp.Errors = apiErrors(codes.Unavailable, errAPIReadOnly)
+			system.WriteAPIResponse(resp, http.StatusServiceUnavailable, w)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(readOnlyPage))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// APIReadOnly returns the state of the read-only mode to admins.
+func (controller *MainController) APIReadOnly(c web.C, r *http.Request) (*poolapi.ReadOnly, codes.Code, string, error) {
+	if _, err := controller.apiAdminID(c, r); err != nil {
+		return nil, codes.PermissionDenied, "read-only error", err
+	}
+	return controller.readOnly.state(), codes.OK, "read-only mode", nil
+}
+
+// APIReadOnlySet enables or disables the manual read-only mode as requested by
+// an admin with the enabled form value, which is parsed by strconv.ParseBool.
+// The automatic read-only mode is unaffected.  The change applies to this
+// dcrstakepool instance only and is lost on restart, where the readonly option
+// applies.
+func (controller *MainController) APIReadOnlySet(c web.C, r *http.Request) (*poolapi.ReadOnly, codes.Code, string, error) {
+	adminID, err := controller.apiAdminID(c, r)
+	if err != nil {
+		return nil, codes.PermissionDenied, "read-only error", err
+	}
+
+	enabled, err := strconv.ParseBool(r.FormValue("enabled"))
+	if err != nil {
+		return nil, codes.InvalidArgument, "read-only error",
+			newAPIError(poolapi.ErrInvalidArgument, "enabled",
+				"enabled must be true or false")
+	}
+
+	if controller.readOnly.setManual(enabled, time.Now()) {
+		action := "disable read-only mode"
+		if enabled {
+			action = "enable read-only mode"
+		}
+		log.Infof("admin user %d: %s", adminID, action)
+		controller.auditUserAction(c, r, adminID, action)
+	}
+
+	return controller.APIReadOnly(c, r)
+}
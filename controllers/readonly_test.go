@@ -0,0 +1,111 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zenazn/goji/web"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	var m readOnlyMode
+	now := time.Unix(1600000000, 0)
+	errReadOnly := errors.New("Error 1290: The MySQL server is running " +
+		"with the --read-only option")
+
+	if m.probed(nil, now) || m.enabled() {
+		t.Fatal("read-only without a failed probe")
+	}
+	if !m.probed(errReadOnly, now) || !m.enabled() {
+		t.Fatal("failed probe did not enter read-only mode")
+	}
+	if m.probed(errReadOnly, now.Add(time.Second)) {
+		t.Fatal("second failed probe changed the mode")
+	}
+	s := m.state()
+	if !s.Automatic || s.Manual || s.Reason != errReadOnly.Error() ||
+		s.Since != now.Unix() {
+		t.Fatalf("unexpected state %+v", s)
+	}
+
+	// A failure during recovery restarts it.
+	for i := 0; i < readOnlyRecoveryProbes-1; i++ {
+		if m.probed(nil, now) {
+			t.Fatalf("left read-only mode after %d probes", i+1)
+		}
+	}
+	m.probed(errReadOnly, now)
+	for i := 0; i < readOnlyRecoveryProbes-1; i++ {
+		m.probed(nil, now)
+	}
+	if !m.enabled() {
+		t.Fatal("left read-only mode after a failure during recovery")
+	}
+	if !m.probed(nil, now) || m.enabled() {
+		t.Fatal("did not leave read-only mode after recovery")
+	}
+
+	// The manual mode is kept when the database recovers.
+	if !m.setManual(true, now) || m.setManual(true, now) {
+		t.Fatal("wrong change of the manual mode")
+	}
+	m.probed(errReadOnly, now)
+	for i := 0; i < readOnlyRecoveryProbes; i++ {
+		m.probed(nil, now)
+	}
+	if s := m.state(); !s.Enabled || !s.Manual || s.Automatic {
+		t.Fatalf("unexpected state %+v", s)
+	}
+	m.setManual(false, now)
+	if m.enabled() {
+		t.Fatal("still read-only after disabling the manual mode")
+	}
+}
+
+func TestReadOnlyGate(t *testing.T) {
+	tests := []struct {
+		method, path string
+		wantCode     int
+	}{
+		{"GET", "/tickets", http.StatusOK},
+		{"HEAD", "/", http.StatusOK},
+		{"GET", "/api/v3/stats", http.StatusOK},
+		{"POST", "/voting", http.StatusServiceUnavailable},
+		{"POST", "/login", http.StatusServiceUnavailable},
+		{"GET", "/emailverify", http.StatusServiceUnavailable},
+		{"POST", "/api/v3/address", http.StatusServiceUnavailable},
+		{"POST", "/api/v3/readonly", http.StatusOK},
+		{"POST", "/api/v3/maintenance", http.StatusOK},
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	controller := &MainController{Cfg: &Config{}}
+	for _, enabled := range []bool{false, true} {
+		controller.readOnly.setManual(enabled, time.Now())
+		for _, test := range tests {
+			c := &web.C{Env: make(map[interface{}]interface{})}
+			r := httptest.NewRequest(test.method, test.path, nil)
+			w := httptest.NewRecorder()
+			controller.ReadOnlyGate(c, ok).ServeHTTP(w, r)
+			want := test.wantCode
+			if !enabled {
+				want = http.StatusOK
+			}
+			if w.Code != want {
+				t.Fatalf("%s %s (enabled %v): expected code %d but got %d",
+					test.method, test.path, enabled, want, w.Code)
+			}
+			if banner := c.Env["ReadOnly"] == true; banner != (enabled && w.Code == http.StatusOK) {
+				t.Fatalf("%s %s (enabled %v): banner %v", test.method,
+					test.path, enabled, banner)
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+	"github.com/zenazn/goji/web"
+)
+
+// scriptExpiryStep is what the script expiry policy does with the multisig
+// script of a user on a run.
+type scriptExpiryStep int
+
+const (
+	scriptKeep scriptExpiryStep = iota
+	scriptClearWarning
+	scriptWarn
+	scriptExpire
+)
+
+// nextScriptExpiryStep returns what to do with a multisig script which is old
+// enough to expire, given when its user was warned, or 0 if they were not,
+// and how many tickets were bought with it.  Scripts with tickets are kept
+// and their warning is cleared, and the others are expired grace after their
+// user was warned.
+func nextScriptExpiryStep(warned int64, tickets int, now time.Time, grace time.Duration) scriptExpiryStep {
+	switch {
+	case tickets > 0 && warned != 0:
+		return scriptClearWarning
+	case tickets > 0:
+		return scriptKeep
+	case warned == 0:
+		return scriptWarn
+	case !now.Before(time.Unix(warned, 0).Add(grace)):
+		return scriptExpire
+	}
+	return scriptKeep
+}
+
+// ExpireStaleScripts applies the script expiry policy: the users whose
+// multisig script was set up more than ScriptExpiry ago and never used to buy
+// tickets are warned by email, and their script is disabled
+// ScriptExpiryGrace after, so that it is not imported into new voting
+// wallets.  Users can reactivate their script with AddressReactivatePost.
+func (controller *MainController) ExpireStaleScripts(ctx context.Context, dbMap *gorp.DbMap) {
+	if controller.Cfg.ScriptExpiry <= 0 {
+		return
+	}
+	now := time.Now()
+	users, err := models.GetScriptExpiryCandidates(dbMap,
+		now.Add(-controller.Cfg.ScriptExpiry).Unix())
+	if err != nil {
+		log.Errorf("unable to get script expiry candidates: %v", err)
+		return
+	}
+	if len(users) == 0 {
+		return
+	}
+
+	msas := make([]string, len(users))
+	for i := range users {
+		msas[i] = users[i].MultiSigAddress
+	}
+	infos, err := controller.Cfg.StakepooldServers.BatchStakePoolUserInfo(ctx, msas)
+	if err != nil {
+		log.Warnf("unable to get tickets of script expiry candidates: %v", err)
+		return
+	}
+
+	grace := controller.Cfg.ScriptExpiryGrace
+	var expired int
+	for i := range users {
+		user := &users[i]
+		info, ok := infos[user.MultiSigAddress]
+		if !ok {
+			// The tickets are unknown, so the script must not expire.
+			continue
+		}
+		tickets := len(info.Tickets) + len(info.InvalidTickets)
+
+		switch nextScriptExpiryStep(user.ScriptExpiryWarned, tickets, now, grace) {
+		case scriptClearWarning:
+			if err := models.SetUserScriptExpiryWarned(dbMap, user.ID, 0); err != nil {
+				log.Errorf("unable to clear script expiry warning of user %d: %v",
+					user.ID, err)
+			}
+
+		case scriptWarn:
+			err := controller.Cfg.EmailSender.ScriptExpiryWarning(user.Email,
+				controller.Cfg.BaseURL, user.MultiSigAddress, now.Add(grace))
+			if err != nil {
+				// Scripts only expire after their user was warned.
+				log.Errorf("unable to warn user %d about script expiry: %v",
+					user.ID, err)
+				continue
+			}
+			if err := models.SetUserScriptExpiryWarned(dbMap, user.ID, now.Unix()); err != nil {
+				log.Errorf("unable to record script expiry warning of user %d: %v",
+					user.ID, err)
+			}
+
+		case scriptExpire:
+			es, err := models.ExpireUserScript(dbMap, user, now.Unix())
+			if err != nil {
+				log.Errorf("unable to expire script %s of user %d: %v",
+					user.MultiSigAddress, user.ID, err)
+				continue
+			}
+			if es == nil {
+				continue
+			}
+			expired++
+			log.Infof("Expired unused script %s of user %d", es.MultiSigAddress,
+				user.ID)
+			err = controller.Cfg.EmailSender.ScriptExpired(user.Email,
+				controller.Cfg.BaseURL, es.MultiSigAddress)
+			if err != nil {
+				log.Warnf("unable to notify user %d about script expiry: %v",
+					user.ID, err)
+			}
+		}
+	}
+
+	if expired > 0 {
+		log.Infof("Expired %d unused scripts", expired)
+		if err := controller.StakepooldUpdateUsers(ctx, dbMap); err != nil {
+			log.Errorf("unable to update users on stakepoold: %v", err)
+		}
+	}
+}
+
+// AddressReactivatePost restores the multisig script of the user which was
+// disabled by ExpireStaleScripts.  The script is imported into the voting
+// wallets again, since wallets created since it expired do not have it.
+func (controller *MainController) AddressReactivatePost(c web.C, r *http.Request) (string, int) {
+	session := controller.GetSession(c)
+	user := controller.GetUser(c)
+	if user == nil {
+		return "/", http.StatusSeeOther
+	}
+	uid64 := user.ID
+
+	dbMap := controller.GetDbMap(c)
+	es, err := models.GetExpiredScriptByUserID(dbMap, uid64)
+	if err != nil {
+		log.Errorf("unable to get expired script of user %d: %v", uid64, err)
+		return "/error", http.StatusSeeOther
+	}
+	if es == nil {
+		return "/address", http.StatusSeeOther
+	}
+
+	script, err := hex.DecodeString(es.MultiSigScript)
+	if err != nil {
+		log.Errorf("invalid expired script of user %d: %v", uid64, err)
+		return "/error", http.StatusSeeOther
+	}
+	_, err = controller.Cfg.StakepooldServers.ImportNewScript(r.Context(), script)
+	if err != nil && !writeApplied(err) {
+		log.Errorf("unable to import script %s of user %d: %v",
+			es.MultiSigAddress, uid64, err)
+		session.AddFlash("Unable to reactivate your voting address, please "+
+			"try again later", "address")
+		return "/address", http.StatusSeeOther
+	}
+
+	if err := models.ReactivateExpiredScript(dbMap, es, time.Now().Unix()); err != nil {
+		log.Errorf("unable to reactivate script %s of user %d: %v",
+			es.MultiSigAddress, uid64, err)
+		session.AddFlash("Unable to reactivate your voting address", "address")
+		return "/address", http.StatusSeeOther
+	}
+	if err := controller.StakepooldUpdateUsers(r.Context(), dbMap); err != nil {
+		log.Errorf("unable to update all: %v", err)
+	}
+
+	log.Infof("user %d reactivated script %s", uid64, es.MultiSigAddress)
+	return "/address", http.StatusSeeOther
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	// signInChallengeLifetime is how long a sign-in challenge may be signed
+	// and submitted after it was issued.
+	signInChallengeLifetime = 5 * time.Minute
+
+	// maxSignInChallenges is the maximum number of outstanding sign-in
+	// challenges, which keeps the challenge store from growing without
+	// bound.
+	maxSignInChallenges = 10000
+)
+
+// errTooManyChallenges is returned when a sign-in challenge cannot be issued
+// because maxSignInChallenges are outstanding.
+var errTooManyChallenges = errors.New("too many outstanding sign-in challenges")
+
+// signInChallenge is a message which must be signed by the key of address
+// before expires to sign in.
+type signInChallenge struct {
+	address string
+	message string
+	expires time.Time
+}
+
+// signInChallenges stores the challenges issued to users signing in with a
+// Decred address until they are used or expire.  Each challenge can only be
+// used once.
+type signInChallenges struct {
+	mtx        sync.Mutex
+	challenges map[string]signInChallenge
+}
+
+func newSignInChallenges() *signInChallenges {
+	return &signInChallenges{
+		challenges: make(map[string]signInChallenge),
+	}
+}
+
+// issue creates a challenge for address and returns its nonce along with the
+// message to sign.  The message names baseURL so that signatures cannot be
+// used to sign in to other voting services.
+func (s *signInChallenges) issue(address, baseURL string, now time.Time) (string, string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for nonce, challenge := range s.challenges {
+		if !now.Before(challenge.expires) {
+			delete(s.challenges, nonce)
+		}
+	}
+	if len(s.challenges) >= maxSignInChallenges {
+		return "", "", errTooManyChallenges
+	}
+
+	nonce := models.NewUserToken().String()
+	message := fmt.Sprintf("Sign in to %s as %s with challenge %s", baseURL,
+		address, nonce)
+	s.challenges[nonce] = signInChallenge{
+		address: address,
+		message: message,
+		expires: now.Add(signInChallengeLifetime),
+	}
+	return nonce, message, nil
+}
+
+// consume removes the challenge with nonce and returns its message if it was
+// issued for address and has not expired.
+func (s *signInChallenges) consume(nonce, address string, now time.Time) (string, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	challenge, ok := s.challenges[nonce]
+	if !ok {
+		return "", false
+	}
+	delete(s.challenges, nonce)
+	if challenge.address != address || !now.Before(challenge.expires) {
+		return "", false
+	}
+	return challenge.message, true
+}
+
+// SignIn renders the page for signing in with a Decred address.
+func (controller *MainController) SignIn(c web.C, r *http.Request) (string, int) {
+	t := controller.GetTemplate(c)
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+
+	// Tell main.html what route is being rendered
+	c.Env["isLogin"] = true
+
+	c.Env["FlashError"] = session.Flashes("signinError")
+	if controller.Cfg.ClosePool {
+		c.Env["IsClosed"] = true
+	}
+	controller.setCaptchaEnv(c, captchaSignIn,
+		"To create a new account, first complete the captcha:")
+
+	widgets := controller.Parse(t, "auth/signin", c.Env)
+
+	c.Env["Title"] = "Decred VSP - Sign In"
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// SignInPost issues a challenge for the submitted address and shows the
+// message which must be signed with its key.
+func (controller *MainController) SignInPost(c web.C, r *http.Request) (string, int) {
+	address := r.FormValue("address")
+	session := controller.GetSession(c)
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+
+	log.Infof("SignIn POST from %v, address %v", remoteIP, address)
+
+	u, err := validateUserPubKeyAddr(address, controller.Cfg.NetParams)
+	if err != nil {
+		session.AddFlash(err.Error(), "signinError")
+		return controller.SignIn(c, r)
+	}
+
+	nonce, message, err := controller.signInChallenges.issue(address,
+		controller.Cfg.BaseURL, time.Now())
+	if err != nil {
+		log.Warnf("unable to issue sign-in challenge: %v", err)
+		session.AddFlash("Unable to sign in right now, please try again later",
+			"signinError")
+		return controller.SignIn(c, r)
+	}
+
+	// Wallets sign messages with the pubkey hash form of the address.
+	c.Env["Address"] = address
+	c.Env["SignAddress"] = u.(*dcrutil.AddressSecpPubKey).AddressPubKeyHash().Address()
+	c.Env["Nonce"] = nonce
+	c.Env["Message"] = message
+
+	return controller.SignIn(c, r)
+}
+
+// SignInVerifyPost verifies the signature of a sign-in challenge and logs the
+// user into the account bound to the signing address, creating the account if
+// none exists.
+func (controller *MainController) SignInVerifyPost(c web.C, r *http.Request) (string, int) {
+	address, nonce, signature := r.FormValue("address"), r.FormValue("nonce"),
+		r.FormValue("signature")
+
+	session := controller.GetSession(c)
+	dbMap := controller.GetDbMap(c)
+	remoteIP := getClientIP(r, controller.Cfg.RealIPHeader)
+
+	message, ok := controller.signInChallenges.consume(nonce, address, time.Now())
+	if !ok {
+		session.AddFlash("The sign-in challenge is invalid or has expired, "+
+			"please request a new one", "signinError")
+		return controller.SignIn(c, r)
+	}
+
+	u, err := validateUserPubKeyAddr(address, controller.Cfg.NetParams)
+	if err != nil {
+		session.AddFlash(err.Error(), "signinError")
+		return controller.SignIn(c, r)
+	}
+
+	valid, err := controller.Cfg.StakepooldServers.VerifyMessage(r.Context(), u,
+		message, signature)
+	if err != nil {
+		log.Errorf("unable to verify sign-in signature for %v: %v", address, err)
+		session.AddFlash("Unable to verify signature", "signinError")
+		return controller.SignIn(c, r)
+	}
+	if !valid {
+		log.Infof("%v sign-in failed with invalid signature, %v", address, remoteIP)
+		session.AddFlash("Invalid signature", "signinError")
+		return controller.SignIn(c, r)
+	}
+
+	users, err := models.GetUsersByUserPubKeyAddr(dbMap, address)
+	if err != nil {
+		log.Errorf("unable to get users by address %v: %v", address, err)
+		return "/error", http.StatusSeeOther
+	}
+
+	switch len(users) {
+	case 0:
+	case 1:
+		log.Infof("SignIn verified from %v, address %v, user %d", remoteIP,
+			address, users[0].ID)
+		session.Values["UserId"] = users[0].ID
+		controller.recordUserActivity(c, r, users[0].ID, activitySignIn,
+			address)
+		return "/tickets", http.StatusSeeOther
+	default:
+		session.AddFlash("This address was submitted by more than one "+
+			"account, please log in with your email address and password",
+			"signinError")
+		return controller.SignIn(c, r)
+	}
+
+	// No account is bound to the address yet, so create one.
+	if controller.Cfg.ClosePool {
+		log.Infof("attempt to register while registration disabled")
+		session.AddFlash(controller.Cfg.ClosePoolMsg, "signinError")
+		return controller.SignIn(c, r)
+	}
+
+	if controller.Cfg.InviteOnly {
+		session.AddFlash("No account uses this address. New accounts "+
+			"require an invite code, please register with your email "+
+			"address.", "signinError")
+		return controller.SignIn(c, r)
+	}
+
+	if !controller.captchaSolved(c, captchaSignIn) {
+		session.AddFlash("No account uses this address. Complete the "+
+			"captcha to create one.", "signinError")
+		return controller.SignIn(c, r)
+	}
+
+	reuse, err := controller.addressReuse(r.Context(), dbMap, 0, u)
+	if err != nil {
+		log.Warnf("unable to check reuse of address %s: %v", address, err)
+	}
+	if reuse != "" {
+		log.Warnf("SignIn from %v with reused address %s: %s", remoteIP,
+			address, reuse)
+		if controller.Cfg.RejectReusedAddrs {
+			session.AddFlash(reuse+". Please generate a new address "+
+				"in the wallet you will purchase tickets with.", "signinError")
+			return controller.SignIn(c, r)
+		}
+	}
+
+	// The new account is about to be created, so consume the CAPTCHA.
+	if !controller.consumeCaptcha(c, captchaSignIn) {
+		session.AddFlash("No account uses this address. Complete the "+
+			"captcha to create one.", "signinError")
+		return controller.SignIn(c, r)
+	}
+
+	// Accounts bound to an address have no email address to verify.
+	user := &models.User{
+		Username:        address,
+		EmailVerified:   1,
+		VoteBits:        1,
+		VoteBitsVersion: int64(controller.voteVersion),
+		Created:         time.Now().Unix(),
+	}
+
+	log.Infof("SignIn POST from %v, address %v. Inserting.", remoteIP, address)
+
+	if err = models.InsertUser(dbMap, user); err != nil {
+		log.Errorf("Error while registering user: %v", err)
+		session.AddFlash("Database error occurred while adding user", "signinError")
+		return controller.SignIn(c, r)
+	}
+
+	if msg := controller.setupUserAddress(r.Context(), dbMap, user.ID, address); msg != "" {
+		// The account cannot be signed in to without its address.
+		if err := models.DeleteUser(dbMap, user.ID); err != nil {
+			log.Errorf("unable to delete user %d: %v", user.ID, err)
+		}
+		session.AddFlash(msg, "signinError")
+		return controller.SignIn(c, r)
+	}
+
+	session.Values["UserId"] = user.ID
+
+	if reuse != "" {
+		session.AddFlash("Warning: "+reuse+". Please check that it was "+
+			"generated by the wallet you will purchase tickets with.",
+			"tickets")
+	}
+
+	return "/tickets", http.StatusSeeOther
+}
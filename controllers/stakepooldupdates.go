@@ -0,0 +1,51 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+)
+
+// maxStakepooldUpdateErrorLength is the length the recorded errors of failed
+// stakepoold updates are truncated to.
+const maxStakepooldUpdateErrorLength = 1000
+
+// SendStakepooldUpdates updates stakepoold with the users when changes of
+// users were recorded as a models.StakepooldUpdate, and removes the changes
+// once it was updated.  The changes are recorded in the transaction which
+// made them, so a change whose update failed or was interrupted by a restart
+// is sent by the next call, which server.go makes every minute.  Since
+// StakepooldUpdateUsers sends all users, the changes recorded before it was
+// called are all sent at once.
+func (controller *MainController) SendStakepooldUpdates(ctx context.Context, dbMap *gorp.DbMap) error {
+	updates, err := models.GetStakepooldUpdates(dbMap)
+	if err != nil {
+		return err
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	maxID := updates[len(updates)-1].ID
+
+	if err := controller.StakepooldUpdateUsers(ctx, dbMap); err != nil {
+		msg := truncateString(err.Error(), maxStakepooldUpdateErrorLength)
+		if err := models.RecordStakepooldUpdateFailure(dbMap, maxID, msg); err != nil {
+			log.Errorf("unable to record failed stakepoold update: %v", err)
+		}
+		return err
+	}
+
+	if err := models.DeleteStakepooldUpdates(dbMap, maxID); err != nil {
+		return err
+	}
+	if first := updates[0]; first.Attempts > 0 {
+		log.Infof("updated stakepoold with %d changes of users after %d "+
+			"failed attempts", len(updates), first.Attempts)
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"decred.org/dcrwallet/wallet/txrules"
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrstakepool/helpers"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+	"github.com/go-gorp/gorp"
+	"github.com/zenazn/goji/web"
+)
+
+// maxFeeTxSize is the size in bytes of the largest fee transaction accepted.
+// Fee transactions only need a few inputs and outputs.
+const maxFeeTxSize = 2000
+
+// TicketFeeInfo is the fee of a ticket shown on the tickets page.
+type TicketFeeInfo struct {
+	Ticket     string
+	FeeAddress string
+	FeeAmount  float64
+	FeeTxHash  string
+	Status     string
+	Deadline   time.Time
+	// Payable is set while a fee transaction may be submitted.
+	Payable bool
+}
+
+// FeeAddressForTicketFeeID generates a unique address for the fee of a
+// ticket when fees are deferred.  Ticket fee addresses are on the internal
+// branch of the fee xpub so they never collide with the fee addresses of
+// users on the external branch.
+func (controller *MainController) FeeAddressForTicketFeeID(id int64) (dcrutil.Address,
+	error) {
+	if id < 0 || id >= hdkeychain.HardenedKeyStart {
+		return nil, fmt.Errorf("bad ticket fee index %v", id)
+	}
+
+	branchKey, err := controller.Cfg.FeeXpub.Child(helpers.InternalBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := branchKey.Child(uint32(id))
+	if err != nil {
+		return nil, err
+	}
+
+	return helpers.DCRUtilAddressFromExtendedKey(key, controller.Cfg.NetParams)
+}
+
+// ticketFeeAmount returns the fee owed for a ticket bought for ticketPrice at
+// height, which is the same fee a ticket would commit to the voting service
+// without deferred fees.
+func ticketFeeAmount(ticketPrice dcrutil.Amount, height int64, poolFees float64,
+	params *chaincfg.Params) dcrutil.Amount {
+	return txrules.StakePoolTicketFee(ticketPrice, txrules.DefaultRelayFeePerKb,
+		int32(height), poolFees, params)
+}
+
+// checkFeeTx decodes the hex encoded fee transaction txHex and checks that it
+// is a regular transaction paying at least amount to feeAddr.
+func checkFeeTx(txHex string, feeAddr dcrutil.Address, amount dcrutil.Amount) (*wire.MsgTx, error) {
+	if len(txHex) > maxFeeTxSize*2 {
+		return nil, errors.New("the fee transaction is too large")
+	}
+	b, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, errors.New("the fee transaction is not hex encoded")
+	}
+	var tx wire.MsgTx
+	if err := tx.FromBytes(b); err != nil {
+		return nil, fmt.Errorf("invalid fee transaction: %v", err)
+	}
+	if stake.DetermineTxType(&tx, true) != stake.TxTypeRegular {
+		return nil, errors.New("the fee transaction is not a regular transaction")
+	}
+
+	feeScript, err := txscript.PayToAddrScript(feeAddr)
+	if err != nil {
+		return nil, err
+	}
+	var paid dcrutil.Amount
+	for _, out := range tx.TxOut {
+		if out.Version == 0 && bytes.Equal(out.PkScript, feeScript) {
+			paid += dcrutil.Amount(out.Value)
+		}
+	}
+	if paid < amount {
+		return nil, fmt.Errorf("the fee transaction pays %v to %v instead "+
+			"of %v", paid, feeAddr, amount)
+	}
+	return &tx, nil
+}
+
+// ticketFeeInfo returns the ticket fee info shown on the tickets page for fee.
+func ticketFeeInfo(fee *models.TicketFee, now time.Time) TicketFeeInfo {
+	deadline := time.Unix(fee.Deadline, 0)
+	return TicketFeeInfo{
+		Ticket:     fee.TicketHash,
+		FeeAddress: fee.FeeAddress,
+		FeeAmount:  dcrutil.Amount(fee.FeeAmount).ToCoin(),
+		FeeTxHash:  fee.FeeTxHash,
+		Status:     fee.Status,
+		Deadline:   deadline,
+		Payable: fee.Status == models.TicketFeePending &&
+			fee.FeeTxHash == "" && now.Before(deadline),
+	}
+}
+
+// userTicketFees returns the fees of the tickets of a user who owns tickets,
+// given with their height or 0 when it is unknown.  Fees are recorded for
+// tickets which have none yet, due by the feepaymentdeadline from now.
+func (controller *MainController) userTicketFees(ctx context.Context,
+	dbMap *gorp.DbMap, userID int64, tickets map[string]int64,
+	blockHeight int64) ([]TicketFeeInfo, error) {
+	fees, err := models.GetTicketFeesByUserID(dbMap, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := make(map[string]struct{}, len(fees))
+	for i := range fees {
+		recorded[fees[i].TicketHash] = struct{}{}
+	}
+	var hashes []chainhash.Hash
+	for ticket := range tickets {
+		if _, ok := recorded[ticket]; ok {
+			continue
+		}
+		hash, err := chainhash.NewHashFromStr(ticket)
+		if err != nil {
+			continue
+		}
+		hashes = append(hashes, *hash)
+	}
+
+	if len(hashes) > 0 {
+		amounts, err := controller.Cfg.StakepooldServers.GetTicketAmounts(ctx, hashes)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		for _, hash := range hashes {
+			a, ok := amounts[hash]
+			if !ok {
+				log.Warnf("unable to get the amounts of ticket %v for "+
+					"its fee", hash)
+				continue
+			}
+			height := tickets[hash.String()]
+			if height == 0 {
+				height = blockHeight
+			}
+			if height == 0 {
+				// The fee is recorded on a later visit.
+				continue
+			}
+			fee := models.TicketFee{
+				UserID:     userID,
+				TicketHash: hash.String(),
+				FeeAmount: int64(ticketFeeAmount(dcrutil.Amount(a.TicketPrice),
+					height, controller.Cfg.PoolFees, controller.Cfg.NetParams)),
+				Status:   models.TicketFeePending,
+				Created:  now.Unix(),
+				Deadline: now.Add(controller.Cfg.FeePaymentDeadline).Unix(),
+			}
+			err := models.InsertTicketFee(dbMap, &fee, func(id int64) (string, error) {
+				addr, err := controller.FeeAddressForTicketFeeID(id)
+				if err != nil {
+					return "", err
+				}
+				return addr.Address(), nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			log.Infof("recorded fee of %v for ticket %v of user %v due to %v",
+				dcrutil.Amount(fee.FeeAmount), fee.TicketHash, userID,
+				fee.FeeAddress)
+			fees = append([]models.TicketFee{fee}, fees...)
+		}
+	}
+
+	now := time.Now()
+	infos := make([]TicketFeeInfo, 0, len(fees))
+	for i := range fees {
+		if _, ok := tickets[fees[i].TicketHash]; !ok &&
+			fees[i].Status != models.TicketFeePending {
+			// Settled fees of tickets which were spent or revoked are
+			// no longer of interest.
+			continue
+		}
+		infos = append(infos, ticketFeeInfo(&fees[i], now))
+	}
+	return infos, nil
+}
+
+// TicketFeePost validates the fee transaction posted from the tickets page
+// for a ticket of the user, broadcasts it, and records it so that the fee is
+// marked paid once the transaction is mined.
+func (controller *MainController) TicketFeePost(c web.C, r *http.Request) (string, int) {
+	session := controller.GetSession(c)
+	user := controller.GetUser(c)
+	if user == nil {
+		return "/", http.StatusSeeOther
+	}
+	if controller.Cfg.FeeMode != models.FeeModeDeferred {
+		return "/tickets", http.StatusSeeOther
+	}
+	userID := user.ID
+	dbMap := controller.GetDbMap(c)
+
+	ticket := strings.TrimSpace(r.FormValue("ticket"))
+	fee, err := models.GetTicketFeeByTicketHash(dbMap, ticket)
+	if err != nil {
+		log.Errorf("GetTicketFeeByTicketHash failed for %v: %v", ticket, err)
+		session.AddFlash("Unable to look up the fee of the ticket", "ticketsError")
+		return "/tickets", http.StatusSeeOther
+	}
+	if fee == nil || fee.UserID != userID {
+		session.AddFlash("Unknown ticket", "ticketsError")
+		return "/tickets", http.StatusSeeOther
+	}
+	if !ticketFeeInfo(fee, time.Now()).Payable {
+		session.AddFlash("The fee of ticket "+ticket+" can no longer be paid",
+			"ticketsError")
+		return "/tickets", http.StatusSeeOther
+	}
+
+	feeAddr, err := dcrutil.DecodeAddress(fee.FeeAddress, controller.Cfg.NetParams)
+	if err != nil {
+		log.Errorf("invalid fee address %v of ticket %v in database: %v",
+			fee.FeeAddress, ticket, err)
+		return "/error", http.StatusSeeOther
+	}
+	txHex := strings.TrimSpace(r.FormValue("feetx"))
+	tx, err := checkFeeTx(txHex, feeAddr, dcrutil.Amount(fee.FeeAmount))
+	if err != nil {
+		session.AddFlash(err.Error(), "ticketsError")
+		return "/tickets", http.StatusSeeOther
+	}
+
+	txBytes, _ := tx.Bytes()
+	hash, err := controller.Cfg.StakepooldServers.SendRawTransaction(r.Context(), txBytes)
+	if err != nil {
+		log.Warnf("unable to broadcast fee transaction %v of ticket %v: %v",
+			tx.TxHash(), ticket, err)
+		session.AddFlash("The fee transaction was rejected by the network",
+			"ticketsError")
+		return "/tickets", http.StatusSeeOther
+	}
+
+	fee.FeeTxHash = hash.String()
+	fee.FeeTx = txHex
+	if err := models.UpdateTicketFee(dbMap, fee); err != nil {
+		log.Errorf("unable to record fee transaction %v of ticket %v: %v",
+			hash, ticket, err)
+		return "/error", http.StatusSeeOther
+	}
+
+	log.Infof("user %v paid the fee of ticket %v with transaction %v",
+		userID, ticket, hash)
+	session.AddFlash("The fee transaction "+hash.String()+" was broadcast. "+
+		"The fee is paid once it is mined.", "ticketsSuccess")
+	return "/tickets", http.StatusSeeOther
+}
+
+// UpdateTicketFees marks the pending ticket fees whose fee transaction was
+// mined as paid, and the fees whose deadline passed without a fee transaction
+// as expired.  A fee transaction submitted in time which is no longer known to
+// the network after the deadline, e.g. because it was double spent, also
+// expires the fee.
+func (controller *MainController) UpdateTicketFees(ctx context.Context, dbMap *gorp.DbMap) {
+	fees, err := models.GetPendingTicketFees(dbMap)
+	if err != nil {
+		log.Errorf("unable to get pending ticket fees: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range fees {
+		fee := &fees[i]
+		expired := now.Unix() > fee.Deadline
+		if fee.FeeTxHash != "" {
+			hash, err := chainhash.NewHashFromStr(fee.FeeTxHash)
+			if err != nil {
+				log.Errorf("invalid fee transaction hash %v of ticket %v "+
+					"in database: %v", fee.FeeTxHash, fee.TicketHash, err)
+				continue
+			}
+			confirmations, err := controller.Cfg.StakepooldServers.
+				GetTransactionConfirmations(ctx, hash)
+			switch {
+			case err == nil && confirmations > 0:
+				fee.Status = models.TicketFeePaid
+				fee.Paid = now.Unix()
+			case err == nil:
+				// Still in the mempool.
+				continue
+			case errors.Is(err, manager.ErrTxNotFound) && expired:
+				fee.Status = models.TicketFeeExpired
+			default:
+				if !errors.Is(err, manager.ErrTxNotFound) {
+					log.Warnf("unable to get the confirmations of fee "+
+						"transaction %v: %v", hash, err)
+				}
+				continue
+			}
+		} else {
+			if !expired {
+				continue
+			}
+			fee.Status = models.TicketFeeExpired
+		}
+
+		if err := models.UpdateTicketFee(dbMap, fee); err != nil {
+			log.Errorf("unable to update the fee of ticket %v: %v",
+				fee.TicketHash, err)
+			continue
+		}
+		log.Infof("fee of ticket %v of user %v is %s", fee.TicketHash,
+			fee.UserID, fee.Status)
+	}
+}
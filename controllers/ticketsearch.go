@@ -0,0 +1,103 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+)
+
+// adminTicketSearchPerPage is the number of tickets listed on each page of
+// the ticket search results of the admin tickets page.
+const adminTicketSearchPerPage = 50
+
+// adminTicketSearchResult is a ticket found by an admin ticket search, with
+// the set of tickets stakepoold found it in and the user it belongs to.
+// UserID is 0 when no user has the multisig address of the ticket.
+type adminTicketSearchResult struct {
+	Ticket          string
+	MultiSigAddress string
+	Set             string
+	UserID          int64
+	Email           string
+}
+
+// searchTickets returns the live, added, ignored and held tickets matching
+// query, which is a ticket hash, a multisig address or the email address of
+// a user.  The results are sorted by set and ticket so that they can be
+// paginated.  The errors are suitable for showing to the admin.
+func (controller *MainController) searchTickets(ctx context.Context, dbMap *gorp.DbMap, query string) ([]adminTicketSearchResult, error) {
+	var tickets []chainhash.Hash
+	var msas []string
+	if hash, err := chainhash.NewHashFromStr(query); err == nil &&
+		len(query) == chainhash.MaxHashStringSize {
+		tickets = append(tickets, *hash)
+	} else if strings.Contains(query, "@") {
+		user := models.GetUserByEmail(dbMap, query)
+		if user == nil {
+			return nil, errors.New("no user with email " + query)
+		}
+		if user.MultiSigAddress == "" {
+			return nil, errors.New("user " + query + " has not submitted an address")
+		}
+		msas = append(msas, user.MultiSigAddress)
+	} else {
+		msas = append(msas, query)
+	}
+
+	found, err := controller.Cfg.StakepooldServers.LookupTickets(ctx, tickets, msas)
+	if err != nil {
+		log.Errorf("LookupTickets failed: %v", err)
+		return nil, errors.New("could not look up tickets in stakepoold")
+	}
+
+	foundMSAs := make([]string, 0, len(found))
+	seen := make(map[string]struct{})
+	for _, f := range found {
+		if _, ok := seen[f.Address]; !ok {
+			seen[f.Address] = struct{}{}
+			foundMSAs = append(foundMSAs, f.Address)
+		}
+	}
+	users, err := models.GetUsersByMultiSigAddresses(dbMap, foundMSAs)
+	if err != nil {
+		log.Errorf("GetUsersByMultiSigAddresses failed: %v", err)
+		return nil, errors.New("could not look up the users of the tickets")
+	}
+	usersByMSA := make(map[string]models.User, len(users))
+	for _, user := range users {
+		usersByMSA[user.MultiSigAddress] = user
+	}
+
+	results := make([]adminTicketSearchResult, 0, len(found))
+	for _, f := range found {
+		hash, err := chainhash.NewHash(f.Hash)
+		if err != nil {
+			log.Warnf("NewHash failed for %v: %v", f.Hash, err)
+			continue
+		}
+		user := usersByMSA[f.Address]
+		results = append(results, adminTicketSearchResult{
+			Ticket:          hash.String(),
+			MultiSigAddress: f.Address,
+			Set:             f.Set,
+			UserID:          user.ID,
+			Email:           user.Email,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Set != results[j].Set {
+			return results[i].Set < results[j].Set
+		}
+		return results[i].Ticket < results[j].Ticket
+	})
+	return results, nil
+}
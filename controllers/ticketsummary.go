@@ -0,0 +1,155 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v3"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/poolapi"
+)
+
+// ticketAmountsCache holds the amounts of tickets and votes fetched from
+// stakepoold.  Mined transactions never change, so entries are kept for the
+// lifetime of the process.  It is safe for concurrent use.
+type ticketAmountsCache struct {
+	sync.Mutex
+	amounts map[chainhash.Hash]*pb.TicketAmounts
+}
+
+// ticketAmounts returns the amounts of the passed tickets and votes, looking up
+// those which are not cached yet.  Transactions unknown to stakepoold are
+// omitted.
+func (controller *MainController) ticketAmounts(ctx context.Context, hashes []chainhash.Hash) (map[chainhash.Hash]*pb.TicketAmounts, error) {
+	cache := &controller.amountsCache
+	amounts := make(map[chainhash.Hash]*pb.TicketAmounts, len(hashes))
+	var missing []chainhash.Hash
+
+	cache.Lock()
+	for _, hash := range hashes {
+		if a, ok := cache.amounts[hash]; ok {
+			amounts[hash] = a
+			continue
+		}
+		missing = append(missing, hash)
+	}
+	cache.Unlock()
+
+	if len(missing) == 0 {
+		return amounts, nil
+	}
+
+	fetched, err := controller.Cfg.StakepooldServers.GetTicketAmounts(ctx, missing)
+	if err != nil {
+		return amounts, err
+	}
+
+	cache.Lock()
+	if cache.amounts == nil {
+		cache.amounts = make(map[chainhash.Hash]*pb.TicketAmounts)
+	}
+	for hash, a := range fetched {
+		cache.amounts[hash] = a
+		amounts[hash] = a
+	}
+	cache.Unlock()
+
+	return amounts, nil
+}
+
+// summaryHashes returns the hashes whose amounts are needed to summarize
+// tickets: the votes of voted tickets and the immature and live tickets
+// themselves.
+func summaryHashes(tickets []*pb.StakePoolUserTicket) []chainhash.Hash {
+	hashes := make([]chainhash.Hash, 0, len(tickets))
+	for _, ticket := range tickets {
+		var s string
+		switch ticket.Status {
+		case "voted":
+			s = ticket.SpentBy
+		case "immature", "live":
+			s = ticket.Ticket
+		default:
+			continue
+		}
+		hash, err := chainhash.NewHashFromStr(s)
+		if err != nil {
+			log.Warnf("NewHashFromStr failed for %v: %v", s, err)
+			continue
+		}
+		hashes = append(hashes, *hash)
+	}
+	return hashes
+}
+
+// ticketSummary totals the tickets of a user.  The payouts of a vote are
+// proportional to the amounts committed to the ticket, so the part of the user
+// payout earned by voting is UserPayout * Subsidy / (TicketPrice + Subsidy).
+func ticketSummary(tickets []*pb.StakePoolUserTicket, amounts map[chainhash.Hash]*pb.TicketAmounts) *poolapi.TicketSummary {
+	summary := &poolapi.TicketSummary{Total: len(tickets)}
+	var rewards, payouts, liveStake int64
+
+	lookup := func(s string) *pb.TicketAmounts {
+		hash, err := chainhash.NewHashFromStr(s)
+		if err != nil {
+			summary.AmountsIncomplete = true
+			return nil
+		}
+		a, ok := amounts[*hash]
+		if !ok {
+			summary.AmountsIncomplete = true
+			return nil
+		}
+		return a
+	}
+
+	for _, ticket := range tickets {
+		switch ticket.Status {
+		case "immature", "live":
+			if ticket.Status == "immature" {
+				summary.Immature++
+			} else {
+				summary.Live++
+			}
+			if a := lookup(ticket.Ticket); a != nil {
+				liveStake += a.TicketPrice
+			}
+		case "voted":
+			summary.Voted++
+			a := lookup(ticket.SpentBy)
+			if a == nil {
+				continue
+			}
+			payouts += a.UserPayout
+			if total := a.TicketPrice + a.Subsidy; total > 0 {
+				rewards += int64(float64(a.UserPayout) * float64(a.Subsidy) /
+					float64(total))
+			}
+		case "missed":
+			summary.Missed++
+		case "expired":
+			summary.Expired++
+		}
+	}
+
+	summary.Rewards = dcrutil.Amount(rewards).ToCoin()
+	summary.VotePayouts = dcrutil.Amount(payouts).ToCoin()
+	summary.LiveStake = dcrutil.Amount(liveStake).ToCoin()
+	return summary
+}
+
+// userTicketSummary summarizes the tickets of a user, looking up the amounts
+// of their tickets and votes.  The counts are still returned, with
+// AmountsIncomplete set, when the amounts are not available.
+func (controller *MainController) userTicketSummary(ctx context.Context, tickets []*pb.StakePoolUserTicket) *poolapi.TicketSummary {
+	amounts, err := controller.ticketAmounts(ctx, summaryHashes(tickets))
+	if err != nil {
+		log.Warnf("RPC GetTicketAmounts failed: %v", err)
+	}
+	return ticketSummary(tickets, amounts)
+}
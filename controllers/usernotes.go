@@ -0,0 +1,204 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	// maxUserNoteLength and maxSupportTicketLength are the maximum lengths
+	// in characters of the notes admins leave about users and of their
+	// external ticket system references.
+	maxUserNoteLength      = 4000
+	maxSupportTicketLength = 255
+)
+
+// parseUserNote validates a note about a user and its optional support ticket
+// reference as posted by an admin, and returns them trimmed.
+func parseUserNote(note, ticket string) (string, string, error) {
+	note, ticket = strings.TrimSpace(note), strings.TrimSpace(ticket)
+	if note == "" {
+		return "", "", errors.New("The note is empty")
+	}
+	if utf8.RuneCountInString(note) > maxUserNoteLength {
+		return "", "", errors.New("The note is longer than " +
+			strconv.Itoa(maxUserNoteLength) + " characters")
+	}
+	if utf8.RuneCountInString(ticket) > maxSupportTicketLength {
+		return "", "", errors.New("The ticket reference is longer than " +
+			strconv.Itoa(maxSupportTicketLength) + " characters")
+	}
+	return note, ticket, nil
+}
+
+// currentSupportTicket returns the support ticket reference of the most recent
+// of notes which has one, or "".  notes are ordered most recent first.
+func currentSupportTicket(notes []models.UserNote) string {
+	for i := range notes {
+		if notes[i].SupportTicket != "" {
+			return notes[i].SupportTicket
+		}
+	}
+	return ""
+}
+
+// adminUserURL returns the URL of the admin user page of the user with id.
+func adminUserURL(id int64) string {
+	return "/adminuser?user=" + strconv.FormatInt(id, 10)
+}
+
+// AdminUser renders the administrative page of a single user, looked up by the
+// ID or email address in the user query value.  It shows the account and its
+// tickets along with the notes which admins left about the user, so that
+// support teams keep the context of their requests in one place.
+func (controller *MainController) AdminUser(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	dbMap := controller.GetReadDbMap(c)
+
+	query := strings.TrimSpace(r.URL.Query().Get("user"))
+	c.Env["Query"] = query
+	if query != "" {
+		user := lookupUser(dbMap, query)
+		if user == nil {
+			session.AddFlash("No user with this ID or email address",
+				"adminUserError")
+		} else {
+			c.Env["UserInfo"] = user
+
+			userTickets, err := controller.userTicketCounts(r.Context(),
+				[]models.User{*user})
+			if err != nil {
+				log.Warnf("unable to get ticket counts of user %d: %v",
+					user.ID, err)
+			}
+			c.Env["UserTickets"] = userTickets[user.ID]
+
+			retired, err := models.GetRetiredScriptsByUserID(dbMap, user.ID)
+			if err != nil {
+				log.Warnf("unable to get retired scripts of user %d: %v",
+					user.ID, err)
+			}
+			c.Env["RetiredScripts"] = retired
+
+			notes, err := models.GetUserNotes(dbMap, user.ID)
+			if err != nil {
+				log.Errorf("unable to get notes of user %d: %v", user.ID, err)
+				session.AddFlash("Unable to get the notes of the user",
+					"adminUserError")
+			}
+			authors := make(map[int64]string)
+			for i := range notes {
+				id := notes[i].AdminUID
+				if _, ok := authors[id]; ok {
+					continue
+				}
+				if author, err := models.GetUserByID(dbMap, id); err == nil {
+					authors[id] = author.Email
+				} else {
+					authors[id] = "user " + strconv.FormatInt(id, 10)
+				}
+			}
+			c.Env["Notes"] = notes
+			c.Env["NoteAuthors"] = authors
+			c.Env["SupportTicket"] = currentSupportTicket(notes)
+		}
+	}
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminUsers"] = true
+	c.Env["Title"] = "Decred Voting Service - User (Admin)"
+
+	c.Env["FlashError"] = session.Flashes("adminUserError")
+	c.Env["FlashSuccess"] = session.Flashes("adminUserSuccess")
+	c.Env["MaxUserNoteLength"] = maxUserNoteLength
+	c.Env["MaxSupportTicketLength"] = maxSupportTicketLength
+	c.Env["AdminResetLinkLifetime"] = controller.Cfg.AdminResetLinkLifetime
+
+	widgets := controller.Parse(t, "admin/user", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// AdminUserPost adds a note about a user, with an optional reference to a
+// request in an external ticket system, or issues a password reset link on
+// behalf of the user, as posted from AdminUser.
+func (controller *MainController) AdminUserPost(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	dbMap := controller.GetDbMap(c)
+	adminID := controller.GetUser(c).ID
+
+	userID, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		session.AddFlash("Invalid user ID", "adminUserError")
+		return "/adminuser", http.StatusSeeOther
+	}
+	user, err := models.GetUserByID(dbMap, userID)
+	if err != nil {
+		session.AddFlash("No user with this ID", "adminUserError")
+		return "/adminuser", http.StatusSeeOther
+	}
+
+	if r.FormValue("action") == "passwordreset" {
+		return controller.adminPasswordReset(c, r, user)
+	}
+
+	note, ticket, err := parseUserNote(r.FormValue("note"),
+		r.FormValue("ticket"))
+	if err != nil {
+		session.AddFlash(err.Error(), "adminUserError")
+		return adminUserURL(user.ID), http.StatusSeeOther
+	}
+
+	err = models.InsertUserNote(dbMap, &models.UserNote{
+		UserID:        user.ID,
+		AdminUID:      adminID,
+		Note:          note,
+		SupportTicket: ticket,
+		Created:       time.Now().Unix(),
+	})
+	if err != nil {
+		log.Errorf("unable to add note about user %d: %v", user.ID, err)
+		session.AddFlash("Unable to add the note", "adminUserError")
+		return adminUserURL(user.ID), http.StatusSeeOther
+	}
+
+	log.Infof("admin user %d added a note about user %d", adminID, user.ID)
+	targets := []string{strconv.FormatInt(user.ID, 10)}
+	if ticket != "" {
+		targets = append(targets, ticket)
+	}
+	controller.auditAdminAction(c, r, "add user note", targets...)
+	session.AddFlash("Note added", "adminUserSuccess")
+
+	return adminUserURL(user.ID), http.StatusSeeOther
+}
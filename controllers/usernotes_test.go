@@ -0,0 +1,52 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrstakepool/models"
+)
+
+func TestParseUserNote(t *testing.T) {
+	tests := []struct {
+		note, ticket         string
+		wantNote, wantTicket string
+		wantErr              bool
+	}{
+		{" Lost seed, restored\n", " #1234 ", "Lost seed, restored", "#1234", false},
+		{"No ticket", "", "No ticket", "", false},
+		{strings.Repeat("ä", maxUserNoteLength), "", strings.Repeat("ä", maxUserNoteLength), "", false},
+		{" \n", "#1", "", "", true},
+		{strings.Repeat("a", maxUserNoteLength+1), "", "", "", true},
+		{"Note", strings.Repeat("1", maxSupportTicketLength+1), "", "", true},
+	}
+	for i, test := range tests {
+		note, ticket, err := parseUserNote(test.note, test.ticket)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%d: want error %v, got %v", i, test.wantErr, err)
+			continue
+		}
+		if note != test.wantNote || ticket != test.wantTicket {
+			t.Errorf("%d: want %q, %q, got %q, %q", i, test.wantNote,
+				test.wantTicket, note, ticket)
+		}
+	}
+}
+
+func TestCurrentSupportTicket(t *testing.T) {
+	notes := []models.UserNote{
+		{Note: "Followed up"},
+		{Note: "Asked for the seed", SupportTicket: "#2"},
+		{Note: "First contact", SupportTicket: "#1"},
+	}
+	if got := currentSupportTicket(notes); got != "#2" {
+		t.Fatalf("want #2, got %q", got)
+	}
+	if got := currentSupportTicket(notes[:1]); got != "" {
+		t.Fatalf("want no ticket, got %q", got)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrstakepool/helpers"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+)
+
+// voteBitsMigrationChunk is the number of users whose vote bits are checked
+// and updated in each transaction of the vote bits migration.
+const voteBitsMigrationChunk = 500
+
+// defaultVoteBits approves the previous block and abstains on all agendas.
+const defaultVoteBits = uint16(1)
+
+// voteBitsMigrationVars publishes the progress of the running or last vote
+// bits migration under /debug/vars of the profile option.
+var voteBitsMigrationVars = expvar.NewMap("votebitsmigration")
+
+// VoteBitsMigrationProgress describes the progress of a vote bits migration.
+type VoteBitsMigrationProgress struct {
+	VoteVersion uint32
+	Running     bool
+	Started     time.Time
+	Finished    time.Time
+	// Total is the number of users when the migration started, Checked the
+	// number of users checked so far, and Migrated and Reset the number of
+	// users whose choices were kept or who were reset to the default.
+	Total    int64
+	Checked  int64
+	Migrated int64
+	Reset    int64
+}
+
+// voteBitsMigration tracks the vote bits migration, so that it does not run
+// twice at once.  The zero value is ready to use.
+type voteBitsMigration struct {
+	mtx      sync.Mutex
+	progress VoteBitsMigrationProgress
+}
+
+// start marks a migration to voteVersion of total users as running and
+// returns whether none was running already.
+func (m *voteBitsMigration) start(voteVersion uint32, total int64, now time.Time) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.progress.Running {
+		return false
+	}
+	m.progress = VoteBitsMigrationProgress{
+		VoteVersion: voteVersion,
+		Running:     true,
+		Started:     now,
+		Total:       total,
+	}
+	m.publish()
+	return true
+}
+
+// update adds the users of a chunk to the progress and returns it.
+func (m *voteBitsMigration) update(checked, migrated, reset int64) VoteBitsMigrationProgress {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.progress.Checked += checked
+	m.progress.Migrated += migrated
+	m.progress.Reset += reset
+	m.publish()
+	return m.progress
+}
+
+// finish marks the migration as no longer running and returns its progress.
+func (m *voteBitsMigration) finish(now time.Time) VoteBitsMigrationProgress {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.progress.Running = false
+	m.progress.Finished = now
+	m.publish()
+	return m.progress
+}
+
+// publish sets voteBitsMigrationVars to the progress.  The mutex must be held.
+func (m *voteBitsMigration) publish() {
+	set := func(key string, value int64) {
+		v := new(expvar.Int)
+		v.Set(value)
+		voteBitsMigrationVars.Set(key, v)
+	}
+	running := int64(0)
+	if m.progress.Running {
+		running = 1
+	}
+	set("voteversion", int64(m.progress.VoteVersion))
+	set("running", running)
+	set("total", m.progress.Total)
+	set("checked", m.progress.Checked)
+	set("migrated", m.progress.Migrated)
+	set("reset", m.progress.Reset)
+}
+
+// current returns the progress of the running or last vote bits migration.
+func (m *voteBitsMigration) current() VoteBitsMigrationProgress {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.progress
+}
+
+// currentVoteBits returns the vote bits user votes with on the agendas of the
+// current vote version, along with the IDs of the agendas whose choice was
+// kept.  When the stored vote bits were set for another vote version, the
+// choices on agendas which are voted on again are migrated to the new agenda
+// definitions by helpers.MigrateVoteBits.  Invalid vote bits are replaced by
+// the default.
+func (controller *MainController) currentVoteBits(user *models.User) (uint16, []string) {
+	voteBits, kept := uint16(user.VoteBits), []string(nil)
+	if uint32(user.VoteBitsVersion) != controller.voteVersion {
+		voteBits, kept = helpers.MigrateVoteBits(controller.Cfg.NetParams,
+			uint32(user.VoteBitsVersion), controller.voteVersion, voteBits)
+	}
+	if !controller.IsValidVoteBits(voteBits) {
+		voteBits, kept = defaultVoteBits, nil
+	}
+	return voteBits, kept
+}
+
+// voteVersionMessage returns the message users with an address get when their
+// voting preferences were migrated to the current vote version.
+func (controller *MainController) voteVersionMessage(kept []string) string {
+	if len(kept) > 0 {
+		return fmt.Sprintf("The voting service now votes on the agendas of "+
+			"vote version %d. Your choices on %s, which are voted on again, "+
+			"were kept. Please review your voting preferences on the voting "+
+			"page.", controller.voteVersion, strings.Join(kept, ", "))
+	}
+	return fmt.Sprintf("The voting service now votes on the agendas of vote "+
+		"version %d. Your previous voting preferences no longer apply, "+
+		"please review them on the voting page.", controller.voteVersion)
+}
+
+// MigrateUserVoteBits stores the vote bits of currentVoteBits for every user
+// whose vote bits were set for another vote version or are invalid, and
+// notifies the users with an address whose vote bits were migrated.  Users are
+// updated in transactions of voteBitsMigrationChunk users, so that a large
+// pool is not blocked for minutes after a new vote version activates, and the
+// progress is logged after every chunk.  Until a user is migrated,
+// StakepooldUpdateUsers sends the migrated vote bits to stakepoold without
+// storing them.  stakepoold is updated once the migration changed any user.
+func (controller *MainController) MigrateUserVoteBits(ctx context.Context, dbMap *gorp.DbMap) error {
+	m := &controller.voteBitsMigration
+	if !m.start(controller.voteVersion, models.GetUserCount(dbMap), time.Now()) {
+		return nil
+	}
+	err := controller.migrateUserVoteBits(ctx, dbMap)
+	progress := m.finish(time.Now())
+	if err != nil {
+		return fmt.Errorf("vote bits migration to vote version %d stopped "+
+			"after %d of %d users: %v", controller.voteVersion,
+			progress.Checked, progress.Total, err)
+	}
+	if progress.Migrated == 0 && progress.Reset == 0 {
+		return nil
+	}
+	log.Infof("VoteBits migration to vote version %v done in %v: kept "+
+		"choices of %d users, reset %d users to the default VoteBits",
+		controller.voteVersion, progress.Finished.Sub(progress.Started).Round(time.Second),
+		progress.Migrated, progress.Reset)
+	return controller.StakepooldUpdateUsers(ctx, dbMap)
+}
+
+// migrateUserVoteBits runs the chunks of MigrateUserVoteBits.
+func (controller *MainController) migrateUserVoteBits(ctx context.Context,
+	dbMap *gorp.DbMap) error {
+	m := &controller.voteBitsMigration
+	var afterID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		users, err := models.GetUserVoteBitsAfter(dbMap, afterID,
+			voteBitsMigrationChunk)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+		afterID = users[len(users)-1].ID
+
+		var updates []models.VoteBitsUpdate
+		kept := make(map[int64][]string)
+		usersByID := make(map[int64]*models.User, len(users))
+		for i := range users {
+			user := &users[i]
+			usersByID[user.ID] = user
+			voteBits, k := controller.currentVoteBits(user)
+			if uint32(user.VoteBitsVersion) == controller.voteVersion &&
+				voteBits == uint16(user.VoteBits) {
+				continue
+			}
+			updates = append(updates, models.VoteBitsUpdate{
+				UserID:             user.ID,
+				OldVoteBits:        user.VoteBits,
+				OldVoteBitsVersion: user.VoteBitsVersion,
+				NewVoteBits:        int64(voteBits),
+				NewVoteBitsVersion: int64(controller.voteVersion),
+			})
+			kept[user.ID] = k
+		}
+		updated, err := models.UpdateUserVoteBits(dbMap, updates)
+		if err != nil {
+			return err
+		}
+
+		var migrated, reset int64
+		for _, id := range updated {
+			user := usersByID[id]
+			versionChanged := uint32(user.VoteBitsVersion) != controller.voteVersion
+			switch {
+			case len(kept[id]) > 0:
+				migrated++
+			case !versionChanged || uint16(user.VoteBits) != defaultVoteBits:
+				reset++
+			}
+			log.Debugf("updated VoteBits of uid %d from %d of vote version "+
+				"%d, kept choices on agendas %v", id, user.VoteBits,
+				user.VoteBitsVersion, kept[id])
+			if versionChanged && user.MultiSigAddress != "" {
+				notifyUser(dbMap, id, models.MessageKindVoteVersion,
+					"New voting agendas", controller.voteVersionMessage(kept[id]))
+			}
+		}
+
+		progress := m.update(int64(len(users)), migrated, reset)
+		if len(updated) > 0 {
+			log.Infof("VoteBits migration to vote version %d: checked %d "+
+				"of %d users, kept choices of %d, reset %d",
+				controller.voteVersion, progress.Checked, progress.Total,
+				progress.Migrated, progress.Reset)
+		}
+	}
+}
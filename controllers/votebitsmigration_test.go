@@ -0,0 +1,79 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrstakepool/models"
+)
+
+func TestCurrentVoteBits(t *testing.T) {
+	agenda := func(id string, bit uint) chaincfg.ConsensusDeployment {
+		return chaincfg.ConsensusDeployment{Vote: chaincfg.Vote{
+			Id:   id,
+			Mask: 3 << bit,
+			Choices: []chaincfg.Choice{
+				{Id: "abstain", Bits: 0, IsAbstain: true},
+				{Id: "no", Bits: 1 << bit, IsNo: true},
+				{Id: "yes", Bits: 2 << bit},
+			},
+		}}
+	}
+	controller := &MainController{
+		Cfg: &Config{NetParams: &chaincfg.Params{
+			Deployments: map[uint32][]chaincfg.ConsensusDeployment{
+				7: {agenda("a", 1), agenda("b", 3)},
+				8: {agenda("c", 1), agenda("a", 3)},
+			},
+		}},
+		voteVersion: 8,
+	}
+
+	tests := []struct {
+		voteBits, voteBitsVersion int64
+		want                      uint16
+		kept                      []string
+	}{
+		{1 | 16, 8, 1 | 16, nil},          // current and valid
+		{1 | 6, 8, 1, nil},                // current and invalid
+		{1 | 4, 7, 1 | 16, []string{"a"}}, // a yes migrated
+		{1 | 16, 7, 1, nil},               // only b yes
+	}
+	for _, test := range tests {
+		got, kept := controller.currentVoteBits(&models.User{
+			VoteBits:        test.voteBits,
+			VoteBitsVersion: test.voteBitsVersion,
+		})
+		if got != test.want || !reflect.DeepEqual(kept, test.kept) {
+			t.Errorf("currentVoteBits(%d, %d) = %d, %v, want %d, %v",
+				test.voteBits, test.voteBitsVersion, got, kept, test.want,
+				test.kept)
+		}
+	}
+}
+
+func TestVoteBitsMigrationProgress(t *testing.T) {
+	var m voteBitsMigration
+	now := time.Unix(1600000000, 0)
+	if !m.start(8, 1200, now) || m.start(8, 1200, now) {
+		t.Fatal("migration started twice")
+	}
+	m.update(500, 3, 2)
+	p := m.update(500, 1, 0)
+	if !p.Running || p.Checked != 1000 || p.Migrated != 4 || p.Reset != 2 {
+		t.Fatalf("unexpected progress %+v", p)
+	}
+	if p = m.finish(now.Add(time.Minute)); p.Running ||
+		!p.Finished.Equal(now.Add(time.Minute)) {
+		t.Fatalf("unexpected progress %+v", p)
+	}
+	if !m.start(8, 1200, now) || m.current().Checked != 0 {
+		t.Fatal("migration did not start over")
+	}
+}
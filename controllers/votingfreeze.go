@@ -0,0 +1,212 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	// votingFreezeRefreshInterval is how long the state of the voting
+	// preferences freeze is cached for the banner shown to users before it
+	// is read from the DB again.
+	votingFreezeRefreshInterval = 30 * time.Second
+
+	// votingFreezeHistory is the number of changes of the freeze shown on
+	// the admin voting page.
+	votingFreezeHistory = 20
+)
+
+// votingFreezeCache caches the current state of the voting preferences
+// freeze.
+type votingFreezeCache struct {
+	mtx     sync.Mutex
+	freeze  *models.VotingFreeze
+	fetched time.Time
+}
+
+// get returns the voting preferences freeze when voting is frozen, reading it
+// from the DB when it was cached longer than votingFreezeRefreshInterval.  The
+// cached state is kept when it cannot be read.
+func (v *votingFreezeCache) get(dbMap *gorp.DbMap, now time.Time) *models.VotingFreeze {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	if now.Sub(v.fetched) >= votingFreezeRefreshInterval {
+		v.fetched = now
+		freeze, err := models.GetVotingFreeze(dbMap)
+		if err != nil {
+			log.Errorf("unable to get voting freeze: %v", err)
+		} else {
+			v.freeze = freeze
+		}
+	}
+
+	if v.freeze == nil || v.freeze.Frozen == 0 {
+		return nil
+	}
+	return v.freeze
+}
+
+// invalidate makes the next get read the freeze from the DB.
+func (v *votingFreezeCache) invalidate() {
+	v.mtx.Lock()
+	v.fetched = time.Time{}
+	v.mtx.Unlock()
+}
+
+// applyVotingFreeze replaces the vote bits of users with those of the voting
+// preferences freeze when voting is frozen, so that the users' own preferences
+// are sent to stakepoold again once it is lifted.  The users are not updated in
+// the DB.
+func applyVotingFreeze(freeze *models.VotingFreeze, users map[int64]*models.User) {
+	if freeze == nil || freeze.Frozen == 0 {
+		return
+	}
+	for _, user := range users {
+		user.VoteBits = freeze.VoteBits
+	}
+}
+
+// ShowVotingFreeze is a middleware that shows a banner on every page while the
+// voting preferences of all users are frozen.
+func (controller *MainController) ShowVotingFreeze(c *web.C, h http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		dbMap := controller.GetDbMap(*c)
+		if freeze := controller.votingFreeze.get(dbMap, time.Now()); freeze != nil {
+			c.Env["VotingFreeze"] = freeze
+		}
+		h.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// AdminVoting renders the administrative page to freeze the voting preferences
+// of all users.
+func (controller *MainController) AdminVoting(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	dbMap := controller.GetDbMap(c)
+
+	freezes, err := models.GetVotingFreezes(dbMap, votingFreezeHistory)
+	if err != nil {
+		log.Errorf("unable to get voting freezes: %v", err)
+		session.AddFlash("Unable to get the voting freeze history",
+			"adminVotingError")
+	}
+
+	t := controller.GetTemplate(c)
+	c.Env["Admin"] = isAdmin
+	c.Env["IsAdminVoting"] = true
+	c.Env["Title"] = "Decred Voting Service - Voting (Admin)"
+
+	c.Env["FlashError"] = session.Flashes("adminVotingError")
+	c.Env["FlashSuccess"] = session.Flashes("adminVotingSuccess")
+
+	c.Env["Frozen"] = len(freezes) > 0 && freezes[0].Frozen != 0
+	c.Env["VotingFreezes"] = freezes
+	c.Env["FreezeVoteBits"] = controller.Cfg.FreezeVoteBits
+	c.Env["FreezePrefs"] = controller.votingPrefs(controller.Cfg.FreezeVoteBits)
+	c.Env["FreezeValid"] = controller.IsValidVoteBits(controller.Cfg.FreezeVoteBits)
+
+	widgets := controller.Parse(t, "admin/voting", c.Env)
+	c.Env["Designation"] = controller.Cfg.Designation
+
+	c.Env["Content"] = template.HTML(widgets)
+
+	return controller.Parse(t, "main", c.Env), http.StatusOK
+}
+
+// AdminVotingPost freezes or unfreezes the voting preferences of all users, as
+// posted from AdminVoting, and sends the resulting vote bits to stakepoold.
+func (controller *MainController) AdminVotingPost(c web.C, r *http.Request) (string, int) {
+	isAdmin, err := controller.isAdmin(c, r)
+	if !isAdmin {
+		log.Warnf("isAdmin check failed: %v", err)
+		return "", http.StatusUnauthorized
+	}
+
+	session := controller.GetSession(c)
+	dbMap := controller.GetDbMap(c)
+	adminID := controller.GetUser(c).ID
+
+	freeze := &models.VotingFreeze{
+		Reason:       truncateString(r.FormValue("reason"), 255),
+		UpdatedByUID: adminID,
+		Created:      time.Now().Unix(),
+	}
+
+	switch r.FormValue("action") {
+	case "freeze":
+		if freeze.Reason == "" {
+			session.AddFlash("Give a reason to freeze voting, which is "+
+				"shown to users", "adminVotingError")
+			return "/adminvoting", http.StatusSeeOther
+		}
+		voteBits := controller.Cfg.FreezeVoteBits
+		if !controller.IsValidVoteBits(voteBits) {
+			session.AddFlash("freezevotebits is not valid for the current "+
+				"agendas", "adminVotingError")
+			return "/adminvoting", http.StatusSeeOther
+		}
+		freeze.Frozen = 1
+		freeze.VoteBits = int64(voteBits)
+
+	case "unfreeze":
+
+	default:
+		session.AddFlash("Unknown action", "adminVotingError")
+		return "/adminvoting", http.StatusSeeOther
+	}
+
+	if err := models.InsertVotingFreeze(dbMap, freeze); err != nil {
+		log.Errorf("unable to save voting freeze: %v", err)
+		session.AddFlash("Unable to save the voting freeze", "adminVotingError")
+		return "/adminvoting", http.StatusSeeOther
+	}
+	controller.votingFreeze.invalidate()
+
+	if freeze.Frozen != 0 {
+		log.Criticalf("admin user %d FROZE the voting preferences of all "+
+			"users, all tickets now vote with votebits %d: %s", adminID,
+			freeze.VoteBits, freeze.Reason)
+		controller.auditAdminAction(c, r, fmt.Sprintf("freeze voting with "+
+			"votebits %d: %s", freeze.VoteBits, freeze.Reason), "all users")
+		session.AddFlash("Voting preferences frozen", "adminVotingSuccess")
+	} else {
+		log.Criticalf("admin user %d unfroze the voting preferences of all "+
+			"users, tickets vote with the preferences of their owners again",
+			adminID)
+		action := "unfreeze voting"
+		if freeze.Reason != "" {
+			action += ": " + freeze.Reason
+		}
+		controller.auditAdminAction(c, r, action, "all users")
+		session.AddFlash("Voting preferences unfrozen", "adminVotingSuccess")
+	}
+
+	if err := controller.StakepooldUpdateUsers(r.Context(), dbMap); err != nil {
+		log.Errorf("unable to update voting preferences on stakepoold: %v", err)
+		session.AddFlash("Unable to send the voting preferences to "+
+			"stakepoold, submit again to retry", "adminVotingError")
+	}
+
+	return "/adminvoting", http.StatusSeeOther
+}
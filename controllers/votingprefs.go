@@ -0,0 +1,215 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/decred/dcrstakepool/helpers"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/csrf"
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
+)
+
+// forceChoices returns voteBits with the choices the operator forces with the
+// forcedchoice option on agendas of the current vote version.
+func (controller *MainController) forceChoices(voteBits uint16) uint16 {
+	return helpers.ForceChoices(controller.Cfg.NetParams, controller.voteVersion,
+		voteBits, controller.Cfg.ForcedChoices)
+}
+
+// votingPrefs returns the exportable voting preferences for voteBits.
+// Agendas whose bits in voteBits match none of their choices are omitted.
+func (controller *MainController) votingPrefs(voteBits uint16) *poolapi.VotingPrefs {
+	prefs := &poolapi.VotingPrefs{
+		VoteVersion: controller.voteVersion,
+		VoteBits:    voteBits,
+		VoteChoices: make(map[string]string),
+	}
+	deployments := controller.getAgendas()
+	for i := range deployments {
+		vote := &deployments[i].Vote
+		for _, choice := range vote.Choices {
+			if voteBits&vote.Mask == choice.Bits {
+				prefs.VoteChoices[vote.Id] = choice.Id
+				break
+			}
+		}
+	}
+	return prefs
+}
+
+// parseVotingPrefs decodes exported voting preferences and validates them
+// against the agendas of the current vote version.  Agendas missing from the
+// choices abstain, and agendas with a choice forced by the operator take it.
+// The returned preferences include a choice for every agenda and the
+// resulting vote bits.
+func (controller *MainController) parseVotingPrefs(data []byte) (*poolapi.VotingPrefs, error) {
+	var imported poolapi.VotingPrefs
+	if err := json.Unmarshal(data, &imported); err != nil {
+		return nil, fmt.Errorf("invalid voting preferences: %v", err)
+	}
+	if imported.VoteVersion != controller.voteVersion {
+		return nil, fmt.Errorf("voting preferences are for vote version %d "+
+			"but the current vote version is %d", imported.VoteVersion,
+			controller.voteVersion)
+	}
+
+	deployments := controller.getAgendas()
+	known := make(map[string]struct{}, len(deployments))
+	voteBits := uint16(1)
+	for i := range deployments {
+		vote := &deployments[i].Vote
+		known[vote.Id] = struct{}{}
+		choiceID, chosen := imported.VoteChoices[vote.Id]
+		var found bool
+		for _, choice := range vote.Choices {
+			if (chosen && choice.Id == choiceID) || (!chosen && choice.IsAbstain) {
+				voteBits |= choice.Bits
+				found = true
+				break
+			}
+		}
+		if !found && chosen {
+			return nil, fmt.Errorf("unknown choice %q for agenda %s",
+				choiceID, vote.Id)
+		}
+	}
+	for agendaID := range imported.VoteChoices {
+		if _, ok := known[agendaID]; !ok {
+			return nil, fmt.Errorf("unknown agenda %s for vote version %d",
+				agendaID, controller.voteVersion)
+		}
+	}
+
+	voteBits = controller.forceChoices(voteBits)
+	if !controller.IsValidVoteBits(voteBits) {
+		return nil, errors.New("resulting votebits are invalid for current agendas")
+	}
+	return controller.votingPrefs(voteBits), nil
+}
+
+// importVotingPrefs saves the vote bits of imported voting preferences for a
+// user and updates the user voting config of stakepoold when they changed.
+func (controller *MainController) importVotingPrefs(ctx context.Context,
+	dbMap *gorp.DbMap, user *models.User, prefs *poolapi.VotingPrefs) error {
+	oldVoteBits := user.VoteBits
+	_, err := helpers.UpdateVoteBitsByID(dbMap, user.ID, prefs.VoteBits,
+		controller.voteVersion)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("imported voting preferences for user %d, voteBits %d to %d",
+		user.ID, oldVoteBits, prefs.VoteBits)
+	if uint16(oldVoteBits) != prefs.VoteBits {
+		if err := controller.SendStakepooldUpdates(ctx, dbMap); err != nil {
+			log.Errorf("unable to update all: %v", err)
+		}
+	}
+	return nil
+}
+
+// VotingPrefsPost previews or imports voting preferences posted from the
+// voting page.  A preview renders the voting page with the resulting choices
+// and vote bits without saving them.
+func (controller *MainController) VotingPrefsPost(c web.C, r *http.Request) (string, int) {
+	session := controller.GetSession(c)
+	c.Env[csrf.TemplateTag] = csrf.TemplateField(r)
+	dbMap := controller.GetDbMap(c)
+
+	user := controller.GetUser(c)
+	if user == nil {
+		return "/", http.StatusSeeOther
+	}
+
+	data := r.FormValue("prefs")
+	prefs, err := controller.parseVotingPrefs([]byte(data))
+	if err != nil {
+		session.AddFlash(err.Error(), "votingError")
+		return "/voting", http.StatusSeeOther
+	}
+
+	if r.FormValue("action") == "preview" {
+		c.Env["ImportPreview"] = prefs
+		c.Env["ImportData"] = data
+		return controller.Voting(c, r)
+	}
+
+	if err := controller.importVotingPrefs(r.Context(), dbMap, user, prefs); err != nil {
+		session.AddFlash("unable to save new voting preferences", "votingError")
+		return "/voting", http.StatusSeeOther
+	}
+	if uint16(user.VoteBits) != prefs.VoteBits {
+		controller.recordUserActivity(c, r, user.ID, activityVoting,
+			voteBitsChange(user.VoteBits, prefs.VoteBits))
+	}
+
+	session.AddFlash("Successfully imported voting preferences", "votingSuccess")
+	return "/voting", http.StatusSeeOther
+}
+
+// APIVotingPrefs returns the voting preferences of the user for export.
+func (controller *MainController) APIVotingPrefs(c web.C,
+	r *http.Request) (*poolapi.VotingPrefs, codes.Code, string, error) {
+	if c.Env["APIUserID"] == nil {
+		return nil, codes.Unauthenticated, "votingprefs error", errAPIInvalidToken
+	}
+
+	user, err := models.GetUserByID(controller.GetDbMap(c), c.Env["APIUserID"].(int64))
+	if err != nil {
+		return nil, codes.Internal, "votingprefs error",
+			newAPIError(poolapi.ErrInternal, "", "failed to get user from database")
+	}
+
+	return controller.votingPrefs(uint16(user.VoteBits)), codes.OK,
+		"voting preferences successfully retrieved", nil
+}
+
+// APIVotingPrefsImport imports the voting preferences posted as JSON in the
+// VotingPrefs form field and returns the resulting preferences.  When Preview
+// is "true" the preferences are only validated and not saved.
+func (controller *MainController) APIVotingPrefsImport(c web.C,
+	r *http.Request) (*poolapi.VotingPrefs, codes.Code, string, error) {
+	dbMap := controller.GetDbMap(c)
+
+	if c.Env["APIUserID"] == nil {
+		return nil, codes.Unauthenticated, "votingprefs error", errAPIInvalidToken
+	}
+
+	prefs, err := controller.parseVotingPrefs([]byte(r.FormValue("VotingPrefs")))
+	if err != nil {
+		return nil, codes.InvalidArgument, "votingprefs error",
+			wrapAPIError(poolapi.ErrInvalidVoteBits, "VotingPrefs", err)
+	}
+
+	if r.FormValue("Preview") == "true" {
+		return prefs, codes.OK, "voting preferences are valid", nil
+	}
+
+	user, err := models.GetUserByID(dbMap, c.Env["APIUserID"].(int64))
+	if err != nil {
+		return nil, codes.Internal, "votingprefs error",
+			newAPIError(poolapi.ErrInternal, "", "failed to get user from database")
+	}
+	if err := controller.importVotingPrefs(r.Context(), dbMap, user, prefs); err != nil {
+		return nil, codes.Internal, "votingprefs error",
+			newAPIError(poolapi.ErrInternal, "",
+				"failed to update voting prefs in database")
+	}
+	if uint16(user.VoteBits) != prefs.VoteBits {
+		controller.recordUserActivity(c, r, user.ID, activityVoting,
+			voteBitsChange(user.VoteBits, prefs.VoteBits))
+	}
+
+	return prefs, codes.OK, "successfully imported voting preferences", nil
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/zenazn/goji/web"
+)
+
+// walletSyncingMessage is shown to users who submit an address while voting
+// wallets are rescanning.
+const walletSyncingMessage = "The voting wallets are syncing, new addresses " +
+	"can be set up once they are done. Please try again in a few minutes."
+
+// walletsSyncing returns the number of stakepoold instances whose wallet was
+// rescanning when last checked.  Writes to them are queued until the rescan
+// is done, but new addresses are not set up meanwhile since they must be
+// imported into every wallet.
+func (controller *MainController) walletsSyncing() int {
+	return len(controller.Cfg.StakepooldServers.RescanningHosts())
+}
+
+// ShowWalletRescan is middleware which shows a banner while voting wallets
+// are rescanning.
+func (controller *MainController) ShowWalletRescan(c *web.C, h http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if n := controller.walletsSyncing(); n > 0 {
+			c.Env["WalletsSyncing"] = fmt.Sprintf("%d of %d", n,
+				len(controller.Cfg.StakepooldServers.Hosts()))
+		}
+		h.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
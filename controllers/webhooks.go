@@ -0,0 +1,603 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	// webhookTimeout is how long a webhook URL has to answer a delivery or
+	// the challenge sent on registration.
+	webhookTimeout = 10 * time.Second
+
+	// maxWebhookAttempts is the number of attempts to deliver a webhook
+	// event after which it is no longer retried.
+	maxWebhookAttempts = 10
+
+	// webhookRetryBaseDelay is the delay before the first retry of a webhook
+	// event.  It doubles with every failed attempt up to
+	// webhookRetryMaxDelay.
+	webhookRetryBaseDelay = time.Minute
+	webhookRetryMaxDelay  = time.Hour * 6
+
+	// webhookRetryBatchSize is the maximum number of webhook events sent at
+	// once.
+	webhookRetryBatchSize = 100
+
+	// webhookLogRetention is how long the delivered and given up webhook
+	// events are kept in the delivery log.
+	webhookLogRetention = time.Hour * 24 * 30
+
+	// webhookLogSize is the number of most recent webhook events listed on
+	// the settings page.
+	webhookLogSize = 20
+
+	// maxWebhookURLLength is the maximum length of a webhook URL.
+	maxWebhookURLLength = 500
+
+	// maxWebhookResponseSize is the part of the response to a delivery
+	// which is read, enough for the answer to the challenge.
+	maxWebhookResponseSize = 1024
+
+	// maxWebhookErrorLength is the length the recorded errors of failed
+	// deliveries are truncated to.
+	maxWebhookErrorLength = 1000
+)
+
+// Headers of webhook deliveries.  The signature is the hex encoded
+// HMAC-SHA256 of the body keyed with the secret of the webhook, prefixed with
+// "sha256=".
+const (
+	webhookEventHeader     = "X-Dcrstakepool-Event"
+	webhookDeliveryHeader  = "X-Dcrstakepool-Delivery"
+	webhookSignatureHeader = "X-Dcrstakepool-Signature"
+)
+
+// webhookEventChallenge is the event sent to verify a webhook URL on
+// registration.  The URL must answer with the challenge as the body.
+const webhookEventChallenge = "challenge"
+
+// webhookStateLowFee is the state of the immature and live tickets which
+// stakepoold ignores as low fee tickets.
+const webhookStateLowFee = "lowfee"
+
+// webhookStateEvents maps the states of tickets to the event sent when a
+// ticket enters them.  Immature tickets are tracked without notifying users.
+var webhookStateEvents = map[string]string{
+	"live":             models.WebhookEventLive,
+	"voted":            models.WebhookEventVoted,
+	"missed":           models.WebhookEventMissed,
+	"expired":          models.WebhookEventExpired,
+	webhookStateLowFee: models.WebhookEventLowFee,
+}
+
+// webhookEvent is the body of a webhook delivery.  Previous is the state the
+// ticket was in when it was last checked, empty for new tickets.
+type webhookEvent struct {
+	Event     string `json:"event"`
+	Ticket    string `json:"ticket,omitempty"`
+	Previous  string `json:"previous,omitempty"`
+	Challenge string `json:"challenge,omitempty"`
+	Time      int64  `json:"time"`
+}
+
+// webhookChange is a ticket whose state changed since it was last checked.
+type webhookChange struct {
+	Ticket string
+	From   string
+	To     string
+}
+
+var errWebhookAddress = errors.New("webhook URL resolves to a local or " +
+	"private network address")
+
+// webhookPrivateNets are the networks webhooks may not be delivered to, besides
+// loopback, link-local and multicast addresses.
+var webhookPrivateNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12",
+		"192.168.0.0/16", "100.64.0.0/10", "fc00::/7"} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// webhookAddressAllowed returns whether webhooks may be delivered to ip, i.e.
+// whether it is a public unicast address.  Users may not make the voting
+// service send requests into the network it runs in.
+func webhookAddressAllowed(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return false
+	}
+	for _, n := range webhookPrivateNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookDialControl refuses connections to the addresses webhooks may not be
+// delivered to.  It checks the resolved address, so that a host name cannot
+// be pointed at an internal address after the URL was validated.
+func webhookDialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !webhookAddressAllowed(ip) {
+		return errWebhookAddress
+	}
+	return nil
+}
+
+// newWebhookClient returns the HTTP client webhooks are delivered with.  It
+// does not follow redirects and only connects to public addresses.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: webhookDialControl,
+	}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     time.Minute,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// validateWebhookURL checks a webhook URL submitted by a user and returns it
+// normalized, or an error to show to the user.
+func validateWebhookURL(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", errors.New("Please enter the webhook URL")
+	}
+	if len(s) > maxWebhookURLLength {
+		return "", fmt.Errorf("The webhook URL may not be longer than %d "+
+			"characters", maxWebhookURLLength)
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Hostname() == "" {
+		return "", errors.New("The webhook URL is not valid")
+	}
+	if u.Scheme != "https" {
+		return "", errors.New("The webhook URL must use https")
+	}
+	if u.User != nil {
+		return "", errors.New("The webhook URL may not contain credentials")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !webhookAddressAllowed(ip) {
+		return "", errors.New("The webhook URL may not point to a local or " +
+			"private network address")
+	}
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// signWebhook returns the signature of a webhook delivery with body.
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// postWebhook delivers the signed event with body to a webhook URL and returns
+// the HTTP status and the beginning of the response body.  Responses other
+// than 2xx are errors.
+func postWebhook(ctx context.Context, client *http.Client, webhookURL, secret,
+	event, deliveryID string, body []byte) (int, []byte, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL,
+		bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dcrstakepool-webhook")
+	req.Header.Set(webhookEventHeader, event)
+	req.Header.Set(webhookDeliveryHeader, deliveryID)
+	req.Header.Set(webhookSignatureHeader, signWebhook(secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body,
+		maxWebhookResponseSize))
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, respBody, fmt.Errorf("webhook responded "+
+			"with HTTP status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+// verifyWebhook sends a challenge to a webhook URL and checks that it answers
+// with the challenge, proving that the user controls the URL and that it
+// expects deliveries.
+func verifyWebhook(ctx context.Context, client *http.Client, webhookURL, secret string) error {
+	challenge := models.NewUserToken().String()
+	body, err := json.Marshal(&webhookEvent{
+		Event:     webhookEventChallenge,
+		Challenge: challenge,
+		Time:      time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	_, resp, err := postWebhook(ctx, client, webhookURL, secret,
+		webhookEventChallenge, challenge, body)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(resp)) != challenge {
+		return errors.New("webhook did not answer with the challenge")
+	}
+	return nil
+}
+
+// webhookTicketState returns the state of ticket tracked for webhooks: its
+// status, or lowfee for the immature and live tickets in lowFee.
+func webhookTicketState(ticket *pb.StakePoolUserTicket, lowFee map[chainhash.Hash]string) string {
+	switch ticket.Status {
+	case "immature", "live":
+		hash, err := chainhash.NewHashFromStr(ticket.Ticket)
+		if err != nil {
+			break
+		}
+		if _, ok := lowFee[*hash]; ok {
+			return webhookStateLowFee
+		}
+	}
+	return ticket.Status
+}
+
+// webhookTicketChanges returns the tickets whose state differs from the state
+// recorded in states, keyed by ticket hash.
+func webhookTicketChanges(states map[string]*models.WebhookTicket,
+	tickets []*pb.StakePoolUserTicket, lowFee map[chainhash.Hash]string) []webhookChange {
+
+	var changes []webhookChange
+	for _, ticket := range tickets {
+		state := webhookTicketState(ticket, lowFee)
+		var from string
+		if recorded, ok := states[ticket.Ticket]; ok {
+			from = recorded.State
+		}
+		if state != from {
+			changes = append(changes, webhookChange{
+				Ticket: ticket.Ticket,
+				From:   from,
+				To:     state,
+			})
+		}
+	}
+	return changes
+}
+
+// queueWebhookEvent queues the event of a ticket state change to be delivered
+// to the webhook of a user.
+func queueWebhookEvent(dbMap *gorp.DbMap, userID int64, event string, change webhookChange, now time.Time) error {
+	body, err := json.Marshal(&webhookEvent{
+		Event:    event,
+		Ticket:   change.Ticket,
+		Previous: change.From,
+		Time:     now.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	return models.InsertWebhookDelivery(dbMap, &models.WebhookDelivery{
+		UserID:      userID,
+		Event:       event,
+		TicketHash:  change.Ticket,
+		Payload:     string(body),
+		Created:     now.Unix(),
+		NextAttempt: now.Unix(),
+	})
+}
+
+// checkWebhookUser records the changes to the states of the tickets of the user
+// of webhook and queues their events.  The states found by the first check of
+// a webhook are recorded without events.
+func checkWebhookUser(dbMap *gorp.DbMap, webhook *models.Webhook,
+	tickets []*pb.StakePoolUserTicket, lowFee map[chainhash.Hash]string, now time.Time) {
+
+	states, err := models.GetWebhookTickets(dbMap, webhook.UserID)
+	if err != nil {
+		log.Errorf("unable to get webhook tickets of user %d: %v",
+			webhook.UserID, err)
+		return
+	}
+
+	for _, change := range webhookTicketChanges(states, tickets, lowFee) {
+		state, ok := states[change.Ticket]
+		if !ok {
+			state = &models.WebhookTicket{
+				UserID:     webhook.UserID,
+				TicketHash: change.Ticket,
+			}
+		}
+		state.State = change.To
+		state.Updated = now.Unix()
+		if err := models.SaveWebhookTicket(dbMap, state); err != nil {
+			log.Errorf("unable to record webhook ticket %s of user %d: %v",
+				change.Ticket, webhook.UserID, err)
+			continue
+		}
+
+		event, ok := webhookStateEvents[change.To]
+		if !ok || webhook.Synced == 0 {
+			continue
+		}
+		if err := queueWebhookEvent(dbMap, webhook.UserID, event, change, now); err != nil {
+			log.Errorf("unable to queue %s webhook event of user %d: %v",
+				event, webhook.UserID, err)
+		}
+	}
+
+	if err := models.SetWebhookSynced(dbMap, webhook.ID, now.Unix()); err != nil {
+		log.Errorf("unable to record webhook check of user %d: %v",
+			webhook.UserID, err)
+	}
+}
+
+// CheckWebhookTickets queues the webhook events of the tickets of users with a
+// verified webhook whose state changed since the last check.
+func (controller *MainController) CheckWebhookTickets(ctx context.Context, dbMap *gorp.DbMap) {
+	webhooks, err := models.GetVerifiedWebhooks(dbMap)
+	if err != nil {
+		log.Errorf("unable to get webhooks: %v", err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	byAddress := make(map[string]*models.Webhook, len(webhooks))
+	msas := make([]string, 0, len(webhooks))
+	for i := range webhooks {
+		user, err := models.GetUserByID(dbMap, webhooks[i].UserID)
+		if err != nil {
+			log.Warnf("unable to get user %d of webhook: %v",
+				webhooks[i].UserID, err)
+			continue
+		}
+		if user.MultiSigAddress == "" {
+			continue
+		}
+		byAddress[user.MultiSigAddress] = &webhooks[i]
+		msas = append(msas, user.MultiSigAddress)
+	}
+	if len(msas) == 0 {
+		return
+	}
+
+	infos, err := controller.Cfg.StakepooldServers.BatchStakePoolUserInfo(ctx, msas)
+	if err != nil {
+		log.Warnf("unable to check webhook tickets: %v", err)
+		return
+	}
+	// Without the low fee tickets they would appear to change state, so the
+	// check is skipped.
+	lowFee, err := controller.Cfg.StakepooldServers.GetIgnoredLowFeeTickets(ctx)
+	if err != nil {
+		log.Warnf("unable to check webhook tickets: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for msa, webhook := range byAddress {
+		info, ok := infos[msa]
+		if !ok {
+			continue
+		}
+		checkWebhookUser(dbMap, webhook, info.Tickets, lowFee, now)
+	}
+}
+
+// recordWebhookFailure records a failed attempt to deliver a webhook event and
+// schedules the next one, unless the event failed maxWebhookAttempts times.
+func recordWebhookFailure(dbMap *gorp.DbMap, delivery *models.WebhookDelivery, status int, sendErr error) {
+	delivery.Attempts++
+	delivery.LastStatus = int64(status)
+	delivery.LastError = truncateString(sendErr.Error(), maxWebhookErrorLength)
+	delivery.NextAttempt = 0
+	if delivery.Attempts < maxWebhookAttempts {
+		delivery.NextAttempt = time.Now().Add(backoffDelay(delivery.Attempts,
+			webhookRetryBaseDelay, webhookRetryMaxDelay)).Unix()
+	} else {
+		log.Warnf("giving up on webhook event %d of user %d after %d "+
+			"attempts: %v", delivery.ID, delivery.UserID, delivery.Attempts,
+			sendErr)
+	}
+	if err := models.UpdateWebhookDelivery(dbMap, delivery); err != nil {
+		log.Errorf("unable to update webhook event %d: %v", delivery.ID, err)
+	}
+}
+
+// SendWebhookEvents delivers the webhook events which are due and removes the
+// events older than webhookLogRetention from the delivery log.
+func (controller *MainController) SendWebhookEvents(ctx context.Context, dbMap *gorp.DbMap) {
+	now := time.Now()
+	before := now.Add(-webhookLogRetention).Unix()
+	if _, err := models.DeleteWebhookDeliveriesBefore(dbMap, before); err != nil {
+		log.Errorf("unable to prune webhook delivery log: %v", err)
+	}
+
+	deliveries, err := models.GetDueWebhookDeliveries(dbMap, now.Unix(),
+		webhookRetryBatchSize)
+	if err != nil {
+		log.Errorf("unable to get webhook events: %v", err)
+		return
+	}
+
+	webhooks := make(map[int64]*models.Webhook)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		webhook, ok := webhooks[delivery.UserID]
+		if !ok {
+			webhook, err = models.GetWebhookByUserID(dbMap, delivery.UserID)
+			if err != nil {
+				log.Errorf("unable to get webhook of user %d: %v",
+					delivery.UserID, err)
+				continue
+			}
+			webhooks[delivery.UserID] = webhook
+		}
+		if webhook == nil || webhook.Verified == 0 {
+			delivery.NextAttempt = 0
+			delivery.LastError = "webhook removed"
+			if err := models.UpdateWebhookDelivery(dbMap, delivery); err != nil {
+				log.Errorf("unable to update webhook event %d: %v",
+					delivery.ID, err)
+			}
+			continue
+		}
+
+		status, _, err := postWebhook(ctx, controller.webhookClient,
+			webhook.URL, webhook.Secret, delivery.Event,
+			strconv.FormatInt(delivery.ID, 10), []byte(delivery.Payload))
+		if err != nil {
+			recordWebhookFailure(dbMap, delivery, status, err)
+			continue
+		}
+		delivery.Attempts++
+		delivery.LastStatus = int64(status)
+		delivery.LastError = ""
+		delivery.NextAttempt = 0
+		delivery.Delivered = time.Now().Unix()
+		if err := models.UpdateWebhookDelivery(dbMap, delivery); err != nil {
+			log.Errorf("unable to update webhook event %d: %v", delivery.ID,
+				err)
+		}
+	}
+}
+
+// webhooksEnabled returns whether the user of the request can use a webhook,
+// which requires the webhooks option and the webhooks feature flag when set.
+func (controller *MainController) webhooksEnabled(c web.C) bool {
+	return controller.Cfg.Webhooks &&
+		controller.FeatureEnabled(c, webhooksFeature, true)
+}
+
+// setWebhookEnv sets the webhook of a user and its most recent deliveries
+// shown on the settings page.
+func (controller *MainController) setWebhookEnv(c web.C, userID int64) {
+	c.Env["WebhooksEnabled"] = true
+	dbMap := controller.GetDbMap(c)
+	webhook, err := models.GetWebhookByUserID(dbMap, userID)
+	if err != nil {
+		log.Errorf("unable to get webhook of user %d: %v", userID, err)
+		return
+	}
+	c.Env["Webhook"] = webhook
+	deliveries, err := models.GetWebhookDeliveriesByUserID(dbMap, userID,
+		webhookLogSize)
+	if err != nil {
+		log.Errorf("unable to get webhook deliveries of user %d: %v", userID,
+			err)
+		return
+	}
+	c.Env["WebhookDeliveries"] = deliveries
+}
+
+// webhookPost registers or removes the webhook of a user as posted from the
+// settings page, and adds the outcome to the flashes of session.
+func (controller *MainController) webhookPost(c web.C, r *http.Request, userID int64) {
+	session := controller.GetSession(c)
+	dbMap := controller.GetDbMap(c)
+
+	switch r.FormValue("webhook") {
+	case "register":
+		webhookURL, err := validateWebhookURL(r.FormValue("webhookURL"))
+		if err != nil {
+			session.AddFlash(err.Error(), "settingsError")
+			return
+		}
+		// A new secret is generated on every registration, so that
+		// registering again replaces a leaked one.
+		secret := models.NewUserToken().String()
+		err = verifyWebhook(r.Context(), controller.webhookClient, webhookURL,
+			secret)
+		if err != nil {
+			log.Infof("webhook %s of user %d failed verification: %v",
+				webhookURL, userID, err)
+			session.AddFlash("The webhook URL did not answer the challenge: "+
+				err.Error(), "settingsError")
+			return
+		}
+		now := time.Now().Unix()
+		err = models.SetWebhook(dbMap, &models.Webhook{
+			UserID:   userID,
+			URL:      webhookURL,
+			Secret:   secret,
+			Created:  now,
+			Verified: now,
+		})
+		if err != nil {
+			log.Errorf("unable to set webhook of user %d: %v", userID, err)
+			session.AddFlash("Unable to register the webhook", "settingsError")
+			return
+		}
+		log.Infof("user %d registered webhook %s", userID, webhookURL)
+		session.AddFlash("Webhook registered. Any previous webhook and "+
+			"secret no longer work.", "settingsSuccess")
+
+	case "remove":
+		if err := models.DeleteWebhook(dbMap, userID); err != nil {
+			log.Errorf("unable to remove webhook of user %d: %v", userID, err)
+			session.AddFlash("Unable to remove the webhook", "settingsError")
+			return
+		}
+		log.Infof("user %d removed their webhook", userID)
+		session.AddFlash("Webhook removed", "settingsSuccess")
+
+	default:
+		session.AddFlash("Unknown webhook action", "settingsError")
+	}
+}
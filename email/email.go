@@ -7,16 +7,30 @@ package email
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/dajohi/goemail"
 )
 
+// FailureHandler is called with an email which could not be sent and the
+// error sending it.  When it returns nil the email was queued to be sent
+// later, and sending it does not fail.
+type FailureHandler func(emailaddress, subject, body string, err error) error
+
 // Sender holds information related to outgoing smtp mail.
 type Sender struct {
 	smtpFrom   string
 	smtpServer *goemail.SMTP
+	onFailure  FailureHandler
+}
+
+// SetFailureHandler sets the handler called with the emails which could not
+// be sent.
+func (s *Sender) SetFailureHandler(h FailureHandler) {
+	s.onFailure = h
 }
 
 // NewSender returns an initiated Sender to send emails with.
@@ -63,9 +77,14 @@ func NewSender(smtpHost string, smtpUsername string, smtpPassword string,
 	}, nil
 }
 
-// SendMail sends an email with the passed data using the system's SMTP
-// configuration.
-func (s *Sender) sendMail(emailaddress, subject, body string) error {
+// Send sends an email with the passed data using the system's SMTP
+// configuration.  Unlike the emails composed by Sender, the failure handler
+// is not called when it fails, so it is used to retry queued emails.
+func (s *Sender) Send(emailaddress, subject, body string) error {
+	if s.smtpServer == nil {
+		return errors.New("smtp server is not configured")
+	}
+
 	// Connect to the server, authenticate, set the sender and recipient,
 	// and send the email all in one step.
 	mailMsg := goemail.NewMessage(s.smtpFrom, subject, body)
@@ -77,6 +96,20 @@ func (s *Sender) sendMail(emailaddress, subject, body string) error {
 	return s.smtpServer.Send(mailMsg)
 }
 
+// sendMail sends an email and passes it to the failure handler, if any, when
+// sending fails.  It only returns an error when the email was neither sent
+// nor queued.
+func (s *Sender) sendMail(emailaddress, subject, body string) error {
+	err := s.Send(emailaddress, subject, body)
+	if err == nil || s.onFailure == nil {
+		return err
+	}
+	if qerr := s.onFailure(emailaddress, subject, body, err); qerr != nil {
+		return fmt.Errorf("%v (unable to queue email: %v)", err, qerr)
+	}
+	return nil
+}
+
 // PasswordChangeRequest creates and sends a password reset email.
 func (s *Sender) PasswordChangeRequest(email, clientIP, baseURL, token string) error {
 	body := "A request to reset your password was made from IP address: " +
@@ -118,6 +151,25 @@ func (s *Sender) EmailChangeNotification(baseURL, currentEmail, newEmail, client
 	return s.sendMail(currentEmail, "Voting service email change", body)
 }
 
+// EmailChangeConfirmation creates and sends an email asking to confirm an
+// email change from the current address, which is required in addition to
+// the confirmation from the new address when the voting service is
+// configured to.
+func (s *Sender) EmailChangeConfirmation(baseURL, currentEmail, newEmail, clientIP, token string) error {
+	body := "A request was made to change the email address " +
+		"for your voting service account at " + baseURL +
+		" from " + currentEmail + " to " + newEmail + "\r\n\n" +
+		"The request was made from IP address " + clientIP + "\r\n\n" +
+		"If you made this request, confirm it by following the link " +
+		"below, as well as the link sent to the new address:\r\n\n" +
+		baseURL + "/emailupdate?t=" + token + "\r\n\n" +
+		"The above link expires an hour after this email was sent.\r\n\n" +
+		"If you did not make this request, do not follow the link and " +
+		"please contact the Voting service administrator immediately.\r\n"
+
+	return s.sendMail(currentEmail, "Voting service email change", body)
+}
+
 // PasswordChangeConfirm creates and sends a password change confirmation email.
 func (s *Sender) PasswordChangeConfirm(email, baseURL, clientIP string) error {
 	body := "Your voting service password for " + baseURL +
@@ -138,3 +190,36 @@ func (s *Sender) Registration(email, baseURL, clientIP, token string) error {
 
 	return s.sendMail(email, "Voting service provider email verification", body)
 }
+
+// ScriptExpiryWarning creates and sends an email warning that the voting
+// address of an account is disabled at expires unless tickets are bought with
+// it.
+func (s *Sender) ScriptExpiryWarning(email, baseURL, multiSigAddress string, expires time.Time) error {
+	body := "No tickets were purchased with the voting address " +
+		multiSigAddress + " of your voting service account at " + baseURL +
+		"\r\n\n" +
+		"To keep the voting wallets lean, unused voting addresses are " +
+		"disabled. Unless a ticket is purchased with it, your voting " +
+		"address will be disabled on " + expires.UTC().Format("2006-01-02") +
+		".\r\n\n" +
+		"A disabled voting address can be reactivated at any time from " +
+		"the address page:\r\n\n" +
+		baseURL + "/address\r\n\n" +
+		"Do not purchase tickets with a disabled voting address before " +
+		"reactivating it, or they may not be voted.\r\n"
+
+	return s.sendMail(email, "Voting service address expiry", body)
+}
+
+// ScriptExpired creates and sends an email notifying that the voting address
+// of an account was disabled.
+func (s *Sender) ScriptExpired(email, baseURL, multiSigAddress string) error {
+	body := "The voting address " + multiSigAddress + " of your voting " +
+		"service account at " + baseURL + " was disabled since no tickets " +
+		"were purchased with it.\r\n\n" +
+		"Do not purchase tickets with it before reactivating it from the " +
+		"address page, or they may not be voted:\r\n\n" +
+		baseURL + "/address\r\n"
+
+	return s.sendMail(email, "Voting service address disabled", body)
+}
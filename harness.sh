@@ -154,7 +154,7 @@ wallethost=${WALLET_RPC_LISTEN}
 walletcert=${WALLET_RPC_CERT}
 walletuser=${RPC_USER}
 walletpassword=${RPC_PASS}
-testnet=true
+network=testnet
 appdata=${NODES_ROOT}/stakepoold-${i}
 rpclisten=${STAKEPOOLD_RPC_LISTEN}
 debuglevel=debug
@@ -182,7 +182,7 @@ dbname=${MYSQL_DB}
 dbuser=${MYSQL_USER}
 dbpassword=${MYSQL_PASS}
 coldwalletextpub=${COLD_WALLET_PUB_KEY}
-testnet=true
+network=testnet
 smtphost=${DCRSTAKEPOOL_SMTP_HOST}
 smtpfrom=${DCRSTAKEPOOL_SMTP_FROM}
 adminips=${DCRSTAKEPOOL_ADMIN_IPS}
@@ -5,16 +5,25 @@
 package helpers
 
 import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
 	"github.com/decred/dcrd/chaincfg/v3"
 	"github.com/decred/dcrd/dcrec"
 	"github.com/decred/dcrd/dcrutil/v3"
 	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrd/txscript/v3"
 )
 
 const (
 	// ExternalBranch is a helper value that needs to
 	// match dcrwallet's udb.ExternalBranch
 	ExternalBranch uint32 = 0
+
+	// InternalBranch is a helper value that needs to
+	// match dcrwallet's udb.InternalBranch
+	InternalBranch uint32 = 1
 )
 
 // DCRUtilAddressFromExtendedKey parses the public address of a hd extended key
@@ -25,3 +34,102 @@ const (
 func DCRUtilAddressFromExtendedKey(key *hdkeychain.ExtendedKey, params *chaincfg.Params) (*dcrutil.AddressPubKeyHash, error) {
 	return dcrutil.NewAddressPubKeyHash(dcrutil.Hash160(key.SerializedPubKey()), params, dcrec.STEcdsaSecp256k1)
 }
+
+// ChildAddress returns the address of the first valid child of key at or after
+// index, and the index of that child.  Child keys are invalid with a
+// probability of less than 1 in 2^127, and are skipped in the same way by
+// dcrstakepool and stakepoold so that both derive the same addresses.
+func ChildAddress(key *hdkeychain.ExtendedKey, index uint32, params *chaincfg.Params) (*dcrutil.AddressPubKeyHash, uint32, error) {
+	for i := index; i < hdkeychain.HardenedKeyStart; i++ {
+		child, err := key.Child(i)
+		if errors.Is(err, hdkeychain.ErrInvalidChild) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		addr, err := DCRUtilAddressFromExtendedKey(child, params)
+		if err != nil {
+			return nil, 0, err
+		}
+		return addr, i, nil
+	}
+	return nil, 0, fmt.Errorf("no valid child at or after index %d", index)
+}
+
+// ChildAddressAtPosition returns the address at position of the addresses
+// derived from the valid children of key in order, and the index of its
+// child, given that the first valid child at or after index start is at
+// startPosition.  Pass 0 for both to count from the first child.
+func ChildAddressAtPosition(key *hdkeychain.ExtendedKey, position, startPosition, start uint32, params *chaincfg.Params) (*dcrutil.AddressPubKeyHash, uint32, error) {
+	if position < startPosition {
+		return nil, 0, fmt.Errorf("position %d is before start position %d",
+			position, startPosition)
+	}
+	index := start
+	for {
+		addr, child, err := ChildAddress(key, index, params)
+		if err != nil {
+			return nil, 0, err
+		}
+		if startPosition == position {
+			return addr, child, nil
+		}
+		startPosition++
+		index = child + 1
+	}
+}
+
+// MultisigScriptHash returns the hex encoded hash160 of the hex encoded redeem
+// script, which is the hash the P2SH ticket address commits to.
+func MultisigScriptHash(script string) (string, error) {
+	b, err := hex.DecodeString(script)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(dcrutil.Hash160(b)), nil
+}
+
+// VerifyMultisigScript checks that the hex encoded redeem script is the 1-of-2
+// multisig script of the public keys of poolPubKeyAddr and userPubKeyAddr, in
+// that order, and that ticketAddr is its P2SH address.  It returns an error
+// describing the first mismatch.
+func VerifyMultisigScript(script, ticketAddr, poolPubKeyAddr, userPubKeyAddr string,
+	params *chaincfg.Params) error {
+	b, err := hex.DecodeString(script)
+	if err != nil {
+		return fmt.Errorf("script is not hex encoded: %v", err)
+	}
+
+	p2sh, err := dcrutil.NewAddressScriptHash(b, params)
+	if err != nil {
+		return err
+	}
+	if p2sh.Address() != ticketAddr {
+		return fmt.Errorf("script hashes to %v instead of ticket address %v",
+			p2sh.Address(), ticketAddr)
+	}
+
+	class, addrs, reqSigs, err := txscript.ExtractPkScriptAddrs(0, b, params,
+		false)
+	if err != nil {
+		return err
+	}
+	if class != txscript.MultiSigTy || reqSigs != 1 || len(addrs) != 2 {
+		return fmt.Errorf("script is not a 1-of-2 multisig script")
+	}
+	want := []string{poolPubKeyAddr, userPubKeyAddr}
+	names := []string{"pool", "user"}
+	for i, addr := range addrs {
+		pk, ok := addr.(*dcrutil.AddressSecpPubKey)
+		if !ok {
+			return fmt.Errorf("%s key of script is not a secp256k1 key",
+				names[i])
+		}
+		if got := pk.AddressPubKeyHash().Address(); got != want[i] {
+			return fmt.Errorf("%s key of script has address %v instead "+
+				"of %v", names[i], got, want[i])
+		}
+	}
+	return nil
+}
@@ -4,11 +4,14 @@
 package helpers
 
 import (
+	"encoding/hex"
 	"errors"
 	"testing"
 
 	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
 	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrd/txscript/v3"
 )
 
 var (
@@ -80,3 +83,128 @@ func TestDCRUtilAddressFromExtendedKey(t *testing.T) {
 		}
 	}
 }
+
+func TestChildAddressAtPosition(t *testing.T) {
+	params := chaincfg.TestNet3Params()
+	key, err := hdkeychain.NewKeyFromString(xpubTestNet, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	branchKey, err := key.Child(ExternalBranch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		position, startPosition, start uint32
+		wantIndex                      uint32
+	}{
+		{0, 0, 0, 0},
+		{7, 0, 0, 7},
+		{7, 3, 3, 7},
+		{7, 7, 7, 7},
+		// A child was skipped before position 2, which is at index 3.
+		{5, 2, 3, 6},
+	}
+	for _, test := range tests {
+		addr, index, err := ChildAddressAtPosition(branchKey, test.position,
+			test.startPosition, test.start, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index != test.wantIndex || addr.Address() != childrenTestNet[index] {
+			t.Errorf("position %d from %d at %d: want child %d %v, got "+
+				"child %d %v", test.position, test.startPosition,
+				test.start, test.wantIndex, childrenTestNet[test.wantIndex],
+				index, addr.Address())
+		}
+	}
+
+	if _, _, err := ChildAddressAtPosition(branchKey, 2, 3, 3, params); err == nil {
+		t.Error("position before the start position did not fail")
+	}
+}
+
+func TestVerifyMultisigScript(t *testing.T) {
+	params := chaincfg.TestNet3Params()
+	key, err := hdkeychain.NewKeyFromString(xpubTestNet, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	branchKey, err := key.Child(ExternalBranch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pubKeys []*dcrutil.AddressSecpPubKey
+	for i := uint32(0); i < 2; i++ {
+		child, err := branchKey.Child(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, err := dcrutil.NewAddressSecpPubKey(child.SerializedPubKey(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubKeys = append(pubKeys, pk)
+	}
+	poolAddr := pubKeys[0].AddressPubKeyHash().Address()
+	userAddr := pubKeys[1].AddressPubKeyHash().Address()
+
+	multisig := func(pks ...*dcrutil.AddressSecpPubKey) (string, string) {
+		script, err := txscript.MultiSigScript(pks, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p2sh, err := dcrutil.NewAddressScriptHash(script, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hex.EncodeToString(script), p2sh.Address()
+	}
+	script, ticketAddr := multisig(pubKeys[0], pubKeys[1])
+	swapped, swappedAddr := multisig(pubKeys[1], pubKeys[0])
+	_, otherAddr := multisig(pubKeys[0], pubKeys[0])
+
+	tests := []struct {
+		name       string
+		script     string
+		ticketAddr string
+		wantErr    bool
+	}{{
+		name:       "valid",
+		script:     script,
+		ticketAddr: ticketAddr,
+	}, {
+		name:       "other ticket address",
+		script:     script,
+		ticketAddr: otherAddr,
+		wantErr:    true,
+	}, {
+		name:       "swapped keys",
+		script:     swapped,
+		ticketAddr: swappedAddr,
+		wantErr:    true,
+	}, {
+		name:       "not hex",
+		script:     "zz",
+		ticketAddr: ticketAddr,
+		wantErr:    true,
+	}}
+	for _, test := range tests {
+		err := VerifyMultisigScript(test.script, test.ticketAddr, poolAddr,
+			userAddr, params)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+
+	hash, err := MultisigScriptHash(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2sh, _ := dcrutil.DecodeAddress(ticketAddr, params)
+	if hash != hex.EncodeToString(p2sh.(*dcrutil.AddressScriptHash).Hash160()[:]) {
+		t.Fatalf("script hash %v does not match ticket address %v", hash,
+			ticketAddr)
+	}
+}
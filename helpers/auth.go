@@ -1,24 +1,28 @@
 package helpers
 
 import (
+	"time"
+
 	"github.com/decred/dcrstakepool/models"
 	"github.com/go-gorp/gorp"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // EmailChangeComplete checks that token is correct, updates a users email
-// based on their choice, and deletes the EmailChange row from the DB.
-func EmailChangeComplete(dbMap *gorp.DbMap, token models.UserToken) error {
+// based on their choice, records that it was changed at now, and deletes the
+// EmailChange row from the DB.  token may be either of the tokens of the
+// change.
+func EmailChangeComplete(dbMap *gorp.DbMap, token models.UserToken, now int64) error {
 	var emailChange models.EmailChange
 
 	err := dbMap.SelectOne(&emailChange,
-		"SELECT * FROM EmailChange WHERE Token = ?", token.String())
+		"SELECT * FROM EmailChange WHERE Token = ? OR OldToken = ?",
+		token.String(), token.String())
 	if err != nil {
 		return err
 	}
 
-	_, err = dbMap.Exec("UPDATE Users SET Email = ? WHERE UserId = ?",
-		emailChange.NewEmail, emailChange.UserID)
+	_, err = dbMap.Exec("UPDATE Users SET Email = ?, EmailChanged = ? WHERE UserId = ?",
+		emailChange.NewEmail, now, emailChange.UserID)
 	if err != nil {
 		return err
 	}
@@ -28,16 +32,19 @@ func EmailChangeComplete(dbMap *gorp.DbMap, token models.UserToken) error {
 		return err
 	}
 
-	_, err = dbMap.Exec("DELETE FROM EmailChange WHERE Token = ?", token.String())
+	_, err = dbMap.Exec("DELETE FROM EmailChange WHERE EmailChangeID = ?",
+		emailChange.ID)
 	return err
 }
 
-// EmailChangeTokenExists checks whether the token exists and returns the
-// EmailChange information if found in the DB.
+// EmailChangeTokenExists checks whether the token, sent to either the new or
+// the current address, exists and returns the EmailChange information if found
+// in the DB.
 func EmailChangeTokenExists(dbMap *gorp.DbMap, token models.UserToken) (*models.EmailChange, error) {
 	var emailChange models.EmailChange
 	err := dbMap.SelectOne(&emailChange,
-		"SELECT * FROM EmailChange WHERE Token = ?", token.String())
+		"SELECT * FROM EmailChange WHERE Token = ? OR OldToken = ?",
+		token.String(), token.String())
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +113,7 @@ func PasswordValidByID(dbMap *gorp.DbMap, id int64, password string) (*models.Us
 		return nil, err
 	}
 
-	err = bcrypt.CompareHashAndPassword(user.Password, []byte(password))
+	err = user.CheckPassword(password)
 	if err != nil {
 		return nil, err
 	}
@@ -132,23 +139,42 @@ func UpdateUserPasswordByID(dbMap *gorp.DbMap, id int64, password []byte) (*mode
 	return &user, err
 }
 
-// UpdateVoteBitsByID sets the user's vote bits specified by id to voteBits.
+// UpdateVoteBitsByID sets the user's vote bits specified by id to voteBits,
+// which were chosen on the agendas of voteVersion, and records the
+// StakepooldUpdate of the change in the same transaction when they changed.
 // Returns the User information if found in the DB.
-func UpdateVoteBitsByID(dbMap *gorp.DbMap, id int64, voteBits uint16) (*models.User, error) {
+func UpdateVoteBitsByID(dbMap *gorp.DbMap, id int64, voteBits uint16, voteVersion uint32) (*models.User, error) {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return nil, err
+	}
+
 	var user models.User
-	err := dbMap.SelectOne(&user, "SELECT * FROM Users WHERE UserId = ?", id)
+	err = tx.SelectOne(&user, "SELECT * FROM Users WHERE UserId = ?", id)
 	if err != nil {
+		tx.Rollback()
 		return nil, err
 	}
+	changed := user.VoteBits != int64(voteBits) ||
+		user.VoteBitsVersion != int64(voteVersion)
 
 	user.VoteBits = int64(voteBits)
+	user.VoteBitsVersion = int64(voteVersion)
 
-	_, err = dbMap.Update(&user)
-	if err != nil {
+	if _, err = tx.Update(&user); err != nil {
+		tx.Rollback()
 		return nil, err
 	}
+	if changed {
+		err = models.InsertStakepooldUpdate(tx, id,
+			models.StakepooldUpdateVoteBits, time.Now().Unix())
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
 
-	return &user, err
+	return &user, tx.Commit()
 }
 
 // UpdateVoteBitsVersionByID sets the user's vote bits version specified by id
@@ -182,9 +208,9 @@ func UserIDExists(dbMap *gorp.DbMap, userid int64) (*models.User, error) {
 }
 
 // Login looks up a user by email and validates the provided clear text password
-// against the bcrypt hashed password stored in the DB. Returns the *User and an
-// error. On failure *User is nil and error is non-nil. On success, error is
-// nil.
+// against the argon2id or legacy bcrypt hashed password stored in the DB.
+// Returns the *User and an error. On failure *User is nil and error is
+// non-nil. On success, error is nil.
 func Login(dbMap *gorp.DbMap, email string, password string) (*models.User, error) {
 	var user models.User
 	err := dbMap.SelectOne(&user, "SELECT * FROM Users WHERE Email = ?", email)
@@ -192,7 +218,7 @@ func Login(dbMap *gorp.DbMap, email string, password string) (*models.User, erro
 		return nil, err
 	}
 
-	err = bcrypt.CompareHashAndPassword(user.Password, []byte(password))
+	err = user.CheckPassword(password)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,131 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+)
+
+// MigrateVoteBits returns the vote bits for the agendas of vote version to
+// which make the same choices as voteBits made on the agendas of vote version
+// from, along with the IDs of the agendas whose choice was kept.  Agendas are
+// matched by their ID and choices by their ID, so choices carry over even when
+// their bits moved.  Agendas which were not voted on in version from, or whose
+// choice no longer exists, abstain.  Without any kept choice the result is the
+// default of approving the previous block and abstaining on all agendas.
+func MigrateVoteBits(params *chaincfg.Params, from, to uint32, voteBits uint16) (uint16, []string) {
+	oldChoices := make(map[string]string) // [agenda id]choice id
+	for _, d := range params.Deployments[from] {
+		for _, choice := range d.Vote.Choices {
+			if voteBits&d.Vote.Mask == choice.Bits {
+				if !choice.IsAbstain {
+					oldChoices[d.Vote.Id] = choice.Id
+				}
+				break
+			}
+		}
+	}
+
+	migrated := uint16(1)
+	var kept []string
+	for _, d := range params.Deployments[to] {
+		choiceID, chosen := oldChoices[d.Vote.Id]
+		var found bool
+		for _, choice := range d.Vote.Choices {
+			if chosen && choice.Id == choiceID {
+				migrated |= choice.Bits
+				kept = append(kept, d.Vote.Id)
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		for _, choice := range d.Vote.Choices {
+			if choice.IsAbstain {
+				migrated |= choice.Bits
+				break
+			}
+		}
+	}
+	return migrated, kept
+}
+
+// ParseForcedChoices parses the choices an operator forces on agendas, given
+// as agendaid:choiceid, into a map of agenda IDs to choice IDs.  Every agenda
+// must be defined by some vote version of params, with the choice among its
+// choices in each of them.
+func ParseForcedChoices(params *chaincfg.Params, specs []string) (map[string]string, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	forced := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("forced choice %q is not agendaid:choiceid",
+				spec)
+		}
+		agendaID, choiceID := parts[0], parts[1]
+		if other, ok := forced[agendaID]; ok && other != choiceID {
+			return nil, fmt.Errorf("agenda %s is forced to both %s and %s",
+				agendaID, other, choiceID)
+		}
+
+		var defined bool
+		for version, deployments := range params.Deployments {
+			for _, d := range deployments {
+				if d.Vote.Id != agendaID {
+					continue
+				}
+				defined = true
+				var found bool
+				for _, choice := range d.Vote.Choices {
+					if choice.Id == choiceID {
+						found = true
+						break
+					}
+				}
+				if !found {
+					return nil, fmt.Errorf("agenda %s of vote version "+
+						"%d has no choice %s", agendaID, version,
+						choiceID)
+				}
+			}
+		}
+		if !defined {
+			return nil, fmt.Errorf("unknown agenda %s", agendaID)
+		}
+		forced[agendaID] = choiceID
+	}
+	return forced, nil
+}
+
+// ForceChoices returns voteBits for the agendas of vote version with the
+// choices of forced, a map of agenda IDs to choice IDs parsed by
+// ParseForcedChoices, replacing the choices made on those agendas.  Forced
+// agendas which are not voted on in version are ignored.
+func ForceChoices(params *chaincfg.Params, version uint32, voteBits uint16, forced map[string]string) uint16 {
+	if len(forced) == 0 {
+		return voteBits
+	}
+	for _, d := range params.Deployments[version] {
+		choiceID, ok := forced[d.Vote.Id]
+		if !ok {
+			continue
+		}
+		for _, choice := range d.Vote.Choices {
+			if choice.Id == choiceID {
+				voteBits = voteBits&^d.Vote.Mask | choice.Bits
+				break
+			}
+		}
+	}
+	return voteBits
+}
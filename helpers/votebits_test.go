@@ -0,0 +1,116 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package helpers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+)
+
+// testAgenda returns an agenda with abstain, no and yes choices on the two
+// bits starting at bit.
+func testAgenda(id string, bit uint) chaincfg.ConsensusDeployment {
+	return chaincfg.ConsensusDeployment{
+		Vote: chaincfg.Vote{
+			Id:   id,
+			Mask: 3 << bit,
+			Choices: []chaincfg.Choice{
+				{Id: "abstain", Bits: 0, IsAbstain: true},
+				{Id: "no", Bits: 1 << bit, IsNo: true},
+				{Id: "yes", Bits: 2 << bit},
+			},
+		},
+	}
+}
+
+func TestMigrateVoteBits(t *testing.T) {
+	params := &chaincfg.Params{
+		Deployments: map[uint32][]chaincfg.ConsensusDeployment{
+			7: {testAgenda("a", 1), testAgenda("b", 3)},
+			// Agenda a moved to bits 3 and 4 and b was replaced by c.
+			8: {testAgenda("c", 1), testAgenda("a", 3)},
+		},
+	}
+
+	tests := []struct {
+		voteBits uint16
+		want     uint16
+		kept     []string
+	}{
+		{1, 1, nil},
+		{1 | 4, 1 | 16, []string{"a"}},     // a yes
+		{1 | 2 | 16, 1 | 8, []string{"a"}}, // a no, b yes
+		{1 | 16, 1, nil},                   // only b yes
+	}
+	for _, test := range tests {
+		got, kept := MigrateVoteBits(params, 7, 8, test.voteBits)
+		if got != test.want || !reflect.DeepEqual(kept, test.kept) {
+			t.Errorf("MigrateVoteBits(%d) = %d, %v, want %d, %v",
+				test.voteBits, got, kept, test.want, test.kept)
+		}
+	}
+
+	// Nothing carries over from an unknown vote version.
+	if got, kept := MigrateVoteBits(params, 6, 8, 1|4); got != 1 || kept != nil {
+		t.Errorf("got %d, %v from an unknown vote version", got, kept)
+	}
+}
+
+func TestForcedChoices(t *testing.T) {
+	params := &chaincfg.Params{
+		Deployments: map[uint32][]chaincfg.ConsensusDeployment{
+			7: {testAgenda("a", 1), testAgenda("b", 3)},
+			8: {testAgenda("c", 1), testAgenda("a", 3)},
+		},
+	}
+
+	for _, specs := range [][]string{
+		{"a"},
+		{"a:"},
+		{"d:yes"},
+		{"a:maybe"},
+		{"a:yes", "a:no"},
+	} {
+		if _, err := ParseForcedChoices(params, specs); err == nil {
+			t.Errorf("parsed invalid forced choices %q", specs)
+		}
+	}
+
+	forced, err := ParseForcedChoices(params, []string{"a:abstain", "b:yes",
+		"a:abstain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a": "abstain", "b": "yes"}
+	if !reflect.DeepEqual(forced, want) {
+		t.Fatalf("parsed %v, want %v", forced, want)
+	}
+
+	tests := []struct {
+		version  uint32
+		voteBits uint16
+		want     uint16
+	}{
+		{7, 1, 1 | 16},
+		{7, 1 | 4 | 8, 1 | 16},        // a yes, b no
+		{8, 1 | 2 | 16, 1 | 2},        // c no, a yes
+		{8, 1 | 4 | 8, 1 | 4},         // c yes, a no
+		{9, 1 | 2 | 16, 1 | 2 | 16},   // unknown vote version
+		{7, 0 | 2 | 8, 0 | 16},        // previous block disapproved
+		{8, 1 | 2 | 4 | 8, 1 | 2 | 4}, // invalid c is kept
+	}
+	for _, test := range tests {
+		got := ForceChoices(params, test.version, test.voteBits, forced)
+		if got != test.want {
+			t.Errorf("ForceChoices(%d, %d) = %d, want %d", test.version,
+				test.voteBits, got, test.want)
+		}
+	}
+	if got := ForceChoices(params, 7, 1|4, nil); got != 1|4 {
+		t.Errorf("got %d without forced choices", got)
+	}
+}
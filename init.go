@@ -0,0 +1,432 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"decred.org/dcrwallet/wallet/txrules"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrstakepool/internal/configcheck"
+	"github.com/decred/dcrstakepool/internal/netparams"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// initCommand is the subcommand which sets up a new voting service, e.g.
+// dcrstakepool init --network=testnet.
+const initCommand = "init"
+
+// initOptions are the options of the init subcommand.  The options which are
+// not passed on the command line are asked for unless noninteractive is set.
+type initOptions struct {
+	ConfigFile         string `short:"C" long:"configfile" description:"Path of the configuration file to write"`
+	Force              bool   `long:"force" description:"Overwrite an existing configuration file"`
+	NonInteractive     bool   `long:"noninteractive" description:"Do not ask for options and fail when a required option is not passed"`
+	Network            string `long:"network" description:"Network to use {mainnet, testnet, simnet}"`
+	BaseURL            string `long:"baseurl" description:"URL the voting service is reached at"`
+	PoolEmail          string `long:"poolemail" description:"Email address for support inquiries"`
+	PoolFees           string `long:"poolfees" description:"The per-ticket fees the user must send to the pool with their tickets"`
+	DBHost             string `long:"dbhost" description:"Hostname for database connection"`
+	DBPort             string `long:"dbport" description:"Port for database connection"`
+	DBUser             string `long:"dbuser" description:"Username for database connection"`
+	DBPassword         string `long:"dbpassword" description:"Password for database connection"`
+	DBName             string `long:"dbname" description:"Name of database"`
+	StakepooldHosts    string `long:"stakepooldhosts" description:"Comma separated hostnames of the stakepoold servers"`
+	StakepooldCerts    string `long:"stakepooldcerts" description:"Comma separated certificate paths of the stakepoold servers"`
+	ColdWalletExtPub   string `long:"coldwalletextpub" description:"The extended public key for addresses to which voting service user fees are sent"`
+	VotingWalletExtPub string `long:"votingwalletextpub" description:"The extended public key of the default account of the voting wallet"`
+	AdminIPs           string `long:"adminips" description:"Comma separated IPs admins connect from"`
+	AdminEmail         string `long:"adminemail" description:"Email address of the admin user to create"`
+	AdminPassword      string `long:"adminpassword" description:"Password of the admin user to create"`
+}
+
+// initConfigTemplate is the configuration file written by the init
+// subcommand.
+var initConfigTemplate = template.Must(template.New("config").Parse(`; Written by dcrstakepool init on {{.Date}}.  See sample-dcrstakepool.conf
+; for all options.
+{{if .Network}}
+network={{.Network}}
+{{end}}
+baseurl={{.BaseURL}}
+poolemail={{.PoolEmail}}
+poolfees={{.PoolFees}}
+
+apisecret={{.APISecret}}
+cookiesecret={{.CookieSecret}}
+
+dbhost={{.DBHost}}
+dbport={{.DBPort}}
+dbuser={{.DBUser}}
+dbpassword={{.DBPassword}}
+dbname={{.DBName}}
+
+stakepooldhosts={{.StakepooldHosts}}
+stakepooldcerts={{.StakepooldCerts}}
+
+coldwalletextpub={{.ColdWalletExtPub}}
+votingwalletextpub={{.VotingWalletExtPub}}
+
+adminips={{.AdminIPs}}
+adminuserids={{.AdminUserID}}
+`))
+
+// initConfig holds the values of the configuration file written by the init
+// subcommand.
+type initConfig struct {
+	initOptions
+	Date         string
+	APISecret    string
+	CookieSecret string
+	AdminUserID  int64
+}
+
+// initCommandRequested returns whether dcrstakepool was started with the init
+// subcommand.
+func initCommandRequested() bool {
+	return len(os.Args) > 1 && os.Args[1] == initCommand
+}
+
+// newSecret returns a random hex encoded 32 byte secret for apisecret and
+// cookiesecret.
+func newSecret() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// requireValue checks that a required option is not empty.
+func requireValue(value string) error {
+	if value == "" {
+		return errors.New("a value is required")
+	}
+	return nil
+}
+
+// validatePoolFees checks that value is a valid per-ticket fee percentage.
+func validatePoolFees(value string) error {
+	fees, err := strconv.ParseFloat(value, 64)
+	if err != nil || !txrules.ValidPoolFeeRate(fees) {
+		return fmt.Errorf("%q is not a valid fee percentage", value)
+	}
+	return nil
+}
+
+// extPubValidator returns a check that value is an extended public key of the
+// network described by params.
+func extPubValidator(params *chaincfg.Params) func(string) error {
+	return func(value string) error {
+		key, err := hdkeychain.NewKeyFromString(value, params)
+		if err != nil {
+			return fmt.Errorf("not an extended key of %s: %v", params.Name,
+				err)
+		}
+		if key.IsPrivate() {
+			return errors.New("an extended private key was given, the " +
+				"extended public key of the account is required")
+		}
+		return nil
+	}
+}
+
+// initPrompter asks for the options of the init subcommand which were not
+// passed on the command line.
+type initPrompter struct {
+	in          *bufio.Reader
+	out         io.Writer
+	interactive bool
+	passed      func(name string) bool
+}
+
+// readLine returns the next line of input without surrounding whitespace.
+func (p *initPrompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// ask sets *value to the answer to question, keeping *value when the answer is
+// empty, once validate accepts it, asking again when it does not.  The
+// option called name is only checked when it was passed on the command line or
+// the prompter is not interactive.  The current value of a secret option is
+// not shown.
+func (p *initPrompter) ask(value *string, name, question string, secret bool, validate func(string) error) error {
+	interactive := p.interactive && !p.passed(name)
+	for {
+		answer := *value
+		if interactive {
+			prompt := question
+			if *value != "" && !secret {
+				prompt = fmt.Sprintf("%s [%s]", question, *value)
+			}
+			fmt.Fprintf(p.out, "%s: ", prompt)
+			line, err := p.readLine()
+			if err != nil {
+				return fmt.Errorf("unable to read %s: %v", name, err)
+			}
+			if line != "" {
+				answer = line
+			}
+		}
+
+		err := validate(answer)
+		if err == nil {
+			*value = answer
+			return nil
+		}
+		if !interactive {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		fmt.Fprintf(p.out, "Invalid %s: %v\n", name, err)
+	}
+}
+
+// askNetwork returns the parameters of the network the voting service runs
+// on, asking for it unless it was passed.
+func (p *initPrompter) askNetwork(opts *initOptions) (*netparams.Params, error) {
+	if opts.Network == "" {
+		opts.Network = netparams.MainNetName
+	}
+	var net *netparams.Params
+	err := p.ask(&opts.Network, "network", "Network (mainnet, testnet, simnet)",
+		false, func(value string) error {
+			var err error
+			net, err = netparams.ByName(value)
+			return err
+		})
+	return net, err
+}
+
+// askInitOptions completes opts with the answers of the operator.  The
+// stakepoold hosts are given the default port of the network.
+func askInitOptions(p *initPrompter, opts *initOptions) (*netparams.Params, error) {
+	net, err := p.askNetwork(opts)
+	if err != nil {
+		return nil, err
+	}
+	minHosts := minStakepooldHosts(net)
+
+	var hosts []string
+	validateHosts := func(value string) error {
+		if err := requireValue(value); err != nil {
+			return err
+		}
+		hosts = normalizeAddresses(strings.Split(value, ","),
+			net.StakepooldRPCServerPort)
+		if len(hosts) < minHosts {
+			return fmt.Errorf("at least %d stakepoold hosts are required "+
+				"on %s", minHosts, net.Name)
+		}
+		return nil
+	}
+	validateCerts := func(value string) error {
+		if err := requireValue(value); err != nil {
+			return err
+		}
+		if n := len(strings.Split(value, ",")); n != len(hosts) {
+			return fmt.Errorf("%d certificates given for %d stakepoold "+
+				"hosts", n, len(hosts))
+		}
+		return nil
+	}
+	validateExtPub := extPubValidator(net.Params)
+
+	questions := []struct {
+		value    *string
+		name     string
+		question string
+		secret   bool
+		validate func(string) error
+	}{
+		{&opts.BaseURL, "baseurl", "URL of the voting service", false, requireValue},
+		{&opts.PoolEmail, "poolemail", "Support email address", false, requireValue},
+		{&opts.PoolFees, "poolfees", "Fee percentage", false, validatePoolFees},
+		{&opts.DBHost, "dbhost", "Database host", false, requireValue},
+		{&opts.DBPort, "dbport", "Database port", false, requireValue},
+		{&opts.DBUser, "dbuser", "Database user", false, requireValue},
+		{&opts.DBPassword, "dbpassword", "Database password", true, requireValue},
+		{&opts.DBName, "dbname", "Database name", false, requireValue},
+		{&opts.StakepooldHosts, "stakepooldhosts", "Comma separated stakepoold hosts", false, validateHosts},
+		{&opts.StakepooldCerts, "stakepooldcerts", "Comma separated stakepoold certificates, in the order of the hosts", false, validateCerts},
+		{&opts.ColdWalletExtPub, "coldwalletextpub", "Extended public key of the fee account of the cold wallet", false, validateExtPub},
+		{&opts.VotingWalletExtPub, "votingwalletextpub", "Extended public key of the default account of the voting wallet", false, validateExtPub},
+		{&opts.AdminIPs, "adminips", "Comma separated IPs admins connect from", false, requireValue},
+		{&opts.AdminEmail, "adminemail", "Email address of the admin user", false, requireValue},
+		{&opts.AdminPassword, "adminpassword", "Password of the admin user", true, requireValue},
+	}
+	for _, q := range questions {
+		err := p.ask(q.value, q.name, q.question, q.secret, q.validate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	opts.StakepooldHosts = strings.Join(hosts, ",")
+	return net, nil
+}
+
+// createInitAdmin returns the ID of the user with email, creating it with
+// password and a verified email address when there is none, e.g. when init is
+// run again after a failure.
+func createInitAdmin(dbMap *gorp.DbMap, email, password string) (int64, error) {
+	if user := models.GetUserByEmail(dbMap, email); user != nil {
+		return user.ID, nil
+	}
+	user := &models.User{
+		Username:      email,
+		Email:         email,
+		EmailVerified: 1,
+		VoteBits:      1,
+		Created:       time.Now().Unix(),
+	}
+	user.HashPassword(password)
+	if err := models.InsertUser(dbMap, user); err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// writeInitConfig writes cfg to the configuration file at path, which is only
+// readable by its owner since it holds the secrets.
+func writeInitConfig(path string, cfg *initConfig, force bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flag, 0600)
+	if err != nil {
+		return err
+	}
+	if err := initConfigTemplate.Execute(f, cfg); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// checkStakepoolds reports whether the certificates of the stakepoold hosts
+// can be read and the hosts accept connections.  stakepoold may be set up
+// after dcrstakepool, so failures are reported without stopping the setup.
+func checkStakepoolds(ctx context.Context, out io.Writer, hosts, certs []string) {
+	for i, host := range hosts {
+		if err := configcheck.CertFile(certs[i]); err != nil {
+			fmt.Fprintf(out, "Warning: stakepoold certificate %s: %v\n",
+				certs[i], err)
+		}
+		err := configcheck.Dial(ctx, host, validateProbeTimeout)
+		if err != nil {
+			fmt.Fprintf(out, "Warning: unable to connect to stakepoold "+
+				"host %s: %v\n", host, err)
+			continue
+		}
+		fmt.Fprintf(out, "Connected to stakepoold host %s\n", host)
+	}
+}
+
+// runInit runs the init subcommand with args, the command line arguments
+// following it.  It asks for the settings of the voting service, checks the
+// connections to the database and stakepoold instances, creates the admin user
+// and writes a configuration file with new secrets.
+func runInit(ctx context.Context, args []string) error {
+	opts := initOptions{
+		ConfigFile: defaultConfigFile,
+		BaseURL:    defaultBaseURL,
+		PoolEmail:  defaultPoolEmail,
+		PoolFees:   strconv.FormatFloat(defaultPoolFees, 'f', -1, 64),
+		DBHost:     defaultDBHost,
+		DBPort:     defaultDBPort,
+		DBUser:     defaultDBUser,
+		DBName:     defaultDBName,
+		AdminIPs:   "127.0.0.1",
+	}
+	parser := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
+	parser.Usage = initCommand + " [OPTIONS]"
+	if _, err := parser.ParseArgs(args); err != nil {
+		return err
+	}
+	opts.ConfigFile = cleanAndExpandPath(opts.ConfigFile)
+	if !opts.Force && fileExists(opts.ConfigFile) {
+		return fmt.Errorf("%s already exists, pass --force to overwrite it",
+			opts.ConfigFile)
+	}
+
+	p := &initPrompter{
+		in:          bufio.NewReader(os.Stdin),
+		out:         os.Stdout,
+		interactive: !opts.NonInteractive,
+		passed: func(name string) bool {
+			option := parser.FindOptionByLongName(name)
+			return option != nil && option.IsSet()
+		},
+	}
+	net, err := askInitOptions(p, &opts)
+	if err != nil {
+		return err
+	}
+
+	cfg := &initConfig{
+		initOptions: opts,
+		Date:        time.Now().UTC().Format("2006-01-02"),
+	}
+	if cfg.APISecret, err = newSecret(); err != nil {
+		return err
+	}
+	if cfg.CookieSecret, err = newSecret(); err != nil {
+		return err
+	}
+
+	dsn := fmt.Sprintf("%s:%s@(%s:%s)/%s?charset=utf8mb4", opts.DBUser,
+		opts.DBPassword, opts.DBHost, opts.DBPort, opts.DBName)
+	if err := configcheck.PingMySQL(ctx, dsn, validateProbeTimeout); err != nil {
+		return fmt.Errorf("unable to connect to the database: %v", err)
+	}
+	fmt.Fprintln(p.out, "Connected to the database")
+
+	checkStakepoolds(ctx, p.out, strings.Split(opts.StakepooldHosts, ","),
+		strings.Split(opts.StakepooldCerts, ","))
+
+	apiKeys := models.NewAPIKeyring(cfg.APISecret, nil,
+		defaultAPITokenLifetime, time.Time{}, defaultMaxClockSkew)
+	dbMap, err := models.GetDbMap(apiKeys, opts.BaseURL, opts.DBUser,
+		opts.DBPassword, opts.DBHost, opts.DBPort, opts.DBName)
+	if err != nil {
+		return err
+	}
+	defer dbMap.Db.Close()
+	cfg.AdminUserID, err = createInitAdmin(dbMap, opts.AdminEmail,
+		opts.AdminPassword)
+	if err != nil {
+		return fmt.Errorf("unable to create the admin user: %v", err)
+	}
+	fmt.Fprintf(p.out, "Admin user %s has id %d\n", opts.AdminEmail,
+		cfg.AdminUserID)
+
+	if err := writeInitConfig(opts.ConfigFile, cfg, opts.Force); err != nil {
+		return fmt.Errorf("unable to write the configuration file: %v", err)
+	}
+	fmt.Fprintf(p.out, "Wrote the configuration for %s to %s\n",
+		net.Name, opts.ConfigFile)
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrstakepool/internal/netparams"
+)
+
+func TestExtPubValidator(t *testing.T) {
+	validate := extPubValidator(chaincfg.TestNet3Params())
+	if err := validate(testnetXPub1); err != nil {
+		t.Errorf("testnet key rejected: %v", err)
+	}
+	if err := validate(mainnetXPub1); err == nil {
+		t.Error("mainnet key accepted on testnet")
+	}
+
+	seed := bytes.Repeat([]byte{1}, hdkeychain.RecommendedSeedLen)
+	priv, err := hdkeychain.NewMaster(seed, chaincfg.TestNet3Params())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validate(priv.String()); err == nil {
+		t.Error("extended private key accepted")
+	}
+}
+
+func TestAskInitOptions(t *testing.T) {
+	// Unset options are asked for, invalid answers are asked again and
+	// empty answers keep the defaults.
+	answers := []string{"testnet", "", "", "101", "", "", "", "", "secret",
+		"", "10.0.0.1,10.0.0.2", "a.cert", "a.cert,b.cert", testnetXPub1,
+		testnetXPub2, "", "admin@example.com", "password"}
+	var out bytes.Buffer
+	p := &initPrompter{
+		in:          bufio.NewReader(strings.NewReader(strings.Join(answers, "\n"))),
+		out:         &out,
+		interactive: true,
+		passed:      func(name string) bool { return name == "dbhost" },
+	}
+	opts := initOptions{
+		BaseURL:   defaultBaseURL,
+		PoolEmail: defaultPoolEmail,
+		PoolFees:  "7.5",
+		DBHost:    defaultDBHost,
+		DBPort:    defaultDBPort,
+		DBUser:    defaultDBUser,
+		DBName:    defaultDBName,
+		AdminIPs:  "127.0.0.1",
+	}
+	net, err := askInitOptions(p, &opts)
+	if err != nil {
+		t.Fatalf("askInitOptions failed: %v\n%s", err, out.String())
+	}
+	if net != &netparams.TestNet3 || opts.Network != netparams.TestNetName {
+		t.Errorf("network %v not selected", net.Name)
+	}
+	if opts.StakepooldHosts != "10.0.0.1:19113,10.0.0.2:19113" {
+		t.Errorf("got stakepooldhosts %q", opts.StakepooldHosts)
+	}
+	if opts.DBPassword != "secret" || opts.PoolFees != "7.5" ||
+		opts.DBHost != defaultDBHost || opts.AdminEmail != "admin@example.com" {
+		t.Errorf("unexpected options %+v", opts)
+	}
+	if !strings.Contains(out.String(), "Invalid poolfees") ||
+		!strings.Contains(out.String(), "Invalid stakepooldcerts") {
+		t.Errorf("invalid answers not reported:\n%s", out.String())
+	}
+
+	// Without a prompt the required options must be passed.
+	p = &initPrompter{passed: func(string) bool { return false }}
+	opts = initOptions{BaseURL: defaultBaseURL}
+	if _, err := askInitOptions(p, &opts); err == nil {
+		t.Error("missing options accepted without a prompt")
+	}
+}
+
+func TestWriteInitConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "dcrstakepool", "dcrstakepool.conf")
+	cfg := &initConfig{
+		initOptions: initOptions{Network: netparams.TestNetName, DBPassword: "secret"},
+		APISecret:   "apisecret",
+		AdminUserID: 7,
+	}
+	if err := writeInitConfig(path, cfg, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeInitConfig(path, cfg, false); err == nil {
+		t.Fatal("existing configuration file overwritten")
+	}
+	if err := writeInitConfig(path, cfg, true); err != nil {
+		t.Fatalf("unable to overwrite with force: %v", err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("configuration file has mode %v", fi.Mode())
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"network=testnet", "dbpassword=secret",
+		"apisecret=apisecret", "adminuserids=7"} {
+		if !strings.Contains(string(b), "\n"+line+"\n") {
+			t.Errorf("configuration file lacks %q:\n%s", line, b)
+		}
+	}
+	if strings.Contains(string(b), "simnet") {
+		t.Errorf("configuration file selects simnet:\n%s", b)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package configcheck checks configuration values beyond what is checked when
+// they are loaded, such as whether hosts resolve and certificate files parse,
+// and collects the results into a report which can be read by deployment
+// tooling before a restart.
+package configcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"time"
+
+	// Register the mysql driver for PingMySQL.
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// Check is the result of a single check.
+type Check struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Report is the result of all checks of a configuration.  It is valid when
+// all of its checks passed.
+type Report struct {
+	Valid  bool    `json:"valid"`
+	Checks []Check `json:"checks"`
+}
+
+// NewReport returns an empty, valid Report.
+func NewReport() *Report {
+	return &Report{Valid: true, Checks: []Check{}}
+}
+
+// Add records the result of the check name, which failed when err is not nil.
+func (r *Report) Add(name string, err error) {
+	check := Check{Name: name, OK: err == nil}
+	if err != nil {
+		check.Error = err.Error()
+		r.Valid = false
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+// Write writes the report to w as indented JSON.
+func (r *Report) Write(w io.Writer) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// ResolveHost checks that the host of addr, which may include a port,
+// resolves to at least one address.
+func ResolveHost(ctx context.Context, addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("%s resolved to no addresses", host)
+	}
+	return nil
+}
+
+// CertFile checks that the file at path holds at least one PEM encoded
+// certificate and that all of its certificates parse.
+func CertFile(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var n int
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return err
+		}
+		n++
+	}
+	if n == 0 {
+		return errors.New("no PEM encoded certificate found")
+	}
+	return nil
+}
+
+// KeyPair checks that the certificate and key files form a valid key pair.
+func KeyPair(certFile, keyFile string) error {
+	_, err := tls.LoadX509KeyPair(certFile, keyFile)
+	return err
+}
+
+// Dir checks that path is an existing directory.
+func Dir(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
+	}
+	return nil
+}
+
+// Dial checks that a TCP connection to addr can be established within
+// timeout.
+func Dial(ctx context.Context, addr string, timeout time.Duration) error {
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// PingMySQL checks that the MySQL server described by the data source name
+// dsn accepts a connection within timeout.
+func PingMySQL(ctx context.Context, dsn string, timeout time.Duration) error {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return db.PingContext(ctx)
+}
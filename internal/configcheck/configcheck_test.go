@@ -0,0 +1,94 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package configcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	r := NewReport()
+	r.Add("passes", nil)
+	if !r.Valid {
+		t.Fatal("report with only passed checks is not valid")
+	}
+	r.Add("fails", errors.New("boom"))
+	r.Add("passes too", nil)
+	if r.Valid {
+		t.Fatal("report with a failed check is valid")
+	}
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	want := []Check{{"passes", true, ""}, {"fails", false, "boom"},
+		{"passes too", true, ""}}
+	if decoded.Valid || len(decoded.Checks) != len(want) {
+		t.Fatalf("decoded report %+v", decoded)
+	}
+	for i := range want {
+		if decoded.Checks[i] != want[i] {
+			t.Errorf("check %d: got %+v, want %+v", i,
+				decoded.Checks[i], want[i])
+		}
+	}
+}
+
+func TestCertFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configcheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := CertFile(filepath.Join(dir, "missing.cert")); err == nil {
+		t.Error("missing file accepted")
+	}
+	notPEM := filepath.Join(dir, "notpem.cert")
+	if err := ioutil.WriteFile(notPEM, []byte("not a cert"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := CertFile(notPEM); err == nil {
+		t.Error("file without a certificate accepted")
+	}
+	badCert := filepath.Join(dir, "bad.cert")
+	pemBytes := []byte("-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n")
+	if err := ioutil.WriteFile(badCert, pemBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := CertFile(badCert); err == nil {
+		t.Error("malformed certificate accepted")
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configcheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := Dir(dir); err != nil {
+		t.Errorf("existing directory rejected: %v", err)
+	}
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Dir(file); err == nil {
+		t.Error("file accepted as a directory")
+	}
+}
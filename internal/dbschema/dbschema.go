@@ -0,0 +1,271 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package dbschema checks that the MySQL database shared by dcrstakepool and
+// stakepoold has the tables, columns and indexes they expect.
+//
+// The tables of the voting service are created and migrated by dcrstakepool
+// without indexes other than the primary and unique keys, so installs which
+// were upgraded from older releases, or whose indexes were dropped, fall back
+// to full table scans that become slow as the tables grow.  Checking the
+// schema on startup tells operators about these problems before they are
+// noticed as timeouts.
+package dbschema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/decred/slog"
+)
+
+// Table is a table expected to exist with at least the listed columns.
+type Table struct {
+	Name    string
+	Columns []string
+}
+
+// Index is an index expected to exist on the listed columns of a table.
+type Index struct {
+	// Name is the name the index is created with.  An existing index of
+	// any name whose leftmost columns are Columns satisfies it.
+	Name string
+
+	Table   string
+	Columns []string
+
+	// Reason describes the queries which are slow without the index.
+	Reason string
+}
+
+// String returns the index in the form Table(Column, ...).
+func (idx *Index) String() string {
+	return idx.Table + "(" + strings.Join(idx.Columns, ", ") + ")"
+}
+
+// Schema describes the tables, columns and indexes of a database.  Names are
+// compared case-insensitively, like MySQL does on most platforms.
+type Schema struct {
+	columns map[string]map[string]struct{} // [table][column]
+	indexes map[string][][]string          // [table] columns of each index
+}
+
+// NewSchema returns an empty Schema.
+func NewSchema() *Schema {
+	return &Schema{
+		columns: make(map[string]map[string]struct{}),
+		indexes: make(map[string][][]string),
+	}
+}
+
+// AddColumn records that table has column.
+func (s *Schema) AddColumn(table, column string) {
+	table = strings.ToLower(table)
+	if s.columns[table] == nil {
+		s.columns[table] = make(map[string]struct{})
+	}
+	s.columns[table][strings.ToLower(column)] = struct{}{}
+}
+
+// AddIndex records that table has an index on columns, in index order.
+func (s *Schema) AddIndex(table string, columns ...string) {
+	table = strings.ToLower(table)
+	lower := make([]string, 0, len(columns))
+	for _, c := range columns {
+		lower = append(lower, strings.ToLower(c))
+	}
+	s.indexes[table] = append(s.indexes[table], lower)
+}
+
+// hasTable returns whether the schema has table.
+func (s *Schema) hasTable(table string) bool {
+	_, ok := s.columns[strings.ToLower(table)]
+	return ok
+}
+
+// hasColumn returns whether the schema has column in table.
+func (s *Schema) hasColumn(table, column string) bool {
+	_, ok := s.columns[strings.ToLower(table)][strings.ToLower(column)]
+	return ok
+}
+
+// hasIndex returns whether an index of the schema can be used to look up
+// rows by the columns of idx, i.e. whether they are its leftmost columns.
+func (s *Schema) hasIndex(idx *Index) bool {
+	for _, columns := range s.indexes[strings.ToLower(idx.Table)] {
+		if len(columns) < len(idx.Columns) {
+			continue
+		}
+		found := true
+		for i, c := range idx.Columns {
+			if columns[i] != strings.ToLower(c) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// Load returns the schema of database as found by the information_schema
+// tables.
+func Load(ctx context.Context, db *sql.DB, database string) (*Schema, error) {
+	s := NewSchema()
+
+	rows, err := db.QueryContext(ctx, "SELECT TABLE_NAME, COLUMN_NAME "+
+		"FROM information_schema.columns WHERE TABLE_SCHEMA = ?", database)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query columns: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, column string
+		if err = rows.Scan(&table, &column); err != nil {
+			return nil, fmt.Errorf("unable to scan column: %v", err)
+		}
+		s.AddColumn(table, column)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to query columns: %v", err)
+	}
+
+	rows, err = db.QueryContext(ctx, "SELECT TABLE_NAME, INDEX_NAME, "+
+		"COLUMN_NAME FROM information_schema.statistics "+
+		"WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX",
+		database)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query indexes: %v", err)
+	}
+	defer rows.Close()
+	var lastTable, lastIndex string
+	var columns []string
+	for rows.Next() {
+		var table, index, column string
+		if err = rows.Scan(&table, &index, &column); err != nil {
+			return nil, fmt.Errorf("unable to scan index: %v", err)
+		}
+		if table != lastTable || index != lastIndex {
+			if columns != nil {
+				s.AddIndex(lastTable, columns...)
+			}
+			lastTable, lastIndex, columns = table, index, nil
+		}
+		columns = append(columns, column)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to query indexes: %v", err)
+	}
+	if columns != nil {
+		s.AddIndex(lastTable, columns...)
+	}
+
+	return s, nil
+}
+
+// Result lists the problems found by Check.
+type Result struct {
+	MissingTables  []string
+	MissingColumns []string // Table.Column
+	MissingIndexes []Index
+}
+
+// Broken returns whether tables or columns are missing, which makes the
+// queries using them fail.  Missing indexes only make queries slow.
+func (r *Result) Broken() bool {
+	return len(r.MissingTables) > 0 || len(r.MissingColumns) > 0
+}
+
+// Check returns the tables, columns and indexes which are missing from s.
+// Indexes on missing tables or columns are not reported, since they cannot
+// be created before the columns.
+func (s *Schema) Check(tables []Table, indexes []Index) *Result {
+	r := new(Result)
+	for _, t := range tables {
+		if !s.hasTable(t.Name) {
+			r.MissingTables = append(r.MissingTables, t.Name)
+			continue
+		}
+		for _, c := range t.Columns {
+			if !s.hasColumn(t.Name, c) {
+				r.MissingColumns = append(r.MissingColumns, t.Name+"."+c)
+			}
+		}
+	}
+
+	for i := range indexes {
+		idx := &indexes[i]
+		if s.hasIndex(idx) {
+			continue
+		}
+		creatable := s.hasTable(idx.Table)
+		for _, c := range idx.Columns {
+			creatable = creatable && s.hasColumn(idx.Table, c)
+		}
+		if creatable {
+			r.MissingIndexes = append(r.MissingIndexes, *idx)
+		}
+	}
+	return r
+}
+
+// CreateIndex creates idx in db.
+func CreateIndex(ctx context.Context, db *sql.DB, idx *Index) error {
+	columns := make([]string, 0, len(idx.Columns))
+	for _, c := range idx.Columns {
+		columns = append(columns, "`"+c+"`")
+	}
+	_, err := db.ExecContext(ctx, "CREATE INDEX `"+idx.Name+"` ON `"+
+		idx.Table+"` ("+strings.Join(columns, ", ")+")")
+	return err
+}
+
+// Verify checks the schema of database against tables and indexes and logs
+// the problems found with logger.  Missing indexes are created when
+// createIndexes is true, and otherwise logged with the slow queries they
+// cause.  The returned result lists the problems which remain.
+func Verify(ctx context.Context, db *sql.DB, database string, tables []Table,
+	indexes []Index, createIndexes bool, logger slog.Logger) (*Result, error) {
+
+	s, err := Load(ctx, db, database)
+	if err != nil {
+		return nil, err
+	}
+	r := s.Check(tables, indexes)
+
+	for _, t := range r.MissingTables {
+		logger.Errorf("Database table %s is missing", t)
+	}
+	for _, c := range r.MissingColumns {
+		logger.Errorf("Database column %s is missing", c)
+	}
+
+	var missing []Index
+	for i := range r.MissingIndexes {
+		idx := &r.MissingIndexes[i]
+		if !createIndexes {
+			logger.Warnf("Database index on %s is missing, which makes %s "+
+				"slow.  Create it with: CREATE INDEX %s ON %s (%s);", idx,
+				idx.Reason, idx.Name, idx.Table, strings.Join(idx.Columns, ", "))
+			missing = append(missing, *idx)
+			continue
+		}
+		logger.Infof("Creating missing database index %s on %s", idx.Name, idx)
+		if err := CreateIndex(ctx, db, idx); err != nil {
+			logger.Errorf("Unable to create database index %s on %s: %v",
+				idx.Name, idx, err)
+			missing = append(missing, *idx)
+		}
+	}
+	r.MissingIndexes = missing
+
+	if !r.Broken() && len(missing) == 0 {
+		logger.Debugf("Database schema of %s is healthy", database)
+	}
+	return r, nil
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dbschema
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/decred/slog"
+)
+
+var (
+	testTables = []Table{
+		{Name: "Users", Columns: []string{"UserId", "MultiSigAddress"}},
+		{Name: "LowFeeTicket", Columns: []string{"TicketHash"}},
+		{Name: "TicketFee", Columns: []string{"TicketHash", "Status"}},
+	}
+	testIndexes = []Index{
+		{Name: "idx_users_msa", Table: "Users",
+			Columns: []string{"MultiSigAddress"}, Reason: "ticket lookups"},
+		{Name: "idx_lowfee_hash", Table: "LowFeeTicket",
+			Columns: []string{"TicketHash"}, Reason: "low fee lookups"},
+		{Name: "idx_fee_hash", Table: "TicketFee",
+			Columns: []string{"TicketHash"}, Reason: "fee lookups"},
+	}
+)
+
+func TestCheck(t *testing.T) {
+	s := NewSchema()
+	s.AddColumn("users", "UserId")
+	s.AddColumn("users", "multisigaddress")
+	s.AddColumn("LowFeeTicket", "TicketHash")
+	s.AddIndex("Users", "UserId")
+	// An index with more columns satisfies the index on its leftmost
+	// column only.
+	s.AddIndex("LowFeeTicket", "Voted", "TicketHash")
+
+	r := s.Check(testTables, testIndexes)
+	if !r.Broken() {
+		t.Fatal("schema without TicketFee is not broken")
+	}
+	if !reflect.DeepEqual(r.MissingTables, []string{"TicketFee"}) {
+		t.Fatalf("got missing tables %v", r.MissingTables)
+	}
+	if len(r.MissingColumns) != 0 {
+		t.Fatalf("got missing columns %v", r.MissingColumns)
+	}
+	// The index on the missing table is not reported.
+	if !reflect.DeepEqual(r.MissingIndexes, testIndexes[:2]) {
+		t.Fatalf("got missing indexes %v", r.MissingIndexes)
+	}
+
+	s.AddColumn("TicketFee", "TicketHash")
+	s.AddIndex("Users", "MultiSigAddress", "UserId")
+	s.AddIndex("LowFeeTicket", "TicketHash")
+	s.AddIndex("TicketFee", "TicketHash")
+	r = s.Check(testTables, testIndexes)
+	if !reflect.DeepEqual(r.MissingColumns, []string{"TicketFee.Status"}) {
+		t.Fatalf("got missing columns %v", r.MissingColumns)
+	}
+	if len(r.MissingTables) != 0 || len(r.MissingIndexes) != 0 {
+		t.Fatalf("got missing tables %v and indexes %v", r.MissingTables,
+			r.MissingIndexes)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.columns")).
+		WithArgs("stakepool").
+		WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME"}).
+			AddRow("Users", "UserId").
+			AddRow("Users", "MultiSigAddress").
+			AddRow("LowFeeTicket", "TicketHash").
+			AddRow("TicketFee", "TicketHash").
+			AddRow("TicketFee", "Status"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.statistics")).
+		WithArgs("stakepool").
+		WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "INDEX_NAME",
+			"COLUMN_NAME"}).
+			AddRow("TicketFee", "TicketHash", "TicketHash").
+			AddRow("Users", "PRIMARY", "UserId"))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX `idx_users_msa` ON " +
+		"`Users` (`MultiSigAddress`)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX `idx_lowfee_hash` ON " +
+		"`LowFeeTicket` (`TicketHash`)")).
+		WillReturnError(sqlmock.ErrCancelled)
+
+	r, err := Verify(context.Background(), db, "stakepool", testTables,
+		testIndexes, true, slog.Disabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Broken() {
+		t.Fatalf("schema is broken: %+v", r)
+	}
+	// The index which failed to be created is still missing.
+	if !reflect.DeepEqual(r.MissingIndexes, testIndexes[1:2]) {
+		t.Fatalf("got missing indexes %v", r.MissingIndexes)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
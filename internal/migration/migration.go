@@ -0,0 +1,235 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package migration defines the format the users of a voting service are
+// exported in, to migrate them to another dcrstakepool instance or to vspd
+// without stranding their tickets.
+//
+// An export is a JSON document holding the network and fee settings of the
+// voting service and, for every user who submitted an address, the 1-of-2
+// multisig script tickets are bought with, the addresses it was made of, the
+// indexes of the child keys the voting and fee addresses were derived from,
+// the voting preferences and the ticket history.  The voting service taking
+// over the users must own the private key of the pool address of every script
+// to vote their tickets.
+package migration
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// Version is the version of the export format written by Write.  It is
+// increased on changes which readers of older versions misinterpret.
+const Version = 1
+
+// Statuses of the tickets of an export besides the statuses reported by the
+// voting wallets, such as live, voted or missed.  TicketInvalid tickets were
+// bought with the script of a user but are not valid tickets of the voting
+// service, e.g. since they pay too low a fee.  TicketUnknown tickets are not
+// known to the voting wallets, e.g. tickets with a deferred fee which was
+// recorded before they were mined.
+const (
+	TicketInvalid = "invalid"
+	TicketUnknown = "unknown"
+)
+
+// Export is a migration export of the users of a voting service.
+type Export struct {
+	Version int `json:"version"`
+	// Network is the name of the network of the voting service, e.g.
+	// mainnet.
+	Network string `json:"network"`
+	// Created is when the export was created, in unix seconds.
+	Created int64 `json:"created"`
+	// PoolFees is the fee percentage of the voting service and FeeMode
+	// how it is paid.
+	PoolFees float64 `json:"poolfees"`
+	FeeMode  string  `json:"feemode"`
+	// VoteVersion is the vote version the voting preferences of the users
+	// were migrated to by the voting service.
+	VoteVersion uint32 `json:"voteversion"`
+	// VotingBranch is the branch of the voting account the pool addresses
+	// of the scripts were derived from.
+	VotingBranch uint32 `json:"votingbranch"`
+	Users        []User `json:"users"`
+}
+
+// User is a user of a migration export.
+type User struct {
+	// ID is the user ID on the voting service which was exported.  It is
+	// not kept by imports.
+	ID            int64  `json:"id"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailverified"`
+	Created       int64  `json:"created"`
+
+	// MultiSigAddress is the P2SH address of the hex encoded 1-of-2
+	// multisig script MultiSigScript of the public key addresses
+	// UserPubKeyAddr and PoolPubKeyAddr.  HeightRegistered is the block
+	// height the script was created at, which wallets rescan from.
+	MultiSigAddress  string `json:"multisigaddress"`
+	MultiSigScript   string `json:"multisigscript"`
+	UserPubKeyAddr   string `json:"userpubkeyaddr"`
+	PoolPubKeyAddr   string `json:"poolpubkeyaddr"`
+	HeightRegistered int64  `json:"heightregistered"`
+
+	// UserFeeAddr is the fee address of the user.  FeeIndex and VotingIndex
+	// are the indexes of the children of the fee account and of the voting
+	// branch which UserFeeAddr and PoolPubKeyAddr were derived from.  They
+	// are omitted when the address was not derived from the account keys of
+	// the voting service, e.g. when it was imported itself.
+	UserFeeAddr string  `json:"userfeeaddr"`
+	FeeIndex    *uint32 `json:"feeindex,omitempty"`
+	VotingIndex *uint32 `json:"votingindex,omitempty"`
+
+	VoteBits        uint16 `json:"votebits"`
+	VoteBitsVersion uint32 `json:"votebitsversion"`
+
+	Tickets []Ticket `json:"tickets,omitempty"`
+}
+
+// Ticket is a ticket bought with the multisig script of a user.
+type Ticket struct {
+	Hash          string `json:"hash"`
+	Status        string `json:"status"`
+	Height        uint32 `json:"height,omitempty"`
+	SpentBy       string `json:"spentby,omitempty"`
+	SpentByHeight uint32 `json:"spentbyheight,omitempty"`
+
+	// The fee of a ticket is only recorded when fees are deferred.
+	FeeAddress string `json:"feeaddress,omitempty"`
+	FeeAmount  int64  `json:"feeamount,omitempty"`
+	FeeTxHash  string `json:"feetxhash,omitempty"`
+	FeeStatus  string `json:"feestatus,omitempty"`
+	FeeCreated int64  `json:"feecreated,omitempty"`
+	FeePaid    int64  `json:"feepaid,omitempty"`
+}
+
+// Write writes e as indented JSON to w.
+func Write(w io.Writer, e *Export) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// Read reads an export written by Write from r.
+func Read(r io.Reader) (*Export, error) {
+	var e Export
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&e); err != nil {
+		return nil, fmt.Errorf("invalid export: %v", err)
+	}
+	if e.Version != Version {
+		return nil, fmt.Errorf("unsupported export version %d, version %d "+
+			"is supported", e.Version, Version)
+	}
+	return &e, nil
+}
+
+// pubKeyAddress decodes the public key address addr of params.
+func pubKeyAddress(addr string, params *chaincfg.Params) (*dcrutil.AddressSecpPubKey, error) {
+	a, err := dcrutil.DecodeAddress(addr, params)
+	if err != nil {
+		return nil, err
+	}
+	pk, ok := a.(*dcrutil.AddressSecpPubKey)
+	if !ok {
+		return nil, errors.New("not a public key address")
+	}
+	return pk, nil
+}
+
+// validateUser checks that the addresses of u are valid on the network of
+// params, and that the script of u is the 1-of-2 multisig script of the pool
+// and user public keys, in the order dcrstakepool creates it.
+func validateUser(u *User, params *chaincfg.Params) error {
+	if u.Email == "" {
+		return errors.New("no email address")
+	}
+	userPK, err := pubKeyAddress(u.UserPubKeyAddr, params)
+	if err != nil {
+		return fmt.Errorf("invalid user public key address %s: %v",
+			u.UserPubKeyAddr, err)
+	}
+	poolPK, err := pubKeyAddress(u.PoolPubKeyAddr, params)
+	if err != nil {
+		return fmt.Errorf("invalid pool public key address %s: %v",
+			u.PoolPubKeyAddr, err)
+	}
+	script, err := hex.DecodeString(u.MultiSigScript)
+	if err != nil {
+		return fmt.Errorf("invalid multisig script: %v", err)
+	}
+	want, err := txscript.MultiSigScript([]*dcrutil.AddressSecpPubKey{poolPK,
+		userPK}, 1)
+	if err != nil {
+		return fmt.Errorf("unable to create multisig script: %v", err)
+	}
+	if !bytes.Equal(script, want) {
+		return errors.New("multisig script is not the script of the pool " +
+			"and user public keys")
+	}
+	p2sh, err := dcrutil.NewAddressScriptHash(script, params)
+	if err != nil {
+		return fmt.Errorf("invalid multisig script: %v", err)
+	}
+	if p2sh.Address() != u.MultiSigAddress {
+		return fmt.Errorf("multisig address %s is not the address of its "+
+			"script", u.MultiSigAddress)
+	}
+	if _, err := dcrutil.DecodeAddress(u.UserFeeAddr, params); err != nil {
+		return fmt.Errorf("invalid fee address %s: %v", u.UserFeeAddr, err)
+	}
+	return nil
+}
+
+// Validate checks that e is an export of the network of params whose users
+// have valid scripts and addresses, and do not share email addresses or
+// scripts.
+func (e *Export) Validate(params *chaincfg.Params) error {
+	if e.Network != params.Name {
+		return fmt.Errorf("the export is of network %s, not %s", e.Network,
+			params.Name)
+	}
+	emails := make(map[string]struct{}, len(e.Users))
+	scripts := make(map[string]struct{}, len(e.Users))
+	for i := range e.Users {
+		u := &e.Users[i]
+		if err := validateUser(u, params); err != nil {
+			return fmt.Errorf("user %d: %v", u.ID, err)
+		}
+		if _, ok := emails[u.Email]; ok {
+			return fmt.Errorf("user %d: email address %s is exported "+
+				"twice", u.ID, u.Email)
+		}
+		emails[u.Email] = struct{}{}
+		if _, ok := scripts[u.MultiSigAddress]; ok {
+			return fmt.Errorf("user %d: multisig address %s is exported "+
+				"twice", u.ID, u.MultiSigAddress)
+		}
+		scripts[u.MultiSigAddress] = struct{}{}
+	}
+	return nil
+}
+
+// PoolTicketAddress returns the address of the pubkey hash of the pool
+// public key address of u, which the voting wallets must own to vote the
+// tickets of u.
+func (u *User) PoolTicketAddress(params *chaincfg.Params) (dcrutil.Address, error) {
+	pk, err := pubKeyAddress(u.PoolPubKeyAddr, params)
+	if err != nil {
+		return nil, err
+	}
+	return pk.AddressPubKeyHash(), nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package migration
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// testUser returns a user of params with a valid script.
+func testUser(t *testing.T, params *chaincfg.Params) User {
+	t.Helper()
+	var pks []*dcrutil.AddressSecpPubKey
+	for _, s := range []string{
+		"0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		"02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5",
+	} {
+		b, _ := hex.DecodeString(s)
+		pk, err := dcrutil.NewAddressSecpPubKey(b, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pks = append(pks, pk)
+	}
+	script, err := txscript.MultiSigScript([]*dcrutil.AddressSecpPubKey{
+		pks[1], pks[0]}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2sh, err := dcrutil.NewAddressScriptHash(script, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := uint32(3)
+	return User{
+		ID:               7,
+		Email:            "user@example.com",
+		EmailVerified:    true,
+		MultiSigAddress:  p2sh.Address(),
+		MultiSigScript:   hex.EncodeToString(script),
+		UserPubKeyAddr:   pks[0].String(),
+		PoolPubKeyAddr:   pks[1].String(),
+		UserFeeAddr:      pks[0].AddressPubKeyHash().Address(),
+		FeeIndex:         &index,
+		HeightRegistered: 1000,
+		VoteBits:         1,
+		VoteBitsVersion:  8,
+		Tickets: []Ticket{{
+			Hash:   strings.Repeat("ab", 32),
+			Status: "voted",
+			Height: 1100,
+		}},
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	params := chaincfg.TestNet3Params()
+	e := &Export{
+		Version:  Version,
+		Network:  params.Name,
+		PoolFees: 7.5,
+		FeeMode:  "standard",
+		Users:    []User{testUser(t, params)},
+	}
+	if err := e.Validate(params); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, e); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, e) {
+		t.Fatalf("got %+v, want %+v", got, e)
+	}
+
+	ticketAddr, err := e.Users[0].PoolTicketAddress(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(ticketAddr.Address(), "Ts") {
+		t.Fatalf("unexpected pool ticket address %s", ticketAddr)
+	}
+
+	if _, err := Read(strings.NewReader(`{"version":2}`)); err == nil {
+		t.Fatal("read an export of another version")
+	}
+}
+
+func TestExportValidate(t *testing.T) {
+	params := chaincfg.TestNet3Params()
+	tests := []struct {
+		name   string
+		modify func(e *Export)
+	}{
+		{"network", func(e *Export) { e.Network = "mainnet" }},
+		{"script", func(e *Export) { e.Users[0].MultiSigScript = "51" }},
+		// A valid multisig script and its address, but with the keys
+		// in the wrong order.
+		{"mismatched script", func(e *Export) {
+			u := &e.Users[0]
+			u.UserPubKeyAddr, u.PoolPubKeyAddr = u.PoolPubKeyAddr,
+				u.UserPubKeyAddr
+		}},
+		{"script address", func(e *Export) {
+			e.Users[0].MultiSigAddress = e.Users[0].UserFeeAddr
+		}},
+		{"pool pubkey", func(e *Export) {
+			e.Users[0].PoolPubKeyAddr = e.Users[0].UserFeeAddr
+		}},
+		{"duplicate", func(e *Export) {
+			e.Users = append(e.Users, e.Users[0])
+		}},
+	}
+	for _, test := range tests {
+		e := &Export{
+			Version: Version,
+			Network: params.Name,
+			Users:   []User{testUser(t, params)},
+		}
+		test.modify(e)
+		if err := e.Validate(params); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
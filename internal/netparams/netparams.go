@@ -0,0 +1,107 @@
+// Copyright (c) 2013-2014 The btcsuite developers
+// Copyright (c) 2015-2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package netparams defines the decred networks dcrstakepool and stakepoold
+// run on, with the default ports of the servers they listen on and connect to,
+// so that both binaries agree on them.
+package netparams
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// Names of the networks as passed to the network option.
+const (
+	MainNetName = "mainnet"
+	TestNetName = "testnet"
+	SimNetName  = "simnet"
+)
+
+// Params groups the parameters of a decred network with the default ports of
+// dcrd, dcrwallet and stakepoold on it.
+type Params struct {
+	*chaincfg.Params
+	DcrdRPCServerPort       string
+	WalletRPCServerPort     string
+	StakepooldRPCServerPort string
+}
+
+// MainNet contains parameters specific to the main network (wire.MainNet).
+var MainNet = Params{
+	Params:                  chaincfg.MainNetParams(),
+	DcrdRPCServerPort:       "9109",
+	WalletRPCServerPort:     "9110",
+	StakepooldRPCServerPort: "9113",
+}
+
+// TestNet3 contains parameters specific to the test network (version 3)
+// (wire.TestNet3).
+var TestNet3 = Params{
+	Params:                  chaincfg.TestNet3Params(),
+	DcrdRPCServerPort:       "19109",
+	WalletRPCServerPort:     "19110",
+	StakepooldRPCServerPort: "19113",
+}
+
+// SimNet contains parameters specific to the simulation test network
+// (wire.SimNet).
+var SimNet = Params{
+	Params:                  chaincfg.SimNetParams(),
+	DcrdRPCServerPort:       "19556",
+	WalletRPCServerPort:     "19557",
+	StakepooldRPCServerPort: "19560",
+}
+
+// ByName returns the parameters of the network called name, one of mainnet,
+// testnet or simnet.
+func ByName(name string) (*Params, error) {
+	switch name {
+	case MainNetName:
+		return &MainNet, nil
+	case TestNetName:
+		return &TestNet3, nil
+	case SimNetName:
+		return &SimNet, nil
+	}
+	return nil, fmt.Errorf("unknown network %q -- choose one of %s, %s "+
+		"or %s", name, MainNetName, TestNetName, SimNetName)
+}
+
+// Select returns the parameters of the network selected by the network option,
+// or by the deprecated testnet and simnet options when it is empty.  It
+// defaults to the main network, and fails when the options disagree.
+func Select(network string, testNet, simNet bool) (*Params, error) {
+	legacy := MainNetName
+	switch {
+	case testNet && simNet:
+		return nil, fmt.Errorf("the testnet and simnet params can't be " +
+			"used together -- choose one of the three")
+	case testNet:
+		legacy = TestNetName
+	case simNet:
+		legacy = SimNetName
+	}
+	if network == "" {
+		network = legacy
+	} else if (testNet || simNet) && network != legacy {
+		return nil, fmt.Errorf("network %s conflicts with the %s option",
+			network, legacy)
+	}
+	return ByName(network)
+}
+
+// DirName returns the name of the directory the data and logs of the network
+// are placed in, which is "testnet3" on the test network as with dcrd.
+func (p *Params) DirName() string {
+	switch p.Net {
+	case wire.TestNet3:
+		return "testnet3"
+	default:
+		return p.Name
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netparams
+
+import "testing"
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		network         string
+		testNet, simNet bool
+		want            *Params
+	}{
+		{"", false, false, &MainNet},
+		{"mainnet", false, false, &MainNet},
+		{"testnet", false, false, &TestNet3},
+		{"simnet", false, false, &SimNet},
+		{"", true, false, &TestNet3},
+		{"", false, true, &SimNet},
+		{"testnet", true, false, &TestNet3},
+		{"mainnet", true, false, nil},
+		{"simnet", true, false, nil},
+		{"", true, true, nil},
+		{"testnet3", false, false, nil},
+	}
+	for _, test := range tests {
+		got, err := Select(test.network, test.testNet, test.simNet)
+		if (err != nil) != (test.want == nil) {
+			t.Errorf("%q testnet=%v simnet=%v: unexpected error %v",
+				test.network, test.testNet, test.simNet, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q testnet=%v simnet=%v: want %v, got %v",
+				test.network, test.testNet, test.simNet, test.want.Name,
+				got.Name)
+		}
+	}
+}
+
+func TestDirName(t *testing.T) {
+	for params, want := range map[*Params]string{
+		&MainNet:  "mainnet",
+		&TestNet3: "testnet3",
+		&SimNet:   "simnet",
+	} {
+		if got := params.DirName(); got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package notify delivers critical alerts to the operators of a voting service
+// through chat bots, such as a locked voting wallet or a flood of low fee
+// tickets, since operators are more likely to watch a chat room than the logs
+// or an inbox.
+//
+// Telegram and Matrix bots are supported.  Alerts of the same kind are sent at
+// most once per cooldown period so that a persisting problem does not flood
+// the chat.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultCooldown is the default minimum time between two alerts of
+	// the same kind.
+	DefaultCooldown = 15 * time.Minute
+
+	// sendTimeout is how long the delivery of an alert to a single bot may
+	// take.
+	sendTimeout = 10 * time.Second
+
+	// maxErrorBodySize is the maximum size of an error response body
+	// included in delivery errors.
+	maxErrorBodySize = 512
+
+	telegramURL = "https://api.telegram.org"
+)
+
+// Kinds of the alerts raised by dcrstakepool and stakepoold.
+const (
+	KindBackendDown  = "backenddown"
+	KindBackendsDown = "backendsdown"
+	KindConnection   = "connection"
+	KindDatabase     = "database"
+	KindLowFeeFlood  = "lowfeeflood"
+	KindMissedVotes  = "missedvotes"
+	KindStaleUsers   = "staleusers"
+	KindVoteErrors   = "voteerrors"
+	KindWalletLocked = "walletlocked"
+	KindWinningNtfns = "winningntfns"
+)
+
+// Notifier delivers messages to operators.
+type Notifier interface {
+	// Name describes the notifier in log messages.
+	Name() string
+	// Notify delivers msg.
+	Notify(ctx context.Context, msg string) error
+}
+
+// Telegram delivers messages to a Telegram chat through a bot.
+type Telegram struct {
+	// Token is the token of the bot given by @BotFather.
+	Token string
+
+	// ChatID is the identifier of the chat, group or channel the bot posts
+	// to, e.g. -1001234567890 or @channelname.
+	ChatID string
+
+	// Client is the HTTP client used to call the Bot API.
+	// http.DefaultClient is used when nil.
+	Client *http.Client
+
+	// apiURL overrides the Bot API address in tests.
+	apiURL string
+}
+
+// Name returns "telegram".
+func (t *Telegram) Name() string {
+	return "telegram"
+}
+
+// Notify posts msg to the chat with the sendMessage method of the Bot API.
+func (t *Telegram) Notify(ctx context.Context, msg string) error {
+	body, err := json.Marshal(struct {
+		ChatID string `json:"chat_id"`
+		Text   string `json:"text"`
+	}{t.ChatID, msg})
+	if err != nil {
+		return err
+	}
+
+	apiURL := t.apiURL
+	if apiURL == "" {
+		apiURL = telegramURL
+	}
+	u := apiURL + "/bot" + t.Token + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u,
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// The token is part of the URL, so errors must not include it.
+	return do(t.Client, req, "Telegram sendMessage")
+}
+
+// Matrix delivers messages to a Matrix room through a bot account.
+type Matrix struct {
+	// HomeServer is the address of the homeserver of the bot account,
+	// e.g. https://matrix.example.com.
+	HomeServer string
+
+	// Token is the access token of the bot account.
+	Token string
+
+	// RoomID is the identifier of the room the bot posts to, e.g.
+	// !abcdefg:example.com.  The bot must have joined the room.
+	RoomID string
+
+	// Client is the HTTP client used to call the homeserver.
+	// http.DefaultClient is used when nil.
+	Client *http.Client
+
+	// txnID makes the transaction identifiers of the messages unique.  It
+	// is accessed atomically.
+	txnID uint64
+}
+
+// Name returns "matrix".
+func (m *Matrix) Name() string {
+	return "matrix"
+}
+
+// Notify sends msg to the room as a text message.
+func (m *Matrix) Notify(ctx context.Context, msg string) error {
+	body, err := json.Marshal(struct {
+		MsgType string `json:"msgtype"`
+		Body    string `json:"body"`
+	}{"m.text", msg})
+	if err != nil {
+		return err
+	}
+
+	// The homeserver deduplicates messages by transaction identifier, so
+	// it must not be reused across restarts either.
+	txnID := strconv.FormatInt(time.Now().UnixNano(), 10) + "." +
+		strconv.FormatUint(atomic.AddUint64(&m.txnID, 1), 10)
+	u := strings.TrimRight(m.HomeServer, "/") + "/_matrix/client/r0/rooms/" +
+		url.PathEscape(m.RoomID) + "/send/m.room.message/" + txnID
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u,
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.Token)
+	return do(m.Client, req, "Matrix send")
+}
+
+// do sends req with client and returns an error describing the request as
+// what when it fails or is not answered with a 2xx status.
+func do(client *http.Client, req *http.Request, what string) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			// Do not leak the URL, which may hold a token.
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s failed: %v", what, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("%s returned %s: %s", what, resp.Status,
+			strings.TrimSpace(string(b)))
+	}
+	return nil
+}
+
+// Config holds the options of the bots alerts are sent with.  A bot is
+// enabled when its token is set.
+type Config struct {
+	TelegramToken    string
+	TelegramChatID   string
+	MatrixHomeServer string
+	MatrixToken      string
+	MatrixRoomID     string
+}
+
+// Notifiers returns the notifiers of the bots enabled by cfg, or an error
+// naming the option missing for an enabled bot.
+func (cfg *Config) Notifiers() ([]Notifier, error) {
+	var notifiers []Notifier
+	if cfg.TelegramToken != "" {
+		if cfg.TelegramChatID == "" {
+			return nil, errors.New("telegramchatid must be set with telegramtoken")
+		}
+		notifiers = append(notifiers, &Telegram{
+			Token:  cfg.TelegramToken,
+			ChatID: cfg.TelegramChatID,
+		})
+	}
+	if cfg.MatrixToken != "" {
+		if cfg.MatrixHomeServer == "" || cfg.MatrixRoomID == "" {
+			return nil, errors.New("matrixhomeserver and matrixroomid must " +
+				"be set with matrixtoken")
+		}
+		u, err := url.Parse(cfg.MatrixHomeServer)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") ||
+			u.Host == "" {
+			return nil, fmt.Errorf("matrixhomeserver %q is not an http(s) URL",
+				cfg.MatrixHomeServer)
+		}
+		notifiers = append(notifiers, &Matrix{
+			HomeServer: cfg.MatrixHomeServer,
+			Token:      cfg.MatrixToken,
+			RoomID:     cfg.MatrixRoomID,
+		})
+	}
+	return notifiers, nil
+}
+
+// Alerter sends alerts to all of its notifiers.  A nil *Alerter discards all
+// alerts, so callers need not check whether any notifier is configured.
+type Alerter struct {
+	source    string
+	cooldown  time.Duration
+	notifiers []Notifier
+
+	mtx  sync.Mutex
+	sent map[string]time.Time // [kind]
+}
+
+// NewAlerter returns an Alerter sending to notifiers, or nil when there are
+// none.  Messages are prefixed with source so that operators can tell which
+// instance raised them.  Alerts of a kind are not sent again within cooldown.
+func NewAlerter(source string, cooldown time.Duration, notifiers ...Notifier) *Alerter {
+	if len(notifiers) == 0 {
+		return nil
+	}
+	return &Alerter{
+		source:    source,
+		cooldown:  cooldown,
+		notifiers: notifiers,
+		sent:      make(map[string]time.Time),
+	}
+}
+
+// Alert sends msg to all notifiers in the background unless an alert of the
+// same kind was sent within the cooldown.  It returns whether the alert is
+// sent.  Delivery errors are logged.
+func (a *Alerter) Alert(kind, msg string) bool {
+	if a == nil || !a.due(kind, time.Now()) {
+		return false
+	}
+	if a.source != "" {
+		msg = "[" + a.source + "] " + msg
+	}
+	for _, n := range a.notifiers {
+		go func(n Notifier) {
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+			if err := n.Notify(ctx, msg); err != nil {
+				log.Errorf("Unable to send %s alert with %s: %v", kind,
+					n.Name(), err)
+			}
+		}(n)
+	}
+	return true
+}
+
+// due returns whether an alert of kind may be sent at now, and records it as
+// sent when it may.
+func (a *Alerter) due(kind string, now time.Time) bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if last, ok := a.sent[kind]; ok && now.Sub(last) < a.cooldown {
+		return false
+	}
+	a.sent[kind] = now
+	return true
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTelegram(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/bottoken/sendMessage" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		var body struct {
+			ChatID string `json:"chat_id"`
+			Text   string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil ||
+			body.ChatID != "chat" || body.Text != "alert" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "token", ChatID: "chat", apiURL: srv.URL}
+	if err := tg.Notify(context.Background(), "alert"); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	tg.ChatID = "other"
+	err := tg.Notify(context.Background(), "alert")
+	if err == nil {
+		t.Fatal("Notify succeeded with a bad request")
+	}
+	if strings.Contains(err.Error(), "token") {
+		t.Fatalf("error %q leaks the token", err)
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	var txnIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "/_matrix/client/r0/rooms/!room:example.com/send/m.room.message/"
+		if r.Method != http.MethodPut || !strings.HasPrefix(r.URL.Path, prefix) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		txnIDs = append(txnIDs, strings.TrimPrefix(r.URL.Path, prefix))
+		w.Write([]byte(`{"event_id":"$event"}`))
+	}))
+	defer srv.Close()
+
+	m := &Matrix{HomeServer: srv.URL + "/", Token: "token",
+		RoomID: "!room:example.com"}
+	for i := 0; i < 2; i++ {
+		if err := m.Notify(context.Background(), "alert"); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+	}
+	if len(txnIDs) != 2 || txnIDs[0] == txnIDs[1] {
+		t.Fatalf("transaction ids %v are not unique", txnIDs)
+	}
+
+	m.Token = "other"
+	if err := m.Notify(context.Background(), "alert"); err == nil {
+		t.Fatal("Notify succeeded with a bad token")
+	}
+}
+
+func TestConfigNotifiers(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    int
+		wantErr bool
+	}{{
+		name: "none",
+	}, {
+		name: "telegram",
+		cfg:  Config{TelegramToken: "token", TelegramChatID: "chat"},
+		want: 1,
+	}, {
+		name: "both",
+		cfg: Config{TelegramToken: "token", TelegramChatID: "chat",
+			MatrixHomeServer: "https://matrix.example.com",
+			MatrixToken:      "token", MatrixRoomID: "!room:example.com"},
+		want: 2,
+	}, {
+		name:    "telegram without chat",
+		cfg:     Config{TelegramToken: "token"},
+		wantErr: true,
+	}, {
+		name:    "matrix without room",
+		cfg:     Config{MatrixHomeServer: "https://matrix.example.com", MatrixToken: "token"},
+		wantErr: true,
+	}, {
+		name: "matrix without scheme",
+		cfg: Config{MatrixHomeServer: "matrix.example.com",
+			MatrixToken: "token", MatrixRoomID: "!room:example.com"},
+		wantErr: true,
+	}}
+	for _, test := range tests {
+		notifiers, err := test.cfg.Notifiers()
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if len(notifiers) != test.want {
+			t.Errorf("%s: got %d notifiers, want %d", test.name,
+				len(notifiers), test.want)
+		}
+	}
+}
+
+type chanNotifier chan string
+
+func (c chanNotifier) Name() string { return "chan" }
+
+func (c chanNotifier) Notify(ctx context.Context, msg string) error {
+	c <- msg
+	return nil
+}
+
+func TestAlerter(t *testing.T) {
+	if NewAlerter("test", time.Minute) != nil {
+		t.Fatal("alerter created without notifiers")
+	}
+	var nilAlerter *Alerter
+	if nilAlerter.Alert(KindWalletLocked, "alert") {
+		t.Fatal("nil alerter sent an alert")
+	}
+
+	c := make(chanNotifier, 1)
+	a := NewAlerter("test", time.Minute, c)
+	if !a.Alert(KindWalletLocked, "wallet locked") {
+		t.Fatal("first alert not sent")
+	}
+	if msg := <-c; msg != "[test] wallet locked" {
+		t.Fatalf("got message %q", msg)
+	}
+	if a.Alert(KindWalletLocked, "wallet locked") {
+		t.Fatal("alert sent again within the cooldown")
+	}
+	if !a.Alert(KindVoteErrors, "vote errors") {
+		t.Fatal("alert of another kind not sent")
+	}
+	<-c
+
+	now := time.Now()
+	if !a.due(KindLowFeeFlood, now) || a.due(KindLowFeeFlood, now.Add(time.Minute-1)) {
+		t.Fatal("alert due within the cooldown")
+	}
+	if !a.due(KindLowFeeFlood, now.Add(time.Minute)) {
+		t.Fatal("alert not due after the cooldown")
+	}
+}
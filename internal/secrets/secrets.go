@@ -0,0 +1,221 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package secrets resolves references to secrets given in place of sensitive
+// configuration values, so that passwords and keys do not need to be stored
+// in plaintext configuration files.
+//
+// A configuration value is a reference when it starts with one of these
+// prefixes:
+//
+//	env:NAME         the value of the environment variable NAME
+//	file:NAME        the contents of the file NAME in the secrets directory
+//	vault:PATH#KEY   the field KEY of the HashiCorp Vault secret at PATH
+//
+// Any other value is used as is.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	envPrefix   = "env:"
+	filePrefix  = "file:"
+	vaultPrefix = "vault:"
+
+	// vaultTimeout is how long a request for a secret from Vault may take.
+	vaultTimeout = 10 * time.Second
+
+	// maxSecretSize is the maximum size of a secret read from a file or
+	// Vault response.
+	maxSecretSize = 1 << 20
+)
+
+// Resolver resolves secret references.  The zero value resolves environment
+// variable references only.
+type Resolver struct {
+	// Dir is the directory holding one file per secret for file: references.
+	Dir string
+
+	// VaultAddr is the address of the Vault server for vault: references,
+	// e.g. https://vault.example.com:8200.
+	VaultAddr string
+
+	// VaultToken is the token used to authenticate to Vault.
+	VaultToken string
+
+	// Client is the HTTP client used to request secrets from Vault.
+	// http.DefaultClient is used when nil.
+	Client *http.Client
+}
+
+// NewResolver returns a Resolver of the secrets in dir and on the Vault server
+// at vaultAddr.  vaultToken defaults to the VAULT_TOKEN environment variable
+// and may itself be an env: or file: reference, but not a vault: one.
+func NewResolver(dir, vaultAddr, vaultToken string) (*Resolver, error) {
+	r := &Resolver{Dir: dir, VaultAddr: vaultAddr}
+	if vaultToken == "" {
+		vaultToken = os.Getenv("VAULT_TOKEN")
+	}
+	if strings.HasPrefix(vaultToken, vaultPrefix) {
+		return nil, errors.New("vaulttoken cannot be stored in Vault")
+	}
+	token, err := r.Resolve(vaultToken)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve vaulttoken: %v", err)
+	}
+	r.VaultToken = token
+	return r, nil
+}
+
+// IsReference returns whether value is a secret reference.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, envPrefix) ||
+		strings.HasPrefix(value, filePrefix) ||
+		strings.HasPrefix(value, vaultPrefix)
+}
+
+// Resolve returns the secret referenced by value, or value itself when it is
+// not a reference.
+func (r *Resolver) Resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, envPrefix):
+		name := value[len(envPrefix):]
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+
+	case strings.HasPrefix(value, filePrefix):
+		return r.readFile(value[len(filePrefix):])
+
+	case strings.HasPrefix(value, vaultPrefix):
+		return r.readVault(value[len(vaultPrefix):])
+	}
+	return value, nil
+}
+
+// ResolveAll replaces each of the passed values which is a secret reference
+// with the secret it references.  names are used to describe the values in
+// errors and must have the same length as values.
+func (r *Resolver) ResolveAll(names []string, values []*string) error {
+	for i, v := range values {
+		if !IsReference(*v) {
+			continue
+		}
+		secret, err := r.Resolve(*v)
+		if err != nil {
+			return fmt.Errorf("unable to resolve %s: %v", names[i], err)
+		}
+		*v = secret
+	}
+	return nil
+}
+
+// readFile returns the contents of the file name in the secrets directory
+// without a trailing newline.
+func (r *Resolver) readFile(name string) (string, error) {
+	if r.Dir == "" {
+		return "", errors.New("no secrets directory is set")
+	}
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid secret file name %q", name)
+	}
+
+	f, err := os.Open(filepath.Join(r.Dir, name))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(f, maxSecretSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(b) > maxSecretSize {
+		return "", fmt.Errorf("secret file %s is too large", name)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// readVault returns a field of a secret stored in Vault.  ref has the form
+// path#key, where path is the API path of the secret without the /v1 prefix,
+// e.g. secret/data/dcrstakepool#cookiesecret.  Both version 1 and version 2
+// of the key/value secrets engine are supported.
+func (r *Resolver) readVault(ref string) (string, error) {
+	if r.VaultAddr == "" {
+		return "", errors.New("no Vault address is set")
+	}
+	i := strings.LastIndex(ref, "#")
+	if i <= 0 || i == len(ref)-1 {
+		return "", fmt.Errorf("invalid Vault reference %q, use path#key", ref)
+	}
+	path, key := strings.Trim(ref[:i], "/"), ref[i+1:]
+
+	ctx, cancel := context.WithTimeout(context.Background(), vaultTimeout)
+	defer cancel()
+	url := strings.TrimRight(r.VaultAddr, "/") + "/v1/" + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	if r.VaultToken != "" {
+		req.Header.Set("X-Vault-Token", r.VaultToken)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vault returned %s for %s", resp.Status, path)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxSecretSize)).Decode(&body)
+	if err != nil {
+		return "", fmt.Errorf("invalid Vault response for %s: %v", path, err)
+	}
+
+	// Version 2 of the key/value engine nests the secret in a second data
+	// object next to its metadata.
+	data := body.Data
+	if nested, ok := data["data"]; ok {
+		if _, ok := data["metadata"]; ok {
+			data = nil
+			if err := json.Unmarshal(nested, &data); err != nil {
+				return "", fmt.Errorf("invalid Vault response for %s: %v",
+					path, err)
+			}
+		}
+	}
+
+	raw, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("Vault secret %s has no key %s", path, key)
+	}
+	var secret string
+	if err := json.Unmarshal(raw, &secret); err != nil {
+		return "", fmt.Errorf("Vault secret %s key %s is not a string", path, key)
+	}
+	return secret, nil
+}
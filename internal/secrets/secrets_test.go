@@ -0,0 +1,130 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secrets
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "dbpassword"), []byte("filepass\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/vsp":
+			w.Write([]byte(`{"data":{"data":{"cookiesecret":"v2secret"},"metadata":{"version":3}}}`))
+		case "/v1/kv/vsp":
+			w.Write([]byte(`{"data":{"apisecret":"v1secret","port":3306}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer vault.Close()
+
+	os.Setenv("SECRETS_TEST_VAR", "envpass")
+	defer os.Unsetenv("SECRETS_TEST_VAR")
+
+	r := &Resolver{Dir: dir, VaultAddr: vault.URL, VaultToken: "token"}
+	tests := []struct {
+		value, want string
+		wantErr     bool
+	}{
+		{value: "plain", want: "plain"},
+		{value: "", want: ""},
+		{value: "env:SECRETS_TEST_VAR", want: "envpass"},
+		{value: "env:SECRETS_TEST_UNSET", wantErr: true},
+		{value: "file:dbpassword", want: "filepass"},
+		{value: "file:missing", wantErr: true},
+		{value: "file:../dbpassword", wantErr: true},
+		{value: "vault:secret/data/vsp#cookiesecret", want: "v2secret"},
+		{value: "vault:/kv/vsp#apisecret", want: "v1secret"},
+		{value: "vault:kv/vsp#port", wantErr: true},
+		{value: "vault:kv/vsp#missing", wantErr: true},
+		{value: "vault:kv/other#apisecret", wantErr: true},
+		{value: "vault:kv/vsp", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := r.Resolve(test.value)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %v", test.value, err,
+				test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.value, got, test.want)
+		}
+	}
+
+	// References need the backend they use to be configured.
+	var zero Resolver
+	if _, err := zero.Resolve("file:dbpassword"); err == nil {
+		t.Error("file reference resolved without a secrets directory")
+	}
+	if _, err := zero.Resolve("vault:kv/vsp#apisecret"); err == nil {
+		t.Error("Vault reference resolved without a Vault address")
+	}
+	r.VaultToken = "wrong"
+	if _, err := r.Resolve("vault:kv/vsp#apisecret"); err == nil {
+		t.Error("Vault reference resolved with a wrong token")
+	}
+}
+
+func TestResolveAll(t *testing.T) {
+	os.Setenv("SECRETS_TEST_VAR", "envpass")
+	defer os.Unsetenv("SECRETS_TEST_VAR")
+
+	a, b := "env:SECRETS_TEST_VAR", "plain"
+	var r Resolver
+	err := r.ResolveAll([]string{"a", "b"}, []*string{&a, &b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != "envpass" || b != "plain" {
+		t.Fatalf("got %q, %q", a, b)
+	}
+
+	c := "env:SECRETS_TEST_UNSET"
+	if err := r.ResolveAll([]string{"c"}, []*string{&c}); err == nil {
+		t.Fatal("unresolvable reference did not fail")
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	os.Setenv("SECRETS_TEST_TOKEN", "s.token")
+	defer os.Unsetenv("SECRETS_TEST_TOKEN")
+
+	r, err := NewResolver("/run/secrets", "https://vault.example.com",
+		"env:SECRETS_TEST_TOKEN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Dir != "/run/secrets" || r.VaultToken != "s.token" {
+		t.Fatalf("got resolver %+v", r)
+	}
+
+	if _, err := NewResolver("", "", "vault:secret/token#token"); err == nil {
+		t.Fatal("token stored in Vault was accepted")
+	}
+	if _, err := NewResolver("", "", "env:SECRETS_TEST_UNSET"); err == nil {
+		t.Fatal("unresolvable token did not fail")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package signedurl signs URLs with an expiry, so that the resources they
+// point to, such as the exports of admins, can be downloaded by scripts
+// without an authenticated session until the link expires.
+//
+// A signed URL carries its expiry in unix seconds in the expires query value
+// and the HMAC-SHA256 of its path and other query values in the sig query
+// value.  The HMAC key is derived from a secret, so that it differs from the
+// other keys derived from the same secret.
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	expiresParam   = "expires"
+	signatureParam = "sig"
+
+	// keyContext is the message the HMAC keys are derived from secrets
+	// with.
+	keyContext = "dcrstakepool signed url"
+)
+
+var (
+	// ErrUnsigned is returned by Verify for URLs without a signature.
+	ErrUnsigned = errors.New("the URL is not signed")
+
+	// ErrInvalidSignature is returned by Verify for URLs whose signature or
+	// expiry is invalid, e.g. because the URL was altered.
+	ErrInvalidSignature = errors.New("invalid URL signature")
+
+	// ErrExpired is returned by Verify for validly signed URLs which
+	// expired.
+	ErrExpired = errors.New("the signed URL expired")
+)
+
+// Signer signs URLs with the key derived from the current secret and verifies
+// them with the keys derived from the current and previous secrets, so that
+// links stay valid while secrets are rotated.
+type Signer struct {
+	keys [][]byte
+}
+
+// NewSigner returns a Signer whose keys are derived from current and
+// previous.
+func NewSigner(current string, previous []string) *Signer {
+	s := new(Signer)
+	for _, secret := range append([]string{current}, previous...) {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(keyContext))
+		s.keys = append(s.keys, mac.Sum(nil))
+	}
+	return s
+}
+
+// signature returns the signature of the path and query of a URL with key.
+func signature(key []byte, path string, query url.Values) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(path))
+	mac.Write([]byte{'?'})
+	mac.Write([]byte(query.Encode()))
+	return mac.Sum(nil)
+}
+
+// Sign returns rawURL, which may be a path, with the query values which make
+// it valid until expires.
+func (s *Signer) Sign(rawURL string, expires time.Time) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Del(signatureParam)
+	query.Set(expiresParam, strconv.FormatInt(expires.Unix(), 10))
+	sig := signature(s.keys[0], u.Path, query)
+	query.Set(signatureParam, base64.RawURLEncoding.EncodeToString(sig))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Verify checks that u was signed with the key of any secret of the Signer and
+// did not expire at now.  It returns ErrUnsigned, ErrInvalidSignature or
+// ErrExpired otherwise.
+func (s *Signer) Verify(u *url.URL, now time.Time) error {
+	query := u.Query()
+	encoded := query.Get(signatureParam)
+	if encoded == "" {
+		return ErrUnsigned
+	}
+	query.Del(signatureParam)
+	sig, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	expires, err := strconv.ParseInt(query.Get(expiresParam), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	valid := false
+	for _, key := range s.keys {
+		if hmac.Equal(sig, signature(key, u.Path, query)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	if now.Unix() > expires {
+		return ErrExpired
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package signedurl
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	old := NewSigner("old", nil)
+	s := NewSigner("current", []string{"old"})
+
+	signed, err := s.Sign("/download/scripts.csv?format=csv", now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSigned, err := old.Sign("/download/scripts.csv", now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		now  time.Time
+		want error
+	}{
+		{"valid", signed, now, nil},
+		{"previous secret", oldSigned, now, nil},
+		{"expired", signed, now.Add(time.Hour + time.Second), ErrExpired},
+		{"unsigned", "/download/scripts.csv", now, ErrUnsigned},
+		{"other path", strings.Replace(signed, "scripts", "users", 1), now,
+			ErrInvalidSignature},
+		{"altered query", strings.Replace(signed, "format=csv", "format=json", 1),
+			now, ErrInvalidSignature},
+		{"extended expiry", strings.Replace(signed, "expires=16", "expires=26", 1),
+			now, ErrInvalidSignature},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Verify(u, test.now); err != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+
+	// Links signed with a retired secret are rejected once it is dropped.
+	u, _ := url.Parse(oldSigned)
+	if err := NewSigner("current", nil).Verify(u, now); err != ErrInvalidSignature {
+		t.Errorf("dropped secret: got %v", err)
+	}
+}
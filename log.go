@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 
 	"github.com/decred/dcrstakepool/controllers"
+	"github.com/decred/dcrstakepool/internal/notify"
 	"github.com/decred/dcrstakepool/models"
 	"github.com/decred/dcrstakepool/signal"
 	"github.com/decred/dcrstakepool/stakepooldclient"
@@ -52,7 +53,9 @@ var (
 	controllersLog      = backendLog.Logger("CNTL")
 	log                 = backendLog.Logger("DCRS")
 	modelsLog           = backendLog.Logger("MODL")
+	notifyLog           = backendLog.Logger("NTFY")
 	stakepooldclientLog = backendLog.Logger("GRPC")
+	stakepooldRPCLog    = backendLog.Logger("SRPC")
 	systemLog           = backendLog.Logger("SYTM")
 )
 
@@ -60,7 +63,9 @@ var (
 func init() {
 	controllers.UseLogger(controllersLog)
 	models.UseLogger(modelsLog)
+	notify.UseLogger(notifyLog)
 	stakepooldclient.UseLogger(stakepooldclientLog)
+	stakepooldclient.UseRPCLogger(stakepooldRPCLog)
 	system.UseLogger(systemLog)
 	signal.UseLogger(systemLog)
 }
@@ -71,6 +76,8 @@ var subsystemLoggers = map[string]slog.Logger{
 	"CNTL": controllersLog,
 	"GRPC": stakepooldclientLog,
 	"MODL": modelsLog,
+	"SRPC": stakepooldRPCLog,
+	"NTFY": notifyLog,
 	"SYTM": systemLog,
 }
 
@@ -0,0 +1,60 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/decred/dcrstakepool/controllers"
+	"github.com/decred/dcrstakepool/internal/migration"
+	"github.com/go-gorp/gorp"
+)
+
+// exportUsers writes the migration export of the users to path, which is only
+// readable by its owner since it holds the email addresses of the users.
+func exportUsers(ctx context.Context, controller *controllers.MainController,
+	dbMap *gorp.DbMap, path string) error {
+	export, err := controller.ExportUsers(ctx, dbMap)
+	if err != nil {
+		return fmt.Errorf("unable to export users: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := migration.Write(f, export); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Infof("Exported %d users to %s", len(export.Users), path)
+	return nil
+}
+
+// importUsers adds the users of the migration export at path.
+func importUsers(ctx context.Context, controller *controllers.MainController,
+	dbMap *gorp.DbMap, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	export, err := migration.Read(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	n, err := controller.ImportUsers(ctx, dbMap, export)
+	if err != nil {
+		return fmt.Errorf("unable to import users: %v", err)
+	}
+	log.Infof("Imported %d users from %s.  Their scripts are imported into "+
+		"the voting wallets on the next startup and they have to reset "+
+		"their password to sign in.", n, path)
+	return nil
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// apiKeyIDLen is the number of bytes of the secret's hash used as a key
+	// id.
+	apiKeyIDLen = 8
+
+	// readOnlyScope is the scope claim of read-only tokens, which may only
+	// be used to query the API, e.g. from a public dashboard.
+	readOnlyScope = "read"
+)
+
+var (
+	// ErrUnknownAPIKeyID indicates a token references a key id that is not
+	// present in the keyring, usually because the secret was retired.
+	ErrUnknownAPIKeyID = errors.New("unknown API key id")
+
+	// ErrLegacyAPIToken indicates a token without a key id was presented
+	// after the legacy token grace period ended.
+	ErrLegacyAPIToken = errors.New("legacy API tokens are no longer accepted")
+)
+
+// APIKeyID returns the key id that identifies tokens signed with secret.  It
+// is derived from the secret so that operators only need to configure the
+// secrets themselves.
+func APIKeyID(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:apiKeyIDLen])
+}
+
+// APIKeyring holds the secrets used to sign and verify user API tokens.  New
+// tokens are always signed with the current secret and carry its key id in
+// the header, while tokens signed with any previous secret remain valid until
+// they expire.  This allows the API secret to be rotated without invalidating
+// every issued token at once.
+type APIKeyring struct {
+	signingKID    string
+	keys          map[string][]byte
+	order         []string
+	tokenLifetime time.Duration
+	legacyUntil   time.Time
+	clockSkew     time.Duration
+}
+
+// NewAPIKeyring returns an APIKeyring that signs with current and accepts
+// tokens signed with current or any of previous.  Tokens are issued with the
+// passed lifetime.  Tokens issued before key ids were introduced are accepted
+// until legacyUntil, or indefinitely when it is the zero time.  The expiry
+// and issue times of tokens are checked with a tolerance of clockSkew, for
+// tokens issued by another dcrstakepool instance whose clock is off.
+func NewAPIKeyring(current string, previous []string, lifetime time.Duration,
+	legacyUntil time.Time, clockSkew time.Duration) *APIKeyring {
+	k := &APIKeyring{
+		signingKID:    APIKeyID(current),
+		keys:          make(map[string][]byte, len(previous)+1),
+		tokenLifetime: lifetime,
+		legacyUntil:   legacyUntil,
+		clockSkew:     clockSkew,
+	}
+	for _, secret := range append([]string{current}, previous...) {
+		kid := APIKeyID(secret)
+		if _, ok := k.keys[kid]; ok {
+			continue
+		}
+		k.keys[kid] = []byte(secret)
+		k.order = append(k.order, kid)
+	}
+	return k
+}
+
+// SigningKeyID returns the key id of the secret used to sign new tokens.
+func (k *APIKeyring) SigningKeyID() string {
+	return k.signingKID
+}
+
+// SignToken creates a new API token for the user with the passed id.
+func (k *APIKeyring) SignToken(issuer string, userID int64) (string, error) {
+	return k.sign(issuer, userID, "")
+}
+
+// SignReadOnlyToken creates a new read-only API token for the user with the
+// passed id.  Read-only tokens are rejected by API calls which change the
+// account and do not reveal its redeem script.
+func (k *APIKeyring) SignReadOnlyToken(issuer string, userID int64) (string, error) {
+	return k.sign(issuer, userID, readOnlyScope)
+}
+
+// SignShortLivedToken creates a new API token for the user with the passed id
+// which expires after lifetime instead of the lifetime of the keyring, as
+// issued by the API login.  It returns the token and its expiry.
+func (k *APIKeyring) SignShortLivedToken(issuer string, userID int64,
+	lifetime time.Duration) (string, time.Time, error) {
+	expires := time.Now().Add(lifetime)
+	token, err := k.signUntil(issuer, userID, "", expires)
+	return token, expires, err
+}
+
+// sign creates a new API token for the user with the passed id, limited to
+// scope unless it is empty.
+func (k *APIKeyring) sign(issuer string, userID int64, scope string) (string, error) {
+	return k.signUntil(issuer, userID, scope, time.Now().Add(k.tokenLifetime))
+}
+
+// signUntil creates a new API token for the user with the passed id which
+// expires at expires, limited to scope unless it is empty.
+func (k *APIKeyring) signUntil(issuer string, userID int64, scope string,
+	expires time.Time) (string, error) {
+	now := time.Now()
+
+	claims := make(jwt.MapClaims)
+	claims["iat"] = now.Unix()
+	claims["exp"] = expires.Unix()
+	claims["iss"] = issuer
+	claims["loggedInAs"] = userID
+	if scope != "" {
+		claims["scope"] = scope
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = k.signingKID
+
+	return token.SignedString(k.keys[k.signingKID])
+}
+
+// legacyAllowed returns whether tokens without a key id are still accepted.
+func (k *APIKeyring) legacyAllowed() bool {
+	return k.legacyUntil.IsZero() || time.Now().Before(k.legacyUntil)
+}
+
+// parse verifies tokenString against the HMAC key, and its expiry, issue and
+// not before times with a tolerance of the clock skew of the keyring.
+func (k *APIKeyring) parse(tokenString string, key []byte) (*jwt.Token, error) {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// validate signing algorithm
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v",
+				token.Header["alg"])
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	now := time.Now().Unix()
+	skew := int64(k.clockSkew / time.Second)
+	switch {
+	case !claims.VerifyExpiresAt(now-skew, false):
+		return nil, errors.New("token is expired")
+	case !claims.VerifyIssuedAt(now+skew, false):
+		return nil, errors.New("token used before issued")
+	case !claims.VerifyNotBefore(now+skew, false):
+		return nil, errors.New("token is not valid yet")
+	}
+	return token, nil
+}
+
+// ParseToken verifies an API token and returns the user id it was issued
+// for and whether it is a read-only token.
+func (k *APIKeyring) ParseToken(tokenString string) (int64, bool, error) {
+	unverified, _, err := new(jwt.Parser).ParseUnverified(tokenString,
+		jwt.MapClaims{})
+	if err != nil {
+		return 0, false, err
+	}
+
+	var token *jwt.Token
+	if kid, ok := unverified.Header["kid"].(string); ok {
+		key, ok := k.keys[kid]
+		if !ok {
+			return 0, false, ErrUnknownAPIKeyID
+		}
+		token, err = k.parse(tokenString, key)
+		if err != nil {
+			return 0, false, err
+		}
+		// Tokens carrying a key id must also carry an expiry.
+		claims, _ := token.Claims.(jwt.MapClaims)
+		if _, ok := claims["exp"]; !ok {
+			return 0, false, errors.New("token has no expiry")
+		}
+	} else {
+		// Legacy tokens were signed with whatever secret was configured
+		// at the time, so try each known secret in turn.
+		if !k.legacyAllowed() {
+			return 0, false, ErrLegacyAPIToken
+		}
+		for _, kid := range k.order {
+			token, err = k.parse(tokenString, k.keys[kid])
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return 0, false, errors.New("invalid token")
+	}
+	id, ok := claims["loggedInAs"].(float64)
+	if !ok {
+		return 0, false, errors.New("token has no user id")
+	}
+	scope, _ := claims["scope"].(string)
+	switch scope {
+	case "":
+		return int64(id), false, nil
+	case readOnlyScope:
+		return int64(id), true, nil
+	}
+	return 0, false, fmt.Errorf("unknown token scope %q", scope)
+}
+
+// NeedsRenewal returns whether a stored token should be replaced with a new
+// one.  This is the case for legacy tokens, tokens signed with a retired
+// secret, and tokens that are invalid or in the last tenth of their lifetime.
+func (k *APIKeyring) NeedsRenewal(tokenString string) bool {
+	token, _, err := new(jwt.Parser).ParseUnverified(tokenString,
+		jwt.MapClaims{})
+	if err != nil {
+		return true
+	}
+	if kid, _ := token.Header["kid"].(string); kid != k.signingKID {
+		return true
+	}
+	if _, _, err := k.ParseToken(tokenString); err != nil {
+		return true
+	}
+	claims, _ := token.Claims.(jwt.MapClaims)
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return true
+	}
+	return time.Until(time.Unix(int64(exp), 0)) < k.tokenLifetime/10
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+func legacyToken(t *testing.T, secret string, id int64) string {
+	t.Helper()
+	claims := jwt.MapClaims{
+		"iat":        time.Now().Unix(),
+		"iss":        "http://127.0.0.1:8000",
+		"loggedInAs": id,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).
+		SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAPIKeyring(t *testing.T) {
+	oldKeys := NewAPIKeyring("old", nil, time.Hour, time.Time{}, 0)
+	oldToken, err := oldKeys.SignToken("issuer", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := NewAPIKeyring("new", []string{"old"}, time.Hour, time.Time{}, 0)
+	newToken, err := keys.SignToken("issuer", 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readOnlyToken, err := keys.SignReadOnlyToken("issuer", 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiredKeys := NewAPIKeyring("new", nil, -time.Hour, time.Time{}, 0)
+	expiredToken, err := expiredKeys.SignToken("issuer", 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pastCutoff := NewAPIKeyring("new", []string{"old"}, time.Hour,
+		time.Now().Add(-time.Hour), 0)
+
+	tests := []struct {
+		name         string
+		keys         *APIKeyring
+		token        string
+		wantID       int64
+		wantReadOnly bool
+		wantErr      bool
+		wantRenewal  bool
+	}{
+		{"current key", keys, newToken, 8, false, false, false},
+		{"previous key", keys, oldToken, 7, false, false, true},
+		{"retired key", NewAPIKeyring("new", nil, time.Hour, time.Time{}, 0),
+			oldToken, 0, false, true, true},
+		{"expired", keys, expiredToken, 0, false, true, true},
+		{"read-only", keys, readOnlyToken, 14, true, false, false},
+		{"legacy current secret", keys, legacyToken(t, "new", 10), 10, false, false, true},
+		{"legacy previous secret", keys, legacyToken(t, "old", 11), 11, false, false, true},
+		{"legacy unknown secret", keys, legacyToken(t, "other", 12), 0, false, true, true},
+		{"legacy past cutoff", pastCutoff, legacyToken(t, "new", 13), 0, false, true, true},
+		{"garbage", keys, "not.a.token", 0, false, true, true},
+	}
+
+	for _, test := range tests {
+		id, readOnly, err := test.keys.ParseToken(test.token)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: ParseToken error = %v, wantErr %v", test.name,
+				err, test.wantErr)
+			continue
+		}
+		if id != test.wantID {
+			t.Errorf("%s: got user id %d, want %d", test.name, id, test.wantID)
+		}
+		if readOnly != test.wantReadOnly {
+			t.Errorf("%s: got read-only %v, want %v", test.name, readOnly,
+				test.wantReadOnly)
+		}
+		if got := test.keys.NeedsRenewal(test.token); got != test.wantRenewal {
+			t.Errorf("%s: NeedsRenewal = %v, want %v", test.name, got,
+				test.wantRenewal)
+		}
+	}
+}
+
+func TestSignShortLivedToken(t *testing.T) {
+	keys := NewAPIKeyring("secret", nil, 24*time.Hour, time.Time{}, 0)
+	token, expires, err := keys.SignShortLivedToken("issuer", 5, 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(expires); d <= 14*time.Minute || d > 15*time.Minute {
+		t.Fatalf("unexpected expiry in %v", d)
+	}
+	id, readOnly, err := keys.ParseToken(token)
+	if err != nil || id != 5 || readOnly {
+		t.Fatalf("ParseToken: got %d, %v, %v", id, readOnly, err)
+	}
+
+	expired, _, err := keys.SignShortLivedToken("issuer", 5, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := keys.ParseToken(expired); err == nil {
+		t.Fatal("expired token accepted")
+	}
+}
+
+func TestAPIKeyringClockSkew(t *testing.T) {
+	// A token which expired 10 seconds ago, as seen by an instance whose
+	// clock is ahead.
+	expired, err := NewAPIKeyring("secret", nil, -10*time.Second,
+		time.Time{}, 0).SignToken("issuer", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A token issued 10 seconds from now, by an instance whose clock is
+	// ahead.
+	claims := jwt.MapClaims{
+		"iat":        time.Now().Add(10 * time.Second).Unix(),
+		"exp":        time.Now().Add(time.Hour).Unix(),
+		"iss":        "issuer",
+		"loggedInAs": 3,
+	}
+	future := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	future.Header["kid"] = APIKeyID("secret")
+	issued, err := future.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strict := NewAPIKeyring("secret", nil, time.Hour, time.Time{}, 0)
+	tolerant := NewAPIKeyring("secret", nil, time.Hour, time.Time{},
+		30*time.Second)
+	for _, token := range []string{expired, issued} {
+		if _, _, err := strict.ParseToken(token); err == nil {
+			t.Error("token accepted without tolerance")
+		}
+		if id, _, err := tolerant.ParseToken(token); err != nil || id != 3 {
+			t.Errorf("token refused with tolerance: %d, %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// argon2SaltLen is the length in bytes of the random salt used for each
+	// argon2id password hash.
+	argon2SaltLen = 16
+
+	// argon2KeyLen is the length in bytes of argon2id password hashes.
+	argon2KeyLen = 32
+)
+
+// argon2Prefix begins every argon2id password hash stored in the Users table.
+var argon2Prefix = []byte("$argon2id$")
+
+// ErrPasswordMismatch is returned when a password does not match the stored
+// hash.
+var ErrPasswordMismatch = errors.New("password does not match")
+
+// Argon2Params are the argon2id parameters used to hash passwords.
+type Argon2Params struct {
+	// Time is the number of passes over the memory.
+	Time uint32
+
+	// Memory is the amount of memory used in KiB.
+	Memory uint32
+
+	// Threads is the degree of parallelism.
+	Threads uint8
+}
+
+// DefaultArgon2Params are the argon2id parameters recommended by RFC 9106 for
+// memory constrained environments.
+var DefaultArgon2Params = Argon2Params{
+	Time:    3,
+	Memory:  64 * 1024,
+	Threads: 4,
+}
+
+// argon2Params are the parameters used for new password hashes.
+var argon2Params = DefaultArgon2Params
+
+// SetArgon2Params sets the argon2id parameters used to hash passwords.
+// Existing hashes created with different parameters are rehashed the next time
+// their user logs in.  It must be called before any passwords are hashed.
+func SetArgon2Params(params Argon2Params) {
+	argon2Params = params
+}
+
+// encodeArgon2 returns the hash of password in the PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>.
+func encodeArgon2(password string, salt []byte, params Argon2Params) []byte {
+	key := argon2.IDKey([]byte(password), salt, params.Time, params.Memory,
+		params.Threads, argon2KeyLen)
+	b64 := base64.RawStdEncoding
+	return []byte(fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Time, params.Threads,
+		b64.EncodeToString(salt), b64.EncodeToString(key)))
+}
+
+// decodeArgon2 parses an argon2id hash in the PHC string format and returns
+// its parameters, salt and key.
+func decodeArgon2(hash []byte) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id version: %v", err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version %d",
+			version)
+	}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory,
+		&params.Time, &params.Threads)
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters: %v", err)
+	}
+
+	b64 := base64.RawStdEncoding
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %v", err)
+	}
+	key, err := b64.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id key: %v", err)
+	}
+
+	return params, salt, key, nil
+}
+
+// HashPassword hashes the passed password string using argon2id.
+func (user *User) HashPassword(password string) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		log.Criticalf("Couldn't hash password: %v", err)
+		panic(err)
+	}
+	user.Password = encodeArgon2(password, salt, argon2Params)
+}
+
+// CheckPassword returns nil when password matches the user's stored password
+// hash.  Both argon2id and legacy bcrypt hashes are supported.
+func (user *User) CheckPassword(password string) error {
+	if !bytes.HasPrefix(user.Password, argon2Prefix) {
+		err := bcrypt.CompareHashAndPassword(user.Password, []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return ErrPasswordMismatch
+		}
+		return err
+	}
+
+	params, salt, key, err := decodeArgon2(user.Password)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory,
+		params.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+// PasswordNeedsRehash returns whether the user's stored password hash is a
+// legacy bcrypt hash or an argon2id hash created with parameters other than the
+// current ones.
+func (user *User) PasswordNeedsRehash() bool {
+	if !bytes.HasPrefix(user.Password, argon2Prefix) {
+		return true
+	}
+	params, _, _, err := decodeArgon2(user.Password)
+	return err != nil || params != argon2Params
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHash(t *testing.T) {
+	params := Argon2Params{Time: 1, Memory: 64, Threads: 1}
+	SetArgon2Params(params)
+	defer SetArgon2Params(DefaultArgon2Params)
+
+	var user User
+	user.HashPassword("password")
+	if err := user.CheckPassword("password"); err != nil {
+		t.Fatalf("CheckPassword: %v", err)
+	}
+	if err := user.CheckPassword("wrong"); err != ErrPasswordMismatch {
+		t.Fatalf("CheckPassword with wrong password: got %v, want %v", err,
+			ErrPasswordMismatch)
+	}
+	if user.PasswordNeedsRehash() {
+		t.Fatal("new hash needs rehash")
+	}
+
+	// Hashing the same password twice must use different salts.
+	other := User{}
+	other.HashPassword("password")
+	if string(other.Password) == string(user.Password) {
+		t.Fatal("hashes of the same password are equal")
+	}
+
+	// Changing the parameters requires existing hashes to be rehashed, but
+	// they must still verify.
+	SetArgon2Params(Argon2Params{Time: 2, Memory: 64, Threads: 1})
+	if !user.PasswordNeedsRehash() {
+		t.Fatal("hash with outdated parameters does not need rehash")
+	}
+	if err := user.CheckPassword("password"); err != nil {
+		t.Fatalf("CheckPassword with outdated parameters: %v", err)
+	}
+}
+
+func TestPasswordLegacyBcrypt(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := User{Password: hash}
+	if err := user.CheckPassword("password"); err != nil {
+		t.Fatalf("CheckPassword: %v", err)
+	}
+	if err := user.CheckPassword("wrong"); err != ErrPasswordMismatch {
+		t.Fatalf("CheckPassword with wrong password: got %v, want %v", err,
+			ErrPasswordMismatch)
+	}
+	if !user.PasswordNeedsRehash() {
+		t.Fatal("bcrypt hash does not need rehash")
+	}
+}
+
+func TestDecodeArgon2Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5",
+	}
+	for _, hash := range tests {
+		if _, _, _, err := decodeArgon2([]byte(hash)); err == nil {
+			t.Errorf("decodeArgon2(%q) succeeded", hash)
+		}
+	}
+}
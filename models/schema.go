@@ -0,0 +1,157 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/decred/dcrstakepool/internal/dbschema"
+	"github.com/go-gorp/gorp"
+)
+
+// schemaModels are the models of the tables registered by newDbMap.
+var schemaModels = []interface{}{
+	AddressIndex{}, AddressJob{}, AdminAudit{}, APIRefreshToken{},
+	EmailChange{}, ExpiredScript{}, FeatureFlag{}, HistoricTicket{},
+	HistoryImport{}, InviteCode{}, LowFeeTicket{}, Message{}, MissedTicket{},
+	PasswordReset{}, QueuedEmail{}, RetiredScript{}, Session{},
+	StakeInfoSnapshot{}, StakepooldUpdate{}, TicketFee{}, TOSAcceptance{},
+	User{}, UserActivity{}, UserNote{}, VotingFreeze{}, Webhook{},
+	WebhookDelivery{}, WebhookTicket{},
+}
+
+// schemaIndexes are the indexes which the queries of dcrstakepool rely on.
+// gorp only creates the primary and unique keys, so they are added by
+// CheckSchema.
+var schemaIndexes = []dbschema.Index{
+	{Name: "idx_Session_Token", Table: "Session", Columns: []string{"Token"},
+		Reason: "loading the session of every request"},
+	{Name: "idx_Session_UserId", Table: "Session", Columns: []string{"UserId"},
+		Reason: "signing out all sessions of a user"},
+	{Name: "idx_Users_Email", Table: "Users", Columns: []string{"Email"},
+		Reason: "signing in and registration"},
+	{Name: "idx_Users_MultiSigAddress", Table: "Users",
+		Columns: []string{"MultiSigAddress"},
+		Reason:  "finding the owners of tickets"},
+	{Name: "idx_Users_UserPubKeyAddr", Table: "Users",
+		Columns: []string{"UserPubKeyAddr"},
+		Reason:  "address submission"},
+	{Name: "idx_Users_BadgeToken", Table: "Users",
+		Columns: []string{"BadgeToken"},
+		Reason:  "serving status badges"},
+	{Name: "idx_LowFeeTicket_TicketHash", Table: "LowFeeTicket",
+		Columns: []string{"TicketHash"},
+		Reason:  "adding and removing low fee tickets"},
+	{Name: "idx_AddressJob_UserId", Table: "AddressJob",
+		Columns: []string{"UserId"},
+		Reason:  "the address setup status page"},
+	{Name: "idx_ExpiredScript_UserId", Table: "ExpiredScript",
+		Columns: []string{"UserId"},
+		Reason:  "reactivating expired scripts"},
+	{Name: "idx_RetiredScript_UserId", Table: "RetiredScript",
+		Columns: []string{"UserId"},
+		Reason:  "the replaced scripts on the admin user page"},
+	{Name: "idx_HistoricTicket_UserId", Table: "HistoricTicket",
+		Columns: []string{"UserId"},
+		Reason:  "the pre-migration history on the tickets page"},
+	{Name: "idx_Message_UserId", Table: "Message", Columns: []string{"UserId"},
+		Reason: "listing the messages of a user"},
+	{Name: "idx_MissedTicket_UserId", Table: "MissedTicket",
+		Columns: []string{"UserId"},
+		Reason:  "the tickets page"},
+	{Name: "idx_TicketFee_UserId", Table: "TicketFee",
+		Columns: []string{"UserId"},
+		Reason:  "the tickets page in the deferred fee mode"},
+	{Name: "idx_InviteCode_Code", Table: "InviteCode", Columns: []string{"Code"},
+		Reason: "registration with an invite code"},
+	{Name: "idx_UserNote_UserId", Table: "UserNote",
+		Columns: []string{"UserId"},
+		Reason:  "the notes on the admin user page"},
+	{Name: "idx_UserActivity_UserId", Table: "UserActivity",
+		Columns: []string{"UserId"},
+		Reason:  "the activity timeline on the settings page"},
+	{Name: "idx_AdminAudit_AdminUid", Table: "AdminAudit",
+		Columns: []string{"AdminUid"},
+		Reason:  "the activity timeline on the settings page"},
+	{Name: "idx_TOSAcceptance_UserId", Table: "TOSAcceptance",
+		Columns: []string{"UserId"},
+		Reason:  "the activity timeline on the settings page"},
+	{Name: "idx_APIRefreshToken_TokenHash", Table: "APIRefreshToken",
+		Columns: []string{"TokenHash"},
+		Reason:  "refreshing the tokens of the API login"},
+	{Name: "idx_APIRefreshToken_UserId", Table: "APIRefreshToken",
+		Columns: []string{"UserId"},
+		Reason:  "revoking the refresh tokens of a user"},
+	{Name: "idx_WebhookTicket_UserId", Table: "WebhookTicket",
+		Columns: []string{"UserId"},
+		Reason:  "checking the tickets of users with a webhook"},
+	{Name: "idx_WebhookDelivery_UserId", Table: "WebhookDelivery",
+		Columns: []string{"UserId"},
+		Reason:  "the webhook delivery log on the settings page"},
+	{Name: "idx_WebhookDelivery_NextAttempt", Table: "WebhookDelivery",
+		Columns: []string{"NextAttempt"},
+		Reason:  "sending queued webhook events"},
+	{Name: "idx_StakeInfoSnapshot_Created", Table: "StakeInfoSnapshot",
+		Columns: []string{"Created"},
+		Reason:  "the luck of the voting service on the stats page"},
+}
+
+// schemaTables returns the tables and columns of the models registered with
+// dbMap.
+func schemaTables(dbMap *gorp.DbMap) ([]dbschema.Table, error) {
+	tables := make([]dbschema.Table, 0, len(schemaModels))
+	for _, m := range schemaModels {
+		tm, err := dbMap.TableFor(reflect.TypeOf(m), false)
+		if err != nil {
+			return nil, err
+		}
+		t := dbschema.Table{Name: tm.TableName}
+		for _, c := range tm.Columns {
+			if !c.Transient {
+				t.Columns = append(t.Columns, c.ColumnName)
+			}
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// CheckSchema verifies that database has the tables and columns of all models
+// and the indexes needed by the queries of dcrstakepool.  Missing indexes are
+// logged along with the queries which are slow without them, or created when
+// createIndexes is true.  An error is returned when tables or columns are
+// missing, since the queries using them fail.
+func CheckSchema(ctx context.Context, dbMap *gorp.DbMap, database string, createIndexes bool) error {
+	tables, err := schemaTables(dbMap)
+	if err != nil {
+		return err
+	}
+	r, err := dbschema.Verify(ctx, dbMap.Db, database, tables, schemaIndexes,
+		createIndexes, log)
+	if err != nil {
+		return fmt.Errorf("unable to check database schema: %v", err)
+	}
+	if r.Broken() {
+		return fmt.Errorf("database schema is missing %d tables and %d "+
+			"columns", len(r.MissingTables), len(r.MissingColumns))
+	}
+	if len(r.MissingIndexes) > 0 {
+		log.Warnf("%d database indexes are missing; set createmissingindexes "+
+			"to create them on startup", len(r.MissingIndexes))
+	}
+	return nil
+}
+
+// ProbeWrites checks that the database accepts writes with a statement which
+// changes nothing.  It fails while the database is unreachable or refuses
+// writes, e.g. while a replica is promoted during a failover and the old
+// primary is read-only.
+func ProbeWrites(ctx context.Context, dbMap *gorp.DbMap) error {
+	_, err := dbMap.Db.ExecContext(ctx, "UPDATE Users SET UserId = UserId "+
+		"WHERE UserId = 0")
+	return err
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/decred/dcrstakepool/internal/dbschema"
+)
+
+func TestSchemaIndexes(t *testing.T) {
+	tables, err := schemaTables(newDbMap(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A schema with all registered columns must only miss the indexes, so
+	// that every index refers to an existing table and column.
+	s := dbschema.NewSchema()
+	for _, table := range tables {
+		for _, c := range table.Columns {
+			s.AddColumn(table.Name, c)
+		}
+	}
+	r := s.Check(tables, schemaIndexes)
+	if r.Broken() {
+		t.Fatalf("schema is broken: %+v", r)
+	}
+	if len(r.MissingIndexes) != len(schemaIndexes) {
+		t.Fatalf("%d of %d indexes are on unknown columns",
+			len(schemaIndexes)-len(r.MissingIndexes), len(schemaIndexes))
+	}
+}
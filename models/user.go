@@ -6,17 +6,18 @@ package models
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
-	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/go-gorp/gorp"
 	// register database driver
 	_ "github.com/go-sql-driver/mysql"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // HashList represents a slice of hash strings.
@@ -86,6 +87,82 @@ func UserTokenFromStr(token string) (UserToken, error) {
 	return ut, nil
 }
 
+// AdminAudit is used for DB responses and records an action an admin took
+// from the admin pages, so that changes made by any of the admins of a voting
+// service can be traced back to them.  Email changes of users are recorded
+// as well, with AdminUID set to the user.  Targets lists the tickets, users,
+// hosts or other objects the action applied to.
+type AdminAudit struct {
+	ID       int64 `db:"AdminAuditID"`
+	AdminUID int64 `db:"AdminUid"`
+	IP       string
+	Action   string
+	Targets  string `db:"Targets,size:1000"`
+	Created  int64
+}
+
+// Accounts of an AddressIndex.  The addresses of the internal branch of the
+// voting account are indexed separately as AddressAccountVotingInternal.
+const (
+	AddressAccountFee            = "fee"
+	AddressAccountVoting         = "voting"
+	AddressAccountVotingInternal = "voting/internal"
+)
+
+// AddressIndex is used for DB responses and records the index of the child key
+// of the branch of the fee or voting account which the address of a user is
+// derived from.  The addresses are derived from the valid children in
+// order, so the index only differs from the user id after an invalid child.
+type AddressIndex struct {
+	ID         int64 `db:"AddressIndexID"`
+	Account    string
+	UserID     int64 `db:"UserId"`
+	ChildIndex int64
+}
+
+// Statuses of an AddressJob.
+const (
+	AddressJobPending   = "pending"
+	AddressJobImporting = "importing"
+	AddressJobComplete  = "complete"
+	AddressJobFailed    = "failed"
+)
+
+// AddressJob is used for DB responses and tracks the setup of the multisig
+// script of a submitted address, so that a setup which failed midway can be
+// shown to the user and retried without redoing the steps which succeeded.
+// ImportedHosts lists the stakepoold instances whose wallet imported the
+// script, separated by commas, out of Wallets.
+type AddressJob struct {
+	ID              int64 `db:"AddressJobID"`
+	UserID          int64 `db:"UserId"`
+	UserPubKeyAddr  string
+	PoolPubKeyAddr  string
+	MultiSigAddress string
+	MultiSigScript  string
+	ImportedHosts   string `db:"ImportedHosts,size:1000"`
+	Wallets         int64
+	HeightImported  int64
+	Status          string
+	Error           string
+	Created         int64
+	Updated         int64
+}
+
+// Imported returns the stakepoold instances whose wallet imported the script.
+func (job *AddressJob) Imported() []string {
+	if job.ImportedHosts == "" {
+		return nil
+	}
+	return strings.Split(job.ImportedHosts, ",")
+}
+
+// AddImported records that the wallet of the stakepoold instance host imported
+// the script.
+func (job *AddressJob) AddImported(host string) {
+	job.ImportedHosts = strings.Join(append(job.Imported(), host), ",")
+}
+
 // EmailChange is used for DB responses and holds information related to an
 // email change.
 type EmailChange struct {
@@ -95,6 +172,99 @@ type EmailChange struct {
 	Token    string
 	Created  int64
 	Expires  int64
+
+	// OldToken confirms the change from the current address of the user
+	// when this is required, or is empty.  NewConfirmed and OldConfirmed
+	// are the times the change was confirmed from either address, or 0.
+	OldToken     string
+	NewConfirmed int64
+	OldConfirmed int64
+}
+
+// Confirmed returns whether the email change was confirmed from the new
+// address, and from the current one if that is required.
+func (ec *EmailChange) Confirmed() bool {
+	return ec.NewConfirmed > 0 && (ec.OldToken == "" || ec.OldConfirmed > 0)
+}
+
+// ExpiredScript is used for DB responses and records the multisig script of
+// a user which was disabled because no tickets were bought with it, so that
+// the user can reactivate it.  Reactivated is the time the user did, or 0 while
+// the script is disabled.
+type ExpiredScript struct {
+	ID              int64 `db:"ExpiredScriptID"`
+	UserID          int64 `db:"UserId"`
+	MultiSigAddress string
+	MultiSigScript  string `db:"MultiSigScript,size:1000"`
+	Expired         int64
+	Reactivated     int64
+}
+
+// FeatureFlag is used for DB responses and holds a flag which gradually
+// rolls out a feature.  While Enabled is 1 the feature is enabled for Percent
+// of the users, or for all of them when Percent is 100.
+type FeatureFlag struct {
+	ID           int64 `db:"FeatureFlagID"`
+	Name         string
+	Description  string
+	Enabled      int64
+	Percent      int64
+	UpdatedByUID int64 `db:"UpdatedByUid"`
+	Updated      int64
+}
+
+// Statuses of a HistoricTicket.
+const (
+	HistoricTicketVoted  = "voted"
+	HistoricTicketMissed = "missed"
+)
+
+// HistoricTicket is used for DB responses and holds a voted or missed ticket
+// of a user from before they migrated to this voting service, found by
+// importing the multisig script they had at their previous voting service.
+// These tickets are only shown as pre-migration history.
+type HistoricTicket struct {
+	ID              int64 `db:"HistoricTicketID"`
+	UserID          int64 `db:"UserId"`
+	MultiSigAddress string
+	TicketHash      string
+	Status          string
+	TicketHeight    int64
+	SpentBy         string
+	SpentByHeight   int64
+	Created         int64
+}
+
+// HistoryImport is used for DB responses and records the import of the
+// multisig script a user had at their previous voting service, with a rescan
+// from RescanHeight, by an admin.  Backfilled is the last time the tickets of
+// the script were stored as HistoricTickets, Tickets of them, or 0 if they
+// were not yet.
+type HistoryImport struct {
+	ID              int64 `db:"HistoryImportID"`
+	UserID          int64 `db:"UserId"`
+	AdminUID        int64 `db:"AdminUid"`
+	MultiSigAddress string
+	MultiSigScript  string `db:"MultiSigScript,size:1000"`
+	RescanHeight    int64
+	Tickets         int64
+	Created         int64
+	Backfilled      int64
+}
+
+// InviteCode is used for DB responses and holds a code which new users must
+// enter to register while the voting service is invite-only.  MaxUses is the
+// number of registrations the code allows and Uses the number it was used
+// for.  Expires is the time after which the code can no longer be used, or 0
+// if it does not expire.
+type InviteCode struct {
+	ID           int64 `db:"InviteCodeID"`
+	Code         string
+	CreatedByUID int64 `db:"CreatedByUid"`
+	MaxUses      int64
+	Uses         int64
+	Created      int64
+	Expires      int64
 }
 
 // LowFeeTicket is used for DB responses and holds low fee ticket information.
@@ -109,6 +279,99 @@ type LowFeeTicket struct {
 	Expires       int64
 }
 
+// Kinds of in-app messages.
+const (
+	MessageKindFeeAddress  = "feeaddress"
+	MessageKindLowFee      = "lowfee"
+	MessageKindMaintenance = "maintenance"
+	MessageKindUserLimit   = "userlimit"
+	MessageKindVoteVersion = "voteversion"
+)
+
+// Message is used for DB responses and holds an in-app message delivered to a
+// user about an event concerning their account or the voting service.  Read
+// is the time the user read the message, or 0 while it is unread.  Low fee
+// messages are written by stakepoold.  Each stakepoold instance writes them,
+// so they have a unique DedupeKey identifying the user, kind and tickets
+// concerned, which is NULL for the other messages.
+type Message struct {
+	ID        int64 `db:"MessageID"`
+	UserID    int64 `db:"UserId"`
+	Kind      string
+	Subject   string
+	Body      string `db:"Body,size:4000"`
+	Created   int64
+	Read      int64
+	DedupeKey sql.NullString `db:"DedupeKey,size:64"`
+}
+
+// Causes of missed tickets recorded by stakepoold.  MissedCauseNetwork means
+// the vote was sent but not included in the next block, all other causes are
+// misses of the voting service.
+const (
+	MissedCausePool         = "pool"
+	MissedCauseWalletLocked = "walletlocked"
+	MissedCauseBroadcast    = "broadcast"
+	MissedCauseUntracked    = "untracked"
+	MissedCauseNetwork      = "network"
+)
+
+// missedCauseLabels describes the causes of missed tickets to users.
+var missedCauseLabels = map[string]string{
+	MissedCausePool:         "Vote could not be created by the VSP",
+	MissedCauseWalletLocked: "Voting wallets were locked",
+	MissedCauseBroadcast:    "Vote could not be broadcast",
+	MissedCauseUntracked:    "Ticket was not tracked by the voting wallets",
+	MissedCauseNetwork:      "Vote not included by the network",
+}
+
+// MissedCauses lists the causes of missed tickets in the order they are shown.
+var MissedCauses = []string{MissedCauseWalletLocked, MissedCauseBroadcast,
+	MissedCauseUntracked, MissedCausePool, MissedCauseNetwork}
+
+// MissedCauseLabel returns the description of the cause of a missed ticket
+// shown to users.
+func MissedCauseLabel(cause string) string {
+	if label, ok := missedCauseLabels[cause]; ok {
+		return label
+	}
+	return cause
+}
+
+// MissedTicket is used for DB responses and holds information about a winning
+// ticket whose vote was not mined.  These rows are written by stakepoold when
+// it audits the votes for each block, with Cause set to one of the
+// MissedCause constants and Reason giving the details.
+type MissedTicket struct {
+	ID          int64 `db:"MissedTicketID"`
+	UserID      int64 `db:"UserId"`
+	TicketHash  string
+	BlockHash   string
+	BlockHeight int64
+	Cause       string
+	Reason      string
+	Created     int64
+}
+
+// CauseLabel returns the description of the cause of the miss shown to users.
+func (m MissedTicket) CauseLabel() string {
+	return MissedCauseLabel(m.Cause)
+}
+
+// QueuedEmail is used for DB responses and holds an email which could not be
+// sent and is retried.  NextAttempt is the unix time of the next attempt, and
+// 0 once the email is no longer retried automatically.
+type QueuedEmail struct {
+	ID          int64 `db:"QueuedEmailID"`
+	Email       string
+	Subject     string
+	Body        string `db:"Body,size:4000"`
+	Attempts    int64
+	LastError   string `db:"LastError,size:1000"`
+	Created     int64
+	NextAttempt int64
+}
+
 // PasswordReset is used for DB responses and holds information related to a
 // password reset.
 type PasswordReset struct {
@@ -119,6 +382,18 @@ type PasswordReset struct {
 	Expires int64
 }
 
+// RetiredScript is used for DB responses and records the multisig script of a
+// user which was replaced, together with the address it was created for,
+// because the user submitted another address before buying tickets with it.
+type RetiredScript struct {
+	ID              int64 `db:"RetiredScriptID"`
+	UserID          int64 `db:"UserId"`
+	MultiSigAddress string
+	MultiSigScript  string `db:"MultiSigScript,size:1000"`
+	UserPubKeyAddr  string
+	Retired         int64
+}
+
 // Session is used for DB responses and holds information about a user's login
 // session.
 type Session struct {
@@ -130,188 +405,1959 @@ type Session struct {
 	Expires int64
 }
 
-// User is used for DB responses and holds information about a user.
-type User struct {
-	ID               int64 `db:"UserId"`
-	Email            string
-	Username         string
-	Password         []byte
+// The ways users pay the pool fees.  With FeeModeCommitment the first
+// commitment of each ticket pays the fee to the fee address of the user.
+// With FeeModeDeferred users pay a separate fee transaction for each ticket,
+// recorded as a TicketFee.  They match the fee modes of stakepoold.
+const (
+	FeeModeCommitment = "commitment"
+	FeeModeDeferred   = "deferred"
+)
+
+// Statuses of ticket fees.
+const (
+	TicketFeePending = "pending"
+	TicketFeePaid    = "paid"
+	TicketFeeExpired = "expired"
+)
+
+// TicketFee is used for DB responses and holds the fee a user owes for a
+// ticket when fees are deferred.  The fee is paid by FeeTx, a transaction
+// paying at least FeeAmount atoms to FeeAddress, which must be submitted
+// before Deadline.  Status is pending until FeeTx is mined, when it becomes
+// paid, or expired when no fee transaction was submitted or mined in time.
+// stakepoold only votes tickets whose fee is paid.
+type TicketFee struct {
+	ID         int64 `db:"TicketFeeID"`
+	UserID     int64 `db:"UserId"`
+	TicketHash string
+	FeeAddress string
+	FeeAmount  int64
+	FeeTxHash  string
+	FeeTx      string `db:"FeeTx,size:4000"`
+	Status     string
+	Created    int64
+	Deadline   int64
+	Paid       int64
+}
+
+// StakeInfoSnapshot is used for DB responses and records the stake info of the
+// voting service wallets at a block, so that the votes of the voting service
+// can be compared with the votes expected from its share of the live tickets
+// over time.  Voted and Missed are the totals of the wallets.
+type StakeInfoSnapshot struct {
+	ID       int64 `db:"StakeInfoSnapshotID"`
+	Height   int64
+	Live     int64
+	PoolSize int64
+	Voted    int64
+	Missed   int64
+	Created  int64
+}
+
+// Reasons of a StakepooldUpdate.
+const (
+	StakepooldUpdateAddress       = "address"
+	StakepooldUpdateVoteBits      = "votebits"
+	StakepooldUpdateRetiredScript = "retiredscript"
+)
+
+// StakepooldUpdate is used for DB responses and records a change of a user
+// which stakepoold was not updated with yet.  It is inserted in the
+// transaction of the change and removed once stakepoold was updated, so that
+// a change whose update failed, or was interrupted by a restart, is sent by
+// the next update rather than lost.
+type StakepooldUpdate struct {
+	ID        int64 `db:"StakepooldUpdateID"`
+	UserID    int64 `db:"UserId"`
+	Reason    string
+	Attempts  int64
+	LastError string `db:"LastError,size:1000"`
+	Created   int64
+}
+
+// TOSAcceptance records a user accepting a version of the voting service's
+// terms of service.
+type TOSAcceptance struct {
+	ID       int64 `db:"TOSAcceptanceID"`
+	UserID   int64 `db:"UserId"`
+	Version  string
+	IP       string
+	Accepted int64
+}
+
+// User is used for DB responses and holds information about a user.
+type User struct {
+	ID                int64 `db:"UserId"`
+	Email             string
+	Username          string
+	Password          []byte
+	MultiSigAddress   string
+	MultiSigScript    string
+	PoolPubKeyAddr    string
+	UserPubKeyAddr    string
+	UserFeeAddr       string
+	HeightRegistered  int64
+	EmailVerified     int64
+	EmailToken        string
+	APIToken          string
+	VoteBits          int64
+	VoteBitsVersion   int64
+	TOSVersion        string
+	TOSAccepted       int64
+	EmailTokenSent    int64
+	EmailTokenExpires int64
+	Created           int64
+	ReadOnlyAPIToken  string
+	InviteCodeID      int64
+
+	// RegistrationIP, RegistrationUserAgent and ReferralCode describe where
+	// the user registered from, to help identify abusive signups.  They are
+	// empty for users who registered before they were recorded.
+	RegistrationIP        string
+	RegistrationUserAgent string `db:"RegistrationUserAgent,size:500"`
+	ReferralCode          string
+
+	// BadgeToken identifies the user in the URL of their status badge, or
+	// is empty if they have none.
+	BadgeToken string
+
+	// ScriptActivated is the time the multisig script of the user was set
+	// up or last reactivated, and ScriptExpiryWarned the time the user was
+	// warned that it is disabled unless tickets are bought with it, or 0.
+	ScriptActivated    int64
+	ScriptExpiryWarned int64
+
+	// EmailChanged is the time the email address of the user was last
+	// changed, or 0.  Changes to the account which could lock the user out
+	// are blocked for a while after.
+	EmailChanged int64
+}
+
+// UserActivity is used for DB responses and records an action a user took on
+// their account, such as logging in or changing their voting preferences,
+// with where it was taken from.  It feeds the activity timeline on the
+// settings page, which helps users notice that their account is used by
+// someone else.
+type UserActivity struct {
+	ID        int64 `db:"UserActivityID"`
+	UserID    int64 `db:"UserId"`
+	Action    string
+	Detail    string
+	IP        string
+	UserAgent string `db:"UserAgent,size:500"`
+	Created   int64
+}
+
+// UserNote is used for DB responses and holds a note an admin left about a
+// user for the support team, e.g. the context of a support request.
+// SupportTicket is an optional reference to the request in an external
+// ticket system.  Notes are never edited, so they form a history of the user.
+type UserNote struct {
+	ID            int64  `db:"UserNoteID"`
+	UserID        int64  `db:"UserId"`
+	AdminUID      int64  `db:"AdminUid"`
+	Note          string `db:"Note,size:4000"`
+	SupportTicket string
+	Created       int64
+}
+
+// VotingFreeze is used for DB responses and records an admin freezing or
+// unfreezing the voting preferences of all users.  The most recent row is the
+// current state.  While Frozen is 1 every managed ticket votes with VoteBits
+// instead of the voting preferences of its owner.
+type VotingFreeze struct {
+	ID           int64 `db:"VotingFreezeID"`
+	Frozen       int64
+	VoteBits     int64
+	Reason       string
+	UpdatedByUID int64 `db:"UpdatedByUid"`
+	Created      int64
+}
+
+// Events of the ticket lifecycle webhooks, sent when a ticket of the user
+// becomes live, votes, is missed or expires, or is ignored by stakepoold as a
+// low fee ticket.
+const (
+	WebhookEventLive    = "ticket.live"
+	WebhookEventVoted   = "ticket.voted"
+	WebhookEventMissed  = "ticket.missed"
+	WebhookEventExpired = "ticket.expired"
+	WebhookEventLowFee  = "ticket.lowfee"
+)
+
+// Webhook is used for DB responses and holds the webhook URL a user registered
+// to be notified of changes to the state of their tickets.  Deliveries are
+// signed with Secret.  Verified is the time the URL answered the challenge
+// sent on registration, and Synced the time the states of the tickets of the
+// user were last checked, or 0 until the first check, which records them
+// without notifying the user.
+type Webhook struct {
+	ID       int64  `db:"WebhookID"`
+	UserID   int64  `db:"UserId"`
+	URL      string `db:"URL,size:500"`
+	Secret   string
+	Created  int64
+	Verified int64
+	Synced   int64
+}
+
+// WebhookTicket is used for DB responses and holds the last known state of a
+// ticket of a user with a webhook, i.e. its status or lowfee.
+type WebhookTicket struct {
+	ID         int64 `db:"WebhookTicketID"`
+	UserID     int64 `db:"UserId"`
+	TicketHash string
+	State      string
+	Updated    int64
+}
+
+// WebhookDelivery is used for DB responses and holds a webhook event sent or
+// to be sent to a user.  NextAttempt is the unix time of the next attempt, and
+// 0 once the event was delivered or is no longer retried.  LastStatus is the
+// HTTP status of the last attempt, or 0 when no response was received.
+type WebhookDelivery struct {
+	ID          int64 `db:"WebhookDeliveryID"`
+	UserID      int64 `db:"UserId"`
+	Event       string
+	TicketHash  string
+	Payload     string `db:"Payload,size:1000"`
+	Attempts    int64
+	LastStatus  int64
+	LastError   string `db:"LastError,size:1000"`
+	Created     int64
+	NextAttempt int64
+	Delivered   int64
+}
+
+// APIRefreshToken is used for DB responses and holds a refresh token issued by
+// the API login, which is exchanged once for new API tokens until Expires.
+// Only the hash of the token is stored.  Used is the unix time it was
+// exchanged, or 0.
+type APIRefreshToken struct {
+	ID        int64  `db:"APIRefreshTokenID"`
+	UserID    int64  `db:"UserId"`
+	TokenHash string `db:"TokenHash,size:64"`
+	Created   int64
+	Expires   int64
+	Used      int64
+}
+
+// SignupCount is used for DB responses and holds the number of users who
+// registered with the same Key, e.g. on the same day or from the same IP.
+type SignupCount struct {
+	Key   string
+	Count int64
+}
+
+// VoteBitsCount is used for DB responses and holds the number of active users
+// voting with the same VoteBits.
+type VoteBitsCount struct {
+	VoteBits int64
+	Count    int64
+}
+
+// MissedCauseCount is used for DB responses and holds the number of missed
+// tickets with the same Cause.
+type MissedCauseCount struct {
+	Cause string
+	Count int64
+}
+
+// GetUserByEmail is a helper function that returns a user with email.
+func GetUserByEmail(dbMap *gorp.DbMap, email string) (user *User) {
+	err := dbMap.SelectOne(&user, "SELECT * FROM Users where Email = ?", email)
+
+	if err != nil {
+		log.Warnf("Can't get user by email: %v", err)
+	}
+	return
+}
+
+// GetUserByID is a helper function that returns a user with id.
+func GetUserByID(dbMap *gorp.DbMap, id int64) (user *User, err error) {
+	err = dbMap.SelectOne(&user, "SELECT * FROM Users WHERE UserId = ?", id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetUserCount gives a count of all users.
+func GetUserCount(dbMap *gorp.DbMap) int64 {
+	userCount, err := dbMap.SelectInt("SELECT COUNT(*) FROM Users")
+	if err != nil {
+		return int64(0)
+	}
+
+	return userCount
+}
+
+// GetUserMax gives the last userid.
+func GetUserMax(dbMap *gorp.DbMap) int64 {
+	maxUserID, err := dbMap.SelectInt("SELECT MAX(Userid) FROM Users")
+	if err != nil {
+		return int64(0)
+	}
+
+	return maxUserID
+}
+
+// GetUserCountActive gives a count of all users who have submitted an address.
+func GetUserCountActive(dbMap *gorp.DbMap) int64 {
+	userCountActive, err := dbMap.SelectInt("SELECT COUNT(*) FROM Users " +
+		"WHERE MultiSigAddress <> ''")
+	if err != nil {
+		return int64(0)
+	}
+
+	return userCountActive
+}
+
+// GetUserCountByUserPubKeyAddr gives a count of the users other than the
+// user with id who have submitted pubKeyAddr.
+func GetUserCountByUserPubKeyAddr(dbMap *gorp.DbMap, pubKeyAddr string, id int64) (int64, error) {
+	return dbMap.SelectInt("SELECT COUNT(*) FROM Users "+
+		"WHERE UserPubKeyAddr = ? AND UserId <> ?", pubKeyAddr, id)
+}
+
+// GetUsersByUserPubKeyAddr returns the users who have submitted pubKeyAddr.
+func GetUsersByUserPubKeyAddr(dbMap *gorp.DbMap, pubKeyAddr string) ([]User, error) {
+	var users []User
+	_, err := dbMap.Select(&users, "SELECT * FROM Users "+
+		"WHERE UserPubKeyAddr = ?", pubKeyAddr)
+	return users, err
+}
+
+// GetUsersByMultiSigAddresses returns the users with one of the multisig
+// addresses.
+func GetUsersByMultiSigAddresses(dbMap *gorp.DbMap, msas []string) ([]User, error) {
+	if len(msas) == 0 {
+		return nil, nil
+	}
+	var users []User
+	_, err := dbMap.Select(&users, "SELECT * FROM Users "+
+		"WHERE MultiSigAddress IN (:MultiSigAddresses)",
+		map[string]interface{}{"MultiSigAddresses": msas})
+	return users, err
+}
+
+// GetMissedTicketsByUserID returns the missed tickets recorded for a user,
+// most recent first.
+func GetMissedTicketsByUserID(dbMap *gorp.DbMap, id int64) ([]MissedTicket, error) {
+	var missedTickets []MissedTicket
+	_, err := dbMap.Select(&missedTickets, "SELECT * FROM MissedTicket "+
+		"WHERE UserId = ? ORDER BY BlockHeight DESC", id)
+	if err != nil {
+		return nil, err
+	}
+	return missedTickets, nil
+}
+
+// InsertHistoryImport records the import of a multisig script of a user from
+// their previous voting service.
+func InsertHistoryImport(dbMap *gorp.DbMap, hi *HistoryImport) error {
+	return dbMap.Insert(hi)
+}
+
+// GetHistoryImport returns the history import with the given ID, or nil if
+// there is none.
+func GetHistoryImport(dbMap *gorp.DbMap, id int64) (*HistoryImport, error) {
+	var hi HistoryImport
+	err := dbMap.SelectOne(&hi, "SELECT * FROM HistoryImport "+
+		"WHERE HistoryImportID = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hi, nil
+}
+
+// GetHistoryImportByAddress returns the history import of the multisig
+// address, or nil if it was not imported.
+func GetHistoryImportByAddress(dbMap *gorp.DbMap, multiSigAddress string) (*HistoryImport, error) {
+	var hi HistoryImport
+	err := dbMap.SelectOne(&hi, "SELECT * FROM HistoryImport "+
+		"WHERE MultiSigAddress = ?", multiSigAddress)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hi, nil
+}
+
+// GetHistoryImports returns the history imports, most recent first.
+func GetHistoryImports(dbMap *gorp.DbMap) ([]HistoryImport, error) {
+	var imports []HistoryImport
+	_, err := dbMap.Select(&imports, "SELECT * FROM HistoryImport "+
+		"ORDER BY Created DESC")
+	if err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
+// BackfillHistoricTickets replaces the historic tickets of the imported
+// multisig script of hi with tickets and records the backfill in hi, at once.
+func BackfillHistoricTickets(dbMap *gorp.DbMap, hi *HistoryImport, tickets []HistoricTicket, now int64) error {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM HistoricTicket WHERE UserId = ? AND "+
+		"MultiSigAddress = ?", hi.UserID, hi.MultiSigAddress)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range tickets {
+		t := &tickets[i]
+		t.UserID = hi.UserID
+		t.MultiSigAddress = hi.MultiSigAddress
+		t.Created = now
+		if err = tx.Insert(t); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	hi.Tickets = int64(len(tickets))
+	hi.Backfilled = now
+	if _, err = tx.Update(hi); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetScriptExpiryCandidates returns the users whose multisig script was set
+// up or reactivated before the passed unix time.
+func GetScriptExpiryCandidates(dbMap *gorp.DbMap, before int64) ([]User, error) {
+	var users []User
+	_, err := dbMap.Select(&users, "SELECT * FROM Users "+
+		"WHERE MultiSigAddress <> '' AND ScriptActivated < ?", before)
+	return users, err
+}
+
+// SetUserScriptExpiryWarned records the time the user with id was warned
+// about the expiry of their multisig script, or 0 to clear the warning.
+func SetUserScriptExpiryWarned(dbMap *gorp.DbMap, id, warned int64) error {
+	_, err := dbMap.Exec("UPDATE Users SET ScriptExpiryWarned = ? "+
+		"WHERE UserId = ?", warned, id)
+	return err
+}
+
+// ExpireUserScript disables the multisig script of user by moving it to an
+// ExpiredScript, at once.  The user keeps their other addresses so that the
+// script can be reactivated.  It returns nil without changes if the script of
+// the user changed since it was loaded.
+func ExpireUserScript(dbMap *gorp.DbMap, user *User, now int64) (*ExpiredScript, error) {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec("UPDATE Users SET MultiSigAddress = '', "+
+		"MultiSigScript = '', ScriptExpiryWarned = 0 "+
+		"WHERE UserId = ? AND MultiSigAddress = ?", user.ID,
+		user.MultiSigAddress)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		tx.Rollback()
+		return nil, err
+	}
+
+	es := &ExpiredScript{
+		UserID:          user.ID,
+		MultiSigAddress: user.MultiSigAddress,
+		MultiSigScript:  user.MultiSigScript,
+		Expired:         now,
+	}
+	if err = tx.Insert(es); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return es, tx.Commit()
+}
+
+// GetExpiredScriptByUserID returns the disabled multisig script of the user
+// with id, or nil if there is none.
+func GetExpiredScriptByUserID(dbMap *gorp.DbMap, id int64) (*ExpiredScript, error) {
+	var es ExpiredScript
+	err := dbMap.SelectOne(&es, "SELECT * FROM ExpiredScript "+
+		"WHERE UserId = ? AND Reactivated = 0 "+
+		"ORDER BY ExpiredScriptID DESC LIMIT 1", id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &es, nil
+}
+
+// ReactivateExpiredScript restores the disabled multisig script es to its
+// user and records the reactivation, at once.  It fails if the user set up
+// another script in the meantime.
+func ReactivateExpiredScript(dbMap *gorp.DbMap, es *ExpiredScript, now int64) error {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("UPDATE Users SET MultiSigAddress = ?, "+
+		"MultiSigScript = ?, ScriptActivated = ?, ScriptExpiryWarned = 0 "+
+		"WHERE UserId = ? AND MultiSigAddress = ''", es.MultiSigAddress,
+		es.MultiSigScript, now, es.UserID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		err = errors.New("the user already has a multisig script")
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	es.Reactivated = now
+	if _, err = tx.Update(es); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RetireUserScript clears the multisig script and the submitted address of
+// user and records them as a RetiredScript, at once, so that the user can
+// submit another address.  The user keeps their fee address.  The setup job of
+// the new address is inserted, and the StakepooldUpdate of the retired script
+// recorded, in the same transaction, so that a restart cannot leave the user
+// without a script and without the job setting up the next one.  It returns
+// nil without changes if the script of the user changed since it was loaded.
+func RetireUserScript(dbMap *gorp.DbMap, user *User, job *AddressJob, now int64) (*RetiredScript, error) {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec("UPDATE Users SET MultiSigAddress = '', "+
+		"MultiSigScript = '', PoolPubKeyAddr = '', UserPubKeyAddr = '', "+
+		"ScriptExpiryWarned = 0 "+
+		"WHERE UserId = ? AND MultiSigAddress = ?", user.ID,
+		user.MultiSigAddress)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		tx.Rollback()
+		return nil, err
+	}
+
+	rs := &RetiredScript{
+		UserID:          user.ID,
+		MultiSigAddress: user.MultiSigAddress,
+		MultiSigScript:  user.MultiSigScript,
+		UserPubKeyAddr:  user.UserPubKeyAddr,
+		Retired:         now,
+	}
+	if err = tx.Insert(rs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = tx.Insert(job); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	err = InsertStakepooldUpdate(tx, user.ID, StakepooldUpdateRetiredScript, now)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return rs, tx.Commit()
+}
+
+// GetRetiredScriptsByUserID returns the replaced multisig scripts of the user
+// with id, most recently retired first.
+func GetRetiredScriptsByUserID(dbMap *gorp.DbMap, id int64) ([]RetiredScript, error) {
+	var scripts []RetiredScript
+	_, err := dbMap.Select(&scripts, "SELECT * FROM RetiredScript "+
+		"WHERE UserId = ? ORDER BY RetiredScriptID DESC", id)
+	if err != nil {
+		return nil, err
+	}
+	return scripts, nil
+}
+
+// GetHistoricTicketsByUserID returns the pre-migration tickets of a user, most
+// recently spent first.
+func GetHistoricTicketsByUserID(dbMap *gorp.DbMap, id int64) ([]HistoricTicket, error) {
+	var tickets []HistoricTicket
+	_, err := dbMap.Select(&tickets, "SELECT * FROM HistoricTicket "+
+		"WHERE UserId = ? ORDER BY SpentByHeight DESC", id)
+	if err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+// InsertAddressJob records a new address setup job.
+func InsertAddressJob(dbMap *gorp.DbMap, job *AddressJob) error {
+	return dbMap.Insert(job)
+}
+
+// UpdateAddressJob records the progress of an address setup job.
+func UpdateAddressJob(dbMap *gorp.DbMap, job *AddressJob) error {
+	_, err := dbMap.Update(job)
+	return err
+}
+
+// GetClosestAddressIndex returns the address index of the user with the
+// highest id up to id in account, or nil when no address of such a user was
+// derived yet.
+func GetClosestAddressIndex(dbMap *gorp.DbMap, account string, id int64) (*AddressIndex, error) {
+	var indexes []AddressIndex
+	_, err := dbMap.Select(&indexes, "SELECT * FROM AddressIndex "+
+		"WHERE Account = ? AND UserId <= ? ORDER BY UserId DESC LIMIT 1",
+		account, id)
+	if err != nil || len(indexes) == 0 {
+		return nil, err
+	}
+	return &indexes[0], nil
+}
+
+// InsertAddressIndex records the child index of the address of a user.
+func InsertAddressIndex(dbMap *gorp.DbMap, index *AddressIndex) error {
+	return dbMap.Insert(index)
+}
+
+// GetAddressJobByUserID returns the most recent address setup job of a user,
+// or nil when the user has none.
+func GetAddressJobByUserID(dbMap *gorp.DbMap, id int64) (*AddressJob, error) {
+	var jobs []AddressJob
+	_, err := dbMap.Select(&jobs, "SELECT * FROM AddressJob "+
+		"WHERE UserId = ? ORDER BY AddressJobID DESC LIMIT 1", id)
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// GetTicketFeesByUserID returns the ticket fees of a user, most recent first.
+func GetTicketFeesByUserID(dbMap *gorp.DbMap, id int64) ([]TicketFee, error) {
+	var fees []TicketFee
+	_, err := dbMap.Select(&fees, "SELECT * FROM TicketFee "+
+		"WHERE UserId = ? ORDER BY TicketFeeID DESC", id)
+	return fees, err
+}
+
+// GetTicketFees returns the fees of the tickets of all users.
+func GetTicketFees(dbMap *gorp.DbMap) ([]TicketFee, error) {
+	var fees []TicketFee
+	_, err := dbMap.Select(&fees, "SELECT * FROM TicketFee "+
+		"ORDER BY TicketFeeID")
+	return fees, err
+}
+
+// GetTicketFeeByTicketHash returns the fee of a ticket, or nil when none was
+// recorded.
+func GetTicketFeeByTicketHash(dbMap *gorp.DbMap, hash string) (*TicketFee, error) {
+	var fees []TicketFee
+	_, err := dbMap.Select(&fees, "SELECT * FROM TicketFee "+
+		"WHERE TicketHash = ?", hash)
+	if err != nil || len(fees) == 0 {
+		return nil, err
+	}
+	return &fees[0], nil
+}
+
+// GetPendingTicketFees returns the ticket fees which are neither paid nor
+// expired.
+func GetPendingTicketFees(dbMap *gorp.DbMap) ([]TicketFee, error) {
+	var fees []TicketFee
+	_, err := dbMap.Select(&fees, "SELECT * FROM TicketFee "+
+		"WHERE Status = ? ORDER BY TicketFeeID", TicketFeePending)
+	return fees, err
+}
+
+// InsertTicketFee records a new ticket fee.  Its fee address is derived from
+// its ID by feeAddress, so the fee is inserted and then updated with the
+// address in a single transaction.
+func InsertTicketFee(dbMap *gorp.DbMap, fee *TicketFee,
+	feeAddress func(id int64) (string, error)) error {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return err
+	}
+	if err = tx.Insert(fee); err != nil {
+		tx.Rollback()
+		return err
+	}
+	fee.FeeAddress, err = feeAddress(fee.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Update(fee); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateTicketFee saves the changes to a ticket fee.
+func UpdateTicketFee(dbMap *gorp.DbMap, fee *TicketFee) error {
+	_, err := dbMap.Update(fee)
+	return err
+}
+
+// GetMessagesByUserID returns up to limit of the messages of a user, most
+// recent first.
+func GetMessagesByUserID(dbMap *gorp.DbMap, id, limit int64) ([]Message, error) {
+	var messages []Message
+	_, err := dbMap.Select(&messages, "SELECT * FROM Message "+
+		"WHERE UserId = ? ORDER BY Created DESC, MessageID DESC LIMIT ?",
+		id, limit)
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// GetUnreadMessageCount gives a count of the unread messages of a user.
+func GetUnreadMessageCount(dbMap *gorp.DbMap, id int64) int64 {
+	count, err := dbMap.SelectInt("SELECT COUNT(*) FROM Message "+
+		"WHERE UserId = ? AND `Read` = 0", id)
+	if err != nil {
+		return int64(0)
+	}
+
+	return count
+}
+
+// MarkMessageRead marks a message of a user as read.  Marking a message which
+// does not exist or belongs to another user is not an error.
+func MarkMessageRead(dbMap *gorp.DbMap, userID, id int64) error {
+	_, err := dbMap.Exec("UPDATE Message SET `Read` = ? "+
+		"WHERE MessageID = ? AND UserId = ? AND `Read` = 0",
+		time.Now().Unix(), id, userID)
+	return err
+}
+
+// MarkAllMessagesRead marks all messages of a user as read.
+func MarkAllMessagesRead(dbMap *gorp.DbMap, userID int64) error {
+	_, err := dbMap.Exec("UPDATE Message SET `Read` = ? "+
+		"WHERE UserId = ? AND `Read` = 0", time.Now().Unix(), userID)
+	return err
+}
+
+// GetMissedTicketCauseCounts returns the number of missed tickets per cause.
+// A ticket recorded by several stakepoold instances is counted once per cause.
+func GetMissedTicketCauseCounts(dbMap *gorp.DbMap) ([]MissedCauseCount, error) {
+	var counts []MissedCauseCount
+	_, err := dbMap.Select(&counts, "SELECT Cause, "+
+		"COUNT(DISTINCT TicketHash) AS Count FROM MissedTicket "+
+		"GROUP BY Cause")
+	return counts, err
+}
+
+// GetMissedTickets returns up to limit missed tickets of all users, most
+// recent first, starting at offset.
+func GetMissedTickets(dbMap *gorp.DbMap, offset, limit int64) ([]MissedTicket, error) {
+	var missedTickets []MissedTicket
+	_, err := dbMap.Select(&missedTickets, "SELECT * FROM MissedTicket "+
+		"ORDER BY BlockHeight DESC, MissedTicketID DESC LIMIT ? OFFSET ?",
+		limit, offset)
+	return missedTickets, err
+}
+
+// GetMissedTicketTotal returns the number of recorded missed tickets.
+func GetMissedTicketTotal(dbMap *gorp.DbMap) int64 {
+	count, err := dbMap.SelectInt("SELECT COUNT(*) FROM MissedTicket")
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// GetMissedTicketCountSince returns the number of tickets which were recorded
+// as missed at or after the unix time since.
+func GetMissedTicketCountSince(dbMap *gorp.DbMap, since int64) (int64, error) {
+	return dbMap.SelectInt("SELECT COUNT(DISTINCT TicketHash) FROM "+
+		"MissedTicket WHERE Created >= ?", since)
+}
+
+// GetUsers returns up to limit users ordered by id, starting at offset.
+func GetUsers(dbMap *gorp.DbMap, offset, limit int64) ([]User, error) {
+	var users []User
+	_, err := dbMap.Select(&users, "SELECT * FROM Users ORDER BY UserId "+
+		"LIMIT ? OFFSET ?", limit, offset)
+	return users, err
+}
+
+// GetSignupsPerDay returns the number of users who registered on each day
+// since the passed unix time, most recent first.  Key is the date as
+// YYYY-MM-DD in the time zone of the DB.
+func GetSignupsPerDay(dbMap *gorp.DbMap, since int64) ([]SignupCount, error) {
+	var counts []SignupCount
+	_, err := dbMap.Select(&counts, "SELECT "+
+		"CAST(DATE(FROM_UNIXTIME(Created)) AS CHAR) AS `Key`, "+
+		"COUNT(*) AS Count FROM Users WHERE Created >= ? "+
+		"GROUP BY `Key` ORDER BY `Key` DESC", since)
+	return counts, err
+}
+
+// GetTopRegistrationIPs returns up to limit of the IPs which more than one
+// user registered from since the passed unix time, most users first.
+func GetTopRegistrationIPs(dbMap *gorp.DbMap, since, limit int64) ([]SignupCount, error) {
+	var counts []SignupCount
+	_, err := dbMap.Select(&counts, "SELECT RegistrationIP AS `Key`, "+
+		"COUNT(*) AS Count FROM Users "+
+		"WHERE Created >= ? AND RegistrationIP <> '' "+
+		"GROUP BY RegistrationIP HAVING Count > 1 "+
+		"ORDER BY Count DESC, `Key` LIMIT ?", since, limit)
+	return counts, err
+}
+
+// GetTopReferralCodes returns up to limit of the referral codes users
+// registered with since the passed unix time, most users first.
+func GetTopReferralCodes(dbMap *gorp.DbMap, since, limit int64) ([]SignupCount, error) {
+	var counts []SignupCount
+	_, err := dbMap.Select(&counts, "SELECT ReferralCode AS `Key`, "+
+		"COUNT(*) AS Count FROM Users "+
+		"WHERE Created >= ? AND ReferralCode <> '' "+
+		"GROUP BY ReferralCode ORDER BY Count DESC, `Key` LIMIT ?",
+		since, limit)
+	return counts, err
+}
+
+// GetVoteBitsCounts returns the number of users who have submitted an address
+// per VoteBits, for aggregate statistics of their voting preferences.
+func GetVoteBitsCounts(dbMap *gorp.DbMap) ([]VoteBitsCount, error) {
+	var counts []VoteBitsCount
+	_, err := dbMap.Select(&counts, "SELECT VoteBits, COUNT(*) AS Count "+
+		"FROM Users WHERE MultiSigAddress <> '' GROUP BY VoteBits")
+	return counts, err
+}
+
+// GetUserCountTOSAccepted gives a count of the users who have accepted the
+// passed version of the terms of service.
+func GetUserCountTOSAccepted(dbMap *gorp.DbMap, version string) int64 {
+	count, err := dbMap.SelectInt("SELECT COUNT(*) FROM Users "+
+		"WHERE TOSVersion = ?", version)
+	if err != nil {
+		return int64(0)
+	}
+
+	return count
+}
+
+// AcceptTOS records that the user with id accepted version of the terms of
+// service from ip.
+func AcceptTOS(dbMap *gorp.DbMap, id int64, version, ip string) error {
+	now := time.Now().Unix()
+	_, err := dbMap.Exec("UPDATE Users SET TOSVersion = ?, TOSAccepted = ? "+
+		"WHERE UserId = ?", version, now, id)
+	if err != nil {
+		return err
+	}
+
+	return dbMap.Insert(&TOSAcceptance{
+		UserID:   id,
+		Version:  version,
+		IP:       ip,
+		Accepted: now,
+	})
+}
+
+// UpdateEmailToken sets the email verification token of the user with id
+// along with the time it was sent and when it expires.
+func UpdateEmailToken(dbMap *gorp.DbMap, id int64, token string, sent, expires int64) error {
+	_, err := dbMap.Exec("UPDATE Users SET EmailToken = ?, EmailTokenSent = ?, "+
+		"EmailTokenExpires = ? WHERE UserId = ?", token, sent, expires, id)
+	return err
+}
+
+// DeleteUnverifiedUsers deletes the users who registered before the passed
+// unix time and never verified their email address, along with their terms
+// of service acceptance records and activity.  It returns the number of users deleted.
+func DeleteUnverifiedUsers(dbMap *gorp.DbMap, before int64) (int64, error) {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("DELETE FROM TOSAcceptance WHERE UserId IN "+
+		"(SELECT UserId FROM Users WHERE EmailVerified = 0 AND Created < ?)",
+		before)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	_, err = tx.Exec("DELETE FROM UserActivity WHERE UserId IN "+
+		"(SELECT UserId FROM Users WHERE EmailVerified = 0 AND Created < ?)",
+		before)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	res, err := tx.Exec("DELETE FROM Users WHERE EmailVerified = 0 AND "+
+		"Created < ?", before)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// DeleteUser deletes the user with id.
+func DeleteUser(dbMap *gorp.DbMap, id int64) error {
+	_, err := dbMap.Exec("DELETE FROM Users WHERE UserId = ?", id)
+	return err
+}
+
+// InsertAdminAudit records an action taken by an admin.
+func InsertAdminAudit(dbMap *gorp.DbMap, audit *AdminAudit) error {
+	return dbMap.Insert(audit)
+}
+
+// GetAdminAudits returns limit recorded admin actions, most recent first,
+// starting at offset.
+func GetAdminAudits(dbMap *gorp.DbMap, offset, limit int64) ([]AdminAudit, error) {
+	var audits []AdminAudit
+	_, err := dbMap.Select(&audits, "SELECT * FROM AdminAudit "+
+		"ORDER BY AdminAuditID DESC LIMIT ? OFFSET ?", limit, offset)
+	return audits, err
+}
+
+// GetAdminAuditCount returns the number of recorded admin actions.
+func GetAdminAuditCount(dbMap *gorp.DbMap) int64 {
+	count, err := dbMap.SelectInt("SELECT COUNT(*) FROM AdminAudit")
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// InsertEmailChange inserts a new EmailChange row into the DB.
+func InsertEmailChange(dbMap *gorp.DbMap, emailChange *EmailChange) error {
+	return dbMap.Insert(emailChange)
+}
+
+// ConfirmEmailChange records that emailChange was confirmed at now with the
+// token sent to the current address of the user if old is set, or with the
+// one sent to the new address otherwise.
+func ConfirmEmailChange(dbMap *gorp.DbMap, emailChange *EmailChange, old bool, now int64) error {
+	column := "NewConfirmed"
+	if old {
+		column = "OldConfirmed"
+	}
+	_, err := dbMap.Exec("UPDATE EmailChange SET "+column+" = ? "+
+		"WHERE EmailChangeID = ?", now, emailChange.ID)
+	if err != nil {
+		return err
+	}
+	if old {
+		emailChange.OldConfirmed = now
+	} else {
+		emailChange.NewConfirmed = now
+	}
+	return nil
+}
+
+// GetFeatureFlags returns all feature flags ordered by name.
+func GetFeatureFlags(dbMap *gorp.DbMap) ([]FeatureFlag, error) {
+	var flags []FeatureFlag
+	_, err := dbMap.Select(&flags, "SELECT * FROM FeatureFlag ORDER BY Name")
+	return flags, err
+}
+
+// SetFeatureFlag inserts a feature flag into the DB, or updates the flag with
+// the same name.
+func SetFeatureFlag(dbMap *gorp.DbMap, flag *FeatureFlag) error {
+	id, err := dbMap.SelectNullInt("SELECT FeatureFlagID FROM FeatureFlag "+
+		"WHERE Name = ?", flag.Name)
+	if err != nil {
+		return err
+	}
+	if !id.Valid {
+		return dbMap.Insert(flag)
+	}
+	flag.ID = id.Int64
+	_, err = dbMap.Update(flag)
+	return err
+}
+
+// DeleteFeatureFlag removes the feature flag with name, disabling the feature
+// for all users.
+func DeleteFeatureFlag(dbMap *gorp.DbMap, name string) error {
+	_, err := dbMap.Exec("DELETE FROM FeatureFlag WHERE Name = ?", name)
+	return err
+}
+
+// InsertInviteCode inserts a new invite code into the DB.
+func InsertInviteCode(dbMap *gorp.DbMap, inviteCode *InviteCode) error {
+	return dbMap.Insert(inviteCode)
+}
+
+// GetInviteCodes returns all invite codes, most recent first.
+func GetInviteCodes(dbMap *gorp.DbMap) ([]InviteCode, error) {
+	var inviteCodes []InviteCode
+	_, err := dbMap.Select(&inviteCodes, "SELECT * FROM InviteCode "+
+		"ORDER BY InviteCodeID DESC")
+	return inviteCodes, err
+}
+
+// ExpireInviteCode makes the invite code with id expire now so that it can no
+// longer be used.  Codes which already expired are unchanged.
+func ExpireInviteCode(dbMap *gorp.DbMap, id int64) error {
+	now := time.Now().Unix()
+	_, err := dbMap.Exec("UPDATE InviteCode SET Expires = ? "+
+		"WHERE InviteCodeID = ? AND (Expires = 0 OR Expires > ?)", now, id, now)
+	return err
+}
+
+// ErrInvalidInviteCode is returned by InsertUserWithInviteCode when the invite
+// code does not exist, has expired or has been used up.
+var ErrInvalidInviteCode = errors.New("invalid invite code")
+
+// InsertUserWithInviteCode inserts a user who registered with an invite code
+// into the DB and counts the use of the code.  ErrInvalidInviteCode is
+// returned, and the user is not inserted, when the code cannot be used.
+func InsertUserWithInviteCode(dbMap *gorp.DbMap, user *User, code string) error {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return err
+	}
+
+	var inviteCode InviteCode
+	err = tx.SelectOne(&inviteCode, "SELECT * FROM InviteCode "+
+		"WHERE Code = ? FOR UPDATE", code)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrInvalidInviteCode
+		}
+		return err
+	}
+
+	now := time.Now().Unix()
+	if inviteCode.Uses >= inviteCode.MaxUses ||
+		(inviteCode.Expires != 0 && inviteCode.Expires <= now) {
+		tx.Rollback()
+		return ErrInvalidInviteCode
+	}
+
+	_, err = tx.Exec("UPDATE InviteCode SET Uses = Uses + 1 "+
+		"WHERE InviteCodeID = ?", inviteCode.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	user.InviteCodeID = inviteCode.ID
+	if err = tx.Insert(user); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// InsertLowFeeTicket inserts a low fee ticket into the DB.
+func InsertLowFeeTicket(dbMap *gorp.DbMap, lowFeeTicket *LowFeeTicket) error {
+	return dbMap.Insert(lowFeeTicket)
+}
+
+// InsertQueuedEmail inserts an email to be retried into the DB.
+func InsertQueuedEmail(dbMap *gorp.DbMap, email *QueuedEmail) error {
+	return dbMap.Insert(email)
+}
+
+// GetDueQueuedEmails returns up to limit of the queued emails whose next
+// attempt is due at now, oldest first.
+func GetDueQueuedEmails(dbMap *gorp.DbMap, now int64, limit int) ([]QueuedEmail, error) {
+	var emails []QueuedEmail
+	_, err := dbMap.Select(&emails, "SELECT * FROM QueuedEmail "+
+		"WHERE NextAttempt > 0 AND NextAttempt <= ? "+
+		"ORDER BY QueuedEmailID LIMIT ?", now, limit)
+	return emails, err
+}
+
+// GetQueuedEmails returns the emails which are queued or were given up on,
+// newest first.
+func GetQueuedEmails(dbMap *gorp.DbMap) ([]QueuedEmail, error) {
+	var emails []QueuedEmail
+	_, err := dbMap.Select(&emails, "SELECT * FROM QueuedEmail "+
+		"ORDER BY QueuedEmailID DESC")
+	return emails, err
+}
+
+// GetQueuedEmailByID returns the queued email with id.
+func GetQueuedEmailByID(dbMap *gorp.DbMap, id int64) (*QueuedEmail, error) {
+	var email QueuedEmail
+	err := dbMap.SelectOne(&email, "SELECT * FROM QueuedEmail "+
+		"WHERE QueuedEmailID = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	return &email, nil
+}
+
+// UpdateQueuedEmail records a failed attempt to send a queued email.
+func UpdateQueuedEmail(dbMap *gorp.DbMap, email *QueuedEmail) error {
+	_, err := dbMap.Update(email)
+	return err
+}
+
+// DeleteQueuedEmail removes a queued email, e.g. once it was sent.
+func DeleteQueuedEmail(dbMap *gorp.DbMap, id int64) error {
+	_, err := dbMap.Exec("DELETE FROM QueuedEmail WHERE QueuedEmailID = ?", id)
+	return err
+}
+
+// InsertStakepooldUpdate records a change of the user with id, made in tx,
+// which stakepoold is to be updated with.
+func InsertStakepooldUpdate(tx *gorp.Transaction, id int64, reason string, now int64) error {
+	return tx.Insert(&StakepooldUpdate{
+		UserID:  id,
+		Reason:  reason,
+		Created: now,
+	})
+}
+
+// GetStakepooldUpdates returns the changes of users which stakepoold was not
+// updated with yet, oldest first.
+func GetStakepooldUpdates(dbMap *gorp.DbMap) ([]StakepooldUpdate, error) {
+	var updates []StakepooldUpdate
+	_, err := dbMap.Select(&updates, "SELECT * FROM StakepooldUpdate "+
+		"ORDER BY StakepooldUpdateID")
+	if err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// DeleteStakepooldUpdates removes the changes of users up to the one with
+// maxID once stakepoold was updated with them.
+func DeleteStakepooldUpdates(dbMap *gorp.DbMap, maxID int64) error {
+	_, err := dbMap.Exec("DELETE FROM StakepooldUpdate "+
+		"WHERE StakepooldUpdateID <= ?", maxID)
+	return err
+}
+
+// RecordStakepooldUpdateFailure records a failed attempt to update stakepoold
+// with the changes of users up to the one with maxID.
+func RecordStakepooldUpdateFailure(dbMap *gorp.DbMap, maxID int64, lastError string) error {
+	_, err := dbMap.Exec("UPDATE StakepooldUpdate SET Attempts = Attempts + 1, "+
+		"LastError = ? WHERE StakepooldUpdateID <= ?", lastError, maxID)
+	return err
+}
+
+// InsertMessage inserts a message for a user into the DB.
+func InsertMessage(dbMap *gorp.DbMap, message *Message) error {
+	return dbMap.Insert(message)
+}
+
+// InsertMessageForAllUsers inserts the same message for every user with a
+// verified email address and returns the number of messages inserted.
+func InsertMessageForAllUsers(dbMap *gorp.DbMap, kind, subject, body string) (int64, error) {
+	result, err := dbMap.Exec("INSERT INTO Message "+
+		"(UserId, Kind, Subject, Body, Created, `Read`) "+
+		"SELECT UserId, ?, ?, ?, ?, 0 FROM Users WHERE EmailVerified > 0",
+		kind, subject, body, time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// InsertUser inserts a user into the DB.
+func InsertUser(dbMap *gorp.DbMap, user *User) error {
+	return dbMap.Insert(user)
+}
+
+// InsertPasswordReset inserts a new PasswordReset row into the DB.
+func InsertPasswordReset(dbMap *gorp.DbMap, passwordReset *PasswordReset) error {
+	return dbMap.Insert(passwordReset)
+}
+
+// SetUserAPIToken generates and saves a unique API Token for a user.
+func SetUserAPIToken(dbMap *gorp.DbMap, apiKeys *APIKeyring, baseURL string,
+	id int64) (string, error) {
+	var user *User
+	tokenString, err := apiKeys.SignToken(baseURL, id)
+	if err != nil {
+		return "", err
+	}
+
+	err = dbMap.SelectOne(&user, "SELECT * FROM Users WHERE UserId = ?", id)
+	user.APIToken = tokenString
+	if err != nil {
+		return "", err
+	}
+
+	_, err = dbMap.Update(user)
+	return tokenString, err
+}
+
+// SetUserReadOnlyAPIToken generates and saves a new read-only API token for a
+// user, replacing and so revoking the previous one.
+func SetUserReadOnlyAPIToken(dbMap *gorp.DbMap, apiKeys *APIKeyring,
+	baseURL string, id int64) (string, error) {
+	tokenString, err := apiKeys.SignReadOnlyToken(baseURL, id)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = dbMap.Exec("UPDATE Users SET ReadOnlyAPIToken = ? WHERE UserId = ?",
+		tokenString, id)
+	return tokenString, err
+}
+
+// RevokeUserReadOnlyAPIToken removes the read-only API token of a user.
+func RevokeUserReadOnlyAPIToken(dbMap *gorp.DbMap, id int64) error {
+	_, err := dbMap.Exec("UPDATE Users SET ReadOnlyAPIToken = '' WHERE UserId = ?", id)
+	return err
+}
+
+// SetUserBadgeToken generates and saves a new status badge token for a user,
+// replacing and so revoking the URL of the previous one.
+func SetUserBadgeToken(dbMap *gorp.DbMap, id int64) (string, error) {
+	token := NewUserToken().String()
+	_, err := dbMap.Exec("UPDATE Users SET BadgeToken = ? WHERE UserId = ?",
+		token, id)
+	return token, err
+}
+
+// RevokeUserBadgeToken removes the status badge token of a user.
+func RevokeUserBadgeToken(dbMap *gorp.DbMap, id int64) error {
+	_, err := dbMap.Exec("UPDATE Users SET BadgeToken = '' WHERE UserId = ?", id)
+	return err
+}
+
+// GetUserByBadgeToken returns the user with the status badge token, or nil if
+// there is none.
+func GetUserByBadgeToken(dbMap *gorp.DbMap, token string) (*User, error) {
+	if token == "" {
+		return nil, nil
+	}
+	var user User
+	err := dbMap.SelectOne(&user, "SELECT * FROM Users WHERE BadgeToken = ?",
+		token)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ErrAddressSubmitted is returned by SetUserScript when the user already
+// submitted an address.
+var ErrAddressSubmitted = errors.New("address already submitted")
+
+// UserScript is the multisig script of a user, the addresses it was created
+// from, and the fee address of the user.
+type UserScript struct {
 	MultiSigAddress  string
 	MultiSigScript   string
 	PoolPubKeyAddr   string
 	UserPubKeyAddr   string
 	UserFeeAddr      string
 	HeightRegistered int64
-	EmailVerified    int64
-	EmailToken       string
-	APIToken         string
-	VoteBits         int64
-	VoteBitsVersion  int64
 }
 
-// HashPassword hashes the passed password string.
-func (user *User) HashPassword(password string) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// SetUserScript stores the multisig script of the user with id, who did not
+// submit an address yet, and records the StakepooldUpdate of the script.  When
+// job is not nil, the address setup job which created the script is completed
+// in the same transaction, so that a restart cannot leave the user with a
+// script but an unfinished job or the other way around.  ErrAddressSubmitted
+// is returned, and nothing is changed, when the user already has an address.
+func SetUserScript(dbMap *gorp.DbMap, id int64, script *UserScript, job *AddressJob, now int64) error {
+	tx, err := dbMap.Begin()
 	if err != nil {
-		log.Criticalf("Couldn't hash password: %v", err)
-		panic(err)
+		return err
+	}
+
+	res, err := tx.Exec("UPDATE Users SET MultiSigAddress = ?, "+
+		"MultiSigScript = ?, PoolPubKeyAddr = ?, UserPubKeyAddr = ?, "+
+		"UserFeeAddr = ?, HeightRegistered = ?, ScriptActivated = ?, "+
+		"ScriptExpiryWarned = 0 WHERE UserId = ? AND UserPubKeyAddr = ''",
+		script.MultiSigAddress, script.MultiSigScript, script.PoolPubKeyAddr,
+		script.UserPubKeyAddr, script.UserFeeAddr, script.HeightRegistered,
+		now, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if n == 0 {
+		tx.Rollback()
+		return ErrAddressSubmitted
+	}
+
+	if job != nil {
+		job.Status = AddressJobComplete
+		job.Error = ""
+		job.Updated = now
+		if _, err = tx.Update(job); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err = InsertStakepooldUpdate(tx, id, StakepooldUpdateAddress, now); err != nil {
+		tx.Rollback()
+		return err
 	}
-	user.Password = hash
+
+	return tx.Commit()
 }
 
-// GetUserByEmail is a helper function that returns a user with email.
-func GetUserByEmail(dbMap *gorp.DbMap, email string) (user *User) {
-	err := dbMap.SelectOne(&user, "SELECT * FROM Users where Email = ?", email)
+// GetAllCurrentMultiSigScripts returns all tracked multisig scripts.
+func GetAllCurrentMultiSigScripts(dbMap *gorp.DbMap) ([]User, error) {
+	var multiSigs []User
+	_, err := dbMap.Select(&multiSigs, "SELECT MultiSigAddress, MultiSigScript, HeightRegistered FROM Users WHERE MultiSigAddress <> ''")
+	if err != nil {
+		return nil, err
+	}
+	return multiSigs, nil
+}
 
+// GetUsersWithMultiSigAddress returns the ID, email, username and multisig
+// address of all users who have submitted an address.
+func GetUsersWithMultiSigAddress(dbMap *gorp.DbMap) ([]User, error) {
+	var users []User
+	_, err := dbMap.Select(&users, "SELECT UserId, Email, Username, MultiSigAddress FROM Users WHERE MultiSigAddress <> ''")
 	if err != nil {
-		log.Warnf("Can't get user by email: %v", err)
+		return nil, err
 	}
-	return
+	return users, nil
 }
 
-// GetUserByID is a helper function that returns a user with id.
-func GetUserByID(dbMap *gorp.DbMap, id int64) (user *User, err error) {
-	err = dbMap.SelectOne(&user, "SELECT * FROM Users WHERE UserId = ?", id)
+// GetUserVotingPrefs returns the ID, multisig address, vote bits and vote bits
+// version of all users who have submitted an address.
+func GetUserVotingPrefs(dbMap *gorp.DbMap) ([]User, error) {
+	var users []User
+	_, err := dbMap.Select(&users, "SELECT UserId, MultiSigAddress, "+
+		"VoteBits, VoteBitsVersion FROM Users WHERE MultiSigAddress <> ''")
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
 
+// GetUserVoteBitsAfter returns the ID, multisig address, vote bits and vote
+// bits version of up to limit users whose ID is greater than afterID, ordered
+// by ID, to go through all users in chunks.
+func GetUserVoteBitsAfter(dbMap *gorp.DbMap, afterID int64, limit int) ([]User, error) {
+	var users []User
+	_, err := dbMap.Select(&users, "SELECT UserId, MultiSigAddress, "+
+		"VoteBits, VoteBitsVersion FROM Users WHERE UserId > ? "+
+		"ORDER BY UserId LIMIT ?", afterID, limit)
 	if err != nil {
 		return nil, err
 	}
+	return users, nil
+}
 
-	return user, nil
+// VoteBitsUpdate changes the vote bits and vote bits version of a user from
+// the Old values to the New ones.
+type VoteBitsUpdate struct {
+	UserID             int64
+	OldVoteBits        int64
+	OldVoteBitsVersion int64
+	NewVoteBits        int64
+	NewVoteBitsVersion int64
 }
 
-// GetUserCount gives a count of all users.
-func GetUserCount(dbMap *gorp.DbMap) int64 {
-	userCount, err := dbMap.SelectInt("SELECT COUNT(*) FROM Users")
+// UpdateUserVoteBits applies updates in one transaction.  The vote bits of
+// users which no longer have the Old values, since they were changed in the
+// meantime, are left alone.  It returns the IDs of the users which were
+// updated.
+func UpdateUserVoteBits(dbMap *gorp.DbMap, updates []VoteBitsUpdate) ([]int64, error) {
+	tx, err := dbMap.Begin()
 	if err != nil {
-		return int64(0)
+		return nil, err
+	}
+	var updated []int64
+	for _, u := range updates {
+		res, err := tx.Exec("UPDATE Users SET VoteBits = ?, "+
+			"VoteBitsVersion = ? WHERE UserId = ? AND VoteBits = ? AND "+
+			"VoteBitsVersion = ?", u.NewVoteBits, u.NewVoteBitsVersion,
+			u.UserID, u.OldVoteBits, u.OldVoteBitsVersion)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			updated = append(updated, u.UserID)
+		}
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
 
-	return userCount
+// GetUsersForExport returns all users who have submitted an address, ordered
+// by ID.
+func GetUsersForExport(dbMap *gorp.DbMap) ([]User, error) {
+	var users []User
+	_, err := dbMap.Select(&users, "SELECT * FROM Users "+
+		"WHERE MultiSigAddress <> '' ORDER BY UserId")
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
 }
 
-// GetUserMax gives the last userid.
-func GetUserMax(dbMap *gorp.DbMap) int64 {
-	maxUserID, err := dbMap.SelectInt("SELECT MAX(Userid) FROM Users")
+// ImportedUser is a user imported from another voting service along with the
+// ticket fees it paid there.
+type ImportedUser struct {
+	User       User
+	TicketFees []TicketFee
+}
+
+// ImportUsers inserts users and their ticket fees in one transaction, setting
+// the IDs of the users.  Nothing is imported when the email address or
+// multisig address of any user is already taken.
+func ImportUsers(dbMap *gorp.DbMap, users []ImportedUser) error {
+	tx, err := dbMap.Begin()
 	if err != nil {
-		return int64(0)
+		return err
+	}
+	for i := range users {
+		u := &users[i].User
+		n, err := tx.SelectInt("SELECT COUNT(*) FROM Users WHERE Email = ? "+
+			"OR MultiSigAddress = ?", u.Email, u.MultiSigAddress)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if n > 0 {
+			tx.Rollback()
+			return fmt.Errorf("email address %s or multisig address %s "+
+				"is already taken", u.Email, u.MultiSigAddress)
+		}
+		if err := tx.Insert(u); err != nil {
+			tx.Rollback()
+			return err
+		}
+		for j := range users[i].TicketFees {
+			fee := &users[i].TicketFees[j]
+			fee.UserID = u.ID
+			if err := tx.Insert(fee); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
 	}
+	return tx.Commit()
+}
 
-	return maxUserID
+// GetUserScripts returns the ID, multisig address and script, the addresses
+// the script was made of and the registration height of all users who have
+// submitted an address.
+func GetUserScripts(dbMap *gorp.DbMap) ([]User, error) {
+	var users []User
+	_, err := dbMap.Select(&users, "SELECT UserId, MultiSigAddress, "+
+		"MultiSigScript, UserPubKeyAddr, PoolPubKeyAddr, HeightRegistered "+
+		"FROM Users WHERE MultiSigAddress <> '' ORDER BY UserId")
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
 }
 
-// GetUserCountActive gives a count of all users who have submitted an address.
-func GetUserCountActive(dbMap *gorp.DbMap) int64 {
-	userCountActive, err := dbMap.SelectInt("SELECT COUNT(*) FROM Users " +
-		"WHERE MultiSigAddress <> ''")
+// GetUserFeeAddresses returns the ID, multisig address and fee address of all
+// users who have submitted an address.
+func GetUserFeeAddresses(dbMap *gorp.DbMap) ([]User, error) {
+	var users []User
+	_, err := dbMap.Select(&users, "SELECT UserId, MultiSigAddress, "+
+		"UserFeeAddr FROM Users WHERE MultiSigAddress <> ''")
 	if err != nil {
-		return int64(0)
+		return nil, err
 	}
+	return users, nil
+}
 
-	return userCountActive
+// GetVotableLowFeeTickets returns all unexpired LowFeeTickets.
+func GetVotableLowFeeTickets(dbMap *gorp.DbMap) ([]LowFeeTicket, error) {
+	var votableLowFeeTickets []LowFeeTicket
+	_, err := dbMap.Select(&votableLowFeeTickets, "SELECT * FROM LowFeeTicket WHERE Voted = 0 AND Expires > UNIX_TIMESTAMP()")
+	if err != nil {
+		return nil, err
+	}
+	return votableLowFeeTickets, nil
 }
 
-// InsertEmailChange inserts a new EmailChange row into the DB.
-func InsertEmailChange(dbMap *gorp.DbMap, emailChange *EmailChange) error {
-	return dbMap.Insert(emailChange)
+// GetVotingFreeze returns the current state of the voting preferences freeze,
+// or nil when they were never frozen.
+func GetVotingFreeze(dbMap *gorp.DbMap) (*VotingFreeze, error) {
+	var freezes []VotingFreeze
+	_, err := dbMap.Select(&freezes, "SELECT * FROM VotingFreeze "+
+		"ORDER BY VotingFreezeID DESC LIMIT 1")
+	if err != nil || len(freezes) == 0 {
+		return nil, err
+	}
+	return &freezes[0], nil
 }
 
-// InsertLowFeeTicket inserts a low fee ticket into the DB.
-func InsertLowFeeTicket(dbMap *gorp.DbMap, lowFeeTicket *LowFeeTicket) error {
-	return dbMap.Insert(lowFeeTicket)
+// GetVotingFreezes returns the most recent limit changes of the voting
+// preferences freeze, most recent first.
+func GetVotingFreezes(dbMap *gorp.DbMap, limit int64) ([]VotingFreeze, error) {
+	var freezes []VotingFreeze
+	_, err := dbMap.Select(&freezes, "SELECT * FROM VotingFreeze "+
+		"ORDER BY VotingFreezeID DESC LIMIT ?", limit)
+	return freezes, err
 }
 
-// InsertUser inserts a user into the DB.
-func InsertUser(dbMap *gorp.DbMap, user *User) error {
-	return dbMap.Insert(user)
+// InsertVotingFreeze records a change of the voting preferences freeze.
+func InsertVotingFreeze(dbMap *gorp.DbMap, freeze *VotingFreeze) error {
+	return dbMap.Insert(freeze)
 }
 
-// InsertPasswordReset inserts a new PasswordReset row into the DB.
-func InsertPasswordReset(dbMap *gorp.DbMap, passwordReset *PasswordReset) error {
-	return dbMap.Insert(passwordReset)
+// GetWebhookByUserID returns the webhook of a user, or nil if they have none.
+func GetWebhookByUserID(dbMap *gorp.DbMap, userID int64) (*Webhook, error) {
+	var webhook Webhook
+	err := dbMap.SelectOne(&webhook, "SELECT * FROM Webhook WHERE UserId = ?",
+		userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
 }
 
-// SetUserAPIToken generates and saves a unique API Token for a user.
-func SetUserAPIToken(dbMap *gorp.DbMap, APISecret string, baseURL string,
-	id int64) (string, error) {
-	var user *User
-	token := jwt.New(jwt.SigningMethodHS256)
+// GetVerifiedWebhooks returns the webhooks whose URL answered the challenge.
+func GetVerifiedWebhooks(dbMap *gorp.DbMap) ([]Webhook, error) {
+	var webhooks []Webhook
+	_, err := dbMap.Select(&webhooks, "SELECT * FROM Webhook "+
+		"WHERE Verified > 0 ORDER BY WebhookID")
+	return webhooks, err
+}
+
+// SetWebhook registers the webhook of a user, replacing any previous one along
+// with the ticket states recorded for it and its pending deliveries.
+func SetWebhook(dbMap *gorp.DbMap, webhook *Webhook) error {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return err
+	}
+	if err := deleteWebhook(tx, webhook.UserID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Insert(webhook); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteWebhook removes the webhook of a user along with the ticket states
+// recorded for it and its pending deliveries.  The delivery log is kept.
+func DeleteWebhook(dbMap *gorp.DbMap, userID int64) error {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return err
+	}
+	if err := deleteWebhook(tx, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteWebhook removes the webhook of a user in tx, see DeleteWebhook.
+func deleteWebhook(tx *gorp.Transaction, userID int64) error {
+	if _, err := tx.Exec("DELETE FROM Webhook WHERE UserId = ?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM WebhookTicket WHERE UserId = ?", userID); err != nil {
+		return err
+	}
+	_, err := tx.Exec("UPDATE WebhookDelivery SET NextAttempt = 0 "+
+		"WHERE UserId = ? AND NextAttempt > 0", userID)
+	return err
+}
+
+// SetWebhookSynced records when the ticket states of a webhook were checked.
+func SetWebhookSynced(dbMap *gorp.DbMap, id, synced int64) error {
+	_, err := dbMap.Exec("UPDATE Webhook SET Synced = ? WHERE WebhookID = ?",
+		synced, id)
+	return err
+}
+
+// GetWebhookTickets returns the recorded ticket states of a user, keyed by
+// ticket hash.
+func GetWebhookTickets(dbMap *gorp.DbMap, userID int64) (map[string]*WebhookTicket, error) {
+	var tickets []WebhookTicket
+	_, err := dbMap.Select(&tickets, "SELECT * FROM WebhookTicket "+
+		"WHERE UserId = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]*WebhookTicket, len(tickets))
+	for i := range tickets {
+		states[tickets[i].TicketHash] = &tickets[i]
+	}
+	return states, nil
+}
+
+// SaveWebhookTicket inserts or updates the recorded state of a ticket.
+func SaveWebhookTicket(dbMap *gorp.DbMap, ticket *WebhookTicket) error {
+	if ticket.ID == 0 {
+		return dbMap.Insert(ticket)
+	}
+	_, err := dbMap.Update(ticket)
+	return err
+}
+
+// InsertWebhookDelivery queues a webhook event to be sent.
+func InsertWebhookDelivery(dbMap *gorp.DbMap, delivery *WebhookDelivery) error {
+	return dbMap.Insert(delivery)
+}
+
+// GetDueWebhookDeliveries returns up to limit of the webhook events whose next
+// attempt is due at now, oldest first.
+func GetDueWebhookDeliveries(dbMap *gorp.DbMap, now int64, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	_, err := dbMap.Select(&deliveries, "SELECT * FROM WebhookDelivery "+
+		"WHERE NextAttempt > 0 AND NextAttempt <= ? "+
+		"ORDER BY WebhookDeliveryID LIMIT ?", now, limit)
+	return deliveries, err
+}
+
+// GetWebhookDeliveriesByUserID returns up to limit of the most recent webhook
+// events of a user, newest first.
+func GetWebhookDeliveriesByUserID(dbMap *gorp.DbMap, userID int64, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	_, err := dbMap.Select(&deliveries, "SELECT * FROM WebhookDelivery "+
+		"WHERE UserId = ? ORDER BY WebhookDeliveryID DESC LIMIT ?",
+		userID, limit)
+	return deliveries, err
+}
+
+// UpdateWebhookDelivery records an attempt to send a webhook event.
+func UpdateWebhookDelivery(dbMap *gorp.DbMap, delivery *WebhookDelivery) error {
+	_, err := dbMap.Update(delivery)
+	return err
+}
+
+// DeleteWebhookDeliveriesBefore removes the webhook events created before the
+// unix time before which are no longer retried, and returns how many were
+// removed.
+func DeleteWebhookDeliveriesBefore(dbMap *gorp.DbMap, before int64) (int64, error) {
+	res, err := dbMap.Exec("DELETE FROM WebhookDelivery "+
+		"WHERE Created < ? AND NextAttempt = 0", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+var (
+	// ErrInvalidRefreshToken is returned by RotateAPIRefreshToken when the
+	// refresh token does not exist or has expired.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
 
-	claims := make(jwt.MapClaims)
-	claims["iat"] = time.Now().Unix()
-	claims["iss"] = baseURL
-	claims["loggedInAs"] = id
+	// ErrRefreshTokenReused is returned by RotateAPIRefreshToken when the
+	// refresh token was already exchanged.  Since it was probably stolen,
+	// all refresh tokens of its user are revoked.
+	ErrRefreshTokenReused = errors.New("refresh token was already used")
+)
 
-	token.Claims = claims
+// apiRefreshTokenHash returns the hash of a refresh token stored in the DB.
+func apiRefreshTokenHash(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
 
-	tokenString, err := token.SignedString([]byte(APISecret))
+// insertAPIRefreshToken issues a new refresh token for a user in tx, valid
+// until expires, and removes their expired ones.
+func insertAPIRefreshToken(tx *gorp.Transaction, userID, now, expires int64) (string, error) {
+	_, err := tx.Exec("DELETE FROM APIRefreshToken WHERE UserId = ? "+
+		"AND Expires <= ?", userID, now)
 	if err != nil {
 		return "", err
 	}
+	token := NewUserToken().String()
+	err = tx.Insert(&APIRefreshToken{
+		UserID:    userID,
+		TokenHash: apiRefreshTokenHash(token),
+		Created:   now,
+		Expires:   expires,
+	})
+	return token, err
+}
 
-	err = dbMap.SelectOne(&user, "SELECT * FROM Users WHERE UserId = ?", id)
-	user.APIToken = tokenString
+// InsertAPIRefreshToken issues a new refresh token for a user, valid until the
+// unix time expires.
+func InsertAPIRefreshToken(dbMap *gorp.DbMap, userID, expires int64) (string, error) {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return "", err
+	}
+	token, err := insertAPIRefreshToken(tx, userID, time.Now().Unix(), expires)
 	if err != nil {
+		tx.Rollback()
 		return "", err
 	}
+	return token, tx.Commit()
+}
 
-	_, err = dbMap.Update(user)
-	return tokenString, err
+// RotateAPIRefreshToken exchanges a refresh token for a new one valid until
+// the unix time expires, and returns the user it was issued for.  A refresh
+// token can only be exchanged once: ErrRefreshTokenReused is returned, and
+// all refresh tokens of the user are revoked, when it is presented again.
+func RotateAPIRefreshToken(dbMap *gorp.DbMap, token string, expires int64) (int64, string, error) {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return 0, "", err
+	}
+
+	var refresh APIRefreshToken
+	err = tx.SelectOne(&refresh, "SELECT * FROM APIRefreshToken "+
+		"WHERE TokenHash = ? FOR UPDATE", apiRefreshTokenHash(token))
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return 0, "", ErrInvalidRefreshToken
+		}
+		return 0, "", err
+	}
+
+	now := time.Now().Unix()
+	switch {
+	case refresh.Used != 0:
+		_, err = tx.Exec("DELETE FROM APIRefreshToken WHERE UserId = ?",
+			refresh.UserID)
+		if err != nil {
+			tx.Rollback()
+			return 0, "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, "", err
+		}
+		return refresh.UserID, "", ErrRefreshTokenReused
+	case refresh.Expires <= now:
+		tx.Rollback()
+		return 0, "", ErrInvalidRefreshToken
+	}
+
+	_, err = tx.Exec("UPDATE APIRefreshToken SET Used = ? "+
+		"WHERE APIRefreshTokenID = ?", now, refresh.ID)
+	if err != nil {
+		tx.Rollback()
+		return 0, "", err
+	}
+	newToken, err := insertAPIRefreshToken(tx, refresh.UserID, now, expires)
+	if err != nil {
+		tx.Rollback()
+		return 0, "", err
+	}
+	return refresh.UserID, newToken, tx.Commit()
+}
+
+// RevokeAPIRefreshToken removes a refresh token, e.g. when an app signs out.
+func RevokeAPIRefreshToken(dbMap *gorp.DbMap, token string) error {
+	_, err := dbMap.Exec("DELETE FROM APIRefreshToken WHERE TokenHash = ?",
+		apiRefreshTokenHash(token))
+	return err
+}
+
+// RevokeUserAPIRefreshTokens removes all refresh tokens of a user, e.g. when
+// their password changes.
+func RevokeUserAPIRefreshTokens(dbMap *gorp.DbMap, userID int64) error {
+	_, err := dbMap.Exec("DELETE FROM APIRefreshToken WHERE UserId = ?", userID)
+	return err
 }
 
-// UpdateUserByID updates a user, specified by id, in the DB with a new
-// multiSigAddr, multiSigScript, multiSigScript, pool pubkey address,
-// user pub key address, and fee address.  Unchanged are the user's ID, email,
-// username and password.
-func UpdateUserByID(dbMap *gorp.DbMap, id int64, multiSigAddr string,
-	multiSigScript string, poolPubKeyAddr string, userPubKeyAddr string,
-	userFeeAddr string, height int64) (user *User) {
-	err := dbMap.SelectOne(&user, "SELECT * FROM Users WHERE UserId = ?", id)
+// InsertUserNote records a note an admin left about a user.
+func InsertUserNote(dbMap *gorp.DbMap, note *UserNote) error {
+	return dbMap.Insert(note)
+}
 
+// GetUserNotes returns the notes left about a user, most recent first.
+func GetUserNotes(dbMap *gorp.DbMap, userID int64) ([]UserNote, error) {
+	var notes []UserNote
+	_, err := dbMap.Select(&notes, "SELECT * FROM UserNote WHERE UserId = ? "+
+		"ORDER BY Created DESC, UserNoteID DESC", userID)
 	if err != nil {
-		log.Warnf("Can't get user by id: %v", err)
+		return nil, err
 	}
+	return notes, nil
+}
 
-	user.MultiSigAddress = multiSigAddr
-	user.MultiSigScript = multiSigScript
-	user.PoolPubKeyAddr = poolPubKeyAddr
-	user.UserPubKeyAddr = userPubKeyAddr
-	user.UserFeeAddr = userFeeAddr
-	user.HeightRegistered = height
+// InsertUserActivity records an action a user took on their account.
+func InsertUserActivity(dbMap *gorp.DbMap, activity *UserActivity) error {
+	return dbMap.Insert(activity)
+}
 
-	_, err = dbMap.Update(user)
+// GetUserActivities returns up to limit of the actions a user took on their
+// account since the unix time since, most recent first.
+func GetUserActivities(dbMap *gorp.DbMap, userID, since int64, limit int) ([]UserActivity, error) {
+	var activities []UserActivity
+	_, err := dbMap.Select(&activities, "SELECT * FROM UserActivity "+
+		"WHERE UserId = ? AND Created >= ? "+
+		"ORDER BY Created DESC, UserActivityID DESC LIMIT ?",
+		userID, since, limit)
+	return activities, err
+}
+
+// GetAdminAuditsByUserID returns up to limit of the actions recorded in the
+// admin audit trail for the user with id, as an admin or about their own
+// account, since the unix time since, most recent first.
+func GetAdminAuditsByUserID(dbMap *gorp.DbMap, id, since int64, limit int) ([]AdminAudit, error) {
+	var audits []AdminAudit
+	_, err := dbMap.Select(&audits, "SELECT * FROM AdminAudit "+
+		"WHERE AdminUid = ? AND Created >= ? "+
+		"ORDER BY AdminAuditID DESC LIMIT ?", id, since, limit)
+	return audits, err
+}
+
+// GetTOSAcceptancesByUserID returns up to limit of the terms of service
+// acceptances of a user since the unix time since, most recent first.
+func GetTOSAcceptancesByUserID(dbMap *gorp.DbMap, id, since int64, limit int) ([]TOSAcceptance, error) {
+	var acceptances []TOSAcceptance
+	_, err := dbMap.Select(&acceptances, "SELECT * FROM TOSAcceptance "+
+		"WHERE UserId = ? AND Accepted >= ? "+
+		"ORDER BY TOSAcceptanceID DESC LIMIT ?", id, since, limit)
+	return acceptances, err
+}
 
+// DeleteUserActivitiesBefore removes the actions of users recorded before the
+// unix time before, and returns how many were removed.
+func DeleteUserActivitiesBefore(dbMap *gorp.DbMap, before int64) (int64, error) {
+	res, err := dbMap.Exec("DELETE FROM UserActivity WHERE Created < ?",
+		before)
 	if err != nil {
-		log.Warnf("Couldn't update user: %v", err)
+		return 0, err
 	}
+	return res.RowsAffected()
+}
 
-	// return updated User
-	return
+// InsertStakeInfoSnapshot records a snapshot of the stake info.
+func InsertStakeInfoSnapshot(dbMap *gorp.DbMap, snapshot *StakeInfoSnapshot) error {
+	return dbMap.Insert(snapshot)
 }
 
-// GetAllCurrentMultiSigScripts returns all tracked multisig scripts.
-func GetAllCurrentMultiSigScripts(dbMap *gorp.DbMap) ([]User, error) {
-	var multiSigs []User
-	_, err := dbMap.Select(&multiSigs, "SELECT MultiSigAddress, MultiSigScript, HeightRegistered FROM Users WHERE MultiSigAddress <> ''")
+// GetStakeInfoSnapshots returns the stake info snapshots recorded since the
+// unix time since, oldest first.
+func GetStakeInfoSnapshots(dbMap *gorp.DbMap, since int64) ([]StakeInfoSnapshot, error) {
+	var snapshots []StakeInfoSnapshot
+	_, err := dbMap.Select(&snapshots, "SELECT * FROM StakeInfoSnapshot "+
+		"WHERE Created >= ? ORDER BY Created", since)
+	return snapshots, err
+}
+
+// DeleteStakeInfoSnapshotsBefore removes the stake info snapshots recorded
+// before the unix time before and returns how many were removed.
+func DeleteStakeInfoSnapshotsBefore(dbMap *gorp.DbMap, before int64) (int64, error) {
+	res, err := dbMap.Exec("DELETE FROM StakeInfoSnapshot WHERE Created < ?",
+		before)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	return multiSigs, nil
+	return res.RowsAffected()
 }
 
-// GetVotableLowFeeTickets returns all unexpired LowFeeTickets.
-func GetVotableLowFeeTickets(dbMap *gorp.DbMap) ([]LowFeeTicket, error) {
-	var votableLowFeeTickets []LowFeeTicket
-	_, err := dbMap.Select(&votableLowFeeTickets, "SELECT * FROM LowFeeTicket WHERE Voted = 0 AND Expires > UNIX_TIMESTAMP()")
+// newDbMap constructs a gorp DbMap for db with all tables registered.
+func newDbMap(db *sql.DB) *gorp.DbMap {
+	dbMap := &gorp.DbMap{
+		Db:              db,
+		Dialect:         gorp.MySQLDialect{Engine: "InnoDB", Encoding: "UTF8MB4"},
+		ExpandSliceArgs: true,
+	}
+
+	// Add a table, setting the table name and specifying that the Id property
+	// is an auto incrementing primary key
+	dbMap.AddTableWithName(AddressIndex{}, "AddressIndex").SetKeys(true, "ID").
+		SetUniqueTogether("Account", "UserId")
+	dbMap.AddTableWithName(AddressJob{}, "AddressJob").SetKeys(true, "ID")
+	dbMap.AddTableWithName(APIRefreshToken{}, "APIRefreshToken").SetKeys(true, "ID")
+	dbMap.AddTableWithName(AdminAudit{}, "AdminAudit").SetKeys(true, "ID")
+	dbMap.AddTableWithName(EmailChange{}, "EmailChange").SetKeys(true, "ID")
+	dbMap.AddTableWithName(ExpiredScript{}, "ExpiredScript").SetKeys(true, "ID")
+	dbMap.AddTableWithName(FeatureFlag{}, "FeatureFlag").SetKeys(true, "ID").
+		ColMap("Name").SetUnique(true)
+	dbMap.AddTableWithName(HistoricTicket{}, "HistoricTicket").SetKeys(true, "ID")
+	dbMap.AddTableWithName(HistoryImport{}, "HistoryImport").SetKeys(true, "ID")
+	dbMap.AddTableWithName(InviteCode{}, "InviteCode").SetKeys(true, "ID")
+	dbMap.AddTableWithName(LowFeeTicket{}, "LowFeeTicket").SetKeys(true, "ID")
+	dbMap.AddTableWithName(Message{}, "Message").SetKeys(true, "ID").
+		ColMap("DedupeKey").SetUnique(true)
+	dbMap.AddTableWithName(MissedTicket{}, "MissedTicket").SetKeys(true, "ID").
+		ColMap("TicketHash").SetUnique(true)
+	dbMap.AddTableWithName(PasswordReset{}, "PasswordReset").SetKeys(true, "ID")
+	dbMap.AddTableWithName(QueuedEmail{}, "QueuedEmail").SetKeys(true, "ID")
+	dbMap.AddTableWithName(RetiredScript{}, "RetiredScript").SetKeys(true, "ID")
+	dbMap.AddTableWithName(Session{}, "Session").SetKeys(true, "ID")
+	dbMap.AddTableWithName(StakeInfoSnapshot{}, "StakeInfoSnapshot").SetKeys(true, "ID")
+	dbMap.AddTableWithName(StakepooldUpdate{}, "StakepooldUpdate").SetKeys(true, "ID")
+	dbMap.AddTableWithName(TicketFee{}, "TicketFee").SetKeys(true, "ID").
+		ColMap("TicketHash").SetUnique(true)
+	dbMap.AddTableWithName(TOSAcceptance{}, "TOSAcceptance").SetKeys(true, "ID")
+	dbMap.AddTableWithName(User{}, "Users").SetKeys(true, "ID")
+	dbMap.AddTableWithName(UserActivity{}, "UserActivity").SetKeys(true, "ID")
+	dbMap.AddTableWithName(UserNote{}, "UserNote").SetKeys(true, "ID")
+	dbMap.AddTableWithName(VotingFreeze{}, "VotingFreeze").SetKeys(true, "ID")
+	dbMap.AddTableWithName(Webhook{}, "Webhook").SetKeys(true, "ID").
+		ColMap("UserID").SetUnique(true)
+	dbMap.AddTableWithName(WebhookDelivery{}, "WebhookDelivery").SetKeys(true, "ID")
+	dbMap.AddTableWithName(WebhookTicket{}, "WebhookTicket").SetKeys(true, "ID")
+
+	return dbMap
+}
+
+// GetReplicaDbMap returns a DbMap for the read-only MySQL replica described by
+// dsn.  Unlike GetDbMap no connection is made and no tables are created or
+// migrated, since the replica receives its schema from the primary.
+func GetReplicaDbMap(dsn string) (*gorp.DbMap, error) {
+	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid replica data source name: %v", err)
 	}
-	return votableLowFeeTickets, nil
+
+	return newDbMap(db), nil
 }
 
 // GetDbMap returns the entire gorp DbMap. It creates tables where none are
 // found and updates values when needed.
-func GetDbMap(APISecret, baseURL, user, password, hostname, port, database string) (*gorp.DbMap, error) {
+func GetDbMap(apiKeys *APIKeyring, baseURL, user, password, hostname, port, database string) (*gorp.DbMap, error) {
 	// Connect to db using standard Go database/sql API.
 	dataSource := fmt.Sprintf("%s:%s@(%s:%s)/%s?charset=utf8mb4",
 		user, password, hostname, port, database)
@@ -327,21 +2373,8 @@ func GetDbMap(APISecret, baseURL, user, password, hostname, port, database strin
 		return nil, fmt.Errorf("failed to ping database server: %v", err)
 	}
 
-	// Construct a gorp DbMap.
-	dbMap := &gorp.DbMap{
-		Db:              db,
-		Dialect:         gorp.MySQLDialect{Engine: "InnoDB", Encoding: "UTF8MB4"},
-		ExpandSliceArgs: true,
-	}
-
-	// Add a table, setting the table name and specifying that the Id property
-	// is an auto incrementing primary key
-	dbMap.AddTableWithName(EmailChange{}, "EmailChange").SetKeys(true, "ID")
-	dbMap.AddTableWithName(LowFeeTicket{}, "LowFeeTicket").SetKeys(true, "ID")
-	dbMap.AddTableWithName(PasswordReset{}, "PasswordReset").SetKeys(true, "ID")
-	dbMap.AddTableWithName(Session{}, "Session").SetKeys(true, "ID")
+	dbMap := newDbMap(db)
 	usersTableName := "Users"
-	dbMap.AddTableWithName(User{}, usersTableName).SetKeys(true, "ID")
 
 	// Create the table.
 	err = dbMap.CreateTablesIfNotExists()
@@ -403,7 +2436,7 @@ func GetDbMap(APISecret, baseURL, user, password, hostname, port, database strin
 	}
 
 	for _, u := range users {
-		_, err := SetUserAPIToken(dbMap, APISecret, baseURL, u.ID)
+		_, err := SetUserAPIToken(dbMap, apiKeys, baseURL, u.ID)
 		if err != nil {
 			log.Errorf("Unable to set API Token for UserId %v: %v", u.ID, err)
 		}
@@ -420,6 +2453,70 @@ func GetDbMap(APISecret, baseURL, user, password, hostname, port, database strin
 	// and it will be upgraded when talking to stakepoold
 	AddColumn(dbMap, database, usersTableName, "VoteBitsVersion", "bigint(20) NULL", "VoteBits", "UPDATE Users SET VoteBitsVersion = 3")
 
+	// add TOSVersion and TOSAccepted columns for storing the version of the
+	// terms of service most recently accepted by the user and when it was
+	// accepted.  Existing users have not accepted any version.
+	AddColumn(dbMap, database, usersTableName, "TOSVersion", "varchar(255) NULL", "VoteBitsVersion", "UPDATE Users SET TOSVersion = ''")
+	AddColumn(dbMap, database, usersTableName, "TOSAccepted", "bigint(20) NULL", "TOSVersion", "UPDATE Users SET TOSAccepted = 0")
+
+	// add EmailTokenSent and EmailTokenExpires columns so that email
+	// verification links expire and resending them can be rate limited.
+	// Outstanding links of existing users are given a day to be used.
+	AddColumn(dbMap, database, usersTableName, "EmailTokenSent", "bigint(20) NULL", "TOSAccepted", "UPDATE Users SET EmailTokenSent = 0")
+	AddColumn(dbMap, database, usersTableName, "EmailTokenExpires", "bigint(20) NULL", "EmailTokenSent",
+		"UPDATE Users SET EmailTokenExpires = IF(EmailToken = '', 0, UNIX_TIMESTAMP() + 86400)")
+
+	// add Created column for the time an account was registered so that
+	// unverified accounts can be removed after a while.  The registration
+	// time of existing users is unknown so the upgrade time is used.
+	AddColumn(dbMap, database, usersTableName, "Created", "bigint(20) NULL", "EmailTokenExpires", "UPDATE Users SET Created = UNIX_TIMESTAMP()")
+
+	// add ReadOnlyAPIToken column for the optional read-only API token a
+	// user may embed in a public dashboard.  Storing it allows it to be
+	// revoked.
+	AddColumn(dbMap, database, usersTableName, "ReadOnlyAPIToken", "varchar(255) NULL", "Created", "UPDATE Users SET ReadOnlyAPIToken = ''")
+
+	// add InviteCodeID column for the invite code a user registered with
+	// while the voting service was invite-only, so that the users of each
+	// code can be listed.
+	AddColumn(dbMap, database, usersTableName, "InviteCodeID", "bigint(20) NULL", "ReadOnlyAPIToken", "UPDATE Users SET InviteCodeID = 0")
+
+	// add the registration metadata columns so that the users registered
+	// from the same IP or with the same referral code can be found.  It is
+	// unknown for existing users.
+	AddColumn(dbMap, database, usersTableName, "RegistrationIP", "varchar(255) NULL", "InviteCodeID", "UPDATE Users SET RegistrationIP = ''")
+	AddColumn(dbMap, database, usersTableName, "RegistrationUserAgent", "varchar(500) NULL", "RegistrationIP", "UPDATE Users SET RegistrationUserAgent = ''")
+	AddColumn(dbMap, database, usersTableName, "ReferralCode", "varchar(255) NULL", "RegistrationUserAgent", "UPDATE Users SET ReferralCode = ''")
+
+	// add BadgeToken column for the optional status badge URL a user may
+	// embed in dashboards and forums.  Storing it allows it to be revoked.
+	AddColumn(dbMap, database, usersTableName, "BadgeToken", "varchar(255) NULL", "ReferralCode", "UPDATE Users SET BadgeToken = ''")
+
+	// add the columns tracking the expiry of multisig scripts which no
+	// tickets were bought with.  The setup time of existing scripts is
+	// unknown, so the registration time is used.
+	AddColumn(dbMap, database, usersTableName, "ScriptActivated", "bigint(20) NULL", "BadgeToken", "UPDATE Users SET ScriptActivated = Created")
+	AddColumn(dbMap, database, usersTableName, "ScriptExpiryWarned", "bigint(20) NULL", "ScriptActivated", "UPDATE Users SET ScriptExpiryWarned = 0")
+
+	// add the columns for confirming email changes from the current address
+	// of the user, and for the cooldown after a change.
+	AddColumn(dbMap, database, "EmailChange", "OldToken", "varchar(255) NULL", "Expires", "UPDATE EmailChange SET OldToken = ''")
+	AddColumn(dbMap, database, "EmailChange", "NewConfirmed", "bigint(20) NULL", "OldToken", "UPDATE EmailChange SET NewConfirmed = 0")
+	AddColumn(dbMap, database, "EmailChange", "OldConfirmed", "bigint(20) NULL", "NewConfirmed", "UPDATE EmailChange SET OldConfirmed = 0")
+	AddColumn(dbMap, database, usersTableName, "EmailChanged", "bigint(20) NULL", "ScriptExpiryWarned", "UPDATE Users SET EmailChanged = 0")
+
+	// make the hashes of missed tickets unique, since every stakepoold
+	// instance records the misses it audits.  Duplicates recorded before
+	// are removed, keeping the first record of each ticket.
+	AddUniqueKey(dbMap, database, "MissedTicket", "TicketHash",
+		"DELETE m FROM MissedTicket m JOIN MissedTicket f "+
+			"ON m.TicketHash = f.TicketHash AND m.MissedTicketID > f.MissedTicketID")
+
+	// add DedupeKey column so that the messages written by every stakepoold
+	// instance are only delivered once.
+	AddColumn(dbMap, database, "Message", "DedupeKey", "varchar(64) NULL", "Read", "")
+	AddUniqueKey(dbMap, database, "Message", "DedupeKey", "")
+
 	return dbMap, nil
 }
 
@@ -444,6 +2541,26 @@ func AddColumn(dbMap *gorp.DbMap, db string, table string, columnToAdd string,
 	}
 }
 
+// AddUniqueKey checks if a column has a unique key and adds it if it doesn't,
+// after running dedupeQry to remove the rows which would violate it.
+func AddUniqueKey(dbMap *gorp.DbMap, db string, table string, column string,
+	dedupeQry string) {
+	n, err := dbMap.SelectInt("SELECT COUNT(*) FROM " +
+		"information_schema.statistics WHERE table_schema = '" + db +
+		"' AND table_name = '" + table + "' AND column_name = '" +
+		column + "' AND non_unique = 0")
+	checkErr(err, "checking whether column "+column+" is unique failed")
+	if err == nil && n == 0 {
+		if dedupeQry != "" {
+			_, err = dbMap.Exec(dedupeQry)
+			checkErr(err, dedupeQry+" failed")
+		}
+		_, err = dbMap.Exec("ALTER TABLE `" + table + "` ADD UNIQUE KEY `" +
+			column + "` (`" + column + "`)")
+		checkErr(err, "adding unique key on "+column+" failed")
+	}
+}
+
 func checkErr(err error, msg string) {
 	if err != nil {
 		log.Critical(msg, err)
@@ -1,8 +1,12 @@
 package models
 
 import (
+	"errors"
 	"reflect"
+	"regexp"
 	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
 )
 
 func TestNewUserToken(t *testing.T) {
@@ -86,3 +90,93 @@ func TestUserToken_String(t *testing.T) {
 		})
 	}
 }
+
+func TestAddressJobImported(t *testing.T) {
+	var job AddressJob
+	if imported := job.Imported(); len(imported) != 0 {
+		t.Fatalf("new job imported into %v", imported)
+	}
+	job.AddImported("host1:9113")
+	job.AddImported("host2:9113")
+	want := []string{"host1:9113", "host2:9113"}
+	if imported := job.Imported(); !reflect.DeepEqual(imported, want) {
+		t.Fatalf("got imported %v, want %v", imported, want)
+	}
+}
+
+func TestSetUserScript(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	dbMap := newDbMap(db)
+	script := &UserScript{
+		MultiSigAddress: "TcfdqCrK2fiFJBZnGj5N6xs6rMsbQBsJBYf",
+		UserPubKeyAddr:  "TkKmVKG7u7PwhQaYr7wgMqBwHneJ2cN4e5YpMVUsWSopx81NFXEzw",
+	}
+
+	// A user who submitted an address meanwhile is not changed.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE Users SET MultiSigAddress")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err = SetUserScript(dbMap, 1, script, nil, 1600000000)
+	if !errors.Is(err, ErrAddressSubmitted) {
+		t.Fatalf("got error %v, want ErrAddressSubmitted", err)
+	}
+
+	// The script, the completion of the job and the stakepoold update are
+	// committed at once.
+	job := &AddressJob{ID: 7, UserID: 1, Status: AddressJobImporting}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE Users SET MultiSigAddress")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("update `AddressJob`")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("insert into `StakepooldUpdate`")).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+	if err := SetUserScript(dbMap, 1, script, job, 1600000000); err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != AddressJobComplete || job.Updated != 1600000000 {
+		t.Fatalf("job is %s since %d, want complete", job.Status, job.Updated)
+	}
+
+	// Nothing is committed when the stakepoold update cannot be recorded.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE Users SET MultiSigAddress")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("insert into `StakepooldUpdate`")).
+		WillReturnError(errors.New("disk full"))
+	mock.ExpectRollback()
+	if err := SetUserScript(dbMap, 1, script, nil, 1600000000); err == nil {
+		t.Fatal("script stored without its stakepoold update")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmailChangeConfirmed(t *testing.T) {
+	// Without an old token only the new address has to confirm.
+	ec := EmailChange{Token: "new"}
+	if ec.Confirmed() {
+		t.Fatal("unconfirmed change is confirmed")
+	}
+	ec.NewConfirmed = 1
+	if !ec.Confirmed() {
+		t.Fatal("change confirmed from the new address is not confirmed")
+	}
+
+	ec = EmailChange{Token: "new", OldToken: "old", NewConfirmed: 1}
+	if ec.Confirmed() {
+		t.Fatal("change not confirmed from the old address is confirmed")
+	}
+	ec.OldConfirmed = 1
+	if !ec.Confirmed() {
+		t.Fatal("change confirmed from both addresses is not confirmed")
+	}
+}
@@ -5,23 +5,72 @@ import (
 )
 
 // Response is the JSON API response to all requests and holds data related to
-// the request if successful.
+// the request if successful.  Errors describes why a request failed.
 type Response struct {
 	Status  string           `json:"status"`
 	Message string           `json:"message"`
 	Data    *json.RawMessage `json:"data,omitempty"`
+	Errors  []Error          `json:"errors,omitempty"`
+}
+
+// Codes of the Errors of a Response.  Unlike the messages they do not change
+// between releases, so clients should check them instead of the messages.
+const (
+	ErrInvalidAPIToken  = "invalid_api_token"
+	ErrInvalidLogin     = "invalid_login"
+	ErrEmailNotVerified = "email_not_verified"
+	ErrInvalidRefresh   = "invalid_refresh_token"
+	ErrReadOnlyAPIToken = "read_only_api_token"
+	ErrNotAdmin         = "not_admin"
+	ErrTOSNotAccepted   = "tos_not_accepted"
+	ErrAddressExists    = "address_exists"
+	ErrNoAddress        = "no_address"
+	ErrInvalidAddress   = "invalid_address"
+	ErrAddressReused    = "address_reused"
+	ErrAddressLength    = "address_length"
+	ErrAddressEncoding  = "address_encoding"
+	ErrAddressNetwork   = "address_network"
+	ErrAddressType      = "address_type"
+	ErrEmailCooldown    = "email_cooldown"
+	ErrInvalidVoteBits  = "invalid_votebits"
+	ErrInvalidArgument  = "invalid_argument"
+	ErrNotPublished     = "not_published"
+	ErrMaintenance      = "maintenance"
+	ErrReadOnly         = "read_only"
+	ErrWalletSyncing    = "wallet_syncing"
+	ErrUnavailable      = "unavailable"
+	ErrInternal         = "internal"
+)
+
+// Error is a failure of a request.  Code is one of the Err constants and
+// Field is the name of the form value which caused it, if any.
+type Error struct {
+	Code    string `json:"code"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
 }
 
 // TODO: make JSON tags lower-case and add "_" between words
 
 // PurchaseInfo is a JSON data struct related to a user's ticket purchases.
+// Script is omitted for requests made with a read-only API token.
+//
+// ScriptHash, PoolPubKeyAddress and UserPubKeyAddress let wallets verify the
+// multisig before purchasing tickets: Script must be the 1-of-2 multisig
+// script of the public keys of PoolPubKeyAddress and UserPubKeyAddress, in
+// that order, ScriptHash is its hash160, and TicketAddress is its P2SH
+// address.  UserPubKeyAddress is the address submitted by the user, which the
+// wallet should own.
 type PurchaseInfo struct {
-	PoolAddress     string  `json:"PoolAddress"`
-	PoolFees        float64 `json:"PoolFees"`
-	Script          string  `json:"Script"`
-	TicketAddress   string  `json:"TicketAddress"`
-	VoteBits        uint16  `json:"VoteBits"`
-	VoteBitsVersion uint32  `json:"VoteBitsVersion"`
+	PoolAddress       string  `json:"PoolAddress"`
+	PoolFees          float64 `json:"PoolFees"`
+	Script            string  `json:"Script,omitempty"`
+	ScriptHash        string  `json:"ScriptHash"`
+	PoolPubKeyAddress string  `json:"PoolPubKeyAddress"`
+	UserPubKeyAddress string  `json:"UserPubKeyAddress"`
+	TicketAddress     string  `json:"TicketAddress"`
+	VoteBits          uint16  `json:"VoteBits"`
+	VoteBitsVersion   uint32  `json:"VoteBitsVersion"`
 }
 
 // Stats is a JSON data struct with information about the pool.
@@ -48,4 +97,219 @@ type Stats struct {
 	UserCount            int64   `json:"UserCount"`
 	UserCountActive      int64   `json:"UserCountActive"`
 	Version              string  `json:"Version"`
+	Luck                 []Luck  `json:"Luck"`
+}
+
+// Luck is a JSON data struct comparing the tickets of the voting service which
+// were selected to vote, whether they voted or missed, over the last Days days
+// with the number of tickets expected to be selected given its share of the
+// live tickets.  Covered is the number of days covered by the stake info
+// recorded by the voting service, which is less than Days while it has not
+// recorded it for long enough.  Luck is Voted plus Missed as a percentage of
+// Expected, or 0 when no tickets were expected.
+type Luck struct {
+	Days     int64   `json:"Days"`
+	Covered  float64 `json:"Covered"`
+	Voted    int64   `json:"Voted"`
+	Missed   int64   `json:"Missed"`
+	Expected float64 `json:"Expected"`
+	Luck     float64 `json:"Luck"`
+}
+
+// Estimate is a JSON data struct with the expected outcome of a ticket
+// purchased at the current ticket price and network ticket pool size.
+// VoteProbability is the probability that the ticket votes before expiring,
+// ExpectedVoteTime is the expected number of seconds until it votes given that
+// it does, and ReturnPercent is NetReward as a percentage of TicketPrice.
+// Amounts are in DCR.
+type Estimate struct {
+	BlockHeight      int64   `json:"BlockHeight"`
+	TicketPrice      float64 `json:"TicketPrice"`
+	PoolSize         uint32  `json:"PoolSize"`
+	PoolFees         float64 `json:"PoolFees"`
+	VoteProbability  float64 `json:"VoteProbability"`
+	ExpectedVoteTime int64   `json:"ExpectedVoteTime"`
+	VoteReward       float64 `json:"VoteReward"`
+	PoolFee          float64 `json:"PoolFee"`
+	NetReward        float64 `json:"NetReward"`
+	ReturnPercent    float64 `json:"ReturnPercent"`
+}
+
+// Ticket is a JSON data struct with information about one of a user's tickets.
+// LiveHeight and LiveTime are only set for immature tickets and give the block
+// height at which the ticket goes live and an estimate of when, as a unix
+// timestamp, that block is mined.
+type Ticket struct {
+	Ticket        string `json:"Ticket"`
+	Status        string `json:"Status"`
+	TicketHeight  uint32 `json:"TicketHeight"`
+	SpentBy       string `json:"SpentBy,omitempty"`
+	SpentByHeight uint32 `json:"SpentByHeight,omitempty"`
+	LiveHeight    uint32 `json:"LiveHeight,omitempty"`
+	LiveTime      int64  `json:"LiveTime,omitempty"`
+}
+
+// TicketSummary is a JSON data struct with totals over all of a user's
+// tickets.  VotePayouts is the sum of the vote outputs paid to the user, which
+// includes the returned stake, and Rewards the part of it earned by voting,
+// net of voting service fees.  LiveStake is the sum of the prices of immature
+// and live tickets.  Amounts are in DCR.  AmountsIncomplete is set when the
+// amounts of some tickets or votes could not be looked up.
+type TicketSummary struct {
+	Total             int     `json:"Total"`
+	Immature          int     `json:"Immature"`
+	Live              int     `json:"Live"`
+	Voted             int     `json:"Voted"`
+	Missed            int     `json:"Missed"`
+	Expired           int     `json:"Expired"`
+	Rewards           float64 `json:"Rewards"`
+	VotePayouts       float64 `json:"VotePayouts"`
+	LiveStake         float64 `json:"LiveStake"`
+	AmountsIncomplete bool    `json:"AmountsIncomplete,omitempty"`
+}
+
+// Tickets is a JSON data struct with the tickets of a user.
+type Tickets struct {
+	BlockHeight    int64          `json:"BlockHeight"`
+	Tickets        []Ticket       `json:"Tickets"`
+	InvalidTickets []string       `json:"InvalidTickets"`
+	Summary        *TicketSummary `json:"Summary,omitempty"`
+}
+
+// Message is a JSON data struct with an in-app message for a user.  Kind is
+// one of "voteversion", "lowfee", "userlimit", "feeaddress" or "maintenance".
+// Created and Read are unix timestamps, and Read is 0 while the message is
+// unread.
+type Message struct {
+	ID      int64  `json:"ID"`
+	Kind    string `json:"Kind"`
+	Subject string `json:"Subject"`
+	Body    string `json:"Body"`
+	Created int64  `json:"Created"`
+	Read    int64  `json:"Read"`
+}
+
+// Messages is a JSON data struct with the most recent messages of a user and
+// their count of unread messages.
+type Messages struct {
+	Unread   int64     `json:"Unread"`
+	Messages []Message `json:"Messages"`
+}
+
+// VSPInfo is a JSON data struct describing the voting service, modeled after
+// the vspinfo response of vspd so that wallets can discover both kinds of
+// voting service the same way.  Unlike the other API responses it is not
+// wrapped in a Response.  PubKey is the key vspd signs its responses with and
+// is omitted, as dcrstakepool does not sign its responses.  Voting, Voted and
+// Revoked count the live, voted and revoked tickets of the voting service.
+type VSPInfo struct {
+	APIVersions   []int   `json:"apiversions"`
+	Timestamp     int64   `json:"timestamp"`
+	PubKey        []byte  `json:"pubkey,omitempty"`
+	FeePercentage float64 `json:"feepercentage"`
+	VspClosed     bool    `json:"vspclosed"`
+	Network       string  `json:"network"`
+	VspdVersion   string  `json:"vspdversion"`
+	VoteVersion   uint32  `json:"voteversion"`
+	Voting        uint32  `json:"voting"`
+	Voted         uint32  `json:"voted"`
+	Revoked       uint32  `json:"revoked"`
+}
+
+// VotingPrefs is a JSON data struct with the voting preferences of a user, as
+// exported and imported to move them between voting services or accounts.
+// VoteChoices maps the ID of each agenda of VoteVersion to the ID of the
+// chosen choice, and VoteBits is the resulting vote bits.  When importing,
+// VoteBits is ignored and agendas missing from VoteChoices abstain.
+type VotingPrefs struct {
+	VoteVersion uint32            `json:"VoteVersion"`
+	VoteBits    uint16            `json:"VoteBits"`
+	VoteChoices map[string]string `json:"VoteChoices"`
+}
+
+// Maintenance is a JSON data struct with the state of the maintenance mode of
+// the voting service, returned to admins.  Since is the unix timestamp the
+// mode was last changed, or 0 when it was set on startup.
+type Maintenance struct {
+	Enabled bool  `json:"Enabled"`
+	Since   int64 `json:"Since"`
+}
+
+// ValidAddress is a JSON data struct describing an address accepted as the
+// UserPubKeyAddr of the address command, with the network it belongs to and
+// its hex encoded public key.
+type ValidAddress struct {
+	Address string `json:"Address"`
+	Network string `json:"Network"`
+	PubKey  string `json:"PubKey"`
+}
+
+// AlertState is a JSON data struct with the state of an alert evaluated by the
+// voting service, returned to admins.  Threshold describes the condition which
+// fires the alert and Value the last evaluated value.  Since is the unix
+// timestamp the alert last started or stopped firing, or 0 when it never
+// fired, and Message describes the firing alert.
+type AlertState struct {
+	Name      string `json:"Name"`
+	Firing    bool   `json:"Firing"`
+	Threshold string `json:"Threshold"`
+	Value     string `json:"Value"`
+	Since     int64  `json:"Since"`
+	Message   string `json:"Message,omitempty"`
+}
+
+// ReadOnly is a JSON data struct with the state of the read-only mode of the
+// voting service, returned to admins.  Manual is set while an admin enabled it
+// and Automatic while the database refuses writes, with Reason the last error.
+// Since is the unix timestamp the mode was last entered or left, or 0 when it
+// was set on startup.
+type ReadOnly struct {
+	Enabled   bool   `json:"Enabled"`
+	Manual    bool   `json:"Manual"`
+	Automatic bool   `json:"Automatic"`
+	Reason    string `json:"Reason,omitempty"`
+	Since     int64  `json:"Since"`
+}
+
+// LoginTokens is a JSON data struct with the tokens issued by the API login.
+// AccessToken is an API token used as the Bearer token until the unix time
+// AccessTokenExpires.  RefreshToken is exchanged once for new tokens with the
+// refresh command until RefreshTokenExpires.
+type LoginTokens struct {
+	AccessToken         string `json:"AccessToken"`
+	AccessTokenExpires  int64  `json:"AccessTokenExpires"`
+	RefreshToken        string `json:"RefreshToken"`
+	RefreshTokenExpires int64  `json:"RefreshTokenExpires"`
+}
+
+// DebugLevel is a JSON data struct with the log levels set at runtime by an
+// admin, in the syntax of the debuglevel option.
+type DebugLevel struct {
+	DebugLevel string `json:"DebugLevel"`
+}
+
+// AgendaChoiceStats is a JSON data struct with the number of users of the
+// voting service voting for a choice of an agenda.
+type AgendaChoiceStats struct {
+	Choice      string  `json:"Choice"`
+	Description string  `json:"Description"`
+	Users       int64   `json:"Users"`
+	Percent     float64 `json:"Percent"`
+}
+
+// AgendaStats is a JSON data struct with how the users of the voting service
+// vote on an agenda.
+type AgendaStats struct {
+	Agenda      string              `json:"Agenda"`
+	Description string              `json:"Description"`
+	Choices     []AgendaChoiceStats `json:"Choices"`
+}
+
+// VotingStats is a JSON data struct with an anonymous breakdown of the vote
+// choices of the users of the voting service, who number Users, on each
+// agenda of VoteVersion.
+type VotingStats struct {
+	VoteVersion uint32        `json:"VoteVersion"`
+	Users       int64         `json:"Users"`
+	Agendas     []AgendaStats `json:"Agendas"`
 }
@@ -0,0 +1,145 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// goroutineCheckInterval is how often the number of goroutines is checked by
+// watchGoroutines.
+const goroutineCheckInterval = time.Minute
+
+// goroutineWarnInterval is the minimum time between two warnings about the
+// number of goroutines while it stays above the limit.
+const goroutineWarnInterval = 15 * time.Minute
+
+// profileListenAddr returns the address the profiling server listens on for
+// the profile option, which is either a port, served on localhost, or a host
+// and port whose host must be a loopback address since the profiles expose
+// the internals of the process.
+func profileListenAddr(profile string) (string, error) {
+	host, port, err := net.SplitHostPort(profile)
+	if err != nil {
+		// Only a port.
+		host, port = "127.0.0.1", profile
+	}
+	if host == "localhost" {
+		host = "127.0.0.1"
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return "", fmt.Errorf("profile must be a port or a loopback "+
+			"address, not %q", profile)
+	}
+	if p, err := net.LookupPort("tcp", port); err != nil || p < 1024 {
+		return "", fmt.Errorf("profile port %q must be between 1024 and "+
+			"65535", port)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// profileHandler returns the handler of the pprof profiles under
+// /debug/pprof/ and of the expvar variables under /debug/vars.  The handlers
+// are registered on their own mux rather than http.DefaultServeMux.
+func profileHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// serveProfiles serves profileHandler on addr until ctx is cancelled.
+func serveProfiles(ctx context.Context, wg *sync.WaitGroup, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: profileHandler()}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.Errorf("profile server Shutdown: %v", err)
+		}
+	}()
+
+	log.Infof("serving profiles on http://%v/debug/pprof/", listener.Addr())
+	go func() {
+		err := server.Serve(listener)
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("profile server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+func init() {
+	expvar.Publish("goroutines", expvar.Func(func() interface{} {
+		return runtime.NumGoroutine()
+	}))
+}
+
+// goroutineWatchdog decides when the number of goroutines is reported.  The
+// number grows with the requests in progress, so a number which keeps growing
+// under steady load is a sign that handlers leak goroutines.
+type goroutineWatchdog struct {
+	limit    int
+	peak     int
+	lastWarn time.Time
+}
+
+// check returns whether count goroutines at now are warned about, which is
+// when count exceeds the limit and either no warning was given for
+// goroutineWarnInterval or count exceeds the peak of the previous warnings.
+func (g *goroutineWatchdog) check(count int, now time.Time) bool {
+	if g.limit <= 0 || count <= g.limit {
+		return false
+	}
+	if count <= g.peak && now.Sub(g.lastWarn) < goroutineWarnInterval {
+		return false
+	}
+	if count > g.peak {
+		g.peak = count
+	}
+	g.lastWarn = now
+	return true
+}
+
+// watchGoroutines logs the number of goroutines every goroutineCheckInterval
+// and warns when it exceeds limit until ctx is cancelled.
+func watchGoroutines(ctx context.Context, wg *sync.WaitGroup, limit int) {
+	defer wg.Done()
+	watchdog := goroutineWatchdog{limit: limit}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(goroutineCheckInterval):
+			count := runtime.NumGoroutine()
+			log.Debugf("%d goroutines running", count)
+			if watchdog.check(count, time.Now()) {
+				log.Warnf("%d goroutines are running, more than the "+
+					"goroutinewarn limit of %d.  Handlers may be leaking "+
+					"goroutines, see the goroutine profile of the profile "+
+					"option.", count, limit)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProfileListenAddr(t *testing.T) {
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{"6060", "127.0.0.1:6060"},
+		{"localhost:6060", "127.0.0.1:6060"},
+		{"[::1]:6060", "[::1]:6060"},
+		{"0.0.0.0:6060", ""},
+		{"192.168.1.2:6060", ""},
+		{"80", ""},
+		{"port", ""},
+	}
+	for _, test := range tests {
+		addr, err := profileListenAddr(test.profile)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", test.profile, addr)
+			}
+			continue
+		}
+		if err != nil || addr != test.want {
+			t.Errorf("%s: got %s, %v, want %s", test.profile, addr, err,
+				test.want)
+		}
+	}
+}
+
+func TestGoroutineWatchdog(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	g := goroutineWatchdog{limit: 100}
+
+	if g.check(100, now) {
+		t.Fatal("warned at the limit")
+	}
+	if !g.check(150, now) {
+		t.Fatal("no warning above the limit")
+	}
+	if g.check(120, now.Add(time.Minute)) {
+		t.Fatal("warned again below the peak")
+	}
+	if !g.check(200, now.Add(2*time.Minute)) {
+		t.Fatal("no warning above the peak")
+	}
+	if !g.check(120, now.Add(2*time.Minute+goroutineWarnInterval)) {
+		t.Fatal("no warning after the warning interval")
+	}
+	if (&goroutineWatchdog{}).check(1e6, now) {
+		t.Fatal("warned without a limit")
+	}
+}
@@ -67,3 +67,43 @@ $(document).ready(function () {
     $(this).parent().parent().fadeOut();
   });
 });
+
+// validates the public key addresses typed into the inputs marked with
+// data-validate-address with the validateaddress API command, and shows the
+// result below them before the form is submitted
+$('input[data-validate-address]').each(function () {
+  var $input = $(this),
+      $feedback = $input.next('.address-feedback'),
+      timer;
+
+  $input.on('input', function () {
+    clearTimeout(timer);
+    var address = $input.val().trim();
+    if (address === '') {
+      $feedback.text('').removeClass('text-danger text-success');
+      return;
+    }
+    timer = setTimeout(function () {
+      var body = new URLSearchParams();
+      body.append('UserPubKeyAddr', address);
+      fetch('/api/v3/validateaddress', {method: 'POST', body: body})
+        .then(function (resp) { return resp.json(); })
+        .then(function (resp) {
+          // ignore the results of addresses which were edited since
+          if ($input.val().trim() !== address) {
+            return;
+          }
+          if (resp.status === 'success') {
+            $feedback.text('Valid ' + resp.data.Network + ' public key address')
+              .removeClass('text-danger').addClass('text-success');
+            return;
+          }
+          var msg = resp.errors && resp.errors.length ? resp.errors[0].message : resp.message;
+          $feedback.text(msg).removeClass('text-success').addClass('text-danger');
+        })
+        .catch(function () {
+          $feedback.text('').removeClass('text-danger text-success');
+        });
+    }, 300);
+  });
+});
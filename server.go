@@ -6,6 +6,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -13,19 +14,47 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/csrf"
 
 	"github.com/decred/dcrstakepool/controllers"
 	"github.com/decred/dcrstakepool/email"
+	"github.com/decred/dcrstakepool/internal/notify"
+	"github.com/decred/dcrstakepool/internal/signedurl"
+	"github.com/decred/dcrstakepool/models"
 	"github.com/decred/dcrstakepool/signal"
 	"github.com/decred/dcrstakepool/stakepooldclient"
 	"github.com/decred/dcrstakepool/system"
 
 	"github.com/zenazn/goji/web"
 	"github.com/zenazn/goji/web/middleware"
+	"google.golang.org/grpc/keepalive"
 )
 
+// bestBlockPollInterval is how often the best block is polled to refresh the
+// cached stake info shortly after a new block.
+const bestBlockPollInterval = 10 * time.Second
+
+// chainParamsCheckInterval is how often the chain parameters and pool fees of
+// the stakepoold instances are checked against those of dcrstakepool.
+const chainParamsCheckInterval = 5 * time.Minute
+
+// webhookCheckInterval is how often the tickets of users with a webhook are
+// checked for changes and the due webhook events are sent.
+const webhookCheckInterval = time.Minute
+
+// readOnlyCheckInterval is how often the database is probed for whether it
+// accepts writes, to enter or leave the automatic read-only mode.
+const readOnlyCheckInterval = 10 * time.Second
+
+// ticketFeeCheckInterval is how often the pending ticket fees are checked for
+// mined fee transactions and passed deadlines when fees are deferred.
+const ticketFeeCheckInterval = time.Minute
+
+// alertCheckInterval is how often the alerts with thresholds are evaluated.
+const alertCheckInterval = time.Minute
+
 var (
 	cfg *config
 )
@@ -50,9 +79,17 @@ func runMain(ctx context.Context) error {
 	// WaitGroup to pass around and wait, after shutdown signal is received,
 	// for goroutines to safely stop.
 	wg := new(sync.WaitGroup)
+	// The init subcommand writes the configuration, so it runs before it
+	// is loaded.
+	if initCommandRequested() {
+		return runInit(ctx, os.Args[2:])
+	}
 	// Load configuration and parse command line.  This function also
 	// initializes logging and configures it accordingly.
 	loadedCfg, _, err := loadConfig()
+	if validateConfigRequested() {
+		return runValidateConfig(ctx, loadedCfg, err)
+	}
 	if err != nil {
 		return fmt.Errorf("Failed to load config: %v", err)
 	}
@@ -65,9 +102,39 @@ func runMain(ctx context.Context) error {
 		}
 	}()
 
-	application, err := system.Init(ctx, wg, cfg.APISecret, cfg.BaseURL, cfg.CookieSecret,
-		cfg.CookieSecure, cfg.DBHost, cfg.DBName, cfg.DBPassword, cfg.DBPort,
-		cfg.DBUser)
+	// Serve the profiles early, so that startup problems can be diagnosed.
+	if cfg.Profile != "" {
+		addr, err := profileListenAddr(cfg.Profile)
+		if err != nil {
+			return err
+		}
+		if err = serveProfiles(ctx, wg, addr); err != nil {
+			return fmt.Errorf("could not serve profiles: %v", err)
+		}
+	}
+	if cfg.GoroutineWarn > 0 {
+		wg.Add(1)
+		go watchGoroutines(ctx, wg, cfg.GoroutineWarn)
+	}
+
+	models.SetArgon2Params(models.Argon2Params{
+		Time:    cfg.Argon2Time,
+		Memory:  cfg.Argon2Memory,
+		Threads: cfg.Argon2Threads,
+	})
+
+	apiKeys := models.NewAPIKeyring(cfg.APISecret, cfg.APISecretPrevious,
+		cfg.APITokenLifetime, cfg.LegacyAPITokenCutoff, cfg.MaxClockSkew)
+	log.Infof("Signing API tokens with key id %s", apiKeys.SigningKeyID())
+
+	application, err := system.Init(ctx, wg, apiKeys, cfg.BaseURL, cfg.CookieSecret,
+		cfg.Cookies, cfg.DBHost, cfg.DBName, cfg.DBPassword, cfg.DBPort,
+		cfg.DBUser, cfg.DBReplicaDSN)
+	if err != nil {
+		return err
+	}
+	err = models.CheckSchema(ctx, application.DbMap, cfg.DBName,
+		cfg.CreateMissingIndexes)
 	if err != nil {
 		return err
 	}
@@ -80,10 +147,17 @@ func runMain(ctx context.Context) error {
 	system.ReloadTemplatesSig(application)
 
 	// Supported API versions are advertised in the API stats result
-	APIVersionsSupported := []int{1, 2}
+	APIVersionsSupported := []int{1, 2, 3}
 
 	stakepooldConnMan, err := stakepooldclient.ConnectStakepooldGRPC(ctx, cfg.StakepooldHosts,
-		cfg.StakepooldCerts)
+		cfg.StakepooldCerts, keepalive.ClientParameters{
+			Time:                cfg.StakepooldKeepalive,
+			Timeout:             cfg.StakepooldKeepaliveTimeout,
+			PermitWithoutStream: cfg.StakepooldKeepalivePermitWithoutStream,
+		}, cfg.StakepooldMaxMessageSize, stakepooldclient.BreakerConfig{
+			Failures: cfg.StakepooldBreakerFailures,
+			Backoff:  cfg.StakepooldBreakerBackoff,
+		})
 	if err != nil {
 		return fmt.Errorf("failed to connect to stakepoold host: %v", err)
 	}
@@ -97,27 +171,79 @@ func runMain(ctx context.Context) error {
 		}
 	}
 
-	controllerCfg := controllers.Config{
-		AdminIPs:        cfg.AdminIPs,
-		AdminUserIDs:    cfg.AdminUserIDs,
-		APISecret:       cfg.APISecret,
-		BaseURL:         cfg.BaseURL,
-		ClosePool:       cfg.ClosePool,
-		ClosePoolMsg:    cfg.ClosePoolMsg,
-		PoolEmail:       cfg.PoolEmail,
-		PoolFees:        cfg.PoolFees,
-		PoolLink:        cfg.PoolLink,
-		RealIPHeader:    cfg.RealIPHeader,
-		MaxVotedTickets: cfg.MaxVotedTickets,
-		Description:     cfg.Description,
-		Designation:     cfg.Designation,
+	// Send critical alerts to the chat rooms of the operators when bots are
+	// configured.  The options were checked by loadConfig.
+	notifiers, _ := cfg.alertConfig().Notifiers()
+	hostname, _ := os.Hostname()
+	alerter := notify.NewAlerter("dcrstakepool "+hostname, cfg.AlertCooldown,
+		notifiers...)
 
+	controllerCfg := controllers.Config{
+		AdminIPs:           cfg.AdminIPs,
+		AdminUserIDs:       cfg.AdminUserIDs,
+		APIKeys:            apiKeys,
+		BaseURL:            cfg.BaseURL,
+		ClosePool:          cfg.ClosePool,
+		ClosePoolMsg:       cfg.ClosePoolMsg,
+		InviteOnly:         cfg.InviteOnly,
+		Maintenance:        cfg.Maintenance,
+		ReadOnly:           cfg.ReadOnly,
+		EmailTokenLifetime: cfg.EmailTokenLifetime,
+		EmailCooldown:      cfg.EmailCooldown,
+		EmailConfirmOld:    cfg.EmailConfirmOld,
+		ScriptExpiry:       cfg.ScriptExpiry,
+		ScriptExpiryGrace:  cfg.ScriptExpiryGrace,
+		PoolEmail:          cfg.PoolEmail,
+		PoolFees:           cfg.PoolFees,
+		FeeMode:            cfg.FeeMode,
+		FeePaymentDeadline: cfg.FeePaymentDeadline,
+		PoolLink:           cfg.PoolLink,
+		RealIPHeader:       cfg.RealIPHeader,
+		MaxVotedTickets:    cfg.MaxVotedTickets,
+		MaxUserLiveTickets: cfg.MaxUserLiveTickets,
+		Description:        cfg.Description,
+		Designation:        cfg.Designation,
+		RejectReusedAddrs:  cfg.RejectReusedAddrs,
+		HideAgendaStats:    cfg.HideAgendaStats,
+		Webhooks:           cfg.Webhooks,
+		FreezeVoteBits:     cfg.FreezeVoteBits,
+		ForcedChoices:      cfg.ForcedChoices,
+		TOSVersion:         cfg.TOSVersion,
+		TOSURL:             cfg.TOSURL,
+		TorMode:            cfg.TorMode,
+
+		MaintenanceAllowIPs:  cfg.MaintenanceAllowIPs,
+		MaintenancePage:      cfg.MaintenancePageHTML,
 		APIVersionsSupported: APIVersionsSupported,
 		FeeXpub:              coldWalletFeeKey,
 		StakepooldServers:    stakepooldConnMan,
 		EmailSender:          sender,
 		VotingXpub:           votingWalletVoteKey,
+		VotingAccount:        cfg.VotingAccount,
+		VotingBranch:         cfg.VotingBranch,
 		NetParams:            activeNetParams.Params,
+		SetDebugLevel:        parseAndSetDebugLevels,
+		LoginTokenLifetime:   cfg.LoginTokenLifetime,
+		RefreshTokenLifetime: cfg.RefreshTokenLifetime,
+		MaxClockSkew:         cfg.MaxClockSkew,
+		CaptchaWidth:         cfg.CaptchaWidth,
+		CaptchaHeight:        cfg.CaptchaHeight,
+		CaptchaDigits:        cfg.CaptchaDigits,
+		CaptchaAudio:         cfg.CaptchaAudio,
+		CaptchaAudioLang:     cfg.CaptchaAudioLang,
+		FeeWatch:             cfg.FeeWatch,
+		FeeWatchURL:          cfg.FeeWatchURL,
+		DownloadLinks:        signedurl.NewSigner(cfg.APISecret, cfg.APISecretPrevious),
+		DownloadLinkLifetime: cfg.DownloadLinkLifetime,
+		Alerts: controllers.AlertConfig{
+			MissedVotes:       cfg.AlertMissedVotes,
+			MissedVotesWindow: cfg.AlertMissedVotesWindow,
+			BackendDown:       cfg.AlertBackendDown,
+			DBErrors:          cfg.AlertDBErrors,
+		},
+		Alerter: alerter,
+
+		AdminResetLinkLifetime: cfg.AdminResetLinkLifetime,
 	}
 
 	controller, err := controllers.NewMainController(ctx, &controllerCfg)
@@ -125,18 +251,265 @@ func runMain(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize the main controller: %v", err)
 	}
 
+	// Migrate users from or to another voting service and exit.
+	if cfg.ExportUsers != "" {
+		return exportUsers(ctx, controller, application.DbMap, cfg.ExportUsers)
+	}
+	if cfg.ImportUsers != "" {
+		return importUsers(ctx, controller, application.DbMap, cfg.ImportUsers)
+	}
+
+	// Enter the read-only mode while the database refuses writes, e.g. during
+	// a MySQL failover.  The periodic jobs below which write to the database
+	// are skipped while the voting service is read-only.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(readOnlyCheckInterval):
+				controller.CheckDBWrites(ctx, application.DbMap)
+			}
+		}
+	}()
+
+	// Periodically delete accounts which were never verified.
+	if cfg.UnverifiedMaxAge > 0 {
+		deleteUnverified := func() {
+			if controller.ReadOnly() {
+				return
+			}
+			before := time.Now().Add(-cfg.UnverifiedMaxAge).Unix()
+			n, err := models.DeleteUnverifiedUsers(application.DbMap, before)
+			if err != nil {
+				log.Errorf("unable to delete unverified users: %v", err)
+				return
+			}
+			if n > 0 {
+				log.Infof("Deleted %d unverified users", n)
+			}
+		}
+		deleteUnverified()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Hour):
+					deleteUnverified()
+				}
+			}
+		}()
+	}
+
+	// Periodically prune the activity timelines of users.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			if !controller.ReadOnly() {
+				controller.PruneUserActivity(application.DbMap)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Hour):
+			}
+		}
+	}()
+
+	// Record the stake info hourly for the luck of the voting service.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			if !controller.ReadOnly() {
+				controller.RecordStakeInfoSnapshot(ctx, application.DbMap)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Hour):
+			}
+		}
+	}()
+
+	// Periodically disable the scripts which no tickets were bought with.
+	if cfg.ScriptExpiry > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if !controller.ReadOnly() {
+					controller.ExpireStaleScripts(ctx, application.DbMap)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(6 * time.Hour):
+				}
+			}
+		}()
+	}
+
+	// Queue the emails which could not be sent and periodically retry them.
+	if cfg.SMTPHost != "" {
+		controller.QueueFailedEmails(application.DbMap)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Minute):
+					if !controller.ReadOnly() {
+						controller.RetryQueuedEmails(application.DbMap)
+					}
+				}
+			}
+		}()
+	}
+
+	// Send critical alerts about the stakepoold instances to the operators.
+	if alerter != nil {
+		log.Infof("Sending critical alerts with %d bots", len(notifiers))
+		wg.Add(1)
+		go monitorBackends(ctx, wg, controller.Cfg.StakepooldServers, alerter)
+	}
+
+	// Evaluate the alerts shown on the status page, which are also sent to
+	// the operators.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(alertCheckInterval):
+				controller.EvaluateAlerts(ctx, application.DbMap)
+			}
+		}
+	}()
+
+	// Track the fee transactions of tickets when fees are deferred.
+	if cfg.FeeMode == models.FeeModeDeferred {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(ticketFeeCheckInterval):
+					if !controller.ReadOnly() {
+						controller.UpdateTicketFees(ctx, application.DbMap)
+					}
+				}
+			}
+		}()
+	}
+
+	// Reconcile the fees of tickets against the payments to the fee
+	// addresses.
+	if cfg.FeeWatch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				controller.WatchFees(ctx, application.DbMap)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(cfg.FeeWatchInterval):
+				}
+			}
+		}()
+	}
+
+	// Notify the users who registered a webhook of changes to their tickets.
+	if cfg.Webhooks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(webhookCheckInterval):
+					if !controller.ReadOnly() {
+						controller.CheckWebhookTickets(ctx, application.DbMap)
+						controller.SendWebhookEvents(ctx, application.DbMap)
+					}
+				}
+			}
+		}()
+	}
+
+	// Poll the best block so that the cached stake info is refreshed after a
+	// new block rather than only when it expires.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(bestBlockPollInterval):
+				_, height, err := controller.Cfg.StakepooldServers.GetBestBlock(ctx)
+				if err != nil {
+					log.Debugf("unable to get best block: %v", err)
+					continue
+				}
+				controller.Cfg.StakepooldServers.InvalidateStakeInfo(height)
+			}
+		}
+	}()
+
 	// Check that dcrstakepool config and all stakepoold configs
 	// have the same value set for `coldwalletextpub`.
 	if err = controller.Cfg.StakepooldServers.CrossCheckColdWalletExtPubs(ctx, cfg.ColdWalletExtPub); err != nil {
 		return err
 	}
 
-	// reset votebits if Vote Version changed or stored VoteBits are invalid
-	_, err = controller.CheckAndResetUserVoteBits(application.DbMap)
-	if err != nil {
-		return fmt.Errorf("failed to check and reset user vote bits: %v", err)
+	// Check that votingwalletextpub is the extended public key of the voting
+	// account in the wallets of all stakepoold instances, so that they own
+	// the ticket addresses derived for users.
+	if err = controller.Cfg.StakepooldServers.CrossCheckVotingAccount(ctx,
+		cfg.VotingAccount, cfg.VotingWalletExtPub); err != nil {
+		return err
 	}
 
+	// Check that all stakepoold instances are configured for the same
+	// network and compatible pool fees, and keep checking in case one of
+	// them is restarted with another config.  Write operations are refused
+	// while a mismatch is found.
+	if err = controller.Cfg.StakepooldServers.CrossCheckChainParams(ctx,
+		activeNetParams.Params, cfg.PoolFees, cfg.FeeMode); err != nil {
+		return fmt.Errorf("stakepoold chain params check failed: %v", err)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(chainParamsCheckInterval):
+				err := controller.Cfg.StakepooldServers.CrossCheckChainParams(ctx,
+					activeNetParams.Params, cfg.PoolFees, cfg.FeeMode)
+				if err != nil {
+					log.Errorf("stakepoold chain params check failed: %v", err)
+				}
+			}
+		}
+	}()
+
 	err = controller.StakepooldUpdateUsers(ctx, application.DbMap)
 	if err != nil {
 		return fmt.Errorf("StakepooldUpdateUsers failed: %v", err)
@@ -145,6 +518,41 @@ func runMain(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("StakepooldUpdateTickets failed: %v", err)
 	}
+
+	// Update stakepoold with the changes of users which it was not updated
+	// with when they were made, e.g. since it was unreachable or the update
+	// was interrupted by a restart.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Minute):
+				if controller.ReadOnly() {
+					continue
+				}
+				err := controller.SendStakepooldUpdates(ctx, application.DbMap)
+				if err != nil {
+					log.Warnf("unable to update users on stakepoold: %v", err)
+				}
+			}
+		}
+	}()
+
+	// Migrate the VoteBits of users to the current vote version, or reset
+	// them when they are invalid, in the background, since it takes minutes
+	// on large pools after a new vote version activates.
+	if !controller.ReadOnly() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := controller.MigrateUserVoteBits(ctx, application.DbMap); err != nil {
+				log.Errorf("unable to migrate user vote bits: %v", err)
+			}
+		}()
+	}
 	// Log the reported count of ignored/added/live tickets from each stakepoold
 	_, err = controller.Cfg.StakepooldServers.GetIgnoredLowFeeTickets(ctx)
 	if err != nil {
@@ -174,13 +582,29 @@ func runMain(ctx context.Context) error {
 	app.Use(middleware.Recoverer)
 	app.Use(application.ApplyDbMap)
 
+	// Routes receiving bulk data, which accept bodies of up to
+	// maxbulkbodybytes rather than maxbodybytes.
+	bulkBodyRoutes := map[string]int64{
+		"/adminhistory": cfg.MaxBulkBodyBytes,
+		"/admintickets": cfg.MaxBulkBodyBytes,
+		"/votingprefs":  cfg.MaxBulkBodyBytes,
+	}
+	for _, v := range APIVersionsSupported {
+		bulkBodyRoutes[fmt.Sprintf("/api/v%d/votingprefs", v)] = cfg.MaxBulkBodyBytes
+	}
+
 	// API routes
 	api := web.New()
 
 	api.Use(application.ApplyAPI)
+	api.Use(system.LimitRequestBody(cfg.MaxBodyBytes, bulkBodyRoutes))
+	api.Use(controller.MaintenanceGate) // must be after ApplyAPI
+	api.Use(controller.ReadOnlyGate)
 
 	api.Handle("/api/v1/:command", application.APIHandler(controller.API))
 	api.Handle("/api/v2/:command", application.APIHandler(controller.API))
+	api.Handle("/api/v3/:command", application.APIHandler(controller.API))
+	api.Get("/api/vspinfo", controller.APIVSPInfo)
 	api.Handle("/api/*", gojify(system.APIInvalidHandler))
 
 	// HTML routes
@@ -191,11 +615,16 @@ func runMain(ctx context.Context) error {
 	// Execute various middleware functions.  The order is very important
 	// as each function establishes part of the application environment/context
 	// that the next function will assume has been setup successfully.
+	html.Use(system.LimitRequestBody(cfg.MaxBodyBytes, bulkBodyRoutes))
 	html.Use(application.ApplyTemplates)
 	html.Use(application.ApplySessions)
-	html.Use(application.ApplyCaptcha) // must be after ApplySessions
-	html.Use(application.ApplyAuth)    // must be after ApplySessions
-	html.Use(csrf.Protect([]byte(cfg.APISecret), csrf.Secure(cfg.CookieSecure)))
+	html.Use(application.ApplyAuth)      // must be after ApplySessions
+	html.Use(controller.MaintenanceGate) // must be after ApplySessions
+	html.Use(controller.ReadOnlyGate)
+	html.Use(csrf.Protect([]byte(cfg.APISecret), cfg.Cookies.CSRFOptions()...))
+	html.Use(controller.RequireTOS) // must be after ApplyAuth
+	html.Use(controller.ShowVotingFreeze)
+	html.Use(controller.ShowWalletRescan)
 
 	// Setup static files
 	static.Get("/assets/*", http.StripPrefix("/assets/",
@@ -209,16 +638,53 @@ func runMain(ctx context.Context) error {
 	html.Post("/admintickets", application.Route(controller.AdminTicketsPost))
 	// Admin status page
 	html.Get("/status", application.Route(controller.AdminStatus))
+	html.Post("/status", application.Route(controller.AdminStatusPost))
+	// Admin users page
+	html.Get("/adminusers", application.Route(controller.AdminUsers))
+	// Admin user page with the notes of the support team
+	html.Get("/adminuser", application.Route(controller.AdminUser))
+	html.Post("/adminuser", application.Route(controller.AdminUserPost))
+	// Admin ticket distribution page
+	html.Get("/admindistribution", application.Route(controller.AdminDistribution))
+	// Admin invite codes page
+	html.Get("/admininvites", application.Route(controller.AdminInvites))
+	html.Post("/admininvites", application.Route(controller.AdminInvitesPost))
+	// Admin pre-migration history import page
+	html.Get("/adminhistory", application.Route(controller.AdminHistory))
+	html.Post("/adminhistory", application.Route(controller.AdminHistoryPost))
+	// Admin maintenance notices page
+	html.Get("/adminmessages", application.Route(controller.AdminMessages))
+	html.Post("/adminmessages", application.Route(controller.AdminMessagesPost))
+	// Admin feature flags page
+	html.Get("/adminfeatures", application.Route(controller.AdminFeatures))
+	html.Post("/adminfeatures", application.Route(controller.AdminFeaturesPost))
+	// Admin failed emails page
+	html.Get("/adminemails", application.Route(controller.AdminEmails))
+	html.Post("/adminemails", application.Route(controller.AdminEmailsPost))
+	// Admin voting preferences freeze page
+	html.Get("/adminvoting", application.Route(controller.AdminVoting))
+	html.Post("/adminvoting", application.Route(controller.AdminVotingPost))
+	// Admin audit page
+	html.Get("/adminaudit", application.Route(controller.AdminAudit))
+	// Admin missed tickets page
+	html.Get("/adminmissed", application.Route(controller.AdminMissed))
+	// Admin fee report page
+	html.Get("/adminfees", application.Route(controller.AdminFees))
 
 	// Address form
 	html.Get("/address", application.Route(controller.Address))
 	html.Post("/address", application.Route(controller.AddressPost))
+	html.Get("/address/status", application.Route(controller.AddressStatus))
+	html.Post("/address/retry", application.Route(controller.AddressRetryPost))
+	html.Post("/address/reactivate", application.Route(controller.AddressReactivatePost))
+	html.Post("/address/replace", application.Route(controller.AddressReplacePost))
 
 	// Email change/update confirmation
 	html.Get("/emailupdate", application.Route(controller.EmailUpdate))
 
 	// Email verification
 	html.Get("/emailverify", application.Route(controller.EmailVerify))
+	html.Post("/emailverify/resend", application.Route(controller.EmailVerifyResendPost))
 
 	// Error page
 	html.Get("/error", application.Route(controller.Error))
@@ -239,6 +705,15 @@ func runMain(ctx context.Context) error {
 	html.Get("/login", application.Route(controller.Login))
 	html.Post("/login", application.Route(controller.LoginPost))
 
+	// Sign in with a Decred address
+	html.Get("/signin", application.Route(controller.SignIn))
+	html.Post("/signin", application.Route(controller.SignInPost))
+	html.Post("/signin/verify", application.Route(controller.SignInVerifyPost))
+
+	// Terms of service acceptance
+	html.Get("/tos", application.Route(controller.TOS))
+	html.Post("/tos", application.Route(controller.TOSPost))
+
 	// Register routes
 	html.Get("/register", application.Route(controller.Register))
 	html.Post("/register", application.Route(controller.RegisterPost))
@@ -252,15 +727,26 @@ func runMain(ctx context.Context) error {
 
 	// Tickets
 	html.Get("/tickets", application.Route(controller.Tickets))
+	html.Post("/tickets/fee", application.Route(controller.TicketFeePost))
+
+	// Messages
+	html.Get("/messages", application.Route(controller.Messages))
+	html.Post("/messages", application.Route(controller.MessagesPost))
 
 	// Voting routes
 	html.Get("/voting", application.Route(controller.Voting))
 	html.Post("/voting", application.Route(controller.VotingPost))
+	html.Post("/votingprefs", application.Route(controller.VotingPrefsPost))
 
 	// KTHXBYE
 	html.Get("/logout", application.Route(controller.Logout))
 
 	app.Handle("/api/*", api)
+	// Status badges are embedded in other sites, so they are served without
+	// sessions or CSRF protection.
+	app.Get("/badge/:token", controller.Badge)
+	// Download links of admin exports are signed instead.
+	app.Get("/download/scripts.csv", controller.ScriptsDownload)
 	app.Handle("/*", html)
 
 	parent := web.New()
@@ -270,12 +756,61 @@ func runMain(ctx context.Context) error {
 
 	app.Compile()
 
-	server := &http.Server{Handler: parent}
+	server := &http.Server{
+		Handler:        parent,
+		ReadTimeout:    cfg.HTTPReadTimeout,
+		WriteTimeout:   cfg.HTTPWriteTimeout,
+		IdleTimeout:    cfg.HTTPIdleTimeout,
+		MaxHeaderBytes: cfg.HTTPMaxHeaderBytes,
+	}
+
+	tlsCfg, redirectHandler, err := serverTLSConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS configuration: %v", err)
+	}
 
 	listener, err := listenTo(cfg.Listen)
 	if err != nil {
 		return fmt.Errorf("could not bind %v", err)
 	}
+	if tlsCfg != nil {
+		server.TLSConfig = tlsCfg
+		listener = tls.NewListener(listener, tlsCfg)
+	}
+
+	// Redirect plain HTTP requests to the TLS listener.
+	if tlsCfg != nil && cfg.HTTPRedirectListen != "" {
+		redirectListener, err := listenTo(cfg.HTTPRedirectListen)
+		if err != nil {
+			return fmt.Errorf("could not bind %v", err)
+		}
+		redirectServer := &http.Server{
+			Handler:        redirectHandler,
+			ReadTimeout:    cfg.HTTPReadTimeout,
+			WriteTimeout:   cfg.HTTPWriteTimeout,
+			IdleTimeout:    cfg.HTTPIdleTimeout,
+			MaxHeaderBytes: cfg.HTTPMaxHeaderBytes,
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-ctx.Done()
+			if err := redirectServer.Shutdown(context.Background()); err != nil {
+				err = fmt.Errorf("HTTP redirect server Shutdown: %v", err)
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}()
+
+		log.Infof("redirecting HTTP requests from %v to https",
+			redirectListener.Addr())
+		go func() {
+			err := redirectServer.Serve(redirectListener)
+			if !errors.Is(err, http.ErrServerClosed) {
+				log.Errorf("HTTP redirect server error: %v", err)
+			}
+		}()
+	}
 
 	// Cleanly shutdown server on interrupt signal.
 	wg.Add(1)
@@ -292,7 +827,11 @@ func runMain(ctx context.Context) error {
 		}
 	}()
 
-	log.Infof("listening on %v", listener.Addr())
+	if tlsCfg != nil {
+		log.Infof("listening on %v using TLS", listener.Addr())
+	} else {
+		log.Infof("listening on %v", listener.Addr())
+	}
 
 	if err = server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("Serve error: %s", err.Error())
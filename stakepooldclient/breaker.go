@@ -0,0 +1,277 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// breakerMaxBackoff caps the time an open circuit breaker rejects RPCs
+	// for, which doubles every time a probe fails.
+	breakerMaxBackoff = 10 * time.Minute
+
+	// breakerEventHistory is the number of state changes of each circuit
+	// breaker kept for the status page.
+	breakerEventHistory = 10
+)
+
+// breakerVars publishes the state of the circuit breaker of each stakepoold
+// connection under /debug/vars of the profile option.
+var breakerVars = expvar.NewMap("stakepooldbreakers")
+
+// BreakerConfig configures the circuit breaker of each stakepoold connection.
+type BreakerConfig struct {
+	// Failures is the number of consecutive RPCs failing with an
+	// unreachable or unresponsive instance which open the breaker.  0
+	// disables the breakers.
+	Failures int
+	// Backoff is how long an open breaker rejects RPCs before letting a
+	// single probe through.
+	Backoff time.Duration
+}
+
+// breakerState is the state of a circuit breaker.
+type breakerState int
+
+const (
+	// breakerClosed lets every RPC through.
+	breakerClosed breakerState = iota
+	// breakerOpen rejects every RPC until its backoff elapsed.
+	breakerOpen
+	// breakerHalfOpen lets a single probe through, whose result closes
+	// or reopens the breaker.
+	breakerHalfOpen
+)
+
+// String returns the name of s shown on the status page.
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// circuitBreaker stops sending RPCs to a stakepoold instance after
+// consecutive failures, so that reads are served by the other instances and
+// writes are queued for retry without waiting on an instance which is down.
+// After a backoff, a single RPC is let through to probe whether the instance
+// recovered.
+type circuitBreaker struct {
+	host string
+	cfg  BreakerConfig
+	vars *expvar.Map
+
+	mtx       sync.Mutex
+	state     breakerState
+	failures  int
+	backoff   time.Duration
+	openUntil time.Time
+	probing   bool
+	events    []manager.BreakerEvent
+}
+
+// newCircuitBreaker returns a closed circuit breaker of the connection to
+// host, or nil when cfg disables the breakers.
+func newCircuitBreaker(host string, cfg BreakerConfig) *circuitBreaker {
+	if cfg.Failures <= 0 {
+		return nil
+	}
+	b := &circuitBreaker{
+		host:    host,
+		cfg:     cfg,
+		vars:    new(expvar.Map).Init(),
+		backoff: cfg.Backoff,
+	}
+	breakerVars.Set(host, b.vars)
+	b.publish()
+	return b
+}
+
+// hostFailure returns whether err, the result of an RPC sent with ctx, shows
+// that the instance is unreachable or unresponsive, and whether the result
+// tells anything about the instance at all, which it does not when the caller
+// gave up on the RPC.  Errors returned by stakepoold itself, such as invalid
+// arguments or wallet errors, show that the instance is up.
+func hostFailure(ctx context.Context, err error) (failed, known bool) {
+	if err == nil {
+		return false, true
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return false, false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true, true
+	}
+	return false, true
+}
+
+// allow returns whether an RPC is sent at now, and whether it is the probe of
+// a half-open breaker.  An open breaker whose backoff elapsed becomes
+// half-open.
+func (b *circuitBreaker) allow(now time.Time) (ok, probe bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if now.Before(b.openUntil) {
+			return false, false
+		}
+		b.transition(breakerHalfOpen, now, "backoff elapsed, probing")
+		fallthrough
+	case breakerHalfOpen:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	}
+	return true, false
+}
+
+// record updates the breaker with the result of an RPC let through by allow.
+func (b *circuitBreaker) record(ctx context.Context, probe bool, err error, now time.Time) {
+	failed, known := hostFailure(ctx, err)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if !known {
+		return
+	}
+	if !failed {
+		b.failures = 0
+		if b.state != breakerClosed {
+			b.backoff = b.cfg.Backoff
+			b.transition(breakerClosed, now, "RPC succeeded")
+		}
+		b.publish()
+		return
+	}
+
+	b.failures++
+	switch {
+	case b.state == breakerHalfOpen && probe:
+		b.backoff *= 2
+		if b.backoff > breakerMaxBackoff {
+			b.backoff = breakerMaxBackoff
+		}
+		b.open(now, fmt.Sprintf("probe failed: %v", err))
+	case b.state == breakerClosed && b.failures >= b.cfg.Failures:
+		b.vars.Add("trips", 1)
+		b.open(now, fmt.Sprintf("%d consecutive failures, last: %v",
+			b.failures, err))
+	default:
+		b.publish()
+	}
+}
+
+// open opens the breaker for its current backoff.  The mutex must be held.
+func (b *circuitBreaker) open(now time.Time, reason string) {
+	b.openUntil = now.Add(b.backoff)
+	b.transition(breakerOpen, now, fmt.Sprintf("%s; rejecting RPCs for %v",
+		reason, b.backoff))
+}
+
+// transition changes the state of the breaker, recording and logging the
+// change.  The mutex must be held.
+func (b *circuitBreaker) transition(state breakerState, now time.Time, reason string) {
+	b.state = state
+	b.events = append(b.events, manager.BreakerEvent{
+		Time:   now,
+		State:  state.String(),
+		Reason: reason,
+	})
+	if len(b.events) > breakerEventHistory {
+		b.events = b.events[len(b.events)-breakerEventHistory:]
+	}
+	b.publish()
+
+	switch state {
+	case breakerOpen:
+		log.Warnf("Circuit breaker of stakepoold %s opened: %s", b.host,
+			reason)
+	default:
+		log.Infof("Circuit breaker of stakepoold %s is %v: %s", b.host,
+			state, reason)
+	}
+}
+
+// publish sets the expvar variables of the breaker.  The mutex must be held.
+func (b *circuitBreaker) publish() {
+	state := new(expvar.String)
+	state.Set(b.state.String())
+	b.vars.Set("state", state)
+	failures := new(expvar.Int)
+	failures.Set(int64(b.failures))
+	b.vars.Set("failures", failures)
+}
+
+// isOpen returns whether the breaker rejects RPCs at now.  A nil breaker is
+// never open.
+func (b *circuitBreaker) isOpen(now time.Time) bool {
+	if b == nil {
+		return false
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.state == breakerOpen && now.Before(b.openUntil)
+}
+
+// snapshot returns the state of the breaker and its recent state changes,
+// most recent first.  A nil breaker is reported as disabled.
+func (b *circuitBreaker) snapshot() (string, []manager.BreakerEvent) {
+	if b == nil {
+		return "disabled", nil
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	events := make([]manager.BreakerEvent, len(b.events))
+	for i, e := range b.events {
+		events[len(events)-1-i] = e
+	}
+	return b.state.String(), events
+}
+
+// intercept is a gRPC unary client interceptor applying the breaker.  RPCs
+// rejected by an open breaker fail with codes.Unavailable without being sent.
+func (b *circuitBreaker) intercept(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ok, probe := b.allow(time.Now())
+	if !ok {
+		return status.Errorf(codes.Unavailable, "circuit breaker of "+
+			"stakepoold %s is open", b.host)
+	}
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	b.record(ctx, probe, err, time.Now())
+	return err
+}
+
+// breaker returns the circuit breaker of connection i, or nil when breakers
+// are disabled.
+func (s *stakepooldManager) breaker(i int) *circuitBreaker {
+	if i >= len(s.breakers) {
+		return nil
+	}
+	return s.breakers[i]
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	b := newCircuitBreaker("breakertest:9113", BreakerConfig{
+		Failures: 3,
+		Backoff:  time.Minute,
+	})
+	now := time.Unix(1600000000, 0)
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	send := func(err error) bool {
+		t.Helper()
+		ok, probe := b.allow(now)
+		if ok {
+			b.record(ctx, probe, err, now)
+		}
+		return ok
+	}
+	wantState := func(want breakerState) {
+		t.Helper()
+		if state, _ := b.snapshot(); state != want.String() {
+			t.Fatalf("breaker is %s, want %v", state, want)
+		}
+	}
+
+	// Errors of stakepoold itself and successes reset the failures.
+	for _, err := range []error{unavailable, unavailable,
+		status.Error(codes.InvalidArgument, "invalid"), unavailable,
+		unavailable, nil, unavailable, unavailable} {
+		if !send(err) {
+			t.Fatal("closed breaker rejected an RPC")
+		}
+	}
+	wantState(breakerClosed)
+
+	// RPCs the caller cancelled do not count.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	ok, probe := b.allow(now)
+	if !ok {
+		t.Fatal("closed breaker rejected an RPC")
+	}
+	b.record(cancelled, probe, status.Error(codes.Canceled, "canceled"), now)
+	wantState(breakerClosed)
+
+	// The third consecutive failure opens the breaker, which rejects RPCs
+	// until the backoff elapsed.
+	send(status.Error(codes.DeadlineExceeded, "deadline exceeded"))
+	wantState(breakerOpen)
+	if send(nil) {
+		t.Fatal("open breaker let an RPC through")
+	}
+	if !b.isOpen(now) {
+		t.Fatal("open breaker is not reported as open")
+	}
+
+	// A single probe is let through once the backoff elapsed, and its
+	// failure reopens the breaker for twice the backoff.
+	now = now.Add(time.Minute)
+	ok, probe = b.allow(now)
+	if !ok || !probe {
+		t.Fatalf("half-open breaker allowed %v, probe %v", ok, probe)
+	}
+	wantState(breakerHalfOpen)
+	if ok, _ := b.allow(now); ok {
+		t.Fatal("half-open breaker let a second RPC through")
+	}
+	b.record(ctx, probe, unavailable, now)
+	wantState(breakerOpen)
+	if !b.isOpen(now.Add(time.Minute)) || b.isOpen(now.Add(2*time.Minute)) {
+		t.Fatal("failed probe did not double the backoff")
+	}
+
+	// A successful probe closes the breaker and resets the backoff.
+	now = now.Add(2 * time.Minute)
+	if !send(nil) {
+		t.Fatal("half-open breaker rejected the probe")
+	}
+	wantState(breakerClosed)
+	for i := 0; i < 3; i++ {
+		send(unavailable)
+	}
+	if !b.isOpen(now.Add(time.Minute-time.Second)) || b.isOpen(now.Add(time.Minute)) {
+		t.Fatal("successful probe did not reset the backoff")
+	}
+
+	state, events := b.snapshot()
+	wantStates := []string{"open", "closed", "half-open", "open",
+		"half-open", "open"}
+	if state != "open" || len(events) != len(wantStates) {
+		t.Fatalf("breaker is %s with %d events, want open with %d", state,
+			len(events), len(wantStates))
+	}
+	for i, e := range events {
+		if e.State != wantStates[i] || e.Reason == "" {
+			t.Errorf("event %d is %s (%s), want %s", i, e.State, e.Reason,
+				wantStates[i])
+		}
+	}
+	if trips := b.vars.Get("trips").String(); trips != "2" {
+		t.Errorf("published %s trips, want 2", trips)
+	}
+	if published := b.vars.Get("state").String(); published != `"open"` {
+		t.Errorf("published state %s, want open", published)
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newCircuitBreaker("breakertest:9114", BreakerConfig{Backoff: time.Minute})
+	if b != nil {
+		t.Fatal("breaker created with no failure threshold")
+	}
+	if b.isOpen(time.Now()) {
+		t.Fatal("disabled breaker is open")
+	}
+	if state, events := b.snapshot(); state != "disabled" || events != nil {
+		t.Fatalf("disabled breaker is %s with %d events", state, len(events))
+	}
+	s := &stakepooldManager{}
+	if s.breaker(0) != nil {
+		t.Fatal("manager without breakers returned a breaker")
+	}
+}
+
+func TestCircuitBreakerIntercept(t *testing.T) {
+	b := newCircuitBreaker("breakertest:9115", BreakerConfig{
+		Failures: 1,
+		Backoff:  time.Hour,
+	})
+	var sent int
+	invoker := func(context.Context, string, interface{}, interface{},
+		*grpc.ClientConn, ...grpc.CallOption) error {
+		sent++
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		err := b.intercept(ctx, "/test", nil, nil, nil, invoker)
+		if status.Code(err) != codes.Unavailable {
+			t.Fatalf("RPC %d failed with %v, want Unavailable", i, err)
+		}
+	}
+	if sent != 1 {
+		t.Fatalf("sent %d RPCs through an open breaker, want 1", sent)
+	}
+}
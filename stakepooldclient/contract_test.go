@@ -0,0 +1,56 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager/managertest"
+)
+
+// TestLiveContract runs the manager contract tests against running simnet
+// stakepoold instances.  It is skipped unless STAKEPOOLD_CONTRACT_HOSTS and
+// STAKEPOOLD_CONTRACT_CERTS are set to comma separated lists of gRPC hosts
+// and their certificates.  STAKEPOOLD_CONTRACT_PUBKEYADDRS may list the pubkey
+// addresses used to test CreateMultisig and STAKEPOOLD_CONTRACT_ADDRESS the
+// address used to test ValidateAddress.
+func TestLiveContract(t *testing.T) {
+	hosts := os.Getenv("STAKEPOOLD_CONTRACT_HOSTS")
+	certs := os.Getenv("STAKEPOOLD_CONTRACT_CERTS")
+	if hosts == "" || certs == "" {
+		t.Skip("STAKEPOOLD_CONTRACT_HOSTS and STAKEPOOLD_CONTRACT_CERTS not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	m, err := ConnectStakepooldGRPC(ctx, strings.Split(hosts, ","),
+		strings.Split(certs, ","), keepalive.ClientParameters{}, 64<<20,
+		BreakerConfig{})
+	if err != nil {
+		t.Fatalf("unable to connect to stakepoold: %v", err)
+	}
+
+	var f managertest.Fixture
+	if addrs := os.Getenv("STAKEPOOLD_CONTRACT_PUBKEYADDRS"); addrs != "" {
+		f.MultisigAddresses = strings.Split(addrs, ",")
+	}
+	if addr := os.Getenv("STAKEPOOLD_CONTRACT_ADDRESS"); addr != "" {
+		f.Address, err = dcrutil.DecodeAddress(addr, chaincfg.SimNetParams())
+		if err != nil {
+			t.Fatalf("invalid STAKEPOOLD_CONTRACT_ADDRESS: %v", err)
+		}
+	}
+
+	managertest.Run(ctx, t, m, f)
+}
@@ -12,10 +12,15 @@ import "github.com/decred/slog"
 // requests it.
 var log = slog.Disabled
 
+// rpcLog is the logger used to trace every RPC sent to the stakepoold
+// instances at the debug level.  Like log, it is disabled by default.
+var rpcLog = slog.Disabled
+
 // DisableLog disables all library log output.  Logging output is disabled
 // by default until either UseLogger or SetLogWriter are called.
 func DisableLog() {
 	log = slog.Disabled
+	rpcLog = slog.Disabled
 }
 
 // UseLogger uses a specified Logger to output package logging info.
@@ -24,3 +29,10 @@ func DisableLog() {
 func UseLogger(logger slog.Logger) {
 	log = logger
 }
+
+// UseRPCLogger uses a specified Logger to trace the RPCs sent to the
+// stakepoold instances.  Tracing is only done while its level is debug or
+// trace, so it can be enabled at runtime by changing the level.
+func UseRPCLogger(logger slog.Logger) {
+	rpcLog = logger
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package manager defines the interface through which dcrstakepool talks to
+// its stakepoold back-ends, along with a mock implementation for tests.
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/models"
+)
+
+// ErrTxNotFound is returned by GetTransactionConfirmations when no stakepoold
+// instance knows the transaction.
+var ErrTxNotFound = errors.New("transaction not found")
+
+// ErrWalletRescanning is the failure of a write which was queued without being
+// sent to a stakepoold instance because its wallet is rescanning.  It is
+// applied once the rescan is done.
+var ErrWalletRescanning = errors.New("wallet is rescanning")
+
+// PartialWriteError is returned by the writes applied to every stakepoold
+// instance, such as ImportNewScript and SetUserVotingPrefs, when they failed on
+// some instances.  The write is queued and retried in the background on the
+// failed instances until it succeeds or is superseded.
+type PartialWriteError struct {
+	Op      string
+	Applied int              // number of instances the write succeeded on
+	Failed  map[string]error // [host]
+}
+
+// Error implements the error interface.
+func (e *PartialWriteError) Error() string {
+	hosts := make([]string, 0, len(e.Failed))
+	for host := range e.Failed {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	msgs := make([]string, len(hosts))
+	for i, host := range hosts {
+		msgs[i] = fmt.Sprintf("%s: %v", host, e.Failed[host])
+	}
+	return fmt.Sprintf("%s failed on %d of %d stakepoold instances, "+
+		"queued for retry (%s)", e.Op, len(hosts), len(hosts)+e.Applied,
+		strings.Join(msgs, "; "))
+}
+
+// Manager coordinates the communication between dcrstakepool and one or more
+// stakepoold instances.  It is satisfied by the gRPC client returned from
+// stakepooldclient.ConnectStakepooldGRPC and by Mock.
+type Manager interface {
+	GetAddedLowFeeTickets(context.Context) (map[chainhash.Hash]string, error)
+	GetIgnoredLowFeeTickets(context.Context) (map[chainhash.Hash]string, error)
+	GetIgnoredLowFeeTicketsPage(ctx context.Context, offset, limit int) (map[chainhash.Hash]string, int, error)
+	GetLiveTickets(context.Context) (map[chainhash.Hash]string, error)
+	GetLiveTicketCounts(context.Context) (map[string]uint32, error)
+	GetTicketAmounts(ctx context.Context, hashes []chainhash.Hash) (map[chainhash.Hash]*pb.TicketAmounts, error)
+	LookupTickets(ctx context.Context, tickets []chainhash.Hash, msas []string) ([]*pb.TicketLookup, error)
+	SetAddedLowFeeTickets(context.Context, []models.LowFeeTicket) error
+	GetLowFeeReview(context.Context) (bool, map[chainhash.Hash]string, error)
+	ResumeLowFeeClassification(context.Context) error
+	PromoteStandby(ctx context.Context, host string) (bool, error)
+	CreateMultisig(context.Context, []string) (*pb.CreateMultisigResponse, error)
+	SyncAll(ctx context.Context, multiSigScripts []models.User, account string, branch uint32, maxUsers int64) error
+	StakePoolUserInfo(ctx context.Context, multiSigAddress string) (*pb.StakePoolUserInfoResponse, error)
+	BatchStakePoolUserInfo(ctx context.Context, multiSigAddresses []string) (map[string]*pb.StakePoolUserInfoResponse, error)
+	SetUserVotingPrefs(ctx context.Context, dbUsers map[int64]*models.User) error
+	WalletInfo(context.Context) ([]*pb.WalletInfoResponse, error)
+	ValidateAddress(ctx context.Context, addr dcrutil.Address) (*pb.ValidateAddressResponse, error)
+	ExistsAddress(ctx context.Context, addr dcrutil.Address) (bool, error)
+	VerifyMessage(ctx context.Context, addr dcrutil.Address, message, signature string) (bool, error)
+	ImportNewScript(ctx context.Context, script []byte) (heightImported int64, err error)
+	ImportNewScriptOn(ctx context.Context, host string, script []byte) (heightImported int64, err error)
+	ImportHistoricScript(ctx context.Context, script []byte, rescanHeight int64) error
+	Hosts() []string
+	RescanningHosts() []string
+	BackendStatus(context.Context) []BackendStatus
+	SyncStatus(ctx context.Context, multiSigAddresses []string) []SyncStatus
+	GetStakeInfo(context.Context) (*pb.GetStakeInfoResponse, error)
+	InvalidateStakeInfo(bestHeight int64)
+	GetBestBlock(context.Context) (*chainhash.Hash, int64, error)
+	SendRawTransaction(ctx context.Context, tx []byte) (*chainhash.Hash, error)
+	GetTransactionConfirmations(ctx context.Context, hash *chainhash.Hash) (int64, error)
+	CrossCheckColdWalletExtPubs(ctx context.Context, dcrstakepoolColdWalletExtPub string) error
+	CrossCheckVotingAccount(ctx context.Context, account string, votingWalletExtPub string) error
+	CrossCheckChainParams(ctx context.Context, params *chaincfg.Params, poolFees float64, feeMode string) error
+}
+
+// BackendStatus provides a summary of a single back-end server
+type BackendStatus struct {
+	Host      string
+	RPCStatus string
+	// ReadLatency and ReadErrorRate are the rolling averages of the read
+	// RPCs sent to the instance.
+	ReadLatency   time.Duration
+	ReadErrorRate float64
+	// PendingWrites is the number of writes which failed on the instance and
+	// are queued for retry.
+	PendingWrites int
+	// MaxMessageSize is the maximum size in bytes of the requests sent to
+	// the instance, negotiated when connecting.
+	MaxMessageSize int
+	// Breaker is the state of the circuit breaker of the connection to the
+	// instance, and BreakerEvents its recent state changes, most recent
+	// first.
+	Breaker       string
+	BreakerEvents []BreakerEvent
+	*WalletStatus
+}
+
+// BreakerEvent is a state change of the circuit breaker of the connection to
+// a back-end server.
+type BreakerEvent struct {
+	Time   time.Time
+	State  string
+	Reason string
+}
+
+// SyncStatus compares the redeem scripts and tickets of a single back-end
+// server with the database and the other back-end servers, so that a wallet
+// which is out of sync is noticed before it fails to vote.
+type SyncStatus struct {
+	Host string
+	// Scripts is the number of redeem scripts imported by the wallet, and
+	// MissingScripts the number of scripts of users in the database which
+	// it did not import.
+	Scripts        int
+	MissingScripts int
+	// Tickets is the number of tickets of the wallet, including immature
+	// ones, and MissingTickets the number of tickets of the other back-end
+	// servers which it does not have.
+	Tickets        int
+	MissingTickets int
+	// Err is set when the back-end server could not be queried.
+	Err error
+}
+
+// Diverged returns whether the back-end server is out of sync or could not be
+// queried.
+func (s SyncStatus) Diverged() bool {
+	return s.Err != nil || s.MissingScripts > 0 || s.MissingTickets > 0
+}
+
+// WalletStatus holds information about a dcrwallet.
+type WalletStatus struct {
+	DaemonConnected bool
+	VoteVersion     uint32
+	Unlocked        bool
+	Voting          bool
+	// Standby is set when the stakepoold instance is a warm standby which
+	// does not broadcast votes.
+	Standby bool
+	// InvalidVoteBits is the number of users whose vote bits were invalid
+	// for the agendas of VoteVersion when stakepoold started, and were
+	// reset to the defaults of the wallet.
+	InvalidVoteBits uint32
+	// Rescanning is set while the wallet rescans from RescanFromHeight,
+	// since RescanStarted.  Writes to the instance are queued meanwhile.
+	Rescanning       bool
+	RescanFromHeight int64
+	RescanStarted    time.Time
+	// ClockSkew is how far the clock of the stakepoold instance is ahead
+	// of the clock of dcrstakepool, measured within half the round trip of
+	// the WalletInfo RPC, and WalletTimeOffset how far the clock of the
+	// dcrd of the wallet is ahead of the time of its peers.  ClockSkewKnown is false for
+	// instances too old to report their time.
+	ClockSkewKnown   bool
+	ClockSkew        time.Duration
+	WalletTimeOffset time.Duration
+	// TxFee and TicketFee are the fee rates of the wallet in DCR/kB, and
+	// TicketPurchasing is set when its ticket buyer is enabled.  FeesKnown
+	// is false for instances too old to report them.
+	FeesKnown        bool
+	TxFee            float64
+	TicketFee        float64
+	TicketPurchasing bool
+}
+
+// ClockSkewExceeds returns whether the clock of the stakepoold instance or of
+// the dcrd of its wallet is off by more than max.
+func (s *WalletStatus) ClockSkewExceeds(max time.Duration) bool {
+	abs := func(d time.Duration) time.Duration {
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	return (s.ClockSkewKnown && abs(s.ClockSkew) > max) ||
+		abs(s.WalletTimeOffset) > max
+}
@@ -0,0 +1,371 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package managertest provides a contract test suite which every
+// implementation of manager.Manager must pass.  The suite is run against
+// manager.Mock as well as against a live stakepoold so that the behavior
+// dcrstakepool relies on stays consistent between the two.
+package managertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+)
+
+// Fixture holds the inputs used by the contract tests.  Tests which need a
+// fixture field that is not set are skipped.
+type Fixture struct {
+	// MultisigAddresses are the pubkey addresses passed to CreateMultisig.
+	MultisigAddresses []string
+
+	// Address is the address passed to ValidateAddress.
+	Address dcrutil.Address
+}
+
+// Run runs the contract tests against m.  Only read-only methods are
+// exercised so that it is safe to run against a live voting service.
+func Run(ctx context.Context, t *testing.T, m manager.Manager, f Fixture) {
+	t.Run("WalletInfo", func(t *testing.T) {
+		testWalletInfo(ctx, t, m)
+	})
+	t.Run("BackendStatus", func(t *testing.T) {
+		testBackendStatus(ctx, t, m)
+	})
+	t.Run("Tickets", func(t *testing.T) {
+		testTickets(ctx, t, m)
+	})
+	t.Run("GetLiveTicketCounts", func(t *testing.T) {
+		testGetLiveTicketCounts(ctx, t, m)
+	})
+	t.Run("GetTicketAmounts", func(t *testing.T) {
+		testGetTicketAmounts(ctx, t, m)
+	})
+	t.Run("LookupTickets", func(t *testing.T) {
+		testLookupTickets(ctx, t, m)
+	})
+	t.Run("BatchStakePoolUserInfo", func(t *testing.T) {
+		testBatchStakePoolUserInfo(ctx, t, m)
+	})
+	t.Run("GetLowFeeReview", func(t *testing.T) {
+		testGetLowFeeReview(ctx, t, m)
+	})
+	t.Run("GetStakeInfo", func(t *testing.T) {
+		testGetStakeInfo(ctx, t, m)
+	})
+	t.Run("GetBestBlock", func(t *testing.T) {
+		testGetBestBlock(ctx, t, m)
+	})
+	t.Run("CreateMultisig", func(t *testing.T) {
+		testCreateMultisig(ctx, t, m, f)
+	})
+	t.Run("ValidateAddress", func(t *testing.T) {
+		testValidateAddress(ctx, t, m, f)
+	})
+	t.Run("ExistsAddress", func(t *testing.T) {
+		testExistsAddress(ctx, t, m, f)
+	})
+	t.Run("VerifyMessage", func(t *testing.T) {
+		testVerifyMessage(ctx, t, m, f)
+	})
+}
+
+func testWalletInfo(ctx context.Context, t *testing.T, m manager.Manager) {
+	resps, err := m.WalletInfo(ctx)
+	if err != nil {
+		t.Fatalf("WalletInfo: %v", err)
+	}
+	if len(resps) == 0 {
+		t.Fatal("WalletInfo returned no responses")
+	}
+	for i, resp := range resps {
+		if resp == nil {
+			t.Fatalf("WalletInfo response %d is nil", i)
+		}
+	}
+}
+
+func testBackendStatus(ctx context.Context, t *testing.T, m manager.Manager) {
+	resps, err := m.WalletInfo(ctx)
+	if err != nil {
+		t.Fatalf("WalletInfo: %v", err)
+	}
+	statuses := m.BackendStatus(ctx)
+	if len(statuses) != len(resps) {
+		t.Fatalf("BackendStatus returned %d statuses for %d wallets",
+			len(statuses), len(resps))
+	}
+	for i, status := range statuses {
+		if status.Host == "" {
+			t.Errorf("BackendStatus %d has no host", i)
+		}
+		if status.RPCStatus == "" {
+			t.Errorf("BackendStatus %d has no RPC status", i)
+		}
+		if status.WalletStatus == nil {
+			t.Errorf("BackendStatus %d has no wallet status", i)
+			continue
+		}
+		if status.VoteVersion != resps[i].VoteVersion {
+			t.Errorf("BackendStatus %d vote version %d does not match "+
+				"WalletInfo vote version %d", i, status.VoteVersion,
+				resps[i].VoteVersion)
+		}
+		if status.Standby != resps[i].Standby {
+			t.Errorf("BackendStatus %d standby %v does not match "+
+				"WalletInfo standby %v", i, status.Standby, resps[i].Standby)
+		}
+	}
+}
+
+func testTickets(ctx context.Context, t *testing.T, m manager.Manager) {
+	getters := map[string]func(context.Context) (map[chainhash.Hash]string, error){
+		"GetLiveTickets":          m.GetLiveTickets,
+		"GetAddedLowFeeTickets":   m.GetAddedLowFeeTickets,
+		"GetIgnoredLowFeeTickets": m.GetIgnoredLowFeeTickets,
+	}
+	for name, get := range getters {
+		tickets, err := get(ctx)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		for hash, msa := range tickets {
+			if msa == "" {
+				t.Errorf("%s: ticket %v has no multisig address", name, hash)
+			}
+		}
+	}
+
+	tickets, total, err := m.GetIgnoredLowFeeTicketsPage(ctx, 0, 1)
+	if err != nil {
+		t.Fatalf("GetIgnoredLowFeeTicketsPage: %v", err)
+	}
+	if len(tickets) > 1 || len(tickets) > total {
+		t.Errorf("GetIgnoredLowFeeTicketsPage returned %d tickets of %d "+
+			"for a page of 1", len(tickets), total)
+	}
+	tickets, _, err = m.GetIgnoredLowFeeTicketsPage(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("GetIgnoredLowFeeTicketsPage: %v", err)
+	}
+	if len(tickets) != 0 {
+		t.Errorf("GetIgnoredLowFeeTicketsPage returned %d tickets for "+
+			"the count only", len(tickets))
+	}
+}
+
+func testGetLiveTicketCounts(ctx context.Context, t *testing.T, m manager.Manager) {
+	counts, err := m.GetLiveTicketCounts(ctx)
+	if err != nil {
+		t.Fatalf("GetLiveTicketCounts: %v", err)
+	}
+	if counts == nil {
+		t.Fatal("GetLiveTicketCounts returned nil counts")
+	}
+	for msa, count := range counts {
+		if msa == "" {
+			t.Errorf("GetLiveTicketCounts: %d tickets have no multisig address", count)
+		}
+		if count == 0 {
+			t.Errorf("GetLiveTicketCounts: multisig address %s has no tickets", msa)
+		}
+	}
+}
+
+func testGetTicketAmounts(ctx context.Context, t *testing.T, m manager.Manager) {
+	amounts, err := m.GetTicketAmounts(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetTicketAmounts: %v", err)
+	}
+	if amounts == nil || len(amounts) != 0 {
+		t.Fatalf("GetTicketAmounts returned %v for no hashes", amounts)
+	}
+
+	// Unknown transactions are omitted rather than failing the request.
+	amounts, err = m.GetTicketAmounts(ctx, []chainhash.Hash{{}})
+	if err != nil {
+		t.Fatalf("GetTicketAmounts: %v", err)
+	}
+	if len(amounts) != 0 {
+		t.Fatalf("GetTicketAmounts returned amounts of an unknown "+
+			"transaction: %v", amounts)
+	}
+}
+
+func testLookupTickets(ctx context.Context, t *testing.T, m manager.Manager) {
+	found, err := m.LookupTickets(ctx, []chainhash.Hash{{}}, nil)
+	if err != nil {
+		t.Fatalf("LookupTickets: %v", err)
+	}
+	if len(found) != 0 {
+		t.Fatalf("LookupTickets found %d unknown tickets", len(found))
+	}
+
+	live, err := m.GetLiveTickets(ctx)
+	if err != nil {
+		t.Fatalf("GetLiveTickets: %v", err)
+	}
+	for hash, msa := range live {
+		found, err := m.LookupTickets(ctx, nil, []string{msa})
+		if err != nil {
+			t.Fatalf("LookupTickets: %v", err)
+		}
+		for _, f := range found {
+			if f.Address != msa {
+				t.Errorf("LookupTickets for %s returned a ticket of %s",
+					msa, f.Address)
+			}
+			if f.Set == "live" && bytes.Equal(f.Hash, hash[:]) {
+				return
+			}
+		}
+		t.Fatalf("LookupTickets did not find live ticket %v of %s", hash, msa)
+	}
+}
+
+func testBatchStakePoolUserInfo(ctx context.Context, t *testing.T, m manager.Manager) {
+	live, err := m.GetLiveTickets(ctx)
+	if err != nil {
+		t.Fatalf("GetLiveTickets: %v", err)
+	}
+	var msas []string
+	seen := make(map[string]struct{})
+	for _, msa := range live {
+		if _, ok := seen[msa]; ok {
+			continue
+		}
+		seen[msa] = struct{}{}
+		msas = append(msas, msa)
+		if len(msas) == 10 {
+			break
+		}
+	}
+
+	infos, err := m.BatchStakePoolUserInfo(ctx, msas)
+	if err != nil {
+		t.Fatalf("BatchStakePoolUserInfo: %v", err)
+	}
+	for _, msa := range msas {
+		info, ok := infos[msa]
+		if !ok {
+			t.Errorf("BatchStakePoolUserInfo returned no info for %s", msa)
+			continue
+		}
+		single, err := m.StakePoolUserInfo(ctx, msa)
+		if err != nil {
+			t.Errorf("StakePoolUserInfo: %v", err)
+			continue
+		}
+		if len(info.Tickets) != len(single.Tickets) {
+			t.Errorf("BatchStakePoolUserInfo returned %d tickets for %s, "+
+				"StakePoolUserInfo %d", len(info.Tickets), msa,
+				len(single.Tickets))
+		}
+	}
+}
+
+func testGetLowFeeReview(ctx context.Context, t *testing.T, m manager.Manager) {
+	paused, tickets, err := m.GetLowFeeReview(ctx)
+	if err != nil {
+		t.Fatalf("GetLowFeeReview: %v", err)
+	}
+	if !paused && len(tickets) != 0 {
+		t.Fatalf("GetLowFeeReview returned %d held tickets while not paused",
+			len(tickets))
+	}
+	for hash, msa := range tickets {
+		if msa == "" {
+			t.Errorf("GetLowFeeReview: ticket %v has no multisig address", hash)
+		}
+	}
+}
+
+func testGetStakeInfo(ctx context.Context, t *testing.T, m manager.Manager) {
+	info, err := m.GetStakeInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetStakeInfo: %v", err)
+	}
+	if info == nil {
+		t.Fatal("GetStakeInfo returned nil stake info")
+	}
+}
+
+func testGetBestBlock(ctx context.Context, t *testing.T, m manager.Manager) {
+	hash, height, err := m.GetBestBlock(ctx)
+	if err != nil {
+		t.Fatalf("GetBestBlock: %v", err)
+	}
+	if hash == nil {
+		t.Fatal("GetBestBlock returned nil hash")
+	}
+	if height < 0 {
+		t.Errorf("GetBestBlock: negative height %d", height)
+	}
+}
+
+func testCreateMultisig(ctx context.Context, t *testing.T, m manager.Manager, f Fixture) {
+	if len(f.MultisigAddresses) == 0 {
+		t.Skip("no multisig addresses in fixture")
+	}
+	resp, err := m.CreateMultisig(ctx, f.MultisigAddresses)
+	if err != nil {
+		t.Fatalf("CreateMultisig: %v", err)
+	}
+	if resp == nil || resp.Address == "" || resp.RedeemScript == "" {
+		t.Fatalf("CreateMultisig returned incomplete response %v", resp)
+	}
+
+	// The same keys must always produce the same script.
+	resp2, err := m.CreateMultisig(ctx, f.MultisigAddresses)
+	if err != nil {
+		t.Fatalf("CreateMultisig: %v", err)
+	}
+	if resp2 == nil || resp2.Address != resp.Address ||
+		resp2.RedeemScript != resp.RedeemScript {
+		t.Fatalf("CreateMultisig is not deterministic: %v != %v", resp, resp2)
+	}
+}
+
+func testValidateAddress(ctx context.Context, t *testing.T, m manager.Manager, f Fixture) {
+	if f.Address == nil {
+		t.Skip("no address in fixture")
+	}
+	resp, err := m.ValidateAddress(ctx, f.Address)
+	if err != nil {
+		t.Fatalf("ValidateAddress: %v", err)
+	}
+	if resp == nil {
+		t.Fatal("ValidateAddress returned nil response")
+	}
+}
+
+func testExistsAddress(ctx context.Context, t *testing.T, m manager.Manager, f Fixture) {
+	if f.Address == nil {
+		t.Skip("no address in fixture")
+	}
+	if _, err := m.ExistsAddress(ctx, f.Address); err != nil {
+		t.Fatalf("ExistsAddress: %v", err)
+	}
+}
+
+func testVerifyMessage(ctx context.Context, t *testing.T, m manager.Manager, f Fixture) {
+	if f.Address == nil {
+		t.Skip("no address in fixture")
+	}
+	// A well-formed signature from which no key can be recovered.
+	sig := base64.StdEncoding.EncodeToString(make([]byte, 65))
+	valid, err := m.VerifyMessage(ctx, f.Address, "contract test", sig)
+	if err != nil {
+		t.Fatalf("VerifyMessage: %v", err)
+	}
+	if valid {
+		t.Fatal("VerifyMessage accepted an invalid signature")
+	}
+}
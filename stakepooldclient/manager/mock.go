@@ -0,0 +1,339 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package manager
+
+import (
+	"context"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/models"
+)
+
+// Ensure that Mock satisfies the Manager interface.
+var _ Manager = (*Mock)(nil)
+
+// Mock is a Manager whose behavior is provided by the caller.  Each method
+// calls the function field of the same name with a Func suffix.  Methods
+// whose function is nil return zero values and a nil error.
+type Mock struct {
+	GetAddedLowFeeTicketsFunc       func(context.Context) (map[chainhash.Hash]string, error)
+	GetIgnoredLowFeeTicketsFunc     func(context.Context) (map[chainhash.Hash]string, error)
+	GetIgnoredLowFeeTicketsPageFunc func(context.Context, int, int) (map[chainhash.Hash]string, int, error)
+	GetLiveTicketsFunc              func(context.Context) (map[chainhash.Hash]string, error)
+	GetLiveTicketCountsFunc         func(context.Context) (map[string]uint32, error)
+	GetTicketAmountsFunc            func(context.Context, []chainhash.Hash) (map[chainhash.Hash]*pb.TicketAmounts, error)
+	LookupTicketsFunc               func(context.Context, []chainhash.Hash, []string) ([]*pb.TicketLookup, error)
+	SetAddedLowFeeTicketsFunc       func(context.Context, []models.LowFeeTicket) error
+	GetLowFeeReviewFunc             func(context.Context) (bool, map[chainhash.Hash]string, error)
+	ResumeLowFeeClassificationFunc  func(context.Context) error
+	PromoteStandbyFunc              func(context.Context, string) (bool, error)
+	CreateMultisigFunc              func(context.Context, []string) (*pb.CreateMultisigResponse, error)
+	SyncAllFunc                     func(context.Context, []models.User, string, uint32, int64) error
+	StakePoolUserInfoFunc           func(context.Context, string) (*pb.StakePoolUserInfoResponse, error)
+	BatchStakePoolUserInfoFunc      func(context.Context, []string) (map[string]*pb.StakePoolUserInfoResponse, error)
+	SetUserVotingPrefsFunc          func(context.Context, map[int64]*models.User) error
+	WalletInfoFunc                  func(context.Context) ([]*pb.WalletInfoResponse, error)
+	ValidateAddressFunc             func(context.Context, dcrutil.Address) (*pb.ValidateAddressResponse, error)
+	ExistsAddressFunc               func(context.Context, dcrutil.Address) (bool, error)
+	VerifyMessageFunc               func(context.Context, dcrutil.Address, string, string) (bool, error)
+	ImportNewScriptFunc             func(context.Context, []byte) (int64, error)
+	ImportNewScriptOnFunc           func(context.Context, string, []byte) (int64, error)
+	ImportHistoricScriptFunc        func(context.Context, []byte, int64) error
+	HostsFunc                       func() []string
+	RescanningHostsFunc             func() []string
+	BackendStatusFunc               func(context.Context) []BackendStatus
+	SyncStatusFunc                  func(context.Context, []string) []SyncStatus
+	GetStakeInfoFunc                func(context.Context) (*pb.GetStakeInfoResponse, error)
+	InvalidateStakeInfoFunc         func(int64)
+	GetBestBlockFunc                func(context.Context) (*chainhash.Hash, int64, error)
+	SendRawTransactionFunc          func(context.Context, []byte) (*chainhash.Hash, error)
+	GetTransactionConfirmationsFunc func(context.Context, *chainhash.Hash) (int64, error)
+	CrossCheckColdWalletExtPubsFunc func(context.Context, string) error
+	CrossCheckVotingAccountFunc     func(context.Context, string, string) error
+	CrossCheckChainParamsFunc       func(context.Context, *chaincfg.Params, float64, string) error
+}
+
+// GetAddedLowFeeTickets calls GetAddedLowFeeTicketsFunc.
+func (m *Mock) GetAddedLowFeeTickets(ctx context.Context) (map[chainhash.Hash]string, error) {
+	if m.GetAddedLowFeeTicketsFunc == nil {
+		return nil, nil
+	}
+	return m.GetAddedLowFeeTicketsFunc(ctx)
+}
+
+// GetIgnoredLowFeeTickets calls GetIgnoredLowFeeTicketsFunc.
+func (m *Mock) GetIgnoredLowFeeTickets(ctx context.Context) (map[chainhash.Hash]string, error) {
+	if m.GetIgnoredLowFeeTicketsFunc == nil {
+		return nil, nil
+	}
+	return m.GetIgnoredLowFeeTicketsFunc(ctx)
+}
+
+// GetIgnoredLowFeeTicketsPage calls GetIgnoredLowFeeTicketsPageFunc.
+func (m *Mock) GetIgnoredLowFeeTicketsPage(ctx context.Context, offset, limit int) (map[chainhash.Hash]string, int, error) {
+	if m.GetIgnoredLowFeeTicketsPageFunc == nil {
+		return nil, 0, nil
+	}
+	return m.GetIgnoredLowFeeTicketsPageFunc(ctx, offset, limit)
+}
+
+// GetLiveTickets calls GetLiveTicketsFunc.
+func (m *Mock) GetLiveTickets(ctx context.Context) (map[chainhash.Hash]string, error) {
+	if m.GetLiveTicketsFunc == nil {
+		return nil, nil
+	}
+	return m.GetLiveTicketsFunc(ctx)
+}
+
+// GetLiveTicketCounts calls GetLiveTicketCountsFunc.
+func (m *Mock) GetLiveTicketCounts(ctx context.Context) (map[string]uint32, error) {
+	if m.GetLiveTicketCountsFunc == nil {
+		return map[string]uint32{}, nil
+	}
+	return m.GetLiveTicketCountsFunc(ctx)
+}
+
+// GetTicketAmounts calls GetTicketAmountsFunc.
+func (m *Mock) GetTicketAmounts(ctx context.Context, hashes []chainhash.Hash) (map[chainhash.Hash]*pb.TicketAmounts, error) {
+	if m.GetTicketAmountsFunc == nil {
+		return map[chainhash.Hash]*pb.TicketAmounts{}, nil
+	}
+	return m.GetTicketAmountsFunc(ctx, hashes)
+}
+
+// LookupTickets calls LookupTicketsFunc.
+func (m *Mock) LookupTickets(ctx context.Context, tickets []chainhash.Hash, msas []string) ([]*pb.TicketLookup, error) {
+	if m.LookupTicketsFunc == nil {
+		return nil, nil
+	}
+	return m.LookupTicketsFunc(ctx, tickets, msas)
+}
+
+// SetAddedLowFeeTickets calls SetAddedLowFeeTicketsFunc.
+func (m *Mock) SetAddedLowFeeTickets(ctx context.Context, tickets []models.LowFeeTicket) error {
+	if m.SetAddedLowFeeTicketsFunc == nil {
+		return nil
+	}
+	return m.SetAddedLowFeeTicketsFunc(ctx, tickets)
+}
+
+// GetLowFeeReview calls GetLowFeeReviewFunc.
+func (m *Mock) GetLowFeeReview(ctx context.Context) (bool, map[chainhash.Hash]string, error) {
+	if m.GetLowFeeReviewFunc == nil {
+		return false, nil, nil
+	}
+	return m.GetLowFeeReviewFunc(ctx)
+}
+
+// ResumeLowFeeClassification calls ResumeLowFeeClassificationFunc.
+func (m *Mock) ResumeLowFeeClassification(ctx context.Context) error {
+	if m.ResumeLowFeeClassificationFunc == nil {
+		return nil
+	}
+	return m.ResumeLowFeeClassificationFunc(ctx)
+}
+
+// PromoteStandby calls PromoteStandbyFunc.
+func (m *Mock) PromoteStandby(ctx context.Context, host string) (bool, error) {
+	if m.PromoteStandbyFunc == nil {
+		return false, nil
+	}
+	return m.PromoteStandbyFunc(ctx, host)
+}
+
+// CreateMultisig calls CreateMultisigFunc.
+func (m *Mock) CreateMultisig(ctx context.Context, addresses []string) (*pb.CreateMultisigResponse, error) {
+	if m.CreateMultisigFunc == nil {
+		return nil, nil
+	}
+	return m.CreateMultisigFunc(ctx, addresses)
+}
+
+// SyncAll calls SyncAllFunc.
+func (m *Mock) SyncAll(ctx context.Context, multiSigScripts []models.User, account string, branch uint32, maxUsers int64) error {
+	if m.SyncAllFunc == nil {
+		return nil
+	}
+	return m.SyncAllFunc(ctx, multiSigScripts, account, branch, maxUsers)
+}
+
+// StakePoolUserInfo calls StakePoolUserInfoFunc.
+func (m *Mock) StakePoolUserInfo(ctx context.Context, multiSigAddress string) (*pb.StakePoolUserInfoResponse, error) {
+	if m.StakePoolUserInfoFunc == nil {
+		return nil, nil
+	}
+	return m.StakePoolUserInfoFunc(ctx, multiSigAddress)
+}
+
+// BatchStakePoolUserInfo calls BatchStakePoolUserInfoFunc.
+func (m *Mock) BatchStakePoolUserInfo(ctx context.Context, multiSigAddresses []string) (map[string]*pb.StakePoolUserInfoResponse, error) {
+	if m.BatchStakePoolUserInfoFunc == nil {
+		return nil, nil
+	}
+	return m.BatchStakePoolUserInfoFunc(ctx, multiSigAddresses)
+}
+
+// SetUserVotingPrefs calls SetUserVotingPrefsFunc.
+func (m *Mock) SetUserVotingPrefs(ctx context.Context, dbUsers map[int64]*models.User) error {
+	if m.SetUserVotingPrefsFunc == nil {
+		return nil
+	}
+	return m.SetUserVotingPrefsFunc(ctx, dbUsers)
+}
+
+// WalletInfo calls WalletInfoFunc.
+func (m *Mock) WalletInfo(ctx context.Context) ([]*pb.WalletInfoResponse, error) {
+	if m.WalletInfoFunc == nil {
+		return nil, nil
+	}
+	return m.WalletInfoFunc(ctx)
+}
+
+// ValidateAddress calls ValidateAddressFunc.
+func (m *Mock) ValidateAddress(ctx context.Context, addr dcrutil.Address) (*pb.ValidateAddressResponse, error) {
+	if m.ValidateAddressFunc == nil {
+		return nil, nil
+	}
+	return m.ValidateAddressFunc(ctx, addr)
+}
+
+// ExistsAddress calls ExistsAddressFunc.
+func (m *Mock) ExistsAddress(ctx context.Context, addr dcrutil.Address) (bool, error) {
+	if m.ExistsAddressFunc == nil {
+		return false, nil
+	}
+	return m.ExistsAddressFunc(ctx, addr)
+}
+
+// VerifyMessage calls VerifyMessageFunc.
+func (m *Mock) VerifyMessage(ctx context.Context, addr dcrutil.Address, message, signature string) (bool, error) {
+	if m.VerifyMessageFunc == nil {
+		return false, nil
+	}
+	return m.VerifyMessageFunc(ctx, addr, message, signature)
+}
+
+// ImportNewScript calls ImportNewScriptFunc.
+func (m *Mock) ImportNewScript(ctx context.Context, script []byte) (int64, error) {
+	if m.ImportNewScriptFunc == nil {
+		return 0, nil
+	}
+	return m.ImportNewScriptFunc(ctx, script)
+}
+
+// ImportNewScriptOn calls ImportNewScriptOnFunc.
+func (m *Mock) ImportNewScriptOn(ctx context.Context, host string, script []byte) (int64, error) {
+	if m.ImportNewScriptOnFunc == nil {
+		return 0, nil
+	}
+	return m.ImportNewScriptOnFunc(ctx, host, script)
+}
+
+// ImportHistoricScript calls ImportHistoricScriptFunc.
+func (m *Mock) ImportHistoricScript(ctx context.Context, script []byte, rescanHeight int64) error {
+	if m.ImportHistoricScriptFunc == nil {
+		return nil
+	}
+	return m.ImportHistoricScriptFunc(ctx, script, rescanHeight)
+}
+
+// Hosts calls HostsFunc.
+func (m *Mock) Hosts() []string {
+	if m.HostsFunc == nil {
+		return nil
+	}
+	return m.HostsFunc()
+}
+
+// RescanningHosts calls RescanningHostsFunc.
+func (m *Mock) RescanningHosts() []string {
+	if m.RescanningHostsFunc == nil {
+		return nil
+	}
+	return m.RescanningHostsFunc()
+}
+
+// BackendStatus calls BackendStatusFunc.
+func (m *Mock) BackendStatus(ctx context.Context) []BackendStatus {
+	if m.BackendStatusFunc == nil {
+		return nil
+	}
+	return m.BackendStatusFunc(ctx)
+}
+
+// SyncStatus calls SyncStatusFunc.
+func (m *Mock) SyncStatus(ctx context.Context, multiSigAddresses []string) []SyncStatus {
+	if m.SyncStatusFunc == nil {
+		return nil
+	}
+	return m.SyncStatusFunc(ctx, multiSigAddresses)
+}
+
+// GetStakeInfo calls GetStakeInfoFunc.
+func (m *Mock) GetStakeInfo(ctx context.Context) (*pb.GetStakeInfoResponse, error) {
+	if m.GetStakeInfoFunc == nil {
+		return nil, nil
+	}
+	return m.GetStakeInfoFunc(ctx)
+}
+
+// InvalidateStakeInfo calls InvalidateStakeInfoFunc.
+func (m *Mock) InvalidateStakeInfo(bestHeight int64) {
+	if m.InvalidateStakeInfoFunc == nil {
+		return
+	}
+	m.InvalidateStakeInfoFunc(bestHeight)
+}
+
+// GetBestBlock calls GetBestBlockFunc.
+func (m *Mock) GetBestBlock(ctx context.Context) (*chainhash.Hash, int64, error) {
+	if m.GetBestBlockFunc == nil {
+		return nil, 0, nil
+	}
+	return m.GetBestBlockFunc(ctx)
+}
+
+// SendRawTransaction calls SendRawTransactionFunc.
+func (m *Mock) SendRawTransaction(ctx context.Context, tx []byte) (*chainhash.Hash, error) {
+	if m.SendRawTransactionFunc == nil {
+		return nil, nil
+	}
+	return m.SendRawTransactionFunc(ctx, tx)
+}
+
+// GetTransactionConfirmations calls GetTransactionConfirmationsFunc.
+func (m *Mock) GetTransactionConfirmations(ctx context.Context, hash *chainhash.Hash) (int64, error) {
+	if m.GetTransactionConfirmationsFunc == nil {
+		return 0, nil
+	}
+	return m.GetTransactionConfirmationsFunc(ctx, hash)
+}
+
+// CrossCheckColdWalletExtPubs calls CrossCheckColdWalletExtPubsFunc.
+func (m *Mock) CrossCheckColdWalletExtPubs(ctx context.Context, xpub string) error {
+	if m.CrossCheckColdWalletExtPubsFunc == nil {
+		return nil
+	}
+	return m.CrossCheckColdWalletExtPubsFunc(ctx, xpub)
+}
+
+// CrossCheckVotingAccount calls CrossCheckVotingAccountFunc.
+func (m *Mock) CrossCheckVotingAccount(ctx context.Context, account string, xpub string) error {
+	if m.CrossCheckVotingAccountFunc == nil {
+		return nil
+	}
+	return m.CrossCheckVotingAccountFunc(ctx, account, xpub)
+}
+
+// CrossCheckChainParams calls CrossCheckChainParamsFunc.
+func (m *Mock) CrossCheckChainParams(ctx context.Context, params *chaincfg.Params, poolFees float64, feeMode string) error {
+	if m.CrossCheckChainParamsFunc == nil {
+		return nil
+	}
+	return m.CrossCheckChainParamsFunc(ctx, params, poolFees, feeMode)
+}
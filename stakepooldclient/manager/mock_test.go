@@ -0,0 +1,134 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package manager_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager/managertest"
+)
+
+func TestMockContract(t *testing.T) {
+	tickets := func(context.Context) (map[chainhash.Hash]string, error) {
+		return map[chainhash.Hash]string{
+			{0x01}: "SscWmiP9TMGZimomJiqQvnrkGe23h3C3sJb",
+		}, nil
+	}
+	userInfo := func(ctx context.Context, msa string) *pb.StakePoolUserInfoResponse {
+		live, _ := tickets(ctx)
+		info := new(pb.StakePoolUserInfoResponse)
+		for hash, a := range live {
+			if a == msa {
+				info.Tickets = append(info.Tickets, &pb.StakePoolUserTicket{
+					Status: "live",
+					Ticket: hash.String(),
+				})
+			}
+		}
+		return info
+	}
+	m := &manager.Mock{
+		GetAddedLowFeeTicketsFunc:   tickets,
+		GetIgnoredLowFeeTicketsFunc: tickets,
+		GetLiveTicketsFunc:          tickets,
+		LookupTicketsFunc: func(ctx context.Context, hashes []chainhash.Hash, msas []string) ([]*pb.TicketLookup, error) {
+			live, _ := tickets(ctx)
+			var found []*pb.TicketLookup
+			for hash, msa := range live {
+				match := false
+				for i := range hashes {
+					match = match || hashes[i] == hash
+				}
+				for _, a := range msas {
+					match = match || a == msa
+				}
+				if match {
+					hash := hash
+					found = append(found, &pb.TicketLookup{
+						Hash:    hash[:],
+						Address: msa,
+						Set:     "live",
+					})
+				}
+			}
+			return found, nil
+		},
+		StakePoolUserInfoFunc: func(ctx context.Context, msa string) (*pb.StakePoolUserInfoResponse, error) {
+			return userInfo(ctx, msa), nil
+		},
+		BatchStakePoolUserInfoFunc: func(ctx context.Context, msas []string) (map[string]*pb.StakePoolUserInfoResponse, error) {
+			infos := make(map[string]*pb.StakePoolUserInfoResponse, len(msas))
+			for _, msa := range msas {
+				infos[msa] = userInfo(ctx, msa)
+			}
+			return infos, nil
+		},
+		WalletInfoFunc: func(context.Context) ([]*pb.WalletInfoResponse, error) {
+			return []*pb.WalletInfoResponse{{
+				VoteVersion:     8,
+				DaemonConnected: true,
+				Unlocked:        true,
+				Voting:          true,
+			}}, nil
+		},
+		BackendStatusFunc: func(context.Context) []manager.BackendStatus {
+			return []manager.BackendStatus{{
+				Host:      "127.0.0.1:9113",
+				RPCStatus: "Ready",
+				WalletStatus: &manager.WalletStatus{
+					DaemonConnected: true,
+					VoteVersion:     8,
+					Unlocked:        true,
+					Voting:          true,
+				},
+			}}
+		},
+		GetStakeInfoFunc: func(context.Context) (*pb.GetStakeInfoResponse, error) {
+			return &pb.GetStakeInfoResponse{BlockHeight: 100}, nil
+		},
+		GetBestBlockFunc: func(context.Context) (*chainhash.Hash, int64, error) {
+			return &chainhash.Hash{0x02}, 100, nil
+		},
+		CreateMultisigFunc: func(context.Context, []string) (*pb.CreateMultisigResponse, error) {
+			return &pb.CreateMultisigResponse{
+				RedeemScript: "5121",
+				Address:      "ScuQxvveKGfpG1ypt6u27F99Anf7EW3cqhq",
+			}, nil
+		},
+		ValidateAddressFunc: func(context.Context, dcrutil.Address) (*pb.ValidateAddressResponse, error) {
+			return &pb.ValidateAddressResponse{IsMine: true}, nil
+		},
+	}
+
+	addr, err := dcrutil.DecodeAddress("SsYn4toZtiSTbngZLxwvSFfUAh6RBpDVHJf",
+		chaincfg.SimNetParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	managertest.Run(context.Background(), t, m, managertest.Fixture{
+		MultisigAddresses: []string{"pool", "user"},
+		Address:           addr,
+	})
+}
+
+func TestMockZeroValue(t *testing.T) {
+	var m manager.Mock
+	ctx := context.Background()
+	if info, err := m.WalletInfo(ctx); info != nil || err != nil {
+		t.Fatalf("WalletInfo: got %v, %v, want nil, nil", info, err)
+	}
+	if height, err := m.ImportNewScript(ctx, nil); height != 0 || err != nil {
+		t.Fatalf("ImportNewScript: got %v, %v, want 0, nil", height, err)
+	}
+	if statuses := m.BackendStatus(ctx); statuses != nil {
+		t.Fatalf("BackendStatus: got %v, want nil", statuses)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+)
+
+// grpcDefaultMaxMessageSize is the maximum size of the messages received by
+// stakepoold instances which do not advertise theirs, the default of gRPC.
+const grpcDefaultMaxMessageSize = 4 << 20
+
+// msgSizeLimit limits the size of the requests sent on a connection to the
+// size its stakepoold instance accepts, so that the requests which grow with
+// the pool, such as the voting preferences of all users or the scripts
+// imported by SyncAll, fail before they are sent rather than being refused.
+type msgSizeLimit struct {
+	send int32 // atomic
+}
+
+// newMsgSizeLimit returns a limit of size bytes until the size advertised by
+// stakepoold is known.
+func newMsgSizeLimit(size int) *msgSizeLimit {
+	return &msgSizeLimit{send: int32(size)}
+}
+
+// get returns the maximum size of the requests in bytes.
+func (l *msgSizeLimit) get() int {
+	return int(atomic.LoadInt32(&l.send))
+}
+
+// negotiate sets the limit to the smallest of the maximum size of the messages
+// of dcrstakepool and the size advertised by stakepoold, which is the gRPC
+// default when it is not advertised.
+func (l *msgSizeLimit) negotiate(local int, advertised uint32) int {
+	size := grpcDefaultMaxMessageSize
+	if advertised > 0 {
+		size = int(advertised)
+	}
+	if local < size {
+		size = local
+	}
+	atomic.StoreInt32(&l.send, int32(size))
+	return size
+}
+
+// intercept is a gRPC unary client interceptor applying the limit.
+func (l *msgSizeLimit) intercept(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	opts = append(opts, grpc.MaxCallSendMsgSize(l.get()))
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// largePoolUsers is the number of users of the large pool in the tests, whose
+// voting preferences and scripts do not fit in the gRPC default message size.
+const largePoolUsers = 200000
+
+type msgSizeVersionServer struct {
+	pb.UnimplementedVersionServiceServer
+	maxMessageSize uint32
+}
+
+func (s *msgSizeVersionServer) Version(context.Context, *pb.VersionRequest) (*pb.VersionResponse, error) {
+	return &pb.VersionResponse{
+		Major:          requiredStakepooldAPI.major,
+		Minor:          requiredStakepooldAPI.minor,
+		MaxMessageSize: s.maxMessageSize,
+	}, nil
+}
+
+type msgSizeStakepooldServer struct {
+	pb.UnimplementedStakepooldServiceServer
+	received int32
+}
+
+func (s *msgSizeStakepooldServer) SetUserVotingPrefs(context.Context, *pb.SetUserVotingPrefsRequest) (*pb.SetUserVotingPrefsResponse, error) {
+	atomic.AddInt32(&s.received, 1)
+	return &pb.SetUserVotingPrefsResponse{}, nil
+}
+
+func (s *msgSizeStakepooldServer) ImportMissingScripts(context.Context, *pb.ImportMissingScriptsRequest) (*pb.ImportMissingScriptsResponse, error) {
+	atomic.AddInt32(&s.received, 1)
+	return &pb.ImportMissingScriptsResponse{}, nil
+}
+
+func TestMsgSizeLimitNegotiate(t *testing.T) {
+	tests := []struct {
+		local      int
+		advertised uint32
+		want       int
+	}{
+		{64 << 20, 0, grpcDefaultMaxMessageSize},
+		{64 << 20, 16 << 20, 16 << 20},
+		{16 << 20, 64 << 20, 16 << 20},
+		{8 << 20, 8 << 20, 8 << 20},
+	}
+	for _, test := range tests {
+		l := newMsgSizeLimit(test.local)
+		if got := l.negotiate(test.local, test.advertised); got != test.want ||
+			l.get() != test.want {
+			t.Errorf("local %d advertised %d: want %d, got %d", test.local,
+				test.advertised, test.want, l.get())
+		}
+	}
+}
+
+func TestLargePoolMessages(t *testing.T) {
+	users := make([]*pb.UserVotingConfigEntry, largePoolUsers)
+	scripts := make([][]byte, largePoolUsers)
+	for i := range users {
+		users[i] = &pb.UserVotingConfigEntry{
+			UserId:          int64(i + 1),
+			MultiSigAddress: fmt.Sprintf("Tc%033d", i),
+			VoteBits:        5,
+			VoteBitsVersion: 8,
+		}
+		scripts[i] = make([]byte, 71)
+	}
+	prefs := &pb.SetUserVotingPrefsRequest{UserVotingConfig: users}
+	imports := &pb.ImportMissingScriptsRequest{Scripts: scripts}
+	if messageSize(prefs) <= grpcDefaultMaxMessageSize ||
+		messageSize(imports) <= grpcDefaultMaxMessageSize {
+		t.Fatal("large pool messages fit in the gRPC default size")
+	}
+
+	tests := []struct {
+		name       string
+		serverSize int
+		advertised uint32
+		clientSize int
+		wantSent   bool
+	}{
+		{"defaults", 64 << 20, 64 << 20, 64 << 20, true},
+		{"small server", 4 << 20, 4 << 20, 64 << 20, false},
+		{"small client", 64 << 20, 64 << 20, 4 << 20, false},
+		{"old server", 64 << 20, 0, 64 << 20, false},
+	}
+	for _, test := range tests {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := grpc.NewServer(grpc.MaxRecvMsgSize(test.serverSize))
+		pb.RegisterVersionServiceServer(server,
+			&msgSizeVersionServer{maxMessageSize: test.advertised})
+		stakepoold := new(msgSizeStakepooldServer)
+		pb.RegisterStakepooldServiceServer(server, stakepoold)
+		go server.Serve(lis)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		conn, _, err := dialStakepoold(ctx, lis.Addr().String(),
+			test.clientSize, nil, grpc.WithInsecure())
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		client := pb.NewStakepooldServiceClient(conn)
+		for _, send := range []func() error{
+			func() error {
+				_, err := client.SetUserVotingPrefs(ctx, prefs)
+				return err
+			},
+			func() error {
+				_, err := client.ImportMissingScripts(ctx, imports)
+				return err
+			},
+		} {
+			err := send()
+			if test.wantSent && err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			if !test.wantSent && status.Code(err) != codes.ResourceExhausted {
+				t.Errorf("%s: want ResourceExhausted, got %v", test.name, err)
+			}
+		}
+		received := atomic.LoadInt32(&stakepoold.received)
+		if test.wantSent && received != 2 || !test.wantSent && received != 0 {
+			t.Errorf("%s: stakepoold received %d requests", test.name, received)
+		}
+
+		cancel()
+		conn.Close()
+		server.Stop()
+	}
+}
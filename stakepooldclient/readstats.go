@@ -0,0 +1,114 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+)
+
+const (
+	// readStatsWeight is the weight given to each new sample in the rolling
+	// averages kept by readStats.
+	readStatsWeight = 0.2
+
+	// readStatsMaxAge is how long the stats of an instance are trusted
+	// without new samples.  Instances whose stats are older are tried first
+	// so that instances which were slow or failing get a chance to recover.
+	readStatsMaxAge = time.Minute
+
+	// unhealthyErrorRate is the rolling error rate above which an instance
+	// is only used for reads once all healthy instances failed.
+	unhealthyErrorRate = 0.5
+)
+
+// readStats keeps rolling latency and error rate averages of the read-only
+// RPCs sent to a stakepoold instance.
+type readStats struct {
+	mtx        sync.Mutex
+	latency    time.Duration
+	errorRate  float64
+	lastSample time.Time
+}
+
+// observe adds the latency and result of an RPC to the rolling averages.
+// Failed RPCs only count towards the error rate.
+func (s *readStats) observe(latency time.Duration, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var failed float64
+	if err != nil {
+		failed = 1
+	}
+	if s.lastSample.IsZero() {
+		s.errorRate = failed
+		if err == nil {
+			s.latency = latency
+		}
+	} else {
+		s.errorRate += readStatsWeight * (failed - s.errorRate)
+		if err == nil {
+			s.latency += time.Duration(readStatsWeight *
+				float64(latency-s.latency))
+		}
+	}
+	s.lastSample = time.Now()
+}
+
+// snapshot returns the current averages and whether they are recent enough
+// to be trusted.
+func (s *readStats) snapshot() (time.Duration, float64, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	fresh := !s.lastSample.IsZero() && time.Since(s.lastSample) < readStatsMaxAge
+	return s.latency, s.errorRate, fresh
+}
+
+// readOrder returns the indexes of the stakepoold connections in the order
+// read-only RPCs should try them: instances without recent stats first, then
+// healthy instances from fastest to slowest, and finally instances which are
+// failing, disconnected or whose circuit breaker is open.
+func (s *stakepooldManager) readOrder() []int {
+	type candidate struct {
+		index   int
+		rank    int
+		latency time.Duration
+	}
+	candidates := make([]candidate, len(s.grpcConnections))
+	now := time.Now()
+	for i, conn := range s.grpcConnections {
+		latency, errorRate, fresh := s.stats[i].snapshot()
+		c := candidate{index: i, rank: 1, latency: latency}
+		switch state := conn.GetState(); {
+		case state == connectivity.TransientFailure ||
+			state == connectivity.Shutdown || s.breaker(i).isOpen(now):
+			c.rank = 2
+		case !fresh:
+			c.rank = 0
+		case errorRate > unhealthyErrorRate:
+			c.rank = 2
+		}
+		candidates[i] = c
+	}
+
+	// A stable sort keeps the configured order among equal candidates.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].rank != candidates[j].rank {
+			return candidates[i].rank < candidates[j].rank
+		}
+		return candidates[i].rank == 1 &&
+			candidates[i].latency < candidates[j].latency
+	})
+
+	order := make([]int, len(candidates))
+	for i, c := range candidates {
+		order[i] = c.index
+	}
+	return order
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestReadStatsObserve(t *testing.T) {
+	var s readStats
+	if _, _, fresh := s.snapshot(); fresh {
+		t.Fatal("stats without samples are fresh")
+	}
+
+	s.observe(100*time.Millisecond, nil)
+	latency, errorRate, fresh := s.snapshot()
+	if latency != 100*time.Millisecond || errorRate != 0 || !fresh {
+		t.Fatalf("got %v, %v, %v after first sample", latency, errorRate, fresh)
+	}
+
+	// Failures raise the error rate without affecting the latency.
+	s.observe(time.Second, errors.New("unavailable"))
+	latency, errorRate, _ = s.snapshot()
+	if latency != 100*time.Millisecond || errorRate != readStatsWeight {
+		t.Fatalf("got %v, %v after failure", latency, errorRate)
+	}
+
+	s.observe(200*time.Millisecond, nil)
+	latency, _, _ = s.snapshot()
+	if latency != 120*time.Millisecond {
+		t.Fatalf("got latency %v, want 120ms", latency)
+	}
+
+	s.lastSample = time.Now().Add(-readStatsMaxAge)
+	if _, _, fresh := s.snapshot(); fresh {
+		t.Fatal("old stats are fresh")
+	}
+}
+
+func TestReadOrder(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s := &stakepooldManager{}
+	for i := 0; i < 4; i++ {
+		conn, err := grpc.DialContext(ctx, lis.Addr().String(),
+			grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		s.grpcConnections = append(s.grpcConnections, conn)
+		s.stats = append(s.stats, new(readStats))
+	}
+
+	// Without stats the configured order is kept.
+	if got := s.readOrder(); !reflect.DeepEqual(got, []int{0, 1, 2, 3}) {
+		t.Fatalf("got order %v without stats", got)
+	}
+
+	s.stats[0].observe(300*time.Millisecond, nil)
+	s.stats[1].observe(100*time.Millisecond, errors.New("unavailable"))
+	s.stats[2].observe(200*time.Millisecond, nil)
+	s.stats[3].observe(100*time.Millisecond, nil)
+
+	// Healthy instances are ordered by latency and failing ones come last.
+	if got := s.readOrder(); !reflect.DeepEqual(got, []int{3, 2, 0, 1}) {
+		t.Fatalf("got order %v", got)
+	}
+
+	// Instances with stale stats are tried first.
+	s.stats[0].lastSample = time.Now().Add(-readStatsMaxAge)
+	if got := s.readOrder(); !reflect.DeepEqual(got, []int{0, 3, 2, 1}) {
+		t.Fatalf("got order %v with stale stats", got)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+)
+
+// rescanCheckInterval is how often the stakepoold instances are asked whether
+// their wallet is rescanning.
+const rescanCheckInterval = 30 * time.Second
+
+// rescanTracker records which stakepoold instances have a rescanning wallet.
+// Writes to them are queued rather than sent, since the wallet is slow or
+// fails to answer until the rescan is done.
+type rescanTracker struct {
+	mtx   sync.Mutex
+	hosts map[string]struct{}
+}
+
+// newRescanTracker returns a rescanTracker without rescanning instances.
+func newRescanTracker() *rescanTracker {
+	return &rescanTracker{hosts: make(map[string]struct{})}
+}
+
+// update records whether the wallet of host is rescanning and returns whether
+// its rescan just ended.
+func (r *rescanTracker) update(host string, rescanning bool) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	_, was := r.hosts[host]
+	if rescanning {
+		r.hosts[host] = struct{}{}
+	} else {
+		delete(r.hosts, host)
+	}
+	return was && !rescanning
+}
+
+// rescanning returns whether the wallet of host is rescanning.
+func (r *rescanTracker) rescanning(host string) bool {
+	r.mtx.Lock()
+	_, ok := r.hosts[host]
+	r.mtx.Unlock()
+	return ok
+}
+
+// list returns the sorted hosts whose wallet is rescanning.
+func (r *rescanTracker) list() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	hosts := make([]string, 0, len(r.hosts))
+	for host := range r.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// noteRescan records the rescan state of host from its WalletInfo response.
+// The queued writes of an instance whose rescan ended are retried right away.
+func (s *stakepooldManager) noteRescan(host string, info *pb.WalletInfoResponse) {
+	wasRescanning := s.rescans.rescanning(host)
+	if info.Rescanning && !wasRescanning {
+		log.Infof("Wallet of stakepoold instance %s is rescanning from "+
+			"height %d, queueing writes until it is done", host,
+			info.RescanFromHeight)
+	}
+	if s.rescans.update(host, info.Rescanning) {
+		n := s.writes.resume(host, time.Now())
+		log.Infof("Wallet of stakepoold instance %s finished rescanning, "+
+			"resuming %d queued writes", host, n)
+	}
+}
+
+// RescanningHosts returns the stakepoold instances whose wallet was rescanning
+// when last checked.
+func (s *stakepooldManager) RescanningHosts() []string {
+	return s.rescans.list()
+}
+
+// watchRescans checks whether the wallets of the stakepoold instances are
+// rescanning every rescanCheckInterval until ctx is done.
+func (s *stakepooldManager) watchRescans(ctx context.Context) {
+	ticker := time.NewTicker(rescanCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, conn := range s.grpcConnections {
+			client := pb.NewStakepooldServiceClient(conn)
+			ctx, cancel := context.WithTimeout(ctx, rescanCheckInterval)
+			info, err := client.WalletInfo(ctx, &pb.WalletInfoRequest{})
+			cancel()
+			if err != nil {
+				log.Debugf("WalletInfo RPC failed on stakepoold instance "+
+					"%s: %v", conn.Target(), err)
+				continue
+			}
+			s.noteRescan(conn.Target(), info)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+	"google.golang.org/grpc"
+)
+
+func TestRescanTracker(t *testing.T) {
+	r := newRescanTracker()
+	if r.update("b", true) || r.update("a", true) || r.update("b", true) {
+		t.Fatal("rescan ended while starting")
+	}
+	if got := r.list(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("got rescanning hosts %v", got)
+	}
+	if !r.update("a", false) {
+		t.Fatal("rescan did not end")
+	}
+	if r.update("a", false) || r.update("c", false) {
+		t.Fatal("rescan ended without a rescan")
+	}
+	if r.rescanning("a") || !r.rescanning("b") {
+		t.Fatalf("got rescanning hosts %v, want b", r.list())
+	}
+}
+
+func TestApplyAllRescanning(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, lis.Addr().String(),
+		grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	host := conn.Target()
+	s := &stakepooldManager{
+		grpcConnections: []*grpc.ClientConn{conn},
+		writes:          newWriteQueue(),
+		rescans:         newRescanTracker(),
+	}
+
+	var applied int32
+	apply := func(context.Context, pb.StakepooldServiceClient) error {
+		atomic.AddInt32(&applied, 1)
+		return nil
+	}
+
+	// Writes to a rescanning instance are queued without being sent.
+	s.noteRescan(host, &pb.WalletInfoResponse{Rescanning: true})
+	err = s.applyAll(ctx, "SetUserVotingPrefs", "prefs", apply)
+	var perr *manager.PartialWriteError
+	if !errors.As(err, &perr) || perr.Applied != 0 ||
+		perr.Failed[host] != manager.ErrWalletRescanning {
+		t.Fatalf("got error %v while rescanning", err)
+	}
+	if applied != 0 || s.writes.pending(host) != 1 {
+		t.Fatalf("applied %d and queued %d writes while rescanning",
+			applied, s.writes.pending(host))
+	}
+	if got := s.RescanningHosts(); !reflect.DeepEqual(got, []string{host}) {
+		t.Fatalf("got rescanning hosts %v", got)
+	}
+
+	// The queued writes are due as soon as the rescan ends.
+	now := time.Now()
+	if len(s.writes.due(now)) != 0 {
+		t.Fatal("write due before the rescan ended")
+	}
+	s.noteRescan(host, &pb.WalletInfoResponse{})
+	if len(s.writes.due(time.Now())) != 1 {
+		t.Fatal("write not due after the rescan ended")
+	}
+	if err := s.applyAll(ctx, "SetUserVotingPrefs", "prefs", apply); err != nil {
+		t.Fatal(err)
+	}
+	if applied != 1 || s.writes.pending(host) != 0 {
+		t.Fatalf("applied %d and queued %d writes after the rescan",
+			applied, s.writes.pending(host))
+	}
+}
@@ -1,78 +1,107 @@
 package stakepooldclient
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
 	"github.com/decred/dcrd/dcrutil/v3"
 	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
-	"github.com/decred/dcrstakepool/helpers"
 	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
 )
 
 var (
 	// Ensure that stakepooldManager satisfies the Manager interface.
-	_                     Manager = (*stakepooldManager)(nil)
-	requiredStakepooldAPI         = semver{major: 10, minor: 0, patch: 0}
+	_                     manager.Manager = (*stakepooldManager)(nil)
+	requiredStakepooldAPI                 = semver{major: 10, minor: 16, patch: 0}
 
 	// cacheTimerStakeInfo is the duration of time after which to
 	// access the wallet and update the stake information instead
 	// of returning cached stake information.
 	cacheTimerStakeInfo = 5 * time.Minute
 
-	// defaultAccountName is the account name for the default wallet
-	// account as a string.
-	defaultAccountName = "default"
-)
+	// minStakeInfoRefresh is the minimum duration of time between updates
+	// of the stake information, which still applies when the cache is
+	// invalidated by a new block so that blocks found in quick succession
+	// do not cause a storm of requests to the wallet.
+	minStakeInfoRefresh = 15 * time.Second
 
-// Manager is satisfied by stakepooldManager.
-type Manager interface {
-	GetAddedLowFeeTickets(context.Context) (map[chainhash.Hash]string, error)
-	GetIgnoredLowFeeTickets(context.Context) (map[chainhash.Hash]string, error)
-	GetLiveTickets(context.Context) (map[chainhash.Hash]string, error)
-	SetAddedLowFeeTickets(context.Context, []models.LowFeeTicket) error
-	CreateMultisig(context.Context, []string) (*pb.CreateMultisigResponse, error)
-	SyncAll(ctx context.Context, multiSigScripts []models.User, maxUsers int64) error
-	StakePoolUserInfo(ctx context.Context, multiSigAddress string) (*pb.StakePoolUserInfoResponse, error)
-	SetUserVotingPrefs(ctx context.Context, dbUsers map[int64]*models.User) error
-	WalletInfo(context.Context) ([]*pb.WalletInfoResponse, error)
-	ValidateAddress(ctx context.Context, addr dcrutil.Address) (*pb.ValidateAddressResponse, error)
-	ImportNewScript(ctx context.Context, script []byte) (heightImported int64, err error)
-	BackendStatus(context.Context) []BackendStatus
-	GetStakeInfo(context.Context) (*pb.GetStakeInfoResponse, error)
-	CrossCheckColdWalletExtPubs(ctx context.Context, dcrstakepoolColdWalletExtPub string) error
-}
+	// ticketAmountsBatchSize is the maximum number of hashes stakepoold
+	// accepts in a single GetTicketAmounts request.
+	ticketAmountsBatchSize = 100
+
+	// userInfoBatchSize is the maximum number of multisig addresses
+	// stakepoold accepts in a single BatchStakePoolUserInfo request.
+	userInfoBatchSize = 500
+)
 
 // stakepooldManager coordinates the communication between dcrstakepool and
 // multiple instances of stakepoold. All interaction with stakepoold should
 // be through stakepooldManager.
 type stakepooldManager struct {
 	grpcConnections []*grpc.ClientConn
+	// stats holds the rolling read RPC latency and error rate of each
+	// connection, used to send reads to the fastest healthy instance.
+	stats []*readStats
+	// msgSizeLimits holds the maximum size of the requests sent on each
+	// connection.
+	msgSizeLimits []*msgSizeLimit
+	// breakers holds the circuit breaker of each connection, nil when
+	// breakers are disabled.
+	breakers []*circuitBreaker
 	// cachedStakeInfo is cached information about the voting service wallet.
 	// This is required because of the time it takes to compute the stake
 	// information. The included timer is used so that new stake information is
-	// only queried for if 5 minutes or more has passed. The mutex is used to
-	// allow concurrent access to the stake information if less than five
-	// minutes has passed.
-	cachedStakeInfo      *pb.GetStakeInfoResponse
-	cachedStakeInfoTimer time.Time
-	cachedStakeInfoMutex sync.Mutex
+	// only queried for if 5 minutes or more has passed, or the cache was
+	// invalidated by a new block. The mutex is used to allow concurrent
+	// access to the stake information until then.
+	cachedStakeInfo        *pb.GetStakeInfoResponse
+	cachedStakeInfoTimer   time.Time
+	cachedStakeInfoFetched time.Time
+	cachedStakeInfoMutex   sync.Mutex
+	// chainParamsMismatch is the error describing the last stakepoold
+	// instance found by CrossCheckChainParams to be configured for another
+	// network or higher pool fees than dcrstakepool, or nil when all of
+	// them matched.  Writes are refused while it is set.
+	chainParamsMismatch    error
+	chainParamsMismatchMtx sync.Mutex
+	// writes holds the writes which failed on some instances and are
+	// retried in the background.
+	writes *writeQueue
+	// rescans holds the instances whose wallet is rescanning.
+	rescans *rescanTracker
 }
 
 // ConnectStakepooldGRPC establishes a gRPC connection with all provided
 // stakepoold hosts. Returns an error if any host cannot be contacted,
-// has the wrong RPC version, or is otherwise mis-configured.
-func ConnectStakepooldGRPC(ctx context.Context, stakepooldHosts []string, stakepooldCerts []string) (*stakepooldManager, error) {
+// has the wrong RPC version, or is otherwise mis-configured.  Keepalive pings
+// are sent with the passed parameters unless their Time is zero, and the
+// state of each connection is logged and failed writes are retried until ctx
+// is done.  Messages of up to maxMessageSize bytes are received, and requests
+// are limited to the smaller of it and the size each host advertises.  Each
+// connection stops sending RPCs for a while after consecutive failures as
+// configured by breakerCfg.
+func ConnectStakepooldGRPC(ctx context.Context, stakepooldHosts []string, stakepooldCerts []string,
+	keepaliveParams keepalive.ClientParameters, maxMessageSize int,
+	breakerCfg BreakerConfig) (*stakepooldManager, error) {
 	conns := make([]*grpc.ClientConn, len(stakepooldHosts))
+	limits := make([]*msgSizeLimit, len(stakepooldHosts))
+	breakers := make([]*circuitBreaker, len(stakepooldHosts))
 	for serverID := range stakepooldHosts {
 		log.Infof("Attempting to connect to stakepoold gRPC %s using "+
 			"certificate located in %s", stakepooldHosts[serverID],
@@ -81,42 +110,128 @@ func ConnectStakepooldGRPC(ctx context.Context, stakepooldHosts []string, stakep
 		if err != nil {
 			return nil, err
 		}
-		conn, err := grpc.Dial(stakepooldHosts[serverID], grpc.WithTransportCredentials(creds))
-		if err != nil {
-			return nil, err
+		opts := []grpc.DialOption{
+			grpc.WithTransportCredentials(creds),
 		}
-		c := pb.NewVersionServiceClient(conn)
-
-		versionResponse, err := c.Version(ctx, &pb.VersionRequest{})
+		if keepaliveParams.Time > 0 {
+			opts = append(opts, grpc.WithKeepaliveParams(keepaliveParams))
+		}
+		breaker := newCircuitBreaker(stakepooldHosts[serverID], breakerCfg)
+		conn, limit, err := dialStakepoold(ctx, stakepooldHosts[serverID],
+			maxMessageSize, breaker, opts...)
 		if err != nil {
 			return nil, err
 		}
 
-		var semverResponse = semver{
-			major: versionResponse.Major,
-			minor: versionResponse.Minor,
-			patch: versionResponse.Patch,
-		}
+		log.Infof("Established connection to gRPC server %s, sending "+
+			"requests of up to %d bytes", stakepooldHosts[serverID],
+			limit.get())
+		conns[serverID] = conn
+		limits[serverID] = limit
+		breakers[serverID] = breaker
+		go watchConnState(ctx, conn)
+	}
 
-		if !semverCompatible(requiredStakepooldAPI, semverResponse) {
-			return nil, fmt.Errorf("Stakepoold gRPC server %s does not have "+
-				"a compatible API version. Advertises %v but require %v",
-				stakepooldHosts[serverID], versionResponse, requiredStakepooldAPI)
-		}
+	stats := make([]*readStats, len(conns))
+	for i := range stats {
+		stats[i] = new(readStats)
+	}
 
-		log.Infof("Established connection to gRPC server %s",
-			stakepooldHosts[serverID])
-		conns[serverID] = conn
+	s := &stakepooldManager{
+		grpcConnections: conns,
+		stats:           stats,
+		msgSizeLimits:   limits,
+		breakers:        breakers,
+		writes:          newWriteQueue(),
+		rescans:         newRescanTracker(),
 	}
+	go s.retryWrites(ctx)
+	go s.watchRescans(ctx)
 
-	return &stakepooldManager{grpcConnections: conns}, nil
+	return s, nil
+}
+
+// dialStakepoold connects to the stakepoold instance at host with the passed
+// dial options, and checks that it has a compatible API version.  The size of
+// the requests sent on the connection is limited as negotiated with the size
+// stakepoold advertises, and RPCs are rejected while breaker, unless nil, is
+// open.
+func dialStakepoold(ctx context.Context, host string, maxMessageSize int,
+	breaker *circuitBreaker, opts ...grpc.DialOption) (*grpc.ClientConn, *msgSizeLimit, error) {
+	limit := newMsgSizeLimit(maxMessageSize)
+	interceptors := []grpc.UnaryClientInterceptor{traceRPC}
+	if breaker != nil {
+		interceptors = append(interceptors, breaker.intercept)
+	}
+	interceptors = append(interceptors, limit.intercept)
+	opts = append(opts,
+		grpc.WithChainUnaryInterceptor(interceptors...),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMessageSize)))
+	conn, err := grpc.Dial(host, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := pb.NewVersionServiceClient(conn)
+
+	versionResponse, err := c.Version(ctx, &pb.VersionRequest{})
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	var semverResponse = semver{
+		major: versionResponse.Major,
+		minor: versionResponse.Minor,
+		patch: versionResponse.Patch,
+	}
+
+	if !semverCompatible(requiredStakepooldAPI, semverResponse) {
+		conn.Close()
+		return nil, nil, fmt.Errorf("Stakepoold gRPC server %s does not have "+
+			"a compatible API version. Advertises %v but require %v",
+			host, versionResponse, requiredStakepooldAPI)
+	}
+
+	limit.negotiate(maxMessageSize, versionResponse.MaxMessageSize)
+	return conn, limit, nil
+}
+
+// watchConnState logs the state changes of a stakepoold connection until ctx
+// is done.  A connection which broke while idle, e.g. a half-open connection
+// through a firewall detected by the keepalive pings, is logged as failed when
+// it is detected rather than when the next RPC fails.
+func watchConnState(ctx context.Context, conn *grpc.ClientConn) {
+	state := conn.GetState()
+	for conn.WaitForStateChange(ctx, state) {
+		newState := conn.GetState()
+		switch newState {
+		case connectivity.TransientFailure:
+			log.Warnf("Connection to stakepoold %s failed while %v, "+
+				"reconnecting", conn.Target(), state)
+		case connectivity.Ready:
+			log.Infof("Connection to stakepoold %s is ready", conn.Target())
+		default:
+			log.Debugf("Connection to stakepoold %s is %v", conn.Target(),
+				newState)
+		}
+		state = newState
+	}
 }
 
 // connected uses WalletInfo RPC to check that all stakepoold and
 // dcrwallet instances are currently online and reachable. Also
-// checks that dcrwallet is unlocked and connected to dcrd. This
-// should be performed before any write operations.
+// checks that dcrwallet is unlocked and connected to dcrd, that all
+// wallets have the same vote version, and that CrossCheckChainParams
+// did not find a mismatched stakepoold. This should be performed
+// before any write operations.
 func (s *stakepooldManager) connected(ctx context.Context) error {
+	s.chainParamsMismatchMtx.Lock()
+	mismatch := s.chainParamsMismatch
+	s.chainParamsMismatchMtx.Unlock()
+	if mismatch != nil {
+		return mismatch
+	}
+
 	responses, err := s.WalletInfo(ctx)
 	if err != nil {
 		return err
@@ -129,6 +244,11 @@ func (s *stakepooldManager) connected(ctx context.Context) error {
 		if !resp.Unlocked {
 			return fmt.Errorf("wallet[%d] is not unlocked", i)
 		}
+		if resp.VoteVersion != responses[0].VoteVersion {
+			return fmt.Errorf("wallets 0 and %d have mismatched vote "+
+				"versions %d and %d", i, responses[0].VoteVersion,
+				resp.VoteVersion)
+		}
 	}
 
 	return nil
@@ -176,6 +296,56 @@ func (s *stakepooldManager) GetIgnoredLowFeeTickets(ctx context.Context) (map[ch
 	return nil, errors.New("GetIgnoredLowFeeTickets RPC failed on all stakepoold instances")
 }
 
+// GetIgnoredLowFeeTicketsPage performs gRPC GetIgnoredLowFeeTickets requests
+// for up to limit ignored low fee tickets from offset in the order of their
+// hashes against all stakepoold instances, and returns the first page fetched
+// without errors along with the number of ignored tickets.  A limit of 0
+// only returns the number.  Returns an error if all RPC requests fail.
+func (s *stakepooldManager) GetIgnoredLowFeeTicketsPage(ctx context.Context, offset, limit int) (map[chainhash.Hash]string, int, error) {
+	req := &pb.GetIgnoredLowFeeTicketsRequest{
+		Offset:    uint32(offset),
+		Limit:     uint32(limit),
+		CountOnly: limit == 0,
+	}
+	for _, conn := range s.grpcConnections {
+		client := pb.NewStakepooldServiceClient(conn)
+		resp, err := client.GetIgnoredLowFeeTickets(ctx, req)
+		if err != nil {
+			log.Warnf("GetIgnoredLowFeeTickets RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			continue
+		}
+
+		// Instances which predate paging ignore the request fields and
+		// return all tickets without a total.
+		tickets, total := resp.Tickets, int(resp.Total)
+		if total == 0 && len(tickets) > 0 {
+			total = len(tickets)
+			tickets = ticketPage(tickets, offset, limit)
+		}
+		return processTicketsResponse(tickets), total, nil
+	}
+
+	// All RPC requests failed
+	return nil, 0, errors.New("GetIgnoredLowFeeTickets RPC failed on all stakepoold instances")
+}
+
+// ticketPage returns up to limit of tickets from offset in the order of their
+// hashes.
+func ticketPage(tickets []*pb.Ticket, offset, limit int) []*pb.Ticket {
+	sort.Slice(tickets, func(i, j int) bool {
+		return bytes.Compare(tickets[i].Hash, tickets[j].Hash) < 0
+	})
+
+	if offset > len(tickets) {
+		offset = len(tickets)
+	}
+	end := offset + limit
+	if end > len(tickets) {
+		end = len(tickets)
+	}
+	return tickets[offset:end]
+}
+
 // GetLiveTickets performs gRPC GetLiveTickets
 // requests against all stakepoold instances and returns the first result fetched
 // without errors. Returns an error if all RPC requests fail.
@@ -197,6 +367,110 @@ func (s *stakepooldManager) GetLiveTickets(ctx context.Context) (map[chainhash.H
 	return nil, errors.New("GetLiveTickets RPC failed on all stakepoold instances")
 }
 
+// GetLiveTicketCounts returns the number of live tickets of each multisig
+// address from the first stakepoold instance to respond.
+func (s *stakepooldManager) GetLiveTicketCounts(ctx context.Context) (map[string]uint32, error) {
+	for _, i := range s.readOrder() {
+		conn := s.grpcConnections[i]
+		client := pb.NewStakepooldServiceClient(conn)
+		start := time.Now()
+		resp, err := client.GetLiveTicketCounts(ctx, &pb.GetLiveTicketCountsRequest{})
+		s.stats[i].observe(time.Since(start), err)
+		if err != nil {
+			log.Warnf("GetLiveTicketCounts RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			continue
+		}
+
+		counts := make(map[string]uint32, len(resp.Counts))
+		for _, c := range resp.Counts {
+			counts[c.MultiSigAddress] = c.Count
+		}
+		return counts, nil
+	}
+
+	// All RPC requests failed
+	return nil, errors.New("GetLiveTicketCounts RPC failed on all stakepoold instances")
+}
+
+// GetTicketAmounts returns the amounts of the passed tickets and votes, which
+// never change once mined, from the first stakepoold instance to respond to
+// each request.  Hashes are sent in batches of at most ticketAmountsBatchSize,
+// the limit of the RPC.  Tickets and votes unknown to the wallet are omitted.
+func (s *stakepooldManager) GetTicketAmounts(ctx context.Context, hashes []chainhash.Hash) (map[chainhash.Hash]*pb.TicketAmounts, error) {
+	amounts := make(map[chainhash.Hash]*pb.TicketAmounts, len(hashes))
+	for len(hashes) > 0 {
+		n := len(hashes)
+		if n > ticketAmountsBatchSize {
+			n = ticketAmountsBatchSize
+		}
+		req := &pb.GetTicketAmountsRequest{Hashes: make([][]byte, 0, n)}
+		for i := range hashes[:n] {
+			req.Hashes = append(req.Hashes, hashes[i].CloneBytes())
+		}
+		hashes = hashes[n:]
+
+		var resp *pb.GetTicketAmountsResponse
+		for _, i := range s.readOrder() {
+			conn := s.grpcConnections[i]
+			client := pb.NewStakepooldServiceClient(conn)
+			start := time.Now()
+			var err error
+			resp, err = client.GetTicketAmounts(ctx, req)
+			s.stats[i].observe(time.Since(start), err)
+			if err != nil {
+				log.Warnf("GetTicketAmounts RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+				continue
+			}
+			break
+		}
+		if resp == nil {
+			// All RPC requests failed
+			return nil, errors.New("GetTicketAmounts RPC failed on all stakepoold instances")
+		}
+
+		for _, a := range resp.Amounts {
+			hash, err := chainhash.NewHash(a.Hash)
+			if err != nil {
+				log.Warnf("NewHash failed for %v: %v", a.Hash, err)
+				continue
+			}
+			amounts[*hash] = a
+		}
+	}
+
+	return amounts, nil
+}
+
+// LookupTickets returns the tickets which are one of the passed tickets or
+// belong to one of the passed multisig addresses, from the first stakepoold
+// instance to respond, along with the set of tickets each was found in.  A
+// ticket in several sets is returned once for each of them.
+func (s *stakepooldManager) LookupTickets(ctx context.Context, tickets []chainhash.Hash, msas []string) ([]*pb.TicketLookup, error) {
+	req := &pb.LookupTicketsRequest{
+		Tickets:           make([][]byte, 0, len(tickets)),
+		MultiSigAddresses: msas,
+	}
+	for i := range tickets {
+		req.Tickets = append(req.Tickets, tickets[i].CloneBytes())
+	}
+
+	for _, i := range s.readOrder() {
+		conn := s.grpcConnections[i]
+		client := pb.NewStakepooldServiceClient(conn)
+		start := time.Now()
+		resp, err := client.LookupTickets(ctx, req)
+		s.stats[i].observe(time.Since(start), err)
+		if err != nil {
+			log.Warnf("LookupTickets RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			continue
+		}
+		return resp.Tickets, nil
+	}
+
+	// All RPC requests failed
+	return nil, errors.New("LookupTickets RPC failed on all stakepoold instances")
+}
+
 func processTicketsResponse(tickets []*pb.Ticket) map[chainhash.Hash]string {
 	processedTickets := make(map[chainhash.Hash]string)
 	for _, ticket := range tickets {
@@ -211,6 +485,78 @@ func processTicketsResponse(tickets []*pb.Ticket) map[chainhash.Hash]string {
 	return processedTickets
 }
 
+// GetLowFeeReview performs gRPC GetLowFeeReview requests against all
+// stakepoold instances.  Automatic classification of low fee tickets is
+// reported as paused when it is paused on any instance, and the tickets held
+// by all instances are returned.  Returns an error if all RPC requests fail.
+func (s *stakepooldManager) GetLowFeeReview(ctx context.Context) (bool, map[chainhash.Hash]string, error) {
+	var paused, ok bool
+	held := make(map[chainhash.Hash]string)
+	for _, conn := range s.grpcConnections {
+		client := pb.NewStakepooldServiceClient(conn)
+		resp, err := client.GetLowFeeReview(ctx, &pb.GetLowFeeReviewRequest{})
+		if err != nil {
+			log.Warnf("GetLowFeeReview RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			continue
+		}
+		ok = true
+		paused = paused || resp.Paused
+		for hash, msa := range processTicketsResponse(resp.Tickets) {
+			held[hash] = msa
+		}
+	}
+
+	if !ok {
+		return false, nil, errors.New("GetLowFeeReview RPC failed on all stakepoold instances")
+	}
+	return paused, held, nil
+}
+
+// ResumeLowFeeClassification calls ResumeLowFeeClassification RPC on all
+// stakepoold instances. It stops executing and returns an error if any RPC
+// call fails.
+func (s *stakepooldManager) ResumeLowFeeClassification(ctx context.Context) error {
+	if err := s.connected(ctx); err != nil {
+		log.Errorf("ResumeLowFeeClassification: stakepoold failed connectivity check: %v", err)
+		return err
+	}
+
+	for _, conn := range s.grpcConnections {
+		client := pb.NewStakepooldServiceClient(conn)
+		resp, err := client.ResumeLowFeeClassification(ctx, &pb.ResumeLowFeeClassificationRequest{})
+		if err != nil {
+			log.Errorf("ResumeLowFeeClassification RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			return err
+		}
+		log.Infof("stakepoold %s released %d held low fee tickets", conn.Target(), resp.Released)
+	}
+
+	return nil
+}
+
+// PromoteStandby calls PromoteStandby RPC on the stakepoold instance at host,
+// making it vote if it is a warm standby.  It returns whether the instance was
+// in standby.
+func (s *stakepooldManager) PromoteStandby(ctx context.Context, host string) (bool, error) {
+	for _, conn := range s.grpcConnections {
+		if conn.Target() != host {
+			continue
+		}
+		client := pb.NewStakepooldServiceClient(conn)
+		resp, err := client.PromoteStandby(ctx, &pb.PromoteStandbyRequest{})
+		if err != nil {
+			log.Errorf("PromoteStandby RPC failed on stakepoold instance %s: %v", host, err)
+			return false, err
+		}
+		if resp.WasStandby {
+			log.Infof("stakepoold %s promoted from standby to active", host)
+		}
+		return resp.WasStandby, nil
+	}
+
+	return false, fmt.Errorf("unknown stakepoold instance %s", host)
+}
+
 // SetAddedLowFeeTickets calls SetAddedLowFeeTickets RPC on all stakepoold instances. It stops
 // executing and returns an error if any RPC call fails
 func (s *stakepooldManager) SetAddedLowFeeTickets(ctx context.Context, dbTickets []models.LowFeeTicket) error {
@@ -287,8 +633,9 @@ func (s *stakepooldManager) CreateMultisig(ctx context.Context, address []string
 }
 
 // SyncAll ensures that the wallet servers are all in sync with each
-// other in terms of tickets, redeem scripts and address indexes.
-func (s *stakepooldManager) SyncAll(ctx context.Context, multiSigScripts []models.User, maxUsers int64) error {
+// other in terms of tickets, redeem scripts and address indexes.  The ticket
+// addresses of users are derived from branch of the voting account.
+func (s *stakepooldManager) SyncAll(ctx context.Context, multiSigScripts []models.User, account string, branch uint32, maxUsers int64) error {
 	if err := s.connected(ctx); err != nil {
 		log.Errorf("SyncAll: stakepoold failed connectivity check: %v", err)
 		return err
@@ -296,7 +643,7 @@ func (s *stakepooldManager) SyncAll(ctx context.Context, multiSigScripts []model
 
 	// Set watched address indexes to maxUsers so all generated ticket
 	// addresses show as 'ismine'.
-	err := s.syncWatchedAddresses(ctx, defaultAccountName, helpers.ExternalBranch, maxUsers)
+	err := s.syncWatchedAddresses(ctx, account, branch, maxUsers)
 	if err != nil {
 		return err
 	}
@@ -492,9 +839,12 @@ func (s *stakepooldManager) StakePoolUserInfo(ctx context.Context, multiSigAddre
 		MultiSigAddress: multiSigAddress,
 	}
 
-	for _, conn := range s.grpcConnections {
+	for _, i := range s.readOrder() {
+		conn := s.grpcConnections[i]
 		client := pb.NewStakepooldServiceClient(conn)
+		start := time.Now()
 		response, err := client.StakePoolUserInfo(ctx, request)
+		s.stats[i].observe(time.Since(start), err)
 		if err != nil {
 			log.Warnf("StakePoolUserInfo RPC failed on stakepoold instance %s: %v", conn.Target(), err)
 			continue
@@ -507,8 +857,60 @@ func (s *stakepooldManager) StakePoolUserInfo(ctx context.Context, multiSigAddre
 	return nil, errors.New("StakePoolUserInfo RPC failed on all stakepoold instances")
 }
 
-// SetUserVotingPrefs performs gRPC SetUserVotingPrefs. It stops
-// executing and returns an error if any RPC call fails
+// BatchStakePoolUserInfo performs gRPC BatchStakePoolUserInfo and returns the
+// user info of the passed multisig addresses, from the first stakepoold
+// instance to respond to each request.  Addresses are sent in batches of at
+// most userInfoBatchSize, the limit of the RPC.  Addresses whose info could
+// not be retrieved by stakepoold are logged and omitted.
+func (s *stakepooldManager) BatchStakePoolUserInfo(ctx context.Context, multiSigAddresses []string) (map[string]*pb.StakePoolUserInfoResponse, error) {
+	infos := make(map[string]*pb.StakePoolUserInfoResponse, len(multiSigAddresses))
+	for len(multiSigAddresses) > 0 {
+		n := len(multiSigAddresses)
+		if n > userInfoBatchSize {
+			n = userInfoBatchSize
+		}
+		req := &pb.BatchStakePoolUserInfoRequest{
+			MultiSigAddresses: multiSigAddresses[:n],
+		}
+		multiSigAddresses = multiSigAddresses[n:]
+
+		var resp *pb.BatchStakePoolUserInfoResponse
+		for _, i := range s.readOrder() {
+			conn := s.grpcConnections[i]
+			client := pb.NewStakepooldServiceClient(conn)
+			start := time.Now()
+			var err error
+			resp, err = client.BatchStakePoolUserInfo(ctx, req)
+			s.stats[i].observe(time.Since(start), err)
+			if err != nil {
+				log.Warnf("BatchStakePoolUserInfo RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+				continue
+			}
+			break
+		}
+		if resp == nil {
+			// All RPC requests failed
+			return nil, errors.New("BatchStakePoolUserInfo RPC failed on all stakepoold instances")
+		}
+
+		for _, r := range resp.Results {
+			if r.Error != "" || r.Info == nil {
+				log.Warnf("BatchStakePoolUserInfo failed for %s: %s",
+					r.MultiSigAddress, r.Error)
+				continue
+			}
+			infos[r.MultiSigAddress] = r.Info
+		}
+	}
+
+	return infos, nil
+}
+
+// SetUserVotingPrefs performs gRPC SetUserVotingPrefs on all stakepoold
+// instances once all of them passed the connectivity check.  The voting
+// preferences are retried in the background on the instances they failed on,
+// which are described by the returned *manager.PartialWriteError, until they
+// succeed or newer preferences are set.
 func (s *stakepooldManager) SetUserVotingPrefs(ctx context.Context, dbUsers map[int64]*models.User) error {
 	if err := s.connected(ctx); err != nil {
 		log.Errorf("SetUserVotingPrefs: stakepoold failed connectivity check: %v", err)
@@ -529,13 +931,13 @@ func (s *stakepooldManager) SetUserVotingPrefs(ctx context.Context, dbUsers map[
 		UserVotingConfig: users,
 	}
 
-	for _, conn := range s.grpcConnections {
-		client := pb.NewStakepooldServiceClient(conn)
-		_, err := client.SetUserVotingPrefs(ctx, setVotingConfigReq)
-		if err != nil {
-			log.Errorf("SetUserVotingPrefs RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+	err := s.applyAll(ctx, "SetUserVotingPrefs", "SetUserVotingPrefs",
+		func(ctx context.Context, client pb.StakepooldServiceClient) error {
+			_, err := client.SetUserVotingPrefs(ctx, setVotingConfigReq)
 			return err
-		}
+		})
+	if err != nil {
+		return err
 	}
 
 	log.Info("SetUserVotingPrefs successful on all stakepoold instances")
@@ -543,7 +945,8 @@ func (s *stakepooldManager) SetUserVotingPrefs(ctx context.Context, dbUsers map[
 }
 
 // WalletInfo calls WalletInfo RPC on all stakepoold instances. It stops
-// executing and returns an error if any RPC call fails
+// executing and returns an error if any RPC call fails.  Whether the wallets
+// are rescanning is recorded from the responses.
 func (s *stakepooldManager) WalletInfo(ctx context.Context) ([]*pb.WalletInfoResponse, error) {
 	responses := make([]*pb.WalletInfoResponse, len(s.grpcConnections))
 
@@ -554,6 +957,7 @@ func (s *stakepooldManager) WalletInfo(ctx context.Context) ([]*pb.WalletInfoRes
 			log.Errorf("WalletInfo RPC failed on stakepoold instance %s: %v", conn.Target(), err)
 			return nil, err
 		}
+		s.noteRescan(conn.Target(), resp)
 		responses[i] = resp
 	}
 
@@ -603,8 +1007,11 @@ func (s *stakepooldManager) ValidateAddress(ctx context.Context, addr dcrutil.Ad
 	return lastResponse, nil
 }
 
-// ImportNewScript calls ImportNewScript RPC on all stakepoold instances. It stops
-// executing and returns an error if any RPC call fails.
+// ImportNewScript calls ImportNewScript RPC on all stakepoold instances once
+// all of them passed the connectivity check.  The import is retried in the
+// background on the instances it failed on, which are described by the
+// returned *manager.PartialWriteError along with the height of the imports
+// which succeeded, or -1 when none did.
 // Because this is a new script, no rescan is necessary.
 func (s *stakepooldManager) ImportNewScript(ctx context.Context, script []byte) (heightImported int64, err error) {
 	if err := s.connected(ctx); err != nil {
@@ -616,40 +1023,157 @@ func (s *stakepooldManager) ImportNewScript(ctx context.Context, script []byte)
 		Script: script,
 	}
 
+	var mtx sync.Mutex
+	heightImported = -1
+	err = s.applyAll(ctx, "ImportNewScript",
+		"ImportNewScript "+hex.EncodeToString(script),
+		func(ctx context.Context, client pb.StakepooldServiceClient) error {
+			resp, err := client.ImportNewScript(ctx, req)
+			if err != nil {
+				return err
+			}
+			mtx.Lock()
+			if resp.HeightImported > heightImported {
+				heightImported = resp.HeightImported
+			}
+			mtx.Unlock()
+			return nil
+		})
+	if err != nil {
+		return heightImported, err
+	}
+
+	log.Info("ImportNewScript successful on all stakepoold instances")
+	return heightImported, nil
+}
+
+// ImportNewScriptOn calls ImportNewScript RPC on the stakepoold instance host
+// only, so that an import which failed on some instances can be resumed
+// without importing the script again into the others.  It fails with
+// manager.ErrWalletRescanning while the wallet of host is rescanning.
+func (s *stakepooldManager) ImportNewScriptOn(ctx context.Context, host string, script []byte) (int64, error) {
+	s.chainParamsMismatchMtx.Lock()
+	mismatch := s.chainParamsMismatch
+	s.chainParamsMismatchMtx.Unlock()
+	if mismatch != nil {
+		return -1, mismatch
+	}
+	if s.rescans.rescanning(host) {
+		return -1, manager.ErrWalletRescanning
+	}
+
 	for _, conn := range s.grpcConnections {
+		if conn.Target() != host {
+			continue
+		}
 		client := pb.NewStakepooldServiceClient(conn)
-		resp, err := client.ImportNewScript(ctx, req)
+		resp, err := client.ImportNewScript(ctx, &pb.ImportNewScriptRequest{
+			Script: script,
+		})
 		if err != nil {
-			log.Errorf("ImportNewScript RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			log.Errorf("ImportNewScript RPC failed on stakepoold instance %s: %v", host, err)
 			return -1, err
 		}
-		heightImported = resp.HeightImported
+		return resp.HeightImported, nil
 	}
 
-	log.Info("ImportNewScript successful on all stakepoold instances")
-	return heightImported, err
+	return -1, fmt.Errorf("unknown stakepoold instance %s", host)
 }
 
-// BackendStatus provides a summary of a single back-end server
-type BackendStatus struct {
-	Host      string
-	RPCStatus string
-	*WalletStatus
+// ImportHistoricScript calls ImportMissingScripts RPC on all stakepoold
+// instances once all of them passed the connectivity check, importing script
+// and rescanning from rescanHeight so that the wallets find the tickets it
+// had before.  Like ImportNewScript, the import is retried in the background
+// on the instances it failed on.  The rescan continues after the RPC returned.
+func (s *stakepooldManager) ImportHistoricScript(ctx context.Context, script []byte, rescanHeight int64) error {
+	if err := s.connected(ctx); err != nil {
+		log.Errorf("ImportHistoricScript: stakepoold failed connectivity check: %v", err)
+		return err
+	}
+
+	req := &pb.ImportMissingScriptsRequest{
+		Scripts:      [][]byte{script},
+		RescanHeight: rescanHeight,
+	}
+	err := s.applyAll(ctx, "ImportHistoricScript",
+		"ImportHistoricScript "+hex.EncodeToString(script),
+		func(ctx context.Context, client pb.StakepooldServiceClient) error {
+			_, err := client.ImportMissingScripts(ctx, req)
+			return err
+		})
+	if err != nil {
+		return err
+	}
+
+	log.Info("ImportHistoricScript successful on all stakepoold instances")
+	return nil
 }
 
-// WalletStatus holds information about a dcrwallet.
-type WalletStatus struct {
-	DaemonConnected bool
-	VoteVersion     uint32
-	Unlocked        bool
-	Voting          bool
+// Hosts returns the addresses of the stakepoold instances.
+func (s *stakepooldManager) Hosts() []string {
+	hosts := make([]string, 0, len(s.grpcConnections))
+	for _, conn := range s.grpcConnections {
+		hosts = append(hosts, conn.Target())
+	}
+	return hosts
+}
+
+// ExistsAddress calls ExistsAddress RPC on all stakepoold instances until
+// receiving a response. Returns an error if all RPC calls fail.
+func (s *stakepooldManager) ExistsAddress(ctx context.Context, addr dcrutil.Address) (bool, error) {
+	req := &pb.ExistsAddressRequest{
+		Address: addr.Address(),
+	}
+
+	for _, conn := range s.grpcConnections {
+		client := pb.NewStakepooldServiceClient(conn)
+		resp, err := client.ExistsAddress(ctx, req)
+		if err != nil {
+			log.Warnf("ExistsAddress RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			continue
+		}
+		return resp.Exists, nil
+	}
+	return false, errors.New("ExistsAddress RPC failed on all stakepoold instances")
+}
+
+// VerifyMessage calls VerifyMessage RPC on the stakepoold instances in read
+// order until receiving a response. Returns an error if all RPC calls fail.
+func (s *stakepooldManager) VerifyMessage(ctx context.Context, addr dcrutil.Address, message, signature string) (bool, error) {
+	req := &pb.VerifyMessageRequest{
+		Address:   addr.Address(),
+		Message:   message,
+		Signature: signature,
+	}
+
+	for _, i := range s.readOrder() {
+		conn := s.grpcConnections[i]
+		client := pb.NewStakepooldServiceClient(conn)
+		start := time.Now()
+		resp, err := client.VerifyMessage(ctx, req)
+		s.stats[i].observe(time.Since(start), err)
+		if err != nil {
+			log.Warnf("VerifyMessage RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			continue
+		}
+		return resp.Valid, nil
+	}
+	return false, errors.New("VerifyMessage RPC failed on all stakepoold instances")
+}
+
+// clockSkew returns how far serverTime, the time of a server when it answered
+// a request sent at sent whose response was received at received, is ahead of
+// the local clock.  The server is assumed to answer halfway through the round
+// trip, so the result is accurate to half of it.
+func clockSkew(sent, received, serverTime time.Time) time.Duration {
+	return serverTime.Sub(sent.Add(received.Sub(sent) / 2))
 }
 
 // BackendStatus uses the state of each RPC connection and the
 // WalletInfo RPC to return a summary of the state of each
 // connected back-end server.
-func (s *stakepooldManager) BackendStatus(ctx context.Context) []BackendStatus {
-	stakepooldPageInfo := make([]BackendStatus, len(s.grpcConnections))
+func (s *stakepooldManager) BackendStatus(ctx context.Context) []manager.BackendStatus {
+	stakepooldPageInfo := make([]manager.BackendStatus, len(s.grpcConnections))
 
 	for i, conn := range s.grpcConnections {
 		stakepooldPageInfo[i].Host = conn.Target()
@@ -668,24 +1192,125 @@ func (s *stakepooldManager) BackendStatus(ctx context.Context) []BackendStatus {
 			stakepooldPageInfo[i].RPCStatus = "TransientFailure"
 		}
 
+		latency, errorRate, _ := s.stats[i].snapshot()
+		stakepooldPageInfo[i].ReadLatency = latency
+		stakepooldPageInfo[i].ReadErrorRate = errorRate
+		stakepooldPageInfo[i].PendingWrites = s.writes.pending(conn.Target())
+		stakepooldPageInfo[i].MaxMessageSize = s.msgSizeLimits[i].get()
+		stakepooldPageInfo[i].Breaker, stakepooldPageInfo[i].BreakerEvents =
+			s.breaker(i).snapshot()
+
 		client := pb.NewStakepooldServiceClient(conn)
 		req := &pb.WalletInfoRequest{}
+		sent := time.Now()
 		resp, err := client.WalletInfo(ctx, req)
+		received := time.Now()
 		if err != nil {
 			log.Warnf("BackendStatus: WalletInfo RPC failed on stakepoold instance %s: %v", conn.Target(), err)
 		} else {
-			stakepooldPageInfo[i].WalletStatus = &WalletStatus{
+			stakepooldPageInfo[i].WalletStatus = &manager.WalletStatus{
 				DaemonConnected: resp.DaemonConnected,
 				VoteVersion:     resp.VoteVersion,
 				Unlocked:        resp.Unlocked,
 				Voting:          resp.Voting,
+				Standby:         resp.Standby,
+				InvalidVoteBits: resp.InvalidVoteBits,
+				Rescanning:      resp.Rescanning,
+				// dcrd reports the offset to add to its clock
+				// to get the time of its peers.
+				WalletTimeOffset: -time.Duration(resp.WalletTimeOffset) * time.Second,
+				// Every wallet has a transaction fee rate, so
+				// it is only unset by older instances.
+				FeesKnown:        resp.TxFee != 0,
+				TxFee:            resp.TxFee,
+				TicketFee:        resp.TicketFee,
+				TicketPurchasing: resp.TicketPurchasing,
+			}
+			if resp.ServerTime != 0 {
+				stakepooldPageInfo[i].ClockSkewKnown = true
+				stakepooldPageInfo[i].ClockSkew = clockSkew(sent, received,
+					time.Unix(0, resp.ServerTime))
+			}
+			if resp.Rescanning {
+				stakepooldPageInfo[i].RescanFromHeight = resp.RescanFromHeight
+				stakepooldPageInfo[i].RescanStarted = time.Unix(resp.RescanStarted, 0)
 			}
+			s.noteRescan(conn.Target(), resp)
 		}
 	}
 
 	return stakepooldPageInfo
 }
 
+// SyncStatus uses the ListImportedAddresses and GetTickets RPCs to compare
+// the redeem scripts and tickets of each stakepoold instance with the passed
+// multisig addresses of the users in the database and with the other
+// instances.  Instances which cannot be queried have Err set and are left out
+// of the comparison of tickets.
+func (s *stakepooldManager) SyncStatus(ctx context.Context, multiSigAddresses []string) []manager.SyncStatus {
+	statuses := make([]manager.SyncStatus, len(s.grpcConnections))
+	addressesPerServer := make([]map[string]struct{}, len(s.grpcConnections))
+	ticketsPerServer := make([]map[string]struct{}, len(s.grpcConnections))
+
+	for i, conn := range s.grpcConnections {
+		statuses[i].Host = conn.Target()
+		client := pb.NewStakepooldServiceClient(conn)
+
+		addrResp, err := client.ListImportedAddresses(ctx, &pb.ListImportedAddressesRequest{})
+		if err != nil {
+			log.Warnf("SyncStatus: ListImportedAddresses RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			statuses[i].Err = err
+			continue
+		}
+		ticketResp, err := client.GetTickets(ctx, &pb.GetTicketsRequest{IncludeImmature: true})
+		if err != nil {
+			log.Warnf("SyncStatus: GetTickets RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			statuses[i].Err = err
+			continue
+		}
+
+		addressesPerServer[i] = make(map[string]struct{}, len(addrResp.Addresses))
+		for _, address := range addrResp.Addresses {
+			addressesPerServer[i][address] = struct{}{}
+		}
+		ticketsPerServer[i] = make(map[string]struct{}, len(ticketResp.Tickets))
+		for _, ticketHash := range ticketResp.Tickets {
+			ticketsPerServer[i][string(ticketHash)] = struct{}{}
+		}
+	}
+
+	compareSyncStatus(statuses, multiSigAddresses, addressesPerServer,
+		ticketsPerServer)
+	return statuses
+}
+
+// compareSyncStatus sets the counts of statuses from the imported addresses
+// and the tickets of each instance, which are nil for the instances which
+// could not be queried.
+func compareSyncStatus(statuses []manager.SyncStatus, multiSigAddresses []string,
+	addressesPerServer, ticketsPerServer []map[string]struct{}) {
+	allTickets := make(map[string]struct{})
+	for _, tickets := range ticketsPerServer {
+		for ticketHash := range tickets {
+			allTickets[ticketHash] = struct{}{}
+		}
+	}
+
+	for i := range statuses {
+		if addressesPerServer[i] == nil {
+			continue
+		}
+		statuses[i].Scripts = len(addressesPerServer[i])
+		for _, address := range multiSigAddresses {
+			if _, ok := addressesPerServer[i][address]; !ok {
+				statuses[i].MissingScripts++
+			}
+		}
+		statuses[i].Tickets = len(ticketsPerServer[i])
+		statuses[i].MissingTickets = len(allTickets) - len(ticketsPerServer[i])
+	}
+}
+
 // GetStakeInfo returns cached stake info if within cachedStakeInfoTimer limit
 // from last cache. Otherwise it calls GetStakeInfo RPC on all stakepoold
 // instances until receiving a response. The response is cached. Returns an
@@ -699,20 +1324,115 @@ func (s *stakepooldManager) GetStakeInfo(ctx context.Context) (*pb.GetStakeInfoR
 		return s.cachedStakeInfo, nil
 	}
 
-	for _, conn := range s.grpcConnections {
+	for _, i := range s.readOrder() {
+		conn := s.grpcConnections[i]
 		client := pb.NewStakepooldServiceClient(conn)
+		start := time.Now()
 		resp, err := client.GetStakeInfo(ctx, &pb.GetStakeInfoRequest{})
+		s.stats[i].observe(time.Since(start), err)
 		if err != nil {
 			log.Warnf("GetStakeInfo RPC failed on stakepoold instance %s: %v", conn.Target(), err)
 			continue
 		}
 		s.cachedStakeInfo = resp
 		s.cachedStakeInfoTimer = now.Add(cacheTimerStakeInfo)
+		s.cachedStakeInfoFetched = now
 		return resp, nil
 	}
 	return nil, errors.New("GetStakeInfo RPC failed on all stakepoold instances")
 }
 
+// InvalidateStakeInfo expires the cached stake info when it was computed
+// before the block at bestHeight, so that the next GetStakeInfo call updates
+// it.  The cache is still kept for at least minStakeInfoRefresh after it was
+// updated.
+func (s *stakepooldManager) InvalidateStakeInfo(bestHeight int64) {
+	s.cachedStakeInfoMutex.Lock()
+	defer s.cachedStakeInfoMutex.Unlock()
+
+	if s.cachedStakeInfo == nil || s.cachedStakeInfo.BlockHeight >= bestHeight {
+		return
+	}
+	expiry := s.cachedStakeInfoFetched.Add(minStakeInfoRefresh)
+	if expiry.Before(s.cachedStakeInfoTimer) {
+		s.cachedStakeInfoTimer = expiry
+	}
+}
+
+// GetBestBlock calls GetBestBlock RPC on the stakepoold instances in read
+// order until receiving a response. Returns an error if all RPC calls fail.
+func (s *stakepooldManager) GetBestBlock(ctx context.Context) (*chainhash.Hash, int64, error) {
+	for _, i := range s.readOrder() {
+		conn := s.grpcConnections[i]
+		client := pb.NewStakepooldServiceClient(conn)
+		start := time.Now()
+		resp, err := client.GetBestBlock(ctx, &pb.GetBestBlockRequest{})
+		s.stats[i].observe(time.Since(start), err)
+		if err != nil {
+			log.Warnf("GetBestBlock RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			continue
+		}
+		hash, err := chainhash.NewHash(resp.Hash)
+		if err != nil {
+			log.Warnf("GetBestBlock RPC on stakepoold instance %s returned "+
+				"an invalid hash: %v", conn.Target(), err)
+			continue
+		}
+		return hash, resp.Height, nil
+	}
+	return nil, 0, errors.New("GetBestBlock RPC failed on all stakepoold instances")
+}
+
+// SendRawTransaction calls SendRawTransaction RPC on the stakepoold instances
+// until one of them broadcasts tx.  Returns the error of the last instance if
+// all RPC calls fail.
+func (s *stakepooldManager) SendRawTransaction(ctx context.Context, tx []byte) (*chainhash.Hash, error) {
+	req := &pb.SendRawTransactionRequest{Tx: tx}
+
+	err := errors.New("no stakepoold instances")
+	for _, conn := range s.grpcConnections {
+		client := pb.NewStakepooldServiceClient(conn)
+		var resp *pb.SendRawTransactionResponse
+		resp, err = client.SendRawTransaction(ctx, req)
+		if err != nil {
+			log.Warnf("SendRawTransaction RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			continue
+		}
+		return chainhash.NewHash(resp.Hash)
+	}
+	return nil, fmt.Errorf("SendRawTransaction RPC failed on all stakepoold instances: %v", err)
+}
+
+// GetTransactionConfirmations calls GetTransactionConfirmations RPC on the
+// stakepoold instances in read order until one of them knows the transaction.
+// Returns manager.ErrTxNotFound if none of them knows it.
+func (s *stakepooldManager) GetTransactionConfirmations(ctx context.Context, hash *chainhash.Hash) (int64, error) {
+	req := &pb.GetTransactionConfirmationsRequest{Hash: hash[:]}
+
+	notFound := false
+	for _, i := range s.readOrder() {
+		conn := s.grpcConnections[i]
+		client := pb.NewStakepooldServiceClient(conn)
+		start := time.Now()
+		resp, err := client.GetTransactionConfirmations(ctx, req)
+		if status.Code(err) == codes.NotFound {
+			s.stats[i].observe(time.Since(start), nil)
+			notFound = true
+			continue
+		}
+		s.stats[i].observe(time.Since(start), err)
+		if err != nil {
+			log.Warnf("GetTransactionConfirmations RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+			continue
+		}
+		return resp.Confirmations, nil
+	}
+	if notFound {
+		return 0, manager.ErrTxNotFound
+	}
+	return 0, errors.New("GetTransactionConfirmations RPC failed on all stakepoold instances")
+}
+
 // CrossCheckColdWalletExtPubs calls GetColdWalletExtPub RPC on all stakepoold
 // instances and compares the returned `coldwalletextpub` value against the
 // value set in dcrstakepool's config.
@@ -731,3 +1451,83 @@ func (s *stakepooldManager) CrossCheckColdWalletExtPubs(ctx context.Context, dcr
 	}
 	return nil
 }
+
+// CrossCheckVotingAccount calls GetAccountExtPub RPC on all stakepoold
+// instances and compares the extended public key of account in their voting
+// wallets against the `votingwalletextpub` value set in dcrstakepool's config.
+// Returns an error if an RPC call to any of the backend clients errors, e.g.
+// because the account does not exist, or if any returned extended public key
+// is not the same as dcrstakepool's.
+func (s *stakepooldManager) CrossCheckVotingAccount(ctx context.Context, account string, votingWalletExtPub string) error {
+	for _, conn := range s.grpcConnections {
+		client := pb.NewStakepooldServiceClient(conn)
+		resp, err := client.GetAccountExtPub(ctx, &pb.GetAccountExtPubRequest{
+			Account: account,
+		})
+		if err != nil {
+			return fmt.Errorf("GetAccountExtPub RPC for account %q failed "+
+				"on stakepoold instance %s: %v", account, conn.Target(), err)
+		}
+		if resp.ExtPub != votingWalletExtPub {
+			return fmt.Errorf("votingwalletextpub is not the extended "+
+				"public key of account %q of the voting wallet of "+
+				"stakepoold instance %s", account, conn.Target())
+		}
+	}
+	return nil
+}
+
+// checkChainParams returns an error describing how the chain parameters, pool
+// fees and fee mode reported by a stakepoold instance do not match those of
+// dcrstakepool.  Lower pool fees than dcrstakepool's are compatible since
+// tickets paying the advertised fees also pay them.
+func checkChainParams(resp *pb.GetChainParamsResponse, params *chaincfg.Params, poolFees float64, feeMode string) error {
+	if resp.NetName != params.Name {
+		return fmt.Errorf("configured for %s instead of %s", resp.NetName,
+			params.Name)
+	}
+	genesisHash, err := chainhash.NewHash(resp.GenesisHash)
+	if err != nil {
+		return fmt.Errorf("invalid genesis hash: %v", err)
+	}
+	if *genesisHash != params.GenesisHash {
+		return fmt.Errorf("has genesis block %v instead of %v", genesisHash,
+			params.GenesisHash)
+	}
+	if resp.PoolFees > poolFees {
+		return fmt.Errorf("requires pool fees of %v%%, higher than the "+
+			"%v%% of dcrstakepool", resp.PoolFees, poolFees)
+	}
+	if resp.FeeMode != feeMode {
+		return fmt.Errorf("uses fee mode %q instead of %q", resp.FeeMode,
+			feeMode)
+	}
+	return nil
+}
+
+// CrossCheckChainParams calls GetChainParams RPC on all stakepoold instances
+// and checks that they are configured for the network of params, for pool
+// fees no higher than poolFees and for feeMode.  Returns an error if an RPC
+// call to any of the backend clients errors or if any instance does not match,
+// in which case write operations are refused until a later call finds them all
+// matching.
+func (s *stakepooldManager) CrossCheckChainParams(ctx context.Context, params *chaincfg.Params, poolFees float64, feeMode string) error {
+	var mismatch error
+	for _, conn := range s.grpcConnections {
+		client := pb.NewStakepooldServiceClient(conn)
+		resp, err := client.GetChainParams(ctx, &pb.GetChainParamsRequest{})
+		if err != nil {
+			return fmt.Errorf("GetChainParams RPC failed on stakepoold instance %s: %v", conn.Target(), err)
+		}
+		if err := checkChainParams(resp, params, poolFees, feeMode); err != nil {
+			mismatch = fmt.Errorf("stakepoold instance %s: %v",
+				conn.Target(), err)
+			break
+		}
+	}
+
+	s.chainParamsMismatchMtx.Lock()
+	s.chainParamsMismatch = mismatch
+	s.chainParamsMismatchMtx.Unlock()
+	return mismatch
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+)
+
+func TestInvalidateStakeInfo(t *testing.T) {
+	fetched := time.Now().Add(-time.Minute)
+	s := &stakepooldManager{
+		cachedStakeInfo:        &pb.GetStakeInfoResponse{BlockHeight: 100},
+		cachedStakeInfoFetched: fetched,
+		cachedStakeInfoTimer:   fetched.Add(cacheTimerStakeInfo),
+	}
+
+	// The stake info of the best block stays cached.
+	s.InvalidateStakeInfo(100)
+	if !s.cachedStakeInfoTimer.Equal(fetched.Add(cacheTimerStakeInfo)) {
+		t.Fatalf("cache invalidated without a new block")
+	}
+
+	// A new block expires the cache minStakeInfoRefresh after it was
+	// updated.
+	s.InvalidateStakeInfo(101)
+	if !s.cachedStakeInfoTimer.Equal(fetched.Add(minStakeInfoRefresh)) {
+		t.Fatalf("cache expires at %v, want %v", s.cachedStakeInfoTimer,
+			fetched.Add(minStakeInfoRefresh))
+	}
+
+	// Invalidating a recently updated cache does not expire it before
+	// minStakeInfoRefresh.
+	now := time.Now()
+	s.cachedStakeInfoFetched = now
+	s.cachedStakeInfoTimer = now.Add(cacheTimerStakeInfo)
+	s.InvalidateStakeInfo(102)
+	if s.cachedStakeInfoTimer.Before(now.Add(minStakeInfoRefresh)) {
+		t.Fatalf("recently updated cache expires at %v",
+			s.cachedStakeInfoTimer)
+	}
+}
+
+func TestCheckChainParams(t *testing.T) {
+	params := chaincfg.TestNet3Params()
+	mainnet := chaincfg.MainNetParams()
+	tests := []struct {
+		name    string
+		resp    *pb.GetChainParamsResponse
+		wantErr bool
+	}{{
+		name: "match",
+		resp: &pb.GetChainParamsResponse{NetName: params.Name,
+			GenesisHash: params.GenesisHash[:], PoolFees: 7.5, FeeMode: "commitment"},
+	}, {
+		name: "lower pool fees",
+		resp: &pb.GetChainParamsResponse{NetName: params.Name,
+			GenesisHash: params.GenesisHash[:], PoolFees: 5, FeeMode: "commitment"},
+	}, {
+		name: "higher pool fees",
+		resp: &pb.GetChainParamsResponse{NetName: params.Name,
+			GenesisHash: params.GenesisHash[:], PoolFees: 10},
+		wantErr: true,
+	}, {
+		name: "other fee mode",
+		resp: &pb.GetChainParamsResponse{NetName: params.Name,
+			GenesisHash: params.GenesisHash[:], PoolFees: 7.5, FeeMode: "deferred"},
+		wantErr: true,
+	}, {
+		name: "other network",
+		resp: &pb.GetChainParamsResponse{NetName: mainnet.Name,
+			GenesisHash: mainnet.GenesisHash[:], PoolFees: 7.5},
+		wantErr: true,
+	}, {
+		name: "other genesis block",
+		resp: &pb.GetChainParamsResponse{NetName: params.Name,
+			GenesisHash: mainnet.GenesisHash[:], PoolFees: 7.5},
+		wantErr: true,
+	}, {
+		name: "invalid genesis hash",
+		resp: &pb.GetChainParamsResponse{NetName: params.Name,
+			GenesisHash: []byte{1}, PoolFees: 7.5},
+		wantErr: true,
+	}}
+	for _, test := range tests {
+		err := checkChainParams(test.resp, params, 7.5, "commitment")
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+}
+
+func TestCompareSyncStatus(t *testing.T) {
+	set := func(keys ...string) map[string]struct{} {
+		m := make(map[string]struct{})
+		for _, k := range keys {
+			m[k] = struct{}{}
+		}
+		return m
+	}
+	errDown := errors.New("down")
+	statuses := []manager.SyncStatus{{Host: "a"}, {Host: "b"}, {Host: "c", Err: errDown}}
+	compareSyncStatus(statuses, []string{"addr1", "addr2", "addr3"},
+		[]map[string]struct{}{set("addr1", "addr2", "addr3", "other"), set("addr1"), nil},
+		[]map[string]struct{}{set("t1", "t2"), set("t2", "t3", "t4"), nil})
+
+	want := []manager.SyncStatus{
+		{Host: "a", Scripts: 4, Tickets: 2, MissingTickets: 2},
+		{Host: "b", Scripts: 1, MissingScripts: 2, Tickets: 3, MissingTickets: 1},
+		{Host: "c", Err: errDown},
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("want %+v, got %+v", want, statuses)
+	}
+	for i := range statuses {
+		if !statuses[i].Diverged() {
+			t.Errorf("%s not diverged", statuses[i].Host)
+		}
+	}
+
+	statuses = []manager.SyncStatus{{Host: "a"}}
+	compareSyncStatus(statuses, []string{"addr1"},
+		[]map[string]struct{}{set("addr1")}, []map[string]struct{}{set("t1")})
+	if statuses[0].Diverged() {
+		t.Fatalf("in sync instance diverged: %+v", statuses[0])
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	sent := time.Unix(1600000000, 0)
+	received := sent.Add(200 * time.Millisecond)
+	tests := []struct {
+		serverTime time.Time
+		want       time.Duration
+	}{
+		{sent.Add(100 * time.Millisecond), 0},
+		{sent.Add(5 * time.Second), 4900 * time.Millisecond},
+		{sent.Add(-time.Minute), -time.Minute - 100*time.Millisecond},
+	}
+	for _, test := range tests {
+		if got := clockSkew(sent, received, test.serverTime); got != test.want {
+			t.Errorf("server time %v: want skew %v, got %v",
+				test.serverTime, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/decred/slog"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// messageSize returns the encoded size of the gRPC message msg, or 0 if it is
+// not a protobuf message.
+func messageSize(msg interface{}) int {
+	if m, ok := msg.(proto.Message); ok {
+		return proto.Size(m)
+	}
+	return 0
+}
+
+// traceRPC is a gRPC client interceptor which logs the target, method,
+// duration and request and response sizes of every RPC to rpcLog.  The level
+// is checked on every call, so tracing costs nothing unless it was enabled.
+func traceRPC(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if rpcLog.Level() > slog.LevelDebug {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	duration := time.Since(start)
+	if err != nil {
+		rpcLog.Debugf("%s %s failed after %v (sent %d bytes): %v",
+			cc.Target(), method, duration, messageSize(req), err)
+		return err
+	}
+	rpcLog.Debugf("%s %s took %v (sent %d bytes, received %d bytes)",
+		cc.Target(), method, duration, messageSize(req), messageSize(reply))
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/slog"
+	"google.golang.org/grpc"
+)
+
+func TestTraceRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, lis.Addr().String(), grpc.WithInsecure(),
+		grpc.WithBlock(), grpc.WithUnaryInterceptor(traceRPC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var buf bytes.Buffer
+	logger := slog.NewBackend(&buf).Logger("SRPC")
+	UseRPCLogger(logger)
+	defer UseRPCLogger(slog.Disabled)
+
+	// Nothing is traced above the debug level.
+	logger.SetLevel(slog.LevelInfo)
+	client := pb.NewVersionServiceClient(conn)
+	if _, err := client.Version(ctx, &pb.VersionRequest{}); err == nil {
+		t.Fatal("unimplemented RPC succeeded")
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("traced at the info level: %s", buf.String())
+	}
+
+	logger.SetLevel(slog.LevelDebug)
+	if _, err := client.Version(ctx, &pb.VersionRequest{}); err == nil {
+		t.Fatal("unimplemented RPC succeeded")
+	}
+	trace := buf.String()
+	for _, want := range []string{lis.Addr().String(),
+		"/stakepoolrpc.VersionService/Version", "failed after"} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("trace %q does not contain %q", trace, want)
+		}
+	}
+}
+
+func TestMessageSize(t *testing.T) {
+	msg := &pb.GetAddedLowFeeTicketsRequest{}
+	if n := messageSize(msg); n != 0 {
+		t.Fatalf("empty message has size %d", n)
+	}
+	if n := messageSize("not a message"); n != 0 {
+		t.Fatalf("non-message has size %d", n)
+	}
+	resp := &pb.VersionResponse{VersionString: "1.2.3", Major: 1}
+	if n := messageSize(resp); n == 0 {
+		t.Fatal("non-empty message has size 0")
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+	"github.com/decred/dcrstakepool/stakepooldclient/manager"
+)
+
+const (
+	// writeRetryInitialDelay is how long after failing a write is first
+	// retried on a stakepoold instance.  The delay doubles after every
+	// failed retry up to writeRetryMaxDelay.
+	writeRetryInitialDelay = 15 * time.Second
+	writeRetryMaxDelay     = 10 * time.Minute
+
+	// writeRetryInterval is how often the queued writes are checked for
+	// retries which are due.
+	writeRetryInterval = 5 * time.Second
+
+	// writeRetryTimeout is how long a retried write may take.
+	writeRetryTimeout = time.Minute
+)
+
+// writeFunc applies a write to the stakepoold instance of client.
+type writeFunc func(ctx context.Context, client pb.StakepooldServiceClient) error
+
+// queuedWrite is a write which failed on a stakepoold instance and is retried
+// in the background.
+type queuedWrite struct {
+	op       string
+	host     string
+	key      string
+	apply    writeFunc
+	attempts int
+	next     time.Time
+}
+
+// writeQueue holds the writes to retry, keyed by host and write key.  A write
+// with the key of a queued write replaces it, so writes of the whole state,
+// such as the voting preferences of all users, are only retried with the
+// latest state.
+type writeQueue struct {
+	mtx    sync.Mutex
+	writes map[string]*queuedWrite // [host + " " + key]
+}
+
+// newWriteQueue returns an empty writeQueue.
+func newWriteQueue() *writeQueue {
+	return &writeQueue{writes: make(map[string]*queuedWrite)}
+}
+
+// add queues a write which failed on host now for retry after
+// writeRetryInitialDelay, replacing the queued write with the same key.
+func (q *writeQueue) add(op, host, key string, apply writeFunc, now time.Time) {
+	q.mtx.Lock()
+	q.writes[host+" "+key] = &queuedWrite{
+		op:    op,
+		host:  host,
+		key:   key,
+		apply: apply,
+		next:  now.Add(writeRetryInitialDelay),
+	}
+	q.mtx.Unlock()
+}
+
+// remove drops the queued write with key of host, which was superseded by a
+// write which succeeded.
+func (q *writeQueue) remove(host, key string) {
+	q.mtx.Lock()
+	delete(q.writes, host+" "+key)
+	q.mtx.Unlock()
+}
+
+// due returns the queued writes whose retry is due at now.
+func (q *writeQueue) due(now time.Time) []*queuedWrite {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	var writes []*queuedWrite
+	for _, w := range q.writes {
+		if !w.next.After(now) {
+			writes = append(writes, w)
+		}
+	}
+	return writes
+}
+
+// done records the result of the retry of w at now.  A write which succeeded
+// is dropped and a failed one is retried after a longer delay, unless w was
+// replaced by a newer write while being retried.
+func (q *writeQueue) done(w *queuedWrite, err error, now time.Time) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	id := w.host + " " + w.key
+	if q.writes[id] != w {
+		return
+	}
+	if err == nil {
+		delete(q.writes, id)
+		return
+	}
+	w.attempts++
+	delay := writeRetryInitialDelay << uint(w.attempts)
+	if delay > writeRetryMaxDelay || delay <= 0 {
+		delay = writeRetryMaxDelay
+	}
+	w.next = now.Add(delay)
+}
+
+// pending returns the number of writes queued for host.
+func (q *writeQueue) pending(host string) int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	var n int
+	for _, w := range q.writes {
+		if w.host == host {
+			n++
+		}
+	}
+	return n
+}
+
+// resume makes the writes queued for host due at now, e.g. once its wallet
+// finished rescanning, and returns their number.
+func (q *writeQueue) resume(host string, now time.Time) int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	var n int
+	for _, w := range q.writes {
+		if w.host == host {
+			w.next = now
+			n++
+		}
+	}
+	return n
+}
+
+// applyAll applies a write to all stakepoold instances at once, after the
+// caller checked that all of them are able to apply it with connected.
+// Unlike stopping at the first failure, which leaves the instances after it
+// without the write, every instance is written to.  The write is queued for
+// retry on the instances it failed on, and a *manager.PartialWriteError
+// describing them is returned.  A queued write with the same key is dropped
+// from the instances the write succeeded on, since it is superseded.  The
+// write is queued without being sent to the instances whose wallet is
+// rescanning, failing with manager.ErrWalletRescanning.
+func (s *stakepooldManager) applyAll(ctx context.Context, op, key string, apply writeFunc) error {
+	errs := make([]error, len(s.grpcConnections))
+	var wg sync.WaitGroup
+	for i, conn := range s.grpcConnections {
+		if s.rescans.rescanning(conn.Target()) {
+			errs[i] = manager.ErrWalletRescanning
+			continue
+		}
+		wg.Add(1)
+		go func(i int, client pb.StakepooldServiceClient) {
+			defer wg.Done()
+			errs[i] = apply(ctx, client)
+		}(i, pb.NewStakepooldServiceClient(conn))
+	}
+	wg.Wait()
+
+	now := time.Now()
+	perr := &manager.PartialWriteError{Op: op, Failed: make(map[string]error)}
+	for i, conn := range s.grpcConnections {
+		host := conn.Target()
+		if errs[i] != nil {
+			log.Errorf("%s RPC failed on stakepoold instance %s, queued for "+
+				"retry: %v", op, host, errs[i])
+			perr.Failed[host] = errs[i]
+			s.writes.add(op, host, key, apply, now)
+			continue
+		}
+		perr.Applied++
+		s.writes.remove(host, key)
+	}
+	if len(perr.Failed) > 0 {
+		return perr
+	}
+	return nil
+}
+
+// retryWrites retries the queued writes which are due until ctx is done.
+// Writes are not retried while CrossCheckChainParams found a mismatched
+// stakepoold instance, nor on the instances whose wallet is rescanning.
+func (s *stakepooldManager) retryWrites(ctx context.Context) {
+	ticker := time.NewTicker(writeRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.chainParamsMismatchMtx.Lock()
+		mismatch := s.chainParamsMismatch
+		s.chainParamsMismatchMtx.Unlock()
+		if mismatch != nil {
+			continue
+		}
+
+		for _, w := range s.writes.due(time.Now()) {
+			if s.rescans.rescanning(w.host) {
+				continue
+			}
+			err := s.retryWrite(ctx, w)
+			if err != nil {
+				log.Warnf("Retry %d of %s failed on stakepoold instance %s: %v",
+					w.attempts+1, w.op, w.host, err)
+			} else {
+				log.Infof("Retried %s successfully on stakepoold instance %s",
+					w.op, w.host)
+			}
+			s.writes.done(w, err, time.Now())
+		}
+	}
+}
+
+// retryWrite applies the queued write w to its stakepoold instance.
+func (s *stakepooldManager) retryWrite(ctx context.Context, w *queuedWrite) error {
+	for _, conn := range s.grpcConnections {
+		if conn.Target() != w.host {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, writeRetryTimeout)
+		defer cancel()
+		return w.apply(ctx, pb.NewStakepooldServiceClient(conn))
+	}
+	return fmt.Errorf("unknown stakepoold instance %s", w.host)
+}
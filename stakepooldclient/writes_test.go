@@ -0,0 +1,91 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stakepooldclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/decred/dcrstakepool/backend/stakepoold/rpc/stakepoolrpc"
+)
+
+func TestWriteQueue(t *testing.T) {
+	q := newWriteQueue()
+	now := time.Now()
+	var applied string
+	write := func(name string) writeFunc {
+		return func(context.Context, pb.StakepooldServiceClient) error {
+			applied = name
+			return nil
+		}
+	}
+
+	q.add("SetUserVotingPrefs", "a", "prefs", write("old"), now)
+	q.add("ImportNewScript", "a", "script", write("script"), now)
+	q.add("SetUserVotingPrefs", "b", "prefs", write("old"), now)
+	if q.pending("a") != 2 || q.pending("b") != 1 {
+		t.Fatalf("pending %d and %d, want 2 and 1", q.pending("a"),
+			q.pending("b"))
+	}
+	if due := q.due(now); len(due) != 0 {
+		t.Fatalf("%d writes due before the retry delay", len(due))
+	}
+
+	// A newer write with the same key replaces the queued one.
+	q.add("SetUserVotingPrefs", "a", "prefs", write("new"), now)
+	if q.pending("a") != 2 {
+		t.Fatalf("pending %d after replacing a write, want 2", q.pending("a"))
+	}
+
+	// A write which succeeded drops the queued one.
+	q.remove("b", "prefs")
+	if q.pending("b") != 0 {
+		t.Fatalf("pending %d after removing a write, want 0", q.pending("b"))
+	}
+
+	later := now.Add(writeRetryInitialDelay)
+	due := q.due(later)
+	if len(due) != 2 {
+		t.Fatalf("%d writes due, want 2", len(due))
+	}
+	for _, w := range due {
+		if w.key != "prefs" {
+			continue
+		}
+		w.apply(context.Background(), nil)
+		if applied != "new" {
+			t.Fatalf("retried %q write, want the new one", applied)
+		}
+		q.done(w, nil, later)
+	}
+	if q.pending("a") != 1 {
+		t.Fatalf("pending %d after a retry succeeded, want 1", q.pending("a"))
+	}
+
+	// Failed retries back off up to writeRetryMaxDelay.
+	w := q.due(later)[0]
+	q.done(w, errors.New("failed"), later)
+	if !w.next.Equal(later.Add(2 * writeRetryInitialDelay)) {
+		t.Fatalf("retry at %v, want %v", w.next,
+			later.Add(2*writeRetryInitialDelay))
+	}
+	for i := 0; i < 100; i++ {
+		q.done(w, errors.New("failed"), later)
+	}
+	if !w.next.Equal(later.Add(writeRetryMaxDelay)) {
+		t.Fatalf("retry at %v, want %v", w.next, later.Add(writeRetryMaxDelay))
+	}
+
+	// A retry of a write which was replaced meanwhile does not drop the
+	// newer write.
+	q.add("ImportNewScript", "a", "script", write("script"), later)
+	q.done(w, nil, later)
+	if q.pending("a") != 1 {
+		t.Fatalf("pending %d after a replaced write was retried, want 1",
+			q.pending("a"))
+	}
+}
@@ -0,0 +1,93 @@
+package system
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxAPIETags is the number of API resources whose ETags are tracked.  The
+// tracker starts over when it is full, which only resets the Last-Modified
+// times of the resources.
+const maxAPIETags = 10000
+
+// APICache describes how clients may cache the successful response of a GET
+// API request.  Key identifies the resource, including the user it is for, to
+// track when it last changed.  Private responses are specific to the user
+// making the request and must not be cached by shared caches.
+type APICache struct {
+	Key     string
+	Private bool
+	MaxAge  time.Duration
+}
+
+// apiETag is the ETag of an API resource and the time it was first served.
+type apiETag struct {
+	etag     string
+	modified time.Time
+}
+
+// apiETagTracker tracks the ETags of API resources to report when each
+// resource last changed as its Last-Modified time.  The zero value is ready to
+// use and it is safe for concurrent use.
+type apiETagTracker struct {
+	mtx   sync.Mutex
+	etags map[string]apiETag
+}
+
+// modified returns the time the resource key was first served with etag,
+// which is now if its ETag just changed.
+func (t *apiETagTracker) modified(key, etag string, now time.Time) time.Time {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if e, ok := t.etags[key]; ok && e.etag == etag {
+		return e.modified
+	}
+	if t.etags == nil || len(t.etags) >= maxAPIETags {
+		t.etags = make(map[string]apiETag)
+	}
+	// Last-Modified has a resolution of seconds.
+	now = now.Truncate(time.Second)
+	t.etags[key] = apiETag{etag: etag, modified: now}
+	return now
+}
+
+// writeCachedAPIResponse writes resp with the ETag, Last-Modified and
+// Cache-Control headers described by resp.Cache, answering conditional
+// requests whose If-None-Match or If-Modified-Since header matches the
+// response with 304 Not Modified.
+func (application *Application) writeCachedAPIResponse(resp *APIResponse,
+	w http.ResponseWriter, r *http.Request) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		log.Warnf("JSON encode error: %v", err)
+		WriteAPIResponse(resp, http.StatusOK, w)
+		return
+	}
+	// Match the output of WriteAPIResponse.
+	body = append(body, '\n')
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	modified := application.apiETags.modified(resp.Cache.Key, etag, time.Now())
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	h.Set("ETag", etag)
+	visibility := "public"
+	if resp.Cache.Private {
+		visibility = "private"
+		h.Add("Vary", "Authorization")
+	}
+	h.Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", visibility,
+		int64(resp.Cache.MaxAge/time.Second)))
+
+	// ServeContent sets Last-Modified and evaluates the conditional
+	// request headers against it and the ETag.
+	http.ServeContent(w, r, "", modified, bytes.NewReader(body))
+}
@@ -0,0 +1,55 @@
+package system
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zenazn/goji/web"
+	"google.golang.org/grpc/codes"
+)
+
+func TestAPIHandlerCache(t *testing.T) {
+	var application Application
+	data := "a"
+	h := application.APIHandler(func(web.C, *http.Request) *APIResponse {
+		resp := NewAPIResponse("success", codes.OK, "ok", data)
+		resp.Cache = &APICache{Key: "stats", MaxAge: time.Minute}
+		return resp
+	})
+	get := func(header, value string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/api/v3/stats", nil)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		h(web.C{}, w, r)
+		return w
+	}
+
+	w := get("", "")
+	etag, modified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	if w.Code != http.StatusOK || etag == "" || modified == "" ||
+		w.Header().Get("Cache-Control") != "public, max-age=60" ||
+		w.Body.String() != `{"status":"success","code":0,"message":"ok","data":"a"}`+"\n" {
+		t.Fatalf("unexpected response %d %v %q", w.Code, w.Header(), w.Body)
+	}
+
+	// Unchanged responses are not sent again.
+	if w = get("If-None-Match", etag); w.Code != http.StatusNotModified ||
+		w.Body.Len() != 0 {
+		t.Fatalf("If-None-Match: got status %d", w.Code)
+	}
+	if w = get("If-Modified-Since", modified); w.Code != http.StatusNotModified {
+		t.Fatalf("If-Modified-Since: got status %d", w.Code)
+	}
+
+	// Changed responses get a new ETag.
+	data = "b"
+	if w = get("If-None-Match", etag); w.Code != http.StatusOK ||
+		w.Header().Get("ETag") == etag {
+		t.Fatalf("changed response: got status %d, ETag %s", w.Code,
+			w.Header().Get("ETag"))
+	}
+}
@@ -21,6 +21,13 @@ func (controller *Controller) GetSession(c web.C) *sessions.Session {
 	return c.Env["Session"].(*sessions.Session)
 }
 
+// GetUser returns the signed in user, which ApplyAuth loaded for the request,
+// or nil when the request has no valid session.
+func (controller *Controller) GetUser(c web.C) *models.User {
+	user, _ := c.Env["User"].(*models.User)
+	return user
+}
+
 // GetTemplate returns the template stored in the header.
 func (controller *Controller) GetTemplate(c web.C) *template.Template {
 	return c.Env["Template"].(*template.Template)
@@ -31,10 +38,11 @@ func (controller *Controller) GetDbMap(c web.C) *gorp.DbMap {
 	return c.Env["DbMap"].(*gorp.DbMap)
 }
 
-// IsCaptchaDone returns the CaptchaDone value stored in the header.
-func (controller *Controller) IsCaptchaDone(c web.C) bool {
-	done, ok := c.Env["CaptchaDone"].(bool)
-	return done && ok
+// GetReadDbMap returns the DbMap stored in the header for heavy read-only
+// queries.  It is the read replica when one is configured and available and
+// the primary otherwise, so it must not be used for writes.
+func (controller *Controller) GetReadDbMap(c web.C) *gorp.DbMap {
+	return c.Env["ReadDbMap"].(*gorp.DbMap)
 }
 
 // Parse parses html templates and returns the template as a string.
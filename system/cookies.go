@@ -0,0 +1,119 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package system
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/csrf"
+)
+
+const (
+	// sessionCookieName and csrfCookieName are the names of the session and
+	// CSRF cookies, before the configured prefix.
+	sessionCookieName = "session"
+	csrfCookieName    = "_gorilla_csrf"
+
+	// hostCookiePrefix and secureCookiePrefix are the cookie name prefixes
+	// browsers only accept with certain attributes, as described by
+	// RFC 6265bis.
+	hostCookiePrefix   = "__Host-"
+	secureCookiePrefix = "__Secure-"
+)
+
+// CookieConfig holds the attributes of the session and CSRF cookies.
+type CookieConfig struct {
+	// Secure prevents the cookies from being sent in clear text.
+	Secure bool
+
+	// SameSite restricts sending the cookies with cross-site requests.
+	SameSite http.SameSite
+
+	// Domain and Path restrict the URLs the cookies are sent to.  When
+	// Domain is empty the cookies are only sent to the host which set them,
+	// rather than to its subdomains too.
+	Domain string
+	Path   string
+
+	// NamePrefix is prepended to the names of the cookies, e.g. to use the
+	// __Host- or __Secure- prefixes or to tell apart the cookies of several
+	// voting services on the same domain.
+	NamePrefix string
+}
+
+// ParseSameSite returns the SameSite attribute described by s, which is one
+// of "default", "lax", "strict" or "none".
+func ParseSameSite(s string) (http.SameSite, error) {
+	switch strings.ToLower(s) {
+	case "default":
+		return http.SameSiteDefaultMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, fmt.Errorf("unknown SameSite mode %q", s)
+}
+
+// Validate returns an error when browsers would reject the cookies described
+// by cc.
+func (cc *CookieConfig) Validate() error {
+	if !strings.HasPrefix(cc.Path, "/") {
+		return errors.New("cookie path must start with /")
+	}
+	if strings.ContainsAny(cc.NamePrefix, " \t,;\\\"=") {
+		return errors.New("cookie name prefix contains invalid characters")
+	}
+	if cc.SameSite == http.SameSiteNoneMode && !cc.Secure {
+		return errors.New("SameSite none requires secure cookies")
+	}
+	switch {
+	case strings.HasPrefix(cc.NamePrefix, hostCookiePrefix):
+		if !cc.Secure || cc.Domain != "" || cc.Path != "/" {
+			return errors.New("the " + hostCookiePrefix + " prefix requires " +
+				"secure cookies, no domain and path /")
+		}
+	case strings.HasPrefix(cc.NamePrefix, secureCookiePrefix):
+		if !cc.Secure {
+			return errors.New("the " + secureCookiePrefix + " prefix " +
+				"requires secure cookies")
+		}
+	}
+	return nil
+}
+
+// SessionCookieName returns the name of the session cookie.
+func (cc *CookieConfig) SessionCookieName() string {
+	return cc.NamePrefix + sessionCookieName
+}
+
+// CSRFOptions returns the options of the CSRF protection middleware which
+// give its cookie the configured attributes.
+func (cc *CookieConfig) CSRFOptions() []csrf.Option {
+	opts := []csrf.Option{
+		csrf.Secure(cc.Secure),
+		csrf.Path(cc.Path),
+		csrf.CookieName(cc.NamePrefix + csrfCookieName),
+	}
+	if cc.Domain != "" {
+		opts = append(opts, csrf.Domain(cc.Domain))
+	}
+	switch cc.SameSite {
+	case http.SameSiteDefaultMode:
+		opts = append(opts, csrf.SameSite(csrf.SameSiteDefaultMode))
+	case http.SameSiteLaxMode:
+		opts = append(opts, csrf.SameSite(csrf.SameSiteLaxMode))
+	case http.SameSiteStrictMode:
+		opts = append(opts, csrf.SameSite(csrf.SameSiteStrictMode))
+	case http.SameSiteNoneMode:
+		opts = append(opts, csrf.SameSite(csrf.SameSiteNoneMode))
+	}
+	return opts
+}
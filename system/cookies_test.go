@@ -0,0 +1,87 @@
+package system
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseSameSite(t *testing.T) {
+	tests := map[string]http.SameSite{
+		"default": http.SameSiteDefaultMode,
+		"lax":     http.SameSiteLaxMode,
+		"Strict":  http.SameSiteStrictMode,
+		"NONE":    http.SameSiteNoneMode,
+	}
+	for s, want := range tests {
+		got, err := ParseSameSite(s)
+		if err != nil {
+			t.Errorf("ParseSameSite(%q): %v", s, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseSameSite(%q): want %v, got %v", s, want, got)
+		}
+	}
+
+	if _, err := ParseSameSite("relaxed"); err == nil {
+		t.Error("ParseSameSite accepted an unknown mode")
+	}
+}
+
+func TestCookieConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cc      CookieConfig
+		wantErr bool
+	}{{
+		name: "defaults",
+		cc:   CookieConfig{SameSite: http.SameSiteLaxMode, Path: "/"},
+	}, {
+		name: "subdomains under a path",
+		cc:   CookieConfig{Domain: "example.com", Path: "/vsp", NamePrefix: "vsp1_"},
+	}, {
+		name:    "relative path",
+		cc:      CookieConfig{Path: "vsp"},
+		wantErr: true,
+	}, {
+		name:    "invalid prefix",
+		cc:      CookieConfig{Path: "/", NamePrefix: "vsp;"},
+		wantErr: true,
+	}, {
+		name:    "SameSite none without secure",
+		cc:      CookieConfig{SameSite: http.SameSiteNoneMode, Path: "/"},
+		wantErr: true,
+	}, {
+		name: "SameSite none",
+		cc:   CookieConfig{Secure: true, SameSite: http.SameSiteNoneMode, Path: "/"},
+	}, {
+		name: "host prefix",
+		cc:   CookieConfig{Secure: true, Path: "/", NamePrefix: "__Host-"},
+	}, {
+		name:    "host prefix with domain",
+		cc:      CookieConfig{Secure: true, Domain: "example.com", Path: "/", NamePrefix: "__Host-"},
+		wantErr: true,
+	}, {
+		name:    "host prefix with path",
+		cc:      CookieConfig{Secure: true, Path: "/vsp", NamePrefix: "__Host-"},
+		wantErr: true,
+	}, {
+		name:    "secure prefix without secure",
+		cc:      CookieConfig{Path: "/", NamePrefix: "__Secure-"},
+		wantErr: true,
+	}, {
+		name: "secure prefix",
+		cc:   CookieConfig{Secure: true, Domain: "example.com", Path: "/", NamePrefix: "__Secure-"},
+	}}
+	for _, test := range tests {
+		err := test.cc.Validate()
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: want error %v, got %v", test.name, test.wantErr, err)
+		}
+	}
+
+	cc := CookieConfig{NamePrefix: "__Host-"}
+	if name := cc.SessionCookieName(); name != "__Host-session" {
+		t.Errorf("SessionCookieName: got %q", name)
+	}
+}
@@ -13,8 +13,10 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/decred/dcrstakepool/models"
+	"github.com/decred/dcrstakepool/poolapi"
 	"github.com/go-gorp/gorp"
 	"github.com/gorilla/sessions"
 	"github.com/zenazn/goji/web"
@@ -25,11 +27,15 @@ import (
 // Application represents dcrstakepool's infrastructure, including html
 // templates and the mysql database.
 type Application struct {
-	APISecret     string
+	APIKeys       *models.APIKeyring
 	Template      *template.Template
 	TemplatesPath string
 	Store         *SQLStore
+	Cookies       CookieConfig
 	DbMap         *gorp.DbMap
+	ReadDbMap     *ReadDbMap
+
+	apiETags apiETagTracker
 }
 
 // GojiWebHandlerFunc is an adaptor that allows an http.HanderFunc where a
@@ -40,15 +46,16 @@ func GojiWebHandlerFunc(h http.HandlerFunc) web.HandlerFunc {
 	}
 }
 
-// Init initiates an Application with the passed variables.
+// Init initiates an Application with the passed variables.  The session cookie
+// is given the attributes of cookies.
 func Init(ctx context.Context, wg *sync.WaitGroup,
-	APISecret, baseURL, cookieSecret string, cookieSecure bool, DBHost,
-	DBName, DBPassword, DBPort, DBUser string) (*Application, error) {
+	apiKeys *models.APIKeyring, baseURL, cookieSecret string, cookies CookieConfig, DBHost,
+	DBName, DBPassword, DBPort, DBUser, DBReplicaDSN string) (*Application, error) {
 
 	var application Application
 	var err error
 	application.DbMap, err = models.GetDbMap(
-		APISecret,
+		apiKeys,
 		baseURL,
 		DBUser,
 		DBPassword,
@@ -59,18 +66,30 @@ func Init(ctx context.Context, wg *sync.WaitGroup,
 		return nil, err
 	}
 
+	var replica *gorp.DbMap
+	if DBReplicaDSN != "" {
+		replica, err = models.GetReplicaDbMap(DBReplicaDSN)
+		if err != nil {
+			return nil, err
+		}
+	}
+	application.ReadDbMap = NewReadDbMap(ctx, wg, application.DbMap, replica)
+
 	hash := sha256.New()
 	io.WriteString(hash, cookieSecret)
 	application.Store = NewSQLStore(ctx, wg, application.DbMap, hash.Sum(nil))
+	application.Cookies = cookies
 	application.Store.Options = &sessions.Options{
-		Path:     "/",
+		Path:     cookies.Path,
+		Domain:   cookies.Domain,
 		HttpOnly: true,
-		Secure:   cookieSecure,
+		Secure:   cookies.Secure,
+		SameSite: cookies.SameSite,
 		//six hours
 		MaxAge: 60 * 60 * 6,
 	}
 
-	application.APISecret = APISecret
+	application.APIKeys = apiKeys
 	return &application, nil
 }
 
@@ -78,6 +97,15 @@ var funcMap = template.FuncMap{
 	"times": func(a, b float64) float64 {
 		return a * b
 	},
+	"days": func(seconds int64) float64 {
+		return float64(seconds) / 86400
+	},
+	"unixTime": func(t int64) string {
+		if t == 0 {
+			return "-"
+		}
+		return time.Unix(t, 0).UTC().Format("2006-01-02 15:04:05 UTC")
+	},
 }
 
 // LoadTemplates parses and loads all html templates found in templatePath.
@@ -154,12 +182,17 @@ func saveSession(c web.C, w http.ResponseWriter, r *http.Request) error {
 
 // APIHandler executes an API processing function that provides an *APIResponse
 // required by WriteAPIResponse.  It returns an web.HandlerFunc so it can be
-// used with a goji router.
+// used with a goji router.  Responses to GET requests which set Cache are
+// written with caching headers and honor conditional requests.
 func (application *Application) APIHandler(apiFun func(web.C, *http.Request) *APIResponse) web.HandlerFunc {
 	return func(c web.C, w http.ResponseWriter, r *http.Request) {
 		apiResp := apiFun(c, r)
 
 		if apiResp != nil {
+			if apiResp.Cache != nil && r.Method == http.MethodGet {
+				application.writeCachedAPIResponse(apiResp, w, r)
+				return
+			}
 			WriteAPIResponse(apiResp, http.StatusOK, w)
 			return
 		}
@@ -184,22 +217,30 @@ func APIInvalidHandler(w http.ResponseWriter, _ *http.Request) {
 	resp := &APIResponse{Status: "error",
 		Code:    codes.InvalidArgument,
 		Message: "invalid API command or version",
+		Errors: []poolapi.Error{{
+			Code:    poolapi.ErrInvalidArgument,
+			Message: "invalid API command or version",
+		}},
 	}
 	WriteAPIResponse(resp, http.StatusNotFound, w)
 }
 
 // APIResponse is the response struct used by the server to marshal to a JSON
-// object. Data should be another struct with JSON tags.
+// object. Data should be another struct with JSON tags.  Errors holds the
+// machine-readable errors of failed requests.  Cache, if set, describes how
+// clients may cache the response.
 type APIResponse struct {
-	Status  string      `json:"status"`
-	Code    codes.Code  `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Status  string          `json:"status"`
+	Code    codes.Code      `json:"code"`
+	Message string          `json:"message"`
+	Data    interface{}     `json:"data,omitempty"`
+	Errors  []poolapi.Error `json:"errors,omitempty"`
+	Cache   *APICache       `json:"-"`
 }
 
 // NewAPIResponse is a constructor for APIResponse.
 func NewAPIResponse(status string, code codes.Code, message string, data interface{}) *APIResponse {
-	return &APIResponse{status, code, message, data}
+	return &APIResponse{Status: status, Code: code, Message: message, Data: data}
 }
 
 // ClientIP gets the client's real IP address using the X-Real-IP header, or
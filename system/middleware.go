@@ -1,13 +1,13 @@
 package system
 
 import (
-	"fmt"
+	"database/sql"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/decred/dcrstakepool/models"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/go-gorp/gorp"
 	"github.com/gorilla/sessions"
 	"github.com/zenazn/goji/web"
@@ -27,7 +27,7 @@ func (application *Application) ApplyTemplates(c *web.C, h http.Handler) http.Ha
 // ApplySessions makes sure controllers can have access to session
 func (application *Application) ApplySessions(c *web.C, h http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
-		session, err := application.Store.New(r, "session")
+		session, err := application.Store.New(r, application.Cookies.SessionCookieName())
 		if err != nil {
 			log.Warnf("session load err: %v ", err)
 		}
@@ -41,12 +41,14 @@ func (application *Application) ApplySessions(c *web.C, h http.Handler) http.Han
 func (application *Application) ApplyDbMap(c *web.C, h http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		c.Env["DbMap"] = application.DbMap
+		c.Env["ReadDbMap"] = application.ReadDbMap.DbMap()
 		h.ServeHTTP(w, r)
 	}
 	return http.HandlerFunc(fn)
 }
 
 // ApplyAPI verifies the header's API token and ensures it belongs to a user.
+// Requests made with a read-only token are marked with APIReadOnly.
 func (application *Application) ApplyAPI(c *web.C, h http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		if strings.HasPrefix(r.URL.Path, "/api") {
@@ -54,24 +56,23 @@ func (application *Application) ApplyAPI(c *web.C, h http.Handler) http.Handler
 			if strings.HasPrefix(authHeader, "Bearer ") {
 				apitoken := strings.TrimPrefix(authHeader, "Bearer ")
 
-				JWTtoken, err := jwt.Parse(apitoken, func(token *jwt.Token) (interface{}, error) {
-					// validate signing algorithm
-					if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-						return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-					}
-					return []byte(application.APISecret), nil
-				})
-
+				userID, readOnly, err := application.APIKeys.ParseToken(apitoken)
 				if err != nil {
 					log.Warnf("invalid token %v: %v", apitoken, err)
-				} else if claims, ok := JWTtoken.Claims.(jwt.MapClaims); ok && JWTtoken.Valid {
+				} else {
 					dbMap := c.Env["DbMap"].(*gorp.DbMap)
 
-					user, err := models.GetUserByID(dbMap, int64(claims["loggedInAs"].(float64)))
-					if err != nil {
-						log.Errorf("unable to map apitoken %v to user id %v", apitoken, claims["loggedInAs"])
-					} else {
+					user, err := models.GetUserByID(dbMap, userID)
+					switch {
+					case err != nil:
+						log.Errorf("unable to map apitoken %v to user id %v", apitoken, userID)
+					case readOnly && user.ReadOnlyAPIToken != apitoken:
+						// Only the latest read-only token of a user is
+						// accepted so that it can be revoked.
+						log.Warnf("revoked read-only apitoken %v for user id %v", apitoken, user.ID)
+					default:
 						c.Env["APIUserID"] = user.ID
+						c.Env["APIReadOnly"] = readOnly
 						log.Infof("mapped apitoken %v to user id %v", apitoken, user.ID)
 					}
 				}
@@ -82,34 +83,42 @@ func (application *Application) ApplyAPI(c *web.C, h http.Handler) http.Handler
 	return http.HandlerFunc(fn)
 }
 
-// ApplyCaptcha verfies whether or not the captcha has been solved.
-func (application *Application) ApplyCaptcha(c *web.C, h http.Handler) http.Handler {
-	fn := func(w http.ResponseWriter, r *http.Request) {
-		session := c.Env["Session"].(*sessions.Session)
-		if done, ok := session.Values["CaptchaDone"].(bool); ok {
-			c.Env["CaptchaDone"] = done
-		} else {
-			c.Env["CaptchaDone"] = false
-		}
-		h.ServeHTTP(w, r)
-	}
-	return http.HandlerFunc(fn)
+// sessionUserID returns the ID of the user signed in with session.  A
+// session whose user ID is not an int64, e.g. since it was corrupted, has no
+// signed in user.
+func sessionUserID(session *sessions.Session) (int64, bool) {
+	userID, ok := session.Values["UserId"].(int64)
+	return userID, ok
 }
 
-// ApplyAuth populates a user's info in the header if their userID is found in
-// the database.
+// ApplyAuth loads the user signed in with the session from the database once
+// per request, storing it as a *models.User under User for handlers to get
+// with GetUser, and populates their count of unread messages in the header.
+// The user is dropped from sessions with an invalid user ID or whose user no
+// longer exists, so that handlers treat them as signed out.
 func (application *Application) ApplyAuth(c *web.C, h http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		session := c.Env["Session"].(*sessions.Session)
-		if userID := session.Values["UserId"]; userID != nil {
+		userID, valid := sessionUserID(session)
+		if value, ok := session.Values["UserId"]; ok && !valid {
+			log.Warnf("Auth error: invalid user ID %v (%T) in session",
+				value, value)
+			delete(session.Values, "UserId")
+		} else if ok {
 			dbMap := c.Env["DbMap"].(*gorp.DbMap)
 
-			user, err := dbMap.Get(models.User{}, userID)
-			if err != nil {
+			user, err := models.GetUserByID(dbMap, userID)
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				log.Warnf("Auth error: user %d of session does not exist",
+					userID)
+				delete(session.Values, "UserId")
+			case err != nil:
 				log.Warnf("Auth error: %v", err)
-				c.Env["User"] = nil
-			} else {
+			default:
 				c.Env["User"] = user
+				c.Env["UnreadMessages"] = models.GetUnreadMessageCount(dbMap,
+					userID)
 			}
 		}
 		h.ServeHTTP(w, r)
@@ -117,6 +126,33 @@ func (application *Application) ApplyAuth(c *web.C, h http.Handler) http.Handler
 	return http.HandlerFunc(fn)
 }
 
+// LimitRequestBody returns a middleware which rejects requests whose body is
+// larger than maxBytes, or than the limit of their path in routeMaxBytes, so
+// that routes receiving bulk data can accept larger bodies than the other
+// form and API posts.  Requests declaring a larger Content-Length are answered
+// with 413 Request Entity Too Large right away, and reading more than the
+// limit of any other body fails.
+func LimitRequestBody(maxBytes int64, routeMaxBytes map[string]int64) func(c *web.C, h http.Handler) http.Handler {
+	return func(c *web.C, h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			limit := maxBytes
+			if l, ok := routeMaxBytes[r.URL.Path]; ok {
+				limit = l
+			}
+			if r.ContentLength > limit {
+				log.Warnf("rejecting %s %s with %d byte body", r.Method,
+					r.URL.Path, r.ContentLength)
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge),
+					http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			h.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
 // Logger is a middleware that logs the start and end of each request, along
 // with some useful data about what was requested, what the response status was,
 // and how long it took to return. This should be used after the RequestID
@@ -0,0 +1,142 @@
+package system
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/decred/dcrstakepool/models"
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/sessions"
+	"github.com/zenazn/goji/web"
+)
+
+func TestLimitRequestBody(t *testing.T) {
+	var read int
+	var readErr error
+	h := LimitRequestBody(10, map[string]int64{"/import": 20})(&web.C{}, http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			read, readErr = len(b), err
+		}))
+
+	// A body within the limit is passed through.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("a=b")))
+	if w.Code != http.StatusOK || read != 3 || readErr != nil {
+		t.Fatalf("got status %d, read %d, err %v", w.Code, read, readErr)
+	}
+
+	// A declared oversized body is rejected without calling the handler.
+	read, readErr = 0, nil
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("a", 11)))
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge || read != 0 {
+		t.Fatalf("got status %d, read %d", w.Code, read)
+	}
+
+	// Reading an oversized body of unknown length fails.
+	r = httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("a", 11)))
+	r.ContentLength = -1
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if readErr == nil {
+		t.Fatal("reading an oversized body succeeded")
+	}
+
+	// Routes with their own limit accept larger bodies up to it.
+	read, readErr = 0, nil
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/import",
+		strings.NewReader(strings.Repeat("a", 20))))
+	if w.Code != http.StatusOK || read != 20 || readErr != nil {
+		t.Fatalf("got status %d, read %d, err %v", w.Code, read, readErr)
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/import",
+		strings.NewReader(strings.Repeat("a", 21))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got status %d", w.Code)
+	}
+}
+
+func TestApplyAuth(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	dbMap := &gorp.DbMap{Db: db, Dialect: gorp.MySQLDialect{}}
+	application := &Application{}
+
+	tests := []struct {
+		name     string
+		userID   interface{}
+		expect   func()
+		wantUser int64
+	}{{
+		name:   "signed out",
+		expect: func() {},
+	}, {
+		name:   "invalid type",
+		userID: "1",
+		expect: func() {},
+	}, {
+		name:   "unknown user",
+		userID: int64(2),
+		expect: func() {
+			mock.ExpectQuery("SELECT \\* FROM Users WHERE UserId = \\?").
+				WithArgs(int64(2)).WillReturnError(sql.ErrNoRows)
+		},
+	}, {
+		name:   "valid user",
+		userID: int64(3),
+		expect: func() {
+			mock.ExpectQuery("SELECT \\* FROM Users WHERE UserId = \\?").
+				WithArgs(int64(3)).
+				WillReturnRows(sqlmock.NewRows([]string{"UserId"}).AddRow(3))
+			mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM Message").
+				WithArgs(int64(3)).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		},
+		wantUser: 3,
+	}}
+	for _, test := range tests {
+		session := sessions.NewSession(nil, "session")
+		if test.userID != nil {
+			session.Values["UserId"] = test.userID
+		}
+		c := &web.C{Env: map[interface{}]interface{}{
+			"Session": session,
+			"DbMap":   dbMap,
+		}}
+		test.expect()
+		var user *models.User
+		h := application.ApplyAuth(c, http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				user, _ = c.Env["User"].(*models.User)
+			}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		switch {
+		case test.wantUser == 0 && user != nil:
+			t.Errorf("%s: got user %d, want none", test.name, user.ID)
+		case test.wantUser == 0 && session.Values["UserId"] != nil:
+			t.Errorf("%s: user ID %v kept in session", test.name,
+				session.Values["UserId"])
+		case test.wantUser != 0 && (user == nil || user.ID != test.wantUser):
+			t.Errorf("%s: got user %v, want %d", test.name, user,
+				test.wantUser)
+		case test.wantUser != 0 && c.Env["UnreadMessages"] != int64(2):
+			t.Errorf("%s: got %v unread messages, want 2", test.name,
+				c.Env["UnreadMessages"])
+		}
+	}
+}
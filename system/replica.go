@@ -0,0 +1,93 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package system
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-gorp/gorp"
+)
+
+const (
+	// replicaCheckInterval is how often the availability of the read
+	// replica is checked.
+	replicaCheckInterval = time.Second * 15
+
+	// replicaPingTimeout is how long to wait for the read replica to answer
+	// a ping before it is considered unavailable.
+	replicaPingTimeout = time.Second * 5
+)
+
+// ReadDbMap selects the DbMap used for heavy read-only queries such as stats,
+// user lists and ticket history.  Queries go to the read replica while it is
+// reachable and fall back to the primary database otherwise.
+type ReadDbMap struct {
+	primary   *gorp.DbMap
+	replica   *gorp.DbMap
+	available int32 // atomic; -1 until the first check
+}
+
+// NewReadDbMap returns a ReadDbMap for primary and replica.  When replica is
+// nil all queries use primary.  Otherwise the replica's availability is checked
+// immediately and then periodically until ctx is canceled.
+func NewReadDbMap(ctx context.Context, wg *sync.WaitGroup, primary, replica *gorp.DbMap) *ReadDbMap {
+	r := &ReadDbMap{
+		primary:   primary,
+		replica:   replica,
+		available: -1,
+	}
+	if replica == nil {
+		return r
+	}
+
+	r.check(ctx)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				r.replica.Db.Close()
+				return
+			case <-time.After(replicaCheckInterval):
+				r.check(ctx)
+			}
+		}
+	}()
+	return r
+}
+
+// check pings the replica and records whether it is available, logging when
+// the availability changes.
+func (r *ReadDbMap) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+	defer cancel()
+
+	err := r.replica.Db.PingContext(ctx)
+	var available int32
+	if err == nil {
+		available = 1
+	}
+	if atomic.SwapInt32(&r.available, available) == available {
+		return
+	}
+	if err != nil {
+		log.Warnf("Read replica unavailable, using primary database: %v", err)
+		return
+	}
+	log.Infof("Read replica available")
+}
+
+// DbMap returns the replica DbMap if it is available and the primary DbMap
+// otherwise.
+func (r *ReadDbMap) DbMap() *gorp.DbMap {
+	if r.replica != nil && atomic.LoadInt32(&r.available) == 1 {
+		return r.replica
+	}
+	return r.primary
+}
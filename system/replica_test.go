@@ -0,0 +1,59 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-gorp/gorp"
+)
+
+func TestReadDbMap(t *testing.T) {
+	primary := new(gorp.DbMap)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// Without a replica the primary is always used.
+	r := NewReadDbMap(ctx, &wg, primary, nil)
+	if r.DbMap() != primary {
+		t.Fatal("primary not used without a replica")
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replica := &gorp.DbMap{Db: db}
+
+	// An unreachable replica falls back to the primary.
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	r = NewReadDbMap(ctx, &wg, primary, replica)
+	if r.DbMap() != primary {
+		t.Fatal("primary not used while the replica is unavailable")
+	}
+
+	// The replica is used once it becomes reachable.
+	mock.ExpectPing()
+	r.check(ctx)
+	if r.DbMap() != replica {
+		t.Fatal("replica not used while available")
+	}
+
+	// And the primary again when it stops responding.
+	mock.ExpectPing().WillReturnError(errors.New("connection lost"))
+	r.check(ctx)
+	if r.DbMap() != primary {
+		t.Fatal("primary not used after the replica became unavailable")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
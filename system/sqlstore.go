@@ -134,7 +134,7 @@ func (s *SQLStore) save(session *sessions.Session) error {
 		// no rows found so new
 		isNew = true
 	}
-	if userID, ok := session.Values["UserId"].(int64); ok {
+	if userID, ok := sessionUserID(session); ok {
 		dbSession.UserID = userID
 	} else {
 		// all sessions with no user specified are UserID -1
@@ -0,0 +1,67 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// serverTLSConfig returns the TLS configuration for the web server, or nil
+// when TLS is not enabled.  The returned handler serves the redirect listener
+// and, when certificates are obtained using ACME, answers HTTP challenges
+// before redirecting all other requests to https.
+func serverTLSConfig(cfg *config) (*tls.Config, http.Handler, error) {
+	_, httpsPort, err := net.SplitHostPort(cfg.Listen)
+	if err != nil {
+		httpsPort = ""
+	}
+	redirect := httpsRedirect(httpsPort)
+
+	switch {
+	case len(cfg.AutoCertHosts) > 0:
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutoCertHosts...),
+			Cache:      autocert.DirCache(cfg.AutoCertCacheDir),
+			Email:      cfg.AutoCertEmail,
+		}
+		tlsCfg := m.TLSConfig()
+		tlsCfg.MinVersion = tls.VersionTLS12
+		return tlsCfg, m.HTTPHandler(redirect), nil
+
+	case cfg.TLSCert != "":
+		keyPair, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsCfg := &tls.Config{
+			Certificates: []tls.Certificate{keyPair},
+			MinVersion:   tls.VersionTLS12,
+		}
+		return tlsCfg, redirect, nil
+	}
+
+	return nil, nil, nil
+}
+
+// httpsRedirect returns a handler which permanently redirects every request
+// to the same host and path using https on the passed port.
+func httpsRedirect(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/decred/dcrstakepool/internal/configcheck"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// validateProbeTimeout is how long validateprobe waits for each connection.
+const validateProbeTimeout = 5 * time.Second
+
+// validateConfigRequested returns whether the validateconfig option was passed
+// on the command line.  It is checked apart from loadConfig so that a config
+// which fails to load is reported as well.
+func validateConfigRequested() bool {
+	var opts struct {
+		ValidateConfig bool `long:"validateconfig"`
+	}
+	parser := flags.NewParser(&opts, flags.IgnoreUnknown)
+	parser.Parse()
+	return opts.ValidateConfig
+}
+
+// validateConfig returns the report of the checks of the config loaded by
+// loadConfig, which failed to load when loadErr is not nil.
+func validateConfig(ctx context.Context, cfg *config, loadErr error) *configcheck.Report {
+	report := configcheck.NewReport()
+	report.Add("load config", loadErr)
+	if loadErr != nil {
+		return report
+	}
+
+	report.Add("templatepath", configcheck.Dir(cfg.TemplatePath))
+	report.Add("publicpath", configcheck.Dir(cfg.PublicPath))
+	report.Add("resolve dbhost", configcheck.ResolveHost(ctx, cfg.DBHost))
+	if cfg.SMTPHost != "" {
+		report.Add("resolve smtphost", configcheck.ResolveHost(ctx, cfg.SMTPHost))
+	}
+	for i, host := range cfg.StakepooldHosts {
+		report.Add("resolve stakepooldhost "+host,
+			configcheck.ResolveHost(ctx, host))
+		report.Add("stakepooldcert "+cfg.StakepooldCerts[i],
+			configcheck.CertFile(cfg.StakepooldCerts[i]))
+	}
+	if cfg.TLSCert != "" {
+		report.Add("tlscert and tlskey",
+			configcheck.KeyPair(cfg.TLSCert, cfg.TLSKey))
+	}
+
+	if !cfg.ValidateProbe {
+		return report
+	}
+	dsn := fmt.Sprintf("%s:%s@(%s:%s)/%s?charset=utf8mb4", cfg.DBUser,
+		cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	report.Add("connect to database",
+		configcheck.PingMySQL(ctx, dsn, validateProbeTimeout))
+	if cfg.DBReplicaDSN != "" {
+		report.Add("connect to database replica",
+			configcheck.PingMySQL(ctx, cfg.DBReplicaDSN, validateProbeTimeout))
+	}
+	if cfg.SMTPHost != "" {
+		report.Add("connect to smtphost",
+			configcheck.Dial(ctx, cfg.SMTPHost, validateProbeTimeout))
+	}
+	for _, host := range cfg.StakepooldHosts {
+		report.Add("connect to stakepooldhost "+host,
+			configcheck.Dial(ctx, host, validateProbeTimeout))
+	}
+	return report
+}
+
+// runValidateConfig prints the report of validateConfig to stdout and returns
+// an error when the config is not valid.
+func runValidateConfig(ctx context.Context, cfg *config, loadErr error) error {
+	report := validateConfig(ctx, cfg, loadErr)
+	if err := report.Write(os.Stdout); err != nil {
+		return err
+	}
+	if !report.Valid {
+		return errors.New("config is not valid")
+	}
+	return nil
+}